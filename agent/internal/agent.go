@@ -9,10 +9,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/pprof"
+	"net/url"
 	"os"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/gorilla/websocket"
@@ -109,6 +111,10 @@ func (a *agent) Receive(ctx *actor.Context) error {
 	case actor.ChildFailed:
 		switch msg.Child {
 		case a.socket:
+			if closeErr, ok := errors.Cause(msg.Error).(*websocket.CloseError); ok &&
+				closeErr.Code == proto.AgentVersionRejectedCode {
+				logVersionRejection(ctx, closeErr.Text)
+			}
 			ctx.Log().Warn("master socket disconnected, shutting down agent...")
 		case a.cm:
 			ctx.Log().Warn("container manager failed, shutting down agent...")
@@ -319,6 +325,22 @@ func (a *agent) setup(ctx *actor.Context) error {
 	return nil
 }
 
+// logVersionRejection tells the operator why the master rejected the version of the agent and
+// what to upgrade.
+func logVersionRejection(ctx *actor.Context, reason string) {
+	var rejection proto.AgentVersionRejection
+	if err := json.Unmarshal([]byte(reason), &rejection); err != nil {
+		ctx.Log().Errorf("master rejected the version of the agent: %s", reason)
+		return
+	}
+	supported := fmt.Sprintf("at least %s", rejection.MinVersion)
+	if rejection.MaxVersion != "" {
+		supported = fmt.Sprintf("between %s and %s", rejection.MinVersion, rejection.MaxVersion)
+	}
+	ctx.Log().Errorf("master rejected agent version %s, since it supports agents %s; "+
+		"please upgrade the %s", rejection.AgentVersion, supported, rejection.Upgrade)
+}
+
 func (a *agent) connectToMaster(ctx *actor.Context) error {
 	tlsConfig, err := a.tlsConfig()
 	if err != nil {
@@ -330,13 +352,15 @@ func (a *agent) connectToMaster(ctx *actor.Context) error {
 		masterProto = "wss"
 	}
 	dialer := websocket.Dialer{
-		Proxy:            websocket.DefaultDialer.Proxy,
-		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
-		TLSClientConfig:  tlsConfig,
+		Proxy:             websocket.DefaultDialer.Proxy,
+		HandshakeTimeout:  websocket.DefaultDialer.HandshakeTimeout,
+		TLSClientConfig:   tlsConfig,
+		EnableCompression: true,
 	}
 
-	masterAddr := fmt.Sprintf("%s://%s:%d/agents?id=%s&resource_pool=%s",
-		masterProto, a.MasterHost, a.MasterPort, a.AgentID, a.ResourcePool)
+	masterAddr := fmt.Sprintf("%s://%s:%d/agents?id=%s&resource_pool=%s&version=%s",
+		masterProto, a.MasterHost, a.MasterPort, a.AgentID, a.ResourcePool,
+		url.QueryEscape(a.Version))
 	ctx.Log().Infof("connecting to master at: %s", masterAddr)
 	conn, resp, err := dialer.Dial(masterAddr, nil)
 	if err != nil {
@@ -346,10 +370,12 @@ func (a *agent) connectToMaster(ctx *actor.Context) error {
 	}
 	ctx.Log().Infof("successfully connected to master")
 
-	a.socket, _ = ctx.ActorOf("websocket", api.WrapSocket(conn, proto.AgentMessage{}, true))
+	a.socket, _ = ctx.ActorOf("websocket", api.WrapSocket(
+		conn, proto.AgentMessage{}, api.SocketOptions{Class: "master", UsePing: true}))
 
 	started := proto.MasterMessage{AgentStarted: &proto.AgentStarted{
-		Version: a.Version, Devices: a.Devices, ResourcePool: a.ResourcePool, Label: a.Label}}
+		Version: a.Version, Devices: a.Devices, ResourcePool: a.ResourcePool, Label: a.Label,
+		Time: time.Now()}}
 	ctx.Ask(a.socket, api.WriteMessage{Message: started})
 	return nil
 }
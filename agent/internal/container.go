@@ -1,13 +1,8 @@
 package internal
 
 import (
-	"bytes"
-	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 	"syscall"
-	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
@@ -17,11 +12,9 @@ import (
 	"github.com/determined-ai/determined/master/pkg/actor"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
-	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 const (
-	agentIDEnvVar     = "DET_AGENT_ID"
 	containerIDEnvVar = "DET_CONTAINER_ID"
 	trialIDEnvVar     = "DET_TRIAL_ID"
 )
@@ -30,10 +23,9 @@ type containerActor struct {
 	cproto.Container
 	spec          *cproto.Spec
 	client        *client.Client
+	harness       *harnessFetcher
 	docker        *actor.Ref
 	containerInfo *types.ContainerJSON
-
-	baseTrialLog model.TrialLog
 }
 
 type (
@@ -41,43 +33,23 @@ type (
 	containerReady      struct{}
 )
 
-func newContainerActor(msg aproto.StartContainer, client *client.Client) actor.Actor {
-	return &containerActor{Container: msg.Container, spec: &msg.Spec, client: client}
-}
-
-// getExtraFluentValues computes the container-specific extra fields to be injected into each Fluent
-// log entry. We configure Docker to send these fields itself, but we need to compute and add them
-// ourselves for agent-inserted logs.
-func getBaseTrialLog(spec *cproto.Spec) model.TrialLog {
-	level := "INFO"
-	stdtype := "stdout"
-	log := model.TrialLog{
-		Level:   &level,
-		StdType: &stdtype,
-	}
-	for _, env := range spec.RunSpec.ContainerConfig.Env {
-		split := strings.SplitN(env, "=", 2)
-		value := split[1]
-		switch split[0] {
-		case agentIDEnvVar:
-			log.AgentID = &value
-		case containerIDEnvVar:
-			log.ContainerID = &value
-		case trialIDEnvVar:
-			log.TrialID, _ = strconv.Atoi(value)
-		}
+func newContainerActor(
+	msg aproto.StartContainer, client *client.Client, harness *harnessFetcher,
+) actor.Actor {
+	return &containerActor{
+		Container: msg.Container, spec: &msg.Spec, client: client, harness: harness,
 	}
-	return log
 }
 
 func (c *containerActor) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
-		c.docker, _ = ctx.ActorOf("docker", &dockerActor{Client: c.client, spec: c.spec})
+		c.docker, _ = ctx.ActorOf("docker", &dockerActor{
+			Client: c.client, spec: c.spec, harness: c.harness,
+		})
 		c.transition(ctx, cproto.Pulling)
 		pull := pullImage{PullSpec: c.spec.PullSpec, Name: c.spec.RunSpec.ContainerConfig.Image}
 		ctx.Tell(c.docker, pull)
-		c.baseTrialLog = getBaseTrialLog(c.spec)
 
 	case getContainerSummary:
 		ctx.Respond(c.Container)
@@ -107,7 +79,11 @@ func (c *containerActor) Receive(ctx *actor.Context) error {
 		c.containerStarted(ctx, aproto.ContainerStarted{ContainerInfo: *c.containerInfo})
 
 	case containerTerminated:
-		c.containerStopped(ctx, aproto.ContainerExited(aproto.ExitCode(msg.ExitCode)))
+		stopped := aproto.ContainerExited(aproto.ExitCode(msg.ExitCode))
+		if stopped.Failure != nil {
+			stopped.Failure.OOMKilled = msg.OOMKilled
+		}
+		c.containerStopped(ctx, stopped)
 		ctx.Self().Stop()
 
 	case aproto.SignalContainer:
@@ -133,13 +109,11 @@ func (c *containerActor) Receive(ctx *actor.Context) error {
 		}
 
 	case aproto.ContainerLog:
+		// Logs of the output of the container go through Fluent when it is used, but logs of the
+		// lifecycle of the container always go to the master, which keeps them with the task.
 		msg.Container = c.Container
 		ctx.Log().Debug(msg)
-		if c.spec.RunSpec.UseFluentLogging {
-			ctx.Tell(ctx.Self().Parent(), c.makeTrialLog(msg))
-		} else {
-			ctx.Tell(ctx.Self().Parent(), msg)
-		}
+		ctx.Tell(ctx.Self().Parent(), msg)
 	case actor.ChildStopped:
 
 	case actor.ChildFailed:
@@ -147,7 +121,14 @@ func (c *containerActor) Receive(ctx *actor.Context) error {
 		return msg.Error
 
 	case dockerErr:
-		c.containerStopped(ctx, aproto.ContainerError(aproto.ContainerFailed, msg.Error))
+		failureType := aproto.ContainerFailed
+		switch {
+		case c.State == cproto.Pulling && isPullAuthError(msg.Error):
+			failureType = aproto.ImagePullAuthFailed
+		case c.State == cproto.Pulling:
+			failureType = aproto.ImagePullFailed
+		}
+		c.containerStopped(ctx, aproto.ContainerError(failureType, msg.Error))
 		return msg.Error
 
 	case echo.Context:
@@ -166,43 +147,6 @@ func (c *containerActor) Receive(ctx *actor.Context) error {
 	return nil
 }
 
-func (c *containerActor) makeTrialLog(log aproto.ContainerLog) model.TrialLog {
-	l := c.baseTrialLog
-	timestamp := time.Now()
-	l.Timestamp = &timestamp
-
-	var source string
-	var msg string
-	switch {
-	case log.AuxMessage != nil:
-		source = "agent"
-		msg = *log.AuxMessage
-	case log.PullMessage != nil:
-		source = "pull"
-		buf := new(bytes.Buffer)
-		if err := log.PullMessage.Display(buf, false); err != nil {
-			msg = err.Error()
-		} else {
-			msg = buf.String()
-			// Docker disables printing the progress bar in non-terminal mode.
-			if msg == "" && log.PullMessage.Progress != nil {
-				msg = log.PullMessage.Progress.String()
-			}
-			msg = strings.TrimSpace(msg)
-		}
-	case log.RunMessage != nil:
-		panic(fmt.Sprintf("unexpected run message from container on Fluent logging: %v", log.RunMessage))
-	default:
-		panic("unknown log message received")
-	}
-	msg += "\n"
-
-	l.Log = &msg
-	l.Source = &source
-
-	return l
-}
-
 func (c *containerActor) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	switch apiCtx.Request().Method {
 	case echo.GET:
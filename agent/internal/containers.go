@@ -38,6 +38,7 @@ type containerManager struct {
 
 	fluentPort int
 	docker     *client.Client
+	harness    *harnessFetcher
 }
 
 func newContainerManager(a *agent, fluentPort int) (*containerManager, error) {
@@ -46,6 +47,8 @@ func newContainerManager(a *agent, fluentPort int) (*containerManager, error) {
 		Options:    a.Options,
 		Devices:    a.Devices,
 		fluentPort: fluentPort,
+		harness: newHarnessFetcher(a.masterClient,
+			fmt.Sprintf("%s://%s:%d", a.masterProto, a.MasterHost, a.MasterPort)),
 	}, nil
 }
 
@@ -99,7 +102,7 @@ func (c *containerManager) Receive(ctx *actor.Context) error {
 
 	case proto.StartContainer:
 		msg.Spec = c.overwriteSpec(msg.Container, msg.Spec)
-		if ref, ok := ctx.ActorOf(msg.Container.ID, newContainerActor(msg, c.docker)); !ok {
+		if ref, ok := ctx.ActorOf(msg.Container.ID, newContainerActor(msg, c.docker, c.harness)); !ok {
 			ctx.Log().Warnf("container already created: %s", msg.Container.ID)
 			if ctx.ExpectingResponse() {
 				ctx.Respond(errors.Errorf("container already created: %s", msg.Container.ID))
@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/stdcopy"
@@ -29,6 +31,7 @@ type dockerActor struct {
 	*client.Client
 	credentialStores map[string]*credentialStore
 	spec             *container.Spec
+	harness          *harnessFetcher
 }
 
 type (
@@ -47,6 +50,8 @@ type (
 	}
 	containerTerminated struct {
 		ExitCode int64
+		// OOMKilled is whether the kernel killed the container because it ran out of memory.
+		OOMKilled bool
 	}
 	dockerErr struct{ Error error }
 )
@@ -166,6 +171,18 @@ func (d *dockerActor) pullImage(ctx *actor.Context, msg pullImage) {
 }
 
 func (d *dockerActor) runContainer(ctx *actor.Context, msg container.RunSpec) {
+	// Fetch the harness wheels first, so that tasks fail before a container is created if there
+	// are none for the platform of the agent.
+	archives := msg.Archives
+	if msg.Harness != nil {
+		harness, err := d.harness.archive(*msg.Harness)
+		if err != nil {
+			sendErr(ctx, err)
+			return
+		}
+		archives = append(archives, container.RunArchive{Path: "/", Archive: harness})
+	}
+
 	response, err := d.ContainerCreate(
 		context.Background(), &msg.ContainerConfig, &msg.HostConfig, &msg.NetworkingConfig, "")
 	if err != nil {
@@ -177,7 +194,7 @@ func (d *dockerActor) runContainer(ctx *actor.Context, msg container.RunSpec) {
 		d.sendAuxLog(ctx, fmt.Sprintf("warning when creating container: %s", w))
 	}
 
-	for _, copyArx := range msg.Archives {
+	for _, copyArx := range archives {
 		d.sendAuxLog(ctx, fmt.Sprintf("copying files to container: %s", copyArx.Path))
 		files, aerr := archive.ToIOReader(copyArx.Archive)
 		if aerr != nil {
@@ -199,6 +216,13 @@ func (d *dockerActor) runContainer(ctx *actor.Context, msg container.RunSpec) {
 	exit, eerr := d.ContainerWait(
 		context.Background(), containerID, dcontainer.WaitConditionNextExit)
 
+	// Containers are removed once they exit, so whether the kernel killed one for running out of
+	// memory is only known from the event that Docker sends when it happens.
+	oomCtx, stopOOMEvents := context.WithCancel(context.Background())
+	defer stopOOMEvents()
+	oomEvents, _ := d.Events(oomCtx, types.EventsOptions{Filters: filters.NewArgs(
+		filters.Arg("container", containerID), filters.Arg("event", "oom"))})
+
 	if err = d.ContainerStart(context.Background(), containerID,
 		types.ContainerStartOptions{}); err != nil {
 		sendErr(ctx, errors.Wrap(err, "error starting container"))
@@ -252,7 +276,13 @@ func (d *dockerActor) runContainer(ctx *actor.Context, msg container.RunSpec) {
 	case err = <-eerr:
 		sendErr(ctx, errors.Wrap(err, "error while waiting for container to exit"))
 	case exit := <-exit:
-		ctx.Tell(ctx.Sender(), containerTerminated{ExitCode: exit.StatusCode})
+		terminated := containerTerminated{ExitCode: exit.StatusCode}
+		select {
+		case <-oomEvents:
+			terminated.OOMKilled = true
+		default:
+		}
+		ctx.Tell(ctx.Sender(), terminated)
 	}
 }
 
@@ -264,6 +294,27 @@ func (d *dockerActor) signalContainer(ctx *actor.Context, msg signalContainer) {
 	}
 }
 
+// pullAuthErrors are parts of the errors of registries that reject the credentials of a pull,
+// which Docker reports as other errors, e.g., internal server errors.
+var pullAuthErrors = []string{
+	"unauthorized", "authentication required", "no basic auth credentials", "access denied",
+}
+
+// isPullAuthError returns whether pulling an image failed because the registry rejected the
+// credentials, or the lack of them.
+func isPullAuthError(err error) bool {
+	if client.IsErrUnauthorized(err) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, authErr := range pullAuthErrors {
+		if strings.Contains(msg, authErr) {
+			return true
+		}
+	}
+	return false
+}
+
 func sendErr(ctx *actor.Context, err error) {
 	ctx.Tell(ctx.Sender(), dockerErr{Error: err})
 }
@@ -286,6 +337,10 @@ func (d *dockerActor) sendPullLogs(ctx *actor.Context, r io.Reader) error {
 			Timestamp:   time.Now(),
 			PullMessage: &log,
 		})
+		// Docker reports some failures, e.g., of authentication, in the stream of the pull.
+		if log.Error != nil {
+			return log.Error
+		}
 	}
 	return scanner.Err()
 }
@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+func TestIsPullAuthError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		auth bool
+	}{
+		{errdefs.Unauthorized(errors.New("denied")), true},
+		{errors.New("Get https://registry/v2/: unauthorized: authentication required"), true},
+		{errors.New("pull access denied for private/image, repository does not exist"), true},
+		{errors.New("no basic auth credentials"), true},
+		{errors.New("manifest for image:latest not found"), false},
+		{errors.New("net/http: TLS handshake timeout"), false},
+	} {
+		if auth := isPullAuthError(errors.Wrap(tc.err, "error pulling image")); auth != tc.auth {
+			t.Errorf("isPullAuthError(%q) = %v, expected %v", tc.err, auth, tc.auth)
+		}
+	}
+}
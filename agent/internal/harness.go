@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/archive"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+// harnessFetcher fetches the harness wheels built for the platform of the agent from the master.
+// It keeps the wheels that it fetched by checksum, so that each wheel is fetched once.
+type harnessFetcher struct {
+	client    *http.Client
+	masterURL string
+
+	mu     sync.Mutex
+	wheels map[string][]byte
+}
+
+func newHarnessFetcher(client *http.Client, masterURL string) *harnessFetcher {
+	return &harnessFetcher{client: client, masterURL: masterURL, wheels: map[string][]byte{}}
+}
+
+// harnessPlatform returns the platform that the agent needs harness wheels for.
+func harnessPlatform(manifest cproto.HarnessManifest) cproto.HarnessPlatform {
+	return cproto.HarnessPlatform{OS: runtime.GOOS, Arch: runtime.GOARCH, Python: manifest.Python}
+}
+
+// archive returns the harness wheels for the platform of the agent as an archive to copy into a
+// container.
+func (f *harnessFetcher) archive(files cproto.HarnessFiles) (archive.Archive, error) {
+	key, wheels, err := files.Manifest.Resolve(harnessPlatform(files.Manifest))
+	if err != nil {
+		return nil, err
+	}
+	var arch archive.Archive
+	for _, wheel := range wheels {
+		content, err := f.fetch(key, wheel, files.Token)
+		if err != nil {
+			return nil, err
+		}
+		arch = append(arch, archive.Item{
+			Path:         filepath.Join(files.Path, wheel.Name),
+			Type:         byte(tar.TypeReg),
+			Content:      content,
+			FileMode:     0644,
+			ModifiedTime: archive.UnixTime{Time: time.Now()},
+			UserID:       files.UserID,
+			GroupID:      files.GroupID,
+		})
+	}
+	return arch, nil
+}
+
+func (f *harnessFetcher) fetch(
+	platformKey string, wheel cproto.HarnessWheel, token string,
+) ([]byte, error) {
+	f.mu.Lock()
+	content, ok := f.wheels[wheel.SHA256]
+	f.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/wheels/%s/%s",
+		f.masterURL, url.PathEscape(platformKey), url.PathEscape(wheel.Name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch harness wheel %s", wheel.Name)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to fetch harness wheel %s: %s", wheel.Name, resp.Status)
+	}
+	content, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read harness wheel %s", wheel.Name)
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != wheel.SHA256 {
+		return nil, errors.Errorf("harness wheel %s does not match its checksum", wheel.Name)
+	}
+
+	f.mu.Lock()
+	f.wheels[wheel.SHA256] = content
+	f.mu.Unlock()
+	return content, nil
+}
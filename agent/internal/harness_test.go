@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+func TestHarnessFetcher(t *testing.T) {
+	content := []byte("wheel")
+	sum := sha256.Sum256(content)
+	wheel := cproto.HarnessWheel{
+		Name: "determined-0.13.8-py3-none-any.whl", SHA256: hex.EncodeToString(sum[:]),
+	}
+	platform := cproto.HarnessPlatform{OS: runtime.GOOS, Arch: runtime.GOARCH, Python: "3.6"}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/wheels/"+platform.Key()+"/"+wheel.Name {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	fetcher := newHarnessFetcher(server.Client(), server.URL)
+
+	files := cproto.HarnessFiles{
+		Path: "/opt/determined/wheels",
+		Manifest: cproto.HarnessManifest{
+			Python: "3.6",
+			Wheels: map[string][]cproto.HarnessWheel{platform.Key(): {wheel}},
+		},
+		UserID: 1000,
+		Token:  "token",
+	}
+	for i := 0; i < 2; i++ {
+		arch, err := fetcher.archive(files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(arch) != 1 || arch[0].Path != "/opt/determined/wheels/"+wheel.Name ||
+			string(arch[0].Content) != "wheel" || arch[0].UserID != 1000 {
+			t.Errorf("unexpected archive: %v", arch)
+		}
+	}
+	// The wheel is fetched once and then served from the cache.
+	if requests != 1 {
+		t.Errorf("expected 1 request but got %d", requests)
+	}
+
+	files.Manifest.Wheels[platform.Key()][0].SHA256 = "0"
+	if _, err := fetcher.archive(files); err == nil {
+		t.Error("expected a checksum mismatch")
+	}
+
+	files.Manifest.Python = "3.10"
+	_, err := fetcher.archive(files)
+	expected := "no harness wheel for " + runtime.GOOS + "/" + runtime.GOARCH + " py3.10"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q but got %v", expected, err)
+	}
+}
@@ -28,7 +28,11 @@ def list_agents(args: argparse.Namespace) -> None:
                 ("id", local_id(agent_id)),
                 ("registered_time", render.format_time(agent["registered_time"])),
                 ("num_slots", len(agent["slots"])),
+                ("num_used_slots", agent["num_used_slots"]),
                 ("num_containers", agent["num_containers"]),
+                ("resource_pool", agent["resource_pool"]),
+                ("enabled", agent["enabled"]),
+                ("draining", agent["draining"]),
                 ("label", agent["label"]),
             ]
         )
@@ -39,7 +43,17 @@ def list_agents(args: argparse.Namespace) -> None:
         print(json.dumps(agents, indent=4))
         return
 
-    headers = ["Agent ID", "Registered Time", "Slots", "Containers", "Label"]
+    headers = [
+        "Agent ID",
+        "Registered Time",
+        "Slots",
+        "Used Slots",
+        "Containers",
+        "Resource Pool",
+        "Enabled",
+        "Draining",
+        "Label",
+    ]
     values = [a.values() for a in agents]
 
     render.tabulate_or_csv(headers, values, args.csv)
@@ -189,6 +189,13 @@ def main(args: List[str] = sys.argv[1:]) -> None:
                 "delete experiment",
                 [
                     Arg("experiment_id", help="delete experiment"),
+                    Arg(
+                        "--hard",
+                        action="store_true",
+                        default=False,
+                        help="delete the experiment for good right away instead of after the "
+                        "grace period",
+                    ),
                     Arg(
                         "--yes",
                         action="store_true",
@@ -198,6 +205,14 @@ def main(args: List[str] = sys.argv[1:]) -> None:
                 ],
             )
         )
+        experiment_args_description.subs.append(
+            Cmd(
+                "undelete",
+                experiment.undelete_experiment,
+                "recover a deleted experiment within the grace period",
+                [Arg("experiment_id", help="undelete experiment")],
+            )
+        )
 
     try:
         parser = make_parser()
@@ -11,6 +11,7 @@ from pathlib import Path
 from pprint import pformat
 from typing import Any, Dict, List, Optional, Set, Tuple
 
+import requests
 import tabulate
 
 import determined_common
@@ -187,6 +188,20 @@ def create(args: Namespace) -> None:
 
 @authentication_required
 def delete_experiment(args: Namespace) -> None:
+    if not args.hard:
+        r = api.delete(args.master, "experiments/{}".format(args.experiment_id))
+        # Masters without a delete grace period delete experiments for good right away.
+        if r.status_code == requests.codes.accepted:
+            print(
+                "Started deleting experiment {}; it is in the DELETING state until its "
+                "checkpoints are deleted".format(args.experiment_id)
+            )
+            return
+        print(
+            "Deleted experiment {}; it can be recovered with 'det experiment undelete' "
+            "until {}".format(args.experiment_id, render.format_time(r.json()["purge_after"]))
+        )
+        return
     if args.yes or render.yes_or_no(
         "Deleting an experiment will result in the unrecoverable \n"
         "deletion of all associated logs, checkpoints, and other \n"
@@ -194,12 +209,23 @@ def delete_experiment(args: Namespace) -> None:
         "alternative, see the 'det archive' command. Do you still \n"
         "wish to proceed?"
     ):
-        api.delete(args.master, "experiments/{}".format(args.experiment_id))
-        print("Successfully deleted experiment {}".format(args.experiment_id))
+        api.delete(
+            args.master, "experiments/{}".format(args.experiment_id), params={"hard": "true"}
+        )
+        print(
+            "Started deleting experiment {}; it is in the DELETING state until its "
+            "checkpoints are deleted".format(args.experiment_id)
+        )
     else:
         print("Aborting experiment deletion.")
 
 
+@authentication_required
+def undelete_experiment(args: Namespace) -> None:
+    api.post(args.master, "experiments/{}/undelete".format(args.experiment_id))
+    print("Undeleted experiment {}".format(args.experiment_id))
+
+
 @authentication_required
 def describe(args: Namespace) -> None:
     docs = []
@@ -537,6 +563,20 @@ def set_max_slots(args: Namespace) -> None:
     print("Set `max_slots` of experiment {} to {}".format(args.experiment_id, args.max_slots))
 
 
+@authentication_required
+def set_max_concurrent_trials(args: Namespace) -> None:
+    patch_experiment(
+        args,
+        "change `max_concurrent_trials` of",
+        {"searcher": {"max_concurrent_trials": args.max_concurrent_trials}},
+    )
+    print(
+        "Set `max_concurrent_trials` of experiment {} to {}".format(
+            args.experiment_id, args.max_concurrent_trials
+        )
+    )
+
+
 @authentication_required
 def set_weight(args: Namespace) -> None:
     patch_experiment(args, "change `weight` of", {"resources": {"weight": args.weight}})
@@ -909,6 +949,15 @@ args_description = Cmd(
                         Arg("max_slots", type=none_or_int, help="max slots"),
                     ],
                 ),
+                Cmd(
+                    "max-concurrent-trials",
+                    set_max_concurrent_trials,
+                    "set `max_concurrent_trials` of a running adaptive search",
+                    [
+                        experiment_id_arg("experiment ID to modify"),
+                        Arg("max_concurrent_trials", type=int, help="max concurrent trials"),
+                    ],
+                ),
                 Cmd(
                     "weight",
                     set_weight,
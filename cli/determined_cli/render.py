@@ -3,7 +3,7 @@ import csv
 import inspect
 import pathlib
 import sys
-from datetime import timezone
+from datetime import timedelta, timezone
 from typing import Any, Dict, Iterable, List, Optional, Sequence
 
 import dateutil.parser
@@ -81,6 +81,12 @@ def format_time(datetime_str: Optional[str]) -> Optional[str]:
     return dt.astimezone(timezone.utc).strftime("%Y-%m-%d %H:%M:%S%z")
 
 
+def format_duration(seconds: Optional[float]) -> Optional[str]:
+    if seconds is None:
+        return None
+    return str(timedelta(seconds=round(seconds)))
+
+
 def format_percent(f: Optional[float]) -> Optional[str]:
     if f is None:
         return None
@@ -1,4 +1,5 @@
 import getpass
+import os
 import subprocess
 import sys
 import tempfile
@@ -10,10 +11,10 @@ from termcolor import colored
 
 from determined_common import api
 from determined_common.api import request
-from determined_common.api.authentication import authentication_required
+from determined_common.api.authentication import Authentication, authentication_required
 from determined_common.check import check_eq, check_len
 
-from . import render
+from . import render, tunnel
 from .command import (
     CONFIG_DESC,
     CONTEXT_DESC,
@@ -105,7 +106,10 @@ def _open_shell(master: str, shell: Command, additional_opts: List[str]) -> None
             *additional_opts,
         ]
 
-        subprocess.run(cmd)
+        # The master only opens tunnels for authenticated users, so pass the session token to the
+        # tunnel through the environment rather than the command line, where others could see it.
+        token = Authentication.instance().get_session_token()
+        subprocess.run(cmd, env={**os.environ, tunnel.TOKEN_ENV_VAR: token})
 
         print(colored("To reconnect, run: det shell open {}".format(shell.id), "green"))
 
@@ -32,6 +32,8 @@ def describe_trial(args: Namespace) -> None:
         "H-Params",
         "Start Time",
         "End Time",
+        "Queued Time",
+        "Running Time",
     ]
     values = [
         [
@@ -40,6 +42,8 @@ def describe_trial(args: Namespace) -> None:
             json.dumps(trial["hparams"], indent=4),
             render.format_time(trial["start_time"]),
             render.format_time(trial["end_time"]),
+            render.format_duration(trial.get("queued_time")),
+            render.format_duration(trial.get("running_time")),
         ]
     ]
     render.tabulate_or_csv(headers, values, args.csv)
@@ -232,6 +236,12 @@ args_description = [
                         "--timestamp-after",
                         help="show logs only from after (RFC 3339 format)",
                     ),
+                    Arg(
+                        "--since",
+                        help="start with the logs from this recent window instead of the whole "
+                        "log, e.g., 5m or an RFC 3339 timestamp; with --follow, newer logs are "
+                        "all shown; cannot be combined with --tail",
+                    ),
                     Arg(
                         "--level",
                         dest="level",
@@ -250,6 +260,11 @@ args_description = [
                         action="append",
                         help="output stream to show logs from (repeat for multiple values)",
                     ),
+                    Arg(
+                        "--strip-ansi",
+                        action="store_true",
+                        help="remove ANSI escape sequences, such as colors, from the logs",
+                    ),
                 ],
             ),
             Cmd(
@@ -2,7 +2,8 @@
 tunnel.py will tunnel a TCP connection through Determined master at MASTER_ADDR to SERVICE_UUID.
 
 This is used to tunnel ssh connections through the master, where the hostname in the SERVICE_UUID
-should be the shell ID of the shell in question.
+should be the shell ID of the shell in question. The master only opens tunnels to the services of
+the authenticated user, so the session token is read from the DET_TUNNEL_TOKEN environment variable.
 """
 
 import argparse
@@ -13,10 +14,12 @@ import socket
 import ssl
 import sys
 import threading
-from typing import Any, Optional, Union
+from typing import Any, Dict, Optional, Union
 
 from determined_common.api import request
 
+TOKEN_ENV_VAR = "DET_TUNNEL_TOKEN"
+
 
 class HTTPSProxyConnection(http.client.HTTPSConnection):
     """
@@ -115,13 +118,21 @@ def http_connect_tunnel(
     else:
         client = http.client.HTTPConnection(parsed_master.hostname, parsed_master.port)
 
-    client.set_tunnel(service)
+    headers = {}  # type: Dict[str, str]
+    token = os.environ.get(TOKEN_ENV_VAR)
+    if token:
+        headers["Authorization"] = "Bearer {}".format(token)
+    client.set_tunnel(service, headers=headers)
 
     try:
         client.connect()
     except socket.gaierror:
         print("failed to look up host:", master, file=sys.stderr)
         raise
+    except OSError as e:
+        # http.client raises OSError if the master refuses to open the tunnel.
+        print("failed to open tunnel to {}: {}".format(service, e), file=sys.stderr)
+        raise
 
     with client.sock as sock:
         sock = SocketWrapper(sock)
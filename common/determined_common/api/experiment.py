@@ -52,6 +52,8 @@ def logs(args: Namespace) -> None:
             query["limit"] = limit
         if follow:
             query["follow"] = "true"
+        if getattr(args, "strip_ansi", False):
+            query["strip_ansi"] = "true"
         for f in [
             "agent_ids",
             "container_ids",
@@ -60,6 +62,7 @@ def logs(args: Namespace) -> None:
             "stdtypes",
             "timestamp_before",
             "timestamp_after",
+            "since",
         ]:
             if getattr(args, f, None) is not None:
                 query[f] = getattr(args, f)
@@ -73,6 +76,10 @@ def logs(args: Namespace) -> None:
                 log = simplejson.loads(line)["result"]
                 print(log["message"], end="")
 
+    if args.tail is not None and getattr(args, "since", None) is not None:
+        # Tails are requested as negative offsets, which the master does not accept with since.
+        raise Exception("--tail cannot be combined with --since")
+
     try:
         if args.head is not None:
             print_logs(0, args.head)
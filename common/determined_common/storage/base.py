@@ -1,5 +1,6 @@
 import abc
 import contextlib
+import logging
 import os
 import shutil
 import uuid
@@ -123,9 +124,10 @@ class StorageManager:
         """
         storage_dir = os.path.join(self._base_path, metadata.storage_id)
 
-        check_true(
-            os.path.exists(storage_dir), "Storage directory does not exist: {}".format(storage_dir)
-        )
+        # Deleting is idempotent so that an interrupted deletion can be retried.
+        if not os.path.exists(storage_dir):
+            logging.info("Storage directory already deleted: {}".format(storage_dir))
+            return
         check_true(
             os.path.isdir(storage_dir), "Storage path is not a directory: {}".format(storage_dir)
         )
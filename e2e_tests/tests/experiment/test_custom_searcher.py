@@ -0,0 +1,114 @@
+import random
+import time
+import uuid
+from typing import Any, Dict, List
+
+import pytest
+import requests
+
+import determined_common.api.authentication as auth
+from determined_common import api
+from tests import config as conf
+from tests import experiment as exp
+
+
+def fetch_events(experiment_id: int, acknowledged: int) -> List[Dict[str, Any]]:
+    r = api.get(
+        conf.make_master_url(),
+        "experiments/{}/searcher/events".format(experiment_id),
+        params={"acknowledged": acknowledged},
+    )
+    assert r.status_code == requests.codes.ok, r.text
+    events = r.json()["events"]  # type: List[Dict[str, Any]]
+    return events
+
+
+def post_operations(experiment_id: int, operations: List[Dict[str, Any]]) -> requests.Response:
+    return api.post(
+        conf.make_master_url(),
+        "experiments/{}/searcher/operations".format(experiment_id),
+        body={"operations": operations},
+    )
+
+
+def run_random_controller(experiment_id: int, max_trials: int) -> None:
+    """
+    Drive a custom searcher with a trivial random search: create every trial up front with a random
+    learning rate, train and validate each once, then shut down once all trials are closed.
+    """
+    acknowledged = 0
+    closed = 0
+    for _ in range(conf.DEFAULT_MAX_WAIT_SECS):
+        operations = []  # type: List[Dict[str, Any]]
+        for event in fetch_events(experiment_id, acknowledged):
+            acknowledged = event["id"]
+            if event["type"] == "initialized":
+                for _ in range(max_trials):
+                    request_id = str(uuid.uuid4())
+                    hparams = {"learning_rate": random.uniform(0.001, 0.1)}
+                    operations += [
+                        {"create": {"request_id": request_id, "hparams": hparams}},
+                        {"train": {"request_id": request_id, "length": {"batches": 100}}},
+                        {"validate": {"request_id": request_id}},
+                    ]
+            elif event["type"] == "validation_completed":
+                operations.append({"close": {"request_id": event["request_id"]}})
+            elif event["type"] == "trial_closed":
+                closed += 1
+                operations.append({"set_progress": closed / max_trials})
+                if closed == max_trials:
+                    operations.append({"shutdown": {"failure": False}})
+
+        if operations:
+            r = post_operations(experiment_id, operations)
+            assert r.status_code == requests.codes.no_content, r.text
+            if closed == max_trials:
+                return
+        time.sleep(1)
+
+    pytest.fail("custom searcher controller did not finish")
+
+
+@pytest.mark.e2e_cpu  # type: ignore
+def test_custom_searcher_random_controller() -> None:
+    experiment_id = exp.create_experiment(
+        conf.fixtures_path("no_op/custom.yaml"), conf.fixtures_path("no_op"), None
+    )
+    auth.initialize_session(conf.make_master_url(), try_reauth=True)
+
+    run_random_controller(experiment_id, max_trials=3)
+
+    exp.wait_for_experiment_state(experiment_id, "COMPLETED")
+    assert exp.num_completed_trials(experiment_id) == 3
+
+
+@pytest.mark.e2e_cpu  # type: ignore
+def test_custom_searcher_rejects_invalid_operations() -> None:
+    experiment_id = exp.create_experiment(
+        conf.fixtures_path("no_op/custom.yaml"), conf.fixtures_path("no_op"), None
+    )
+    auth.initialize_session(conf.make_master_url(), try_reauth=True)
+
+    r = post_operations(experiment_id, [{"close": {"request_id": str(uuid.uuid4())}}])
+    assert r.status_code == requests.codes.bad_request, r.text
+    r = post_operations(experiment_id, [{"create": {"hparams": {"unknown": 1}}}])
+    assert r.status_code == requests.codes.bad_request, r.text
+
+    exp.cancel_experiment(experiment_id)
+
+
+@pytest.mark.e2e_cpu  # type: ignore
+def test_custom_searcher_pauses_on_silent_controller() -> None:
+    experiment_id = exp.create_experiment(
+        conf.fixtures_path("no_op/custom.yaml"), conf.fixtures_path("no_op"), None
+    )
+    auth.initialize_session(conf.make_master_url(), try_reauth=True)
+
+    # The fixture's controller timeout is 30 seconds.
+    exp.wait_for_experiment_state(experiment_id, "PAUSED", max_wait_secs=90)
+
+    # Contacting the experiment again resumes it.
+    fetch_events(experiment_id, 0)
+    exp.wait_for_experiment_state(experiment_id, "ACTIVE", max_wait_secs=30)
+
+    exp.cancel_experiment(experiment_id)
@@ -9,10 +9,11 @@ from typing import Dict, Set
 import botocore.exceptions
 import numpy as np
 import pytest
+import requests
 import yaml
 
 from determined.experimental import Determined, ModelSortBy
-from determined_common import check, storage
+from determined_common import api, check, storage
 from tests import config as conf
 from tests import experiment as exp
 from tests.fixtures.metric_maker.metric_maker import structure_equal, structure_to_metrics
@@ -82,6 +83,101 @@ def test_metric_gathering() -> None:
         assert structure_equal(expected, actual)
 
 
+# Metric names that are awkward to quote in SQL, URLs or JSON, or to group by.
+ADVERSARIAL_METRIC_NAMES = [
+    "val/accuracy@top5",
+    "loss.total",
+    "ünïcödé 损失 📉",
+    "long_metric_name" * 64,
+    "'; DROP TABLE steps; --",
+    '"quoted" \\ name',
+    "%s%d$1",
+    "a+b&c=d?e#f",
+]
+
+
+@pytest.mark.e2e_cpu  # type: ignore
+def test_adversarial_metric_names() -> None:
+    """
+    Confirm that metric names are stored, listed and looked up verbatim.
+    """
+    config = conf.load_config(conf.fixtures_path("metric_maker/const.yaml"))
+    names = {name: i + 1 for i, name in enumerate(ADVERSARIAL_METRIC_NAMES)}
+    config["hyperparameters"]["training_structure"]["val"] = {"loss": 1, **names}
+    config["hyperparameters"]["validation_structure"]["val"] = {"error": 1, **names}
+    config["searcher"]["metric"] = ADVERSARIAL_METRIC_NAMES[0]
+    config["searcher"]["max_length"] = {"batches": 200}
+    experiment_id = exp.run_basic_test_with_temp_config(
+        config, conf.fixtures_path("metric_maker"), 1
+    )
+    trial_id = exp.experiment_trials(experiment_id)[0]["id"]
+
+    r = api.get(conf.make_master_url(), "experiments/{}/metric-names".format(experiment_id))
+    assert r.status_code == requests.codes.ok, r.text
+    metric_names = r.json()
+    expected = sorted(["loss", *ADVERSARIAL_METRIC_NAMES])
+    assert [m["name"] for m in metric_names["training"]] == expected
+    assert all(m["count"] == 2 for m in metric_names["training"])
+    expected = ["error", *ADVERSARIAL_METRIC_NAMES]
+    assert {m["name"] for m in metric_names["validation"]} == set(expected)
+
+    for name, scale in names.items():
+        r = api.get(
+            conf.make_master_url(),
+            "experiments/{}/metrics/summary".format(experiment_id),
+            params={"metric": name, "reduction": "max"},
+        )
+        assert r.status_code == requests.codes.ok, r.text
+        for step in r.json()["trials"][0]["steps"]:
+            assert set(step["metrics"]["avg_metrics"]) == {name}
+            if step["validation"] is not None:
+                assert set(step["validation"]["metrics"]["validation_metrics"]) == {name}
+        for point in r.json()["reduced_metrics"]:
+            assert point["num_trials"] == 1
+            assert set(point["metrics"]) == {name}
+            assert set(point["validation_metrics"]) <= {name}
+
+        r = api.get(
+            conf.make_master_url(), "trials/{}/metrics".format(trial_id), params={"metric": name}
+        )
+        assert r.status_code == requests.codes.ok, r.text
+        for step in r.json()["steps"]:
+            for batch in step["metrics"]["batch_metrics"]:
+                assert set(batch) == {name}
+        first_batch = r.json()["steps"][0]["metrics"]["batch_metrics"][0]
+        assert first_batch[name] == scale
+
+
+@pytest.mark.e2e_cpu  # type: ignore
+def test_experiment_prometheus_metrics() -> None:
+    config = conf.load_config(conf.fixtures_path("metric_maker/const.yaml"))
+    config["searcher"]["max_length"] = {"batches": 200}
+    experiment_id = exp.run_basic_test_with_temp_config(
+        config, conf.fixtures_path("metric_maker"), 1
+    )
+    trial_id = exp.experiment_trials(experiment_id)[0]["id"]
+
+    r = api.get(conf.make_master_url(), "experiments/{}/metrics/prometheus".format(experiment_id))
+    assert r.status_code == requests.codes.ok, r.text
+    assert r.headers["Content-Type"].startswith("text/plain")
+    series = {}
+    for line in r.text.splitlines():
+        if not line.startswith("#"):
+            name, value = line.rsplit(" ", 1)
+            series[name] = float(value)
+
+    labels = 'experiment_id="{}",metric="{{}}",trial_id="{}"'.format(experiment_id, trial_id)
+    for metric in ["loss", "more_loss"]:
+        assert "det_experiment_training_metric{" + labels.format(metric) + "}" in series
+    # The validation metrics are scaled by the base value after 200 batches.
+    validation = "det_experiment_validation_metric{" + labels.format("error") + "}"
+    assert series[validation] == 3 * (1 + 200)
+
+    with pytest.raises(api.errors.APIException) as e:
+        api.get(conf.make_master_url(), "experiments/{}/metrics/prometheus".format(2 ** 30))
+    assert e.value.status_code == requests.codes.not_found
+
+
 @pytest.mark.e2e_gpu  # type: ignore
 def test_gc_checkpoints_s3(secrets: Dict[str, str]) -> None:
     config = exp.s3_checkpoint_config(secrets)
@@ -189,16 +285,50 @@ def test_experiment_delete() -> None:
     )
 
     subprocess.check_call(
-        ["det", "-m", conf.make_master_url(), "experiment", "delete", str(experiment_id), "--yes"],
+        [
+            "det",
+            "-m",
+            conf.make_master_url(),
+            "experiment",
+            "delete",
+            str(experiment_id),
+            "--hard",
+            "--yes",
+        ],
         env={**os.environ, "DET_ADMIN": "1"},
     )
 
-    # "det experiment describe" call should fail, because the
-    # experiment is no longer in the database.
-    with pytest.raises(subprocess.CalledProcessError):
-        subprocess.check_call(
-            ["det", "-m", conf.make_master_url(), "experiment", "describe", str(experiment_id)]
-        )
+    # The experiment is deleted in the background; once it is done, "det experiment describe"
+    # should fail, because the experiment is no longer in the database.
+    for _ in range(60):
+        try:
+            subprocess.check_call(
+                ["det", "-m", conf.make_master_url(), "experiment", "describe", str(experiment_id)]
+            )
+        except subprocess.CalledProcessError:
+            break
+        time.sleep(1)
+    else:
+        raise AssertionError("experiment {} was not deleted".format(experiment_id))
+
+
+@pytest.mark.e2e_cpu  # type: ignore
+def test_experiment_delete_undelete() -> None:
+    experiment_id = exp.run_basic_test(
+        conf.fixtures_path("no_op/single.yaml"), conf.fixtures_path("no_op"), 1
+    )
+
+    def listed() -> bool:
+        r = api.get(conf.make_master_url(), "experiments", params={"filter": "all"})
+        return experiment_id in {e["id"] for e in r.json()}
+
+    api.delete(conf.make_master_url(), "experiments/{}".format(experiment_id))
+    assert not listed()
+    r = api.get(conf.make_master_url(), "experiments/{}".format(experiment_id))
+    assert r.json()["deleted_at"] is not None
+
+    api.post(conf.make_master_url(), "experiments/{}/undelete".format(experiment_id))
+    assert listed()
 
 
 @pytest.mark.e2e_cpu  # type: ignore
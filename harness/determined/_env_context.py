@@ -32,6 +32,7 @@ class EnvContext:
         det_cluster_id: str,
         trial_seed: int,
         managed_training: bool = True,
+        heartbeat_interval: int = 0,
     ):
         self.master_addr = master_addr
         self.master_port = master_port
@@ -56,6 +57,8 @@ class EnvContext:
         self.det_cluster_id = det_cluster_id
         self.trial_seed = trial_seed
         self.managed_training = managed_training
+        # Seconds between the heartbeats sent to the master; zero disables them.
+        self.heartbeat_interval = heartbeat_interval
 
         self._per_slot_batch_size, self._global_batch_size = self._calculate_batch_sizes()
 
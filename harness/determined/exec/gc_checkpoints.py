@@ -22,14 +22,27 @@ def delete_checkpoints(
     """
     logging.info("Deleting {} checkpoints".format(len(to_delete)))
 
+    # A failure to delete one checkpoint does not stop the others from being deleted. The master
+    # parses the failures out of the logs, so their format must not change.
+    failed = 0
     for record in to_delete:
         metadata = storage.StorageMetadata.from_json(record)
         if not dry_run:
             logging.info("Deleting checkpoint {}".format(metadata))
-            manager.delete(metadata)
+            try:
+                manager.delete(metadata)
+            except Exception as e:
+                failed += 1
+                logging.error(
+                    "Failed to delete checkpoint {}: {}".format(
+                        metadata.storage_id, str(e).replace("\n", " ")
+                    )
+                )
         else:
             logging.info("Dry run: deleting checkpoint {}".format(metadata.storage_id))
 
+    if failed:
+        raise Exception("Failed to delete {} of {} checkpoints".format(failed, len(to_delete)))
     logging.info("Finished deleting {} checkpoints".format(len(to_delete)))
 
 
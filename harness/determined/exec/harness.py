@@ -179,6 +179,7 @@ def main() -> None:
     det_experiment_id = os.environ["DET_EXPERIMENT_ID"]
     det_cluster_id = os.environ["DET_CLUSTER_ID"]
     trial_seed = int(os.environ["DET_TRIAL_SEED"])
+    heartbeat_interval = int(os.environ.get("DET_HEARTBEAT_INTERVAL", "0"))
 
     gpu_uuids = gpu.get_gpu_uuids_and_validate(use_gpu, slot_ids)
 
@@ -205,6 +206,7 @@ def main() -> None:
         det_experiment_id,
         det_cluster_id,
         trial_seed,
+        heartbeat_interval=heartbeat_interval,
     )
 
     logging.info(
@@ -1,6 +1,7 @@
 import logging
 import socket
 import ssl
+import threading
 from typing import Any, Optional
 
 import lomond
@@ -58,6 +59,15 @@ class SocketManager(workload.Source):
             ping_rate=0, session_class=lambda socket: CustomSSLWebsocketSession(socket, env)
         )
 
+        # The event loop does not run while workloads run, so heartbeats are sent from a separate
+        # thread.
+        self.send_lock = threading.Lock()
+        self.heartbeats_stopped = threading.Event()
+        self.heartbeat_thread = None  # type: Optional[threading.Thread]
+        if self.env.heartbeat_interval > 0:
+            self.heartbeat_thread = threading.Thread(target=self.send_heartbeats, daemon=True)
+            self.heartbeat_thread.start()
+
         # Handle the messages up to and including the rendezvous message.
         for ws_event in self.ws_events:
             ri = self.check_for_rendezvous_info(ws_event)
@@ -68,6 +78,21 @@ class SocketManager(workload.Source):
         else:
             raise ValueError("Ran out of events without finding rendezvous message")
 
+    def send_heartbeats(self) -> None:
+        """
+        Tell the master that this container is still up every heartbeat interval, so that it can
+        tell when the machine running it is lost.
+        """
+        while not self.heartbeats_stopped.wait(self.env.heartbeat_interval):
+            try:
+                self.send_text(util.json_encode({"type": "HEARTBEAT"}))
+            except Exception as e:
+                logging.warning(f"Failed to send heartbeat to master: {e}")
+
+    def send_text(self, text: str) -> None:
+        with self.send_lock:
+            self.socket.send_text(text)
+
     def __iter__(self) -> workload.Stream:
         # Always yield the initial workload first.
         yield from self.yield_workload(self.env.initial_workload)
@@ -83,6 +108,9 @@ class SocketManager(workload.Source):
         self.close()
 
     def close(self) -> None:
+        self.heartbeats_stopped.set()
+        if self.heartbeat_thread is not None:
+            self.heartbeat_thread.join()
         self.socket.close()
 
         # Empty the websocket.
@@ -197,7 +225,7 @@ class SocketManager(workload.Source):
             duration = metrics["end_time"] - metrics["start_time"]
             logging.info(f"Workload completed: {metrics['workload']} (duration {duration})")
 
-            self.socket.send_text(util.json_encode(metrics))
+            self.send_text(util.json_encode(metrics))
 
         yield wkld, [], respond
 
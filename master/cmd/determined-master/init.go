@@ -64,6 +64,11 @@ func registerConfig() {
 
 	registerString(flags, name("config-file"),
 		defaults.ConfigFile, "location of config file")
+	registerBool(flags, name("allow-unknown-config-fields"),
+		defaults.AllowUnknownConfigFields, "ignore unknown fields in the master configuration")
+	registerBool(flags, name("allow-unknown-experiment-config-fields"),
+		defaults.AllowUnknownExperimentConfigFields,
+		"ignore unknown fields in experiment configurations")
 
 	registerString(flags, name("log", "level"),
 		defaults.Log.Level, "choose logging level from [trace, debug, info, warn, error, fatal]")
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -36,6 +37,7 @@ var rootCmd = &cobra.Command{
 func runRoot() error {
 	logStore := logger.NewLogBuffer(logStoreSize)
 	log.AddHook(logStore)
+	log.AddHook(logger.RequestIDHook{})
 
 	config, err := initializeConfig()
 	if err != nil {
@@ -48,7 +50,9 @@ func runRoot() error {
 	}
 	log.Infof("master configuration: %s", printableConfig)
 
-	m := internal.New(version.Version, logStore, config)
+	m := internal.New(version.Version, logStore, config, func() (*internal.Config, error) {
+		return reloadConfig(config.ConfigFile)
+	})
 	return m.Run()
 }
 
@@ -84,6 +88,40 @@ func initializeConfig() (*internal.Config, error) {
 	return config, nil
 }
 
+// reloadConfig returns the validated configuration after re-reading the config file, which
+// replaces the settings previously read from it. Environment variables and command line flags
+// keep their values.
+func reloadConfig(configFile string) (*internal.Config, error) {
+	bs, err := readConfigFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	var configMap map[string]interface{}
+	if err = yaml.Unmarshal(bs, &configMap); err != nil {
+		return nil, errors.Wrap(err, "error unmarshal yaml configuration file")
+	}
+	if configMap == nil {
+		configMap = map[string]interface{}{}
+	}
+	js, err := json.Marshal(configMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal configuration map into json bytes")
+	}
+	viper.SetConfigType("json")
+	if err = viper.ReadConfig(bytes.NewReader(js)); err != nil {
+		return nil, errors.Wrap(err, "error replacing configuration in viper")
+	}
+
+	config, err := getConfig(viper.AllSettings())
+	if err != nil {
+		return nil, err
+	}
+	if err := check.Validate(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 func readConfigFile(configPath string) ([]byte, error) {
 	isDefault := configPath == ""
 	if isDefault {
@@ -122,9 +160,15 @@ func getConfig(configMap map[string]interface{}) (*internal.Config, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot marshal configuration map into json bytes")
 	}
-	if err = yaml.Unmarshal(bs, &config, yaml.DisallowUnknownFields); err != nil {
+	if err = yaml.Unmarshal(bs, &config); err != nil {
 		return nil, errors.Wrap(err, "cannot unmarshal configuration")
 	}
+	if !config.AllowUnknownConfigFields {
+		if err = check.KnownFields(bs, config); err != nil {
+			return nil, errors.Wrap(err,
+				"invalid configuration (run with --allow-unknown-config-fields to ignore)")
+		}
+	}
 
 	if err := config.Resolve(); err != nil {
 		return nil, err
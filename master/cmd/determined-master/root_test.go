@@ -63,3 +63,26 @@ func TestUnmarshalMasterConfiguration(t *testing.T) {
 		t.Errorf("SaveTrialBest %d <= 0", f)
 	}
 }
+
+func TestUnknownMasterConfigFields(t *testing.T) {
+	configMap := map[string]interface{}{
+		"resouce_pools": []interface{}{},
+		"resource_pools": []interface{}{
+			map[string]interface{}{
+				"pool_name": "default",
+				"provider": map[string]interface{}{
+					"provider":          "aws",
+					"master_url":        "http://master:8080",
+					"network_interface": map[string]interface{}{"publc_ip": true},
+				},
+			},
+		},
+	}
+	_, err := getConfig(configMap)
+	assert.ErrorContains(t, err,
+		"unknown fields: resouce_pools, resource_pools[0].provider.network_interface.publc_ip")
+
+	configMap["allow_unknown_config_fields"] = true
+	_, err = getConfig(configMap)
+	assert.NilError(t, err)
+}
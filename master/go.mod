@@ -2,6 +2,7 @@ module github.com/determined-ai/determined/master
 
 require (
 	cloud.google.com/go v0.58.0
+	cloud.google.com/go/storage v1.9.0
 	github.com/Azure/go-ansiterm v0.0.0-20170929234023-d6e3b3328b78 // indirect
 	github.com/Microsoft/go-winio v0.4.9 // indirect
 	github.com/aws/aws-sdk-go v1.34.32
@@ -18,6 +18,7 @@ import (
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/agentv1"
 	proto "github.com/determined-ai/determined/proto/pkg/apiv1"
 )
@@ -30,6 +31,19 @@ type agent struct {
 	containers       map[container.ID]*actor.Ref
 	resourcePoolName string
 	label            string
+	// version is the version of the agent, which it reports on connect or, if it predates that,
+	// once it starts.
+	version string
+	// containerTasks holds the ID of the task of each container, with which the lifecycle logs
+	// and failures of the container are recorded.
+	containerTasks map[container.ID]string
+	// lastHeartbeat is when the agent last answered a ping of the master.
+	lastHeartbeat time.Time
+	// heartbeatWatchers are told each time the agent answers a ping of the master.
+	heartbeatWatchers map[*actor.Ref]bool
+	// clockSkew is how far the clock of the agent was ahead of the clock of the master when the
+	// agent started; it is nil for agents that do not report their clock.
+	clockSkew *ClockSkew
 
 	// uuid is an anonymous ID that is used when reporting telemetry
 	// information to allow agent connection and disconnection events
@@ -40,11 +54,19 @@ type agent struct {
 // AgentSummary summarizes the state on an agent.
 type AgentSummary struct {
 	ID             string       `json:"id"`
+	Address        string       `json:"address"`
 	RegisteredTime time.Time    `json:"registered_time"`
 	Slots          SlotsSummary `json:"slots"`
+	NumSlots       int          `json:"num_slots"`
+	NumUsedSlots   int          `json:"num_used_slots"`
 	NumContainers  int          `json:"num_containers"`
 	ResourcePool   string       `json:"resource_pool"`
 	Label          string       `json:"label"`
+	Version        string       `json:"version"`
+	// Enabled is true if any slot of the agent may be allocated to new tasks. A disabled agent
+	// that is still running containers is draining.
+	Enabled  bool `json:"enabled"`
+	Draining bool `json:"draining"`
 }
 
 func (a *agent) Receive(ctx *actor.Context) error {
@@ -53,19 +75,38 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		a.uuid = uuid.New()
 		a.slots, _ = ctx.ActorOf("slots", &slots{resourcePool: a.resourcePool})
 		a.containers = make(map[container.ID]*actor.Ref)
+		a.containerTasks = make(map[container.ID]string)
+		a.heartbeatWatchers = make(map[*actor.Ref]bool)
 	case AgentSummary:
 		ctx.Respond(a.summarize(ctx))
 	case ws.WebSocketConnected:
 		check.Panic(check.True(a.socket == nil, "websocket already connected"))
-		socket, ok := msg.Accept(ctx, aproto.MasterMessage{}, true)
+		socket, ok := msg.Accept(ctx, aproto.MasterMessage{}, ws.SocketOptions{
+			Class: "agent", UsePing: true,
+		})
 		check.Panic(check.True(ok, "failed to accept websocket connection"))
 		a.socket = socket
+		a.lastHeartbeat = time.Now()
 		lastColonIndex := strings.LastIndex(msg.Ctx.Request().RemoteAddr, ":")
 		if lastColonIndex == -1 {
 			a.address = msg.Ctx.Request().RemoteAddr
 		} else {
 			a.address = msg.Ctx.Request().RemoteAddr[0:lastColonIndex]
 		}
+	case ws.Heartbeat:
+		a.lastHeartbeat = msg.Time
+		for watcher := range a.heartbeatWatchers {
+			ctx.Tell(watcher, a.heartbeat(ctx))
+		}
+	case sproto.WatchAgentHeartbeats:
+		a.heartbeatWatchers[msg.Handler] = true
+		ctx.Tell(msg.Handler, a.heartbeat(ctx))
+	case sproto.UnwatchAgentHeartbeats:
+		delete(a.heartbeatWatchers, msg.Handler)
+	case getClockSkew:
+		if a.clockSkew != nil {
+			ctx.Respond(*a.clockSkew)
+		}
 	case sproto.KillTaskContainer:
 		ctx.Log().Infof("killing container id: %s", msg.ContainerID)
 		killMsg := aproto.SignalContainer{
@@ -83,6 +124,7 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		ctx.Ask(a.socket, start)
 		ctx.Tell(a.slots, msg.StartContainer)
 		a.containers[msg.Container.ID] = msg.TaskActor
+		a.containerTasks[msg.Container.ID] = msg.TaskID
 	case aproto.MasterMessage:
 		a.handleIncomingWSMessage(ctx, msg)
 	case *proto.GetAgentRequest:
@@ -106,6 +148,9 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		telemetry.ReportAgentDisconnected(ctx.Self().System(), a.uuid)
 
 		return errors.Wrapf(msg.Error, "child failed: %s", msg.Child.Address())
+	case actor.ChildStopped:
+		// The socket closed, e.g., because the agent was rejected for its version.
+		ctx.Self().Stop()
 	case actor.PostStop:
 		ctx.Log().Infof("agent disconnected")
 		for cid := range a.containers {
@@ -135,9 +180,24 @@ func (a *agent) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	}
 }
 
+func (a *agent) heartbeat(ctx *actor.Context) sproto.AgentHeartbeat {
+	return sproto.AgentHeartbeat{Agent: ctx.Self().Address().Local(), Time: a.lastHeartbeat}
+}
+
 func (a *agent) handleIncomingWSMessage(ctx *actor.Context, msg aproto.MasterMessage) {
 	switch {
 	case msg.AgentStarted != nil:
+		if a.version == "" {
+			a.version = msg.AgentStarted.Version
+			id := ctx.Self().Address().Local()
+			if rejection := admitVersion(ctx, id, a.version); rejection != nil {
+				ctx.Tell(a.socket, ws.CloseMessage{
+					Code: aproto.AgentVersionRejectedCode, Reason: closeReason(*rejection),
+				})
+				return
+			}
+			ctx.Tell(ctx.Self().Parent(), agentVersionReported{version: a.version})
+		}
 		telemetry.ReportAgentConnected(ctx.Self().System(), a.uuid, msg.AgentStarted.Devices)
 		ctx.Log().Infof("agent connected ip: %v resource pool: %s slots: %d",
 			a.address, msg.AgentStarted.ResourcePool, len(msg.AgentStarted.Devices))
@@ -146,19 +206,31 @@ func (a *agent) handleIncomingWSMessage(ctx *actor.Context, msg aproto.MasterMes
 		ctx.Tell(a.slots, *msg.AgentStarted)
 		a.resourcePoolName = msg.AgentStarted.ResourcePool
 		a.label = msg.AgentStarted.Label
+		a.measureClockSkew(ctx, msg.AgentStarted.Time)
 	case msg.ContainerStateChanged != nil:
 		a.containerStateChanged(ctx, *msg.ContainerStateChanged)
 	case msg.ContainerLog != nil:
 		ref, ok := a.containers[msg.ContainerLog.Container.ID]
 		check.Panic(check.True(ok,
 			"container not allocated to agent: container %s", msg.ContainerLog.Container.ID))
-		ctx.Tell(ref, sproto.ContainerLog{
+		log := sproto.ContainerLog{
 			Container:   msg.ContainerLog.Container,
 			Timestamp:   msg.ContainerLog.Timestamp,
 			PullMessage: msg.ContainerLog.PullMessage,
 			RunMessage:  msg.ContainerLog.RunMessage,
 			AuxMessage:  msg.ContainerLog.AuxMessage,
-		})
+		}
+		if source, level, message, ok := log.Lifecycle(); ok {
+			ctx.Self().System().TellAt(sproto.AllocationRecorderAddr, sproto.ContainerLifecycleLog{
+				TaskID:      a.containerTasks[log.Container.ID],
+				ContainerID: string(log.Container.ID),
+				Timestamp:   log.Timestamp,
+				Source:      source,
+				Level:       level,
+				Message:     message,
+			})
+		}
+		ctx.Tell(ref, log)
 	default:
 		check.Panic(errors.Errorf("error parsing incoming message"))
 	}
@@ -179,7 +251,16 @@ func (a *agent) containerStateChanged(ctx *actor.Context, sc aproto.ContainerSta
 		}
 	case container.Terminated:
 		ctx.Log().Infof("stopped container id: %s", sc.Container.ID)
+		if failure := sc.ContainerStopped.Failure; failure != nil {
+			ctx.Self().System().TellAt(sproto.AllocationRecorderAddr, sproto.AllocationFailed{
+				TaskID:      a.containerTasks[sc.Container.ID],
+				Reason:      failureReason(*failure),
+				FailureType: string(failure.FailureType),
+				Message:     failure.ErrMsg,
+			})
+		}
 		delete(a.containers, sc.Container.ID)
+		delete(a.containerTasks, sc.Container.ID)
 		rsc.ContainerStopped = &sproto.TaskContainerStopped{
 			ContainerStopped: *sc.ContainerStopped,
 		}
@@ -189,13 +270,65 @@ func (a *agent) containerStateChanged(ctx *actor.Context, sc aproto.ContainerSta
 	ctx.Tell(a.slots, sc)
 }
 
+// measureClockSkew compares the time that the agent reported on start to the clock of the master.
+// The latency of the message counts toward the skew, which is fine for the skews that break log
+// ordering and timeouts.
+func (a *agent) measureClockSkew(ctx *actor.Context, agentTime time.Time) {
+	if agentTime.IsZero() {
+		return
+	}
+	masterTime := time.Now()
+	skew := agentTime.Sub(masterTime)
+	a.clockSkew = &ClockSkew{
+		AgentID:    ctx.Self().Address().Local(),
+		Address:    a.address,
+		AgentTime:  agentTime,
+		MasterTime: masterTime,
+		Skew:       skew.Seconds(),
+	}
+	if abs(skew) > DefaultClockSkewThreshold {
+		ctx.Log().Warnf("clock of agent differs from the clock of the master by %s", skew)
+	}
+}
+
+// failureReason classifies the failure of a container by what the agent saw of it.
+func failureReason(failure aproto.ContainerFailure) model.FailureReason {
+	switch failure.FailureType {
+	case aproto.AgentFailed:
+		return model.AgentLostReason
+	case aproto.ImagePullFailed, aproto.ImagePullAuthFailed:
+		return model.ImagePullReason
+	case aproto.ContainerFailed:
+		switch {
+		case failure.OOMKilled:
+			return model.OOMKilledReason
+		case failure.ExitCode == nil:
+		case *failure.ExitCode != aproto.SuccessExitCode:
+			return model.ExitNonzeroReason
+		}
+	}
+	return model.UnknownReason
+}
+
 func (a *agent) summarize(ctx *actor.Context) AgentSummary {
-	return AgentSummary{
+	slots := ctx.Ask(a.slots, SlotsSummary{}).Get().(SlotsSummary)
+	summary := AgentSummary{
 		ID:             ctx.Self().Address().Local(),
+		Address:        a.address,
 		RegisteredTime: ctx.Self().RegisteredTime(),
-		Slots:          ctx.Ask(a.slots, SlotsSummary{}).Get().(SlotsSummary),
+		Slots:          slots,
+		NumSlots:       len(slots),
 		NumContainers:  len(a.containers),
 		ResourcePool:   a.resourcePoolName,
 		Label:          a.label,
+		Version:        a.version,
+	}
+	for _, slot := range slots {
+		if slot.Container != nil {
+			summary.NumUsedSlots++
+		}
+		summary.Enabled = summary.Enabled || slot.Enabled
 	}
+	summary.Draining = !summary.Enabled && summary.NumContainers > 0
+	return summary
 }
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestFailureReason(t *testing.T) {
+	exited := func(code aproto.ExitCode) aproto.ContainerFailure {
+		return *aproto.ContainerExited(code).Failure
+	}
+	oomKilled := exited(137)
+	oomKilled.OOMKilled = true
+
+	for _, tc := range []struct {
+		name    string
+		failure aproto.ContainerFailure
+		reason  model.FailureReason
+	}{
+		{name: "out of memory", failure: oomKilled, reason: model.OOMKilledReason},
+		// Containers that the master kills exit with the same code as those killed by the kernel.
+		{name: "killed", failure: exited(137), reason: model.ExitNonzeroReason},
+		{name: "nonzero exit", failure: exited(1), reason: model.ExitNonzeroReason},
+		{
+			name:    "agent failed",
+			failure: *aproto.ContainerError(aproto.AgentFailed, nil).Failure,
+			reason:  model.AgentLostReason,
+		},
+		{
+			name:    "task error",
+			failure: *aproto.ContainerError(aproto.TaskError, nil).Failure,
+			reason:  model.UnknownReason,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, failureReason(tc.failure), tc.reason)
+		})
+	}
+}
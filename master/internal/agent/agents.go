@@ -8,20 +8,24 @@ import (
 
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
 // Initialize creates a new global agent actor.
 func Initialize(system *actor.System, e *echo.Echo, c *actor.Ref) {
-	_, ok := system.ActorOf(actor.Addr("agents"), &agents{cluster: c})
+	ref, ok := system.ActorOf(actor.Addr("agents"), &agents{cluster: c, versions: map[*actor.Ref]string{}})
 	check.Panic(check.True(ok, "agents address already taken"))
 	// Route /agents and /agents/<agent id>/slots to the agents actor and slots actors.
 	e.Any("/agents*", api.Route(system, nil))
+	e.GET(clockSkewPath, api.Route(system, ref))
 }
 
 type agents struct {
 	cluster *actor.Ref
+	// versions holds the version of each connected agent, as far as it is known.
+	versions map[*actor.Ref]string
 }
 
 type agentsSummary map[string]AgentSummary
@@ -30,11 +34,33 @@ func (a *agents) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case api.WebSocketConnected:
 		id, resourcePool := msg.Ctx.QueryParam("id"), msg.Ctx.QueryParam("resource_pool")
-		if ref, err := a.createAgentActor(ctx, id, resourcePool); err != nil {
+		// Agents that predate reporting their versions on connect are checked once they start.
+		agentVersion := msg.Ctx.QueryParam("version")
+		if agentVersion != "" {
+			if rejection := admitVersion(ctx, id, agentVersion); rejection != nil {
+				msg.Reject(ctx, aproto.AgentVersionRejectedCode, closeReason(*rejection))
+				return nil
+			}
+		}
+		if ref, err := a.createAgentActor(ctx, id, resourcePool, agentVersion); err != nil {
 			ctx.Respond(err)
 		} else {
 			ctx.Respond(ctx.Ask(ref, msg).Get())
 		}
+	case GetVersionCounts:
+		counts := map[string]int{}
+		for _, v := range a.versions {
+			counts[v]++
+		}
+		ctx.Respond(counts)
+	case agentVersionReported:
+		if _, ok := a.versions[ctx.Sender()]; ok {
+			a.versions[ctx.Sender()] = msg.version
+		}
+	case actor.ChildStopped:
+		delete(a.versions, msg.Child)
+	case actor.ChildFailed:
+		delete(a.versions, msg.Child)
 	case *apiv1.GetAgentsRequest:
 		response := &apiv1.GetAgentsResponse{}
 		for _, a := range a.summarize(ctx) {
@@ -50,7 +76,9 @@ func (a *agents) Receive(ctx *actor.Context) error {
 	return nil
 }
 
-func (a *agents) createAgentActor(ctx *actor.Context, id, resourcePool string) (*actor.Ref, error) {
+func (a *agents) createAgentActor(
+	ctx *actor.Context, id, resourcePool, agentVersion string,
+) (*actor.Ref, error) {
 	if id == "" {
 		return nil, errors.Errorf("invalid agent id specified: %s", id)
 	}
@@ -60,16 +88,23 @@ func (a *agents) createAgentActor(ctx *actor.Context, id, resourcePool string) (
 	if a.cluster.Child(resourcePool) == nil {
 		return nil, errors.Errorf("cannot find specified resource pool %s for agent %s", resourcePool, id)
 	}
-	ref, ok := ctx.ActorOf(id, &agent{resourcePool: a.cluster.Child(resourcePool)})
+	ref, ok := ctx.ActorOf(id, &agent{
+		resourcePool: a.cluster.Child(resourcePool), version: agentVersion,
+	})
 	if !ok {
 		return nil, errors.Errorf("agent already connected: %s", id)
 	}
+	a.versions[ref] = agentVersion
 	return ref, nil
 }
 
 func (a *agents) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	switch apiCtx.Request().Method {
 	case echo.GET:
+		if apiCtx.Path() == clockSkewPath {
+			ctx.Respond(a.handleClockSkew(ctx, apiCtx))
+			return
+		}
 		ctx.Respond(apiCtx.JSON(http.StatusOK, a.summarize(ctx)))
 	default:
 		ctx.Respond(echo.ErrMethodNotAllowed)
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// DefaultClockSkewThreshold is how far the clock of an agent may differ from the clock of the
+// master before the agent is listed by GET /agents/clock_skew, unless the request sets a
+// threshold.
+const DefaultClockSkewThreshold = 5 * time.Second
+
+// clockSkewPath is the path of the endpoint that lists agents whose clocks are skewed.
+const clockSkewPath = "/agents/clock_skew"
+
+type (
+	// getClockSkew asks an agent for its ClockSkew; agents that do not report their clocks do not
+	// answer.
+	getClockSkew struct{}
+
+	// ClockSkew is how far the clock of an agent was ahead of the clock of the master when the
+	// agent started.
+	ClockSkew struct {
+		AgentID    string    `json:"agent_id"`
+		Address    string    `json:"address"`
+		AgentTime  time.Time `json:"agent_time"`
+		MasterTime time.Time `json:"master_time"`
+		// Skew is negative for agents whose clocks are behind the clock of the master.
+		Skew float64 `json:"skew_seconds"`
+	}
+)
+
+// handleClockSkew responds with the agents whose clocks differ from the clock of the master by
+// more than the threshold of the request, largest skew first.
+func (a *agents) handleClockSkew(ctx *actor.Context, apiCtx echo.Context) error {
+	threshold := DefaultClockSkewThreshold
+	if raw := apiCtx.QueryParam("threshold"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				"threshold must be a non-negative duration, e.g., 500ms")
+		}
+		threshold = parsed
+	}
+
+	skewed := []ClockSkew{}
+	for _, resp := range ctx.AskAll(getClockSkew{}, ctx.Children()...).GetAll() {
+		if skew, ok := resp.(ClockSkew); ok && abs(skew.duration()) > threshold {
+			skewed = append(skewed, skew)
+		}
+	}
+	sort.Slice(skewed, func(i, j int) bool {
+		return abs(skewed[i].duration()) > abs(skewed[j].duration())
+	})
+	return apiCtx.JSON(http.StatusOK, struct {
+		Threshold float64     `json:"threshold_seconds"`
+		Agents    []ClockSkew `json:"agents"`
+	}{threshold.Seconds(), skewed})
+}
+
+func (c ClockSkew) duration() time.Duration {
+	return c.AgentTime.Sub(c.MasterTime)
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
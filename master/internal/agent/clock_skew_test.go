@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+func TestMeasureClockSkew(t *testing.T) {
+	system := actor.NewSystem("")
+	ref, _ := system.ActorOf(actor.Addr("agent-1"), actor.ActorFunc(func(ctx *actor.Context) error {
+		if agentTime, ok := ctx.Message().(time.Time); ok {
+			a := &agent{address: "10.0.0.1"}
+			a.measureClockSkew(ctx, agentTime)
+			ctx.Respond(a.clockSkew)
+		}
+		return nil
+	}))
+
+	before := time.Now()
+	skew := system.Ask(ref, before.Add(time.Minute)).Get().(*ClockSkew)
+	assert.Equal(t, skew.AgentID, "agent-1")
+	assert.Equal(t, skew.Address, "10.0.0.1")
+	assert.Assert(t, !skew.MasterTime.Before(before))
+	assert.Equal(t, skew.Skew, skew.duration().Seconds())
+	assert.Assert(t, skew.duration() <= time.Minute && skew.duration() > 59*time.Second)
+
+	// Agents that do not report their clocks have no skew.
+	assert.Assert(t, system.Ask(ref, time.Time{}).Get().(*ClockSkew) == nil)
+}
+
+// clockSkewResponse is the body of GET /agents/clock_skew.
+type clockSkewResponse struct {
+	Threshold float64     `json:"threshold_seconds"`
+	Agents    []ClockSkew `json:"agents"`
+}
+
+func startClockSkewAgents(t *testing.T, skews map[string]*time.Duration) *actor.Ref {
+	system := actor.NewSystem("")
+	ref, _ := system.ActorOf(actor.Addr("agents"), &agents{versions: map[*actor.Ref]string{}})
+	masterTime := time.Date(2020, 11, 20, 12, 0, 0, 0, time.UTC)
+	for id, skew := range skews {
+		id, skew := id, skew
+		_, created := system.ActorOf(actor.Addr("agents", id), actor.ActorFunc(
+			func(ctx *actor.Context) error {
+				// Like agents that do not report their clocks, agents without a skew do not answer.
+				if _, ok := ctx.Message().(getClockSkew); ok && skew != nil {
+					ctx.Respond(ClockSkew{
+						AgentID:    id,
+						AgentTime:  masterTime.Add(*skew),
+						MasterTime: masterTime,
+						Skew:       skew.Seconds(),
+					})
+				}
+				return nil
+			}))
+		assert.Assert(t, created)
+	}
+	return ref
+}
+
+func getClockSkews(ref *actor.Ref, query string) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest(http.MethodGet, clockSkewPath+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetPath(clockSkewPath)
+	resp := ref.System().Ask(ref, c).Get()
+	if resp == nil {
+		return rec, nil
+	}
+	return rec, resp.(error)
+}
+
+func TestClockSkewThreshold(t *testing.T) {
+	duration := func(d time.Duration) *time.Duration { return &d }
+	ref := startClockSkewAgents(t, map[string]*time.Duration{
+		"ahead":   duration(10 * time.Second),
+		"behind":  duration(-20 * time.Second),
+		"close":   duration(time.Second),
+		"unknown": nil,
+		"in-sync": duration(0),
+	})
+
+	agentIDs := func(query string) (float64, []string) {
+		rec, err := getClockSkews(ref, query)
+		assert.NilError(t, err)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		var resp clockSkewResponse
+		assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		ids := []string{}
+		for _, skew := range resp.Agents {
+			ids = append(ids, skew.AgentID)
+		}
+		return resp.Threshold, ids
+	}
+
+	// Agents are listed by the size of their skews, whether they are ahead or behind.
+	threshold, ids := agentIDs("")
+	assert.Equal(t, threshold, DefaultClockSkewThreshold.Seconds())
+	assert.DeepEqual(t, ids, []string{"behind", "ahead"})
+
+	threshold, ids = agentIDs("?threshold=500ms")
+	assert.Equal(t, threshold, 0.5)
+	assert.DeepEqual(t, ids, []string{"behind", "ahead", "close"})
+
+	// Skews equal to the threshold are tolerated.
+	_, ids = agentIDs("?threshold=10s")
+	assert.DeepEqual(t, ids, []string{"behind"})
+
+	_, ids = agentIDs("?threshold=1m")
+	assert.DeepEqual(t, ids, []string{})
+}
+
+func TestClockSkewInvalidThreshold(t *testing.T) {
+	ref := startClockSkewAgents(t, nil)
+	for _, threshold := range []string{"5", "-1s", "soon"} {
+		_, err := getClockSkews(ref, "?threshold="+threshold)
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok, threshold)
+		assert.Equal(t, httpErr.Code, http.StatusBadRequest)
+	}
+}
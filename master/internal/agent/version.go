@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/version"
+)
+
+// These are the actions that the master takes on agents whose versions it does not support.
+const (
+	WarnUnsupportedVersions   = "warn"
+	RejectUnsupportedVersions = "reject"
+)
+
+// maxCloseReasonBytes is the most bytes that the reason of a websocket close frame may hold.
+const maxCloseReasonBytes = 123
+
+// VersionPolicy is what the master does with agents whose versions it does not support.
+type VersionPolicy struct {
+	// MinVersion and MaxVersion bound the versions of agents that the master supports. They default
+	// to version.MinAgentVersion and the version of the master.
+	MinVersion string `json:"min_version"`
+	MaxVersion string `json:"max_version"`
+	// Action is WarnUnsupportedVersions to log agents with unsupported versions and let them
+	// connect, or RejectUnsupportedVersions to refuse them.
+	Action string `json:"action"`
+}
+
+// Validate implements the check.Validatable interface.
+func (p VersionPolicy) Validate() []error {
+	errs := []error{
+		check.In(p.Action, []string{WarnUnsupportedVersions, RejectUnsupportedVersions},
+			"agent_versions.action must be warn or reject"),
+	}
+	for _, v := range []string{p.MinVersion, p.MaxVersion} {
+		if v == "" {
+			continue
+		}
+		if _, err := version.Compare(v, v); err != nil {
+			errs = append(errs, errors.Wrap(err, "invalid agent_versions bound"))
+		}
+	}
+	return errs
+}
+
+var versionPolicy atomic.Value
+
+func init() {
+	versionPolicy.Store(VersionPolicy{Action: WarnUnsupportedVersions})
+}
+
+// SetVersionPolicy sets what the master does with agents whose versions it does not support.
+func SetVersionPolicy(p VersionPolicy) {
+	versionPolicy.Store(p)
+}
+
+func currentVersionPolicy() VersionPolicy {
+	return versionPolicy.Load().(VersionPolicy)
+}
+
+// checkVersion returns why the master does not support an agent of the given version, or nil if it
+// does. Versions that cannot be compared, such as those of development builds, are supported.
+func (p VersionPolicy) checkVersion(agentVersion string) *aproto.AgentVersionRejection {
+	minVersion, maxVersion := p.MinVersion, p.MaxVersion
+	if minVersion == "" {
+		minVersion = version.MinAgentVersion
+	}
+	if maxVersion == "" && version.Version != version.Unset {
+		maxVersion = version.Version
+	}
+	rejection := &aproto.AgentVersionRejection{
+		AgentVersion: agentVersion, MinVersion: minVersion, MaxVersion: maxVersion,
+	}
+	if cmp, err := version.Compare(agentVersion, minVersion); err == nil && cmp < 0 {
+		rejection.Upgrade = "agent"
+		return rejection
+	}
+	if maxVersion == "" {
+		return nil
+	}
+	if cmp, err := version.Compare(agentVersion, maxVersion); err == nil && cmp > 0 {
+		rejection.Upgrade = "master"
+		return rejection
+	}
+	return nil
+}
+
+// GetVersionCounts asks the agents actor for the number of connected agents of each version.
+type GetVersionCounts struct{}
+
+// agentVersionReported tells the agents actor the version of an agent that reported it only once
+// it started.
+type agentVersionReported struct {
+	version string
+}
+
+// admitVersion applies the version policy to an agent, logging agents whose versions are not
+// supported, and returns the rejection if the agent must be refused.
+func admitVersion(
+	ctx *actor.Context, agentID, agentVersion string,
+) *aproto.AgentVersionRejection {
+	policy := currentVersionPolicy()
+	rejection := policy.checkVersion(agentVersion)
+	switch {
+	case rejection == nil:
+		return nil
+	case policy.Action == RejectUnsupportedVersions:
+		ctx.Log().Warnf("rejecting agent %s: %s", agentID, describeRejection(*rejection))
+		return rejection
+	default:
+		ctx.Log().Warnf("agent %s: %s", agentID, describeRejection(*rejection))
+		return nil
+	}
+}
+
+// describeRejection explains to the operator why an agent version is not supported.
+func describeRejection(r aproto.AgentVersionRejection) string {
+	supported := fmt.Sprintf("at least %s", r.MinVersion)
+	if r.MaxVersion != "" {
+		supported = fmt.Sprintf("between %s and %s", r.MinVersion, r.MaxVersion)
+	}
+	return fmt.Sprintf("agent version %s is not supported by the master, which supports agents %s; "+
+		"upgrade the %s", r.AgentVersion, supported, r.Upgrade)
+}
+
+// closeReason encodes a rejection as the reason of a websocket close frame, falling back to a
+// shorter text if the versions are too long to fit.
+func closeReason(r aproto.AgentVersionRejection) string {
+	if reason, err := json.Marshal(r); err == nil && len(reason) <= maxCloseReasonBytes {
+		return string(reason)
+	}
+	return fmt.Sprintf("unsupported agent version; upgrade the %s", r.Upgrade)
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/version"
+)
+
+func TestCheckVersion(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	version.Version = "0.13.8"
+
+	policy := VersionPolicy{MinVersion: "0.13.5", Action: RejectUnsupportedVersions}
+	assert.Assert(t, policy.checkVersion("0.13.5") == nil)
+	assert.Assert(t, policy.checkVersion("0.13.8rc1") == nil)
+	assert.Assert(t, policy.checkVersion("unknown") == nil)
+	assert.DeepEqual(t, policy.checkVersion("0.13.4"), &aproto.AgentVersionRejection{
+		AgentVersion: "0.13.4", MinVersion: "0.13.5", MaxVersion: "0.13.8", Upgrade: "agent",
+	})
+	assert.Equal(t, policy.checkVersion("0.14.0").Upgrade, "master")
+
+	// Development builds of the master support agents of any newer version.
+	version.Version = version.Unset
+	assert.Assert(t, policy.checkVersion("0.14.0") == nil)
+}
+
+func TestCloseReason(t *testing.T) {
+	rejection := aproto.AgentVersionRejection{
+		AgentVersion: "0.12.3", MinVersion: "0.13.0", MaxVersion: "0.13.8", Upgrade: "agent",
+	}
+	var decoded aproto.AgentVersionRejection
+	assert.NilError(t, json.Unmarshal([]byte(closeReason(rejection)), &decoded))
+	assert.DeepEqual(t, decoded, rejection)
+
+	rejection.AgentVersion = "0.12.3.dev0+" + strings.Repeat("0", 100)
+	reason := closeReason(rejection)
+	assert.Assert(t, len(reason) <= maxCloseReasonBytes)
+	assert.Equal(t, reason, "unsupported agent version; upgrade the agent")
+}
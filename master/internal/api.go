@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -158,6 +159,28 @@ func (a *apiServer) actorRequest(addr string, req actor.Message, v interface{})
 		return status.Errorf(codes.InvalidArgument, "/api/v1%s is not a valid path", addr)
 	}
 	resp := a.m.system.AskAt(actorAddr, req)
+	return a.processActorResponse(resp, v)
+}
+
+// actorRequestWithTimeout is like actorRequest, but gives up on the actor if it does not respond
+// within the configured ask timeout or timeout, whichever is longer.
+func (a *apiServer) actorRequestWithTimeout(
+	addr string, req actor.Message, v interface{}, timeout time.Duration,
+) error {
+	actorAddr := actor.Address{}
+	if err := actorAddr.UnmarshalText([]byte(addr)); err != nil {
+		return status.Errorf(codes.InvalidArgument, "/api/v1%s is not a valid path", addr)
+	}
+	resp := a.m.system.AskAt(actorAddr, req)
+	if resp.Source() != nil {
+		if _, err := a.awaitResponse(resp, timeout); err != nil {
+			return err
+		}
+	}
+	return a.processActorResponse(resp, v)
+}
+
+func (a *apiServer) processActorResponse(resp actor.Response, v interface{}) error {
 	if err := api.ProcessActorResponseError(&resp); err != nil {
 		return err
 	}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// AccessLogConfig configures the log of the requests that the master serves.
+type AccessLogConfig struct {
+	// Enabled logs each request with its method, path, status, duration and request ID.
+	Enabled bool `json:"enabled"`
+	// Level is the level at which requests are logged; it defaults to "info".
+	Level string `json:"level"`
+	// SilencePaths are paths whose requests are never logged, such as health checks and metrics
+	// polls. Paths match either the path of a request or its route, e.g.,
+	// "/experiments/:experiment_id", and a path that ends in "*" matches every path that starts
+	// with the rest of it.
+	SilencePaths []string `json:"silence_paths"`
+	// PathLevels overrides Level for the requests to the given paths, which match like
+	// SilencePaths. The longest matching path applies.
+	PathLevels map[string]string `json:"path_levels"`
+}
+
+// Validate implements the check.Validatable interface.
+func (a AccessLogConfig) Validate() []error {
+	var errs []error
+	if a.Level != "" {
+		if _, err := log.ParseLevel(a.Level); err != nil {
+			errs = append(errs, errors.Wrap(err, "access_log.level must be a log level"))
+		}
+	}
+	for path, level := range a.PathLevels {
+		if _, err := log.ParseLevel(level); err != nil {
+			errs = append(errs, errors.Wrapf(err,
+				"access_log.path_levels[%q] must be a log level", path))
+		}
+	}
+	return errs
+}
+
+// level returns the level at which requests to the given path and route are logged, and false if
+// they are not logged.
+func (a AccessLogConfig) level(path, route string) (log.Level, bool) {
+	for _, pattern := range a.SilencePaths {
+		if matchAccessLogPath(pattern, path, route) {
+			return 0, false
+		}
+	}
+	level, best := a.Level, ""
+	for pattern, patternLevel := range a.PathLevels {
+		if matchAccessLogPath(pattern, path, route) && len(pattern) > len(best) {
+			level, best = patternLevel, pattern
+		}
+	}
+	if level == "" {
+		return log.InfoLevel, true
+	}
+	parsed, err := log.ParseLevel(level)
+	if err != nil {
+		return log.InfoLevel, true
+	}
+	return parsed, true
+}
+
+// matchAccessLogPath returns whether a pattern of the access log config matches the path or route
+// of a request.
+func matchAccessLogPath(pattern, path, route string) bool {
+	if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+		return strings.HasPrefix(path, prefix) || strings.HasPrefix(route, prefix)
+	}
+	return pattern == path || pattern == route
+}
+
+// AccessLog logs the requests that the master serves, as configured by the config that the given
+// function returns for each request, so that changes to it take effect right away. The error of a
+// request is handled here, so that its status is logged, rather than by the caller.
+func AccessLog(config func() AccessLogConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			conf := config()
+			if !conf.Enabled {
+				return next(c)
+			}
+			start := time.Now()
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			req := c.Request()
+			level, ok := conf.level(req.URL.Path, c.Path())
+			if !ok {
+				return nil
+			}
+			// Queries are left out, since they can hold secrets such as tokens.
+			log.WithContext(req.Context()).WithFields(log.Fields{
+				"method":    req.Method,
+				"path":      req.URL.Path,
+				"status":    c.Response().Status,
+				"bytes":     c.Response().Size,
+				"duration":  time.Since(start).String(),
+				"remote_ip": c.RealIP(),
+			}).Log(level, fmt.Sprintf("%s %s %d", req.Method, req.URL.Path, c.Response().Status))
+			return nil
+		}
+	}
+}
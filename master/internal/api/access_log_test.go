@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"gotest.tools/assert"
+)
+
+func TestAccessLogConfigLevel(t *testing.T) {
+	config := AccessLogConfig{
+		Level:        "debug",
+		SilencePaths: []string{"/info", "/agents*"},
+		PathLevels: map[string]string{
+			"/experiments*":                 "info",
+			"/experiments/:experiment_id/*": "warning",
+		},
+	}
+	tests := []struct {
+		path   string
+		route  string
+		level  log.Level
+		logged bool
+	}{
+		{"/info", "/info", 0, false},
+		{"/agents/a1/slots", "/agents/:agent_id/slots", 0, false},
+		{"/trials/1", "/trials/:trial_id", log.DebugLevel, true},
+		{"/experiments", "/experiments", log.InfoLevel, true},
+		{"/experiments/1/kill", "/experiments/:experiment_id/kill", log.WarnLevel, true},
+	}
+	for _, tc := range tests {
+		level, logged := config.level(tc.path, tc.route)
+		assert.Equal(t, logged, tc.logged, tc.path)
+		assert.Equal(t, level, tc.level, tc.path)
+	}
+
+	assert.Equal(t, len(AccessLogConfig{}.Validate()), 0)
+	assert.Equal(t, len(AccessLogConfig{
+		Level: "loud", PathLevels: map[string]string{"/info": "quiet"},
+	}.Validate()), 2)
+}
+
+func TestAccessLog(t *testing.T) {
+	hook := test.NewGlobal()
+	defer log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+
+	config := AccessLogConfig{Enabled: true, SilencePaths: []string{"/info"}}
+	e := echo.New()
+	e.HTTPErrorHandler = JSONErrorHandler
+	e.Use(AccessLog(func() AccessLogConfig { return config }))
+	e.GET("/info", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/items/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "no such item")
+	})
+
+	send := func(path string) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, send("/info"), http.StatusOK)
+	assert.Equal(t, len(hook.AllEntries()), 0)
+
+	// Errors are handled before they are logged, so that the log has their status.
+	assert.Equal(t, send("/items/1?token=secret"), http.StatusNotFound)
+	assert.Equal(t, len(hook.AllEntries()), 1)
+	entry := hook.LastEntry()
+	assert.Equal(t, entry.Level, log.InfoLevel)
+	assert.Equal(t, entry.Message, "GET /items/1 404")
+	assert.Equal(t, entry.Data["path"], "/items/1")
+	assert.Equal(t, entry.Data["status"], http.StatusNotFound)
+
+	config.Enabled = false
+	assert.Equal(t, send("/items/1"), http.StatusNotFound)
+	assert.Equal(t, len(hook.AllEntries()), 1)
+}
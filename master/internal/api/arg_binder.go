@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
@@ -15,7 +16,38 @@ var parsers = map[reflect.Kind]func(v string) (interface{}, error){
 	reflect.Bool:   func(v string) (interface{}, error) { return strconv.ParseBool(v) },
 }
 
-// BindArgs binds path and query parameters in the context to struct fields.
+// typeParsers parse the values of types whose kind alone does not say how to parse them. Their
+// errors are reported as they are, so they should name the expected format.
+var typeParsers = map[reflect.Type]func(v string) (interface{}, error){
+	reflect.TypeOf(time.Time{}):      parseTime,
+	reflect.TypeOf(time.Duration(0)): parseDuration,
+}
+
+// parseTime parses an RFC 3339 time or a number of seconds since the Unix epoch. Times are
+// returned in UTC, whatever time zone they were given in.
+func parseTime(v string) (interface{}, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return nil, errors.Errorf(
+		"%q is not an RFC 3339 time, e.g., 2020-10-01T12:00:00Z, or Unix time in seconds", v)
+}
+
+// parseDuration parses a Go duration string.
+func parseDuration(v string) (interface{}, error) {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return nil, errors.Errorf("%q is not a duration, e.g., 90s, 30m or 1h30m", v)
+	}
+	return d, nil
+}
+
+// BindArgs binds path and query parameters in the context to struct fields. Besides strings, ints
+// and bools, fields may be times, given as RFC 3339 times or Unix times in seconds, and durations,
+// given as Go duration strings.
 func BindArgs(i interface{}, c echo.Context) error {
 	v := reflect.ValueOf(i).Elem()
 	for index := 0; index < v.Type().NumField(); index++ {
@@ -43,11 +75,20 @@ func bindValue(name string, t reflect.Type, f reflect.Value, value string) error
 		return errors.Errorf("missing parameter: %s", name)
 	}
 
-	parser, ok := parsers[t.Kind()]
+	elem := t
 	if t.Kind() == reflect.Ptr {
 		// Use the parser of the underlying type of the pointer.
-		parser, ok = parsers[t.Elem().Kind()]
+		elem = t.Elem()
+	}
+	if parser, ok := typeParsers[elem]; ok {
+		parsed, err := parser(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid parameter %s", name)
+		}
+		setValue(t, f, parsed)
+		return nil
 	}
+	parser, ok := parsers[elem.Kind()]
 	if !ok {
 		return errors.Errorf("no parser found for kind: %v", t.Kind())
 	}
@@ -55,7 +96,11 @@ func bindValue(name string, t reflect.Type, f reflect.Value, value string) error
 	if err != nil {
 		return errors.Wrapf(err, "unable to parse to %v: %s", t.Kind(), value)
 	}
+	setValue(t, f, parsed)
+	return nil
+}
 
+func setValue(t reflect.Type, f reflect.Value, parsed interface{}) {
 	if t.Kind() == reflect.Ptr {
 		// Create a pointer and set its value to the parsed value.
 		f.Set(reflect.New(t.Elem()))
@@ -63,5 +108,4 @@ func bindValue(name string, t reflect.Type, f reflect.Value, value string) error
 	} else {
 		f.Set(reflect.ValueOf(parsed))
 	}
-	return nil
 }
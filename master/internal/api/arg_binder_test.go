@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+)
+
+type timeArgs struct {
+	Since  *time.Time     `query:"since"`
+	Until  time.Time      `query:"until"`
+	Period *time.Duration `query:"period"`
+}
+
+func bindTimeArgs(query string) (timeArgs, error) {
+	req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	var args timeArgs
+	err := BindArgs(&args, echo.New().NewContext(req, httptest.NewRecorder()))
+	return args, err
+}
+
+func TestBindArgsTimes(t *testing.T) {
+	expected := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	for _, value := range []string{
+		"2020-10-01T12:00:00Z",
+		"2020-10-01T14:00:00%2B02:00",
+		"2020-10-01T07:00:00-05:00",
+		"1601553600",
+	} {
+		args, err := bindTimeArgs("since=" + value + "&until=" + value)
+		assert.NilError(t, err, value)
+		// Times are converted to UTC, so that they compare equal whatever zone they were given in.
+		assert.Equal(t, *args.Since, expected, value)
+		assert.Equal(t, args.Until, expected, value)
+		assert.Equal(t, args.Since.Location(), time.UTC, value)
+	}
+
+	args, err := bindTimeArgs("until=2020-10-01T12:00:00.5Z")
+	assert.NilError(t, err)
+	assert.Equal(t, args.Until, expected.Add(500*time.Millisecond))
+	assert.Assert(t, args.Since == nil)
+	assert.Assert(t, args.Period == nil)
+}
+
+func TestBindArgsDurations(t *testing.T) {
+	args, err := bindTimeArgs("until=0&period=1h30m")
+	assert.NilError(t, err)
+	assert.Equal(t, *args.Period, 90*time.Minute)
+	assert.Equal(t, args.Until, time.Unix(0, 0).UTC())
+}
+
+func TestBindArgsInvalidTimes(t *testing.T) {
+	for query, message := range map[string]string{
+		"until=yesterday":  `invalid parameter until: "yesterday" is not an RFC 3339 time`,
+		"until=2020-10-01": `invalid parameter until: "2020-10-01" is not an RFC 3339 time`,
+		"until=1.5":        `invalid parameter until: "1.5" is not an RFC 3339 time`,
+		"until=0&since=2020-10-01T12:00:00": `invalid parameter since: ` +
+			`"2020-10-01T12:00:00" is not an RFC 3339 time`,
+		"until=0&period=5":   `invalid parameter period: "5" is not a duration`,
+		"until=0&period=1d":  `invalid parameter period: "1d" is not a duration`,
+		"since=1601553600":   "missing parameter: until",
+		"until=0&period=1h!": `invalid parameter period: "1h!" is not a duration`,
+	} {
+		_, err := bindTimeArgs(query)
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok, query)
+		assert.Equal(t, httpErr.Code, http.StatusBadRequest, query)
+		assert.ErrorContains(t, err, message, query)
+	}
+}
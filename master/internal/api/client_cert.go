@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+
+	"github.com/soheilhy/cmux"
+)
+
+type clientCertificateKey struct{}
+
+// ConnContext records the verified client certificate of a TLS connection in the context of its
+// requests. It is needed because the master wraps its TLS connections to multiplex them, which
+// hides their TLS state from net/http.
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if muxConn, ok := conn.(*cmux.MuxConn); ok {
+		conn = muxConn.Conn
+	}
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return ctx
+	}
+	// The handshake is done by the time connections are matched, since that reads from them.
+	state := tlsConn.ConnectionState()
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, clientCertificateKey{}, state.VerifiedChains[0][0])
+}
+
+// VerifiedClientCertificate returns the client certificate that the TLS connection of a request
+// was verified with, or nil if there is none.
+func VerifiedClientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return r.TLS.VerifiedChains[0][0]
+	}
+	cert, _ := r.Context().Value(clientCertificateKey{}).(*x509.Certificate)
+	return cert
+}
@@ -7,7 +7,8 @@ import (
 	"github.com/labstack/echo"
 )
 
-// JSONErrorHandler sends a JSON response with a single "message" key containing the error message.
+// JSONErrorHandler sends a JSON response with a "message" key containing the error message and,
+// if the request has an ID, a "request_id" key containing it.
 func JSONErrorHandler(err error, c echo.Context) {
 	// Default to a 500 internal server error unless the endpoint explicitly returns otherwise.
 	var (
@@ -26,7 +27,11 @@ func JSONErrorHandler(err error, c echo.Context) {
 		if c.Request().Method == echo.HEAD {
 			err = c.NoContent(code)
 		} else {
-			err = c.JSON(code, map[string]interface{}{"message": fmt.Sprint(msg)})
+			body := map[string]interface{}{"message": fmt.Sprint(msg)}
+			if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+				body["request_id"] = id
+			}
+			err = c.JSON(code, body)
 		}
 		// Log the error returned from formatting the error response.
 		if err != nil {
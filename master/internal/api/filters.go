@@ -10,6 +10,8 @@ const (
 	FilterOperationGreaterThan
 	// FilterOperationLessThan checks if the field is less than a value.
 	FilterOperationLessThan
+	// FilterOperationGreaterThanOrEqual checks if the field is greater than or equal to a value.
+	FilterOperationGreaterThanOrEqual
 )
 
 // Filter is a general representation for a filter provided to an API.
@@ -10,9 +10,16 @@ import (
 )
 
 var (
-	upgrader = websocket.Upgrader{}
+	upgrader = websocket.Upgrader{EnableCompression: true}
 )
 
+// StatusResponse is a handler result that Route sends with a status other than 200 OK, such as
+// 201 Created or 202 Accepted. A nil body is sent as no content.
+type StatusResponse struct {
+	Code int
+	Body interface{}
+}
+
 // Route returns an echo compatible handler for JSON requests.
 func Route(handler func(c echo.Context) (interface{}, error)) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -27,11 +34,19 @@ func Route(handler func(c echo.Context) (interface{}, error)) echo.HandlerFunc {
 			}
 			return err
 		}
+		if c.Response().Committed {
+			return nil
+		}
 		if result == nil {
 			return c.NoContent(http.StatusNoContent)
 		}
 
 		switch typed := result.(type) {
+		case StatusResponse:
+			if typed.Body == nil {
+				return c.NoContent(typed.Code)
+			}
+			return c.JSON(typed.Code, typed.Body)
 		case []byte:
 			return c.JSONBlob(http.StatusOK, typed)
 		default:
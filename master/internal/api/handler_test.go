@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+)
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		result func(c echo.Context) interface{}
+		code   int
+		body   string
+	}{
+		{"no content", func(echo.Context) interface{} { return nil }, http.StatusNoContent, ""},
+		{"object", func(echo.Context) interface{} {
+			return map[string]int{"id": 1}
+		}, http.StatusOK, `{"id":1}`},
+		{"bytes", func(echo.Context) interface{} {
+			return []byte(`[1]`)
+		}, http.StatusOK, `[1]`},
+		{"created", func(echo.Context) interface{} {
+			return StatusResponse{Code: http.StatusCreated, Body: map[string]int{"id": 1}}
+		}, http.StatusCreated, `{"id":1}`},
+		{"accepted", func(echo.Context) interface{} {
+			return StatusResponse{Code: http.StatusAccepted}
+		}, http.StatusAccepted, ""},
+		// Responses that handlers wrote themselves are not written again.
+		{"written", func(c echo.Context) interface{} {
+			assert.NilError(t, c.String(http.StatusAccepted, "queued"))
+			return nil
+		}, http.StatusAccepted, "queued"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+			assert.NilError(t, Route(func(c echo.Context) (interface{}, error) {
+				return tc.result(c), nil
+			})(c))
+			assert.Equal(t, rec.Code, tc.code)
+			assert.Equal(t, strings.TrimSpace(rec.Body.String()), tc.body)
+		})
+	}
+}
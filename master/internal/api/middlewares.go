@@ -1,8 +1,18 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/version"
 )
 
 // CORSWithTargetedOrigin builds on labstack/echo CORS by dynamically setting the origin header to
@@ -18,3 +28,119 @@ func CORSWithTargetedOrigin(next echo.HandlerFunc) echo.HandlerFunc {
 		return middleware.CORSWithConfig(config)(next)(c)
 	}
 }
+
+// RequestID gives each request an ID, which is the one from the X-Request-ID header of the request
+// if the client sent one, or a new UUID. The ID is set on the DetContext, so that logs about the
+// request include it, and returned in the X-Request-ID header of the response, so that reports of
+// failed requests can be matched to the logs of the master. It must run after the middleware
+// that sets up the DetContext.
+func RequestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Request().Header.Get(echo.HeaderXRequestID)
+		if !logger.ValidRequestID(id) {
+			id = uuid.New().String()
+		}
+		// The gRPC gateway forwards the ID to gRPC handlers from the request header.
+		c.Request().Header.Set(echo.HeaderXRequestID, id)
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		if dc, ok := c.(*context.DetContext); ok {
+			dc.SetRequestID(id)
+		}
+		return next(c)
+	}
+}
+
+// Headers with which clients report their version and are warned that it is too old.
+const (
+	ClientVersionHeader  = "X-Determined-Client-Version"
+	VersionWarningHeader = "X-Determined-Version-Warning"
+)
+
+// ClientVersionWarning warns clients that report a version older than minVersion, in the
+// X-Determined-Client-Version header, that they may not work with the master. Their requests are
+// served as usual, but responses have an X-Determined-Version-Warning header, and the first
+// request from each such major and minor version is logged. The header is set by clients, so only
+// the major and minor version parsed from it are echoed back or remembered.
+func ClientVersionWarning(minVersion string) echo.MiddlewareFunc {
+	var warned sync.Map
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			clientVersion := c.Request().Header.Get(ClientVersionHeader)
+			if clientVersion == "" {
+				return next(c)
+			}
+			if cmp, err := version.Compare(clientVersion, minVersion); err != nil || cmp >= 0 {
+				return next(c)
+			}
+			release, err := version.MajorMinor(clientVersion)
+			if err != nil {
+				return next(c)
+			}
+			warning := fmt.Sprintf("client version %s is older than the minimum version %s "+
+				"supported by the master; please upgrade", release, minVersion)
+			c.Response().Header().Set(VersionWarningHeader, warning)
+			if _, loaded := warned.LoadOrStore(release, true); !loaded {
+				c.Logger().Warnf("%s (from %s)", warning, c.RealIP())
+			}
+			return next(c)
+		}
+	}
+}
+
+// StaticHeaders sets the given headers, keyed by name, in every response.
+func StaticHeaders(headers map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for name, value := range headers {
+				c.Response().Header().Set(name, value)
+			}
+			return next(c)
+		}
+	}
+}
+
+// TrailingSlashConfig lists the routes that serve web pages rather than APIs.
+type TrailingSlashConfig struct {
+	// WebRoutes are the paths under which web pages are served. Requests for one of them are
+	// redirected to it with a trailing slash, so that relative links in its pages resolve under it,
+	// and paths under them are left as they are.
+	WebRoutes []string
+}
+
+// TrailingSlashes normalizes the trailing slashes of request paths before they are routed. Web
+// routes get a trailing slash, as described by the config. Trailing slashes are removed from every
+// other path, without a redirect, so that API clients reach the same route whether or not they
+// send one.
+func TrailingSlashes(config TrailingSlashConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			path := req.URL.Path
+			for _, route := range config.WebRoutes {
+				if path == route {
+					dest := path + "/"
+					if qs := c.QueryString(); qs != "" {
+						dest += "?" + qs
+					}
+					return c.Redirect(http.StatusMovedPermanently, dest)
+				}
+			}
+			for _, route := range config.WebRoutes {
+				if strings.HasPrefix(path, route+"/") {
+					return next(c)
+				}
+			}
+
+			if trimmed := strings.TrimRight(path, "/"); trimmed != path && trimmed != "" {
+				uri := trimmed
+				if qs := c.QueryString(); qs != "" {
+					uri += "?" + qs
+				}
+				req.URL.Path = trimmed
+				req.URL.RawPath = ""
+				req.RequestURI = uri
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+func TestTrailingSlashes(t *testing.T) {
+	e := echo.New()
+	e.Pre(TrailingSlashes(TrailingSlashConfig{WebRoutes: []string{"/web", "/web/docs"}}))
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Request().URL.String())
+	}
+	e.GET("/api/items", handler)
+	e.GET("/web/", handler)
+	e.GET("/web/*", handler)
+
+	tests := []struct {
+		path     string
+		code     int
+		expected string
+	}{
+		{"/api/items", http.StatusOK, "/api/items"},
+		{"/api/items/", http.StatusOK, "/api/items"},
+		{"/api/items//?limit=1", http.StatusOK, "/api/items?limit=1"},
+		{"/web?q=1", http.StatusMovedPermanently, "/web/?q=1"},
+		{"/web/docs", http.StatusMovedPermanently, "/web/docs/"},
+		{"/web/", http.StatusOK, "/web/"},
+		{"/web/docs/", http.StatusOK, "/web/docs/"},
+		{"/web/page/", http.StatusOK, "/web/page/"},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, rec.Code, tc.code, tc.path)
+		if tc.code == http.StatusMovedPermanently {
+			assert.Equal(t, rec.Header().Get(echo.HeaderLocation), tc.expected, tc.path)
+		} else {
+			assert.Equal(t, rec.Body.String(), tc.expected, tc.path)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = JSONErrorHandler
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return next(&context.DetContext{Context: c})
+		}
+	})
+	e.Use(RequestID)
+	e.GET("/fail", func(c echo.Context) error {
+		id, ok := logger.RequestID(c.Request().Context())
+		assert.Assert(t, ok)
+		assert.Equal(t, id, c.(*context.DetContext).RequestID())
+		return echo.NewHTTPError(http.StatusBadRequest, "bad request")
+	})
+
+	send := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+		if id != "" {
+			req.Header.Set(echo.HeaderXRequestID, id)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("client-id.1")
+	assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), "client-id.1")
+	assert.Equal(t, rec.Body.String(),
+		`{"message":"bad request","request_id":"client-id.1"}`)
+
+	for _, id := range []string{"", "bad id\n"} {
+		rec = send(id)
+		generated := rec.Header().Get(echo.HeaderXRequestID)
+		assert.Equal(t, len(generated), 36, "generated ID %q", generated)
+		assert.Assert(t, strings.Contains(rec.Body.String(), generated))
+	}
+}
+
+func TestCacheStatic(t *testing.T) {
+	e := echo.New()
+	e.Use(CacheStatic())
+	script := strings.Repeat("console.log('determined');\n", 100)
+	e.GET("/static/main.3f2a9c1b.chunk.js", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "application/javascript", []byte(script))
+	})
+	// Like the WebUI, serve the index page in place of a missing asset.
+	e.GET("/static/missing.0123abcd.js", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, "<html></html>")
+	})
+
+	send := func(path, ifNoneMatch, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		req.Header.Set(echo.HeaderAcceptEncoding, acceptEncoding)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("/static/main.3f2a9c1b.chunk.js", "", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Body.String(), script)
+	assert.Equal(t, rec.Header().Get("Cache-Control"), immutableCacheControl)
+	etag := rec.Header().Get("ETag")
+	assert.Assert(t, etag != "")
+
+	rec = send("/static/main.3f2a9c1b.chunk.js", etag, "")
+	assert.Equal(t, rec.Code, http.StatusNotModified)
+	assert.Equal(t, rec.Body.Len(), 0)
+
+	rec = send("/static/main.3f2a9c1b.chunk.js", etag, "gzip, deflate")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get(echo.HeaderContentEncoding), "gzip")
+	assert.Assert(t, rec.Header().Get("ETag") != etag)
+	zr, err := gzip.NewReader(rec.Body)
+	assert.NilError(t, err)
+	unzipped, err := ioutil.ReadAll(zr)
+	assert.NilError(t, err)
+	assert.Equal(t, string(unzipped), script)
+
+	rec = send("/static/main.3f2a9c1b.chunk.js", "", "gzip;q=0")
+	assert.Equal(t, rec.Header().Get(echo.HeaderContentEncoding), "")
+
+	rec = send("/static/missing.0123abcd.js", "", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Cache-Control"), revalidateCacheControl)
+}
+
+func TestClientVersionWarning(t *testing.T) {
+	e := echo.New()
+	e.Use(ClientVersionWarning("0.13.0"))
+	e.GET("/info", func(c echo.Context) error {
+		return c.String(http.StatusOK, "info")
+	})
+
+	for clientVersion, warned := range map[string]bool{
+		"":            false,
+		"0.13.0":      false,
+		"0.13.8.dev0": false,
+		"0.12.13":     true,
+		"unparseable": false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/info", nil)
+		req.Header.Set(ClientVersionHeader, clientVersion)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, rec.Code, http.StatusOK, clientVersion)
+		assert.Equal(t, rec.Header().Get(VersionWarningHeader) != "", warned, clientVersion)
+	}
+
+	// Only the major and minor version are echoed back from the header.
+	req := httptest.NewRequest(http.MethodGet, "/info", nil)
+	req.Header.Set(ClientVersionHeader, "0.12.13.dev0<script>")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Header().Get(VersionWarningHeader), "client version 0.12 is older than "+
+		"the minimum version 0.13.0 supported by the master; please upgrade")
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// immutableCacheControl lets clients keep assets whose names change with their content forever.
+	immutableCacheControl = "public, max-age=31536000, immutable"
+	// revalidateCacheControl makes clients check with the server, using the ETag, before reusing
+	// a cached response.
+	revalidateCacheControl = "no-cache"
+
+	// minGzipSize is the smallest response worth compressing.
+	minGzipSize = 1024
+	// maxGzipCacheEntries bounds how many compressed responses are kept in memory.
+	maxGzipCacheEntries = 512
+)
+
+// hashedAssetPattern matches the names of files that are built with a hash of their content in
+// them, e.g., "main.3f2a9c1b.chunk.js".
+var hashedAssetPattern = regexp.MustCompile(`[.-][0-9a-f]{8,}\.`)
+
+// compressibleTypes are the prefixes of the content types that are worth compressing.
+var compressibleTypes = []string{
+	"text/",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// bufferedWriter holds the response of a handler so that it can be inspected before it is sent.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// CacheStatic makes static files cacheable by clients. Successful GET responses get an ETag
+// computed from their content, and requests with a matching If-None-Match header are answered
+// with 304 Not Modified. Files with a content hash in their name may be cached forever, while
+// everything else, including HTML pages served in place of missing files, must be revalidated.
+// Large text responses are gzipped for clients that accept it.
+func CacheStatic() echo.MiddlewareFunc {
+	var lock sync.Mutex
+	gzipped := map[string][]byte{}
+	compress := func(etag string, body []byte) ([]byte, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if data, ok := gzipped[etag]; ok {
+			return data, nil
+		}
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		if len(gzipped) >= maxGzipCacheEntries {
+			gzipped = map[string][]byte{}
+		}
+		gzipped[etag] = buf.Bytes()
+		return buf.Bytes(), nil
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method != http.MethodGet {
+				return next(c)
+			}
+
+			res := c.Response()
+			w := res.Writer
+			buffered := &bufferedWriter{ResponseWriter: w}
+			res.Writer = buffered
+			err := next(c)
+			res.Writer = w
+			if err != nil || buffered.status == 0 {
+				return err
+			}
+
+			body := buffered.body.Bytes()
+			if buffered.status != http.StatusOK {
+				w.WriteHeader(buffered.status)
+				_, err = w.Write(body)
+				return err
+			}
+
+			header := res.Header()
+			contentType := header.Get(echo.HeaderContentType)
+			if header.Get("Cache-Control") == "" {
+				header.Set("Cache-Control", staticCacheControl(req.URL.Path, contentType))
+			}
+
+			sum := sha256.Sum256(body)
+			etag := hex.EncodeToString(sum[:16])
+			useGzip := false
+			if isCompressible(contentType) && header.Get(echo.HeaderContentEncoding) == "" {
+				header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+				if len(body) >= minGzipSize && AcceptsGzip(req.Header.Get(echo.HeaderAcceptEncoding)) {
+					useGzip = true
+					etag += "-gzip"
+				}
+			}
+			etag = `"` + etag + `"`
+			header.Set("ETag", etag)
+
+			if etagMatches(req.Header.Get("If-None-Match"), etag) {
+				header.Del(echo.HeaderContentLength)
+				w.WriteHeader(http.StatusNotModified)
+				return nil
+			}
+
+			if useGzip {
+				if body, err = compress(etag, body); err != nil {
+					return err
+				}
+				header.Set(echo.HeaderContentEncoding, "gzip")
+				header.Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+			}
+			w.WriteHeader(http.StatusOK)
+			_, err = w.Write(body)
+			return err
+		}
+	}
+}
+
+// staticCacheControl returns the Cache-Control header for a static file. HTML is never cached
+// for good, since the WebUI serves its index page in place of files that don't exist, including
+// ones with hashed names.
+func staticCacheControl(urlPath, contentType string) string {
+	if !strings.HasPrefix(contentType, echo.MIMETextHTML) &&
+		hashedAssetPattern.MatchString(path.Base(urlPath)) {
+		return immutableCacheControl
+	}
+	return revalidateCacheControl
+}
+
+func isCompressible(contentType string) bool {
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsGzip returns whether an Accept-Encoding header allows gzip.
+func AcceptsGzip(acceptEncoding string) bool {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(coding, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") {
+				if weight, err := strconv.ParseFloat(q[2:], 64); err == nil && weight == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// etagMatches returns whether an If-None-Match header matches an ETag, comparing weakly.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -16,9 +16,9 @@ func (a *apiServer) GetAgents(
 ) (resp *apiv1.GetAgentsResponse, err error) {
 	switch {
 	case a.m.system.Get(actor.Addr("agents")) != nil:
-		err = a.actorRequest("/agents", req, &resp)
+		err = a.actorRequestWithTimeout("/agents", req, &resp, agentsAskTimeout)
 	case a.m.system.Get(actor.Addr("pods")) != nil:
-		err = a.actorRequest("/pods", req, &resp)
+		err = a.actorRequestWithTimeout("/pods", req, &resp, agentsAskTimeout)
 	default:
 		err = status.Error(codes.NotFound, "cannot find agents or pods actor")
 	}
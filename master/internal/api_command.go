@@ -29,6 +29,8 @@ type protoCommandParams struct {
 	Config       *pstruct.Struct
 	Files        []*utilv1.File
 	Data         []byte
+	// OverrideMaintenance starts the command even if the cluster is in maintenance mode.
+	OverrideMaintenance bool
 }
 
 // prepareLaunchParams prepares command launch parameters.
@@ -39,6 +41,9 @@ func (a *apiServer) prepareLaunchParams(ctx context.Context, req *protoCommandPa
 	if err != nil {
 		return nil, nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
 	}
+	if err = a.m.checkMaintenance(*user, req.OverrideMaintenance); err != nil {
+		return nil, nil, status.Error(codes.Unavailable, err.Error())
+	}
 
 	cmdParams := command.CommandParams{UserFiles: filesToArchive(req.Files)}
 	if req.TemplateName != "" {
@@ -86,10 +91,11 @@ func (a *apiServer) LaunchCommand(
 	ctx context.Context, req *apiv1.LaunchCommandRequest,
 ) (*apiv1.LaunchCommandResponse, error) {
 	cmdParams, user, err := a.prepareLaunchParams(ctx, &protoCommandParams{
-		TemplateName: req.TemplateName,
-		Config:       req.Config,
-		Files:        req.Files,
-		Data:         req.Data,
+		TemplateName:        req.TemplateName,
+		Config:              req.Config,
+		Files:               req.Files,
+		Data:                req.Data,
+		OverrideMaintenance: req.OverrideMaintenance,
 	})
 	if err != nil {
 		return nil, err
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,11 +14,13 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpc"
 	"github.com/determined-ai/determined/master/internal/lttb"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -63,6 +66,68 @@ func (a *apiServer) checkExperimentExists(id int) error {
 	}
 }
 
+// viewer returns the user whose teams limit the experiments that the user of the request sees, as
+// experimentViewer does.
+func (a *apiServer) viewer(ctx context.Context) (*model.UserID, error) {
+	user, _, err := grpc.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
+	}
+	return experimentViewer(*user), nil
+}
+
+// checkExperimentVisible reports experiments of teams that the user of the request is not a member
+// of as missing.
+func (a *apiServer) checkExperimentVisible(ctx context.Context, id int) error {
+	viewer, err := a.viewer(ctx)
+	if err != nil || viewer == nil {
+		return err
+	}
+	switch visible, err := a.m.db.ExperimentVisible(id, viewer); {
+	case err != nil:
+		return err
+	case !visible:
+		return status.Errorf(codes.NotFound, "experiment %d not found", id)
+	}
+	return nil
+}
+
+// checkTrialVisible reports trials of experiments that the user of the request does not see as
+// missing.
+func (a *apiServer) checkTrialVisible(ctx context.Context, id int) error {
+	viewer, err := a.viewer(ctx)
+	if err != nil || viewer == nil {
+		return err
+	}
+	switch visible, err := a.m.db.TrialVisible(id, viewer); {
+	case err != nil:
+		return err
+	case !visible:
+		return status.Errorf(codes.NotFound, "trial %d not found", id)
+	}
+	return nil
+}
+
+// checkCheckpointVisible reports checkpoints of experiments that the user of the request does not
+// see as missing.
+func (a *apiServer) checkCheckpointVisible(ctx context.Context, rawID string) error {
+	viewer, err := a.viewer(ctx)
+	if err != nil || viewer == nil {
+		return err
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid checkpoint UUID: %s", rawID)
+	}
+	switch visible, err := a.m.db.CheckpointVisible(id, viewer); {
+	case err != nil:
+		return err
+	case !visible:
+		return status.Errorf(codes.NotFound, "checkpoint %s not found", rawID)
+	}
+	return nil
+}
+
 func (a *apiServer) getExperiment(experimentID int) (*experimentv1.Experiment, error) {
 	exp := &experimentv1.Experiment{}
 	switch err := a.m.db.QueryProto("get_experiment", exp, experimentID); {
@@ -76,14 +141,17 @@ func (a *apiServer) getExperiment(experimentID int) (*experimentv1.Experiment, e
 }
 
 func (a *apiServer) GetExperiment(
-	_ context.Context, req *apiv1.GetExperimentRequest,
+	ctx context.Context, req *apiv1.GetExperimentRequest,
 ) (*apiv1.GetExperimentResponse, error) {
+	if err := a.checkExperimentVisible(ctx, int(req.ExperimentId)); err != nil {
+		return nil, err
+	}
 	exp, err := a.getExperiment(int(req.ExperimentId))
 	if err != nil {
 		return nil, err
 	}
 
-	confBytes, err := a.m.db.ExperimentConfigRaw(int(req.ExperimentId))
+	confBytes, err := redactedRaw(a.m.db.ExperimentConfigRaw(int(req.ExperimentId)))
 	if err != nil {
 		return nil, errors.Wrapf(err,
 			"error fetching experiment config from database: %d", req.ExperimentId)
@@ -98,11 +166,22 @@ func (a *apiServer) GetExperiment(
 }
 
 func (a *apiServer) GetExperiments(
-	_ context.Context, req *apiv1.GetExperimentsRequest) (*apiv1.GetExperimentsResponse, error) {
+	ctx context.Context, req *apiv1.GetExperimentsRequest) (*apiv1.GetExperimentsResponse, error) {
+	user, _, err := grpc.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
+	}
 	resp := &apiv1.GetExperimentsResponse{}
-	if err := a.m.db.QueryProto("get_experiments", &resp.Experiments); err != nil {
+	if err = a.m.db.QueryProto(
+		"get_experiments", &resp.Experiments, experimentViewer(*user)); err != nil {
 		return nil, err
 	}
+	var inResourcePool map[int32]bool
+	if req.ResourcePool != "" {
+		if inResourcePool, err = a.experimentsInResourcePool(req.ResourcePool); err != nil {
+			return nil, err
+		}
+	}
 	a.filter(&resp.Experiments, func(i int) bool {
 		v := resp.Experiments[i]
 		if req.Archived != nil && req.Archived.Value != v.Archived {
@@ -133,12 +212,52 @@ func (a *apiServer) GetExperiments(
 			return false
 		}
 
+		if inResourcePool != nil && !inResourcePool[v.Id] {
+			return false
+		}
+
 		return strings.Contains(strings.ToLower(v.Description), strings.ToLower(req.Description))
 	})
 	a.sort(resp.Experiments, req.OrderBy, req.SortBy, apiv1.GetExperimentsRequest_SORT_BY_ID)
 	return resp, a.paginate(&resp.Pagination, &resp.Experiments, req.Offset, req.Limit)
 }
 
+// experimentsInResourcePool returns the IDs of the experiments with trials that ran, are running or
+// are queued in the given resource pool. The allocations of the experiments record where their
+// trials ran; the resource manager knows where the trials that were not allocated slots yet wait.
+func (a *apiServer) experimentsInResourcePool(pool string) (map[int32]bool, error) {
+	allocated, err := a.m.db.ExperimentIDsInResourcePool(pool)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int32]bool)
+	for _, id := range allocated {
+		ids[id] = true
+	}
+
+	result, err := a.awaitResponse(
+		a.m.system.Ask(a.m.rm, resourcemanagers.GetTaskSummaries{}), taskSummariesAskTimeout)
+	if err != nil {
+		return nil, err
+	}
+	summaries, ok := result.(map[resourcemanagers.TaskID]resourcemanagers.TaskSummary)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "failed to get task summaries")
+	}
+	for _, summary := range summaries {
+		if summary.ResourcePool != pool || summary.Group == nil ||
+			summary.Group.Address().Parent() != experimentsAddr {
+			continue
+		}
+		id, err := strconv.Atoi(summary.Group.Address().Local())
+		if err != nil {
+			continue
+		}
+		ids[int32(id)] = true
+	}
+	return ids, nil
+}
+
 func (a *apiServer) GetExperimentLabels(_ context.Context,
 	req *apiv1.GetExperimentLabelsRequest) (*apiv1.GetExperimentLabelsResponse, error) {
 	resp := &apiv1.GetExperimentLabelsResponse{}
@@ -166,8 +285,11 @@ func (a *apiServer) GetExperimentLabels(_ context.Context,
 }
 
 func (a *apiServer) GetExperimentValidationHistory(
-	_ context.Context, req *apiv1.GetExperimentValidationHistoryRequest,
+	ctx context.Context, req *apiv1.GetExperimentValidationHistoryRequest,
 ) (*apiv1.GetExperimentValidationHistoryResponse, error) {
+	if err := a.checkExperimentVisible(ctx, int(req.ExperimentId)); err != nil {
+		return nil, err
+	}
 	var resp apiv1.GetExperimentValidationHistoryResponse
 	switch err := a.m.db.QueryProto("proto_experiment_validation_history", &resp, req.ExperimentId); {
 	case err == db.ErrNotFound:
@@ -185,7 +307,7 @@ func (a *apiServer) PreviewHPSearch(
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "error parsing experiment config: %s", err)
 	}
-	config := model.DefaultExperimentConfig(&a.m.config.TaskContainerDefaults)
+	config := model.DefaultExperimentConfig(&a.m.currentConfig().TaskContainerDefaults)
 	if err = json.Unmarshal(bytes, &config); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "error parsing experiment config: %s", err)
 	}
@@ -270,6 +392,9 @@ func (a *apiServer) PreviewHPSearch(
 func (a *apiServer) ActivateExperiment(
 	ctx context.Context, req *apiv1.ActivateExperimentRequest,
 ) (resp *apiv1.ActivateExperimentResponse, err error) {
+	if err = a.checkExperimentVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	if err = a.checkExperimentExists(int(req.Id)); err != nil {
 		return nil, err
 	}
@@ -288,6 +413,9 @@ func (a *apiServer) ActivateExperiment(
 func (a *apiServer) PauseExperiment(
 	ctx context.Context, req *apiv1.PauseExperimentRequest,
 ) (resp *apiv1.PauseExperimentResponse, err error) {
+	if err = a.checkExperimentVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	if err = a.checkExperimentExists(int(req.Id)); err != nil {
 		return nil, err
 	}
@@ -306,6 +434,9 @@ func (a *apiServer) PauseExperiment(
 func (a *apiServer) CancelExperiment(
 	ctx context.Context, req *apiv1.CancelExperimentRequest,
 ) (resp *apiv1.CancelExperimentResponse, err error) {
+	if err = a.checkExperimentVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	if err = a.checkExperimentExists(int(req.Id)); err != nil {
 		return nil, err
 	}
@@ -313,6 +444,10 @@ func (a *apiServer) CancelExperiment(
 	addr := experimentsAddr.Child(req.Id).String()
 	err = a.actorRequest(addr, req, &resp)
 	if status.Code(err) == codes.NotFound {
+		// Experiments waiting for their dependencies have no actor yet.
+		if _, err = a.m.cancelQueuedExperiment(int(req.Id)); err != nil {
+			return nil, err
+		}
 		return &apiv1.CancelExperimentResponse{}, nil
 	}
 	return resp, err
@@ -322,6 +457,9 @@ func (a *apiServer) KillExperiment(
 	ctx context.Context, req *apiv1.KillExperimentRequest,
 ) (
 	resp *apiv1.KillExperimentResponse, err error) {
+	if err = a.checkExperimentVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	if err = a.checkExperimentExists(int(req.Id)); err != nil {
 		return nil, err
 	}
@@ -329,6 +467,10 @@ func (a *apiServer) KillExperiment(
 	addr := experimentsAddr.Child(req.Id).String()
 	err = a.actorRequest(addr, req, &resp)
 	if status.Code(err) == codes.NotFound {
+		// Experiments waiting for their dependencies have no actor yet.
+		if _, err = a.m.cancelQueuedExperiment(int(req.Id)); err != nil {
+			return nil, err
+		}
 		return &apiv1.KillExperimentResponse{}, nil
 	}
 	return resp, err
@@ -337,6 +479,9 @@ func (a *apiServer) KillExperiment(
 func (a *apiServer) ArchiveExperiment(
 	ctx context.Context, req *apiv1.ArchiveExperimentRequest,
 ) (*apiv1.ArchiveExperimentResponse, error) {
+	if err := a.checkExperimentVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	id := int(req.Id)
 
 	dbExp, err := a.m.db.ExperimentWithoutConfigByID(id)
@@ -347,6 +492,9 @@ func (a *apiServer) ArchiveExperiment(
 		return nil, errors.Errorf("cannot archive experiment %v in non terminate state %v",
 			id, dbExp.State)
 	}
+	if err = checkExperimentNotDeleted(dbExp); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
 
 	if dbExp.Archived {
 		return &apiv1.ArchiveExperimentResponse{}, nil
@@ -355,6 +503,7 @@ func (a *apiServer) ArchiveExperiment(
 	err = a.m.db.SaveExperimentArchiveStatus(dbExp)
 	switch err {
 	case nil:
+		a.m.experimentListCache.invalidate()
 		return &apiv1.ArchiveExperimentResponse{}, nil
 	default:
 		return nil, errors.Wrapf(err, "failed to archive experiment %d",
@@ -365,6 +514,9 @@ func (a *apiServer) ArchiveExperiment(
 func (a *apiServer) UnarchiveExperiment(
 	ctx context.Context, req *apiv1.UnarchiveExperimentRequest,
 ) (*apiv1.UnarchiveExperimentResponse, error) {
+	if err := a.checkExperimentVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	id := int(req.Id)
 
 	dbExp, err := a.m.db.ExperimentWithoutConfigByID(id)
@@ -375,6 +527,9 @@ func (a *apiServer) UnarchiveExperiment(
 		return nil, errors.Errorf("cannot unarchive experiment %v in non terminate state %v",
 			id, dbExp.State)
 	}
+	if err = checkExperimentNotDeleted(dbExp); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
 
 	if !dbExp.Archived {
 		return &apiv1.UnarchiveExperimentResponse{}, nil
@@ -383,6 +538,7 @@ func (a *apiServer) UnarchiveExperiment(
 	err = a.m.db.SaveExperimentArchiveStatus(dbExp)
 	switch err {
 	case nil:
+		a.m.experimentListCache.invalidate()
 		return &apiv1.UnarchiveExperimentResponse{}, nil
 	default:
 		return nil, errors.Wrapf(err, "failed to archive experiment %d",
@@ -393,6 +549,9 @@ func (a *apiServer) UnarchiveExperiment(
 func (a *apiServer) PatchExperiment(
 	ctx context.Context, req *apiv1.PatchExperimentRequest,
 ) (*apiv1.PatchExperimentResponse, error) {
+	if err := a.checkExperimentVisible(ctx, int(req.Experiment.Id)); err != nil {
+		return nil, err
+	}
 	var exp experimentv1.Experiment
 	switch err := a.m.db.QueryProto("get_experiment", &exp, req.Experiment.Id); {
 	case err == db.ErrNotFound:
@@ -438,6 +597,9 @@ func (a *apiServer) PatchExperiment(
 func (a *apiServer) GetExperimentCheckpoints(
 	ctx context.Context, req *apiv1.GetExperimentCheckpointsRequest,
 ) (*apiv1.GetExperimentCheckpointsResponse, error) {
+	if err := a.checkExperimentVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	ok, err := a.m.db.CheckExperimentExists(int(req.Id))
 	switch {
 	case err != nil:
@@ -504,6 +666,9 @@ func (a *apiServer) CreateExperiment(
 		parentID := int(req.ParentId)
 		detParams.ParentID = &parentID
 	}
+	if err := a.m.checkExperimentConfigSize(req.Config); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	dbExp, validateOnly, err := a.m.parseCreateExperiment(&detParams)
 
@@ -519,8 +684,14 @@ func (a *apiServer) CreateExperiment(
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
 	}
+	if err = a.m.checkMaintenance(*user, req.OverrideMaintenance); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
 
 	dbExp.OwnerID = &user.ID
+	if dbExp.TeamID, err = a.m.experimentTeam(*user, nil); err != nil {
+		return nil, err
+	}
 	e, err := newExperiment(a.m, dbExp)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create experiment: %s", err)
@@ -540,6 +711,9 @@ var metricsStreamPeriod = 30 * time.Second
 
 func (a *apiServer) MetricNames(req *apiv1.MetricNamesRequest,
 	resp apiv1.Determined_MetricNamesServer) error {
+	if err := a.checkExperimentVisible(resp.Context(), int(req.ExperimentId)); err != nil {
+		return err
+	}
 	experimentID := int(req.ExperimentId)
 
 	config, err := a.m.db.ExperimentConfig(experimentID)
@@ -598,6 +772,9 @@ func (a *apiServer) MetricNames(req *apiv1.MetricNamesRequest,
 
 func (a *apiServer) MetricBatches(req *apiv1.MetricBatchesRequest,
 	resp apiv1.Determined_MetricBatchesServer) error {
+	if err := a.checkExperimentVisible(resp.Context(), int(req.ExperimentId)); err != nil {
+		return err
+	}
 	experimentID := int(req.ExperimentId)
 	metricName := req.MetricName
 	metricType := req.MetricType
@@ -657,6 +834,9 @@ func (a *apiServer) MetricBatches(req *apiv1.MetricBatchesRequest,
 
 func (a *apiServer) TrialsSnapshot(req *apiv1.TrialsSnapshotRequest,
 	resp apiv1.Determined_TrialsSnapshotServer) error {
+	if err := a.checkExperimentVisible(resp.Context(), int(req.ExperimentId)); err != nil {
+		return err
+	}
 	experimentID := int(req.ExperimentId)
 	batchesProcessed := int(req.BatchesProcessed)
 	metricName := req.MetricName
@@ -739,6 +919,8 @@ func (a *apiServer) topTrials(experimentID int, maxTrials int, s model.SearcherC
 		return nil, errors.New("single-trial experiments are not supported for trial sampling")
 	case s.PBTConfig != nil:
 		return nil, errors.New("population-based training not supported for trial sampling")
+	case s.CustomConfig != nil:
+		ranking = ByMetricOfInterest
 	default:
 		return nil, errors.New("unable to detect a searcher algorithm for trial sampling")
 	}
@@ -808,6 +990,9 @@ func (a *apiServer) fetchTrialSample(trialID int32, metricName string, metricTyp
 
 func (a *apiServer) TrialsSample(req *apiv1.TrialsSampleRequest,
 	resp apiv1.Determined_TrialsSampleServer) error {
+	if err := a.checkExperimentVisible(resp.Context(), int(req.ExperimentId)); err != nil {
+		return err
+	}
 	experimentID := int(req.ExperimentId)
 	maxTrials := int(req.MaxTrials)
 	if maxTrials == 0 {
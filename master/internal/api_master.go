@@ -9,9 +9,14 @@ import (
 
 	structpb "github.com/golang/protobuf/ptypes/struct"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/determined-ai/determined/master/pkg/jsonschema"
 	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/proto/pkg/logv1"
 
 	"github.com/determined-ai/determined/master/internal/api"
@@ -25,13 +30,14 @@ func (a *apiServer) GetMaster(
 		Version:     a.m.Version,
 		MasterId:    a.m.MasterID,
 		ClusterId:   a.m.ClusterID,
-		ClusterName: a.m.config.ClusterName,
+		ClusterName: a.m.currentConfig().ClusterName,
+		MasterUrl:   a.m.MasterURL,
 	}, nil
 }
 
 func (a *apiServer) GetMasterConfig(
 	_ context.Context, _ *apiv1.GetMasterConfigRequest) (*apiv1.GetMasterConfigResponse, error) {
-	config, err := a.m.config.Printable()
+	config, err := a.m.currentConfig().Printable()
 	if err != nil {
 		return nil, errors.Wrap(err, "error parsing master config")
 	}
@@ -42,6 +48,25 @@ func (a *apiServer) GetMasterConfig(
 	}, err
 }
 
+// configSchemas are the JSON Schemas of the configuration files, generated from the structs they
+// are parsed into when the master starts.
+var configSchemas = map[string]map[string]interface{}{
+	"experiment": jsonschema.Generate(model.ExperimentConfig{}),
+	"master":     jsonschema.Generate(Config{}),
+}
+
+func (a *apiServer) GetConfigSchema(
+	_ context.Context, req *apiv1.GetConfigSchemaRequest) (*apiv1.GetConfigSchemaResponse, error) {
+	schema, ok := configSchemas[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no configuration schema named %q", req.Name)
+	}
+	return &apiv1.GetConfigSchemaResponse{
+		Schema:  protoutils.ToStruct(schema),
+		Version: a.m.Version,
+	}, nil
+}
+
 func (a *apiServer) MasterLogs(
 	req *apiv1.MasterLogsRequest, resp apiv1.Determined_MasterLogsServer) error {
 	if err := grpc.ValidateRequest(
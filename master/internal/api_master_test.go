@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestGetConfigSchema(t *testing.T) {
+	a := &apiServer{m: &Master{Version: "1.2.3"}}
+
+	resp, err := a.GetConfigSchema(context.Background(), &apiv1.GetConfigSchemaRequest{Name: "master"})
+	assert.NilError(t, err)
+	assert.Equal(t, resp.Version, "1.2.3")
+	properties := resp.Schema.Fields["properties"].GetStructValue().Fields
+
+	// Every field of the master configuration is described.
+	bs, err := json.Marshal(DefaultConfig())
+	assert.NilError(t, err)
+	var fields map[string]interface{}
+	assert.NilError(t, json.Unmarshal(bs, &fields))
+	for name := range fields {
+		_, ok := properties[name]
+		assert.Assert(t, ok, "%s is not described", name)
+	}
+
+	resp, err = a.GetConfigSchema(context.Background(), &apiv1.GetConfigSchemaRequest{
+		Name: "experiment",
+	})
+	assert.NilError(t, err)
+	_, ok := resp.Schema.Fields["properties"].GetStructValue().Fields["hyperparameters"]
+	assert.Assert(t, ok)
+
+	_, err = a.GetConfigSchema(context.Background(), &apiv1.GetConfigSchemaRequest{Name: "agent"})
+	assert.Equal(t, status.Code(err), codes.NotFound)
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -13,6 +14,8 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/webhooks"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
 	"github.com/determined-ai/determined/proto/pkg/modelv1"
@@ -41,10 +44,18 @@ func (a *apiServer) GetModels(
 	a.filter(&resp.Models, func(i int) bool {
 		v := resp.Models[i]
 
+		if v.Archived && !req.ShowArchived {
+			return false
+		}
+
 		if !strings.Contains(strings.ToLower(v.Name), strings.ToLower(req.Name)) {
 			return false
 		}
 
+		if !matchesList(v.Labels, req.Labels) {
+			return false
+		}
+
 		return strings.Contains(strings.ToLower(v.Description), strings.ToLower(req.Description))
 	})
 
@@ -53,15 +64,29 @@ func (a *apiServer) GetModels(
 }
 
 func (a *apiServer) PostModel(
-	_ context.Context, req *apiv1.PostModelRequest) (*apiv1.PostModelResponse, error) {
+	ctx context.Context, req *apiv1.PostModelRequest) (*apiv1.PostModelResponse, error) {
+	if req.Model == nil || req.Model.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "model name is required")
+	}
+	switch _, err := a.GetModel(ctx, &apiv1.GetModelRequest{ModelName: req.Model.Name}); {
+	case err == nil:
+		return nil, status.Errorf(codes.AlreadyExists, "model %s already exists", req.Model.Name)
+	case status.Code(err) != codes.NotFound:
+		return nil, err
+	}
+
 	b, err := protojson.Marshal(req.Model.Metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "error marshaling model.Metadata")
 	}
+	labels, err := labelsJSON(req.Model.Labels)
+	if err != nil {
+		return nil, err
+	}
 
 	m := &modelv1.Model{}
 	err = a.m.db.QueryProto(
-		"insert_model", m, req.Model.Name, req.Model.Description, b, time.Now(), time.Now(),
+		"insert_model", m, req.Model.Name, req.Model.Description, b, labels, time.Now(), time.Now(),
 	)
 
 	return &apiv1.PostModelResponse{Model: m},
@@ -77,12 +102,6 @@ func (a *apiServer) PatchModel(
 
 	currModel := getResp.Model
 
-	if currModel.Description != req.Model.Description {
-		log.Infof("model (%s) description changing from \"%s\" to \"%s\"",
-			req.Model.Name, currModel.Description, req.Model.Description)
-		currModel.Description = req.Model.Description
-	}
-
 	currMeta, err := protojson.Marshal(currModel.Metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "error marshaling database model metadata")
@@ -93,23 +112,64 @@ func (a *apiServer) PatchModel(
 		return nil, errors.Wrap(err, "error marshaling request model metadata")
 	}
 
-	if currModel.Description == req.Model.Description && bytes.Equal(currMeta, newMeta) {
+	currLabels, err := labelsJSON(currModel.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	newLabels, err := labelsJSON(req.Model.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	if currModel.Description == req.Model.Description && bytes.Equal(currMeta, newMeta) &&
+		bytes.Equal(currLabels, newLabels) {
 		return &apiv1.PatchModelResponse{Model: currModel}, nil
 	}
 
+	if currModel.Description != req.Model.Description {
+		log.Infof("model (%s) description changing from \"%s\" to \"%s\"",
+			req.Model.Name, currModel.Description, req.Model.Description)
+	}
+
 	if !bytes.Equal(currMeta, newMeta) {
 		log.Infof("model (%s) metadata changing from %s to %s",
 			req.Model.Name, currMeta, newMeta)
-		currModel.Metadata = req.Model.Metadata
 	}
 
+	if !bytes.Equal(currLabels, newLabels) {
+		log.Infof("model (%s) labels changing from %s to %s",
+			req.Model.Name, currLabels, newLabels)
+	}
+
+	m := &modelv1.Model{}
 	err = a.m.db.QueryProto(
-		"update_model", &modelv1.Model{}, req.Model.Name, currModel.Description, newMeta, time.Now())
+		"update_model", m, req.Model.Name, req.Model.Description, newMeta, newLabels, time.Now())
 
-	return &apiv1.PatchModelResponse{Model: currModel},
+	return &apiv1.PatchModelResponse{Model: m},
 		errors.Wrapf(err, "error updating model %s in database", req.Model.Name)
 }
 
+func (a *apiServer) ArchiveModel(
+	_ context.Context, req *apiv1.ArchiveModelRequest) (*apiv1.ArchiveModelResponse, error) {
+	return &apiv1.ArchiveModelResponse{}, a.setModelArchived(req.ModelName, true)
+}
+
+func (a *apiServer) UnarchiveModel(
+	_ context.Context, req *apiv1.UnarchiveModelRequest) (*apiv1.UnarchiveModelResponse, error) {
+	return &apiv1.UnarchiveModelResponse{}, a.setModelArchived(req.ModelName, false)
+}
+
+func (a *apiServer) setModelArchived(name string, archived bool) error {
+	switch err := a.m.db.QueryProto(
+		"update_model_archived", &modelv1.Model{}, name, archived, time.Now()); err {
+	case db.ErrNotFound:
+		return status.Errorf(codes.NotFound, "model %s not found", name)
+	default:
+		return errors.Wrapf(err, "error updating model %s in database", name)
+	}
+}
+
 func (a *apiServer) GetModelVersion(
 	_ context.Context, req *apiv1.GetModelVersionRequest) (*apiv1.GetModelVersionResponse, error) {
 	resp := &apiv1.GetModelVersionResponse{}
@@ -148,6 +208,10 @@ func (a *apiServer) PostModelVersion(
 	if err != nil {
 		return nil, err
 	}
+	if getResp.Model.Archived {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"model %s is archived; unarchive it to add versions", req.ModelName)
+	}
 
 	// make sure the checkpoint exists
 	c := &checkpointv1.Checkpoint{}
@@ -161,7 +225,7 @@ func (a *apiServer) PostModelVersion(
 	}
 
 	if c.State != checkpointv1.State_STATE_COMPLETED {
-		return nil, errors.Errorf(
+		return nil, status.Errorf(codes.FailedPrecondition,
 			"checkpoint %s is in %s state. checkpoints for model versions must be in a COMPLETED state",
 			c.Uuid, c.State,
 		)
@@ -180,5 +244,71 @@ func (a *apiServer) PostModelVersion(
 	respModelVersion.ModelVersion.Model = getResp.Model
 	respModelVersion.ModelVersion.Checkpoint = c
 
+	if err == nil {
+		webhooks.Publish(a.m.system, model.ModelVersionRegisteredEvent, "", map[string]interface{}{
+			"model_name":      req.ModelName,
+			"version":         respModelVersion.ModelVersion.Version,
+			"checkpoint_uuid": c.Uuid,
+			"experiment_id":   c.ExperimentId,
+			"trial_id":        c.TrialId,
+		})
+	}
+
 	return respModelVersion, errors.Wrapf(err, "error adding model version to model %s", req.ModelName)
 }
+
+func (a *apiServer) PatchModelVersion(
+	ctx context.Context, req *apiv1.PatchModelVersionRequest,
+) (*apiv1.PatchModelVersionResponse, error) {
+	getResp, err := a.GetModelVersion(ctx, &apiv1.GetModelVersionRequest{
+		ModelName: req.ModelName, ModelVersion: req.ModelVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := protojson.Marshal(req.Metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling request model version metadata")
+	}
+
+	labels, err := labelsJSON(req.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := &modelv1.ModelVersion{}
+	err = a.m.db.QueryProto("update_model_version", updated,
+		req.ModelName, req.ModelVersion, metadata, labels, time.Now())
+	updated.Model = getResp.ModelVersion.Model
+	updated.Checkpoint = getResp.ModelVersion.Checkpoint
+
+	return &apiv1.PatchModelVersionResponse{ModelVersion: updated}, errors.Wrapf(err,
+		"error updating model %s version %d in database", req.ModelName, req.ModelVersion)
+}
+
+func (a *apiServer) DeleteModelVersion(
+	_ context.Context, req *apiv1.DeleteModelVersionRequest,
+) (*apiv1.DeleteModelVersionResponse, error) {
+	// Only the model version is deleted; its checkpoint is kept, and becomes subject to checkpoint
+	// garbage collection again.
+	switch err := a.m.db.QueryProto("delete_model_version", &modelv1.ModelVersion{},
+		req.ModelName, req.ModelVersion); err {
+	case db.ErrNotFound:
+		return nil, status.Errorf(
+			codes.NotFound, "model %s version %d not found", req.ModelName, req.ModelVersion)
+	default:
+		return &apiv1.DeleteModelVersionResponse{}, errors.Wrapf(err,
+			"error deleting model %s version %d from database", req.ModelName, req.ModelVersion)
+	}
+}
+
+// labelsJSON returns the JSON array of the labels of a model or model version, to be saved in the
+// database.
+func labelsJSON(labels []string) ([]byte, error) {
+	if labels == nil {
+		labels = []string{}
+	}
+	b, err := json.Marshal(labels)
+	return b, errors.Wrap(err, "error marshaling labels")
+}
@@ -82,9 +82,10 @@ func (a *apiServer) LaunchNotebook(
 	ctx context.Context, req *apiv1.LaunchNotebookRequest,
 ) (*apiv1.LaunchNotebookResponse, error) {
 	cmdParams, user, err := a.prepareLaunchParams(ctx, &protoCommandParams{
-		TemplateName: req.TemplateName,
-		Config:       req.Config,
-		Files:        req.Files,
+		TemplateName:        req.TemplateName,
+		Config:              req.Config,
+		Files:               req.Files,
+		OverrideMaintenance: req.OverrideMaintenance,
 	})
 	if err != nil {
 		return nil, err
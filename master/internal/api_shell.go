@@ -39,10 +39,11 @@ func (a *apiServer) LaunchShell(
 	ctx context.Context, req *apiv1.LaunchShellRequest,
 ) (*apiv1.LaunchShellResponse, error) {
 	cmdParams, user, err := a.prepareLaunchParams(ctx, &protoCommandParams{
-		TemplateName: req.TemplateName,
-		Config:       req.Config,
-		Files:        req.Files,
-		Data:         req.Data,
+		TemplateName:        req.TemplateName,
+		Config:              req.Config,
+		Files:               req.Files,
+		Data:                req.Data,
+		OverrideMaintenance: req.OverrideMaintenance,
 	})
 	if err != nil {
 		return nil, err
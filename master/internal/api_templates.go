@@ -46,7 +46,13 @@ func (a *apiServer) PutTemplate(
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid config provided: %s", err.Error())
 	}
-	err = a.m.db.QueryProto("put_template", req.Template, req.Template.Name, config)
+	// Templates that are replaced without a description keep theirs.
+	var description *string
+	if req.Template.Description != "" {
+		description = &req.Template.Description
+	}
+	err = a.m.db.QueryProto(
+		"put_template", req.Template, req.Template.Name, description, config)
 	return &apiv1.PutTemplateResponse{Template: req.Template},
 		errors.Wrapf(err, "error putting template")
 }
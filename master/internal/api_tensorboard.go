@@ -70,9 +70,10 @@ func (a *apiServer) LaunchTensorboard(
 	}
 
 	cmdParams, user, err := a.prepareLaunchParams(ctx, &protoCommandParams{
-		TemplateName: req.TemplateName,
-		Config:       req.Config,
-		Files:        req.Files,
+		TemplateName:        req.TemplateName,
+		Config:              req.Config,
+		Files:               req.Files,
+		OverrideMaintenance: req.OverrideMaintenance,
 	})
 	if err != nil {
 		return nil, err
@@ -2,7 +2,9 @@ package internal
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -22,6 +24,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpc"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/ansi"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
@@ -51,13 +54,41 @@ func trialStatus(d *db.PgDB, trialID int32) (model.State, int, error) {
 
 func (a *apiServer) TrialLogs(
 	req *apiv1.TrialLogsRequest, resp apiv1.Determined_TrialLogsServer) error {
+	if err := a.checkTrialVisible(resp.Context(), int(req.TrialId)); err != nil {
+		return err
+	}
 	if err := grpc.ValidateRequest(
 		grpc.ValidateLimit(req.Limit),
 		grpc.ValidateFollow(req.Limit, req.Follow),
+		grpc.ValidateCursor(req.Offset, req.Cursor),
+		grpc.ValidateSince(req.Offset, req.Cursor, req.Since),
 	); err != nil {
 		return err
 	}
 
+	// Logs are fetched in commit order, and once the first page is sent, after the last log sent
+	// rather than by offset, so that logs added concurrently neither shift the page boundaries nor
+	// are skipped.
+	var after *db.TrialLogsCursor
+	if req.Cursor != "" {
+		cursor, err := decodeTrialLogsCursor(int(req.TrialId), req.Cursor)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		after = &cursor
+	}
+
+	// Starting from a recent window of logs works like a cursor after the last log before it.
+	if req.Since != "" {
+		since, err := parseLogsSince(req.Since, time.Now())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if after, err = a.m.db.LastTrialLogBefore(int(req.TrialId), since); err != nil {
+			return err
+		}
+	}
+
 	_, total, err := trialStatus(a.m.db, req.TrialId)
 	if err != nil {
 		return err
@@ -74,13 +105,22 @@ func (a *apiServer) TrialLogs(
 		return b.ForEach(func(r interface{}) error {
 			trialLog := r.(*model.TrialLog)
 			logID++
+			after = &db.TrialLogsCursor{InsertedAt: trialLog.InsertedAt, ID: trialLog.ID}
+			message := trialLog.Message
+			if req.StripAnsi {
+				message = ansi.Strip(message)
+			}
 			return resp.Send(&apiv1.TrialLogsResponse{
-				Id:      logID,
-				Message: trialLog.Message,
+				Id:         logID,
+				Message:    message,
+				NextCursor: encodeTrialLogsCursor(trialLog.TrialID, *after),
 			})
 		})
 	}
 
+	// Once the trial has terminated, no more logs are inserted for it, so a last fetch without the
+	// lag catches up on the logs that it left out.
+	lag := db.TrialLogsCommitLag
 	fetch := func(lr api.LogsRequest) (api.LogBatch, error) {
 		switch {
 		case lr.Follow, lr.Limit > batchSize:
@@ -89,7 +129,12 @@ func (a *apiServer) TrialLogs(
 			return nil, nil
 		}
 
-		b, err := a.m.db.TrialLogs(int(req.TrialId), lr.Offset, lr.Limit, lr.Filters)
+		if after != nil {
+			lr.Offset = 0
+		}
+
+		b, err := a.m.db.TrialLogs(
+			int(req.TrialId), after, lag, lr.Offset, lr.Limit, lr.Filters)
 		if err != nil {
 			return nil, err
 		}
@@ -99,10 +144,15 @@ func (a *apiServer) TrialLogs(
 
 	terminateCheck := api.TerminationCheckFn(func() (bool, error) {
 		state, _, err := trialStatus(a.m.db, req.TrialId)
-		if err != nil || model.TerminalStates[state] {
+		switch {
+		case err != nil:
 			return true, err
+		case model.TerminalStates[state] && lag > 0:
+			lag = 0
+			return false, nil
+		default:
+			return model.TerminalStates[state], nil
 		}
-		return false, nil
 	})
 
 	lReq := api.LogsRequest{Offset: offset, Limit: limit, Follow: req.Follow, Filters: filters}
@@ -119,6 +169,53 @@ func (a *apiServer) TrialLogs(
 	).AwaitTermination()
 }
 
+// encodeTrialLogsCursor returns the opaque cursor that continues after the trial log at the given
+// position.
+func encodeTrialLogsCursor(trialID int, position db.TrialLogsCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(
+		"%d:%d:%d", trialID, position.InsertedAt.UnixNano(), position.ID)))
+}
+
+// decodeTrialLogsCursor returns the position of the trial log that the cursor continues after.
+func decodeTrialLogsCursor(trialID int, cursor string) (db.TrialLogsCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return db.TrialLogsCursor{}, errors.New("invalid cursor")
+	}
+	var cursorTrialID, logID int
+	var insertedAt int64
+	if _, err := fmt.Sscanf(
+		string(decoded), "%d:%d:%d", &cursorTrialID, &insertedAt, &logID,
+	); err != nil {
+		return db.TrialLogsCursor{}, errors.New("invalid cursor")
+	}
+	if cursorTrialID != trialID {
+		return db.TrialLogsCursor{}, errors.Errorf("cursor belongs to trial %d", cursorTrialID)
+	}
+	return db.TrialLogsCursor{InsertedAt: time.Unix(0, insertedAt).UTC(), ID: logID}, nil
+}
+
+// parseLogsSince returns the start of the window of recent logs described by since, which is a
+// duration before now, an RFC 3339 timestamp or a number of seconds since the Unix epoch. Both the
+// gRPC and the REST trial logs endpoints use it, so that they accept the same values.
+func parseLogsSince(since string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		if d < 0 {
+			return time.Time{}, errors.Errorf("since must not be a negative duration: %s", since)
+		}
+		return now.Add(-d), nil
+	}
+	if seconds, err := strconv.ParseInt(since, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, errors.Errorf(
+			"since must be a duration, e.g., 5m, or an RFC 3339 timestamp, not %q", since)
+	}
+	return t, nil
+}
+
 func constructTrialLogsFilters(req *apiv1.TrialLogsRequest) ([]api.Filter, error) {
 	var filters []api.Filter
 
@@ -186,6 +283,9 @@ func constructTrialLogsFilters(req *apiv1.TrialLogsRequest) ([]api.Filter, error
 
 func (a *apiServer) TrialLogsFields(
 	req *apiv1.TrialLogsFieldsRequest, resp apiv1.Determined_TrialLogsFieldsServer) error {
+	if err := a.checkTrialVisible(resp.Context(), int(req.TrialId)); err != nil {
+		return err
+	}
 	fetch := func(lr api.LogsRequest) (api.LogBatch, error) {
 		var fields apiv1.TrialLogsFieldsResponse
 		err := a.m.db.QueryProto("get_trial_log_fields", &fields, req.TrialId)
@@ -225,8 +325,11 @@ func (a *apiServer) TrialLogsFields(
 }
 
 func (a *apiServer) GetTrialCheckpoints(
-	_ context.Context, req *apiv1.GetTrialCheckpointsRequest,
+	ctx context.Context, req *apiv1.GetTrialCheckpointsRequest,
 ) (*apiv1.GetTrialCheckpointsResponse, error) {
+	if err := a.checkTrialVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	_, _, err := trialStatus(a.m.db, req.Id)
 	if err != nil {
 		return nil, err
@@ -283,6 +386,9 @@ func (a *apiServer) GetTrialCheckpoints(
 func (a *apiServer) KillTrial(
 	ctx context.Context, req *apiv1.KillTrialRequest,
 ) (*apiv1.KillTrialResponse, error) {
+	if err := a.checkTrialVisible(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 	ok, err := a.m.db.CheckTrialExists(int(req.Id))
 	switch {
 	case err != nil:
@@ -300,9 +406,66 @@ func (a *apiServer) KillTrial(
 	return &resp, err
 }
 
+func (a *apiServer) PostTrialLogs(stream apiv1.Determined_PostTrialLogsServer) error {
+	var received int32
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&apiv1.PostTrialLogsResponse{Received: received})
+		} else if err != nil {
+			return err
+		}
+		received++
+
+		l, err := trialLogFromProto(req)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid trial log: %s", err)
+		}
+		a.m.system.Tell(a.m.trialLogger, l)
+	}
+}
+
+// trialLogFromProto converts a log entry sent to PostTrialLogs into a model.TrialLog.
+func trialLogFromProto(req *apiv1.PostTrialLogsRequest) (model.TrialLog, error) {
+	if req.TrialId <= 0 {
+		return model.TrialLog{}, errors.Errorf("trial_id must be positive, got %d", req.TrialId)
+	}
+	optional := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+	l := model.TrialLog{
+		TrialID:     int(req.TrialId),
+		Message:     req.Message,
+		AgentID:     optional(req.AgentId),
+		ContainerID: optional(req.ContainerId),
+		Level:       optional(req.Level),
+		Log:         optional(req.Log),
+		Source:      optional(req.Source),
+		StdType:     optional(req.Stdtype),
+	}
+	if req.RankId != nil {
+		rank := int(req.RankId.Value)
+		l.RankID = &rank
+	}
+	if req.Timestamp != nil {
+		timestamp, err := ptypes.Timestamp(req.Timestamp)
+		if err != nil {
+			return model.TrialLog{}, err
+		}
+		l.Timestamp = &timestamp
+	}
+	return l, nil
+}
+
 func (a *apiServer) GetExperimentTrials(
-	_ context.Context, req *apiv1.GetExperimentTrialsRequest,
+	ctx context.Context, req *apiv1.GetExperimentTrialsRequest,
 ) (*apiv1.GetExperimentTrialsResponse, error) {
+	if err := a.checkExperimentVisible(ctx, int(req.ExperimentId)); err != nil {
+		return nil, err
+	}
 	resp := &apiv1.GetExperimentTrialsResponse{}
 
 	switch err := a.m.db.QueryProto(
@@ -354,9 +517,12 @@ func (a *apiServer) GetExperimentTrials(
 	return resp, nil
 }
 
-func (a *apiServer) GetTrial(_ context.Context, req *apiv1.GetTrialRequest) (
+func (a *apiServer) GetTrial(ctx context.Context, req *apiv1.GetTrialRequest) (
 	*apiv1.GetTrialResponse, error,
 ) {
+	if err := a.checkTrialVisible(ctx, int(req.TrialId)); err != nil {
+		return nil, err
+	}
 	resp := &apiv1.GetTrialResponse{Trial: &trialv1.Trial{}}
 	switch err := a.m.db.QueryProto(
 		"proto_get_trials_plus",
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestTrialLogFromProto(t *testing.T) {
+	l, err := trialLogFromProto(&apiv1.PostTrialLogsRequest{TrialId: 1, Message: "hello\n"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, l, model.TrialLog{TrialID: 1, Message: "hello\n"})
+
+	now := time.Now().UTC()
+	timestamp, err := ptypes.TimestampProto(now)
+	assert.NilError(t, err)
+	l, err = trialLogFromProto(&apiv1.PostTrialLogsRequest{
+		TrialId:     2,
+		Message:     "[rank=0] hello\n",
+		ContainerId: "container",
+		RankId:      &wrappers.Int32Value{Value: 0},
+		Timestamp:   timestamp,
+		Stdtype:     "stdout",
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, *l.ContainerID, "container")
+	assert.Equal(t, *l.RankID, 0)
+	assert.Assert(t, l.Timestamp.Equal(now))
+	assert.Equal(t, *l.StdType, "stdout")
+	assert.Assert(t, l.AgentID == nil)
+	assert.Assert(t, l.Level == nil)
+
+	_, err = trialLogFromProto(&apiv1.PostTrialLogsRequest{Message: "hello\n"})
+	assert.ErrorContains(t, err, "trial_id must be positive, got 0")
+}
+
+func TestTrialLogsCursor(t *testing.T) {
+	position := db.TrialLogsCursor{
+		InsertedAt: time.Date(2020, 11, 19, 12, 0, 0, 123456000, time.UTC), ID: 1234,
+	}
+	cursor := encodeTrialLogsCursor(3, position)
+	decoded, err := decodeTrialLogsCursor(3, cursor)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decoded, position)
+
+	_, err = decodeTrialLogsCursor(4, cursor)
+	assert.ErrorContains(t, err, "cursor belongs to trial 3")
+	_, err = decodeTrialLogsCursor(3, "not a cursor")
+	assert.ErrorContains(t, err, "invalid cursor")
+	_, err = decodeTrialLogsCursor(3, encodeTrialLogsCursor(3, position)[1:])
+	assert.ErrorContains(t, err, "invalid cursor")
+}
+
+func TestParseLogsSince(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	since, err := parseLogsSince("5m", now)
+	assert.NilError(t, err)
+	assert.Equal(t, since, now.Add(-5*time.Minute))
+
+	since, err = parseLogsSince("2020-10-01T11:00:00Z", now)
+	assert.NilError(t, err)
+	assert.Equal(t, since, now.Add(-time.Hour))
+
+	since, err = parseLogsSince("1601550000", now)
+	assert.NilError(t, err)
+	assert.Equal(t, since, now.Add(-time.Hour))
+
+	_, err = parseLogsSince("-5m", now)
+	assert.ErrorContains(t, err, "negative duration")
+	_, err = parseLogsSince("yesterday", now)
+	assert.ErrorContains(t, err, `not "yesterday"`)
+}
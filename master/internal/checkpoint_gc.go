@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/determined-ai/determined/master/internal/db"
@@ -20,6 +21,10 @@ type checkpointGCTask struct {
 	agentUserGroup *model.AgentUserGroup
 	taskSpec       *tasks.TaskSpec
 
+	// toDelete, if set, holds the checkpoints to delete in place of those that the GC policy of the
+	// experiment selects, and the outcome is reported to the parent with checkpointsDeleted.
+	toDelete json.RawMessage
+
 	// TODO (DET-789): Set up proper log handling for checkpoint GC.
 	logs []sproto.ContainerLog
 }
@@ -27,22 +32,26 @@ type checkpointGCTask struct {
 func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
-		ctx.Tell(t.rm, resourcemanagers.AllocateRequest{
-			Name: fmt.Sprintf("Checkpoint GC (Experiment %d)", t.experiment.ID),
-			FittingRequirements: resourcemanagers.FittingRequirements{
-				SingleAgent: true,
-			},
-			TaskActor:      ctx.Self(),
-			NonPreemptible: true,
-		})
+		// Wait for the limiter, if there is one, before deleting checkpoints.
+		if limiter := ctx.Self().System().Get(checkpointGCLimiterAddr); limiter != nil {
+			ctx.Tell(limiter, acquireCheckpointGCSlot{})
+		} else {
+			t.allocate(ctx)
+		}
 
-	case resourcemanagers.ResourcesAllocated:
-		config := t.experiment.Config.CheckpointStorage
+	case checkpointGCSlotAcquired:
+		t.allocate(ctx)
 
-		checkpoints, err := t.db.ExperimentCheckpointsToGCRaw(t.experiment.ID,
-			&config.SaveExperimentBest, &config.SaveTrialBest, &config.SaveTrialLatest, true)
-		if err != nil {
-			return err
+	case resourcemanagers.ResourcesAllocated:
+		checkpoints := t.toDelete
+		if checkpoints == nil {
+			config := t.experiment.Config.CheckpointStorage
+			var err error
+			checkpoints, err = t.db.ExperimentCheckpointsToGCRaw(t.experiment.ID,
+				&config.SaveExperimentBest, &config.SaveTrialBest, &config.SaveTrialLatest, true)
+			if err != nil {
+				return err
+			}
 		}
 
 		ctx.Log().Info("starting checkpoint garbage collection")
@@ -57,6 +66,9 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 			}
 			a.Start(ctx, taskSpec)
 		}
+	case resourcemanagers.RegistryTokenFetched:
+		msg.Resume(ctx)
+
 	case resourcemanagers.ReleaseResources:
 		// Ignore the release resource message and wait for the GC job to finish.
 
@@ -74,15 +86,100 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 		} else {
 			ctx.Log().Info("finished checkpoint garbage collection")
 		}
+		if t.toDelete != nil {
+			report := checkpointsDeleted{failures: parseCheckpointDeletionFailures(t.logs)}
+			if status.Failure != nil {
+				report.err = status.Failure
+			}
+			ctx.Tell(ctx.Self().Parent(), report)
+		}
 		ctx.Self().Stop()
 
 	case sproto.ContainerLog:
 		t.logs = append(t.logs, msg)
 
 	case actor.PostStop:
+		if limiter := ctx.Self().System().Get(checkpointGCLimiterAddr); limiter != nil {
+			ctx.Tell(limiter, releaseCheckpointGCSlot{})
+		}
 
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
 	}
 	return nil
 }
+
+func (t *checkpointGCTask) allocate(ctx *actor.Context) {
+	ctx.Tell(t.rm, resourcemanagers.AllocateRequest{
+		Name: fmt.Sprintf("Checkpoint GC (Experiment %d)", t.experiment.ID),
+		FittingRequirements: resourcemanagers.FittingRequirements{
+			SingleAgent: true,
+		},
+		TaskActor:      ctx.Self(),
+		NonPreemptible: true,
+		UserID:         t.experiment.OwnerID,
+		ExperimentID:   &t.experiment.ID,
+		// Checkpoint GC frees storage rather than starting new work.
+		BypassMaintenance: true,
+	})
+}
+
+var checkpointGCLimiterAddr = actor.Addr("checkpointGCLimiter")
+
+type (
+	// acquireCheckpointGCSlot asks the checkpoint GC limiter for permission to delete checkpoints.
+	acquireCheckpointGCSlot struct{}
+	// checkpointGCSlotAcquired is sent by the checkpoint GC limiter once a task may delete
+	// checkpoints.
+	checkpointGCSlotAcquired struct{}
+	// releaseCheckpointGCSlot tells the checkpoint GC limiter that a task has finished deleting
+	// checkpoints, or no longer waits to.
+	releaseCheckpointGCSlot struct{}
+)
+
+// checkpointGCLimiter bounds the number of checkpoint GC tasks that delete checkpoints at once, so
+// that many experiments being garbage collected together do not overwhelm checkpoint storage.
+// Tasks wait for a slot in the order they asked for one.
+type checkpointGCLimiter struct {
+	limit   int
+	active  map[*actor.Ref]bool
+	waiting []*actor.Ref
+}
+
+func newCheckpointGCLimiter(limit int) *checkpointGCLimiter {
+	return &checkpointGCLimiter{limit: limit, active: map[*actor.Ref]bool{}}
+}
+
+func (l *checkpointGCLimiter) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+
+	case acquireCheckpointGCSlot:
+		l.waiting = append(l.waiting, ctx.Sender())
+		l.grant(ctx)
+
+	case releaseCheckpointGCSlot:
+		delete(l.active, ctx.Sender())
+		for i, ref := range l.waiting {
+			if ref == ctx.Sender() {
+				l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+				break
+			}
+		}
+		l.grant(ctx)
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+// grant hands out free slots to waiting tasks.
+func (l *checkpointGCLimiter) grant(ctx *actor.Context) {
+	for len(l.active) < l.limit && len(l.waiting) > 0 {
+		ref := l.waiting[0]
+		l.waiting = l.waiting[1:]
+		l.active[ref] = true
+		ctx.Tell(ref, checkpointGCSlotAcquired{})
+	}
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// gcTaskStub acquires a checkpoint GC slot when it starts and reports when it gets one.
+type gcTaskStub struct {
+	id       int
+	acquired chan int
+}
+
+func (s *gcTaskStub) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		ctx.Tell(ctx.Self().System().Get(checkpointGCLimiterAddr), acquireCheckpointGCSlot{})
+	case checkpointGCSlotAcquired:
+		s.acquired <- s.id
+	case actor.PostStop:
+		ctx.Tell(ctx.Self().System().Get(checkpointGCLimiterAddr), releaseCheckpointGCSlot{})
+	}
+	return nil
+}
+
+func TestCheckpointGCLimiter(t *testing.T) {
+	system := actor.NewSystem("")
+	system.ActorOf(checkpointGCLimiterAddr, newCheckpointGCLimiter(2))
+
+	acquired := make(chan int, 10)
+	var tasks []*actor.Ref
+	for i := 0; i < 4; i++ {
+		ref, _ := system.ActorOf(
+			actor.Addr(fmt.Sprintf("gc-%d", i)), &gcTaskStub{id: i, acquired: acquired})
+		tasks = append(tasks, ref)
+		// Give each task time to ask for a slot, so that they ask in order.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	receive := func() int {
+		select {
+		case id := <-acquired:
+			return id
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a checkpoint GC slot")
+			return -1
+		}
+	}
+	assert.Equal(t, receive(), 0)
+	assert.Equal(t, receive(), 1)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, len(acquired), 0, "only two tasks hold slots at once")
+
+	// Tasks that stop while waiting give up their place in line.
+	assert.NilError(t, tasks[2].StopAndAwaitTermination())
+	assert.NilError(t, tasks[0].StopAndAwaitTermination())
+	assert.Equal(t, receive(), 3)
+}
@@ -12,6 +12,23 @@ import (
 	"github.com/determined-ai/determined/master/pkg/tasks"
 )
 
+// managerAddrs are the addresses of the actors that manage each kind of command.
+var managerAddrs = []actor.Address{
+	actor.Addr("commands"), actor.Addr("notebooks"), actor.Addr("shells"), actor.Addr("tensorboard"),
+}
+
+// SetTaskSpec replaces the task spec that a command manager launches new commands with.
+type SetTaskSpec struct {
+	TaskSpec *tasks.TaskSpec
+}
+
+// UpdateTaskSpec makes all command managers launch new commands with the given task spec.
+func UpdateTaskSpec(system *actor.System, taskSpec *tasks.TaskSpec) {
+	for _, addr := range managerAddrs {
+		system.TellAt(addr, SetTaskSpec{TaskSpec: taskSpec})
+	}
+}
+
 // RegisterAPIHandler initializes and registers the API handlers for all command related features.
 func RegisterAPIHandler(
 	system *actor.System,
@@ -85,6 +85,7 @@ type command struct {
 	allocation     resourcemanagers.Allocation
 	proxyNames     []string
 	exitStatus     *string
+	killed         bool
 	addresses      []container.Address
 
 	proxy       *actor.Ref
@@ -103,6 +104,7 @@ func (c *command) Receive(ctx *actor.Context) error {
 		c.rps = ctx.Self().System().Get(actor.Addr("resourceManagers"))
 		c.proxy = ctx.Self().System().Get(actor.Addr("proxy"))
 
+		commandID := string(c.taskID)
 		c.task = &resourcemanagers.AllocateRequest{
 			ID:             c.taskID,
 			Name:           c.config.Description,
@@ -113,6 +115,11 @@ func (c *command) Receive(ctx *actor.Context) error {
 				SingleAgent: true,
 			},
 			TaskActor: ctx.Self(),
+			UserID:    &c.owner.ID,
+			CommandID: &commandID,
+			// Commands ask for resources when they are launched, which only admins who override
+			// the maintenance mode can do during maintenance.
+			BypassMaintenance: true,
 		}
 		ctx.Tell(c.rps, *c.task)
 		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), ScheduledEvent: &c.taskID})
@@ -120,7 +127,7 @@ func (c *command) Receive(ctx *actor.Context) error {
 	case actor.PostStop:
 		c.terminate(ctx)
 
-	case resourcemanagers.ResourcesAllocated:
+	case resourcemanagers.ResourcesAllocated, resourcemanagers.RegistryTokenFetched:
 		return c.receiveSchedulerMsg(ctx)
 
 	case getSummary:
@@ -213,6 +220,7 @@ func (c *command) Receive(ctx *actor.Context) error {
 						Scheme: "http",
 						Host:   fmt.Sprintf("%s:%d", address.HostIP, address.HostPort),
 					},
+					Owner: &c.owner.ID,
 				})
 				names = append(names, string(c.taskID))
 			}
@@ -298,6 +306,16 @@ func (c *command) receiveSchedulerMsg(ctx *actor.Context) error {
 		c.userFiles = nil
 		c.additionalFiles = nil
 
+	case resourcemanagers.RegistryTokenFetched:
+		if c.task == nil || msg.ID != c.task.ID || c.exitStatus != nil {
+			return nil
+		}
+		msg.Resume(ctx)
+		// A kill that was sent while the token was fetched did not reach the container.
+		if c.killed {
+			msg.Allocation.Kill(ctx)
+		}
+
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
 	}
@@ -316,6 +334,7 @@ func (c *command) terminate(ctx *actor.Context) {
 		c.exit(ctx, "task is aborted without being scheduled")
 	} else {
 		ctx.Log().Info("task forcible terminating")
+		c.killed = true
 		c.allocation.Kill(ctx)
 	}
 }
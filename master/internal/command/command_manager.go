@@ -40,6 +40,9 @@ type CommandLaunchRequest struct {
 
 func (c *commandManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
+	case SetTaskSpec:
+		c.taskSpec = msg.TaskSpec
+
 	case *apiv1.GetCommandsRequest:
 		resp := &apiv1.GetCommandsResponse{}
 		for _, command := range ctx.AskAll(&commandv1.Command{}, ctx.Children()...).GetAll() {
@@ -191,7 +191,7 @@ func (e *eventManager) Receive(ctx *actor.Context) error {
 			break
 		}
 
-		ws, ok := msg.Accept(ctx, nil, false)
+		ws, ok := msg.Accept(ctx, nil, api.SocketOptions{Class: "command_events"})
 		if !ok {
 			break
 		}
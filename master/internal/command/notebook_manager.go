@@ -154,6 +154,9 @@ func (n *notebookManager) processLaunchRequest(
 
 func (n *notebookManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
+	case SetTaskSpec:
+		n.taskSpec = msg.TaskSpec
+
 	case *apiv1.GetNotebooksRequest:
 		resp := &apiv1.GetNotebooksResponse{}
 		for _, notebook := range ctx.AskAll(&notebookv1.Notebook{}, ctx.Children()...).GetAll() {
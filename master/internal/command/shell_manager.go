@@ -53,6 +53,9 @@ type ShellLaunchRequest struct {
 
 func (s *shellManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
+	case SetTaskSpec:
+		s.taskSpec = msg.TaskSpec
+
 	case *apiv1.GetShellsRequest:
 		resp := &apiv1.GetShellsResponse{}
 		for _, shell := range ctx.AskAll(&shellv1.Shell{}, ctx.Children()...).GetAll() {
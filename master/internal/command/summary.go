@@ -6,6 +6,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/redact"
 )
 
 type (
@@ -32,7 +33,7 @@ type (
 	}
 )
 
-// newSummary returns a new summary of the command.
+// newSummary returns a new summary of the command, with the secrets of its config redacted.
 func newSummary(c *command) summary {
 	state := "PENDING"
 	switch {
@@ -45,7 +46,7 @@ func newSummary(c *command) summary {
 		RegisteredTime: c.registeredTime,
 		Owner:          c.owner,
 		ID:             c.taskID,
-		Config:         c.config,
+		Config:         redact.Redact(c.config).(model.CommandConfig),
 		State:          state,
 		ServiceAddress: c.serviceAddress,
 		Addresses:      c.addresses,
@@ -73,6 +73,9 @@ type tensorboardTick struct{}
 
 func (t *tensorboardManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
+	case SetTaskSpec:
+		t.taskSpec = msg.TaskSpec
+
 	case actor.PreStart:
 		actors.NotifyAfter(ctx, tickInterval, tensorboardTick{})
 	case *apiv1.GetTensorboardsRequest:
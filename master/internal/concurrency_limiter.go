@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/metrics"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// concurrencyInFlightMetric and concurrencyQueuedMetric are the number of requests to each
+	// group of limited endpoints that are being served and that are waiting to be served.
+	concurrencyInFlightMetric = "det_api_concurrency_in_flight"
+	concurrencyQueuedMetric   = "det_api_concurrency_queued"
+	// concurrencyRejectedMetric counts requests rejected because a limit was reached.
+	concurrencyRejectedMetric = "det_api_concurrency_rejected_total"
+)
+
+// concurrencyLimiter bounds the number of requests to a group of endpoints that are served at
+// once, in total and for each user, with semaphores. Requests over a limit wait for a slot up to
+// the queue timeout and are then rejected with a 429 response.
+type concurrencyLimiter struct {
+	group   string
+	config  ConcurrencyLimitConfig
+	metrics *metrics.Registry
+
+	// global is nil if the total number of requests is unlimited.
+	global chan struct{}
+	// perUser holds the semaphores of the users with requests in the group, which are dropped once
+	// the users have no requests left, so that idle users don't pile up.
+	mu      sync.Mutex
+	perUser map[model.UserID]*userSemaphore
+}
+
+type userSemaphore struct {
+	slots chan struct{}
+	// refs is the number of requests of the user that hold or wait for a slot.
+	refs int
+}
+
+func newConcurrencyLimiter(
+	group string, config ConcurrencyLimitConfig, registry *metrics.Registry,
+) *concurrencyLimiter {
+	l := &concurrencyLimiter{
+		group:   group,
+		config:  config,
+		metrics: registry,
+		perUser: map[model.UserID]*userSemaphore{},
+	}
+	if config.MaxConcurrent > 0 {
+		l.global = make(chan struct{}, config.MaxConcurrent)
+	}
+	return l
+}
+
+// acquireUser returns the semaphore of the user, or nil if requests per user are unlimited. Every
+// semaphore returned must be released with releaseUser.
+func (l *concurrencyLimiter) acquireUser(userID model.UserID) *userSemaphore {
+	if l.config.MaxConcurrentPerUser == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sem, ok := l.perUser[userID]
+	if !ok {
+		sem = &userSemaphore{slots: make(chan struct{}, l.config.MaxConcurrentPerUser)}
+		l.perUser[userID] = sem
+	}
+	sem.refs++
+	return sem
+}
+
+func (l *concurrencyLimiter) releaseUser(userID model.UserID, sem *userSemaphore) {
+	if sem == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if sem.refs--; sem.refs == 0 {
+		delete(l.perUser, userID)
+	}
+}
+
+// acquire waits for a slot in the semaphores, which may be nil, until the timeout expires or done
+// is closed. It returns a function that releases the slots, or false if they were not acquired.
+func (l *concurrencyLimiter) acquire(
+	done <-chan struct{}, sems ...chan struct{},
+) (func(), bool) {
+	timer := time.NewTimer(time.Duration(l.config.QueueTimeout) * time.Second)
+	defer timer.Stop()
+
+	var acquired []chan struct{}
+	release := func() {
+		for _, sem := range acquired {
+			<-sem
+		}
+	}
+	for _, sem := range sems {
+		if sem == nil {
+			continue
+		}
+		// Take free slots without waiting, even if the timeout is zero.
+		select {
+		case sem <- struct{}{}:
+			acquired = append(acquired, sem)
+			continue
+		default:
+		}
+		select {
+		case sem <- struct{}{}:
+			acquired = append(acquired, sem)
+		case <-timer.C:
+			release()
+			return nil, false
+		case <-done:
+			release()
+			return nil, false
+		}
+	}
+	return release, true
+}
+
+// middleware limits the requests to the endpoints that it is added to. The endpoints must require
+// authentication if requests per user are limited.
+func (l *concurrencyLimiter) middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	if l.global == nil && l.config.MaxConcurrentPerUser == 0 {
+		return next
+	}
+	labels := map[string]string{"group": l.group}
+	return func(c echo.Context) error {
+		var userID model.UserID
+		var userSem *userSemaphore
+		var userSlots chan struct{}
+		if l.config.MaxConcurrentPerUser > 0 {
+			userID = c.(*context.DetContext).MustGetUser().ID
+			userSem = l.acquireUser(userID)
+			defer l.releaseUser(userID, userSem)
+			userSlots = userSem.slots
+		}
+
+		// Per-user slots are taken first, so that the requests of a user over their own limit do
+		// not hold global slots that other users could use.
+		l.metrics.Add(concurrencyQueuedMetric,
+			"Number of requests waiting to be served, by group of endpoints.", labels, 1)
+		release, ok := l.acquire(c.Request().Context().Done(), userSlots, l.global)
+		l.metrics.Add(concurrencyQueuedMetric,
+			"Number of requests waiting to be served, by group of endpoints.", labels, -1)
+		if !ok {
+			l.metrics.Inc(concurrencyRejectedMetric,
+				"Number of requests rejected because of concurrency limits, by group of endpoints.",
+				labels)
+			retryAfter := l.config.QueueTimeout
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf(
+				"too many concurrent %s requests; retry later", l.group))
+		}
+		defer release()
+
+		l.metrics.Add(concurrencyInFlightMetric,
+			"Number of requests being served, by group of endpoints.", labels, 1)
+		defer l.metrics.Add(concurrencyInFlightMetric,
+			"Number of requests being served, by group of endpoints.", labels, -1)
+		return next(c)
+	}
+}
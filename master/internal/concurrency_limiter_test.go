@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/metrics"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	registry := metrics.NewRegistry()
+	limiter := newConcurrencyLimiter("logs", ConcurrencyLimitConfig{
+		MaxConcurrent: 2, MaxConcurrentPerUser: 1,
+	}, registry)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handler := limiter.middleware(func(c echo.Context) error {
+		started <- struct{}{}
+		<-finish
+		return nil
+	})
+	serve := func(userID model.UserID) (*httptest.ResponseRecorder, chan error) {
+		rec := httptest.NewRecorder()
+		c := &context.DetContext{
+			Context: echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec),
+		}
+		c.SetUser(model.User{ID: userID})
+		errs := make(chan error, 1)
+		go func() { errs <- handler(c) }()
+		return rec, errs
+	}
+	assertRejected := func(rec *httptest.ResponseRecorder, errs chan error) {
+		httpErr, ok := (<-errs).(*echo.HTTPError)
+		assert.Assert(t, ok)
+		assert.Equal(t, httpErr.Code, http.StatusTooManyRequests)
+		assert.Equal(t, rec.Header().Get("Retry-After"), "1")
+	}
+
+	// Each user may have one request in flight, and two may be in flight in total.
+	_, first := serve(1)
+	<-started
+	assertRejected(serve(1))
+	_, second := serve(2)
+	<-started
+	assertRejected(serve(3))
+
+	var buf bytes.Buffer
+	assert.NilError(t, registry.WriteText(&buf))
+	assert.Assert(t, strings.Contains(buf.String(), `det_api_concurrency_in_flight{group="logs"} 2`))
+	assert.Assert(t, strings.Contains(buf.String(),
+		`det_api_concurrency_rejected_total{group="logs"} 2`))
+
+	finish <- struct{}{}
+	finish <- struct{}{}
+	assert.NilError(t, <-first)
+	assert.NilError(t, <-second)
+	assert.Equal(t, len(limiter.perUser), 0)
+
+	// Once the requests finish, their slots are free again.
+	_, third := serve(1)
+	<-started
+	finish <- struct{}{}
+	assert.NilError(t, <-third)
+}
+
+func TestConcurrencyLimiterUnlimited(t *testing.T) {
+	called := false
+	next := func(c echo.Context) error {
+		called = true
+		return nil
+	}
+	handler := newConcurrencyLimiter(
+		"metrics", ConcurrencyLimitConfig{QueueTimeout: 10}, metrics.NewRegistry(),
+	).middleware(next)
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	assert.NilError(t, handler(c))
+	assert.Assert(t, called)
+}
@@ -3,18 +3,34 @@ package internal
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/url"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/determined-ai/determined/master/internal/agent"
+	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/email"
 	"github.com/determined-ai/determined/master/internal/provisioner"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/internal/storage"
+	"github.com/determined-ai/determined/master/internal/telemetry"
+	actorapi "github.com/determined-ai/determined/master/pkg/actor/api"
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/jsonschema"
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/redact"
 )
 
 // These are package-level variables so that they can be set at link time.
@@ -40,6 +56,17 @@ func DefaultConfig() *Config {
 			NetworkMode:  "bridge",
 		},
 		TensorBoardTimeout: 5 * 60,
+		AskTimeout:         2,
+		// Requests that create experiments may hold a config and a model definition of up to
+		// these sizes, in bytes.
+		MaxExperimentConfigBytes: 1 << 20,
+		MaxModelDefinitionBytes:  128 << 20,
+		// Imported experiment archives also carry the metrics of every trial.
+		MaxExperimentArchiveBytes: 1 << 30,
+		// Deleted experiments can be undeleted for a week.
+		DeleteGracePeriod: 7 * 24 * 60 * 60,
+		// Container lifecycle logs explain recent task failures, so they are kept for a month.
+		TaskContainerLogRetentionDays: 30,
 		Security: SecurityConfig{
 			DefaultTask: model.AgentUserGroup{
 				UID:   0,
@@ -57,9 +84,69 @@ func DefaultConfig() *Config {
 			Enabled:          true,
 			SegmentMasterKey: DefaultSegmentMasterKey,
 			SegmentWebUIKey:  DefaultSegmentWebUIKey,
+			Categories: telemetry.Categories{
+				ClusterSnapshots:       true,
+				ExperimentStateChanges: true,
+				MasterLifecycle:        true,
+				ClusterChanges:         true,
+			},
 		},
 		EnableCors:  false,
+		EnablePprof: true,
 		ClusterName: "",
+		SearcherEvents: SearcherEventsConfig{
+			CleanupInterval: 60 * 60,
+			Retention:       0,
+		},
+		TrialLogs: TrialLogsConfig{
+			FlushCount:    1000,
+			FlushInterval: 20,
+		},
+		Checkpoints: CheckpointsConfig{
+			GCConcurrency: 10,
+		},
+		TrialHeartbeats: TrialHeartbeatsConfig{
+			Interval:  30,
+			MaxMissed: 6,
+		},
+		TrialRestarts: TrialRestartsConfig{
+			BackoffBase: 10,
+			BackoffMax:  300,
+		},
+		ExperimentSchedules: ExperimentSchedulesConfig{
+			CatchUpWindow: 60 * 60,
+		},
+		Experiments: ExperimentsConfig{
+			UniqueExternalIDs: true,
+			ListCacheTTL:      5,
+
+			RestoreConcurrency:     16,
+			RestoreRMAsksPerSecond: 20,
+			RestoreRMAskBurst:      20,
+		},
+		ConcurrencyLimits: ConcurrencyLimitsConfig{
+			Metrics:          ConcurrencyLimitConfig{QueueTimeout: 10},
+			Logs:             ConcurrencyLimitConfig{QueueTimeout: 10},
+			ModelDefinitions: ConcurrencyLimitConfig{QueueTimeout: 10},
+		},
+		ActorMailboxes: ActorMailboxesConfig{
+			HighWaterMark: 1000,
+		},
+		Tunnels: TunnelsConfig{
+			IdleTimeout: 60 * 60,
+			MaxDuration: 24 * 60 * 60,
+		},
+		WebSockets: WebSocketsConfig{
+			MaxMessageSize: actorapi.MaxWebsocketMessageSize,
+		},
+		AgentVersions: agent.VersionPolicy{Action: agent.WarnUnsupportedVersions},
+		ModelDefinitionStorage: storage.ModelDefinitionsConfig{
+			Type: storage.ModelDefinitionsPostgres,
+		},
+		AccessLog: api.AccessLogConfig{
+			// Load balancers and Prometheus poll these often.
+			SilencePaths: []string{"/info", "/metrics", "/healthz"},
+		},
 	}
 }
 
@@ -72,6 +159,7 @@ type Config struct {
 	Log                   logger.Config                     `json:"log"`
 	DB                    db.Config                         `json:"db"`
 	TensorBoardTimeout    int                               `json:"tensorboard_timeout"`
+	AskTimeout            int                               `json:"ask_timeout"`
 	Security              SecurityConfig                    `json:"security"`
 	CheckpointStorage     CheckpointStorageConfig           `json:"checkpoint_storage"`
 	TaskContainerDefaults model.TaskContainerDefaultsConfig `json:"task_container_defaults"`
@@ -80,7 +168,57 @@ type Config struct {
 	Root                  string                            `json:"root"`
 	Telemetry             TelemetryConfig                   `json:"telemetry"`
 	EnableCors            bool                              `json:"enable_cors"`
+	EnablePprof           bool                              `json:"enable_pprof"`
 	ClusterName           string                            `json:"cluster_name"`
+	MasterURL             string                            `json:"master_url"`
+	SearcherEvents        SearcherEventsConfig              `json:"searcher_events"`
+	TrialLogs             TrialLogsConfig                   `json:"trial_logs"`
+	Checkpoints           CheckpointsConfig                 `json:"checkpoints"`
+	TrialHeartbeats       TrialHeartbeatsConfig             `json:"trial_heartbeats"`
+	TrialRestarts         TrialRestartsConfig               `json:"trial_restarts"`
+	Experiments           ExperimentsConfig                 `json:"experiments"`
+	ExperimentSchedules   ExperimentSchedulesConfig         `json:"experiment_schedules"`
+	SubmitValidators      []SubmitValidatorConfig           `json:"submit_validators"`
+	SMTP                  *email.Config                     `json:"smtp"`
+	WebUI                 WebUIConfig                       `json:"webui"`
+	FeatureFlags          map[string]bool                   `json:"feature_flags"`
+	ConcurrencyLimits     ConcurrencyLimitsConfig           `json:"concurrency_limits"`
+	ActorMailboxes        ActorMailboxesConfig              `json:"actor_mailboxes"`
+	AccessLog             api.AccessLogConfig               `json:"access_log"`
+	Tunnels               TunnelsConfig                     `json:"tunnels"`
+	WebSockets            WebSocketsConfig                  `json:"websockets"`
+	AgentVersions         agent.VersionPolicy               `json:"agent_versions"`
+
+	// MetricsBearerToken, if set, lets clients that send it as a bearer token, such as Prometheus
+	// scrapers, read /metrics without logging in.
+	MetricsBearerToken string `json:"metrics_bearer_token" secret:"true"`
+
+	// ModelDefinitionStorage configures where the model definitions of experiments are stored.
+	ModelDefinitionStorage storage.ModelDefinitionsConfig `json:"model_definition_storage"`
+
+	// AllowUnknownConfigFields disables rejecting unknown fields in the master configuration.
+	AllowUnknownConfigFields bool `json:"allow_unknown_config_fields"`
+	// AllowUnknownExperimentConfigFields disables rejecting unknown fields in the configurations
+	// of submitted experiments.
+	AllowUnknownExperimentConfigFields bool `json:"allow_unknown_experiment_config_fields"`
+
+	// MaxExperimentConfigBytes limits the size of the configs of submitted experiments.
+	MaxExperimentConfigBytes int64 `json:"max_experiment_config_bytes"`
+	// MaxModelDefinitionBytes limits the size of the model definitions of submitted experiments,
+	// as they are encoded in the request.
+	MaxModelDefinitionBytes int64 `json:"max_model_definition_bytes"`
+	// MaxExperimentArchiveBytes limits the size of the experiment archives that are imported.
+	MaxExperimentArchiveBytes int64 `json:"max_experiment_archive_bytes"`
+
+	// AutoArchiveDays is the number of days after which experiments in a terminal state are
+	// archived automatically. Zero disables automatic archival.
+	AutoArchiveDays int `json:"auto_archive_days"`
+	// DeleteGracePeriod is the number of seconds for which deleted experiments can be undeleted
+	// before they are deleted for good. Zero deletes experiments right away.
+	DeleteGracePeriod int `json:"delete_grace_period"`
+	// TaskContainerLogRetentionDays is the number of days for which the logs of the lifecycle of
+	// task containers are kept. Zero keeps them forever.
+	TaskContainerLogRetentionDays int `json:"task_container_log_retention_days"`
 
 	Scheduler   *resourcemanagers.Config `json:"scheduler"`
 	Provisioner *provisioner.Config      `json:"provisioner"`
@@ -88,20 +226,62 @@ type Config struct {
 	ResourceManager *resourcemanagers.ResourceManagerConfig `json:"resource_manager"`
 }
 
-// Printable returns a printable string.
-func (c Config) Printable() ([]byte, error) {
-	const hiddenValue = "********"
-	c.DB.Password = hiddenValue
-	c.Telemetry.SegmentMasterKey = hiddenValue
-	c.Telemetry.SegmentWebUIKey = hiddenValue
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	errs := []error{
+		check.GreaterThan(c.AskTimeout, 0, "ask_timeout must be positive"),
+		check.GreaterThan(c.MaxExperimentConfigBytes, int64(0),
+			"max_experiment_config_bytes must be positive"),
+		check.GreaterThan(c.MaxModelDefinitionBytes, int64(0),
+			"max_model_definition_bytes must be positive"),
+		check.GreaterThan(c.MaxExperimentArchiveBytes, int64(0),
+			"max_experiment_archive_bytes must be positive"),
+		check.GreaterThanOrEqualTo(c.AutoArchiveDays, 0, "auto_archive_days must be non-negative"),
+		check.GreaterThanOrEqualTo(c.DeleteGracePeriod, 0,
+			"delete_grace_period must be non-negative"),
+		check.GreaterThanOrEqualTo(c.TaskContainerLogRetentionDays, 0,
+			"task_container_log_retention_days must be non-negative"),
+	}
+	if c.MasterURL != "" {
+		if parsed, err := url.Parse(c.MasterURL); err != nil || parsed.Scheme == "" ||
+			parsed.Host == "" {
+			errs = append(errs, errors.Errorf(
+				"master_url must be an absolute URL with a scheme and host, not %q", c.MasterURL))
+		}
+	}
+	return errs
+}
+
+// externalMasterURL returns the URL at which users reach the master, without a trailing slash,
+// which absolute links to the master are built from. It is "" if the URL is unknown.
+func (c Config) externalMasterURL() string {
+	if c.MasterURL != "" {
+		return strings.TrimSuffix(c.MasterURL, "/")
+	}
+	// Before master_url was added, the URL at which agents reach the master was used.
+	if c.ResourcePoolsConfig != nil {
+		for _, pool := range c.ResourcePoolsConfig.ResourcePools {
+			if pool.Provider != nil && pool.Provider.MasterURL != "" {
+				log.Warnf("master_url is not set, so links to the master use the master_url of "+
+					"the provisioner of resource pool %s; this is deprecated, set master_url instead",
+					pool.PoolName)
+				return strings.TrimSuffix(pool.Provider.MasterURL, "/")
+			}
+		}
+	}
+	return ""
+}
 
+// Printable returns a printable string, in which the values of fields tagged with
+// `secret:"true"` are hidden.
+func (c Config) Printable() ([]byte, error) {
 	cs, err := c.CheckpointStorage.printable()
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to convert checkpoint storage config to printable")
 	}
 	c.CheckpointStorage = cs
 
-	optJSON, err := json.Marshal(c)
+	optJSON, err := json.Marshal(redact.Redact(c))
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to convert config to JSON")
 	}
@@ -170,18 +350,17 @@ func (c CheckpointStorageConfig) Validate() []error {
 	return nil
 }
 
+// JSONSchema implements the jsonschema.Schemer interface.
+func (c CheckpointStorageConfig) JSONSchema() map[string]interface{} {
+	return jsonschema.Schema(reflect.TypeOf(model.CheckpointStorageConfig{}))
+}
+
 func (c *CheckpointStorageConfig) printable() ([]byte, error) {
-	var hiddenValue = "********"
-	switch csm, err := c.ToModel(); {
-	case err != nil:
+	csm, err := c.ToModel()
+	if err != nil {
 		return nil, err
-	case csm.S3Config != nil:
-		csm.S3Config.AccessKey = &hiddenValue
-		csm.S3Config.SecretKey = &hiddenValue
-		return csm.MarshalJSON()
-	default:
-		return csm.MarshalJSON()
 	}
+	return redact.Redact(*csm).(model.CheckpointStorageConfig).MarshalJSON()
 }
 
 // FromModel initializes a CheckpointStorageConfig from the corresponding model.
@@ -242,12 +421,84 @@ func (c *CheckpointStorageConfig) UnmarshalJSON(data []byte) error {
 type SecurityConfig struct {
 	DefaultTask model.AgentUserGroup `json:"default_task"`
 	TLS         TLSConfig            `json:"tls"`
+	Headers     HeadersConfig        `json:"headers"`
+}
+
+// headerNamePattern matches valid HTTP header names.
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// HeadersConfig configures the security headers that the master sends in every response, in
+// addition to the fixed X-XSS-Protection, X-Content-Type-Options and X-Frame-Options headers.
+// Empty headers are not sent.
+type HeadersConfig struct {
+	ContentSecurityPolicy string `json:"content_security_policy"`
+	ReferrerPolicy        string `json:"referrer_policy"`
+	PermissionsPolicy     string `json:"permissions_policy"`
+	// HSTSMaxAge is the number of seconds that browsers should only reach the master over HTTPS,
+	// as sent in the Strict-Transport-Security header. The header is only sent in responses to
+	// requests made over TLS, including through a proxy that terminates TLS.
+	HSTSMaxAge            int  `json:"hsts_max_age"`
+	HSTSExcludeSubdomains bool `json:"hsts_exclude_subdomains"`
+	// Custom holds further headers to send, keyed by name.
+	Custom map[string]string `json:"custom"`
+}
+
+// Validate implements the check.Validatable interface.
+func (h HeadersConfig) Validate() []error {
+	errs := []error{
+		check.GreaterThanOrEqualTo(h.HSTSMaxAge, 0,
+			"security.headers.hsts_max_age must be non-negative"),
+	}
+	headers := h.staticHeaders()
+	headers["Content-Security-Policy"] = h.ContentSecurityPolicy
+	for name, value := range headers {
+		if !headerNamePattern.MatchString(name) {
+			errs = append(errs, errors.Errorf(
+				"security.headers.custom has an invalid header name %q", name))
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			errs = append(errs, errors.Errorf(
+				"security.headers must not contain line breaks, but %s does", name))
+		}
+	}
+	return errs
+}
+
+// staticHeaders returns the headers that are sent in every response, keyed by name.
+func (h HeadersConfig) staticHeaders() map[string]string {
+	headers := map[string]string{}
+	for name, value := range h.Custom {
+		headers[name] = value
+	}
+	for name, value := range map[string]string{
+		"Referrer-Policy":    h.ReferrerPolicy,
+		"Permissions-Policy": h.PermissionsPolicy,
+	} {
+		if value != "" {
+			headers[name] = value
+		}
+	}
+	return headers
 }
 
+// The ways in which the master can ask clients for TLS certificates.
+const (
+	// ClientAuthRequireAndVerify rejects connections without a valid client certificate.
+	ClientAuthRequireAndVerify = "require-and-verify"
+	// ClientAuthVerifyIfGiven accepts connections without a client certificate, but rejects those
+	// with an invalid one.
+	ClientAuthVerifyIfGiven = "verify-if-given"
+)
+
 // TLSConfig is the configuration for setting up serving over TLS.
 type TLSConfig struct {
 	Cert string `json:"cert"`
-	Key  string `json:"key"`
+	Key  string `json:"key" secret:"false"`
+	// ClientAuth is whether clients must present certificates signed by one of the CAs in the
+	// ClientCA file. Requests with a valid certificate are authenticated as the user named by its
+	// common name.
+	ClientAuth string `json:"client_auth"`
+	ClientCA   string `json:"client_ca"`
 }
 
 // Validate implements the check.Validatable interface.
@@ -258,6 +509,23 @@ func (t *TLSConfig) Validate() []error {
 	} else if t.Key == "" && t.Cert != "" {
 		errs = append(errs, errors.New("TLS cert file provided without a key file"))
 	}
+	switch t.ClientAuth {
+	case "":
+		if t.ClientCA != "" {
+			errs = append(errs, errors.New("TLS client CA file provided without client_auth"))
+		}
+	case ClientAuthRequireAndVerify, ClientAuthVerifyIfGiven:
+		if !t.Enabled() {
+			errs = append(errs, errors.New("TLS client authentication requires TLS to be enabled"))
+		}
+		if t.ClientCA == "" {
+			errs = append(errs, errors.New("TLS client authentication requires a client CA file"))
+		}
+	default:
+		errs = append(errs, errors.Errorf(
+			"invalid TLS client_auth %q: must be %q or %q",
+			t.ClientAuth, ClientAuthRequireAndVerify, ClientAuthVerifyIfGiven))
+	}
 	return errs
 }
 
@@ -276,9 +544,315 @@ func (t *TLSConfig) ReadCertificate() (*tls.Certificate, error) {
 	return &cert, err
 }
 
+// ClientAuthType returns the policy for TLS client authentication described by this
+// configuration.
+func (t *TLSConfig) ClientAuthType() tls.ClientAuthType {
+	switch t.ClientAuth {
+	case ClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven
+	default:
+		return tls.NoClientCert
+	}
+}
+
+// ReadClientCAs returns the CAs that verify client certificates (nil if client authentication is
+// disabled).
+func (t *TLSConfig) ReadClientCAs() (*x509.CertPool, error) {
+	if t.ClientAuth == "" {
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(t.ClientCA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates found in %s", t.ClientCA)
+	}
+	return pool, nil
+}
+
 // TelemetryConfig is the configuration for telemetry.
 type TelemetryConfig struct {
 	Enabled          bool   `json:"enabled"`
-	SegmentMasterKey string `json:"segment_master_key"`
-	SegmentWebUIKey  string `json:"segment_webui_key"`
+	SegmentMasterKey string `json:"segment_master_key" secret:"true"`
+	SegmentWebUIKey  string `json:"segment_webui_key" secret:"true"`
+	telemetry.Categories
+}
+
+// SearcherEventsConfig configures the cleanup of searcher events, which are only needed to
+// restore non-terminal experiments after a master restart.
+type SearcherEventsConfig struct {
+	// CleanupInterval is the number of seconds between cleanup passes. If it is zero, cleanup only
+	// runs when the master starts or when an admin requests it.
+	CleanupInterval int `json:"cleanup_interval" description:"the seconds between cleanup passes"`
+	// Retention is the number of seconds to keep the searcher events of an experiment after it
+	// reaches a terminal state. If it is zero, they are deleted as soon as the experiment ends.
+	Retention int `json:"retention" description:"the seconds events are kept after an experiment ends"`
+}
+
+// Validate implements the check.Validatable interface.
+func (s SearcherEventsConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(s.CleanupInterval, 0,
+			"searcher_events.cleanup_interval must be non-negative"),
+		check.GreaterThanOrEqualTo(s.Retention, 0, "searcher_events.retention must be non-negative"),
+	}
+}
+
+// TrialLogsConfig configures how the master batches trial logs before saving them to the database.
+// A batch is saved once it holds FlushCount logs, or at least every FlushInterval.
+type TrialLogsConfig struct {
+	// FlushCount is the largest number of logs saved in a single insert. For the strategy of
+	// many-rows-per-insert, performance was significantly worse below 500, and no improvements
+	// after 1000.
+	FlushCount int `json:"flush_count" description:"the largest number of logs saved at once"`
+	// FlushInterval is the number of milliseconds that logs are held before being saved. It is set
+	// low by default to ensure a good user experience.
+	FlushInterval int `json:"flush_interval" description:"how many milliseconds logs are held"`
+}
+
+// Validate implements the check.Validatable interface.
+func (t TrialLogsConfig) Validate() []error {
+	return []error{
+		check.GreaterThan(t.FlushCount, 0, "trial_logs.flush_count must be positive"),
+		check.GreaterThan(t.FlushInterval, 0, "trial_logs.flush_interval must be positive"),
+	}
+}
+
+// CheckpointsConfig configures how the master manages checkpoints.
+type CheckpointsConfig struct {
+	// GCConcurrency is the largest number of checkpoint GC tasks that delete checkpoints from
+	// checkpoint storage at once. Other GC tasks wait for one of them to finish.
+	GCConcurrency int `json:"gc_concurrency" description:"how many checkpoint GC tasks run at once"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c CheckpointsConfig) Validate() []error {
+	return []error{
+		check.GreaterThan(c.GCConcurrency, 0, "checkpoints.gc_concurrency must be positive"),
+	}
+}
+
+// TrialHeartbeatsConfig configures how the master detects trials whose containers or agents
+// stopped responding, e.g., because a machine lost power, so that they can be requeued.
+type TrialHeartbeatsConfig struct {
+	// Interval is the number of seconds between the heartbeats that trial containers send. Zero
+	// disables heartbeats.
+	Interval int `json:"interval" description:"the seconds between heartbeats of trial containers"`
+	// MaxMissed is the number of intervals that may pass without a heartbeat from a container or
+	// agent of a trial before the trial is considered lost.
+	MaxMissed int `json:"max_missed" description:"how many heartbeats may be missed"`
+}
+
+// Timeout returns how long a container or agent may go without a heartbeat.
+func (t TrialHeartbeatsConfig) Timeout() time.Duration {
+	return time.Duration(t.Interval*t.MaxMissed) * time.Second
+}
+
+// Validate implements the check.Validatable interface.
+func (t TrialHeartbeatsConfig) Validate() []error {
+	errs := []error{
+		check.GreaterThanOrEqualTo(t.Interval, 0, "trial_heartbeats.interval must be non-negative"),
+		check.GreaterThan(t.MaxMissed, 0, "trial_heartbeats.max_missed must be positive"),
+	}
+	// Agents send heartbeats whenever they answer the pings of the master.
+	if agentInterval := int(actorapi.PingInterval / time.Second); t.Interval > 0 {
+		errs = append(errs, check.GreaterThanOrEqualTo(t.Interval*t.MaxMissed, 2*agentInterval,
+			"trial_heartbeats.interval * trial_heartbeats.max_missed must be at least %d seconds, "+
+				"since agents only send heartbeats every %d seconds", 2*agentInterval, agentInterval))
+	}
+	return errs
+}
+
+// TrialRestartsConfig configures how long trials wait before they are restarted after a failure,
+// so that trials that crash right away do not restart in a tight loop. The wait doubles with every
+// failure, starting from the base and up to the maximum.
+type TrialRestartsConfig struct {
+	// BackoffBase is the number of seconds to wait after the first failure. Zero disables waiting.
+	BackoffBase int `json:"backoff_base" description:"the seconds to wait after the first failure"`
+	// BackoffMax is the largest number of seconds to wait after a failure.
+	BackoffMax int `json:"backoff_max" description:"the most seconds to wait after a failure"`
+}
+
+// Backoff returns how long to wait before restarting a trial that has failed the given number of
+// times.
+func (t TrialRestartsConfig) Backoff(failures int) time.Duration {
+	if t.BackoffBase == 0 || failures <= 0 {
+		return 0
+	}
+	backoff := t.BackoffBase
+	for i := 1; i < failures && backoff < t.BackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > t.BackoffMax {
+		backoff = t.BackoffMax
+	}
+	return time.Duration(backoff) * time.Second
+}
+
+// Validate implements the check.Validatable interface.
+func (t TrialRestartsConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(t.BackoffBase, 0,
+			"trial_restarts.backoff_base must be non-negative"),
+		check.GreaterThanOrEqualTo(t.BackoffMax, t.BackoffBase,
+			"trial_restarts.backoff_max must be at least trial_restarts.backoff_base"),
+	}
+}
+
+// ExperimentSchedulesConfig configures how the master creates experiments from their schedules.
+type ExperimentSchedulesConfig struct {
+	// CatchUpWindow is the number of seconds for which a schedule that was due while the master
+	// was down still runs once the master starts; later, it skips to its next time.
+	CatchUpWindow int `json:"catch_up_window"`
+}
+
+// Validate implements the check.Validatable interface.
+func (e ExperimentSchedulesConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(e.CatchUpWindow, 0,
+			"experiment_schedules.catch_up_window must be non-negative"),
+	}
+}
+
+// ActorMailboxesConfig configures the mailboxes in which messages to the actors of the master wait
+// to be processed.
+type ActorMailboxesConfig struct {
+	// Capacity is the number of messages that the mailbox of an actor holds before further
+	// messages to it are dropped. Zero means unbounded.
+	Capacity int `json:"capacity"`
+	// Capacities overrides Capacity for the actors at the given addresses, e.g., "/trialLogger".
+	Capacities map[string]int `json:"capacities"`
+	// HighWaterMark is the number of messages in the mailbox of an actor at which a warning is
+	// logged. Zero disables the warning.
+	HighWaterMark int `json:"high_water_mark"`
+}
+
+// TunnelsConfig configures the CONNECT tunnels through which users reach their commands, notebooks
+// and shells.
+type TunnelsConfig struct {
+	// IdleTimeout is the number of seconds after which tunnels that copy no traffic are closed.
+	// Zero disables the timeout.
+	IdleTimeout int `json:"idle_timeout"`
+	// MaxDuration is the number of seconds after which tunnels are closed regardless of their
+	// traffic. Zero disables the limit.
+	MaxDuration int `json:"max_duration"`
+}
+
+// Validate implements the check.Validatable interface.
+func (t TunnelsConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(t.IdleTimeout, 0, "tunnels.idle_timeout must be non-negative"),
+		check.GreaterThanOrEqualTo(t.MaxDuration, 0, "tunnels.max_duration must be non-negative"),
+	}
+}
+
+// WebSocketsConfig configures the websockets of trial containers, agents and other clients.
+type WebSocketsConfig struct {
+	// MaxMessageSize is the number of bytes of the largest message that the master reads from a
+	// websocket. Larger messages are dropped, and fail the workload of the trial that sent them.
+	MaxMessageSize int64 `json:"max_message_size"`
+}
+
+// Validate implements the check.Validatable interface.
+func (w WebSocketsConfig) Validate() []error {
+	return []error{
+		check.GreaterThan(w.MaxMessageSize, int64(0), "websockets.max_message_size must be positive"),
+	}
+}
+
+// Validate implements the check.Validatable interface.
+func (a ActorMailboxesConfig) Validate() []error {
+	errs := []error{
+		check.GreaterThanOrEqualTo(a.Capacity, 0, "actor_mailboxes.capacity must be non-negative"),
+		check.GreaterThanOrEqualTo(a.HighWaterMark, 0,
+			"actor_mailboxes.high_water_mark must be non-negative"),
+	}
+	for address, capacity := range a.Capacities {
+		errs = append(errs, check.GreaterThanOrEqualTo(capacity, 0,
+			fmt.Sprintf("actor_mailboxes.capacities[%q] must be non-negative", address)))
+	}
+	return errs
+}
+
+// ExperimentsConfig configures how the master accepts and lists experiments.
+type ExperimentsConfig struct {
+	// UniqueExternalIDs rejects experiments whose external ID is already used by another
+	// experiment, so that external systems can find exactly one experiment by their own ID.
+	UniqueExternalIDs bool `json:"unique_external_ids"`
+	// ListCacheTTL is the number of seconds for which the experiment list and summaries are
+	// cached, unless an experiment changes first. Zero disables caching.
+	ListCacheTTL int `json:"list_cache_ttl"`
+	// RestoreConcurrency is the number of experiments that the master restores at once when it
+	// starts.
+	RestoreConcurrency int `json:"restore_concurrency"`
+	// RestoreRMAsksPerSecond is the rate at which restoring experiments and trials may ask the
+	// resource manager for resources, and RestoreRMAskBurst is how many asks may be made at once
+	// after a lull. Zero disables the limit.
+	RestoreRMAsksPerSecond float64 `json:"restore_rm_asks_per_second"`
+	RestoreRMAskBurst      int     `json:"restore_rm_ask_burst"`
+}
+
+// Validate implements the check.Validatable interface.
+func (e ExperimentsConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(e.ListCacheTTL, 0,
+			"experiments.list_cache_ttl must be non-negative"),
+		check.GreaterThan(e.RestoreConcurrency, 0,
+			"experiments.restore_concurrency must be positive"),
+		check.GreaterThanOrEqualTo(e.RestoreRMAsksPerSecond, 0.0,
+			"experiments.restore_rm_asks_per_second must be non-negative"),
+		check.GreaterThan(e.RestoreRMAskBurst, 0,
+			"experiments.restore_rm_ask_burst must be positive"),
+	}
+}
+
+// ConcurrencyLimitsConfig limits how many requests to each group of expensive endpoints the
+// master serves at once, so that a few clients cannot saturate the database.
+type ConcurrencyLimitsConfig struct {
+	// Metrics covers the endpoints that return the metrics of trials and experiments.
+	Metrics ConcurrencyLimitConfig `json:"metrics"`
+	// Logs covers the endpoints that return trial logs.
+	Logs ConcurrencyLimitConfig `json:"logs"`
+	// ModelDefinitions covers the endpoints that download the model definitions of experiments.
+	ModelDefinitions ConcurrencyLimitConfig `json:"model_definitions"`
+}
+
+// ConcurrencyLimitConfig limits how many requests to a group of endpoints are served at once, in
+// total and for each user. Zero leaves the number unlimited.
+type ConcurrencyLimitConfig struct {
+	MaxConcurrent        int `json:"max_concurrent"`
+	MaxConcurrentPerUser int `json:"max_concurrent_per_user"`
+	// QueueTimeout is the number of seconds that a request waits for the other requests to finish
+	// before it is rejected.
+	QueueTimeout int `json:"queue_timeout"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c ConcurrencyLimitConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(c.MaxConcurrent, 0, "max_concurrent must be non-negative"),
+		check.GreaterThanOrEqualTo(c.MaxConcurrentPerUser, 0,
+			"max_concurrent_per_user must be non-negative"),
+		check.GreaterThanOrEqualTo(c.QueueTimeout, 0, "queue_timeout must be non-negative"),
+	}
+}
+
+// WebUIConfig configures how the master serves the WebUI.
+type WebUIConfig struct {
+	// APIPathPattern is a regular expression matched against request paths relative to the WebUI
+	// route. Requests whose path matches it, or starts with "api/", get a JSON 404 response instead
+	// of the WebUI's index page when there is no file at that path.
+	APIPathPattern string `json:"api_path_pattern"`
+}
+
+// Validate implements the check.Validatable interface.
+func (w WebUIConfig) Validate() []error {
+	if _, err := regexp.Compile(w.APIPathPattern); err != nil {
+		return []error{errors.Wrap(err, "webui.api_path_pattern must be a valid regular expression")}
+	}
+	return nil
 }
@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/internal/telemetry"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+var telemetryAddr = actor.Addr("telemetry")
+
+// reloadableConfigFields are the top-level fields of the master configuration that can be changed
+// without restarting the master, keyed by their JSON name. Each copies the field from src to dst.
+var reloadableConfigFields = map[string]func(dst, src *Config){
+	"log":         func(dst, src *Config) { dst.Log = src.Log },
+	"telemetry":   func(dst, src *Config) { dst.Telemetry = src.Telemetry },
+	"enable_cors": func(dst, src *Config) { dst.EnableCors = src.EnableCors },
+	"access_log":  func(dst, src *Config) { dst.AccessLog = src.AccessLog },
+	"ask_timeout": func(dst, src *Config) { dst.AskTimeout = src.AskTimeout },
+	"experiments": func(dst, src *Config) { dst.Experiments = src.Experiments },
+	"experiment_schedules": func(dst, src *Config) {
+		dst.ExperimentSchedules = src.ExperimentSchedules
+	},
+	"submit_validators": func(dst, src *Config) {
+		dst.SubmitValidators = src.SubmitValidators
+	},
+	"feature_flags": func(dst, src *Config) {
+		dst.FeatureFlags = src.FeatureFlags
+	},
+	"max_experiment_config_bytes": func(dst, src *Config) {
+		dst.MaxExperimentConfigBytes = src.MaxExperimentConfigBytes
+	},
+	"max_model_definition_bytes": func(dst, src *Config) {
+		dst.MaxModelDefinitionBytes = src.MaxModelDefinitionBytes
+	},
+	"max_experiment_archive_bytes": func(dst, src *Config) {
+		dst.MaxExperimentArchiveBytes = src.MaxExperimentArchiveBytes
+	},
+	"task_container_defaults": func(dst, src *Config) {
+		dst.TaskContainerDefaults = src.TaskContainerDefaults
+	},
+}
+
+// ConfigReload describes the outcome of reloading the master configuration.
+type ConfigReload struct {
+	// Applied lists the fields that changed and took effect.
+	Applied []string `json:"applied"`
+	// RequireRestart lists the fields that changed but only take effect once the master is
+	// restarted.
+	RequireRestart []string `json:"require_restart"`
+}
+
+// changedConfigFields returns the JSON names of the top-level fields that differ between the two
+// configurations, sorted.
+func changedConfigFields(current, next *Config) ([]string, error) {
+	fields := func(c *Config) (map[string]json.RawMessage, error) {
+		bs, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]json.RawMessage
+		return m, json.Unmarshal(bs, &m)
+	}
+	currentFields, err := fields(current)
+	if err != nil {
+		return nil, err
+	}
+	nextFields, err := fields(next)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for name, value := range nextFields {
+		if string(currentFields[name]) != string(value) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range currentFields {
+		if _, ok := nextFields[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// applyConfigReload returns a copy of current with the reloadable fields of next applied to it,
+// along with which changed fields were applied and which require a restart.
+func applyConfigReload(current, next *Config) (*Config, *ConfigReload, error) {
+	changed, err := changedConfigFields(current, next)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error comparing configurations")
+	}
+
+	updated := *current
+	reload := &ConfigReload{Applied: []string{}, RequireRestart: []string{}}
+	for _, name := range changed {
+		if apply, ok := reloadableConfigFields[name]; ok {
+			apply(&updated, next)
+			reload.Applied = append(reload.Applied, name)
+		} else {
+			reload.RequireRestart = append(reload.RequireRestart, name)
+		}
+	}
+	return &updated, reload, nil
+}
+
+// reloadConfig re-reads the master configuration and applies the fields that are safe to change
+// while the master is running.
+func (m *Master) reloadConfig() (*ConfigReload, error) {
+	if m.loadConfig == nil {
+		return nil, errors.New("the master configuration cannot be reloaded")
+	}
+	m.reloadLock.Lock()
+	defer m.reloadLock.Unlock()
+
+	newConfig, err := m.loadConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "error reloading master configuration")
+	}
+
+	m.configLock.Lock()
+	updated, reload, err := applyConfigReload(m.config, newConfig)
+	if err != nil {
+		m.configLock.Unlock()
+		return nil, err
+	}
+	m.config = updated
+	var taskSpec *tasks.TaskSpec
+	for _, name := range reload.Applied {
+		switch name {
+		case "log":
+			logger.SetLogrus(updated.Log)
+		case "task_container_defaults":
+			spec := *m.taskSpec
+			spec.TaskContainerDefaults = updated.TaskContainerDefaults
+			m.taskSpec, taskSpec = &spec, &spec
+		}
+	}
+	m.configLock.Unlock()
+
+	for _, name := range reload.Applied {
+		switch name {
+		case "task_container_defaults":
+			command.UpdateTaskSpec(m.system, taskSpec)
+		case "telemetry":
+			if ref := m.system.Get(telemetryAddr); ref != nil {
+				if err := ref.StopAndAwaitTermination(); err != nil {
+					log.WithError(err).Warn("failed to stop telemetry")
+				}
+			}
+			m.startTelemetry(updated)
+		}
+	}
+
+	log.Infof("reloaded master configuration: applied %v, requiring restart %v",
+		reload.Applied, reload.RequireRestart)
+	return reload, nil
+}
+
+// handleReloadSignal reloads the master configuration whenever the master receives SIGHUP.
+func (m *Master) handleReloadSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			log.Info("received SIGHUP, reloading master configuration")
+			if _, err := m.reloadConfig(); err != nil {
+				log.WithError(err).Error("failed to reload master configuration")
+			}
+		}
+	}()
+}
+
+// flushOnShutdown stops the trial logger and telemetry when the master is asked to terminate, so
+// that the logs and telemetry they buffer are sent, and then lets the signal terminate the master
+// as usual.
+func (m *Master) flushOnShutdown() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Infof("received %s, flushing trial logs and telemetry", sig)
+		if err := m.trialLogger.StopAndAwaitTermination(); err != nil {
+			log.WithError(err).Error("failed to flush trial logs")
+		}
+		if ref := m.system.Get(telemetryAddr); ref != nil {
+			if err := ref.StopAndAwaitTermination(); err != nil {
+				log.WithError(err).Error("failed to flush telemetry")
+			}
+		}
+		signal.Stop(signals)
+		if err := syscall.Kill(syscall.Getpid(), sig.(syscall.Signal)); err != nil {
+			log.WithError(err).Errorf("failed to resend %s", sig)
+			os.Exit(1)
+		}
+	}()
+}
+
+func (m *Master) postReloadConfig(c echo.Context) (interface{}, error) {
+	return m.reloadConfig()
+}
+
+// startTelemetry starts reporting telemetry if it is enabled by the configuration.
+func (m *Master) startTelemetry(config *Config) {
+	if !config.Telemetry.Enabled || config.Telemetry.SegmentMasterKey == "" {
+		log.Info("telemetry reporting is disabled")
+		return
+	}
+	t, err := telemetry.NewActor(
+		m.db, m.telemetryInfo(config), config.Telemetry.SegmentMasterKey, config.Telemetry.Categories,
+	)
+	if err != nil {
+		// We wouldn't want to totally fail just because telemetry failed; just note the error.
+		log.WithError(err).Errorf("failed to initialize telemetry")
+		return
+	}
+	log.Info("telemetry reporting is enabled; run with `--telemetry-enabled=false` to disable")
+	m.system.ActorOf(telemetryAddr, t)
+}
+
+// telemetryInfo identifies the master in the telemetry that it reports.
+func (m *Master) telemetryInfo(config *Config) telemetry.MasterInfo {
+	return telemetry.MasterInfo{
+		ClusterID:           m.ClusterID,
+		MasterID:            m.MasterID,
+		MasterVersion:       m.Version,
+		ResourceManagerType: resourcemanagers.GetResourceManagerType(config.ResourceManager),
+	}
+}
+
+// getTelemetryPreview returns the telemetry that the master reports when it starts and then
+// periodically, as currently configured, even if telemetry is disabled.
+func (m *Master) getTelemetryPreview(c echo.Context) (interface{}, error) {
+	config := m.currentConfig()
+	return telemetry.Preview(m.db, m.telemetryInfo(config), config.Telemetry.Categories)
+}
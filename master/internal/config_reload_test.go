@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestApplyConfigReload(t *testing.T) {
+	current := DefaultConfig()
+	next := DefaultConfig()
+	next.Log.Level = "debug"
+	next.EnableCors = true
+	next.Port = 9090
+	next.DB.Host = "other-db"
+	next.TaskContainerDefaults.ShmSizeBytes = 1024
+	next.MaxModelDefinitionBytes = 1 << 20
+
+	updated, reload, err := applyConfigReload(current, next)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reload.Applied, []string{
+		"enable_cors", "log", "max_model_definition_bytes", "task_container_defaults",
+	})
+	assert.DeepEqual(t, reload.RequireRestart, []string{"db", "port"})
+
+	assert.Equal(t, updated.Log.Level, "debug")
+	assert.Equal(t, updated.EnableCors, true)
+	assert.Equal(t, updated.TaskContainerDefaults.ShmSizeBytes, int64(1024))
+	assert.Equal(t, updated.MaxModelDefinitionBytes, int64(1<<20))
+	assert.Equal(t, updated.Port, current.Port)
+	assert.Equal(t, updated.DB.Host, current.DB.Host)
+	assert.Equal(t, current.EnableCors, false)
+}
+
+func TestApplyConfigReloadUnchanged(t *testing.T) {
+	updated, reload, err := applyConfigReload(DefaultConfig(), DefaultConfig())
+	assert.NilError(t, err)
+	assert.Equal(t, len(reload.Applied), 0)
+	assert.Equal(t, len(reload.RequireRestart), 0)
+	assert.DeepEqual(t, updated, DefaultConfig())
+}
@@ -1,6 +1,10 @@
 package internal
 
 import (
+	"crypto/tls"
+	"encoding/json"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -12,7 +16,10 @@ import (
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/provisioner"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/redact"
 )
 
 func TestUnmarshalConfigWithProvisioner(t *testing.T) {
@@ -156,3 +163,185 @@ checkpoint_storage:
 	assert.NilError(t, err)
 	assert.DeepEqual(t, unmarshaled, expected)
 }
+
+func TestPrintableRedactsSecrets(t *testing.T) {
+	config := DefaultConfig()
+	config.DB.Password = "db_password"
+	config.Telemetry.SegmentMasterKey = "segment_secret"
+	config.CheckpointStorage = CheckpointStorageConfig(`{
+  "type": "s3",
+  "bucket": "my_bucket",
+  "access_key": "my_key",
+  "secret_key": "my_secret"
+}`)
+
+	printable, err := config.Printable()
+	assert.NilError(t, err)
+	for _, secret := range []string{"db_password", "segment_secret", "my_key", "my_secret"} {
+		assert.Assert(t, !strings.Contains(string(printable), secret), secret)
+	}
+
+	var parsed struct {
+		DB                db.Config       `json:"db"`
+		Telemetry         TelemetryConfig `json:"telemetry"`
+		CheckpointStorage model.S3Config  `json:"checkpoint_storage"`
+	}
+	assert.NilError(t, json.Unmarshal(printable, &parsed))
+	assert.Equal(t, parsed.DB.Password, redact.Mask)
+	assert.Equal(t, parsed.Telemetry.SegmentMasterKey, redact.Mask)
+	assert.Equal(t, parsed.Telemetry.SegmentWebUIKey, "")
+	assert.Equal(t, *parsed.CheckpointStorage.AccessKey, redact.Mask)
+	assert.Equal(t, *parsed.CheckpointStorage.SecretKey, redact.Mask)
+	assert.Equal(t, parsed.CheckpointStorage.Bucket, "my_bucket")
+
+	// The configuration itself keeps its secrets.
+	assert.Equal(t, config.DB.Password, "db_password")
+}
+
+// TestSecretFieldsAreTagged fails when a configuration field is named like a secret but does not
+// say whether it is one, so that new secrets are not printed by accident.
+func TestSecretFieldsAreTagged(t *testing.T) {
+	secretName := regexp.MustCompile(`(?i)(password|secret|key|token|credential)`)
+	seen := map[reflect.Type]bool{}
+	var check func(t reflect.Type, path string) []string
+	check = func(t reflect.Type, path string) []string {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice ||
+			t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] ||
+			!strings.HasPrefix(t.PkgPath(), "github.com/determined-ai/determined") {
+			return nil
+		}
+		seen[t] = true
+		var untagged []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := path + "." + field.Name
+			if _, ok := field.Tag.Lookup("secret"); !ok && secretName.MatchString(field.Name) {
+				untagged = append(untagged, name)
+			}
+			untagged = append(untagged, check(field.Type, name)...)
+		}
+		return untagged
+	}
+
+	var untagged []string
+	untagged = append(untagged, check(reflect.TypeOf(Config{}), "Config")...)
+	untagged = append(untagged,
+		check(reflect.TypeOf(model.CheckpointStorageConfig{}), "CheckpointStorageConfig")...)
+	assert.Assert(t, len(untagged) == 0,
+		"fields that may hold secrets must be tagged with `secret:\"true\"` or "+
+			"`secret:\"false\"`: %v", untagged)
+}
+
+func TestTelemetryCategoriesDefaultToEnabled(t *testing.T) {
+	config := DefaultConfig()
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+telemetry:
+  enabled: true
+  cluster_snapshots: false
+`), config))
+	assert.Equal(t, config.Telemetry.ClusterSnapshots, false)
+	assert.Equal(t, config.Telemetry.ExperimentStateChanges, true)
+	assert.Equal(t, config.Telemetry.MasterLifecycle, true)
+	assert.Equal(t, config.Telemetry.ClusterChanges, true)
+}
+
+func TestTrialRestartBackoff(t *testing.T) {
+	restarts := DefaultConfig().TrialRestarts
+	assert.Equal(t, restarts.Backoff(0), time.Duration(0))
+	assert.Equal(t, restarts.Backoff(1), 10*time.Second)
+	assert.Equal(t, restarts.Backoff(3), 40*time.Second)
+	assert.Equal(t, restarts.Backoff(6), 300*time.Second)
+	assert.Equal(t, restarts.Backoff(100), 300*time.Second)
+
+	restarts.BackoffBase = 0
+	assert.Equal(t, restarts.Backoff(3), time.Duration(0))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	config := DefaultConfig()
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+security:
+  headers:
+    referrer_policy: no-referrer
+    permissions_policy: camera=()
+    hsts_max_age: 31536000
+    custom:
+      Cross-Origin-Opener-Policy: same-origin
+`), config))
+	headers := config.Security.Headers
+	assert.Equal(t, headers.HSTSMaxAge, 31536000)
+	assert.DeepEqual(t, headers.staticHeaders(), map[string]string{
+		"Referrer-Policy":            "no-referrer",
+		"Permissions-Policy":         "camera=()",
+		"Cross-Origin-Opener-Policy": "same-origin",
+	})
+	assert.NilError(t, check.Validate(headers))
+
+	headers.Custom["Bad Header"] = "value"
+	headers.ContentSecurityPolicy = "default-src 'self'\r\nSet-Cookie: x"
+	err := check.Validate(headers)
+	assert.ErrorContains(t, err, `invalid header name "Bad Header"`)
+	assert.ErrorContains(t, err, "but Content-Security-Policy does")
+}
+
+func TestExternalMasterURL(t *testing.T) {
+	config := DefaultConfig()
+	assert.Equal(t, config.externalMasterURL(), "")
+
+	config.ResourcePoolsConfig = &resourcemanagers.ResourcePoolsConfig{
+		ResourcePools: []resourcemanagers.ResourcePoolConfig{
+			{PoolName: "default"},
+			{PoolName: "aws", Provider: &provisioner.Config{MasterURL: "http://10.0.0.1:8080"}},
+		},
+	}
+	assert.Equal(t, config.externalMasterURL(), "http://10.0.0.1:8080")
+
+	config.MasterURL = "https://det.example.com/"
+	assert.Equal(t, config.externalMasterURL(), "https://det.example.com")
+
+	config.ResourcePoolsConfig = nil
+	assert.NilError(t, check.Validate(config))
+
+	config.MasterURL = "det.example.com"
+	assert.ErrorContains(t, check.Validate(config),
+		`master_url must be an absolute URL with a scheme and host, not "det.example.com"`)
+}
+
+func TestDeleteGracePeriod(t *testing.T) {
+	config := DefaultConfig()
+	assert.Equal(t, config.DeleteGracePeriod, 7*24*60*60)
+
+	assert.NilError(t, yaml.Unmarshal([]byte(`delete_grace_period: 0`), config))
+	assert.NilError(t, check.Validate(config))
+
+	config.DeleteGracePeriod = -1
+	assert.ErrorContains(t, check.Validate(config), "delete_grace_period must be non-negative")
+}
+
+func TestTLSClientAuth(t *testing.T) {
+	config := TLSConfig{Cert: "cert.pem", Key: "key.pem"}
+	assert.NilError(t, check.Validate(config))
+	assert.Equal(t, config.ClientAuthType(), tls.NoClientCert)
+
+	config.ClientAuth = ClientAuthVerifyIfGiven
+	assert.ErrorContains(t, check.Validate(config), "requires a client CA file")
+	config.ClientCA = "ca.pem"
+	assert.NilError(t, check.Validate(config))
+	assert.Equal(t, config.ClientAuthType(), tls.VerifyClientCertIfGiven)
+
+	config.ClientAuth = ClientAuthRequireAndVerify
+	assert.Equal(t, config.ClientAuthType(), tls.RequireAndVerifyClientCert)
+	config.Cert, config.Key = "", ""
+	assert.ErrorContains(t, check.Validate(config), "requires TLS to be enabled")
+
+	config = TLSConfig{ClientAuth: "request"}
+	assert.ErrorContains(t, check.Validate(config), `invalid TLS client_auth "request"`)
+	config = TLSConfig{ClientCA: "ca.pem"}
+	assert.ErrorContains(t, check.Validate(config), "client CA file provided without client_auth")
+}
@@ -3,6 +3,7 @@ package context
 import (
 	"github.com/labstack/echo"
 
+	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
 )
 
@@ -12,6 +13,26 @@ type DetContext struct {
 	echo.Context
 }
 
+// SetRequestID sets the ID of the request for an echo request context. The ID is also added to
+// the context of the request, so that logs about the request include it.
+func (c *DetContext) SetRequestID(id string) {
+	c.Set("request-id", id)
+	req := c.Request()
+	c.SetRequest(req.WithContext(logger.WithRequestID(req.Context(), id)))
+}
+
+// RequestID returns the ID of the request for an echo request context, or an empty string if it
+// has not been set.
+func (c *DetContext) RequestID() string {
+	id, _ := c.Get("request-id").(string)
+	return id
+}
+
+// Logger returns a logger whose entries include the ID of the request.
+func (c *DetContext) Logger() echo.Logger {
+	return logger.NewWithContext(c.Request().Context())
+}
+
 // SetUser sets the user for an echo request context.
 func (c *DetContext) SetUser(user model.User) {
 	c.Set("user", user)
@@ -32,6 +53,13 @@ func (c *DetContext) MustGetUser() model.User {
 	return user.(model.User)
 }
 
+// GetUserSession returns the user session for the relevant echo request context, if the request
+// was authenticated with a session token.
+func (c *DetContext) GetUserSession() (model.UserSession, bool) {
+	session, ok := c.Get("user-session").(model.UserSession)
+	return session, ok
+}
+
 // MustGetUserSession returns the user session for the relevant echo request context. Panics if
 // the user has not been set, so this method should only be used inside handlers that
 // _require_ authentication.
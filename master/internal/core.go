@@ -1,18 +1,23 @@
 package internal
 
 import (
+	"crypto/subtle"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/pprof"
-	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,28 +28,42 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/soheilhy/cmux"
 
+	"github.com/determined-ai/determined/master/internal/agent"
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/command"
 	"github.com/determined-ai/determined/master/internal/context"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/email"
+	"github.com/determined-ai/determined/master/internal/events"
 	"github.com/determined-ai/determined/master/internal/grpc"
+	"github.com/determined-ai/determined/master/internal/metrics"
 	"github.com/determined-ai/determined/master/internal/proxy"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/storage"
 	"github.com/determined-ai/determined/master/internal/telemetry"
 	"github.com/determined-ai/determined/master/internal/template"
 	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/internal/webhooks"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	ws "github.com/determined-ai/determined/master/pkg/actor/api"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/etc"
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/master/version"
 )
 
 const (
-	defaultAskTimeout = 2 * time.Second
-	webuiBaseRoute    = "/det"
+	webuiBaseRoute = "/det"
+	pprofRoute     = "/debug/pprof"
+
+	// harnessUser is the user that agents fetch harness wheels as; harnessSessionDuration is how
+	// long its session lasts. The master starts a new session each time it starts.
+	harnessUser            = "determined"
+	harnessSessionDuration = 10 * 365 * 24 * time.Hour
 )
 
 // Master manages the Determined master state.
@@ -52,9 +71,25 @@ type Master struct {
 	ClusterID string
 	MasterID  string
 	Version   string
-
-	config   *Config
-	taskSpec *tasks.TaskSpec
+	// MasterURL is the URL at which users reach the master, or "" if it is unknown.
+	MasterURL string
+
+	// configLock guards config and taskSpec, which are replaced when the configuration is
+	// reloaded.
+	configLock sync.RWMutex
+	config     *Config
+	taskSpec   *tasks.TaskSpec
+	loadConfig func() (*Config, error)
+	// reloadLock serializes reloads of the configuration.
+	reloadLock sync.Mutex
+
+	// maintenanceLock guards maintenance, which is nil unless the cluster is in maintenance mode.
+	maintenanceLock sync.RWMutex
+	maintenance     *model.Maintenance
+
+	// schedulerPauseLock guards schedulerPause, which is nil unless the scheduler is paused.
+	schedulerPauseLock sync.RWMutex
+	schedulerPause     *model.SchedulerPause
 
 	logs          *logger.LogBuffer
 	system        *actor.System
@@ -64,51 +99,237 @@ type Master struct {
 	db            *db.PgDB
 	proxy         *actor.Ref
 	trialLogger   *actor.Ref
+	metrics       *metrics.Registry
+
+	experimentListCache *experimentListCache
+	modelDefinitions    *storage.ModelDefinitions
+	// restoreRMAsks rate-limits the resource manager asks of restoring experiments and trials.
+	restoreRMAsks *tokenBucket
 }
 
-// New creates an instance of the Determined master.
-func New(version string, logStore *logger.LogBuffer, config *Config) *Master {
+// New creates an instance of the Determined master. loadConfig re-reads the configuration when
+// it is reloaded.
+func New(
+	version string, logStore *logger.LogBuffer, config *Config, loadConfig func() (*Config, error),
+) *Master {
 	logger.SetLogrus(config.Log)
 	return &Master{
-		MasterID: uuid.New().String(),
-		Version:  version,
-		logs:     logStore,
-		config:   config,
+		MasterID:   uuid.New().String(),
+		Version:    version,
+		logs:       logStore,
+		config:     config,
+		loadConfig: loadConfig,
+		metrics:    metrics.NewRegistry(),
+
+		experimentListCache: newExperimentListCache(),
 	}
 }
 
+// currentConfig returns the configuration of the master, including any reloaded fields.
+func (m *Master) currentConfig() *Config {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
+	return m.config
+}
+
+// bodyLimit rejects requests with bodies larger than the limit that the current config gives, so
+// that the limit follows reloads of the config.
+func (m *Master) bodyLimit(limit func(config *Config) int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			limited := middleware.BodyLimit(strconv.FormatInt(limit(m.currentConfig()), 10))
+			return limited(next)(c)
+		}
+	}
+}
+
+// currentTaskSpec returns the task spec that new tasks are started with.
+func (m *Master) currentTaskSpec() *tasks.TaskSpec {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
+	return m.taskSpec
+}
+
 func (m *Master) getConfig(c echo.Context) (interface{}, error) {
-	return m.config.Printable()
+	return m.currentConfig().Printable()
 }
 
 func (m *Master) getInfo(c echo.Context) (interface{}, error) {
 	telemetryInfo := aproto.TelemetryInfo{}
 
-	if m.config.Telemetry.Enabled && m.config.Telemetry.SegmentWebUIKey != "" {
+	config := m.currentConfig()
+	if config.Telemetry.Enabled && config.Telemetry.SegmentWebUIKey != "" {
 		// Only advertise a Segment WebUI key if a key has been configured and
 		// telemetry is enabled.
 		telemetryInfo.Enabled = true
-		telemetryInfo.SegmentKey = m.config.Telemetry.SegmentWebUIKey
+		telemetryInfo.SegmentKey = config.Telemetry.SegmentWebUIKey
+	}
+
+	maintenanceInfo := aproto.MaintenanceInfo{}
+	if maintenance := m.currentMaintenance(); maintenance != nil {
+		maintenanceInfo.Enabled = true
+		maintenanceInfo.Message = maintenance.Message
+	}
+
+	// Only clusters of agents, not of Kubernetes nodes, have an agents actor to ask.
+	var agentVersions map[string]int
+	resp := m.system.AskAt(actor.Addr("agents"), agent.GetVersionCounts{})
+	if counts, ok := resp.GetOrTimeout(infoAskTimeout); ok {
+		agentVersions, _ = counts.(map[string]int)
 	}
 
 	return &aproto.MasterInfo{
-		ClusterID:   m.ClusterID,
-		MasterID:    m.MasterID,
-		Version:     m.Version,
-		Telemetry:   telemetryInfo,
-		ClusterName: m.config.ClusterName,
+		ClusterID:     m.ClusterID,
+		MasterID:      m.MasterID,
+		Version:       m.Version,
+		Telemetry:     telemetryInfo,
+		ClusterName:   config.ClusterName,
+		MasterURL:     m.MasterURL,
+		Maintenance:   maintenanceInfo,
+		AgentVersions: agentVersions,
+
+		APIVersion:    version.APIVersion,
+		MinCLIVersion: version.MinCLIVersion,
+		Features:      m.features(),
+		Build: aproto.BuildInfo{
+			GitCommit: version.GitCommit,
+			BuildDate: version.BuildDate,
+			GoVersion: runtime.Version(),
+		},
 	}, nil
 }
 
+// metricsAuth lets requests that carry the configured metrics bearer token through to /metrics and
+// authenticates the others with authFuncs.
+func (m *Master) metricsAuth(authFuncs ...echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		authenticated := next
+		for i := len(authFuncs) - 1; i >= 0; i-- {
+			authenticated = authFuncs[i](authenticated)
+		}
+		return func(c echo.Context) error {
+			token := m.currentConfig().MetricsBearerToken
+			auth := c.Request().Header.Get(echo.HeaderAuthorization)
+			given := strings.TrimPrefix(auth, "Bearer ")
+			if token != "" && given != auth &&
+				subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+				return next(c)
+			}
+			return authenticated(c)
+		}
+	}
+}
+
+func (m *Master) getMetrics(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, metrics.ContentType)
+	c.Response().WriteHeader(http.StatusOK)
+	return m.metrics.WriteText(c.Response())
+}
+
+// registerMailboxMetrics exports the backlogs of the mailboxes of the actors, by the type of the
+// actors, so that actors that fall behind can be spotted before asks of them time out.
+func (m *Master) registerMailboxMetrics() {
+	mailboxSeries := func(value func(actor.MailboxStats) int) func() ([]metrics.Series, error) {
+		return func() ([]metrics.Series, error) {
+			var series []metrics.Series
+			for typeName, stats := range m.system.MailboxStats() {
+				series = append(series, metrics.Series{
+					Labels: map[string]string{"type": typeName},
+					Value:  float64(value(stats)),
+				})
+			}
+			return series, nil
+		}
+	}
+	m.metrics.SetSeriesFunc("det_actor_mailbox_depth",
+		"Number of messages waiting in the mailboxes of actors, by type of actor.",
+		mailboxSeries(func(s actor.MailboxStats) int { return s.Depth }))
+	m.metrics.SetSeriesFunc("det_actor_mailbox_max_depth",
+		"Number of messages waiting in the fullest mailbox of an actor, by type of actor.",
+		mailboxSeries(func(s actor.MailboxStats) int { return s.MaxDepth }))
+	m.metrics.SetCounterSeriesFunc("det_actor_mailbox_overflows_total",
+		"Number of messages dropped because the mailbox of an actor was full, by type of actor.",
+		mailboxSeries(func(s actor.MailboxStats) int { return s.Overflows }))
+}
+
+// registerWebSocketMetrics exports the traffic of websockets, by the class of the websockets, e.g.,
+// trial or agent.
+func (m *Master) registerWebSocketMetrics() {
+	socketSeries := func(value func(ws.SocketStats) int64) func() ([]metrics.Series, error) {
+		return func() ([]metrics.Series, error) {
+			var series []metrics.Series
+			for class, stats := range ws.Stats() {
+				series = append(series, metrics.Series{
+					Labels: map[string]string{"class": class},
+					Value:  float64(value(stats)),
+				})
+			}
+			return series, nil
+		}
+	}
+	m.metrics.SetCounterSeriesFunc("det_websocket_sent_bytes_total",
+		"Number of bytes of messages sent on websockets before compression, by class of socket.",
+		socketSeries(func(s ws.SocketStats) int64 { return s.BytesSent }))
+	m.metrics.SetCounterSeriesFunc("det_websocket_received_bytes_total",
+		"Number of bytes of messages received on websockets after decompression, by class of socket.",
+		socketSeries(func(s ws.SocketStats) int64 { return s.BytesReceived }))
+	m.metrics.SetCounterSeriesFunc("det_websocket_messages_too_large_total",
+		"Number of messages dropped for exceeding websockets.max_message_size, by class of socket.",
+		socketSeries(func(s ws.SocketStats) int64 { return s.MessagesTooLarge }))
+}
+
+// getHarnessWheel serves a harness wheel listed in the harness manifest. The ETag is the checksum
+// of the wheel, so that caches can tell whether they hold it.
+func (m *Master) getHarnessWheel(c echo.Context) error {
+	args := struct {
+		Platform string `path:"platform"`
+		Filename string `path:"filename"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return err
+	}
+	spec := m.currentTaskSpec()
+	wheel, ok := spec.HarnessManifest.Lookup(args.Platform, args.Filename)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf(
+			"no harness wheel %s for platform %s", args.Filename, args.Platform))
+	}
+	c.Response().Header().Set("ETag", fmt.Sprintf("%q", wheel.SHA256))
+	return c.File(tasks.HarnessWheelPath(spec.HarnessPath, args.Platform, wheel.Name))
+}
+
+// startHarnessSession starts the session that agents authenticate with when they fetch harness
+// wheels, as the built-in harnessUser. Without it, agents cannot fetch wheels, so tasks fail to
+// start with an error that says so.
+func (m *Master) startHarnessSession() string {
+	user, err := m.db.UserByUsername(harnessUser)
+	if err == nil && !user.Active {
+		err = errors.Errorf("user %s is inactive", harnessUser)
+	}
+	var token string
+	if err == nil {
+		token, err = m.db.StartUserSessionUntil(user, time.Now().Add(harnessSessionDuration))
+	}
+	if err != nil {
+		log.WithError(err).Warn("failed to start the session that agents fetch harness wheels with")
+	}
+	return token
+}
+
 func (m *Master) getMasterLogs(c echo.Context) (interface{}, error) {
 	args := struct {
-		LessThanID    *int `query:"less_than_id"`
-		GreaterThanID *int `query:"greater_than_id"`
-		Limit         *int `query:"tail"`
+		LessThanID    *int    `query:"less_than_id"`
+		GreaterThanID *int    `query:"greater_than_id"`
+		Limit         *int    `query:"tail"`
+		Since         *string `query:"since"`
 	}{}
 	if err := api.BindArgs(&args, c); err != nil {
 		return nil, err
 	}
+	since, err := parseSinceArg(args.Since)
+	if err != nil {
+		return nil, err
+	}
 
 	limit := -1
 	if args.Limit != nil {
@@ -125,7 +346,12 @@ func (m *Master) getMasterLogs(c echo.Context) (interface{}, error) {
 		endID = *args.LessThanID
 	}
 
-	entries := m.logs.Entries(startID, endID, limit)
+	var entries []*logger.Entry
+	if since == nil {
+		entries = m.logs.Entries(startID, endID, limit)
+	} else {
+		entries = logEntriesSince(m.logs.Entries(startID, endID, -1), *since, limit)
+	}
 	if len(entries) == 0 {
 		// Return a zero-length array here so the JSON encoding is `[]` rather than `null`.
 		entries = make([]*logger.Entry, 0)
@@ -133,6 +359,17 @@ func (m *Master) getMasterLogs(c echo.Context) (interface{}, error) {
 	return entries, nil
 }
 
+// logEntriesSince returns the last limit entries, or all of them if limit is negative, that were
+// logged at or after since. The entries must be in the order they were logged.
+func logEntriesSince(entries []*logger.Entry, since time.Time, limit int) []*logger.Entry {
+	start := sort.Search(len(entries), func(i int) bool { return !entries[i].Time.Before(since) })
+	entries = entries[start:]
+	if limit >= 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
 func (m *Master) startServers(cert *tls.Certificate) error {
 	// Create the base TCP socket listener and, if configured, set up TLS wrapping.
 	baseListener, err := net.Listen("tcp", fmt.Sprintf(":%d", m.config.Port))
@@ -140,16 +377,30 @@ func (m *Master) startServers(cert *tls.Certificate) error {
 		return err
 	}
 
+	var gatewayCert *tls.Certificate
 	if cert != nil {
-		baseListener = tls.NewListener(baseListener, &tls.Config{
+		tlsConfig := &tls.Config{
 			Certificates:             []tls.Certificate{*cert},
 			MinVersion:               tls.VersionTLS12,
 			PreferServerCipherSuites: true,
-		})
+			ClientAuth:               m.config.Security.TLS.ClientAuthType(),
+		}
+		if tlsConfig.ClientCAs, err = m.config.Security.TLS.ReadClientCAs(); err != nil {
+			return errors.Wrap(err, "failed to read TLS client CAs")
+		}
+		if tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+			// The gRPC gateway connects back to the master, so it needs a client certificate too.
+			var gatewayCA *x509.Certificate
+			if gatewayCert, gatewayCA, err = newGatewayClientCertificate(); err != nil {
+				return errors.Wrap(err, "failed to create gRPC gateway client certificate")
+			}
+			tlsConfig.ClientCAs.AddCert(gatewayCA)
+		}
+		baseListener = tls.NewListener(baseListener, tlsConfig)
 	}
 
 	// Initialize listeners and multiplexing.
-	if err := grpc.RegisterHTTPProxy(m.echo, m.config.Port, cert); err != nil {
+	if err := grpc.RegisterHTTPProxy(m.echo, m.config.Port, cert, gatewayCert); err != nil {
 		return errors.Wrap(err, "failed to register gRPC gateway")
 	}
 
@@ -174,6 +425,7 @@ func (m *Master) startServers(cert *tls.Certificate) error {
 	start("HTTP server", func() error {
 		m.echo.Listener = httpListener
 		m.echo.HidePort = true
+		m.echo.Server.ConnContext = api.ConnContext
 		return m.echo.StartServer(m.echo.Server)
 	})
 	start("cmux listener", mux.Serve)
@@ -182,23 +434,63 @@ func (m *Master) startServers(cert *tls.Certificate) error {
 	return <-errs
 }
 
-func (m *Master) restoreExperiment(e *model.Experiment) {
+// restoreExperiments restores experiments from the database in the background, a few at a time,
+// so that restoring many experiments at once does not overwhelm the database or the resource
+// manager.
+func (m *Master) restoreExperiments(toRestore []*model.Experiment) {
+	config := m.config.Experiments
+	m.restoreRMAsks = newTokenBucket(config.RestoreRMAsksPerSecond, config.RestoreRMAskBurst)
+	log.Infof("restoring %d experiments, %d at a time", len(toRestore), config.RestoreConcurrency)
+
+	restoreConcurrently(toRestore, config.RestoreConcurrency, func(exp *model.Experiment) {
+		// Failures are logged and leave the experiment errored; see postExperimentRestore.
+		_ = m.restoreExperiment(exp)
+	})
+}
+
+// restoreConcurrently calls restore on each of the experiments in the background, in order, with
+// at most concurrency calls running at once.
+func restoreConcurrently(
+	toRestore []*model.Experiment, concurrency int, restore func(*model.Experiment),
+) {
+	queue := make(chan *model.Experiment, len(toRestore))
+	for _, exp := range toRestore {
+		queue <- exp
+	}
+	close(queue)
+	for i := 0; i < concurrency && i < len(toRestore); i++ {
+		go func() {
+			for exp := range queue {
+				restore(exp)
+			}
+		}()
+	}
+}
+
+// restoreExperiment restores an experiment from the database, marking it as errored if that
+// fails.
+func (m *Master) restoreExperiment(e *model.Experiment) error {
 	// Check if the returned config is the zero value, i.e. the config could not be parsed
 	// correctly. If the config could not be parsed, mark the experiment as errored.
+	var err error
 	if !reflect.DeepEqual(e.Config, model.ExperimentConfig{}) {
-		err := restoreExperiment(m, e)
-		if err == nil {
-			return
+		if err = restoreExperiment(m, e); err == nil {
+			return nil
 		}
 		log.WithError(err).Errorf("failed to restore experiment: %d", e.ID)
 	} else {
-		log.Errorf("failed to parse experiment config: %d", e.ID)
+		err = errors.Errorf("failed to parse experiment config: %d", e.ID)
+		log.Error(err)
 	}
+	from := e.State
 	e.State = model.ErrorState
-	if err := m.db.TerminateExperimentInRestart(e.ID, e.State); err != nil {
-		log.WithError(err).Error("failed to mark experiment as errored")
+	if tErr := m.db.TerminateExperimentInRestart(e.ID, e.State); tErr != nil {
+		log.WithError(tErr).Error("failed to mark experiment as errored")
 	}
 	telemetry.ReportExperimentStateChanged(m.system, m.db, *e)
+	recordTransitionWithoutActor(m.db, e.ID, from, e.State,
+		fmt.Sprintf("failed to restore experiment: %s", err))
+	return err
 }
 
 // convertDBErrorsToNotFound helps reduce boilerplate in our handlers, by
@@ -272,9 +564,17 @@ func (m *Master) postTrialLogs(c echo.Context) (interface{}, error) {
 	return "", nil
 }
 
+// isWebUIAPIPath returns whether a path relative to the WebUI route looks like an API path rather
+// than a WebUI route: either it is under api/ or it matches the configured pattern.
+func isWebUIAPIPath(groupPath string, pattern *regexp.Regexp) bool {
+	return groupPath == "api" || strings.HasPrefix(groupPath, "api/") ||
+		(pattern != nil && pattern.MatchString(groupPath))
+}
+
 // Run causes the Determined master to connect the database and begin listening for HTTP requests.
 func (m *Master) Run() error {
 	log.Infof("Determined master %s (built with %s)", m.Version, runtime.Version())
+	m.MasterURL = m.config.externalMasterURL()
 
 	var err error
 
@@ -282,28 +582,37 @@ func (m *Master) Run() error {
 		return errors.Wrap(err, "could not set static root")
 	}
 
-	m.db, err = db.Setup(&m.config.DB)
+	cert, err := m.config.Security.TLS.ReadCertificate()
 	if err != nil {
-		return err
+		return errors.Wrap(err, "failed to read TLS certificate")
 	}
 
-	m.ClusterID, err = m.db.GetClusterID()
+	stopDegradedServer, err := m.startDegradedServer(cert)
 	if err != nil {
-		return errors.Wrap(err, "could not fetch cluster id from database")
+		return errors.Wrap(err, "failed to start degraded HTTP server")
 	}
-	cert, err := m.config.Security.TLS.ReadCertificate()
+	err = m.setupDB()
+	stopDegradedServer()
 	if err != nil {
-		return errors.Wrap(err, "failed to read TLS certificate")
+		return err
+	}
+	if err = m.setupModelDefinitions(); err != nil {
+		return err
+	}
+	harnessPath := filepath.Join(m.config.Root, "wheels")
+	harnessManifest, err := tasks.LoadHarnessManifest(harnessPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to list harness wheels")
 	}
 	m.taskSpec = &tasks.TaskSpec{
 		ClusterID:             m.ClusterID,
-		HarnessPath:           filepath.Join(m.config.Root, "wheels"),
+		HarnessPath:           harnessPath,
+		HarnessManifest:       harnessManifest,
 		TaskContainerDefaults: m.config.TaskContainerDefaults,
 		MasterCert:            cert,
+		HarnessToken:          m.startHarnessSession(),
 	}
 
-	go m.cleanUpSearcherEvents()
-
 	// Actor structure:
 	// master system
 	// +- Agent Group (actors.Group: agents)
@@ -319,55 +628,118 @@ func (m *Master) Run() error {
 	// +- RWCoordinator (internal.rw_coordinator: rwCoordinator)
 	// +- Telemetry (telemetry.telemetryActor: telemetry)
 	// +- TrialLogger (internal.trialLogger: trialLogger)
+	// +- SearcherEventsCleaner (internal.searcherEventsCleaner: searcherEventsCleaner)
+	// +- ExperimentArchiver (internal.experimentArchiver: experimentArchiver)
+	// +- AllocationRecorder (internal.allocationRecorder: allocationRecorder)
+	// +- TaskContainerLogCleaner (internal.taskContainerLogCleaner: taskContainerLogCleaner)
+	// +- CheckpointGCLimiter (internal.checkpointGCLimiter: checkpointGCLimiter)
+	// +- Webhooks (webhooks.webhookActor: webhooks)
+	// +- Email (email.emailActor: email)
+	// +- Events (events.eventsActor: events)
 	// +- Experiments (actors.Group: experiments)
 	//     +- Experiment (internal.experiment: <experiment-id>)
 	//         +- Trial (internal.trial: <trial-request-id>)
 	//             +- Websocket (actors.WebSocket: <remote-address>)
-	m.system = actor.NewSystem("master")
-
-	m.trialLogger, _ = m.system.ActorOf(actor.Addr("trialLogger"), newTrialLogger(m.db))
+	// +- ExperimentScheduler (internal.experimentScheduler: experimentScheduler)
+	// +- DependencyResolver (internal.dependencyResolver: dependencyResolver)
+	// +- ExperimentDeleter (internal.experimentDeleter: experimentDeleter)
+	//     +- CheckpointGCTask (internal.checkpointGCTask: delete-checkpoint-gc-<uuid>)
+	// +- ExperimentPurger (internal.experimentPurger: experimentPurger)
+	m.system = actor.NewSystemWithMailboxes("master", actor.MailboxConfig{
+		Capacity:      m.config.ActorMailboxes.Capacity,
+		Capacities:    m.config.ActorMailboxes.Capacities,
+		HighWaterMark: m.config.ActorMailboxes.HighWaterMark,
+	})
+	m.registerMailboxMetrics()
+	ws.SetReadLimit(m.config.WebSockets.MaxMessageSize)
+	m.registerWebSocketMetrics()
+	agent.SetVersionPolicy(m.config.AgentVersions)
+
+	m.trialLogger, _ = m.system.ActorOf(actor.Addr("trialLogger"), newTrialLogger(
+		m.db.AddTrialLogs, m.config.TrialLogs))
+	m.flushOnShutdown()
+	m.system.ActorOf(actor.Addr("searcherEventsCleaner"), &searcherEventsCleaner{
+		db:      m.db,
+		metrics: m.metrics,
+		config:  m.config.SearcherEvents,
+	})
+	m.system.ActorOf(actor.Addr("experimentArchiver"), &experimentArchiver{
+		archiveExperiments: m.db.ArchiveTerminalStateExperiments,
+		days:               m.config.AutoArchiveDays,
+		listCache:          m.experimentListCache,
+	})
+	m.system.ActorOf(sproto.AllocationRecorderAddr, &allocationRecorder{db: m.db})
+	m.system.ActorOf(actor.Addr("taskContainerLogCleaner"), &taskContainerLogCleaner{
+		db:   m.db,
+		days: m.config.TaskContainerLogRetentionDays,
+	})
+	m.system.ActorOf(checkpointGCLimiterAddr,
+		newCheckpointGCLimiter(m.config.Checkpoints.GCConcurrency))
+	m.system.ActorOf(webhooks.Addr, webhooks.NewActor(m.db, m.MasterURL))
+	m.system.ActorOf(events.Addr, events.NewActor())
+	if m.config.SMTP != nil {
+		smtp := *m.config.SMTP
+		if smtp.MasterURL == "" {
+			smtp.MasterURL = m.MasterURL
+		}
+		emailActor, eErr := email.NewActor(smtp, m.db,
+			etc.MustStaticFile(etc.ExperimentEmailTemplateResource), m.metrics)
+		if eErr != nil {
+			return eErr
+		}
+		m.system.ActorOf(email.Addr, emailActor)
+	}
 
 	userService, err := user.New(m.db, m.system)
 	if err != nil {
 		return errors.Wrap(err, "cannot initialize user manager")
 	}
+	// Team membership decides which experiments users see.
+	userService.OnTeamsChanged(m.experimentListCache.invalidate)
 	authFuncs := []echo.MiddlewareFunc{userService.ProcessAuthentication}
+	adminAuthFuncs := []echo.MiddlewareFunc{userService.ProcessAdminAuthentication}
 
 	m.proxy, _ = m.system.ActorOf(actor.Addr("proxy"), &proxy.Proxy{})
 
-	// Used to decide whether we add trailing slash to the paths or not affecting
-	// relative links in web pages hosted under these routes.
-	staticWebDirectoryPaths := map[string]bool{
-		"/docs":          true,
-		webuiBaseRoute:   true,
-		"/docs/rest-api": true,
-	}
-
 	// Initialize the HTTP server and listen for incoming requests.
 	m.echo = echo.New()
+	// Web pages get a trailing slash, which affects relative links in them, and API routes do not.
+	// Proxied services handle the paths under them themselves.
+	webRoutes := []string{webuiBaseRoute, "/docs", "/docs/rest-api", "/proxy"}
+	if m.config.EnablePprof {
+		webRoutes = append(webRoutes, pprofRoute)
+	}
+	m.echo.Pre(api.TrailingSlashes(api.TrailingSlashConfig{WebRoutes: webRoutes}))
 	m.echo.Use(middleware.Recover())
-	m.echo.Use(middleware.AddTrailingSlashWithConfig(middleware.TrailingSlashConfig{
-		Skipper: func(c echo.Context) bool {
-			return !staticWebDirectoryPaths[c.Path()]
-		},
-		RedirectCode: http.StatusMovedPermanently,
-	}))
+	// Like CORS, access logs can be reconfigured by reloading the configuration.
+	m.echo.Use(api.AccessLog(func() api.AccessLogConfig { return m.currentConfig().AccessLog }))
 	setupEchoRedirects(m)
 
-	if m.config.EnableCors {
-		m.echo.Use(api.CORSWithTargetedOrigin)
-	}
+	// CORS can be toggled by reloading the configuration, so the middleware is always installed.
+	m.echo.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		cors := api.CORSWithTargetedOrigin(next)
+		return func(c echo.Context) error {
+			if m.currentConfig().EnableCors {
+				return cors(c)
+			}
+			return next(c)
+		}
+	})
 
-	// Add resistance to common HTTP attacks.
-	//
-	// TODO(DET-1696): Enable Content Security Policy (CSP).
+	// Add resistance to common HTTP attacks. Further security headers, such as a Content Security
+	// Policy, are configured by the cluster.
+	headers := m.config.Security.Headers
 	secureConfig := middleware.SecureConfig{
-		Skipper:            middleware.DefaultSkipper,
-		XSSProtection:      "1; mode=block",
-		ContentTypeNosniff: "nosniff",
-		XFrameOptions:      "SAMEORIGIN",
+		Skipper:               middleware.DefaultSkipper,
+		XSSProtection:         "1; mode=block",
+		ContentTypeNosniff:    "nosniff",
+		XFrameOptions:         "SAMEORIGIN",
+		HSTSMaxAge:            headers.HSTSMaxAge,
+		HSTSExcludeSubdomains: headers.HSTSExcludeSubdomains,
+		ContentSecurityPolicy: headers.ContentSecurityPolicy,
 	}
 	m.echo.Use(middleware.SecureWithConfig(secureConfig))
+	m.echo.Use(api.StaticHeaders(headers.staticHeaders()))
 
 	// Register middleware that extends default context.
 	m.echo.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
@@ -376,6 +748,8 @@ func (m *Master) Run() error {
 			return h(cc)
 		}
 	})
+	m.echo.Use(api.RequestID)
+	m.echo.Use(api.ClientVersionWarning(version.MinCLIVersion))
 
 	m.echo.Use(convertDBErrorsToNotFound)
 
@@ -387,9 +761,16 @@ func (m *Master) Run() error {
 	m.rm = resourcemanagers.Setup(
 		m.system, m.echo, m.config.ResourceManager, m.config.ResourcePoolsConfig, cert,
 	)
+	if err = m.loadMaintenance(); err != nil {
+		return errors.Wrap(err, "could not load maintenance mode")
+	}
+	if err = m.loadSchedulerPause(); err != nil {
+		return errors.Wrap(err, "could not load scheduler pause")
+	}
 	tasksGroup := m.echo.Group("/tasks", authFuncs...)
 	tasksGroup.GET("", api.Route(m.getTasks))
 	tasksGroup.GET("/:task_id", api.Route(m.getTask))
+	tasksGroup.GET("/:task_id/container-logs", api.Route(m.getTaskContainerLogs))
 
 	// Distributed lock server.
 	rwCoordinator := newRWCoordinator()
@@ -401,99 +782,200 @@ func (m *Master) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "couldn't retrieve experiments to restore")
 	}
-	for _, exp := range toRestore {
-		go m.restoreExperiment(exp)
+	m.restoreExperiments(toRestore)
+
+	// Create experiments from their schedules, including those that were due while the master was
+	// down.
+	m.system.ActorOf(actor.Addr("experimentScheduler"), &experimentScheduler{m: m})
+
+	// Start the experiments that wait for other experiments once those meet their condition.
+	m.system.ActorOf(actor.Addr("dependencyResolver"), &dependencyResolver{m: m})
+
+	// Resume deleting the experiments that were being deleted when the master stopped.
+	m.system.ActorOf(experimentDeleterAddr, newExperimentDeleter(m))
+	toDelete, err := m.db.DeletingExperimentIDs()
+	if err != nil {
+		return errors.Wrap(err, "couldn't retrieve experiments to delete")
+	}
+	for _, id := range toDelete {
+		m.system.TellAt(experimentDeleterAddr, deleteExperiment{experimentID: id})
 	}
+	// Delete for good the experiments that were soft-deleted longer than the grace period ago.
+	m.system.ActorOf(actor.Addr("experimentPurger"), &experimentPurger{
+		db:          m.db,
+		gracePeriod: time.Duration(m.config.DeleteGracePeriod) * time.Second,
+	})
 
 	// Docs and WebUI.
 	webuiRoot := filepath.Join(m.config.Root, "webui")
 	reactRoot := filepath.Join(webuiRoot, "react")
-	reactRootAbs, err := filepath.Abs(reactRoot)
-	if err != nil {
-		return errors.Wrap(err, "failed to get absolute path to react root")
-	}
 	reactIndex := filepath.Join(reactRoot, "index.html")
 
+	cacheStatic := api.CacheStatic()
+
 	// Docs.
-	m.echo.Static("/docs/rest-api", filepath.Join(webuiRoot, "docs", "rest-api"))
-	m.echo.Static("/docs", filepath.Join(webuiRoot, "docs"))
-
-	webuiGroup := m.echo.Group(webuiBaseRoute)
-	webuiGroup.File("/", reactIndex)
-	webuiGroup.GET("/*", func(c echo.Context) error {
-		groupPath := strings.TrimPrefix(c.Request().URL.Path, webuiBaseRoute+"/")
-		requestedFile := filepath.Join(reactRoot, groupPath)
-		// We do a simple check against directory traversal attacks.
-		requestedFileAbs, err := filepath.Abs(requestedFile)
-		if err != nil {
-			log.WithError(err).Error("failed to get absolute path to requested file")
-			return c.File(reactIndex)
-		}
-		isInReactDir := strings.HasPrefix(requestedFileAbs, reactRootAbs)
-		if !isInReactDir {
-			return echo.NewHTTPError(http.StatusForbidden)
-		}
+	docsGroup := m.echo.Group("/docs", cacheStatic)
+	docsGroup.Static("/rest-api", filepath.Join(webuiRoot, "docs", "rest-api"))
+	docsGroup.Static("", filepath.Join(webuiRoot, "docs"))
 
-		var hasMatchingFile bool
-		stat, err := os.Stat(requestedFile)
-		switch {
-		case os.IsNotExist(err):
-		case os.IsPermission(err):
-			hasMatchingFile = false
-		case err != nil:
-			return echo.NewHTTPError(http.StatusInternalServerError, "failed to check if file exists")
-		default:
-			hasMatchingFile = !stat.IsDir()
-		}
-		if hasMatchingFile {
-			return c.File(requestedFile)
-		}
+	var apiPathPattern *regexp.Regexp
+	if m.config.WebUI.APIPathPattern != "" {
+		apiPathPattern = regexp.MustCompile(m.config.WebUI.APIPathPattern)
+	}
 
+	webuiGroup := m.echo.Group(webuiBaseRoute, cacheStatic)
+	webuiGroup.GET("/", func(c echo.Context) error {
 		return c.File(reactIndex)
 	})
+	webuiGroup.GET("/*", webuiHandler(reactRoot, apiPathPattern))
 
-	m.echo.Static("/api/v1/api.swagger.json",
-		filepath.Join(m.config.Root, "swagger/determined/api/v1/api.swagger.json"))
+	m.echo.GET("/api/v1/api.swagger.json", swaggerHandler(
+		filepath.Join(m.config.Root, "swagger/determined/api/v1/api.swagger.json")), cacheStatic)
 
 	m.echo.GET("/config", api.Route(m.getConfig))
 	m.echo.GET("/info", api.Route(m.getInfo))
+	m.echo.GET("/healthz", api.Route(m.getHealthz))
 	m.echo.GET("/logs", api.Route(m.getMasterLogs), authFuncs...)
+	m.echo.GET("/metrics", m.getMetrics, m.metricsAuth(authFuncs...))
+	m.echo.GET("/cluster/utilization", api.Route(m.getClusterUtilization), authFuncs...)
+	m.echo.GET("/usage", api.Route(m.getUsage), authFuncs...)
+	m.echo.GET("/usage/csv", m.getUsageCSV, authFuncs...)
+	m.echo.GET("/events", m.getEvents,
+		userService.ProcessAuthentication, m.featureFlag(eventsFeatureFlag))
 
 	m.echo.GET("/experiment-list", api.Route(m.getExperimentList), authFuncs...)
 	m.echo.GET("/experiment-summaries", api.Route(m.getExperimentSummaries), authFuncs...)
 
-	experimentsGroup := m.echo.Group("/experiments", authFuncs...)
+	// Expensive endpoints are limited in how many requests they serve at once.
+	limits := m.config.ConcurrencyLimits
+	metricsLimit := newConcurrencyLimiter("metrics", limits.Metrics, m.metrics).middleware
+	logsLimit := newConcurrencyLimiter("logs", limits.Logs, m.metrics).middleware
+	modelDefLimit := newConcurrencyLimiter(
+		"model_definitions", limits.ModelDefinitions, m.metrics).middleware
+
+	experimentsGroup := m.echo.Group(
+		"/experiments", append(authFuncs, m.checkExperimentVisible)...)
 	experimentsGroup.GET("", api.Route(m.getExperiments))
+	experimentsGroup.GET("/diff", api.Route(m.getExperimentConfigDiff))
+	experimentsGroup.POST("/compare", api.Route(m.postExperimentsCompare))
 	experimentsGroup.GET("/:experiment_id", api.Route(m.getExperiment))
 	experimentsGroup.GET("/:experiment_id/checkpoints", api.Route(m.getExperimentCheckpoints))
 	experimentsGroup.GET("/:experiment_id/config", api.Route(m.getExperimentConfig))
-	experimentsGroup.GET("/:experiment_id/model_def", m.getExperimentModelDefinition)
+	experimentsGroup.GET("/:experiment_id/dependencies",
+		api.Route(m.getExperimentDependencies))
+	experimentsGroup.GET("/:experiment_id/state_history",
+		api.Route(m.getExperimentStateHistory))
+	experimentsGroup.GET("/:experiment_id/model_def", m.getExperimentModelDefinition,
+		modelDefLimit)
+	experimentsGroup.GET("/:experiment_id/bundle", m.getExperimentBundle, modelDefLimit)
+	experimentsGroup.GET("/:experiment_id/export", m.getExperimentExport, modelDefLimit)
 	experimentsGroup.GET("/:experiment_id/preview_gc", api.Route(m.getExperimentCheckpointsToGC))
-	experimentsGroup.GET("/:experiment_id/summary", api.Route(m.getExperimentSummary))
-	experimentsGroup.GET("/:experiment_id/metrics/summary", api.Route(m.getExperimentSummaryMetrics))
+	experimentsGroup.GET("/:experiment_id/queue_position",
+		api.Route(m.getExperimentQueuePosition))
+	experimentsGroup.GET("/:experiment_id/summary", api.Route(m.getExperimentSummary),
+		metricsLimit)
+	experimentsGroup.GET("/:experiment_id/metrics/summary",
+		api.Route(m.getExperimentSummaryMetrics), metricsLimit)
+	experimentsGroup.GET("/:experiment_id/metric-names",
+		api.Route(m.getExperimentMetricNames), metricsLimit)
+	experimentsGroup.GET("/:experiment_id/metrics/prometheus",
+		m.getExperimentPrometheusMetrics, metricsLimit)
+	experimentsGroup.GET("/:experiment_id/leaderboard", api.Route(m.getExperimentLeaderboard))
+	experimentsGroup.GET("/:experiment_id/hparam-importance",
+		api.Route(m.getExperimentHParamImportance), m.featureFlag(hparamImportanceFeatureFlag))
 	experimentsGroup.PATCH("/:experiment_id", api.Route(m.patchExperiment))
-	experimentsGroup.POST("", api.Route(m.postExperiment))
+	experimentsGroup.PUT("/:experiment_id/owner", api.Route(m.putExperimentOwner))
+	experimentsGroup.PUT("/:experiment_id/team", api.Route(m.putExperimentTeam))
+	// Requests to create experiments carry both the config and the model definition.
+	experimentsGroup.POST("", api.Route(m.postExperiment),
+		m.bodyLimit(func(config *Config) int64 {
+			return config.MaxExperimentConfigBytes + config.MaxModelDefinitionBytes
+		}))
+	// Imported archives are read as they are received, limiting the model definition they carry.
+	experimentsGroup.POST("/import", api.Route(m.postExperimentImport),
+		m.bodyLimit(func(config *Config) int64 { return config.MaxExperimentArchiveBytes }))
+	// Model definitions uploaded on their own are streamed to disk and limited as they are read.
+	experimentsGroup.POST("/:experiment_id/model_def", api.Route(m.postExperimentModelDefinition))
 	experimentsGroup.POST("/:experiment_id/kill", api.Route(m.postExperimentKill))
+	experimentsGroup.POST("/:experiment_id/restore", api.Route(m.postExperimentRestore),
+		adminAuthFuncs...)
+	experimentsGroup.GET("/:experiment_id/searcher", api.Route(m.getExperimentSearcherState))
+	experimentsGroup.GET("/:experiment_id/searcher/events", api.Route(m.getCustomSearcherEvents))
+	experimentsGroup.POST("/:experiment_id/searcher/operations",
+		api.Route(m.postCustomSearcherOperations))
+	experimentsGroup.GET("/:experiment_id/searcher/max_concurrent_trials",
+		api.Route(m.getExperimentMaxConcurrentTrials))
 	experimentsGroup.DELETE("/:experiment_id", api.Route(m.deleteExperiment))
+	experimentsGroup.POST("/:experiment_id/retry-delete", api.Route(m.postExperimentRetryDelete))
+	experimentsGroup.POST("/:experiment_id/undelete", api.Route(m.postExperimentUndelete))
+
+	schedulesGroup := m.echo.Group("/schedules", authFuncs...)
+	schedulesGroup.GET("", api.Route(m.getExperimentSchedules))
+	schedulesGroup.GET("/:schedule_id", api.Route(m.getExperimentSchedule))
+	schedulesGroup.PATCH("/:schedule_id", api.Route(m.patchExperimentSchedule))
+	schedulesGroup.DELETE("/:schedule_id", api.Route(m.deleteExperimentSchedule))
+
+	adminGroup := m.echo.Group("/admin", adminAuthFuncs...)
+	adminGroup.POST("/cleanup-searcher-events", api.Route(m.postCleanupSearcherEvents))
+	adminGroup.POST("/reload-config", api.Route(m.postReloadConfig))
+	adminGroup.POST("/migrate-model-definitions", api.Route(m.postMigrateModelDefinitions))
+	adminGroup.GET("/maintenance", api.Route(m.getMaintenance))
+	adminGroup.POST("/maintenance", api.Route(m.postMaintenance))
+	m.echo.GET("/telemetry/preview", api.Route(m.getTelemetryPreview), adminAuthFuncs...)
+
+	schedulerGroup := m.echo.Group("/scheduler", authFuncs...)
+	schedulerGroup.GET("/status", api.Route(m.getSchedulerStatus))
+	schedulerGroup.POST("/pause", api.Route(m.postSchedulerPause), adminAuthFuncs...)
+	schedulerGroup.POST("/resume", api.Route(m.postSchedulerResume), adminAuthFuncs...)
+
+	webhooksGroup := m.echo.Group("/webhooks", adminAuthFuncs...)
+	webhooksGroup.GET("", api.Route(m.getWebhooks))
+	webhooksGroup.POST("", api.Route(m.postWebhook))
+	webhooksGroup.GET("/:webhook_id", api.Route(m.getWebhook))
+	webhooksGroup.PUT("/:webhook_id", api.Route(m.putWebhook))
+	webhooksGroup.DELETE("/:webhook_id", api.Route(m.deleteWebhook))
+	webhooksGroup.GET("/:webhook_id/deliveries", api.Route(m.getWebhookDeliveries))
 
 	searcherGroup := m.echo.Group("/searcher", authFuncs...)
 	searcherGroup.POST("/preview", api.Route(m.getSearcherPreview))
 
-	trialsGroup := m.echo.Group("/trials", authFuncs...)
+	trialsGroup := m.echo.Group("/trials", append(authFuncs, m.checkTrialVisible)...)
 	trialsGroup.GET("/:trial_id", api.Route(m.getTrial))
-	trialsGroup.GET("/:trial_id/details", api.Route(m.getTrialDetails))
-	trialsGroup.GET("/:trial_id/logs", m.getTrialLogs)
-	trialsGroup.GET("/:trial_id/metrics", api.Route(m.getTrialMetrics))
-	trialsGroup.GET("/:trial_id/logsv2", api.Route(m.getTrialLogsV2))
+	trialsGroup.GET("/:trial_id/details", api.Route(m.getTrialDetails), metricsLimit)
+	trialsGroup.GET("/:trial_id/logs", api.Route(m.getTrialLogs), logsLimit)
+	trialsGroup.GET("/:trial_id/metrics", api.Route(m.getTrialMetrics), metricsLimit)
+	trialsGroup.GET("/:trial_id/logsv2", api.Route(m.getTrialLogsV2), logsLimit)
+	trialsGroup.GET("/:trial_id/logs/download", m.getTrialLogsDownload, logsLimit)
+	trialsGroup.GET("/:trial_id/runner_state", api.Route(m.getTrialRunnerState))
 	trialsGroup.POST("/:trial_id/kill", api.Route(m.postTrialKill))
 
-	checkpointsGroup := m.echo.Group("/checkpoints", authFuncs...)
+	checkpointsGroup := m.echo.Group(
+		"/checkpoints", append(authFuncs, m.checkCheckpointVisible)...)
 	checkpointsGroup.GET("", api.Route(m.getCheckpoints))
+	checkpointsGroup.GET("/storage/test", api.Route(m.getCheckpointStorageTest), adminAuthFuncs...)
+	checkpointsGroup.POST("/metadata", api.Route(m.patchCheckpointsMetadata))
 	checkpointsGroup.GET("/:checkpoint_uuid", api.Route(m.getCheckpoint))
+	checkpointsGroup.GET("/:checkpoint_uuid/references", api.Route(m.getCheckpointReferences))
 	checkpointsGroup.POST("/:checkpoint_uuid/metadata", api.Route(m.addCheckpointMetadata))
+	checkpointsGroup.PATCH("/:checkpoint_uuid/metadata", api.Route(m.patchCheckpointMetadata))
 	checkpointsGroup.DELETE("/:checkpoint_uuid/metadata", api.Route(m.deleteCheckpointMetadata))
 
+	modelsGroup := m.echo.Group("/models", authFuncs...)
+	modelsGroup.GET("", api.Route(m.getModels))
+	modelsGroup.POST("", api.Route(m.postModel))
+	modelsGroup.GET("/:model_name", api.Route(m.getModel))
+	modelsGroup.PATCH("/:model_name", api.Route(m.patchModel))
+	modelsGroup.POST("/:model_name/archive", api.Route(m.postModelArchive))
+	modelsGroup.POST("/:model_name/unarchive", api.Route(m.postModelUnarchive))
+	modelsGroup.GET("/:model_name/versions", api.Route(m.getModelVersions))
+	modelsGroup.POST("/:model_name/versions", api.Route(m.postModelVersion))
+	modelsGroup.GET("/:model_name/versions/:model_version", api.Route(m.getModelVersion))
+	modelsGroup.PATCH("/:model_name/versions/:model_version", api.Route(m.patchModelVersion))
+	modelsGroup.DELETE("/:model_name/versions/:model_version", api.Route(m.deleteModelVersion))
+
 	m.echo.POST("/trial_logs", api.Route(m.postTrialLogs))
+	// Agents fetch harness wheels with the session that the master hands them in task specs.
+	m.echo.GET("/wheels/:platform/:filename", m.getHarnessWheel, authFuncs...)
 
 	m.echo.GET("/ws/trial/:experiment_id/:trial_id/:container_id",
 		api.WebSocketRoute(m.trialWebSocket))
@@ -501,19 +983,29 @@ func (m *Master) Run() error {
 	m.echo.GET("/ws/data-layer/*",
 		api.WebSocketRoute(m.rwCoordinatorWebSocket))
 
-	m.echo.Any("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
-	m.echo.Any("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
-	m.echo.Any("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
-	m.echo.Any("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
-	m.echo.Any("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+	m.echo.GET("/ws/scheduler/events",
+		api.WebSocketRoute(m.schedulerEventsWebSocket), adminAuthFuncs...)
+
+	if m.config.EnablePprof {
+		registerPprofRoutes(m.echo, adminAuthFuncs...)
+	}
+
+	m.echo.GET("/proxy/tunnels", api.Route(m.getProxyTunnels), adminAuthFuncs...)
 
 	handler := m.system.AskAt(actor.Addr("proxy"), proxy.NewProxyHandler{ServiceID: "service"})
 	m.echo.Any("/proxy/:service/*", handler.Get().(echo.HandlerFunc))
 
-	handler = m.system.AskAt(actor.Addr("proxy"), proxy.NewConnectHandler{})
-	m.echo.CONNECT("*", handler.Get().(echo.HandlerFunc))
+	handler = m.system.AskAt(actor.Addr("proxy"), proxy.NewConnectHandler{
+		User: func(c echo.Context) model.User {
+			return c.(*context.DetContext).MustGetUser()
+		},
+		IdleTimeout: time.Duration(m.config.Tunnels.IdleTimeout) * time.Second,
+		MaxDuration: time.Duration(m.config.Tunnels.MaxDuration) * time.Second,
+	})
+	m.echo.CONNECT("*", handler.Get().(echo.HandlerFunc), authFuncs...)
 
 	user.RegisterAPIHandler(m.echo, userService, authFuncs...)
+	m.echo.GET("/users/me/limits", api.Route(m.getUserLimits), authFuncs...)
 	command.RegisterAPIHandler(
 		m.system,
 		m.echo,
@@ -522,28 +1014,24 @@ func (m *Master) Run() error {
 		m.config.TensorBoardTimeout,
 		m.config.Security.DefaultTask,
 		m.taskSpec,
-		authFuncs...,
+		append(authFuncs, m.maintenanceGate)...,
 	)
-	template.RegisterAPIHandler(m.echo, m.db, authFuncs...)
-
-	if m.config.Telemetry.Enabled && m.config.Telemetry.SegmentMasterKey != "" {
-		if telemetry, err := telemetry.NewActor(
-			m.db,
-			m.ClusterID,
-			m.MasterID,
-			m.Version,
-			resourcemanagers.GetResourceManagerType(m.config.ResourceManager),
-			m.config.Telemetry.SegmentMasterKey,
-		); err != nil {
-			// We wouldn't want to totally fail just because telemetry failed; just note the error.
-			log.WithError(err).Errorf("failed to initialize telemetry")
-		} else {
-			log.Info("telemetry reporting is enabled; run with `--telemetry-enabled=false` to disable")
-			m.system.ActorOf(actor.Addr("telemetry"), telemetry)
-		}
-	} else {
-		log.Info("telemetry reporting is disabled")
-	}
+	template.RegisterAPIHandler(m.echo, m.db, m.resolveTemplate, authFuncs...)
+
+	m.startTelemetry(m.config)
+	m.handleReloadSignal()
 
 	return m.startServers(cert)
 }
+
+// registerPprofRoutes serves the profiles of the master under pprofRoute. Profiles can expose
+// secrets held in memory, so authFuncs must only let admins through. The index page links to each
+// profile, such as goroutine?debug=2 for a full goroutine stack dump.
+func registerPprofRoutes(e *echo.Echo, authFuncs ...echo.MiddlewareFunc) {
+	pprofGroup := e.Group(pprofRoute, authFuncs...)
+	pprofGroup.Any("/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
+	pprofGroup.Any("/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
+	pprofGroup.Any("/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
+	pprofGroup.Any("/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+	pprofGroup.Any("/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// askTimeoutsMetric counts the asks of handlers that timed out, by the address of the actor that
+// was asked, to show which actor is the bottleneck when the master is busy.
+const askTimeoutsMetric = "det_actor_ask_timeouts_total"
+
+// Timeouts of asks that are slower than most, e.g., because the asked actor collects the state of
+// all of its children. They only apply if they are longer than the configured ask timeout.
+const (
+	agentsAskTimeout        = 30 * time.Second
+	taskSummariesAskTimeout = 30 * time.Second
+	// infoAskTimeout is short, since load balancers and clients poll /info often and it must not
+	// hang on a busy actor.
+	infoAskTimeout = time.Second
+)
+
+// askTimeout returns how long handlers wait for the response of an actor by default.
+func (m *Master) askTimeout() time.Duration {
+	return time.Duration(m.currentConfig().AskTimeout) * time.Second
+}
+
+// awaitAsk waits for the response of an ask for the configured ask timeout, or for timeout if it
+// is longer. Timed out asks are counted in the metrics.
+func (m *Master) awaitAsk(resp actor.Response, timeout time.Duration) (actor.Message, error) {
+	if defaultTimeout := m.askTimeout(); timeout < defaultTimeout {
+		timeout = defaultTimeout
+	}
+	result, ok := resp.GetOrTimeout(timeout)
+	if !ok {
+		addr := resp.Source().Address().String()
+		m.metrics.Inc(askTimeoutsMetric, "Number of asks of actors by handlers that timed out.",
+			map[string]string{"actor": addr})
+		return nil, fmt.Errorf("master busy: %s did not respond within %s", addr, timeout)
+	}
+	return result, nil
+}
+
+// awaitResponse is like awaitAsk, but returns timeouts as 503 errors for HTTP handlers, since
+// they mean that the master is too busy to respond rather than that something went wrong.
+func (m *Master) awaitResponse(resp actor.Response, timeout time.Duration) (actor.Message, error) {
+	result, err := m.awaitAsk(resp, timeout)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	}
+	return result, nil
+}
+
+// awaitResponse is like awaitAsk, but returns timeouts as Unavailable errors for gRPC handlers.
+func (a *apiServer) awaitResponse(
+	resp actor.Response, timeout time.Duration,
+) (actor.Message, error) {
+	result, err := a.m.awaitAsk(resp, timeout)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/metrics"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+func TestAwaitResponse(t *testing.T) {
+	system := actor.NewSystem("")
+	release := make(chan struct{})
+	ref, _ := system.ActorOf(actor.Addr("busy"), actor.ActorFunc(func(ctx *actor.Context) error {
+		if msg, ok := ctx.Message().(string); ok {
+			if msg == "wait" {
+				<-release
+			}
+			ctx.Respond(msg)
+		}
+		return nil
+	}))
+	defer close(release)
+
+	config := DefaultConfig()
+	config.AskTimeout = 1
+	m := &Master{config: config, metrics: metrics.NewRegistry(), system: system}
+
+	result, err := m.awaitResponse(system.Ask(ref, "ping"), 0)
+	assert.NilError(t, err)
+	assert.Equal(t, result, "ping")
+
+	start := time.Now()
+	_, err = m.awaitResponse(system.Ask(ref, "wait"), 0)
+	assert.Assert(t, time.Since(start) >= time.Second)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.Assert(t, ok)
+	assert.Equal(t, httpErr.Code, http.StatusServiceUnavailable)
+	assert.ErrorContains(t, err, "master busy: /busy did not respond within 1s")
+
+	var buf bytes.Buffer
+	assert.NilError(t, m.metrics.WriteText(&buf))
+	assert.Assert(t, strings.Contains(buf.String(), `det_actor_ask_timeouts_total{actor="/busy"} 1`))
+}
@@ -1,12 +1,23 @@
 package internal
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
 
+	requestContext "github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/storage"
+	"github.com/determined-ai/determined/master/pkg/jsonpatch"
 	"github.com/determined-ai/determined/master/pkg/model"
 )
 
@@ -31,24 +42,83 @@ type ExportableCheckpoint struct {
 	SearcherMetric    float64         `db:"searcher_metric" json:"searcher_metric"`
 }
 
+// redact masks the registry credentials in the experiment config of the checkpoint.
+func (e *ExportableCheckpoint) redact() error {
+	config, err := model.RedactRegistryAuthJSON(e.ExperimentConfig)
+	e.ExperimentConfig = config
+	return err
+}
+
 func (m *Master) getCheckpoint(c echo.Context) (interface{}, error) {
 	checkpoint := ExportableCheckpoint{}
-	err := m.db.Query("get_checkpoint", &checkpoint, c.Param("checkpoint_uuid"))
-	return checkpoint, err
+	if err := m.db.Query("get_checkpoint", &checkpoint, c.Param("checkpoint_uuid")); err != nil {
+		return nil, err
+	}
+	return checkpoint, checkpoint.redact()
+}
+
+// getCheckpointReferences lists the trials, experiments and model versions that use a checkpoint
+// as a source, so that users can check that nothing depends on a checkpoint before deleting it.
+func (m *Master) getCheckpointReferences(c echo.Context) (interface{}, error) {
+	id, err := uuid.Parse(c.Param("checkpoint_uuid"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid checkpoint UUID")
+	}
+	checkpoint, err := m.db.CheckpointByUUID(id)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("checkpoint %s not found", id))
+	}
+	refs, err := m.db.CheckpointReferences(id.String())
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		UUID  string `json:"uuid"`
+		InUse bool   `json:"in_use"`
+		*db.CheckpointReferences
+	}{
+		UUID:                 id.String(),
+		InUse:                len(refs.Trials)+len(refs.Experiments)+len(refs.ModelVersions) > 0,
+		CheckpointReferences: refs,
+	}, nil
 }
 
 func (m *Master) getCheckpoints(c echo.Context) (interface{}, error) {
 	var checkpoints []ExportableCheckpoint
+	viewer := experimentViewer(c.(*requestContext.DetContext).MustGetUser())
 	if eid := c.QueryParam("experiment_id"); eid != "" {
+		if id, err := strconv.Atoi(eid); err == nil {
+			if err = checkVisible(viewer, []int{id}, m.db.ExperimentVisible); err != nil {
+				return nil, err
+			}
+		}
 		if err := m.db.Query("get_checkpoints_for_experiment", &checkpoints, eid); err != nil {
 			return nil, err
 		}
 	} else {
 		tid := c.QueryParam("trial_id")
+		if id, err := strconv.Atoi(tid); err == nil && viewer != nil {
+			switch visible, err := m.db.TrialVisible(id, viewer); {
+			case err != nil:
+				return nil, err
+			case !visible:
+				return nil, echo.NewHTTPError(
+					http.StatusNotFound, fmt.Sprintf("trial %d not found", id))
+			}
+		}
 		if err := m.db.Query("get_checkpoints_for_trial", &checkpoints, tid); err != nil {
 			return nil, err
 		}
 	}
+	for i := range checkpoints {
+		if err := checkpoints[i].redact(); err != nil {
+			return nil, err
+		}
+	}
 	return checkpoints, nil
 }
 
@@ -117,3 +187,191 @@ func (m *Master) deleteCheckpointMetadata(c echo.Context) (interface{}, error) {
 
 	return checkpoint.Metadata, m.db.UpdateCheckpointMetadata(checkpoint)
 }
+
+// patchCheckpointMetadata updates the metadata of a checkpoint with either a JSON Merge Patch
+// (RFC 7386) or a JSON Patch (RFC 6902), depending on the content type of the request, and
+// returns the updated metadata.
+func (m *Master) patchCheckpointMetadata(c echo.Context) (interface{}, error) {
+	uuid, err := uuid.Parse(c.Param("checkpoint_uuid"))
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := m.db.CheckpointByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error querying for checkpoint (%v)", uuid)
+	}
+	if checkpoint == nil {
+		return nil, errors.Errorf("checkpoint (%v) does not exist", uuid)
+	}
+	if checkpoint.Metadata == nil {
+		checkpoint.Metadata = model.JSONObj{}
+	}
+	metadata := map[string]interface{}(checkpoint.Metadata)
+
+	var patched interface{}
+	switch contentType := c.Request().Header.Get(echo.HeaderContentType); contentType {
+	case "application/merge-patch+json":
+		var patch interface{}
+		if err = json.Unmarshal(body, &patch); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		patched = jsonpatch.MergePatch(metadata, patch)
+	case "application/json-patch+json":
+		var patch []jsonpatch.Operation
+		if err = json.Unmarshal(body, &patch); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if patched, err = jsonpatch.Apply(metadata, patch); err != nil {
+			return nil, echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+	default:
+		return nil, echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			"metadata can only be patched with `application/merge-patch+json` or "+
+				"`application/json-patch+json` requests")
+	}
+
+	patchedMetadata, ok := patched.(map[string]interface{})
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusUnprocessableEntity,
+			"checkpoint metadata must be an object")
+	}
+	checkpoint.Metadata = patchedMetadata
+	return checkpoint.Metadata, m.db.UpdateCheckpointMetadata(checkpoint)
+}
+
+// These are the outcomes of patching the metadata of a checkpoint of a batch.
+const (
+	metadataPatched  = "ok"
+	metadataNotFound = "not_found"
+	metadataInvalid  = "invalid"
+	metadataError    = "error"
+)
+
+// checkpointMetadataResult is the outcome of patching the metadata of a checkpoint of a batch,
+// along with the patched metadata if it succeeded.
+type checkpointMetadataResult struct {
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Metadata model.JSONObj `json:"metadata,omitempty"`
+}
+
+// patchCheckpointsMetadata updates the metadata of many checkpoints at once from a map of
+// checkpoint UUIDs to JSON Merge Patches (RFC 7386) of their metadata, and returns the outcome for
+// each UUID.
+func (m *Master) patchCheckpointsMetadata(c echo.Context) (interface{}, error) {
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+	var patches map[string]json.RawMessage
+	if err = json.Unmarshal(body, &patches); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			"the request must map checkpoint UUIDs to patches of their metadata")
+	}
+	viewer := experimentViewer(c.(*requestContext.DetContext).MustGetUser())
+	patchOne := func(
+		id uuid.UUID, patch func(model.JSONObj) (model.JSONObj, error),
+	) (model.JSONObj, error) {
+		// Checkpoints of experiments that the user does not see are reported as missing.
+		if viewer != nil {
+			switch visible, err := m.db.CheckpointVisible(id, viewer); {
+			case err != nil:
+				return nil, err
+			case !visible:
+				return nil, db.ErrNotFound
+			}
+		}
+		return m.db.PatchCheckpointMetadata(id, patch)
+	}
+	return patchMetadataBatch(patches, patchOne), nil
+}
+
+// patchMetadataBatch applies each merge patch to the metadata of its checkpoint with patchOne.
+// Checkpoints are patched one at a time, each in its own transaction, so a checkpoint that is
+// missing or whose patch is invalid does not keep the others from being patched.
+func patchMetadataBatch(
+	patches map[string]json.RawMessage,
+	patchOne func(uuid.UUID, func(model.JSONObj) (model.JSONObj, error)) (model.JSONObj, error),
+) map[string]checkpointMetadataResult {
+	results := make(map[string]checkpointMetadataResult, len(patches))
+	for rawID, rawPatch := range patches {
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			results[rawID] = checkpointMetadataResult{
+				Status: metadataInvalid, Error: fmt.Sprintf("invalid checkpoint UUID: %s", rawID),
+			}
+			continue
+		}
+		// Patches that are not objects would replace the metadata, which must be an object.
+		var patch map[string]interface{}
+		if err = json.Unmarshal(rawPatch, &patch); err != nil || patch == nil {
+			results[rawID] = checkpointMetadataResult{
+				Status: metadataInvalid, Error: "the patch of the metadata must be an object",
+			}
+			continue
+		}
+
+		metadata, err := patchOne(id, func(current model.JSONObj) (model.JSONObj, error) {
+			patched := jsonpatch.MergePatch(map[string]interface{}(current), patch)
+			return patched.(map[string]interface{}), nil
+		})
+		switch {
+		case errors.Cause(err) == db.ErrNotFound:
+			results[rawID] = checkpointMetadataResult{
+				Status: metadataNotFound, Error: fmt.Sprintf("checkpoint (%v) does not exist", id),
+			}
+		case err != nil:
+			results[rawID] = checkpointMetadataResult{Status: metadataError, Error: err.Error()}
+		default:
+			results[rawID] = checkpointMetadataResult{Status: metadataPatched, Metadata: metadata}
+		}
+	}
+	return results
+}
+
+// storageTestTimeout bounds how long testing the checkpoint storage may take.
+const storageTestTimeout = 30 * time.Second
+
+// getCheckpointStorageTest writes an object to the checkpoint storage of the master, reads it back
+// and deletes it, so that misconfigured storage is caught before trials fail to save checkpoints.
+func (m *Master) getCheckpointStorageTest(c echo.Context) (interface{}, error) {
+	config, err := m.currentConfig().CheckpointStorage.ToModel()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), storageTestTimeout)
+	defer cancel()
+	return storage.Test(ctx, *config), nil
+}
+
+// checkStorageOverride checks the checkpoint storage of an experiment that overrides the storage
+// of the master, so that experiments that could not save checkpoints are rejected when they are
+// submitted rather than failing at their first checkpoint. Object stores are tested as
+// getCheckpointStorageTest tests the storage of the master; shared_fs and hdfs storage are only
+// checked statically, since the master may not mount the paths of agents or reach HDFS.
+func checkStorageOverride(master, experiment model.CheckpointStorageConfig) error {
+	// Only where checkpoints are stored matters, not how many are kept.
+	master.SaveExperimentBest, master.SaveTrialBest, master.SaveTrialLatest =
+		experiment.SaveExperimentBest, experiment.SaveTrialBest, experiment.SaveTrialLatest
+	if reflect.DeepEqual(master, experiment) {
+		return nil
+	}
+	if err := storage.Check(experiment); err != nil {
+		return err
+	}
+	if experiment.S3Config == nil && experiment.GCSConfig == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storageTestTimeout)
+	defer cancel()
+	if result := storage.Test(ctx, experiment); !result.Success {
+		return errors.Errorf("%s checkpoint storage failed the %s step of a test: %s",
+			result.Type, result.Step, result.Error)
+	}
+	return nil
+}
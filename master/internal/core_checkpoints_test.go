@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestPatchMetadataBatch(t *testing.T) {
+	evaluated, missing, broken := uuid.New(), uuid.New(), uuid.New()
+	stored := map[uuid.UUID]model.JSONObj{
+		evaluated: {"stage": "dev", "eval": map[string]interface{}{"f1": 0.8, "split": "val"}},
+		broken:    {},
+	}
+	patchOne := func(
+		id uuid.UUID, patch func(model.JSONObj) (model.JSONObj, error),
+	) (model.JSONObj, error) {
+		if id == broken {
+			return nil, errors.New("connection reset")
+		}
+		metadata, ok := stored[id]
+		if !ok {
+			return nil, db.ErrNotFound
+		}
+		patched, err := patch(metadata)
+		if err == nil {
+			stored[id] = patched
+		}
+		return patched, err
+	}
+
+	results := patchMetadataBatch(map[string]json.RawMessage{
+		evaluated.String(): json.RawMessage(
+			`{"stage": null, "eval": {"f1": 0.83, "split": "holdout"}}`),
+		missing.String():    json.RawMessage(`{"stage": "prod"}`),
+		broken.String():     json.RawMessage(`{"stage": "prod"}`),
+		"not-a-uuid":        json.RawMessage(`{"stage": "prod"}`),
+		uuid.New().String(): json.RawMessage(`"replace everything"`),
+	}, patchOne)
+
+	want := model.JSONObj{"eval": map[string]interface{}{"f1": 0.83, "split": "holdout"}}
+	assert.DeepEqual(t, results[evaluated.String()],
+		checkpointMetadataResult{Status: metadataPatched, Metadata: want})
+	assert.DeepEqual(t, stored[evaluated], want)
+	assert.Equal(t, results[missing.String()].Status, metadataNotFound)
+	assert.Equal(t, results[broken.String()].Status, metadataError)
+	assert.Equal(t, results["not-a-uuid"].Status, metadataInvalid)
+	assert.Equal(t, len(results), 5)
+	for id, result := range results {
+		if result.Status == metadataInvalid && id != "not-a-uuid" {
+			assert.Equal(t, result.Error, "the patch of the metadata must be an object")
+		}
+	}
+}
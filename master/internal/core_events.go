@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/events"
+)
+
+// eventsKeepAliveInterval is how often a comment is sent on idle event streams, so that proxies
+// do not close them.
+const eventsKeepAliveInterval = 15 * time.Second
+
+// parseEventTypes parses a comma-separated list of event types. An empty list selects every type.
+func parseEventTypes(param string) (map[events.Type]bool, error) {
+	types := map[events.Type]bool{}
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !events.Types[events.Type(name)] {
+			return nil, echo.NewHTTPError(
+				http.StatusBadRequest, fmt.Sprintf("unknown event type %q", name))
+		}
+		types[events.Type(name)] = true
+	}
+	return types, nil
+}
+
+// writeServerSentEvent writes an event in the text/event-stream format. The id is left out if it
+// is zero.
+func writeServerSentEvent(w io.Writer, id uint64, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		if _, err = fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, payload)
+	return err
+}
+
+// getEvents streams changes to the state of the cluster as server-sent events. Clients resume
+// streams with the Last-Event-ID header, which browsers send when they reconnect, or the
+// last_event_id query parameter. A "reset" event is sent first if some of the events since then
+// are no longer kept, and a "disconnected" event is sent last if the client falls behind.
+func (m *Master) getEvents(c echo.Context) error {
+	types, err := parseEventTypes(c.QueryParam("types"))
+	if err != nil {
+		return err
+	}
+	subscribe := events.Subscribe{Types: types}
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+	if lastEventID != "" {
+		id, pErr := strconv.ParseUint(lastEventID, 10, 64)
+		if pErr != nil {
+			return echo.NewHTTPError(
+				http.StatusBadRequest, fmt.Sprintf("invalid last event ID %q", lastEventID))
+		}
+		subscribe.LastEventID = &id
+	}
+
+	sub, ok := m.system.AskAt(events.Addr, subscribe).Get().(*events.Subscription)
+	if !ok {
+		return errors.New("events are not available")
+	}
+	defer m.system.TellAt(events.Addr, events.Unsubscribe{Subscription: sub})
+
+	resp := c.Response()
+	resp.Header().Set(echo.HeaderContentType, "text/event-stream")
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.Header().Set("Connection", "keep-alive")
+	resp.WriteHeader(http.StatusOK)
+
+	if sub.Missed {
+		if err = writeServerSentEvent(resp, 0, "reset", map[string]string{
+			"reason": "some events since the last event ID are no longer available",
+		}); err != nil {
+			return nil
+		}
+	}
+	for _, event := range sub.Backlog {
+		if err = writeServerSentEvent(resp, event.ID, string(event.Type), event); err != nil {
+			return nil
+		}
+	}
+	resp.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			switch {
+			case ok:
+				err = writeServerSentEvent(resp, event.ID, string(event.Type), event)
+			case sub.Overflowed:
+				_ = writeServerSentEvent(resp, 0, "disconnected", map[string]string{
+					"reason": "the client fell too far behind; reconnect to resume",
+				})
+				resp.Flush()
+				return nil
+			default:
+				return nil
+			}
+		case <-keepAlive.C:
+			_, err = io.WriteString(resp, ": keep-alive\n\n")
+		case <-c.Request().Context().Done():
+			return nil
+		}
+		// Errors writing to the stream mean that the client went away.
+		if err != nil {
+			return nil
+		}
+		resp.Flush()
+	}
+}
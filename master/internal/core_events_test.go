@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/events"
+)
+
+func TestParseEventTypes(t *testing.T) {
+	types, err := parseEventTypes("")
+	assert.NilError(t, err)
+	assert.Equal(t, len(types), 0)
+
+	types, err = parseEventTypes("agent.connected, experiment.state_changed")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, types, map[events.Type]bool{
+		events.AgentConnected:         true,
+		events.ExperimentStateChanged: true,
+	})
+
+	_, err = parseEventTypes("agent.connected,agent.exploded")
+	assert.ErrorContains(t, err, `unknown event type "agent.exploded"`)
+}
+
+func TestWriteServerSentEvent(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NilError(t, writeServerSentEvent(&buf, 7, "agent.connected", map[string]string{
+		"agent_id": "agent-1",
+	}))
+	assert.NilError(t, writeServerSentEvent(&buf, 0, "reset", "gone"))
+	assert.Equal(t, buf.String(),
+		"id: 7\nevent: agent.connected\ndata: {\"agent_id\":\"agent-1\"}\n\n"+
+			"event: reset\ndata: \"gone\"\n\n")
+}
@@ -1,11 +1,16 @@
 package internal
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +22,9 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/lttb"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/archive"
@@ -27,19 +35,21 @@ import (
 // ExperimentRequestQuery contains values for the experiments request queries with defaults already
 // applied. This should to be kept in sync with the expected queries from ParseExperimentsQuery.
 type ExperimentRequestQuery struct {
-	User   string
-	Limit  int
-	Offset int
-	Filter string
+	User       string
+	ExternalID string
+	Limit      int
+	Offset     int
+	Filter     string
 }
 
 // ParseExperimentsQuery parse queries for the experiments endpoint.
 func ParseExperimentsQuery(apiCtx echo.Context) (*ExperimentRequestQuery, error) {
 	args := struct {
-		User   *string `query:"user"`
-		Limit  *int    `query:"limit"`
-		Offset *int    `query:"offset"`
-		Filter *string `query:"filter"`
+		User       *string `query:"user"`
+		ExternalID *string `query:"external_id"`
+		Limit      *int    `query:"limit"`
+		Offset     *int    `query:"offset"`
+		Filter     *string `query:"filter"`
 	}{}
 	var err error
 	if err = api.BindArgs(&args, apiCtx); err != nil {
@@ -52,6 +62,10 @@ func ParseExperimentsQuery(apiCtx echo.Context) (*ExperimentRequestQuery, error)
 		queries.User = *args.User
 	}
 
+	if args.ExternalID != nil {
+		queries.ExternalID = *args.ExternalID
+	}
+
 	if args.Filter != nil {
 		queries.Filter = *args.Filter
 	}
@@ -90,9 +104,12 @@ func (m *Master) getExperimentSummaries(c echo.Context) (interface{}, error) {
 		}
 		states = strings.Join(allStates, ",")
 	}
-	var results []ExperimentSummary
-	err := m.db.Query("get_experiment_summaries", &results, states)
-	return results, err
+	viewer := experimentViewer(c.(*context.DetContext).MustGetUser())
+	return m.cachedExperimentList(c, "experiment-summaries", func() (interface{}, error) {
+		var results []ExperimentSummary
+		err := m.db.Query("get_experiment_summaries", &results, states, viewer)
+		return results, err
+	})
 }
 
 func (m *Master) getExperimentList(c echo.Context) (interface{}, error) {
@@ -101,10 +118,13 @@ func (m *Master) getExperimentList(c echo.Context) (interface{}, error) {
 	if err != nil {
 		skipInactive = false
 	}
-	if userFilter != "" {
-		return m.db.ExperimentDescriptorsRawForUser(true, skipInactive, userFilter)
-	}
-	return m.db.ExperimentDescriptorsRaw(true, skipInactive)
+	viewer := experimentViewer(c.(*context.DetContext).MustGetUser())
+	return m.cachedExperimentList(c, "experiment-list", func() (interface{}, error) {
+		if userFilter != "" {
+			return m.db.ExperimentDescriptorsRawForUser(true, skipInactive, userFilter, viewer)
+		}
+		return m.db.ExperimentDescriptorsRaw(true, skipInactive, viewer)
+	})
 }
 
 func (m *Master) getExperiments(c echo.Context) (interface{}, error) {
@@ -115,7 +135,16 @@ func (m *Master) getExperiments(c echo.Context) (interface{}, error) {
 
 	skipArchived := query.Filter != "all"
 
-	return m.db.ExperimentListRaw(skipArchived, query.User, query.Limit, query.Offset)
+	return m.db.ExperimentListRaw(skipArchived, query.User, query.ExternalID,
+		experimentViewer(c.(*context.DetContext).MustGetUser()), query.Limit, query.Offset)
+}
+
+// redactedRaw masks the registry credentials in a raw JSON response that contains configs.
+func redactedRaw(data []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return model.RedactRegistryAuthJSON(data)
 }
 
 func (m *Master) getExperiment(c echo.Context) (interface{}, error) {
@@ -125,7 +154,49 @@ func (m *Master) getExperiment(c echo.Context) (interface{}, error) {
 	if err := api.BindArgs(&args, c); err != nil {
 		return nil, err
 	}
-	return m.db.ExperimentRaw(args.ExperimentID)
+	return redactedRaw(m.db.ExperimentRaw(args.ExperimentID))
+}
+
+// putExperimentOwner transfers the ownership of an experiment to another active user, e.g., when
+// its owner leaves. Containers that are already running keep the agent user and group of the
+// previous owner.
+func (m *Master) putExperimentOwner(c echo.Context) (interface{}, error) {
+	if !c.(*context.DetContext).MustGetUser().Admin {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "only admins may transfer experiments")
+	}
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	body := struct {
+		Username string `json:"username"`
+	}{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid owner: "+err.Error())
+	}
+
+	owner, err := m.db.UserByUsername(body.Username)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, echo.NewHTTPError(
+			http.StatusBadRequest, fmt.Sprintf("user %q does not exist", body.Username))
+	case err != nil:
+		return nil, errors.Wrapf(err, "loading user %s", body.Username)
+	case !owner.Active:
+		return nil, echo.NewHTTPError(
+			http.StatusBadRequest, fmt.Sprintf("user %q is not active", body.Username))
+	}
+
+	if err := m.db.SaveExperimentOwner(args.ExperimentID, owner.ID); err != nil {
+		return nil, err
+	}
+	m.experimentListCache.invalidate()
+	return struct {
+		ID       model.UserID `json:"id"`
+		Username string       `json:"username"`
+	}{owner.ID, owner.Username}, nil
 }
 
 func (m *Master) getExperimentCheckpoints(c echo.Context) (interface{}, error) {
@@ -146,7 +217,26 @@ func (m *Master) getExperimentSummary(c echo.Context) (interface{}, error) {
 	if err := api.BindArgs(&args, c); err != nil {
 		return nil, err
 	}
-	return m.db.ExperimentWithTrialSummariesRaw(args.ExperimentID)
+	return redactedRaw(m.db.ExperimentWithTrialSummariesRaw(args.ExperimentID))
+}
+
+// getExperimentStateHistory returns the state transitions of an experiment in the order they
+// happened, along with why each happened.
+func (m *Master) getExperimentStateHistory(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	switch exists, err := m.db.CheckExperimentExists(args.ExperimentID); {
+	case err != nil:
+		return nil, err
+	case !exists:
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("experiment %d not found", args.ExperimentID))
+	}
+	return m.db.ExperimentStateHistory(args.ExperimentID)
 }
 
 func (m *Master) getExperimentConfig(c echo.Context) (interface{}, error) {
@@ -156,17 +246,158 @@ func (m *Master) getExperimentConfig(c echo.Context) (interface{}, error) {
 	if err := api.BindArgs(&args, c); err != nil {
 		return nil, err
 	}
-	return m.db.ExperimentConfigRaw(args.ExperimentID)
+	return redactedRaw(m.db.ExperimentConfigRaw(args.ExperimentID))
+}
+
+// summaryMetricsArgs are the arguments of the experiment summary metrics endpoint.
+type summaryMetricsArgs struct {
+	ExperimentID  int
+	MaxDatapoints *int
+	Reduction     *db.MetricReduction
+	// Metrics are the exact names of the metrics to return, or all metrics if there are none.
+	Metrics []string
+}
+
+func parseSummaryMetricsArgs(c echo.Context) (summaryMetricsArgs, error) {
+	args := struct {
+		ExperimentID  int     `path:"experiment_id"`
+		MaxDatapoints *int    `query:"max_datapoints"`
+		Reduction     *string `query:"reduction"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return summaryMetricsArgs{}, err
+	}
+	if args.MaxDatapoints != nil && *args.MaxDatapoints < minDatapoints {
+		return summaryMetricsArgs{}, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("max_datapoints must be at least %d", minDatapoints))
+	}
+	metrics, err := parseMetricNames(c)
+	if err != nil {
+		return summaryMetricsArgs{}, err
+	}
+	parsed := summaryMetricsArgs{
+		ExperimentID: args.ExperimentID, MaxDatapoints: args.MaxDatapoints, Metrics: metrics,
+	}
+	if args.Reduction != nil {
+		reduction := db.MetricReduction(*args.Reduction)
+		if !reduction.Valid() {
+			return summaryMetricsArgs{}, echo.NewHTTPError(http.StatusBadRequest,
+				"reduction must be one of mean, median, min, max or last")
+		}
+		parsed.Reduction = &reduction
+	}
+	return parsed, nil
 }
 
 func (m *Master) getExperimentSummaryMetrics(c echo.Context) (interface{}, error) {
+	args, err := parseSummaryMetricsArgs(c)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := m.db.ExperimentWithSummaryMetricsRaw(args.ExperimentID, args.Reduction)
+	if err != nil {
+		return nil, err
+	}
+	if len(args.Metrics) > 0 {
+		if summary, err = filterMetrics(summary, args.Metrics); err != nil {
+			return nil, err
+		}
+	}
+	if args.MaxDatapoints == nil {
+		return summary, nil
+	}
+	return downsampleSummaryMetrics(summary, *args.MaxDatapoints)
+}
+
+func (m *Master) getExperimentMetricNames(c echo.Context) (interface{}, error) {
 	args := struct {
 		ExperimentID int `path:"experiment_id"`
 	}{}
 	if err := api.BindArgs(&args, c); err != nil {
 		return nil, err
 	}
-	return m.db.ExperimentWithSummaryMetricsRaw(args.ExperimentID)
+	switch exists, err := m.db.CheckExperimentExists(args.ExperimentID); {
+	case err != nil:
+		return nil, err
+	case !exists:
+		return nil, echo.NewHTTPError(
+			http.StatusNotFound, fmt.Sprintf("experiment %d not found", args.ExperimentID))
+	}
+
+	training, validation, err := m.db.MetricNameCounts(args.ExperimentID)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		Training   []db.MetricNameCount `json:"training"`
+		Validation []db.MetricNameCount `json:"validation"`
+	}{training, validation}, nil
+}
+
+// leaderboardSmallerIsBetter returns whether smaller values of the leaderboard metric are better,
+// given the requested order of the leaderboard, if any. It defaults to the order of the searcher,
+// which is only meaningful for the searcher metric, so the order of other metrics is required.
+func leaderboardSmallerIsBetter(
+	order *string, metric string, searcher model.SearcherConfig,
+) (bool, error) {
+	if order == nil {
+		if metric != searcher.Metric {
+			return false, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"order is required for %s, which is not the searcher metric %s",
+				metric, searcher.Metric))
+		}
+		return searcher.SmallerIsBetter, nil
+	}
+	switch *order {
+	case "asc":
+		return true, nil
+	case "desc":
+		return false, nil
+	default:
+		return false, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("order must be asc or desc, not %q", *order))
+	}
+}
+
+func (m *Master) getExperimentLeaderboard(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int     `path:"experiment_id"`
+		Metric       *string `query:"metric"`
+		Order        *string `query:"order"`
+		Limit        *int    `query:"limit"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	if args.Limit != nil && *args.Limit < 1 {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "limit must be at least 1")
+	}
+
+	config, err := m.db.ExperimentConfig(args.ExperimentID)
+	if errors.Cause(err) == db.ErrNotFound {
+		return nil, echo.NewHTTPError(
+			echo.ErrNotFound.Code, fmt.Sprintf("experiment %d not found", args.ExperimentID))
+	} else if err != nil {
+		return nil, err
+	}
+	metric := config.Searcher.Metric
+	if args.Metric != nil {
+		metric = *args.Metric
+	}
+	smallerIsBetter, err := leaderboardSmallerIsBetter(args.Order, metric, config.Searcher)
+	if err != nil {
+		return nil, err
+	}
+
+	trials, err := m.db.ExperimentLeaderboard(args.ExperimentID, metric, smallerIsBetter, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		Metric          string                `json:"metric"`
+		SmallerIsBetter bool                  `json:"smaller_is_better"`
+		Trials          []db.LeaderboardEntry `json:"trials"`
+	}{metric, smallerIsBetter, trials}, nil
 }
 
 func (m *Master) getExperimentCheckpointsToGC(c echo.Context) (interface{}, error) {
@@ -183,6 +414,50 @@ func (m *Master) getExperimentCheckpointsToGC(c echo.Context) (interface{}, erro
 		args.ExperimentID, args.ExperimentBest, args.TrialBest, args.TrialLatest, false)
 }
 
+// experimentSubStateQueued is the sub-state of active experiments that wait for resources as a
+// whole because max_active_experiments experiments already hold resources.
+const experimentSubStateQueued = "QUEUED"
+
+// getExperimentQueuePosition returns where the experiment waits for resources in the queue of its
+// resource pool, or a null position if none of its trials wait.
+func (m *Master) getExperimentQueuePosition(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	switch exists, err := m.db.CheckExperimentExists(args.ExperimentID); {
+	case err != nil:
+		return nil, err
+	case !exists:
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("experiment not found: %d", args.ExperimentID))
+	}
+
+	resp := m.system.Ask(m.rm, resourcemanagers.GetQueuePosition{ExperimentID: args.ExperimentID})
+	if _, err := m.awaitResponse(resp, taskSummariesAskTimeout); err != nil {
+		return nil, err
+	}
+	// The resource managers actor forwards an empty response as nil.
+	var position *resourcemanagers.QueuePosition
+	if p, ok := resp.Get().(resourcemanagers.QueuePosition); ok {
+		position = &p
+	}
+	// Experiments that are held back by max_active_experiments have no trials that hold
+	// resources, so they are queued as a whole.
+	var subState *string
+	if position != nil && position.PendingReason == resourcemanagers.PendingMaxActiveExperiments {
+		queued := experimentSubStateQueued
+		subState = &queued
+	}
+	return struct {
+		ExperimentID  int                             `json:"experiment_id"`
+		SubState      *string                         `json:"sub_state"`
+		QueuePosition *resourcemanagers.QueuePosition `json:"queue_position"`
+	}{args.ExperimentID, subState, position}, nil
+}
+
 func (m *Master) getExperimentModelDefinition(c echo.Context) error {
 	args := struct {
 		ExperimentID int `path:"experiment_id"`
@@ -191,7 +466,7 @@ func (m *Master) getExperimentModelDefinition(c echo.Context) error {
 		return err
 	}
 
-	modelDef, err := m.db.ExperimentModelDefinitionRaw(args.ExperimentID)
+	modelDef, err := m.experimentModelDefinition(args.ExperimentID)
 	if err != nil {
 		return err
 	}
@@ -251,6 +526,9 @@ func (m *Master) patchExperiment(c echo.Context) (interface{}, error) {
 			SaveTrialBest      int `json:"save_trial_best"`
 			SaveTrialLatest    int `json:"save_trial_latest"`
 		} `json:"checkpoint_storage"`
+		Searcher *struct {
+			MaxConcurrentTrials *int `json:"max_concurrent_trials"`
+		} `json:"searcher"`
 		Archived *bool `json:"archived"`
 	}{}
 	if err := api.BindPatch(&patch, c); err != nil {
@@ -261,6 +539,31 @@ func (m *Master) patchExperiment(c echo.Context) (interface{}, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "loading experiment %v", args.ExperimentID)
 	}
+	if err = checkExperimentNotDeleted(dbExp); err != nil {
+		return nil, echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	// Imported experiments may only be archived and unarchived.
+	if patch.State != nil || patch.Description != nil || patch.Labels != nil ||
+		patch.Resources != nil || patch.CheckpointStorage != nil || patch.Searcher != nil {
+		if err = checkExperimentNotImported(dbExp); err != nil {
+			return nil, err
+		}
+	}
+	// Experiments waiting for their dependencies start active, so activating them does nothing;
+	// they can only be canceled.
+	if patch.State != nil && dbExp.State == model.QueuedDependencyState {
+		switch *patch.State {
+		case model.ActiveState:
+		case model.StoppingCanceledState:
+			if _, err = m.cancelQueuedExperiment(args.ExperimentID); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"experiment %d is waiting for its dependencies and can only be canceled",
+				args.ExperimentID))
+		}
+	}
 
 	agentUserGroup, err := m.db.AgentUserGroup(*dbExp.OwnerID)
 	if err != nil {
@@ -268,7 +571,18 @@ func (m *Master) patchExperiment(c echo.Context) (interface{}, error) {
 	}
 
 	if agentUserGroup == nil {
-		agentUserGroup = &m.config.Security.DefaultTask
+		agentUserGroup = &m.currentConfig().Security.DefaultTask
+	}
+
+	// Adjust the searcher first since the experiment may reject the change, e.g., if its searcher
+	// does not support it. The submitted configuration is kept so that the searcher can be
+	// restored; the change is recorded as a searcher event instead.
+	if patch.Searcher != nil && patch.Searcher.MaxConcurrentTrials != nil {
+		if _, err := m.askExperiment(args.ExperimentID, setMaxConcurrentTrials{
+			maxConcurrentTrials: *patch.Searcher.MaxConcurrentTrials,
+		}); err != nil {
+			return nil, err
+		}
 	}
 
 	if patch.Archived != nil {
@@ -308,6 +622,7 @@ func (m *Master) patchExperiment(c echo.Context) (interface{}, error) {
 	if err := m.db.SaveExperimentConfig(dbExp); err != nil {
 		return nil, errors.Wrapf(err, "patching experiment %d", dbExp.ID)
 	}
+	m.experimentListCache.invalidate()
 
 	if patch.State != nil {
 		m.system.TellAt(actor.Addr("experiments", args.ExperimentID), *patch.State)
@@ -328,7 +643,7 @@ func (m *Master) patchExperiment(c echo.Context) (interface{}, error) {
 		m.system.ActorOf(actor.Addr(fmt.Sprintf("patch-checkpoint-gc-%s", uuid.New().String())),
 			&checkpointGCTask{
 				agentUserGroup: agentUserGroup,
-				taskSpec:       m.taskSpec,
+				taskSpec:       m.currentTaskSpec(),
 				rm:             m.rm,
 				db:             m.db,
 				experiment:     dbExp,
@@ -350,52 +665,208 @@ type CreateExperimentParams struct {
 	GitCommitter  *string         `json:"git_committer"`
 	GitCommitDate *time.Time      `json:"git_commit_date"`
 	ValidateOnly  bool            `json:"validate_only"`
+	// ExternalID identifies the experiment in the system that submits it.
+	ExternalID *string `json:"external_id"`
+	// Schedule saves the submission to create experiments from at the scheduled times, rather than
+	// creating one right away.
+	Schedule *scheduleParams `json:"schedule"`
+	// DependsOn holds the experiment in the QUEUED_DEPENDENCY state until the experiments with
+	// these IDs meet DependencyCondition, which defaults to completing.
+	DependsOn           []int                      `json:"depends_on"`
+	DependencyCondition *model.DependencyCondition `json:"dependency_condition"`
+	// WarmStartFromDependency starts the experiment from the best checkpoint of its only
+	// dependency.
+	WarmStartFromDependency bool `json:"warm_start_from_dependency"`
+	// Team names the team whose members see the experiment. It defaults to the only team of the
+	// submitting user, if any, and an empty team makes the experiment visible to everyone.
+	Team *string `json:"team"`
 }
 
-func (m *Master) parseCreateExperiment(params *CreateExperimentParams) (
-	*model.Experiment, bool, error,
+// checkExperimentConfigSize returns an error if the config of a submitted experiment is larger
+// than the master allows.
+func (m *Master) checkExperimentConfigSize(config string) error {
+	if limit := m.currentConfig().MaxExperimentConfigBytes; int64(len(config)) > limit {
+		return errors.Errorf(
+			"experiment config is %d bytes, larger than the limit of %d bytes; see "+
+				"max_experiment_config_bytes in the master config", len(config), limit)
+	}
+	return nil
+}
+
+// maxExternalIDLength is the longest external ID that experiments may have.
+const maxExternalIDLength = 255
+
+// checkExternalID checks that an experiment may be created with the external ID.
+func (m *Master) checkExternalID(externalID string) error {
+	switch {
+	case strings.TrimSpace(externalID) == "":
+		return echo.NewHTTPError(http.StatusBadRequest, "external_id must not be empty")
+	case len(externalID) > maxExternalIDLength:
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"external_id must be at most %d characters", maxExternalIDLength))
+	case !m.currentConfig().Experiments.UniqueExternalIDs:
+		return nil
+	}
+	ids, err := m.db.ExperimentIDsByExternalID(externalID)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+			"external_id %q is already used by experiment %d", externalID, ids[0]))
+	}
+	return nil
+}
+
+// duplicateExternalID is the error for an experiment whose external ID was taken by another
+// experiment that was submitted concurrently.
+func duplicateExternalID(externalID string) error {
+	return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+		"external_id %q is already used by another experiment", externalID))
+}
+
+// experimentConfigDefaults returns the config that the templates and configs of submitted
+// experiments are merged onto, and the options to unmarshal them with.
+func experimentConfigDefaults(masterConfig *Config) (
+	model.ExperimentConfig, []yaml.JSONOpt, error,
 ) {
-	config := model.DefaultExperimentConfig(&m.config.TaskContainerDefaults)
+	config := model.DefaultExperimentConfig(&masterConfig.TaskContainerDefaults)
 
-	checkpointStorage, err := m.config.CheckpointStorage.ToModel()
+	checkpointStorage, err := masterConfig.CheckpointStorage.ToModel()
 	if err != nil {
-		return nil, false, errors.Wrap(err, "invalid experiment configuration")
+		return config, nil, err
 	}
 
 	config.CheckpointStorage = *checkpointStorage
 
+	var opts []yaml.JSONOpt
+	if !masterConfig.AllowUnknownExperimentConfigFields {
+		opts = append(opts, yaml.DisallowUnknownFields)
+	}
+	return config, opts, nil
+}
+
+// resolveTemplate merges the config of a template onto the experiment defaults of the master, as
+// submitting an experiment with the template and an empty config would.
+func (m *Master) resolveTemplate(templateConfig []byte) (json.RawMessage, error) {
+	config, opts, err := experimentConfigDefaults(m.currentConfig())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid checkpoint storage configuration")
+	}
+	if err = yaml.Unmarshal(templateConfig, &config, opts...); err != nil {
+		return nil, errors.Wrap(err, "invalid template configuration")
+	}
+	// The defaults hold the settings of every searcher, which only choosing one narrows down, so
+	// a template without a searcher resolves to the settings that all searchers share.
+	var chosen struct {
+		Searcher struct {
+			Name string `json:"name"`
+		} `json:"searcher"`
+	}
+	if err = yaml.Unmarshal(templateConfig, &chosen); err != nil || chosen.Searcher.Name != "" {
+		return redactedRaw(json.Marshal(config))
+	}
+	return redactedRaw(json.Marshal(struct {
+		model.ExperimentConfig
+		Searcher map[string]interface{} `json:"searcher"`
+	}{config, map[string]interface{}{
+		"metric":                 config.Searcher.Metric,
+		"smaller_is_better":      config.Searcher.SmallerIsBetter,
+		"source_trial_id":        config.Searcher.SourceTrialID,
+		"source_checkpoint_uuid": config.Searcher.SourceCheckpointUUID,
+	}}))
+}
+
+// validateExperimentConfig runs the checks that an experiment config must pass, against the
+// current master config, before an experiment is created from it.
+func (m *Master) validateExperimentConfig(config model.ExperimentConfig, parentID *int) error {
+	masterConfig := m.currentConfig()
+	if cerr := check.Validate(config); cerr != nil {
+		return errors.Wrap(cerr, "invalid experiment configuration")
+	}
+
+	masterStorage, err := masterConfig.CheckpointStorage.ToModel()
+	if err != nil {
+		return errors.Wrap(err, "invalid checkpoint storage configuration of the master")
+	}
+	if serr := checkStorageOverride(*masterStorage, config.CheckpointStorage); serr != nil {
+		return errors.Wrap(serr, "invalid checkpoint storage configuration")
+	}
+
+	if verr := validateSubmission(masterConfig.SubmitValidators, config); verr != nil {
+		return verr
+	}
+
+	if config.EarlyStopping != nil {
+		if merr := m.checkEarlyStoppingMetric(config, parentID); merr != nil {
+			return errors.Wrap(merr, "invalid experiment configuration")
+		}
+	}
+	return nil
+}
+
+// startExperiment saves a new experiment and starts its actor.
+func (m *Master) startExperiment(dbExp *model.Experiment) (*experiment, error) {
+	e, err := newExperiment(m, dbExp)
+	if err != nil {
+		return nil, err
+	}
+	m.system.ActorOf(actor.Addr("experiments", e.ID), e)
+	m.experimentListCache.invalidate()
+	return e, nil
+}
+
+func (m *Master) parseCreateExperiment(params *CreateExperimentParams) (
+	*model.Experiment, bool, error,
+) {
+	masterConfig := m.currentConfig()
+	config, opts, err := experimentConfigDefaults(masterConfig)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "invalid experiment configuration")
+	}
+
 	if params.Template != nil {
 		template, terr := m.db.TemplateByName(*params.Template)
 		if terr != nil {
 			return nil, false, terr
 		}
-		if yerr := yaml.Unmarshal(template.Config, &config, yaml.DisallowUnknownFields); yerr != nil {
+		if yerr := yaml.Unmarshal(template.Config, &config, opts...); yerr != nil {
 			return nil, false, yerr
 		}
 	}
 
-	if yerr := yaml.Unmarshal(
-		[]byte(params.ConfigBytes), &config, yaml.DisallowUnknownFields,
-	); yerr != nil {
+	if yerr := yaml.Unmarshal([]byte(params.ConfigBytes), &config, opts...); yerr != nil {
 		return nil, false, errors.Wrap(yerr, "invalid experiment configuration")
 	}
+	if !masterConfig.AllowUnknownExperimentConfigFields {
+		if kerr := checkKnownExperimentFields([]byte(params.ConfigBytes)); kerr != nil {
+			return nil, false, errors.Wrap(kerr, "invalid experiment configuration")
+		}
+	}
 
 	if config.Environment.PodSpec == nil {
 		if config.Resources.SlotsPerTrial == 0 {
-			config.Environment.PodSpec = m.config.TaskContainerDefaults.CPUPodSpec
+			config.Environment.PodSpec = masterConfig.TaskContainerDefaults.CPUPodSpec
 		} else {
-			config.Environment.PodSpec = m.config.TaskContainerDefaults.GPUPodSpec
+			config.Environment.PodSpec = masterConfig.TaskContainerDefaults.GPUPodSpec
 		}
 	}
 
-	if cerr := check.Validate(config); cerr != nil {
-		return nil, false, errors.Wrap(cerr, "invalid experiment configuration")
+	if verr := m.validateExperimentConfig(config, params.ParentID); verr != nil {
+		return nil, false, verr
 	}
 
 	var modelBytes []byte
 	if params.ParentID != nil {
-		var dbErr error
-		modelBytes, dbErr = m.db.ExperimentModelDefinitionRaw(*params.ParentID)
+		parent, dbErr := m.db.ExperimentWithoutConfigByID(*params.ParentID)
+		if dbErr != nil {
+			return nil, false, errors.Wrapf(
+				dbErr, "unable to find parent experiment %v", *params.ParentID)
+		}
+		if derr := checkExperimentNotDeleted(parent); derr != nil {
+			return nil, false, derr
+		}
+		modelBytes, dbErr = m.experimentModelDefinition(*params.ParentID)
 		if dbErr != nil {
 			return nil, false, errors.Wrapf(
 				dbErr, "unable to find parent experiment %v", *params.ParentID)
@@ -412,7 +883,46 @@ func (m *Master) parseCreateExperiment(params *CreateExperimentParams) (
 	dbExp, err := model.NewExperiment(
 		config, modelBytes, params.ParentID, params.Archived,
 		params.GitRemote, params.GitCommit, params.GitCommitter, params.GitCommitDate)
-	return dbExp, params.ValidateOnly, err
+	if err != nil {
+		return nil, false, err
+	}
+	dbExp.ExternalID = params.ExternalID
+	return dbExp, params.ValidateOnly, nil
+}
+
+// checkEarlyStoppingMetric checks that the early stopping policies refer to a validation metric
+// that the experiment will report. Metric names are only known once a model has validated, so only
+// the metric of experiments forked from one that validated is checked; otherwise, validations that
+// lack the metric are skipped when the policies are evaluated.
+func (m *Master) checkEarlyStoppingMetric(config model.ExperimentConfig, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
+	_, names, _, _, err := m.db.MetricNames(*parentID, time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	return checkEarlyStoppingMetricNames(config, parentID, names)
+}
+
+// checkEarlyStoppingMetricNames checks the early stopping metric against the validation metrics
+// that the parent experiment reported, if it reported any.
+func checkEarlyStoppingMetricNames(
+	config model.ExperimentConfig, parentID *int, names []string,
+) error {
+	metric, _ := config.EarlyStoppingMetric()
+	if len(names) == 0 {
+		return nil
+	}
+	for _, name := range names {
+		if name == metric {
+			return nil
+		}
+	}
+	sort.Strings(names)
+	return errors.Errorf(
+		"early_stopping metric %s is not a validation metric of parent experiment %d (%s)",
+		metric, *parentID, strings.Join(names, ", "))
 }
 
 func (m *Master) postExperiment(c echo.Context) (interface{}, error) {
@@ -427,6 +937,9 @@ func (m *Master) postExperiment(c echo.Context) (interface{}, error) {
 	if err = json.Unmarshal(body, &params); err != nil {
 		return nil, errors.Wrap(err, "invalid experiment params")
 	}
+	if err = m.checkExperimentConfigSize(params.ConfigBytes); err != nil {
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+	}
 
 	dbExp, validateOnly, err := m.parseCreateExperiment(&params)
 
@@ -436,75 +949,371 @@ func (m *Master) postExperiment(c echo.Context) (interface{}, error) {
 			errors.Wrap(err, "invalid experiment"))
 	}
 
+	if params.Schedule != nil {
+		if err = params.Schedule.validate(time.Now()); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if dbExp.ExternalID != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"external_id cannot be used with a schedule, since it identifies one experiment")
+		}
+		if params.Team != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"team cannot be used with a schedule; scheduled experiments join the owner's team")
+		}
+	}
+
+	if dbExp.ExternalID != nil {
+		if err = m.checkExternalID(*dbExp.ExternalID); err != nil {
+			return nil, err
+		}
+		// The database enforces the uniqueness, in case another experiment with the same external
+		// ID is submitted concurrently.
+		dbExp.ExternalIDUnique = m.currentConfig().Experiments.UniqueExternalIDs
+	}
+
+	if dbExp.TeamID, err = m.experimentTeam(user, params.Team); err != nil {
+		return nil, err
+	}
+
+	deps, err := m.parseExperimentDependencies(&params, dbExp)
+	if err != nil {
+		return nil, err
+	}
+
 	if validateOnly {
-		return nil, c.NoContent(http.StatusNoContent)
+		return nil, nil
+	}
+
+	// Saving a schedule does not start any work, so it is allowed during maintenance.
+	if params.Schedule != nil {
+		return m.postExperimentSchedule(c, user, *params.Schedule, dbExp)
+	}
+	// Likewise, queued experiments only start once the maintenance ends.
+	if deps != nil {
+		return m.postQueuedExperiment(c, user, dbExp, deps)
+	}
+
+	override, _ := strconv.ParseBool(c.QueryParam(maintenanceOverrideParam))
+	if err = m.checkMaintenance(user, override); err != nil {
+		return nil, echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
 	}
 
 	dbExp.OwnerID = &user.ID
-	e, err := newExperiment(m, dbExp)
-	if err != nil {
+	e, err := m.startExperiment(dbExp)
+	if errors.Cause(err) == db.ErrDuplicateRecord {
+		return nil, duplicateExternalID(*dbExp.ExternalID)
+	} else if err != nil {
 		return nil, errors.Wrap(err, "starting experiment")
 	}
-	m.system.ActorOf(actor.Addr("experiments", e.ID), e)
 
 	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/experiments/%v", e.ID))
 	response := model.ExperimentDescriptor{
-		ID:       e.ID,
-		Archived: false,
-		Config:   e.Config,
-		Labels:   make([]string, 0),
+		ID:         e.ID,
+		Archived:   false,
+		Config:     e.Config,
+		Labels:     make([]string, 0),
+		ExternalID: e.ExternalID,
 	}
-	return c.JSON(http.StatusCreated, response), nil
+	return api.StatusResponse{Code: http.StatusCreated, Body: response}, nil
 }
 
-func (m *Master) deleteExperiment(c echo.Context) (interface{}, error) {
+// modelDefinitionFormField is the field of the multipart form posted to
+// /experiments/:experiment_id/model_def that carries the model definition as a .tar.gz archive.
+const modelDefinitionFormField = "model_definition"
+
+// multipartOverheadBytes bounds the size of the multipart headers and boundaries around an
+// uploaded model definition, so that uploads that are clearly too large are rejected up front.
+const multipartOverheadBytes = 64 << 10
+
+func modelDefinitionTooLarge(limit int64) error {
+	return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf(
+		"model definition is larger than the limit of %d bytes; see "+
+			"max_model_definition_bytes in the master config", limit))
+}
+
+// spoolModelDefinition streams the model definition field of a multipart upload to a temporary
+// file, failing once it is larger than the limit. The caller must close and remove the file.
+func spoolModelDefinition(req *http.Request, limit int64) (*os.File, int64, error) {
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, 0, echo.NewHTTPError(http.StatusBadRequest,
+			"the model definition must be uploaded as multipart/form-data")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"the upload has no %s field", modelDefinitionFormField))
+		} else if err != nil {
+			return nil, 0, echo.NewHTTPError(http.StatusBadRequest,
+				"invalid multipart upload: "+err.Error())
+		}
+		if part.FormName() != modelDefinitionFormField {
+			continue
+		}
+
+		file, err := ioutil.TempFile("", "model-definition-*.tar.gz")
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "error creating file for model definition")
+		}
+		size, err := io.Copy(file, io.LimitReader(part, limit+1))
+		switch {
+		case err != nil:
+			err = echo.NewHTTPError(http.StatusBadRequest,
+				"error receiving model definition: "+err.Error())
+		case size > limit:
+			err = modelDefinitionTooLarge(limit)
+		default:
+			_, err = file.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+			return nil, 0, err
+		}
+		return file, size, nil
+	}
+}
+
+// postExperimentModelDefinition uploads the model definition of an experiment separately from its
+// config, e.g., for experiments submitted with an empty model definition because it is large. The
+// archive is streamed to disk as it is received, and checked, hashed and stored from there rather
+// than buffered in memory; only the experiment loads it, once it accepts the model definition. The
+// model definition may only be replaced while the experiment is paused and has not run any trials.
+func (m *Master) postExperimentModelDefinition(c echo.Context) (interface{}, error) {
 	args := struct {
 		ExperimentID int `path:"experiment_id"`
 	}{}
 	if err := api.BindArgs(&args, c); err != nil {
 		return nil, err
 	}
+
+	user := c.(*context.DetContext).MustGetUser()
+	dbExp, err := m.db.ExperimentWithoutConfigByID(args.ExperimentID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Admin && (dbExp.OwnerID == nil || *dbExp.OwnerID != user.ID) {
+		return nil, echo.NewHTTPError(http.StatusForbidden,
+			"only the owner of an experiment or an admin may upload its model definition")
+	}
+	if err = checkExperimentNotImported(dbExp); err != nil {
+		return nil, err
+	}
+
+	// Reject uploads whose declared size is too large before the client sends the body.
+	limit := m.currentConfig().MaxModelDefinitionBytes
+	if c.Request().ContentLength > limit+multipartOverheadBytes {
+		return nil, modelDefinitionTooLarge(limit)
+	}
+
+	file, size, err := spoolModelDefinition(c.Request(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	numFiles, err := archive.CountTarGz(file)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			"the model definition must be a .tar.gz archive: "+err.Error())
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	hash, err := m.modelDefinitions.PutFrom(c.Request().Context(), file)
+	if err != nil {
+		return nil, err
+	}
+	// Decode the model definition here rather than in the experiment actor, which would otherwise
+	// stop handling messages while it does.
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	modelDefinition, err := archive.FromTarGz(content)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.askExperiment(args.ExperimentID, setModelDefinition{
+		modelDefinitionHash:  hash,
+		modelDefinitionBytes: content,
+		modelDefinition:      modelDefinition,
+	}); err != nil {
+		return nil, err
+	}
+	return struct {
+		ExperimentID int   `json:"experiment_id"`
+		Size         int64 `json:"size"`
+		NumFiles     int   `json:"num_files"`
+	}{
+		ExperimentID: args.ExperimentID,
+		Size:         size,
+		NumFiles:     numFiles,
+	}, nil
+}
+
+// deleteExperiment soft-deletes an experiment, which hides it from experiment lists until it is
+// deleted for good once the delete grace period passes; until then it can be undeleted. With
+// ?hard=true, or without a grace period, the experiment is deleted for good right away.
+func (m *Master) deleteExperiment(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int   `path:"experiment_id"`
+		Hard         *bool `query:"hard"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
 	expID := args.ExperimentID
-	dbExp, err := m.db.ExperimentByID(expID)
+	dbExp, err := m.db.ExperimentWithoutConfigByID(expID)
 	if err != nil {
 		return nil, errors.Wrapf(err, "loading experiment %v to delete", expID)
 	}
+	if dbExp.State == model.DeletingState {
+		return nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+			"experiment %v is already being deleted; retry failed deletions with "+
+				"POST /experiments/%v/retry-delete", expID, expID))
+	}
 	if _, ok := model.TerminalStates[dbExp.State]; !ok {
 		return nil, errors.Errorf("cannot delete experiment %v in state %v", expID, dbExp.State)
 	}
+	gracePeriod := time.Duration(m.currentConfig().DeleteGracePeriod) * time.Second
+	hard := (args.Hard != nil && *args.Hard) || gracePeriod == 0
+	if !hard && dbExp.DeletedAt != nil {
+		return nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+			"experiment %v is already deleted; undelete it with POST /experiments/%v/undelete "+
+				"or delete it for good with ?hard=true", expID, expID))
+	}
 
-	agentUserGroup, err := m.db.AgentUserGroup(*dbExp.OwnerID)
+	// Deleting the experiment would delete the checkpoints of any model versions registered from it.
+	versions, err := m.db.ExperimentModelVersions(expID)
 	if err != nil {
-		return nil, errors.Errorf("cannot find user and group for experiment %v", expID)
+		return nil, err
+	}
+	if len(versions) > 0 {
+		var names []string
+		for _, v := range versions {
+			names = append(names, fmt.Sprintf("%s version %d", v.ModelName, v.Version))
+		}
+		return nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+			"cannot delete experiment %v: its checkpoints are registered as model versions (%s)",
+			expID, strings.Join(names, ", ")))
+	}
+
+	if !hard {
+		deletedAt, err := m.db.SoftDeleteExperiment(expID)
+		if err != nil {
+			return nil, err
+		}
+		m.experimentListCache.invalidate()
+		return struct {
+			ExperimentID int       `json:"experiment_id"`
+			DeletedAt    time.Time `json:"deleted_at"`
+			PurgeAfter   time.Time `json:"purge_after"`
+		}{
+			ExperimentID: expID,
+			DeletedAt:    deletedAt,
+			PurgeAfter:   deletedAt.Add(gracePeriod),
+		}, nil
 	}
-	if agentUserGroup == nil {
-		agentUserGroup = &m.config.Security.DefaultTask
-	}
-
-	// Change the GC policy to remove all checkpoints. This will trigger a checkpoint GC task,
-	// if needed, to remove the checkpoint files.
-	dbExp.Config.CheckpointStorage.SaveExperimentBest = 0
-	dbExp.Config.CheckpointStorage.SaveTrialBest = 0
-	dbExp.Config.CheckpointStorage.SaveTrialLatest = 0
-	if serr := m.db.SaveExperimentConfig(dbExp); serr != nil {
-		return nil, errors.Wrapf(serr, "patching experiment %d", dbExp.ID)
-	}
-	addr := actor.Addr(fmt.Sprintf("delete-checkpoint-gc-%s", uuid.New().String()))
-	m.system.ActorOf(addr, &checkpointGCTask{
-		agentUserGroup: agentUserGroup,
-		taskSpec:       m.taskSpec,
-		rm:             m.rm,
-		db:             m.db,
-		experiment:     dbExp,
-	})
 
-	c.Logger().Infof("deleting experiment %v from database", expID)
-	if err = m.db.DeleteExperiment(expID); err != nil {
-		return nil, errors.Wrapf(err, "deleting experiment %v from database", expID)
+	// Deleting checkpoints from storage can take a long time, so it happens in the background
+	// while the experiment is in the deleting state, which clients can poll for.
+	if err = m.db.MarkExperimentDeleting(expID); err != nil {
+		return nil, err
+	}
+	recordTransitionWithoutActor(m.db, expID, dbExp.State, model.DeletingState,
+		"deleted through the API")
+	m.experimentListCache.invalidate()
+	return m.startExperimentDeletion(c, expID)
+}
+
+// checkExperimentNotDeleted returns an error if the experiment was soft-deleted, since deleted
+// experiments are only kept so that they can be undeleted.
+func checkExperimentNotDeleted(exp *model.Experiment) error {
+	if exp.DeletedAt == nil {
+		return nil
+	}
+	return errors.Errorf(
+		"experiment %d is deleted; undelete it with POST /experiments/%d/undelete first",
+		exp.ID, exp.ID)
+}
+
+// postExperimentUndelete restores an experiment that was soft-deleted and whose grace period did
+// not pass yet.
+func (m *Master) postExperimentUndelete(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	dbExp, err := m.db.ExperimentWithoutConfigByID(args.ExperimentID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading experiment %v", args.ExperimentID)
 	}
+	switch {
+	case dbExp.State == model.DeletingState:
+		return nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+			"experiment %v is being deleted for good and cannot be undeleted", args.ExperimentID))
+	case dbExp.DeletedAt == nil:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"experiment %v is not deleted", args.ExperimentID))
+	}
+	if err = m.db.UndeleteExperiment(args.ExperimentID); err != nil {
+		return nil, err
+	}
+	m.experimentListCache.invalidate()
 	return nil, nil
 }
 
+// postExperimentRetryDelete retries deleting an experiment whose checkpoints could not all be
+// deleted from storage.
+func (m *Master) postExperimentRetryDelete(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	dbExp, err := m.db.ExperimentWithoutConfigByID(args.ExperimentID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading experiment %v", args.ExperimentID)
+	}
+	if dbExp.State != model.DeletingState {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"experiment %v is not being deleted", args.ExperimentID))
+	}
+	return m.startExperimentDeletion(c, args.ExperimentID)
+}
+
+// startExperimentDeletion hands an experiment in the deleting state to the experiment deleter and
+// responds with 202 Accepted, pointing to the experiment to poll for the outcome.
+func (m *Master) startExperimentDeletion(c echo.Context, expID int) (interface{}, error) {
+	resp, err := m.awaitResponse(
+		m.system.AskAt(experimentDeleterAddr, deleteExperiment{experimentID: expID}), m.askTimeout())
+	if err != nil {
+		return nil, err
+	}
+	if dErr, _ := resp.(error); dErr == errDeletionInProgress {
+		return nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+			"the deletion of experiment %v is in progress", expID))
+	} else if dErr != nil {
+		return nil, dErr
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/experiments/%v", expID))
+	return api.StatusResponse{Code: http.StatusAccepted}, nil
+}
+
 func (m *Master) postExperimentKill(c echo.Context) (interface{}, error) {
 	args := struct {
 		ExperimentID int `path:"experiment_id"`
@@ -515,11 +1324,294 @@ func (m *Master) postExperimentKill(c echo.Context) (interface{}, error) {
 
 	resp := m.system.AskAt(actor.Addr("experiments", args.ExperimentID), killExperiment{})
 	if resp.Source() == nil {
+		if canceled, err := m.cancelQueuedExperiment(args.ExperimentID); err != nil {
+			return nil, err
+		} else if canceled {
+			return nil, nil
+		}
 		return nil, echo.NewHTTPError(http.StatusNotFound,
 			fmt.Sprintf("active experiment not found: %d", args.ExperimentID))
 	}
-	if _, notTimedOut := resp.GetOrTimeout(defaultAskTimeout); !notTimedOut {
-		return nil, errors.Errorf("attempt to kill experiment timed out")
+	if _, err := m.awaitResponse(resp, 0); err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
+
+// postExperimentRestore retries restoring an experiment that was marked as errored because it
+// could not be restored, e.g., due to a transient failure while the master was starting up.
+func (m *Master) postExperimentRestore(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+
+	dbExp, err := m.db.ExperimentWithoutConfigByID(args.ExperimentID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkExperimentNotImported(dbExp); err != nil {
+		return nil, err
+	}
+	if dbExp.State != model.ErrorState {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"cannot restore experiment %d in state %v: only errored experiments can be restored",
+			args.ExperimentID, dbExp.State))
+	}
+	if m.system.Get(actor.Addr("experiments", args.ExperimentID)) != nil {
+		return nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+			"experiment %d is still running", args.ExperimentID))
+	}
+
+	dbExp, err = m.db.ExperimentByID(args.ExperimentID)
+	if err != nil || reflect.DeepEqual(dbExp.Config, model.ExperimentConfig{}) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"cannot restore experiment %d: its config cannot be parsed", args.ExperimentID))
+	}
+
+	if err = m.db.ReopenErroredExperiment(args.ExperimentID); err != nil {
+		return nil, echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	dbExp.State = model.ActiveState
+	recordTransitionWithoutActor(m.db, args.ExperimentID, model.ErrorState, dbExp.State,
+		"restored through the API")
+	dbExp.EndTime = nil
+
+	if err = m.restoreExperiment(dbExp); err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf(
+			"failed to restore experiment %d: %s", args.ExperimentID, err))
+	}
+	return nil, nil
+}
+
+// minDatapoints is the smallest number of points that LTTB can downsample a series to, since it
+// always keeps the first and last points.
+const minDatapoints = 3
+
+// metricSeries is a metric series in a list of points of an experiment summary: each is named by
+// the prefix and the name of the metric, and the metrics are found by following the path from a
+// point.
+type metricSeries struct {
+	prefix string
+	path   []string
+}
+
+// stepSeries are the metric series in the steps of the trials of an experiment summary, whose
+// batches are the sum of the prior and the new batches of a step.
+var (
+	stepSeries = []metricSeries{
+		{"training.", []string{"metrics", "avg_metrics"}},
+		{"validation.", []string{"validation", "metrics", "validation_metrics"}},
+	}
+	stepBatchKeys = []string{"prior_batches_processed", "num_batches"}
+)
+
+// reducedSeries are the metric series in the metrics of an experiment summary reduced across
+// trials.
+var (
+	reducedSeries = []metricSeries{
+		{"training.", []string{"metrics"}},
+		{"validation.", []string{"validation_metrics"}},
+	}
+	reducedBatchKeys = []string{"batches"}
+)
+
+// downsampleSummaryMetrics thins out the steps of each trial in an experiment summary, and the
+// metrics reduced across trials, so that every training and validation metric series has at most
+// maxDatapoints points.
+func downsampleSummaryMetrics(summary []byte, maxDatapoints int) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(summary))
+	decoder.UseNumber()
+	var experiment map[string]interface{}
+	if err := decoder.Decode(&experiment); err != nil {
+		return nil, errors.Wrap(err, "failed to parse experiment summary")
+	}
+
+	trials, _ := experiment["trials"].([]interface{})
+	for _, t := range trials {
+		trial, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if steps, ok := trial["steps"].([]interface{}); ok && len(steps) > maxDatapoints {
+			trial["steps"] = downsamplePoints(steps, maxDatapoints, stepBatchKeys, stepSeries)
+		}
+	}
+	if reduced, ok := experiment["reduced_metrics"].([]interface{}); ok &&
+		len(reduced) > maxDatapoints {
+		experiment["reduced_metrics"] = downsamplePoints(
+			reduced, maxDatapoints, reducedBatchKeys, reducedSeries)
+	}
+
+	return json.Marshal(experiment)
+}
+
+// downsamplePoints thins out a list of points, each of which has metrics at the number of batches
+// given by the sum of its batch keys. Each series is downsampled with LTTB to preserve its shape.
+// A point is kept if any series selected it, and the values of the point that their series did
+// not select are dropped from it.
+func downsamplePoints(
+	points []interface{}, maxDatapoints int, batchKeys []string, metricSeries []metricSeries,
+) []interface{} {
+	series := make(map[string][]lttb.Point)
+	for _, p := range points {
+		point, _ := p.(map[string]interface{})
+		batches := pointBatches(point, batchKeys)
+		for _, ms := range metricSeries {
+			addSeriesPoints(series, ms.prefix, batches, point, ms.path...)
+		}
+	}
+
+	selected := make(map[string]map[float64]bool, len(series))
+	keep := make(map[float64]bool)
+	for name, seriesPoints := range series {
+		selected[name] = make(map[float64]bool)
+		for _, point := range lttb.Downsample(seriesPoints, maxDatapoints) {
+			selected[name][point.X] = true
+			keep[point.X] = true
+		}
+	}
+	sampled := make([]interface{}, 0, len(keep))
+	for _, p := range points {
+		point, _ := p.(map[string]interface{})
+		batches := pointBatches(point, batchKeys)
+		if !keep[batches] {
+			continue
+		}
+		for _, ms := range metricSeries {
+			metrics := metricsAt(point, ms.path...)
+			for name := range metrics {
+				if selectedPoints, ok := selected[ms.prefix+name]; ok && !selectedPoints[batches] {
+					delete(metrics, name)
+				}
+			}
+		}
+		sampled = append(sampled, point)
+		delete(keep, batches)
+	}
+	return sampled
+}
+
+// pointBatches returns the number of batches of a point in a metric series, which is the sum of
+// its batch keys; for a step, it is the total number of batches a trial had processed by its end.
+func pointBatches(point map[string]interface{}, batchKeys []string) float64 {
+	var batches float64
+	for _, key := range batchKeys {
+		if number, ok := point[key].(json.Number); ok {
+			value, _ := number.Float64()
+			batches += value
+		}
+	}
+	return batches
+}
+
+// metricsAt returns the metrics found by following path from obj, or nil if there are none.
+func metricsAt(obj interface{}, path ...string) map[string]interface{} {
+	for _, key := range path {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		obj = m[key]
+	}
+	metrics, _ := obj.(map[string]interface{})
+	return metrics
+}
+
+// addSeriesPoints appends the numeric metrics found by following path from obj to the series
+// with the same names, prefixed by prefix.
+func addSeriesPoints(
+	series map[string][]lttb.Point, prefix string, x float64, obj interface{}, path ...string,
+) {
+	for name, value := range metricsAt(obj, path...) {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		y, err := number.Float64()
+		if err != nil {
+			continue
+		}
+		series[prefix+name] = append(series[prefix+name], lttb.Point{X: x, Y: y})
+	}
+}
+
+// parseMetricNames returns the exact names of the metrics given by the repeated metric query
+// parameter. Names are matched verbatim, so clients percent-encode them like any other query
+// parameter value; in particular, a + in a name must be sent as %2B rather than as is.
+func parseMetricNames(c echo.Context) ([]string, error) {
+	names := c.QueryParams()["metric"]
+	for _, name := range names {
+		if name == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "metric must not be empty")
+		}
+	}
+	return names, nil
+}
+
+// filterMetrics keeps only the named metrics in the steps of a trial, or of each trial of an
+// experiment, and in the metrics of an experiment reduced across trials: their training, batch
+// and validation metrics.
+func filterMetrics(raw []byte, names []string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var parsed map[string]interface{}
+	if err := decoder.Decode(&parsed); err != nil {
+		return nil, errors.Wrap(err, "failed to parse metrics")
+	}
+
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+	owners := []interface{}{parsed}
+	if trials, ok := parsed["trials"].([]interface{}); ok {
+		owners = trials
+	}
+	reduced, _ := parsed["reduced_metrics"].([]interface{})
+	for _, point := range reduced {
+		for _, ms := range reducedSeries {
+			keepMetrics(keep, point, ms.path...)
+		}
+	}
+	for _, owner := range owners {
+		o, _ := owner.(map[string]interface{})
+		steps, _ := o["steps"].([]interface{})
+		for _, step := range steps {
+			for _, ms := range stepSeries {
+				keepMetrics(keep, step, ms.path...)
+			}
+			s, _ := step.(map[string]interface{})
+			m, _ := s["metrics"].(map[string]interface{})
+			batches, _ := m["batch_metrics"].([]interface{})
+			for _, batch := range batches {
+				keepMetrics(keep, batch)
+			}
+		}
+	}
+
+	return json.Marshal(parsed)
+}
+
+// keepMetrics deletes the metrics found by following path from obj whose names are not kept.
+func keepMetrics(keep map[string]bool, obj interface{}, path ...string) {
+	metrics := metricsAt(obj, path...)
+	for name := range metrics {
+		if !keep[name] {
+			delete(metrics, name)
+		}
+	}
+}
+
+// checkKnownExperimentFields returns an error naming any fields of the experiment configuration
+// that are not recognized, including ones nested in fields with custom parsing, which decoding
+// with yaml.DisallowUnknownFields does not catch.
+func checkKnownExperimentFields(configBytes []byte) error {
+	js, err := yaml.YAMLToJSON(configBytes)
+	if err != nil {
+		return err
+	}
+	return check.KnownFields(js, &model.ExperimentConfig{})
+}
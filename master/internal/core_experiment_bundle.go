@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+// bundleFile is a file of an experiment bundle whose contents are known up front.
+type bundleFile struct {
+	name     string
+	contents []byte
+}
+
+// getExperimentBundle streams a zip archive with everything about an experiment that users share:
+// its configuration, trials, checkpoints and metrics.
+func (m *Master) getExperimentBundle(c echo.Context) error {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return err
+	}
+
+	// Load everything but the metrics before responding, so that errors get a proper response.
+	files, err := m.experimentBundleFiles(args.ExperimentID)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="exp%d_bundle.zip"`, args.ExperimentID))
+	c.Response().WriteHeader(http.StatusOK)
+	return writeExperimentBundle(c.Response(), files,
+		func(f func(db.ExperimentMetric) error) error {
+			return m.db.ForEachExperimentMetric(args.ExperimentID, f)
+		})
+}
+
+func (m *Master) experimentBundleFiles(experimentID int) ([]bundleFile, error) {
+	config, err := redactedRaw(m.db.ExperimentConfigRaw(experimentID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading config of experiment %d", experimentID)
+	}
+	if config, err = yaml.JSONToYAML(config); err != nil {
+		return nil, errors.Wrapf(err, "converting config of experiment %d", experimentID)
+	}
+
+	summary, err := redactedRaw(m.db.ExperimentWithTrialSummariesRaw(experimentID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading trials of experiment %d", experimentID)
+	}
+	trials, err := indentedField(summary, "trials")
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading trials of experiment %d", experimentID)
+	}
+
+	checkpoints, err := m.db.ExperimentCheckpointsRaw(experimentID, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading checkpoints of experiment %d", experimentID)
+	}
+	if checkpoints, err = indentedField(checkpoints, "checkpoints"); err != nil {
+		return nil, errors.Wrapf(err, "reading checkpoints of experiment %d", experimentID)
+	}
+
+	return []bundleFile{
+		{name: "config.yaml", contents: config},
+		{name: "trials.json", contents: trials},
+		{name: "checkpoints.json", contents: checkpoints},
+	}, nil
+}
+
+// indentedField returns the value of a field of a JSON object, indented for readability.
+func indentedField(object []byte, field string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(object, &fields); err != nil {
+		return nil, err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, fields[field], "", "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+// writeExperimentBundle writes a zip archive of the files followed by metrics.csv, which lists the
+// metrics given by forEachMetric. The metrics are written as they are read, so that large
+// experiments are never held in memory.
+func writeExperimentBundle(
+	w io.Writer,
+	files []bundleFile,
+	forEachMetric func(func(db.ExperimentMetric) error) error,
+) error {
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err = fw.Write(file.contents); err != nil {
+			return err
+		}
+	}
+
+	fw, err := zw.Create("metrics.csv")
+	if err != nil {
+		return err
+	}
+	metrics := csv.NewWriter(fw)
+	if err = metrics.Write([]string{"trial_id", "batches", "kind", "name", "value"}); err != nil {
+		return err
+	}
+	if err = forEachMetric(func(metric db.ExperimentMetric) error {
+		return metrics.Write([]string{
+			strconv.Itoa(metric.TrialID),
+			strconv.Itoa(metric.Batches),
+			metric.Kind,
+			metric.Name,
+			metric.Value,
+		})
+	}); err != nil {
+		return err
+	}
+	if metrics.Flush(); metrics.Error() != nil {
+		return metrics.Error()
+	}
+	return zw.Close()
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+func TestWriteExperimentBundle(t *testing.T) {
+	files := []bundleFile{
+		{name: "config.yaml", contents: []byte("description: test\n")},
+		{name: "trials.json", contents: []byte("[]")},
+	}
+	metrics := []db.ExperimentMetric{
+		{TrialID: 1, Batches: 100, Kind: "training", Name: "loss", Value: "0.5"},
+		{TrialID: 1, Batches: 100, Kind: "validation", Name: "accuracy, top 5", Value: "0.9"},
+	}
+
+	forEachMetric := func(f func(db.ExperimentMetric) error) error {
+		for _, metric := range metrics {
+			if err := f(metric); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	assert.NilError(t, writeExperimentBundle(&buf, files, forEachMetric))
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NilError(t, err)
+	contents := map[string]string{}
+	var names []string
+	for _, file := range r.File {
+		rc, err := file.Open()
+		assert.NilError(t, err)
+		bs, err := ioutil.ReadAll(rc)
+		assert.NilError(t, err)
+		names = append(names, file.Name)
+		contents[file.Name] = string(bs)
+	}
+	assert.DeepEqual(t, names, []string{"config.yaml", "trials.json", "metrics.csv"})
+	assert.Equal(t, contents["config.yaml"], "description: test\n")
+	assert.Equal(t, contents["metrics.csv"], "trial_id,batches,kind,name,value\n"+
+		"1,100,training,loss,0.5\n"+
+		"1,100,validation,\"accuracy, top 5\",0.9\n")
+}
@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/lttb"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// maxComparedExperiments is the largest number of experiments that can be compared at once.
+const maxComparedExperiments = 50
+
+// compareRequest is the body of the experiment comparison endpoint.
+type compareRequest struct {
+	ExperimentIDs []int    `json:"experiment_ids"`
+	Metrics       []string `json:"metrics"`
+	// MetricType is "validation" or "training"; it defaults to "validation".
+	MetricType    string `json:"metric_type"`
+	MaxDatapoints *int   `json:"max_datapoints"`
+	// Orders maps metrics to "asc" if smaller values are better or "desc" if larger values are.
+	// Metrics that are the searcher metric of an experiment default to the order of its searcher.
+	Orders map[string]string `json:"orders"`
+}
+
+// comparedPoint is the value of a metric after a trial had processed the given number of batches.
+type comparedPoint struct {
+	Batches int     `json:"batches"`
+	Value   float64 `json:"value"`
+}
+
+// comparedMetric is the curve of a metric of the best trial of an experiment and its summary
+// statistics, which are computed before the curve is downsampled. Every field is null if the
+// trial did not report the metric.
+type comparedMetric struct {
+	Points []comparedPoint `json:"points"`
+	Final  *float64        `json:"final"`
+	Best   *float64        `json:"best"`
+	// AUC is the area under the curve over batches, by the trapezoidal rule.
+	AUC *float64 `json:"auc"`
+}
+
+// comparedExperiment holds the curves of the best trial of an experiment by its searcher metric.
+// TrialID is null if no trial has reported the searcher metric.
+type comparedExperiment struct {
+	ExperimentID int                       `json:"experiment_id"`
+	TrialID      *int                      `json:"trial_id"`
+	Metrics      map[string]comparedMetric `json:"metrics"`
+}
+
+func (r *compareRequest) validate() error {
+	switch r.MetricType {
+	case "":
+		r.MetricType = "validation"
+	case "validation", "training":
+	default:
+		return errors.New("metric_type must be validation or training")
+	}
+	switch {
+	case len(r.ExperimentIDs) == 0:
+		return errors.New("experiment_ids must not be empty")
+	case len(r.ExperimentIDs) > maxComparedExperiments:
+		return errors.Errorf("at most %d experiments can be compared", maxComparedExperiments)
+	case len(r.Metrics) == 0:
+		return errors.New("metrics must not be empty")
+	case r.MaxDatapoints != nil && *r.MaxDatapoints < minDatapoints:
+		return errors.Errorf("max_datapoints must be at least %d", minDatapoints)
+	}
+	seenIDs := make(map[int]bool, len(r.ExperimentIDs))
+	for _, id := range r.ExperimentIDs {
+		if seenIDs[id] {
+			return errors.Errorf("experiment_ids lists experiment %d more than once", id)
+		}
+		seenIDs[id] = true
+	}
+	seenMetrics := make(map[string]bool, len(r.Metrics))
+	for _, metric := range r.Metrics {
+		if seenMetrics[metric] {
+			return errors.Errorf("metrics lists %s more than once", metric)
+		}
+		seenMetrics[metric] = true
+	}
+	for metric, order := range r.Orders {
+		if order != "asc" && order != "desc" {
+			return errors.Errorf("the order of %s must be asc or desc, not %q", metric, order)
+		}
+	}
+	return nil
+}
+
+// postExperimentsCompare returns the curves of the given metrics of the best trial of each of the
+// given experiments, aligned on batches, with one query per metric for all the experiments.
+func (m *Master) postExperimentsCompare(c echo.Context) (interface{}, error) {
+	var req compareRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("invalid comparison request: %s", err))
+	}
+	if err := req.validate(); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := m.checkExperimentsVisible(c, req.ExperimentIDs...); err != nil {
+		return nil, err
+	}
+
+	searchers := make([]model.SearcherConfig, len(req.ExperimentIDs))
+	experiments := make([]comparedExperiment, len(req.ExperimentIDs))
+	var trialIDs []int
+	one := 1
+	for i, id := range req.ExperimentIDs {
+		config, err := m.db.ExperimentConfig(id)
+		if errors.Cause(err) == db.ErrNotFound {
+			return nil, echo.NewHTTPError(
+				http.StatusNotFound, fmt.Sprintf("experiment %d not found", id))
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "loading config of experiment %d", id)
+		}
+		searchers[i] = config.Searcher
+		best, err := m.db.ExperimentLeaderboard(
+			id, config.Searcher.Metric, config.Searcher.SmallerIsBetter, &one)
+		if err != nil {
+			return nil, err
+		}
+		experiments[i] = comparedExperiment{
+			ExperimentID: id, Metrics: make(map[string]comparedMetric, len(req.Metrics)),
+		}
+		if len(best) > 0 {
+			experiments[i].TrialID = &best[0].TrialID
+			trialIDs = append(trialIDs, best[0].TrialID)
+		}
+	}
+
+	// Check that the order of every metric is known before loading the curves.
+	smallerIsBetter := make(map[string][]bool, len(req.Metrics))
+	for _, metric := range req.Metrics {
+		smallerIsBetter[metric] = make([]bool, len(experiments))
+		for i, searcher := range searchers {
+			var order *string
+			if o, ok := req.Orders[metric]; ok {
+				order = &o
+			} else if metric != searcher.Metric {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+					"orders must give the order of %s, which is not the searcher metric of "+
+						"experiment %d", metric, req.ExperimentIDs[i]))
+			}
+			better, err := leaderboardSmallerIsBetter(order, metric, searcher)
+			if err != nil {
+				return nil, err
+			}
+			smallerIsBetter[metric][i] = better
+		}
+	}
+
+	for _, metric := range req.Metrics {
+		series, err := m.db.TrialsMetricSeries(trialIDs, metric, req.MetricType == "validation")
+		if err != nil {
+			return nil, err
+		}
+		for i := range experiments {
+			var points []lttb.Point
+			if experiments[i].TrialID != nil {
+				points = series[*experiments[i].TrialID]
+			}
+			experiments[i].Metrics[metric] = compareCurve(
+				points, smallerIsBetter[metric][i], req.MaxDatapoints)
+		}
+	}
+
+	return struct {
+		MetricType  string               `json:"metric_type"`
+		Experiments []comparedExperiment `json:"experiments"`
+	}{req.MetricType, experiments}, nil
+}
+
+// compareCurve summarizes a metric series ordered by batches and downsamples it to at most
+// maxDatapoints points, if set.
+func compareCurve(points []lttb.Point, smallerIsBetter bool, maxDatapoints *int) comparedMetric {
+	if len(points) == 0 {
+		return comparedMetric{}
+	}
+	final, best, auc := points[len(points)-1].Y, points[0].Y, 0.0
+	for i, point := range points {
+		if (smallerIsBetter && point.Y < best) || (!smallerIsBetter && point.Y > best) {
+			best = point.Y
+		}
+		if i > 0 {
+			prev := points[i-1]
+			auc += (point.X - prev.X) * (point.Y + prev.Y) / 2
+		}
+	}
+
+	if maxDatapoints != nil {
+		points = lttb.Downsample(points, *maxDatapoints)
+	}
+	metric := comparedMetric{
+		Points: make([]comparedPoint, 0, len(points)),
+		Final:  &final,
+		Best:   &best,
+		AUC:    &auc,
+	}
+	for _, point := range points {
+		metric.Points = append(metric.Points, comparedPoint{Batches: int(point.X), Value: point.Y})
+	}
+	return metric
+}
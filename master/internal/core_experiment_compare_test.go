@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/lttb"
+)
+
+func TestCompareRequestValidate(t *testing.T) {
+	req := compareRequest{ExperimentIDs: []int{1, 2}, Metrics: []string{"loss"}}
+	assert.NilError(t, req.validate())
+	assert.Equal(t, req.MetricType, "validation")
+
+	two := 2
+	for _, req := range []compareRequest{
+		{Metrics: []string{"loss"}},
+		{ExperimentIDs: []int{1}},
+		{ExperimentIDs: []int{1, 1}, Metrics: []string{"loss"}},
+		{ExperimentIDs: []int{1}, Metrics: []string{"loss", "loss"}},
+		{ExperimentIDs: []int{1}, Metrics: []string{"loss"}, MetricType: "test"},
+		{ExperimentIDs: []int{1}, Metrics: []string{"loss"}, MaxDatapoints: &two},
+		{ExperimentIDs: make([]int, maxComparedExperiments+1), Metrics: []string{"loss"}},
+		{
+			ExperimentIDs: []int{1}, Metrics: []string{"loss"},
+			Orders: map[string]string{"loss": "best"},
+		},
+	} {
+		assert.Assert(t, req.validate() != nil, "%+v", req)
+	}
+}
+
+func TestCompareCurve(t *testing.T) {
+	points := []lttb.Point{{X: 100, Y: 4}, {X: 200, Y: 2}, {X: 300, Y: 3}, {X: 400, Y: 1}}
+
+	metric := compareCurve(points, true, nil)
+	assert.Equal(t, len(metric.Points), 4)
+	assert.Equal(t, metric.Points[1], comparedPoint{Batches: 200, Value: 2})
+	assert.Equal(t, *metric.Final, 1.0)
+	assert.Equal(t, *metric.Best, 1.0)
+	assert.Equal(t, *metric.AUC, 750.0)
+
+	// The statistics are computed before the curve is downsampled.
+	three := 3
+	metric = compareCurve(points, false, &three)
+	assert.Equal(t, len(metric.Points), 3)
+	assert.Equal(t, *metric.Best, 4.0)
+	assert.Equal(t, *metric.AUC, 750.0)
+
+	metric = compareCurve(nil, true, nil)
+	assert.Assert(t, metric.Points == nil && metric.Final == nil && metric.Best == nil &&
+		metric.AUC == nil)
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// parseExperimentDependencies checks the dependencies that an experiment was submitted with,
+// returning nil if it has none. An experiment can only depend on experiments that already exist,
+// and those cannot depend on it in turn, so dependencies never form a cycle.
+func (m *Master) parseExperimentDependencies(
+	params *CreateExperimentParams, dbExp *model.Experiment,
+) (*model.ExperimentDependencies, error) {
+	if len(params.DependsOn) == 0 {
+		if params.DependencyCondition != nil || params.WarmStartFromDependency {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"dependency_condition and warm_start_from_dependency require depends_on")
+		}
+		return nil, nil
+	}
+	if params.Schedule != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			"depends_on cannot be used with a schedule")
+	}
+
+	deps := &model.ExperimentDependencies{
+		DependsOn: params.DependsOn,
+		Condition: model.DependencyCondition{Type: model.DependenciesCompleted},
+		WarmStart: params.WarmStartFromDependency,
+	}
+	if params.DependencyCondition != nil {
+		deps.Condition = *params.DependencyCondition
+	}
+	if err := deps.Condition.Validate(); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			"invalid dependency_condition: "+err.Error())
+	}
+	if deps.WarmStart {
+		searcher := dbExp.Config.Searcher
+		switch {
+		case len(deps.DependsOn) != 1:
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"warm_start_from_dependency requires exactly one dependency")
+		case searcher.SourceTrialID != nil || searcher.SourceCheckpointUUID != nil:
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"warm_start_from_dependency cannot be used with searcher.source_trial_id or "+
+					"searcher.source_checkpoint_uuid")
+		}
+	}
+
+	states, err := m.db.ExperimentStates(deps.DependsOn)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool, len(deps.DependsOn))
+	for _, id := range deps.DependsOn {
+		if seen[id] {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("depends_on lists experiment %d more than once", id))
+		}
+		seen[id] = true
+		if _, ok := states[id]; !ok {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("dependency experiment %d not found", id))
+		}
+	}
+	return deps, nil
+}
+
+// postQueuedExperiment saves a validated experiment in the QUEUED_DEPENDENCY state, in which it
+// waits for the experiments that it depends on.
+func (m *Master) postQueuedExperiment(
+	c echo.Context, user model.User, dbExp *model.Experiment, deps *model.ExperimentDependencies,
+) (interface{}, error) {
+	dbExp.OwnerID = &user.ID
+	dbExp.State = model.QueuedDependencyState
+	if err := m.storeModelDefinition(dbExp); err != nil {
+		return nil, err
+	}
+	if err := m.db.AddQueuedExperiment(dbExp, deps); err == db.ErrDuplicateRecord {
+		return nil, duplicateExternalID(*dbExp.ExternalID)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "queueing experiment")
+	}
+	ids := make([]string, 0, len(deps.DependsOn))
+	for _, id := range deps.DependsOn {
+		ids = append(ids, fmt.Sprint(id))
+	}
+	if err := m.db.AddExperimentStateTransition(&model.ExperimentStateTransition{
+		ExperimentID: dbExp.ID,
+		To:           dbExp.State,
+		Timestamp:    dbExp.StartTime,
+		Reason: fmt.Sprintf("experiment created, waiting for experiments %s",
+			strings.Join(ids, ", ")),
+	}); err != nil {
+		return nil, err
+	}
+	m.experimentListCache.invalidate()
+
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/experiments/%v", dbExp.ID))
+	return api.StatusResponse{Code: http.StatusCreated, Body: model.ExperimentDescriptor{
+		ID:         dbExp.ID,
+		Archived:   dbExp.Archived,
+		Config:     dbExp.Config,
+		Labels:     make([]string, 0),
+		ExternalID: dbExp.ExternalID,
+	}}, nil
+}
+
+// transitionQueuedExperiment moves an experiment out of the QUEUED_DEPENDENCY state and records
+// why, returning false if it was no longer queued.
+func (m *Master) transitionQueuedExperiment(
+	id int, state model.State, reason string,
+) (bool, error) {
+	moved, err := m.db.TransitionQueuedExperiment(id, state)
+	if err != nil || !moved {
+		return false, err
+	}
+	m.experimentListCache.invalidate()
+	from := model.QueuedDependencyState
+	return true, m.db.AddExperimentStateTransition(&model.ExperimentStateTransition{
+		ExperimentID: id,
+		From:         &from,
+		To:           state,
+		Timestamp:    time.Now().UTC(),
+		Reason:       reason,
+	})
+}
+
+// cancelQueuedExperiment cancels an experiment that is waiting for its dependencies, returning
+// false if it was not waiting. Such experiments have no actor to kill.
+func (m *Master) cancelQueuedExperiment(id int) (bool, error) {
+	return m.transitionQueuedExperiment(
+		id, model.CanceledState, "experiment canceled while waiting for its dependencies")
+}
+
+func (m *Master) getExperimentDependencies(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+
+	response := struct {
+		DependsOn []int                      `json:"depends_on"`
+		Condition *model.DependencyCondition `json:"condition"`
+		WarmStart bool                       `json:"warm_start"`
+		// Dependents lists the experiments that depend on this one.
+		Dependents []int `json:"dependents"`
+	}{DependsOn: []int{}}
+	deps, err := m.db.ExperimentDependenciesByID(args.ExperimentID)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		if _, err = m.db.ExperimentWithoutConfigByID(args.ExperimentID); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		response.DependsOn = deps.DependsOn
+		response.Condition = &deps.Condition
+		response.WarmStart = deps.WarmStart
+	}
+	if response.Dependents, err = m.db.ExperimentDependents(args.ExperimentID); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
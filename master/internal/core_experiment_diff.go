@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+// configValue is a key that only one of two experiment configs has.
+type configValue struct {
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// configChange is a key whose value differs between two experiment configs.
+type configChange struct {
+	Path string      `json:"path"`
+	A    interface{} `json:"a"`
+	B    interface{} `json:"b"`
+}
+
+// configDiff describes how the config of experiment B differs from the config of experiment A.
+// Keys are paths of nested keys joined by dots; lists are compared as a whole.
+type configDiff struct {
+	A       int            `json:"a"`
+	B       int            `json:"b"`
+	Added   []configValue  `json:"added"`
+	Removed []configValue  `json:"removed"`
+	Changed []configChange `json:"changed"`
+}
+
+// getExperimentConfigDiff compares the configs of two experiments, as resolved when they were
+// submitted, i.e., with the defaults of the master filled in.
+func (m *Master) getExperimentConfigDiff(c echo.Context) (interface{}, error) {
+	args := struct {
+		A int `query:"a"`
+		B int `query:"b"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+
+	if err := m.checkExperimentsVisible(c, args.A, args.B); err != nil {
+		return nil, err
+	}
+
+	configs := make([]map[string]interface{}, 2)
+	for i, id := range []int{args.A, args.B} {
+		raw, err := redactedRaw(m.db.ExperimentConfigRaw(id))
+		if errors.Cause(err) == db.ErrNotFound {
+			return nil, echo.NewHTTPError(
+				echo.ErrNotFound.Code, fmt.Sprintf("experiment %d not found", id))
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "loading config of experiment %d", id)
+		}
+		if err := json.Unmarshal(raw, &configs[i]); err != nil {
+			return nil, errors.Wrapf(err, "parsing config of experiment %d", id)
+		}
+	}
+
+	diff := diffConfigs(configs[0], configs[1])
+	diff.A, diff.B = args.A, args.B
+	return diff, nil
+}
+
+// diffConfigs returns the keys that were added, removed or changed from config a to config b,
+// sorted by path.
+func diffConfigs(a, b map[string]interface{}) configDiff {
+	diff := configDiff{
+		Added:   []configValue{},
+		Removed: []configValue{},
+		Changed: []configChange{},
+	}
+	diffConfigObjects("", a, b, &diff)
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Path < diff.Added[j].Path })
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return diff.Removed[i].Path < diff.Removed[j].Path
+	})
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Path < diff.Changed[j].Path
+	})
+	return diff
+}
+
+func diffConfigObjects(prefix string, a, b map[string]interface{}, diff *configDiff) {
+	for key, aValue := range a {
+		path := prefix + key
+		bValue, ok := b[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, configValue{Path: path, Value: aValue})
+			continue
+		}
+		aObject, aIsObject := aValue.(map[string]interface{})
+		bObject, bIsObject := bValue.(map[string]interface{})
+		switch {
+		case aIsObject && bIsObject:
+			diffConfigObjects(path+".", aObject, bObject, diff)
+		case !reflect.DeepEqual(aValue, bValue):
+			diff.Changed = append(diff.Changed, configChange{Path: path, A: aValue, B: bValue})
+		}
+	}
+	for key, bValue := range b {
+		if _, ok := a[key]; !ok {
+			diff.Added = append(diff.Added, configValue{Path: prefix + key, Value: bValue})
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	parse := func(s string) map[string]interface{} {
+		var config map[string]interface{}
+		assert.NilError(t, json.Unmarshal([]byte(s), &config))
+		return config
+	}
+	a := parse(`{
+		"description": "baseline",
+		"hyperparameters": {"lr": {"type": "const", "val": 0.1}, "dropout": 0.5},
+		"bind_mounts": [{"host_path": "/data"}],
+		"max_restarts": 5
+	}`)
+	b := parse(`{
+		"description": "tuned",
+		"hyperparameters": {"lr": {"type": "const", "val": 0.01}, "momentum": 0.9},
+		"bind_mounts": [{"host_path": "/data"}, {"host_path": "/scratch"}],
+		"max_restarts": 5
+	}`)
+
+	diff := diffConfigs(a, b)
+	assert.DeepEqual(t, diff.Added, []configValue{{Path: "hyperparameters.momentum", Value: 0.9}})
+	assert.DeepEqual(t, diff.Removed, []configValue{{Path: "hyperparameters.dropout", Value: 0.5}})
+	assert.DeepEqual(t, diff.Changed, []configChange{
+		{
+			Path: "bind_mounts",
+			A:    []interface{}{map[string]interface{}{"host_path": "/data"}},
+			B: []interface{}{
+				map[string]interface{}{"host_path": "/data"},
+				map[string]interface{}{"host_path": "/scratch"},
+			},
+		},
+		{Path: "description", A: "baseline", B: "tuned"},
+		{Path: "hyperparameters.lr.val", A: 0.1, B: 0.01},
+	})
+
+	same := diffConfigs(a, a)
+	assert.Equal(t, len(same.Added)+len(same.Removed)+len(same.Changed), 0)
+}
@@ -0,0 +1,578 @@
+package internal
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/version"
+)
+
+// experimentArchiveFormatVersion is the version of the format of experiment archives. Raise it
+// whenever archives change in a way that masters reading the previous format would misread.
+const experimentArchiveFormatVersion = 1
+
+// minExperimentArchiveMasterVersion is the oldest master version whose archives can be imported.
+const minExperimentArchiveMasterVersion = "0.13.0"
+
+// Files of an experiment archive. The manifest comes first, so that incompatible archives are
+// rejected before the rest is read, and is followed by one file per trial.
+const (
+	experimentArchiveManifest        = "manifest.json"
+	experimentArchiveExperiment      = "experiment.json"
+	experimentArchiveModelDefinition = "model_definition.tar.gz"
+	experimentArchiveTrialsDir       = "trials"
+)
+
+// experimentManifest describes where an experiment archive comes from.
+type experimentManifest struct {
+	FormatVersion int       `json:"format_version"`
+	MasterVersion string    `json:"master_version"`
+	ClusterID     string    `json:"cluster_id"`
+	ExperimentID  int       `json:"experiment_id"`
+	ExportedAt    time.Time `json:"exported_at"`
+	// Checkpoints is whether the trials of the archive list their checkpoints.
+	Checkpoints bool `json:"checkpoints"`
+}
+
+// archivedExperiment is an experiment in an archive, without its model definition and trials.
+type archivedExperiment struct {
+	Config        json.RawMessage `json:"config"`
+	State         model.State     `json:"state"`
+	StartTime     time.Time       `json:"start_time"`
+	EndTime       *time.Time      `json:"end_time"`
+	Archived      bool            `json:"archived"`
+	GitRemote     *string         `json:"git_remote"`
+	GitCommit     *string         `json:"git_commit"`
+	GitCommitter  *string         `json:"git_committer"`
+	GitCommitDate *time.Time      `json:"git_commit_date"`
+}
+
+// archivedTrial is a trial in an archive with its metrics and, optionally, its checkpoints. The
+// checkpoints only reference checkpoint storage that the exporting cluster used.
+type archivedTrial struct {
+	ID          int                `json:"id"`
+	State       model.State        `json:"state"`
+	StartTime   time.Time          `json:"start_time"`
+	EndTime     *time.Time         `json:"end_time"`
+	HParams     model.JSONObj      `json:"hparams"`
+	Seed        int64              `json:"seed"`
+	Steps       []archivedStep     `json:"steps"`
+	Validations []model.Validation `json:"validations"`
+	Checkpoints []model.Checkpoint `json:"checkpoints,omitempty"`
+}
+
+// archivedStep is a step of a trial in an archive.
+type archivedStep struct {
+	ID                    int           `json:"id"`
+	State                 model.State   `json:"state"`
+	StartTime             time.Time     `json:"start_time"`
+	EndTime               *time.Time    `json:"end_time"`
+	NumBatches            int           `json:"num_batches"`
+	PriorBatchesProcessed int           `json:"prior_batches_processed"`
+	Metrics               model.JSONObj `json:"metrics"`
+}
+
+// experimentArchive is the contents of an experiment archive other than its trials, which are
+// read one at a time from an archivedTrialReader.
+type experimentArchive struct {
+	Manifest        experimentManifest
+	Experiment      archivedExperiment
+	ModelDefinition []byte
+}
+
+// archivedTrialReader reads the trials of an experiment archive as they are received.
+type archivedTrialReader struct {
+	tr *tar.Reader
+}
+
+// getExperimentExport streams a .tar.gz archive of an experiment that another cluster can import:
+// its config, model definition, trials with their hyperparameters and metrics and, if requested,
+// references to its checkpoints.
+func (m *Master) getExperimentExport(c echo.Context) error {
+	args := struct {
+		ExperimentID int   `path:"experiment_id"`
+		Checkpoints  *bool `query:"checkpoints"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return err
+	}
+
+	// Load the experiment before responding, so that errors get a proper response. The config is
+	// exported as it is stored, rather than as this master parses it.
+	exp, err := m.db.ExperimentWithoutConfigByID(args.ExperimentID)
+	if err != nil {
+		return errors.Wrapf(err, "loading experiment %d", args.ExperimentID)
+	}
+	config, err := m.db.ExperimentConfigRaw(exp.ID)
+	if err != nil {
+		return errors.Wrapf(err, "loading config of experiment %d", exp.ID)
+	}
+	if exp.Imported() {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"experiment %d was imported from cluster %s; export it from that cluster instead",
+			exp.ID, *exp.ImportedFromClusterID))
+	}
+	if err = m.loadModelDefinition(exp); err != nil {
+		return err
+	}
+	trials, err := m.db.ExperimentTrials(exp.ID)
+	if err != nil {
+		return err
+	}
+
+	manifest := experimentManifest{
+		FormatVersion: experimentArchiveFormatVersion,
+		MasterVersion: version.Version,
+		ClusterID:     m.ClusterID,
+		ExperimentID:  exp.ID,
+		ExportedAt:    time.Now().UTC(),
+		Checkpoints:   args.Checkpoints != nil && *args.Checkpoints,
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/gzip")
+	c.Response().Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="exp%d_export.tar.gz"`, exp.ID))
+	c.Response().WriteHeader(http.StatusOK)
+	return writeExperimentArchive(c.Response(), manifest, archiveExperiment(exp, config),
+		exp.ModelDefinitionBytes, func(f func(archivedTrial) error) error {
+			for _, trial := range trials {
+				archived, err := m.archiveTrial(trial, manifest.Checkpoints)
+				if err != nil {
+					return err
+				}
+				if err = f(archived); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func archiveExperiment(exp *model.Experiment, config []byte) archivedExperiment {
+	return archivedExperiment{
+		Config:        config,
+		State:         exp.State,
+		StartTime:     exp.StartTime,
+		EndTime:       exp.EndTime,
+		Archived:      exp.Archived,
+		GitRemote:     exp.GitRemote,
+		GitCommit:     exp.GitCommit,
+		GitCommitter:  exp.GitCommitter,
+		GitCommitDate: exp.GitCommitDate,
+	}
+}
+
+func (m *Master) archiveTrial(trial model.Trial, checkpoints bool) (archivedTrial, error) {
+	archived := archivedTrial{
+		ID:        trial.ID,
+		State:     trial.State,
+		StartTime: trial.StartTime,
+		EndTime:   trial.EndTime,
+		HParams:   trial.HParams,
+		Seed:      trial.Seed,
+	}
+	steps, err := m.db.TrialSteps(trial.ID)
+	if err != nil {
+		return archived, err
+	}
+	for _, step := range steps {
+		archived.Steps = append(archived.Steps, archivedStep{
+			ID:                    step.ID,
+			State:                 step.State,
+			StartTime:             step.StartTime,
+			EndTime:               step.EndTime,
+			NumBatches:            step.NumBatches,
+			PriorBatchesProcessed: step.PriorBatchesProcessed,
+			Metrics:               step.Metrics,
+		})
+	}
+	if archived.Validations, err = m.db.TrialValidations(trial.ID); err != nil {
+		return archived, err
+	}
+	if checkpoints {
+		if archived.Checkpoints, err = m.db.TrialCheckpoints(trial.ID); err != nil {
+			return archived, err
+		}
+	}
+	return archived, nil
+}
+
+// writeExperimentArchive writes a .tar.gz experiment archive. The trials given by forEachTrial are
+// written as they are loaded, so that only one trial of a large experiment is held in memory.
+func writeExperimentArchive(
+	w io.Writer,
+	manifest experimentManifest,
+	experiment archivedExperiment,
+	modelDefinition []byte,
+	forEachTrial func(func(archivedTrial) error) error,
+) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	writeFile := func(name string, contents []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(contents)),
+			ModTime: manifest.ExportedAt,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(contents)
+		return err
+	}
+	writeJSON := func(name string, v interface{}) error {
+		contents, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "encoding %s", name)
+		}
+		return writeFile(name, contents)
+	}
+
+	if err := writeJSON(experimentArchiveManifest, manifest); err != nil {
+		return err
+	}
+	if err := writeJSON(experimentArchiveExperiment, experiment); err != nil {
+		return err
+	}
+	if err := writeFile(experimentArchiveModelDefinition, modelDefinition); err != nil {
+		return err
+	}
+	if err := forEachTrial(func(trial archivedTrial) error {
+		name := path.Join(experimentArchiveTrialsDir, fmt.Sprintf("%d.json", trial.ID))
+		return writeJSON(name, trial)
+	}); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// checkExperimentArchiveCompatible returns an error that explains why a master of the given version
+// cannot import an archive, if it cannot.
+func checkExperimentArchiveCompatible(manifest experimentManifest, masterVersion string) error {
+	if manifest.FormatVersion != experimentArchiveFormatVersion {
+		return errors.Errorf(
+			"the archive has format version %d, but this master (version %s) only imports format "+
+				"version %d", manifest.FormatVersion, masterVersion, experimentArchiveFormatVersion)
+	}
+	if manifest.MasterVersion == masterVersion {
+		return nil
+	}
+	incompatible := errors.Errorf(
+		"the archive was exported by master version %s, which is incompatible with this master "+
+			"(version %s)", manifest.MasterVersion, masterVersion)
+	newer, err := version.Compare(manifest.MasterVersion, masterVersion)
+	if err != nil {
+		return incompatible
+	}
+	if newer > 0 {
+		return errors.Errorf(
+			"the archive was exported by master version %s, which is newer than this master "+
+				"(version %s); upgrade this master to import it", manifest.MasterVersion, masterVersion)
+	}
+	older, _ := version.Compare(manifest.MasterVersion, minExperimentArchiveMasterVersion)
+	if older < 0 {
+		return errors.Errorf(
+			"the archive was exported by master version %s, but only archives of master version %s "+
+				"and later can be imported", manifest.MasterVersion, minExperimentArchiveMasterVersion)
+	}
+	return nil
+}
+
+// readExperimentArchive reads a .tar.gz experiment archive up to its trials, checking that a
+// master of the given version can import it before reading past the manifest. The experiment and
+// its model definition must come before the trials, as they do in the archives that masters write.
+// The model definition may be at most modelDefinitionLimit bytes.
+func readExperimentArchive(
+	r io.Reader, masterVersion string, modelDefinitionLimit int64,
+) (*experimentArchive, *archivedTrialReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "the archive is not a .tar.gz file")
+	}
+	tr := tar.NewReader(gz)
+
+	var archive experimentArchive
+	var sawManifest, sawExperiment bool
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, errors.Wrap(err, "reading the archive")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if !sawManifest && name != experimentArchiveManifest {
+			return nil, nil, errors.Errorf(
+				"the archive must start with %s", experimentArchiveManifest)
+		}
+		switch {
+		case name == experimentArchiveManifest:
+			if err = json.NewDecoder(tr).Decode(&archive.Manifest); err != nil {
+				return nil, nil, errors.Wrapf(err, "reading %s", name)
+			}
+			if err = checkExperimentArchiveCompatible(archive.Manifest, masterVersion); err != nil {
+				return nil, nil, err
+			}
+			sawManifest = true
+		case name == experimentArchiveExperiment:
+			if err = json.NewDecoder(tr).Decode(&archive.Experiment); err != nil {
+				return nil, nil, errors.Wrapf(err, "reading %s", name)
+			}
+			sawExperiment = true
+		case name == experimentArchiveModelDefinition:
+			if !sawExperiment {
+				return nil, nil, errors.Errorf("the archive must have %s before %s",
+					experimentArchiveExperiment, experimentArchiveModelDefinition)
+			}
+			if header.Size > modelDefinitionLimit {
+				return nil, nil, modelDefinitionTooLarge(modelDefinitionLimit)
+			}
+			if archive.ModelDefinition, err = ioutil.ReadAll(tr); err != nil {
+				return nil, nil, errors.Wrapf(err, "reading %s", name)
+			}
+			return &archive, &archivedTrialReader{tr: tr}, nil
+		case path.Dir(name) == experimentArchiveTrialsDir:
+			return nil, nil, errors.Errorf(
+				"the archive must have %s before its trials", experimentArchiveModelDefinition)
+		}
+	}
+
+	if !sawManifest {
+		return nil, nil, errors.Errorf("the archive has no %s", experimentArchiveManifest)
+	} else if !sawExperiment {
+		return nil, nil, errors.Errorf("the archive has no %s", experimentArchiveExperiment)
+	}
+	return nil, nil, errors.Errorf("the archive has no %s", experimentArchiveModelDefinition)
+}
+
+// next returns the next trial of the archive, or io.EOF once all of them have been read.
+func (r *archivedTrialReader) next() (*archivedTrial, error) {
+	for {
+		header, err := r.tr.Next()
+		if err == io.EOF {
+			return nil, err
+		} else if err != nil {
+			return nil, errors.Wrap(err, "reading the archive")
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || path.Dir(name) != experimentArchiveTrialsDir ||
+			!strings.HasSuffix(name, ".json") {
+			continue
+		}
+		var trial archivedTrial
+		if err = json.NewDecoder(r.tr).Decode(&trial); err != nil {
+			return nil, errors.Wrapf(err, "reading %s", name)
+		}
+		return &trial, nil
+	}
+}
+
+// importedState returns the terminal state that an imported experiment or trial is recreated in.
+// Imported experiments never run, so experiments that were still running are canceled.
+func importedState(state model.State) model.State {
+	if model.TerminalStates[state] {
+		return state
+	}
+	if terminal, ok := model.StoppingToTerminalStates[state]; ok {
+		return terminal
+	}
+	return model.CanceledState
+}
+
+// importedOperationState returns the state that an imported step, validation or checkpoint is
+// recreated in; ones that were still in progress will never finish.
+func importedOperationState(state model.State) model.State {
+	if state == model.ActiveState {
+		return model.ErrorState
+	}
+	return state
+}
+
+// config parses the config of the archived experiment, returning an error if this master cannot.
+func (a *experimentArchive) config() (model.ExperimentConfig, error) {
+	config := model.DefaultExperimentConfig(nil)
+	if err := json.Unmarshal(a.Experiment.Config, &config); err != nil {
+		return config, errors.Wrap(err, "parsing the config of the experiment")
+	}
+	if err := check.Validate(config); err != nil {
+		return config, errors.Wrap(err, "validating the config of the experiment")
+	}
+	return config, nil
+}
+
+// endTime returns the time that the archived experiment, and those of its trials that did not,
+// ended.
+func (a *experimentArchive) endTime() *time.Time {
+	if a.Experiment.EndTime == nil {
+		return &a.Manifest.ExportedAt
+	}
+	return a.Experiment.EndTime
+}
+
+// toImport returns the row that recreates the archived experiment, with its parsed config, for an
+// owner. The experiment is in a terminal state, keeps its original timestamps, and records the
+// cluster it came from.
+func (a *experimentArchive) toImport(
+	config model.ExperimentConfig, ownerID model.UserID,
+) *model.Experiment {
+	clusterID, experimentID := a.Manifest.ClusterID, a.Manifest.ExperimentID
+	return &model.Experiment{
+		State:                    importedState(a.Experiment.State),
+		Config:                   config,
+		ModelDefinitionBytes:     a.ModelDefinition,
+		StartTime:                a.Experiment.StartTime,
+		EndTime:                  a.endTime(),
+		Archived:                 a.Experiment.Archived,
+		GitRemote:                a.Experiment.GitRemote,
+		GitCommit:                a.Experiment.GitCommit,
+		GitCommitter:             a.Experiment.GitCommitter,
+		GitCommitDate:            a.Experiment.GitCommitDate,
+		OwnerID:                  &ownerID,
+		ImportedFromClusterID:    &clusterID,
+		ImportedFromExperimentID: &experimentID,
+	}
+}
+
+// trialToImport returns the rows that recreate a trial of the archived experiment, in terminal
+// states.
+func (a *experimentArchive) trialToImport(archived archivedTrial) db.ImportedTrial {
+	trial := db.ImportedTrial{
+		Trial: model.Trial{
+			State:     importedState(archived.State),
+			StartTime: archived.StartTime,
+			EndTime:   archived.EndTime,
+			HParams:   archived.HParams,
+			Seed:      archived.Seed,
+		},
+		Validations: archived.Validations,
+		Checkpoints: archived.Checkpoints,
+	}
+	if trial.Trial.EndTime == nil {
+		trial.Trial.EndTime = a.endTime()
+	}
+	for _, step := range archived.Steps {
+		trial.Steps = append(trial.Steps, model.Step{
+			ID:                    step.ID,
+			State:                 importedOperationState(step.State),
+			StartTime:             step.StartTime,
+			EndTime:               step.EndTime,
+			NumBatches:            step.NumBatches,
+			PriorBatchesProcessed: step.PriorBatchesProcessed,
+			Metrics:               step.Metrics,
+		})
+	}
+	for i := range trial.Validations {
+		trial.Validations[i].State = importedOperationState(trial.Validations[i].State)
+	}
+	for i := range trial.Checkpoints {
+		trial.Checkpoints[i].State = importedOperationState(trial.Checkpoints[i].State)
+	}
+	return trial
+}
+
+// postExperimentImport recreates an experiment from an archive exported by another cluster, which
+// is the body of the request. The experiment is owned by the importing user and is read-only: it
+// is never scheduled and cannot be changed, other than archiving it. Trials are added to the
+// database as they are read, so that only one of them is held in memory.
+func (m *Master) postExperimentImport(c echo.Context) (interface{}, error) {
+	user := c.(*context.DetContext).MustGetUser()
+
+	invalid := func(err error) error {
+		if _, ok := err.(*echo.HTTPError); ok {
+			return err
+		}
+		return echo.NewHTTPError(http.StatusBadRequest,
+			errors.Wrap(err, "invalid experiment archive").Error())
+	}
+	archive, trials, err := readExperimentArchive(
+		c.Request().Body, version.Version, m.currentConfig().MaxModelDefinitionBytes)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	if archive.Manifest.ClusterID == m.ClusterID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"the archive was exported by this cluster, from experiment %d",
+			archive.Manifest.ExperimentID))
+	}
+
+	config, err := archive.config()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	exp := archive.toImport(config, user.ID)
+	if err = m.storeModelDefinition(exp); err != nil {
+		return nil, err
+	}
+	imported, err := m.db.BeginExperimentImport(exp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "importing experiment %d of cluster %s",
+			archive.Manifest.ExperimentID, archive.Manifest.ClusterID)
+	}
+	defer imported.Rollback()
+	for {
+		archived, err := trials.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, invalid(err)
+		}
+		trial := archive.trialToImport(*archived)
+		if err = imported.AddTrial(&trial); err != nil {
+			return nil, errors.Wrapf(err, "importing trial %d of experiment %d of cluster %s",
+				archived.ID, archive.Manifest.ExperimentID, archive.Manifest.ClusterID)
+		}
+	}
+	if err = imported.Commit(); err != nil {
+		return nil, errors.Wrapf(err, "importing experiment %d of cluster %s",
+			archive.Manifest.ExperimentID, archive.Manifest.ClusterID)
+	}
+	m.experimentListCache.invalidate()
+
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/experiments/%v", exp.ID))
+	labels := make([]string, 0, len(exp.Config.Labels))
+	for label := range exp.Config.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return api.StatusResponse{Code: http.StatusCreated, Body: model.ExperimentDescriptor{
+		ID:       exp.ID,
+		Archived: exp.Archived,
+		Config:   exp.Config,
+		Labels:   labels,
+	}}, nil
+}
+
+// checkExperimentNotImported returns an error if the experiment was imported from another
+// cluster, since imported experiments are read-only.
+func checkExperimentNotImported(exp *model.Experiment) error {
+	if !exp.Imported() {
+		return nil
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+		"experiment %d was imported from cluster %s and is read-only",
+		exp.ID, *exp.ImportedFromClusterID))
+}
@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestExperimentArchiveRoundtrip(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	manifest := experimentManifest{
+		FormatVersion: experimentArchiveFormatVersion,
+		MasterVersion: "0.13.8",
+		ClusterID:     "dev",
+		ExperimentID:  7,
+		ExportedAt:    start.Add(time.Hour),
+	}
+	experiment := archivedExperiment{
+		Config:    json.RawMessage(`{"description": "exported"}`),
+		State:     model.ActiveState,
+		StartTime: start,
+	}
+	trials := []archivedTrial{
+		{
+			ID:        12,
+			State:     model.CompletedState,
+			StartTime: start,
+			HParams:   model.JSONObj{"lr": 0.1},
+			Steps: []archivedStep{{
+				ID: 1, State: model.CompletedState, StartTime: start, NumBatches: 100,
+				Metrics: model.JSONObj{"avg_metrics": map[string]interface{}{"loss": 0.5}},
+			}},
+		},
+		{
+			ID:          11,
+			State:       model.ActiveState,
+			StartTime:   start,
+			Steps:       []archivedStep{{ID: 1, State: model.ActiveState, StartTime: start}},
+			Checkpoints: []model.Checkpoint{{StepID: 1, State: model.ActiveState}},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NilError(t, writeExperimentArchive(&buf, manifest, experiment, []byte("model"),
+		func(f func(archivedTrial) error) error {
+			for _, trial := range trials {
+				if err := f(trial); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+
+	archive, trialReader, err := readExperimentArchive(&buf, "0.13.8", 1<<20)
+	assert.NilError(t, err)
+	assert.Equal(t, archive.Manifest.ClusterID, "dev")
+	var config map[string]string
+	assert.NilError(t, json.Unmarshal(archive.Experiment.Config, &config))
+	assert.Equal(t, config["description"], "exported")
+	assert.DeepEqual(t, archive.ModelDefinition, []byte("model"))
+
+	exp := archive.toImport(model.ExperimentConfig{}, model.UserID(3))
+	assert.Equal(t, exp.State, model.CanceledState)
+	assert.Equal(t, exp.StartTime, start)
+	assert.Equal(t, *exp.EndTime, manifest.ExportedAt)
+	assert.Equal(t, *exp.ImportedFromClusterID, "dev")
+	assert.Equal(t, *exp.ImportedFromExperimentID, 7)
+	assert.Equal(t, *exp.OwnerID, model.UserID(3))
+
+	// Trials are read in the order of the archive.
+	var imported []db.ImportedTrial
+	for {
+		trial, err := trialReader.next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		imported = append(imported, archive.trialToImport(*trial))
+	}
+	assert.Equal(t, len(imported), 2)
+	assert.Equal(t, imported[0].Trial.State, model.CompletedState)
+	assert.Equal(t, imported[0].Steps[0].State, model.CompletedState)
+	assert.Equal(t, imported[0].Steps[0].NumBatches, 100)
+	assert.Equal(t, imported[1].Trial.State, model.CanceledState)
+	assert.Equal(t, *imported[1].Trial.EndTime, manifest.ExportedAt)
+	assert.Equal(t, imported[1].Steps[0].State, model.ErrorState)
+	assert.Equal(t, imported[1].Checkpoints[0].State, model.ErrorState)
+}
+
+func TestReadExperimentArchiveOrder(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{
+		experimentArchiveManifest, experimentArchiveExperiment, "trials/1.json",
+	} {
+		content := []byte(`{"format_version": 1, "master_version": "0.13.8"}`)
+		assert.NilError(t, tw.WriteHeader(&tar.Header{
+			Name: name, Mode: 0644, Size: int64(len(content)),
+		}))
+		_, err := tw.Write(content)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	assert.NilError(t, gz.Close())
+
+	_, _, err := readExperimentArchive(&buf, "0.13.8", 1<<20)
+	assert.ErrorContains(t, err, "the archive must have model_definition.tar.gz before its trials")
+}
+
+func TestReadExperimentArchiveModelDefinitionLimit(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NilError(t, writeExperimentArchive(&buf, experimentManifest{
+		FormatVersion: experimentArchiveFormatVersion,
+		MasterVersion: "0.13.8",
+	}, archivedExperiment{Config: json.RawMessage("{}")}, []byte("model"),
+		func(func(archivedTrial) error) error { return nil }))
+	_, _, err := readExperimentArchive(&buf, "0.13.8", 4)
+	assert.ErrorContains(t, err, "model definition is larger than the limit of 4 bytes")
+}
+
+func TestCheckExperimentArchiveCompatible(t *testing.T) {
+	tests := []struct {
+		name          string
+		formatVersion int
+		exportedBy    string
+		masterVersion string
+		err           string
+	}{
+		{name: "same version", exportedBy: "0.13.8", masterVersion: "0.13.8"},
+		{name: "older version", exportedBy: "0.13.2", masterVersion: "0.13.8.dev0"},
+		{name: "same development build", exportedBy: "unknown", masterVersion: "unknown"},
+		{
+			name:          "format version",
+			formatVersion: experimentArchiveFormatVersion + 1,
+			exportedBy:    "0.13.8",
+			masterVersion: "0.13.8",
+			err: "the archive has format version 2, but this master (version 0.13.8) only " +
+				"imports format version 1",
+		},
+		{
+			name:          "newer version",
+			exportedBy:    "0.14.0",
+			masterVersion: "0.13.8",
+			err: "the archive was exported by master version 0.14.0, which is newer than this " +
+				"master (version 0.13.8); upgrade this master to import it",
+		},
+		{
+			name:          "too old",
+			exportedBy:    "0.12.13",
+			masterVersion: "0.13.8",
+			err: "the archive was exported by master version 0.12.13, but only archives of " +
+				"master version 0.13.0 and later can be imported",
+		},
+		{
+			name:          "unknown version",
+			exportedBy:    "unknown",
+			masterVersion: "0.13.8",
+			err: "the archive was exported by master version unknown, which is incompatible " +
+				"with this master (version 0.13.8)",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			formatVersion := tc.formatVersion
+			if formatVersion == 0 {
+				formatVersion = experimentArchiveFormatVersion
+			}
+			err := checkExperimentArchiveCompatible(experimentManifest{
+				FormatVersion: formatVersion,
+				MasterVersion: tc.exportedBy,
+			}, tc.masterVersion)
+			if tc.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/metrics"
+)
+
+// experimentMetricsRegistry exports the latest training and validation metrics of the trials of
+// an experiment as gauges labeled by experiment, trial and metric name. Metric names are label
+// values rather than parts of the gauge names, so any name a trial reports can be scraped.
+func experimentMetricsRegistry(
+	experimentID int, training, validation []db.LatestTrialMetric,
+) *metrics.Registry {
+	series := func(latest []db.LatestTrialMetric) func() ([]metrics.Series, error) {
+		s := make([]metrics.Series, 0, len(latest))
+		for _, l := range latest {
+			s = append(s, metrics.Series{
+				Labels: map[string]string{
+					"experiment_id": strconv.Itoa(experimentID),
+					"trial_id":      strconv.Itoa(l.TrialID),
+					"metric":        l.Name,
+				},
+				Value: l.Value,
+			})
+		}
+		return func() ([]metrics.Series, error) { return s, nil }
+	}
+
+	r := metrics.NewRegistry()
+	r.SetSeriesFunc("det_experiment_training_metric",
+		"Latest averaged training metric of each trial.", series(training))
+	r.SetSeriesFunc("det_experiment_validation_metric",
+		"Latest validation metric of each trial.", series(validation))
+	return r
+}
+
+// getExperimentPrometheusMetrics renders the latest metrics of the trials of an experiment in the
+// Prometheus text exposition format, so that they can be scraped alongside the master's own
+// metrics at /metrics.
+func (m *Master) getExperimentPrometheusMetrics(c echo.Context) error {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return err
+	}
+	switch exists, err := m.db.CheckExperimentExists(args.ExperimentID); {
+	case err != nil:
+		return err
+	case !exists:
+		return echo.NewHTTPError(
+			http.StatusNotFound, fmt.Sprintf("experiment %d not found", args.ExperimentID))
+	}
+
+	training, validation, err := m.db.LatestTrialMetrics(args.ExperimentID)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, metrics.ContentType)
+	c.Response().WriteHeader(http.StatusOK)
+	return experimentMetricsRegistry(args.ExperimentID, training, validation).
+		WriteText(c.Response())
+}
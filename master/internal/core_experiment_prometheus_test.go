@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+func TestExperimentMetricsRegistry(t *testing.T) {
+	r := experimentMetricsRegistry(3,
+		[]db.LatestTrialMetric{
+			{TrialID: 1, Name: "loss", Value: 0.5},
+			{TrialID: 2, Name: "loss", Value: 0.25},
+		},
+		[]db.LatestTrialMetric{
+			{TrialID: 1, Name: `val/acc@top5 "best"`, Value: 0.9},
+		},
+	)
+
+	var buf bytes.Buffer
+	assert.NilError(t, r.WriteText(&buf))
+	assert.Equal(t, buf.String(), strings.TrimPrefix(`
+# HELP det_experiment_training_metric Latest averaged training metric of each trial.
+# TYPE det_experiment_training_metric gauge
+det_experiment_training_metric{experiment_id="3",metric="loss",trial_id="1"} 0.5
+det_experiment_training_metric{experiment_id="3",metric="loss",trial_id="2"} 0.25
+# HELP det_experiment_validation_metric Latest validation metric of each trial.
+# TYPE det_experiment_validation_metric gauge
+det_experiment_validation_metric{experiment_id="3",metric="val/acc@top5 \"best\"",trial_id="1"} 0.9
+`, "\n"))
+}
+
+func TestExperimentMetricsRegistryEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NilError(t, experimentMetricsRegistry(3, nil, nil).WriteText(&buf))
+	assert.Equal(t, buf.String(), strings.TrimPrefix(`
+# HELP det_experiment_training_metric Latest averaged training metric of each trial.
+# TYPE det_experiment_training_metric gauge
+# HELP det_experiment_validation_metric Latest validation metric of each trial.
+# TYPE det_experiment_validation_metric gauge
+`, "\n"))
+}
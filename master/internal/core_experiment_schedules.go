@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/pkg/cron"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// scheduleParams is the schedule of an experiment submission: either a single time to create the
+// experiment at, or a cron expression, evaluated in UTC, to create experiments whenever it fires,
+// optionally up to a number of times.
+type scheduleParams struct {
+	StartAt *time.Time `json:"start_at"`
+	Cron    *string    `json:"cron"`
+	MaxRuns *int       `json:"max_runs"`
+}
+
+// validate returns an error describing the first problem with the schedule, if any.
+func (p scheduleParams) validate(now time.Time) error {
+	switch {
+	case (p.StartAt == nil) == (p.Cron == nil):
+		return errors.New("schedule must have exactly one of start_at and cron")
+	case p.StartAt != nil && !p.StartAt.After(now):
+		return errors.New("schedule start_at must be in the future")
+	case p.MaxRuns != nil && p.Cron == nil:
+		return errors.New("schedule max_runs can only be used with cron")
+	case p.MaxRuns != nil && *p.MaxRuns <= 0:
+		return errors.New("schedule max_runs must be positive")
+	}
+	if p.Cron != nil {
+		if _, err := cron.Parse(*p.Cron); err != nil {
+			return errors.Wrap(err, "invalid schedule")
+		}
+	}
+	return nil
+}
+
+// nextScheduleRunTime returns when an experiment schedule next fires after the given time, or nil
+// if it will not fire again.
+func nextScheduleRunTime(schedule *model.ExperimentSchedule, after time.Time) (*time.Time, error) {
+	switch {
+	case schedule.Cron == nil:
+		if schedule.Runs > 0 || schedule.StartAt == nil || !schedule.StartAt.After(after) {
+			return nil, nil
+		}
+		return schedule.StartAt, nil
+	case schedule.MaxRuns != nil && schedule.Runs >= *schedule.MaxRuns:
+		return nil, nil
+	}
+	parsed, err := cron.Parse(*schedule.Cron)
+	if err != nil {
+		return nil, err
+	}
+	next := parsed.Next(after.UTC())
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// postExperimentSchedule saves a validated experiment submission with its schedule.
+func (m *Master) postExperimentSchedule(
+	c echo.Context, user model.User, params scheduleParams, dbExp *model.Experiment,
+) (interface{}, error) {
+	now := time.Now().UTC()
+	schedule := model.ExperimentSchedule{
+		OwnerID:              user.ID,
+		Config:               dbExp.Config,
+		ModelDefinitionBytes: dbExp.ModelDefinitionBytes,
+		GitRemote:            dbExp.GitRemote,
+		GitCommit:            dbExp.GitCommit,
+		GitCommitter:         dbExp.GitCommitter,
+		GitCommitDate:        dbExp.GitCommitDate,
+		StartAt:              params.StartAt,
+		Cron:                 params.Cron,
+		MaxRuns:              params.MaxRuns,
+		Enabled:              true,
+		CreatedAt:            now,
+	}
+	var err error
+	if schedule.NextRunTime, err = nextScheduleRunTime(&schedule, now); err != nil {
+		return nil, err
+	}
+	if err = m.db.AddExperimentSchedule(&schedule); err != nil {
+		return nil, err
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/schedules/%d", schedule.ID))
+	return api.StatusResponse{Code: http.StatusCreated, Body: schedule}, nil
+}
+
+// getExperimentSchedules lists the schedules of the user of the request, or all schedules for an
+// admin.
+func (m *Master) getExperimentSchedules(c echo.Context) (interface{}, error) {
+	user := c.(*context.DetContext).MustGetUser()
+	var ownerID *model.UserID
+	if !user.Admin {
+		ownerID = &user.ID
+	}
+	schedules, err := m.db.ExperimentSchedules(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if schedules == nil {
+		schedules = []model.ExperimentSchedule{}
+	}
+	return schedules, nil
+}
+
+func (m *Master) getExperimentSchedule(c echo.Context) (interface{}, error) {
+	args := struct {
+		ScheduleID int `path:"schedule_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	schedule, err := m.ownedExperimentSchedule(c, args.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	experimentIDs, err := m.db.ExperimentIDsBySchedule(args.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		*model.ExperimentSchedule
+		// ExperimentIDs lists the experiments that the schedule created.
+		ExperimentIDs []int `json:"experiment_ids"`
+	}{schedule, experimentIDs}, nil
+}
+
+// ownedExperimentSchedule loads an experiment schedule that the user of the request may see and
+// change.
+func (m *Master) ownedExperimentSchedule(
+	c echo.Context, id int,
+) (*model.ExperimentSchedule, error) {
+	user := c.(*context.DetContext).MustGetUser()
+	schedule, err := m.db.ExperimentScheduleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Admin && schedule.OwnerID != user.ID {
+		return nil, echo.NewHTTPError(http.StatusForbidden,
+			"only the owner of a schedule or an admin may access it")
+	}
+	return schedule, nil
+}
+
+// patchExperimentSchedule enables or disables an experiment schedule. A schedule that is enabled
+// again fires at its next time after now, rather than catching up on the times it was disabled.
+func (m *Master) patchExperimentSchedule(c echo.Context) (interface{}, error) {
+	args := struct {
+		ScheduleID int `path:"schedule_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	var patch struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(c.Request().Body).Decode(&patch); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid patch: "+err.Error())
+	}
+	if patch.Enabled == nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "enabled must be set")
+	}
+
+	schedule, err := m.ownedExperimentSchedule(c, args.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if *patch.Enabled && !schedule.Enabled {
+		if schedule.NextRunTime, err = nextScheduleRunTime(schedule, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	schedule.Enabled = *patch.Enabled
+	if err = m.db.UpdateExperimentSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// deleteExperimentSchedule deletes an experiment schedule. The experiments that it created are
+// kept.
+func (m *Master) deleteExperimentSchedule(c echo.Context) (interface{}, error) {
+	args := struct {
+		ScheduleID int `path:"schedule_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	if _, err := m.ownedExperimentSchedule(c, args.ScheduleID); err != nil {
+		return nil, err
+	}
+	return nil, m.db.DeleteExperimentSchedule(args.ScheduleID)
+}
@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// experimentViewer returns the ID of the user whose teams limit the experiments that the user sees,
+// or nil if the user is an admin and sees every experiment.
+func experimentViewer(user model.User) *model.UserID {
+	if user.Admin {
+		return nil
+	}
+	return &user.ID
+}
+
+// defaultExperimentTeam returns the ID of the team that experiments of a user with the given teams
+// belong to if their submission names none: the only team of the user, if the user is a member of
+// exactly one team. Otherwise, the experiments have no team and are visible to everyone.
+func defaultExperimentTeam(teams []model.Team) *int {
+	if len(teams) != 1 {
+		return nil
+	}
+	id := teams[0].ID
+	return &id
+}
+
+// experimentTeam returns the ID of the team with the given name for an experiment of the user,
+// which must be a member of it unless the user is an admin. A nil name selects the default team
+// of the user and an empty one no team.
+func (m *Master) experimentTeam(user model.User, name *string) (*int, error) {
+	if name != nil && *name == "" {
+		return nil, nil
+	}
+	teams, err := m.db.UserTeams(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if name == nil {
+		return defaultExperimentTeam(teams), nil
+	}
+	for _, team := range teams {
+		if team.Name == *name {
+			id := team.ID
+			return &id, nil
+		}
+	}
+	if !user.Admin {
+		return nil, echo.NewHTTPError(http.StatusForbidden,
+			fmt.Sprintf("user %s is not a member of team %q", user.Username, *name))
+	}
+	team, err := m.db.TeamByName(*name)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, echo.NewHTTPError(
+			http.StatusBadRequest, fmt.Sprintf("team %q does not exist", *name))
+	case err != nil:
+		return nil, errors.Wrapf(err, "loading team %s", *name)
+	}
+	return &team.ID, nil
+}
+
+// experimentVisibleFunc reports whether an experiment is visible to a viewer, like
+// (*db.PgDB).ExperimentVisible.
+type experimentVisibleFunc func(experimentID int, viewer *model.UserID) (bool, error)
+
+// checkVisible responds to a request for experiments that the viewer does not all see, because
+// they belong to teams that the viewer is not a member of, as if the first such experiment did not
+// exist. A nil viewer is an admin, who sees every experiment.
+func checkVisible(viewer *model.UserID, ids []int, visible experimentVisibleFunc) error {
+	if viewer == nil {
+		return nil
+	}
+	for _, id := range ids {
+		switch ok, err := visible(id, viewer); {
+		case err != nil:
+			return err
+		case !ok:
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("experiment %d not found", id))
+		}
+	}
+	return nil
+}
+
+// checkExperimentsVisible checks that the user of a request sees the experiments, for handlers
+// of several experiments that are not named by the :experiment_id path parameter.
+func (m *Master) checkExperimentsVisible(c echo.Context, ids ...int) error {
+	viewer := experimentViewer(c.(*context.DetContext).MustGetUser())
+	return checkVisible(viewer, ids, m.db.ExperimentVisible)
+}
+
+// checkExperimentVisible is a middleware that responds to requests for an experiment that the user
+// does not see, because it belongs to a team that the user is not a member of, as if the
+// experiment did not exist. Handlers of experiments that are named otherwise check them with
+// checkExperimentsVisible.
+func (m *Master) checkExperimentVisible(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("experiment_id"))
+		if err != nil {
+			return next(c)
+		}
+		if err := m.checkExperimentsVisible(c, id); err != nil {
+			return err
+		}
+		return next(c)
+	}
+}
+
+// checkTrialVisible is a middleware that responds to requests for a trial, named by the :trial_id
+// path parameter, of an experiment that the user does not see as if the trial did not exist.
+func (m *Master) checkTrialVisible(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("trial_id"))
+		viewer := experimentViewer(c.(*context.DetContext).MustGetUser())
+		if err != nil || viewer == nil {
+			return next(c)
+		}
+		switch visible, err := m.db.TrialVisible(id, viewer); {
+		case err != nil:
+			return err
+		case !visible:
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("trial %d not found", id))
+		}
+		return next(c)
+	}
+}
+
+// checkCheckpointVisible is a middleware that responds to requests for a checkpoint, named by the
+// :checkpoint_uuid path parameter, of an experiment that the user does not see as if the
+// checkpoint did not exist.
+func (m *Master) checkCheckpointVisible(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := uuid.Parse(c.Param("checkpoint_uuid"))
+		viewer := experimentViewer(c.(*context.DetContext).MustGetUser())
+		if err != nil || viewer == nil {
+			return next(c)
+		}
+		switch visible, err := m.db.CheckpointVisible(id, viewer); {
+		case err != nil:
+			return err
+		case !visible:
+			return echo.NewHTTPError(
+				http.StatusNotFound, fmt.Sprintf("checkpoint %s not found", id))
+		}
+		return next(c)
+	}
+}
+
+// putExperimentTeam moves an experiment to another team, or out of its team if the team is null
+// or empty. Only admins and the owner of the experiment, if a member of the new team, may do so.
+func (m *Master) putExperimentTeam(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	body := struct {
+		Team *string `json:"team"`
+	}{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid team: "+err.Error())
+	}
+	if body.Team == nil {
+		body.Team = new(string)
+	}
+
+	user := c.(*context.DetContext).MustGetUser()
+	dbExp, err := m.db.ExperimentByID(args.ExperimentID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading experiment %v", args.ExperimentID)
+	}
+	if !user.Admin && (dbExp.OwnerID == nil || *dbExp.OwnerID != user.ID) {
+		return nil, echo.NewHTTPError(http.StatusForbidden,
+			"only admins and the owner of an experiment may change its team")
+	}
+	teamID, err := m.experimentTeam(user, body.Team)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.db.SaveExperimentTeam(args.ExperimentID, teamID); err != nil {
+		return nil, err
+	}
+	m.experimentListCache.invalidate()
+	return struct {
+		ID   int    `json:"id"`
+		Team string `json:"team,omitempty"`
+	}{args.ExperimentID, *body.Team}, nil
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestExperimentViewer(t *testing.T) {
+	assert.Assert(t, experimentViewer(model.User{ID: 1, Admin: true}) == nil)
+	assert.Equal(t, *experimentViewer(model.User{ID: 2}), model.UserID(2))
+}
+
+func TestDefaultExperimentTeam(t *testing.T) {
+	assert.Assert(t, defaultExperimentTeam(nil) == nil)
+	assert.Equal(t, *defaultExperimentTeam([]model.Team{{ID: 3, Name: "vision"}}), 3)
+	// Users of several teams must choose one, so their experiments have none by default.
+	assert.Assert(t, defaultExperimentTeam([]model.Team{
+		{ID: 3, Name: "vision"}, {ID: 4, Name: "nlp"},
+	}) == nil)
+}
+
+func TestCheckVisible(t *testing.T) {
+	// User 2 is a member of the team of experiment 1 only; experiment 3 has no team.
+	visible := func(id int, viewer *model.UserID) (bool, error) {
+		return id == 3 || (id == 1 && *viewer == 2), nil
+	}
+	member, nonMember := model.UserID(2), model.UserID(5)
+
+	assert.NilError(t, checkVisible(&member, []int{1, 3}, visible))
+	assert.NilError(t, checkVisible(nil, []int{1, 2, 3}, visible))
+
+	// Comparing or diffing experiments of another team fails as if they did not exist.
+	for _, ids := range [][]int{{1, 3}, {3, 1}} {
+		err := checkVisible(&nonMember, ids, visible)
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok, "%v", err)
+		assert.Equal(t, httpErr.Code, http.StatusNotFound)
+		assert.Equal(t, httpErr.Message, "experiment 1 not found")
+	}
+}
@@ -0,0 +1,416 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func summaryWithSteps(numSteps int) []byte {
+	steps := make([]map[string]interface{}, 0, numSteps)
+	for i := 0; i < numSteps; i++ {
+		step := map[string]interface{}{
+			"id":                      i + 1,
+			"num_batches":             100,
+			"prior_batches_processed": i * 100,
+			"metrics": map[string]interface{}{
+				"avg_metrics": map[string]interface{}{"loss": math.Sin(float64(i) / 10)},
+			},
+		}
+		if i%10 == 9 {
+			step["validation"] = map[string]interface{}{
+				"metrics": map[string]interface{}{
+					"validation_metrics": map[string]interface{}{"error": 1 / float64(i)},
+				},
+			}
+		}
+		steps = append(steps, step)
+	}
+	summary, err := json.Marshal(map[string]interface{}{
+		"id":     1,
+		"trials": []interface{}{map[string]interface{}{"id": 1, "steps": steps}},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return summary
+}
+
+func summarySteps(t *testing.T, summary []byte) []map[string]interface{} {
+	var parsed struct {
+		Trials []struct {
+			Steps []map[string]interface{} `json:"steps"`
+		} `json:"trials"`
+	}
+	assert.NilError(t, json.Unmarshal(summary, &parsed))
+	assert.Equal(t, len(parsed.Trials), 1)
+	return parsed.Trials[0].Steps
+}
+
+func TestDownsampleSummaryMetrics(t *testing.T) {
+	summary, err := downsampleSummaryMetrics(summaryWithSteps(500), 50)
+	assert.NilError(t, err)
+	steps := summarySteps(t, summary)
+
+	// At most 50 points of each of the training and validation series are kept.
+	training, validation := 0, 0
+	for _, step := range steps {
+		if len(metricsAt(step, "metrics", "avg_metrics")) > 0 {
+			training++
+		}
+		if len(metricsAt(step, "validation", "metrics", "validation_metrics")) > 0 {
+			validation++
+		}
+	}
+	assert.Assert(t, training <= 50, fmt.Sprintf("%d training points kept", training))
+	assert.Assert(t, validation <= 50, fmt.Sprintf("%d validation points kept", validation))
+	assert.Assert(t, training >= 45, fmt.Sprintf("%d training points kept", training))
+	assert.Equal(t, steps[0]["id"], float64(1))
+	assert.Equal(t, steps[len(steps)-1]["id"], float64(500))
+
+	lastID := 0.0
+	for _, step := range steps {
+		assert.Assert(t, step["id"].(float64) > lastID, "steps must remain ordered")
+		lastID = step["id"].(float64)
+	}
+}
+
+func TestDownsampleSummaryMetricsFewSteps(t *testing.T) {
+	summary, err := downsampleSummaryMetrics(summaryWithSteps(20), 50)
+	assert.NilError(t, err)
+	assert.Equal(t, len(summarySteps(t, summary)), 20)
+}
+
+func TestDownsampleSummaryMetricsReduced(t *testing.T) {
+	var parsed map[string]interface{}
+	assert.NilError(t, json.Unmarshal(summaryWithSteps(20), &parsed))
+	reduced := make([]interface{}, 0, 500)
+	for i := 0; i < 500; i++ {
+		reduced = append(reduced, map[string]interface{}{
+			"batches":            (i + 1) * 100,
+			"num_trials":         2,
+			"metrics":            map[string]interface{}{"loss": math.Sin(float64(i) / 10)},
+			"validation_metrics": map[string]interface{}{"error": 1 / float64(i+1)},
+		})
+	}
+	parsed["reduced_metrics"] = reduced
+	withReduced, err := json.Marshal(parsed)
+	assert.NilError(t, err)
+
+	summary, err := downsampleSummaryMetrics(withReduced, 50)
+	assert.NilError(t, err)
+	assert.Equal(t, len(summarySteps(t, summary)), 20)
+	var downsampled struct {
+		ReducedMetrics []map[string]interface{} `json:"reduced_metrics"`
+	}
+	assert.NilError(t, json.Unmarshal(summary, &downsampled))
+	training, validation := 0, 0
+	lastBatches := 0.0
+	for _, point := range downsampled.ReducedMetrics {
+		training += len(metricsAt(point, "metrics"))
+		validation += len(metricsAt(point, "validation_metrics"))
+		assert.Assert(t, point["batches"].(float64) > lastBatches, "points must remain ordered")
+		lastBatches = point["batches"].(float64)
+	}
+	assert.Assert(t, training <= 50, fmt.Sprintf("%d training points kept", training))
+	assert.Assert(t, validation <= 50, fmt.Sprintf("%d validation points kept", validation))
+	assert.Equal(t, downsampled.ReducedMetrics[0]["batches"], float64(100))
+	assert.Equal(t, lastBatches, float64(50000))
+}
+
+func TestParseSummaryMetricsArgs(t *testing.T) {
+	parse := func(query string) (summaryMetricsArgs, error) {
+		req := httptest.NewRequest(http.MethodGet, "/experiments/1/metrics/summary?"+query, nil)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("experiment_id")
+		c.SetParamValues("1")
+		return parseSummaryMetricsArgs(c)
+	}
+
+	args, err := parse("")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, args, summaryMetricsArgs{ExperimentID: 1})
+
+	median := db.ReduceMedian
+	args, err = parse("reduction=median&max_datapoints=100")
+	assert.NilError(t, err)
+	assert.Equal(t, *args.Reduction, median)
+	assert.Equal(t, *args.MaxDatapoints, 100)
+
+	// Metric names are taken verbatim once they are decoded from the query.
+	args, err = parse("metric=val%2Faccuracy%40top5&metric=a%2Bb&metric=%27%3B+DROP+TABLE+steps")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, args.Metrics, []string{"val/accuracy@top5", "a+b", "'; DROP TABLE steps"})
+
+	for _, query := range []string{
+		"reduction=average", "reduction=MEAN", "max_datapoints=1", "metric=",
+	} {
+		_, err := parse(query)
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok, "%s: %v", query, err)
+		assert.Equal(t, httpErr.Code, http.StatusBadRequest)
+	}
+}
+
+// adversarialMetricNames are metric names that trials may report which are awkward to quote in SQL,
+// URLs or JSON, or to group by.
+var adversarialMetricNames = []string{
+	"val/accuracy@top5",
+	"loss.total",
+	"ünïcödé 损失 📉",
+	strings.Repeat("long_metric_name", 64),
+	`'; DROP TABLE steps; --`,
+	`"quoted" \ name`,
+	"%s%d$1",
+	"a+b&c=d?e#f",
+}
+
+func TestFilterMetrics(t *testing.T) {
+	metrics := map[string]interface{}{"loss": 1}
+	for i, name := range adversarialMetricNames {
+		metrics[name] = i
+	}
+	summary, err := json.Marshal(map[string]interface{}{
+		"trials": []interface{}{map[string]interface{}{
+			"steps": []interface{}{map[string]interface{}{
+				"metrics": map[string]interface{}{
+					"avg_metrics":   metrics,
+					"batch_metrics": []interface{}{metrics, metrics},
+				},
+				"validation": map[string]interface{}{
+					"metrics": map[string]interface{}{"validation_metrics": metrics},
+				},
+			}},
+		}},
+		"reduced_metrics": []interface{}{map[string]interface{}{
+			"batches": 100, "metrics": metrics, "validation_metrics": metrics,
+		}},
+	})
+	assert.NilError(t, err)
+
+	for i, name := range adversarialMetricNames {
+		filtered, err := filterMetrics(summary, []string{name})
+		assert.NilError(t, err)
+		step := summarySteps(t, filtered)[0]
+		expected := map[string]interface{}{name: float64(i)}
+		stepMetrics := step["metrics"].(map[string]interface{})
+		assert.DeepEqual(t, stepMetrics["avg_metrics"], expected)
+		assert.DeepEqual(t, stepMetrics["batch_metrics"], []interface{}{expected, expected})
+		assert.DeepEqual(t, step["validation"], map[string]interface{}{
+			"metrics": map[string]interface{}{"validation_metrics": expected},
+		})
+		var parsed struct {
+			ReducedMetrics []map[string]interface{} `json:"reduced_metrics"`
+		}
+		assert.NilError(t, json.Unmarshal(filtered, &parsed))
+		assert.DeepEqual(t, parsed.ReducedMetrics, []map[string]interface{}{{
+			"batches": float64(100), "metrics": expected, "validation_metrics": expected,
+		}})
+	}
+
+	// The metrics of a single trial are filtered the same way.
+	trial, err := json.Marshal(map[string]interface{}{
+		"id": 1,
+		"steps": []interface{}{map[string]interface{}{
+			"metrics": map[string]interface{}{"avg_metrics": metrics},
+		}},
+	})
+	assert.NilError(t, err)
+	filtered, err := filterMetrics(trial, []string{"loss", adversarialMetricNames[0]})
+	assert.NilError(t, err)
+	var parsed struct {
+		Steps []struct {
+			Metrics struct {
+				AvgMetrics map[string]int `json:"avg_metrics"`
+			} `json:"metrics"`
+		} `json:"steps"`
+	}
+	assert.NilError(t, json.Unmarshal(filtered, &parsed))
+	assert.DeepEqual(t, parsed.Steps[0].Metrics.AvgMetrics,
+		map[string]int{"loss": 1, adversarialMetricNames[0]: 0})
+}
+
+func TestParseAdversarialMetricNames(t *testing.T) {
+	for _, name := range adversarialMetricNames {
+		query := url.Values{"metric": []string{name}}.Encode()
+		req := httptest.NewRequest(http.MethodGet, "/trials/1/metrics?"+query, nil)
+		names, err := parseMetricNames(echo.New().NewContext(req, httptest.NewRecorder()))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, names, []string{name})
+	}
+}
+
+func TestCheckKnownExperimentFields(t *testing.T) {
+	assert.NilError(t, checkKnownExperimentFields([]byte(`
+searcher:
+  name: single
+  metric: loss
+  max_length:
+    batches: 100
+hyperparameters:
+  lr:
+    type: double
+    minval: 0.001
+    maxval: 0.1
+`)))
+	assert.Error(t, checkKnownExperimentFields([]byte(`
+searcher:
+  name: single
+  metrik: loss
+hyperparameters:
+  lr:
+    type: double
+    min_val: 0.001
+`)), "unknown fields: hyperparameters.lr.min_val, searcher.metrik")
+}
+
+func TestCheckExternalIDFormat(t *testing.T) {
+	m := &Master{}
+	for _, externalID := range []string{"", "  ", strings.Repeat("x", maxExternalIDLength+1)} {
+		err := m.checkExternalID(externalID)
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok, "%q: %v", externalID, err)
+		assert.Equal(t, httpErr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCheckExperimentConfigSize(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxExperimentConfigBytes = 16
+	m := &Master{config: config}
+	assert.NilError(t, m.checkExperimentConfigSize("searcher: {}"))
+	assert.ErrorContains(t, m.checkExperimentConfigSize(strings.Repeat("#", 17)),
+		"experiment config is 17 bytes, larger than the limit of 16 bytes")
+}
+
+func TestSpoolModelDefinition(t *testing.T) {
+	upload := func(fields map[string]string) *http.Request {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for name, content := range fields {
+			part, err := writer.CreateFormFile(name, name+".tar.gz")
+			assert.NilError(t, err)
+			_, err = part.Write([]byte(content))
+			assert.NilError(t, err)
+		}
+		assert.NilError(t, writer.Close())
+		req := httptest.NewRequest(http.MethodPost, "/experiments/1/model_def", &body)
+		req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+		return req
+	}
+	code := func(err error) int {
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok, err)
+		return httpErr.Code
+	}
+
+	file, size, err := spoolModelDefinition(upload(map[string]string{
+		"notes": "ignored", modelDefinitionFormField: "archive",
+	}), 7)
+	assert.NilError(t, err)
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	content, err := ioutil.ReadAll(file)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "archive")
+	assert.Equal(t, size, int64(7))
+
+	_, _, err = spoolModelDefinition(upload(map[string]string{
+		modelDefinitionFormField: "archive",
+	}), 6)
+	assert.Equal(t, code(err), http.StatusRequestEntityTooLarge)
+	_, _, err = spoolModelDefinition(upload(map[string]string{"notes": "archive"}), 7)
+	assert.Equal(t, code(err), http.StatusBadRequest)
+	_, _, err = spoolModelDefinition(
+		httptest.NewRequest(http.MethodPost, "/experiments/1/model_def", nil), 7)
+	assert.Equal(t, code(err), http.StatusBadRequest)
+}
+
+func TestLeaderboardSmallerIsBetter(t *testing.T) {
+	searcher := model.SearcherConfig{Metric: "val_loss", SmallerIsBetter: true}
+	order := func(o string) *string { return &o }
+
+	smallerIsBetter, err := leaderboardSmallerIsBetter(nil, "val_loss", searcher)
+	assert.NilError(t, err)
+	assert.Assert(t, smallerIsBetter)
+
+	_, err = leaderboardSmallerIsBetter(nil, "val_acc", searcher)
+	assert.ErrorContains(t, err, "order is required for val_acc")
+
+	smallerIsBetter, err = leaderboardSmallerIsBetter(order("asc"), "val_acc", searcher)
+	assert.NilError(t, err)
+	assert.Assert(t, smallerIsBetter)
+
+	smallerIsBetter, err = leaderboardSmallerIsBetter(order("desc"), "val_loss", searcher)
+	assert.NilError(t, err)
+	assert.Assert(t, !smallerIsBetter)
+
+	_, err = leaderboardSmallerIsBetter(order("best"), "val_loss", searcher)
+	assert.ErrorContains(t, err, `order must be asc or desc, not "best"`)
+}
+
+func TestResolveTemplate(t *testing.T) {
+	m := &Master{config: DefaultConfig()}
+
+	resolved, err := m.resolveTemplate(
+		[]byte("description: from template\nresources:\n  slots_per_trial: 4\n"))
+	assert.NilError(t, err)
+	var config struct {
+		Description string `json:"description"`
+		MaxRestarts int    `json:"max_restarts"`
+		Resources   struct {
+			SlotsPerTrial int `json:"slots_per_trial"`
+		} `json:"resources"`
+	}
+	assert.NilError(t, json.Unmarshal(resolved, &config))
+	assert.Equal(t, config.Description, "from template")
+	assert.Equal(t, config.Resources.SlotsPerTrial, 4)
+	assert.Equal(t, config.MaxRestarts, model.DefaultExperimentConfig(nil).MaxRestarts)
+	assert.Assert(t, strings.Contains(string(resolved), `"smaller_is_better":true`))
+
+	resolved, err = m.resolveTemplate(
+		[]byte("searcher:\n  name: single\n  max_length:\n    batches: 100\n"))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(resolved), `"name":"single"`))
+
+	_, err = m.resolveTemplate([]byte("not_a_field: 1\n"))
+	assert.ErrorContains(t, err, "invalid template configuration")
+}
+
+func TestCheckStorageOverride(t *testing.T) {
+	master := model.CheckpointStorageConfig{
+		SaveTrialBest: 1,
+		S3Config:      &model.S3Config{Bucket: "Legacy_Bucket"},
+	}
+
+	// Keeping other numbers of checkpoints in the storage of the master is not an override.
+	experiment := master
+	experiment.SaveTrialBest = 3
+	experiment.S3Config = &model.S3Config{Bucket: "Legacy_Bucket"}
+	assert.NilError(t, checkStorageOverride(master, experiment))
+
+	experiment.S3Config = &model.S3Config{Bucket: "other/bucket"}
+	assert.ErrorContains(t, checkStorageOverride(master, experiment),
+		"must not contain slashes or spaces")
+
+	experiment.S3Config = nil
+	experiment.SharedFSConfig = &model.SharedFSConfig{HostPath: "/mnt/checkpoints"}
+	assert.NilError(t, checkStorageOverride(master, experiment))
+}
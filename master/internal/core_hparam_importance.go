@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/hpimportance"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// minHParamImportanceTrials is the number of completed trials below which hyperparameter
+// importance is not computed, since it would mostly reflect noise.
+const minHParamImportanceTrials = 10
+
+type hparamImportance struct {
+	Metric string `json:"metric"`
+	// NumTrials is the number of completed trials that reported the metric.
+	NumTrials int `json:"num_trials"`
+	// NotEnoughData is set if there are fewer than MinTrials such trials, in which case Importance
+	// is not computed.
+	NotEnoughData bool               `json:"not_enough_data"`
+	MinTrials     int                `json:"min_trials"`
+	Importance    map[string]float64 `json:"importance,omitempty"`
+}
+
+func (m *Master) getExperimentHParamImportance(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int     `path:"experiment_id"`
+		Metric       *string `query:"metric"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+
+	config, err := m.db.ExperimentConfig(args.ExperimentID)
+	if errors.Cause(err) == db.ErrNotFound {
+		return nil, echo.NewHTTPError(
+			echo.ErrNotFound.Code, fmt.Sprintf("experiment %d not found", args.ExperimentID))
+	} else if err != nil {
+		return nil, err
+	}
+	metric := config.Searcher.Metric
+	if args.Metric != nil {
+		metric = *args.Metric
+	}
+
+	trials, err := m.db.CompletedTrialsFinalMetric(args.ExperimentID, metric)
+	if err != nil {
+		return nil, err
+	}
+	result := hparamImportance{
+		Metric:    metric,
+		NumTrials: len(trials),
+		MinTrials: minHParamImportanceTrials,
+	}
+	if len(trials) < minHParamImportanceTrials {
+		result.NotEnoughData = true
+		return result, nil
+	}
+
+	// The importance is cached until more trials complete.
+	trialsKey := hparamImportanceTrialsKey(trials)
+	switch cached, err := m.db.HParamImportance(args.ExperimentID, metric, trialsKey); {
+	case err == nil:
+		result.Importance = cached
+		return result, nil
+	case errors.Cause(err) != db.ErrNotFound:
+		return nil, err
+	}
+
+	result.Importance = computeHParamImportance(
+		config.Hyperparameters, trials, int64(config.Reproducibility.ExperimentSeed))
+	if err := m.db.SaveHParamImportance(
+		args.ExperimentID, metric, trialsKey, result.Importance); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// hparamImportanceTrialsKey identifies the set of trials that hyperparameter importance is
+// computed from.
+func hparamImportanceTrialsKey(trials []db.TrialFinalMetric) string {
+	ids := make([]string, 0, len(trials))
+	for _, trial := range trials {
+		ids = append(ids, strconv.Itoa(trial.TrialID))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// computeHParamImportance returns the importance of each hyperparameter that is not constant for
+// predicting the final metric of the given trials. Numeric hyperparameters are used as is and
+// categorical ones by the index of their value.
+func computeHParamImportance(
+	hparams model.Hyperparameters, trials []db.TrialFinalMetric, seed int64,
+) map[string]float64 {
+	var names []string
+	var encoders []func(interface{}) float64
+	hparams.Each(func(name string, param model.Hyperparameter) {
+		switch {
+		case param.IntHyperparameter != nil, param.DoubleHyperparameter != nil,
+			param.LogHyperparameter != nil:
+			encoders = append(encoders, func(value interface{}) float64 {
+				if number, ok := value.(float64); ok {
+					return number
+				}
+				return 0
+			})
+		case param.CategoricalHyperparameter != nil:
+			index := make(map[string]float64, len(param.CategoricalHyperparameter.Vals))
+			for i, val := range param.CategoricalHyperparameter.Vals {
+				index[hparamKey(val)] = float64(i)
+			}
+			encoders = append(encoders, func(value interface{}) float64 {
+				return index[hparamKey(value)]
+			})
+		default:
+			return
+		}
+		names = append(names, name)
+	})
+
+	x := make([][]float64, 0, len(trials))
+	y := make([]float64, 0, len(trials))
+	for _, trial := range trials {
+		sample := make([]float64, len(names))
+		for i, name := range names {
+			sample[i] = encoders[i](trial.HParams[name])
+		}
+		x = append(x, sample)
+		y = append(y, trial.Value)
+	}
+
+	importance := make(map[string]float64, len(names))
+	for i, value := range hpimportance.Compute(x, y, seed) {
+		importance[names[i]] = value
+	}
+	return importance
+}
+
+func hparamKey(value interface{}) string {
+	key, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(key)
+}
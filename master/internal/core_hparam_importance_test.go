@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestComputeHParamImportance(t *testing.T) {
+	hparams := model.Hyperparameters{
+		"global_batch_size": {ConstHyperparameter: &model.ConstHyperparameter{Val: 32}},
+		"lr": {
+			DoubleHyperparameter: &model.DoubleHyperparameter{Minval: 0.001, Maxval: 0.1},
+		},
+		"optimizer": {
+			CategoricalHyperparameter: &model.CategoricalHyperparameter{
+				Vals: []interface{}{"sgd", "adam", "rmsprop"},
+			},
+		},
+	}
+	optimizerLoss := map[string]float64{"sgd": 1, "adam": 0.2, "rmsprop": 0.5}
+
+	var trials []db.TrialFinalMetric
+	for i := 0; i < 30; i++ {
+		optimizer := []string{"sgd", "adam", "rmsprop"}[i%3]
+		trials = append(trials, db.TrialFinalMetric{
+			TrialID: i + 1,
+			HParams: model.JSONObj{
+				"global_batch_size": 32.0, "lr": 0.001 + float64(i)*0.003, "optimizer": optimizer,
+			},
+			Value: optimizerLoss[optimizer],
+		})
+	}
+
+	importance := computeHParamImportance(hparams, trials, 0)
+	assert.Equal(t, len(importance), 2, "constant hyperparameters are not ranked")
+	assert.Assert(t, importance["optimizer"] > importance["lr"], fmt.Sprint(importance))
+}
+
+func TestHParamImportanceTrialsKey(t *testing.T) {
+	trials := []db.TrialFinalMetric{{TrialID: 1}, {TrialID: 2}}
+	key := hparamImportanceTrialsKey(trials)
+	assert.Equal(t, hparamImportanceTrialsKey(trials), key)
+	assert.Assert(t, hparamImportanceTrialsKey(append(trials, db.TrialFinalMetric{TrialID: 3})) != key)
+}
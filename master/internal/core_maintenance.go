@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// maintenanceOverrideParam is the query parameter with which admins start experiments and commands
+// while the cluster is in maintenance mode.
+const maintenanceOverrideParam = "override_maintenance"
+
+// maintenanceStatus is the response of the maintenance endpoints.
+type maintenanceStatus struct {
+	Enabled bool `json:"enabled"`
+	*model.Maintenance
+}
+
+// currentMaintenance returns the maintenance mode of the cluster, or nil if it is not enabled.
+func (m *Master) currentMaintenance() *model.Maintenance {
+	m.maintenanceLock.RLock()
+	defer m.maintenanceLock.RUnlock()
+	return m.maintenance
+}
+
+// loadMaintenance restores the maintenance mode that was enabled before the master restarted.
+func (m *Master) loadMaintenance() error {
+	maintenance, err := m.db.Maintenance()
+	if err != nil {
+		return err
+	}
+	if maintenance != nil {
+		log.Infof("cluster is in maintenance mode since %s", maintenance.StartTime)
+		m.maintenance = maintenance
+		m.system.Tell(m.rm, resourcemanagers.SetMaintenance{Enabled: true})
+	}
+	return nil
+}
+
+// checkMaintenance returns an error explaining the maintenance mode if it stops the user from
+// starting new work. Admins may override the maintenance mode.
+func (m *Master) checkMaintenance(user model.User, override bool) error {
+	maintenance := m.currentMaintenance()
+	switch {
+	case maintenance == nil:
+		return nil
+	case override && user.Admin:
+		log.Infof("user %s is starting work during maintenance", user.Username)
+		return nil
+	case maintenance.Message != "":
+		return errors.Errorf("the cluster is in maintenance mode: %s", maintenance.Message)
+	default:
+		return errors.New("the cluster is in maintenance mode")
+	}
+}
+
+// maintenanceGate rejects requests that start commands, notebooks, shells and TensorBoards while
+// the cluster is in maintenance mode.
+func (m *Master) maintenanceGate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Method == http.MethodPost {
+			user := c.(*context.DetContext).MustGetUser()
+			override, _ := strconv.ParseBool(c.QueryParam(maintenanceOverrideParam))
+			if err := m.checkMaintenance(user, override); err != nil {
+				return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+			}
+		}
+		return next(c)
+	}
+}
+
+func (m *Master) getMaintenance(c echo.Context) (interface{}, error) {
+	maintenance := m.currentMaintenance()
+	return maintenanceStatus{Enabled: maintenance != nil, Maintenance: maintenance}, nil
+}
+
+func (m *Master) postMaintenance(c echo.Context) (interface{}, error) {
+	body := struct {
+		Enabled bool   `json:"enabled"`
+		Message string `json:"message"`
+	}{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("invalid maintenance request: %s", err))
+	}
+
+	m.maintenanceLock.Lock()
+	defer m.maintenanceLock.Unlock()
+	var maintenance *model.Maintenance
+	if body.Enabled {
+		maintenance = &model.Maintenance{
+			Message:   body.Message,
+			StartTime: time.Now().UTC(),
+			UserID:    c.(*context.DetContext).MustGetUser().ID,
+		}
+		if err := m.db.StartMaintenance(maintenance); err != nil {
+			return nil, err
+		}
+	} else if err := m.db.EndMaintenance(); err != nil {
+		return nil, err
+	}
+	m.maintenance = maintenance
+	m.system.Tell(m.rm, resourcemanagers.SetMaintenance{Enabled: body.Enabled})
+	log.Infof("maintenance mode enabled: %t", body.Enabled)
+	return maintenanceStatus{Enabled: body.Enabled, Maintenance: maintenance}, nil
+}
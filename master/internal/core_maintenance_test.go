@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestCheckMaintenance(t *testing.T) {
+	user := model.User{Username: "user"}
+	admin := model.User{Username: "admin", Admin: true}
+
+	m := &Master{}
+	assert.NilError(t, m.checkMaintenance(user, false))
+
+	m.maintenance = &model.Maintenance{}
+	assert.Error(t, m.checkMaintenance(user, false), "the cluster is in maintenance mode")
+	assert.Error(t, m.checkMaintenance(admin, false), "the cluster is in maintenance mode")
+
+	m.maintenance.Message = "upgrading until 5pm"
+	assert.Error(t, m.checkMaintenance(user, true),
+		"the cluster is in maintenance mode: upgrading until 5pm")
+	assert.NilError(t, m.checkMaintenance(admin, true))
+}
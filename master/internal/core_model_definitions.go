@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/storage"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// defaultModelDefinitionMigrationBatchSize is the number of model definitions that a migration of
+// model definitions out of Postgres lists at a time, unless the request asks for another number.
+const defaultModelDefinitionMigrationBatchSize = 100
+
+// setupModelDefinitions connects to the configured model definition storage.
+func (m *Master) setupModelDefinitions() error {
+	var err error
+	if m.modelDefinitions, err = storage.NewModelDefinitions(
+		context.Background(), m.config.ModelDefinitionStorage, m.db); err != nil {
+		return errors.Wrap(err, "failed to set up model definition storage")
+	}
+	log.Infof("storing model definitions in %s storage", m.modelDefinitions.Type())
+	return nil
+}
+
+// storeModelDefinition stores the model definition of a new experiment in the model definition
+// storage, so that the experiment only refers to it by hash in the database.
+func (m *Master) storeModelDefinition(exp *model.Experiment) error {
+	hash, err := m.modelDefinitions.Put(context.Background(), exp.ModelDefinitionBytes)
+	if err != nil {
+		return err
+	}
+	exp.ModelDefinitionHash = &hash
+	return nil
+}
+
+// loadModelDefinition loads the model definition of an experiment that was loaded from the
+// database from the model definition storage, unless the experiment keeps it in the experiments
+// table.
+func (m *Master) loadModelDefinition(exp *model.Experiment) error {
+	if exp.ModelDefinitionBytes != nil || exp.ModelDefinitionHash == nil {
+		return nil
+	}
+	content, err := m.modelDefinitions.Get(context.Background(), *exp.ModelDefinitionHash)
+	if err != nil {
+		return errors.Wrapf(err, "loading model definition of experiment %d", exp.ID)
+	}
+	exp.ModelDefinitionBytes = content
+	return nil
+}
+
+// experimentModelDefinition returns the model definition of an experiment as a .tar.gz archive.
+func (m *Master) experimentModelDefinition(id int) ([]byte, error) {
+	content, hash, err := m.db.ExperimentModelDefinition(id)
+	if err != nil || content != nil || hash == nil {
+		return content, err
+	}
+	return m.modelDefinitions.Get(context.Background(), *hash)
+}
+
+// modelDefinitionMigration counts the model definitions that a migration moved out of Postgres.
+type modelDefinitionMigration struct {
+	Storage string `json:"storage"`
+	// Experiments is the number of experiments whose model definitions were moved out of the
+	// experiments table.
+	Experiments int `json:"experiments"`
+	// Blobs is the number of model definitions that were moved out of the model_definitions
+	// table, which only happens if the storage is not postgres.
+	Blobs int `json:"blobs"`
+}
+
+// postMigrateModelDefinitions moves the model definitions that are kept in Postgres to the
+// configured model definition storage, in batches. Each copy is read back and its hash verified
+// before the copy in the database is deleted, so the migration can be interrupted and rerun.
+func (m *Master) postMigrateModelDefinitions(c echo.Context) (interface{}, error) {
+	args := struct {
+		BatchSize *int `query:"batch_size"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	batchSize := defaultModelDefinitionMigrationBatchSize
+	if args.BatchSize != nil {
+		if *args.BatchSize <= 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "batch_size must be positive")
+		}
+		batchSize = *args.BatchSize
+	}
+
+	ctx := c.Request().Context()
+	migration := modelDefinitionMigration{Storage: m.modelDefinitions.Type()}
+	err := m.migrateExperimentModelDefinitions(ctx, batchSize, &migration)
+	if err == nil && migration.Storage != storage.ModelDefinitionsPostgres {
+		err = m.migrateModelDefinitionBlobs(ctx, batchSize, &migration)
+	}
+	log.Infof("moved the model definitions of %d experiments and %d stored model definitions "+
+		"to %s storage", migration.Experiments, migration.Blobs, migration.Storage)
+	if err != nil {
+		return nil, errors.Wrapf(err, "migrating model definitions stopped after moving those of "+
+			"%d experiments and %d stored model definitions", migration.Experiments, migration.Blobs)
+	}
+	return migration, nil
+}
+
+// verifiedPut stores a model definition and reads it back to check that it was stored intact.
+func (m *Master) verifiedPut(ctx context.Context, content []byte) (string, error) {
+	hash, err := m.modelDefinitions.Put(ctx, content)
+	if err != nil {
+		return "", err
+	}
+	if _, err = m.modelDefinitions.Get(ctx, hash); err != nil {
+		return "", errors.Wrap(err, "verifying stored model definition")
+	}
+	return hash, nil
+}
+
+// migrateExperimentModelDefinitions moves the model definitions that experiments keep in the
+// experiments table to the model definition storage.
+func (m *Master) migrateExperimentModelDefinitions(
+	ctx context.Context, batchSize int, migration *modelDefinitionMigration,
+) error {
+	for {
+		ids, err := m.db.InlineModelDefinitionExperimentIDs(batchSize)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		for _, id := range ids {
+			content, _, err := m.db.ExperimentModelDefinition(id)
+			if err != nil {
+				return err
+			}
+			// The model definition was replaced or moved since the batch was listed.
+			if content == nil {
+				continue
+			}
+			hash, err := m.verifiedPut(ctx, content)
+			if err != nil {
+				return errors.Wrapf(err, "moving model definition of experiment %d", id)
+			}
+			moved, err := m.db.MoveExperimentModelDefinition(id, hash)
+			if err != nil {
+				return err
+			}
+			if moved {
+				migration.Experiments++
+			}
+		}
+	}
+}
+
+// migrateModelDefinitionBlobs moves the model definitions in the model_definitions table, which
+// postgres storage keeps them in, to the model definition storage.
+func (m *Master) migrateModelDefinitionBlobs(
+	ctx context.Context, batchSize int, migration *modelDefinitionMigration,
+) error {
+	for {
+		hashes, err := m.db.ModelDefinitionBlobHashes(batchSize)
+		if err != nil || len(hashes) == 0 {
+			return err
+		}
+		for _, hash := range hashes {
+			content, err := m.db.ModelDefinitionBlob(hash)
+			if err != nil {
+				return err
+			}
+			if actual := storage.Hash(content); actual != hash {
+				return errors.Errorf(
+					"model definition %s in the database is corrupt: its content has the hash %s",
+					hash, actual)
+			}
+			if _, err = m.verifiedPut(ctx, content); err != nil {
+				return err
+			}
+			if err = m.db.DeleteModelDefinitionBlob(hash); err != nil {
+				return err
+			}
+			migration.Blobs++
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/jsonpatch"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/modelv1"
+)
+
+// The model registry endpoints below are thin wrappers around their gRPC counterparts, so that
+// both APIs behave the same.
+
+// grpcCodeStatuses maps the gRPC status codes returned by the model registry to HTTP statuses.
+var grpcCodeStatuses = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.FailedPrecondition: http.StatusConflict,
+}
+
+// modelRegistryResponse converts the response of a model registry gRPC method to a JSON response.
+// A nil response results in an empty response.
+func modelRegistryResponse(resp proto.Message, err error) (interface{}, error) {
+	switch {
+	case err != nil:
+		if s, ok := status.FromError(err); ok {
+			if code, ok := grpcCodeStatuses[s.Code()]; ok {
+				return nil, echo.NewHTTPError(code, s.Message())
+			}
+		}
+		return nil, err
+	case resp == nil:
+		return nil, nil
+	}
+	return protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(resp)
+}
+
+func (m *Master) getModels(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name         *string `query:"name"`
+		Description  *string `query:"description"`
+		ShowArchived *bool   `query:"show_archived"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	req := &apiv1.GetModelsRequest{Labels: c.QueryParams()["label"]}
+	if args.Name != nil {
+		req.Name = *args.Name
+	}
+	if args.Description != nil {
+		req.Description = *args.Description
+	}
+	if args.ShowArchived != nil {
+		req.ShowArchived = *args.ShowArchived
+	}
+	return modelRegistryResponse((&apiServer{m: m}).GetModels(c.Request().Context(), req))
+}
+
+func (m *Master) getModel(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name string `path:"model_name"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	return modelRegistryResponse((&apiServer{m: m}).GetModel(
+		c.Request().Context(), &apiv1.GetModelRequest{ModelName: args.Name}))
+}
+
+func (m *Master) postModel(c echo.Context) (interface{}, error) {
+	body := struct {
+		Name string `json:"name"`
+		modelFields
+	}{}
+	if err := c.Bind(&body); err != nil {
+		return nil, err
+	}
+	return modelRegistryResponse((&apiServer{m: m}).PostModel(
+		c.Request().Context(), &apiv1.PostModelRequest{Model: &modelv1.Model{
+			Name:        body.Name,
+			Description: body.Description,
+			Metadata:    protoutils.ToStruct(body.Metadata),
+			Labels:      body.Labels,
+		}}))
+}
+
+func (m *Master) patchModel(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name string `path:"model_name"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	a := &apiServer{m: m}
+	getResp, err := a.GetModel(c.Request().Context(), &apiv1.GetModelRequest{ModelName: args.Name})
+	if err != nil {
+		return modelRegistryResponse(nil, err)
+	}
+
+	current := modelFields{
+		Description: getResp.Model.Description,
+		Labels:      getResp.Model.Labels,
+	}
+	if current.Metadata, err = structToMap(getResp.Model.Metadata); err != nil {
+		return nil, err
+	}
+	var patched modelFields
+	if err = mergePatchRequest(c, current, &patched); err != nil {
+		return nil, err
+	}
+	return modelRegistryResponse(a.PatchModel(
+		c.Request().Context(), &apiv1.PatchModelRequest{Model: &modelv1.Model{
+			Name:        args.Name,
+			Description: patched.Description,
+			Metadata:    protoutils.ToStruct(patched.Metadata),
+			Labels:      patched.Labels,
+		}}))
+}
+
+func (m *Master) postModelArchive(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name string `path:"model_name"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	_, err := (&apiServer{m: m}).ArchiveModel(
+		c.Request().Context(), &apiv1.ArchiveModelRequest{ModelName: args.Name})
+	return modelRegistryResponse(nil, err)
+}
+
+func (m *Master) postModelUnarchive(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name string `path:"model_name"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	_, err := (&apiServer{m: m}).UnarchiveModel(
+		c.Request().Context(), &apiv1.UnarchiveModelRequest{ModelName: args.Name})
+	return modelRegistryResponse(nil, err)
+}
+
+func (m *Master) getModelVersions(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name string `path:"model_name"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	return modelRegistryResponse((&apiServer{m: m}).GetModelVersions(
+		c.Request().Context(), &apiv1.GetModelVersionsRequest{ModelName: args.Name}))
+}
+
+func (m *Master) getModelVersion(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name    string `path:"model_name"`
+		Version int    `path:"model_version"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	return modelRegistryResponse((&apiServer{m: m}).GetModelVersion(
+		c.Request().Context(), &apiv1.GetModelVersionRequest{
+			ModelName:    args.Name,
+			ModelVersion: int32(args.Version),
+		}))
+}
+
+func (m *Master) postModelVersion(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name string `path:"model_name"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	body := struct {
+		CheckpointUUID string `json:"checkpoint_uuid"`
+	}{}
+	if err := c.Bind(&body); err != nil {
+		return nil, err
+	}
+	return modelRegistryResponse((&apiServer{m: m}).PostModelVersion(
+		c.Request().Context(), &apiv1.PostModelVersionRequest{
+			ModelName:      args.Name,
+			CheckpointUuid: body.CheckpointUUID,
+		}))
+}
+
+func (m *Master) patchModelVersion(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name    string `path:"model_name"`
+		Version int    `path:"model_version"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	a := &apiServer{m: m}
+	getResp, err := a.GetModelVersion(c.Request().Context(), &apiv1.GetModelVersionRequest{
+		ModelName:    args.Name,
+		ModelVersion: int32(args.Version),
+	})
+	if err != nil {
+		return modelRegistryResponse(nil, err)
+	}
+
+	current := modelVersionFields{Labels: getResp.ModelVersion.Labels}
+	if current.Metadata, err = structToMap(getResp.ModelVersion.Metadata); err != nil {
+		return nil, err
+	}
+	var patched modelVersionFields
+	if err = mergePatchRequest(c, current, &patched); err != nil {
+		return nil, err
+	}
+	return modelRegistryResponse(a.PatchModelVersion(
+		c.Request().Context(), &apiv1.PatchModelVersionRequest{
+			ModelName:    args.Name,
+			ModelVersion: int32(args.Version),
+			Metadata:     protoutils.ToStruct(patched.Metadata),
+			Labels:       patched.Labels,
+		}))
+}
+
+func (m *Master) deleteModelVersion(c echo.Context) (interface{}, error) {
+	args := struct {
+		Name    string `path:"model_name"`
+		Version int    `path:"model_version"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	_, err := (&apiServer{m: m}).DeleteModelVersion(
+		c.Request().Context(), &apiv1.DeleteModelVersionRequest{
+			ModelName:    args.Name,
+			ModelVersion: int32(args.Version),
+		})
+	return modelRegistryResponse(nil, err)
+}
+
+// modelFields are the fields of a model that users can change.
+type modelFields struct {
+	Description string                 `json:"description"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Labels      []string               `json:"labels"`
+}
+
+// modelVersionFields are the fields of a model version that users can change.
+type modelVersionFields struct {
+	Metadata map[string]interface{} `json:"metadata"`
+	Labels   []string               `json:"labels"`
+}
+
+// mergePatchRequest applies the JSON Merge Patch in the body of the request to current, and
+// decodes the result into patched.
+func mergePatchRequest(c echo.Context, current, patched interface{}) error {
+	if contentType := c.Request().Header.Get(echo.HeaderContentType); contentType !=
+		"application/merge-patch+json" {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			"only `application/merge-patch+json` requests are supported")
+	}
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+	var patch interface{}
+	if err = json.Unmarshal(body, &patch); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	bs, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err = json.Unmarshal(bs, &doc); err != nil {
+		return err
+	}
+	if bs, err = json.Marshal(jsonpatch.MergePatch(doc, patch)); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(patched); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return nil
+}
+
+// structToMap converts metadata from its Protobuf form.
+func structToMap(s *structpb.Struct) (map[string]interface{}, error) {
+	bs, err := protojson.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	return m, json.Unmarshal(bs, &m)
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/modelv1"
+)
+
+func TestModelRegistryResponse(t *testing.T) {
+	resp, err := modelRegistryResponse(&apiv1.GetModelResponse{
+		Model: &modelv1.Model{Name: "production"},
+	}, nil)
+	assert.NilError(t, err)
+	var parsed map[string]map[string]interface{}
+	assert.NilError(t, json.Unmarshal(resp.([]byte), &parsed))
+	assert.Equal(t, parsed["model"]["name"], "production")
+	_, ok := parsed["model"]["last_updated_time"]
+	assert.Assert(t, ok, "fields are named like in the gRPC API")
+
+	_, err = modelRegistryResponse(
+		(*apiv1.GetModelResponse)(nil), status.Error(codes.AlreadyExists, "exists"))
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.Assert(t, ok)
+	assert.Equal(t, httpErr.Code, http.StatusConflict)
+	assert.Equal(t, httpErr.Message, "exists")
+}
+
+func mergePatchContext(contentType, body string) echo.Context {
+	req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, contentType)
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestMergePatchRequest(t *testing.T) {
+	current := modelFields{
+		Description: "old",
+		Metadata:    map[string]interface{}{"team": "vision", "stage": "dev"},
+		Labels:      []string{"a"},
+	}
+
+	var patched modelFields
+	c := mergePatchContext("application/merge-patch+json",
+		`{"metadata": {"stage": null, "owner": "ml"}, "labels": ["a", "b"]}`)
+	assert.NilError(t, mergePatchRequest(c, current, &patched))
+	assert.DeepEqual(t, patched, modelFields{
+		Description: "old",
+		Metadata:    map[string]interface{}{"team": "vision", "owner": "ml"},
+		Labels:      []string{"a", "b"},
+	})
+
+	for _, test := range []struct {
+		contentType string
+		body        string
+		code        int
+	}{
+		{"application/json", `{}`, http.StatusUnsupportedMediaType},
+		{"application/merge-patch+json", `{`, http.StatusBadRequest},
+		{"application/merge-patch+json", `{"labels": "a"}`, http.StatusUnprocessableEntity},
+		{"application/merge-patch+json", `{"name": "renamed"}`, http.StatusUnprocessableEntity},
+	} {
+		err := mergePatchRequest(mergePatchContext(test.contentType, test.body), current, &patched)
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok, test.body)
+		assert.Equal(t, httpErr.Code, test.code, test.body)
+	}
+}
@@ -0,0 +1,11 @@
+package internal
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/internal/proxy"
+)
+
+func (m *Master) getProxyTunnels(c echo.Context) (interface{}, error) {
+	return m.system.Ask(m.proxy, proxy.GetTunnels{}).Get(), nil
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+)
+
+// schedulerEventsWriteTimeout is how long writing a message to a scheduler events websocket may
+// take before the client is considered gone.
+const schedulerEventsWriteTimeout = 10 * time.Second
+
+// schedulerEventsWebSocket streams the decisions of the schedulers of all resource pools as JSON
+// messages: tasks being allocated resources, denied them, or preempted, with the reasons. Clients
+// that fall behind are disconnected with a close message.
+func (m *Master) schedulerEventsWebSocket(socket *websocket.Conn, c echo.Context) error {
+	defer func() {
+		_ = socket.Close()
+	}()
+
+	sub, ok := m.system.Ask(m.rm, resourcemanagers.SubscribeSchedulingDecisions{}).
+		Get().(*resourcemanagers.DecisionSubscription)
+	if !ok {
+		return errors.New("scheduling decisions are not available")
+	}
+	defer m.system.Tell(m.rm, resourcemanagers.UnsubscribeSchedulingDecisions{Subscription: sub})
+
+	c.Logger().Infof("streaming scheduling decisions to %v", socket.RemoteAddr())
+
+	// Clients only read, but reading is how the close of the websocket by the client is noticed.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := socket.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case decision, ok := <-sub.Decisions:
+			if !ok {
+				if sub.Overflowed {
+					_ = socket.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseTryAgainLater,
+							"the client fell too far behind; reconnect to resume"),
+						time.Now().Add(schedulerEventsWriteTimeout))
+				}
+				return nil
+			}
+			_ = socket.SetWriteDeadline(time.Now().Add(schedulerEventsWriteTimeout))
+			err = socket.WriteJSON(decision)
+		case <-keepAlive.C:
+			err = socket.WriteControl(
+				websocket.PingMessage, nil, time.Now().Add(schedulerEventsWriteTimeout))
+		case <-closed:
+			return nil
+		}
+		// Errors writing to the websocket mean that the client went away.
+		if err != nil {
+			return nil
+		}
+	}
+}
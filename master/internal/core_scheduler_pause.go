@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// schedulerStatus is the response of the scheduler endpoints.
+type schedulerStatus struct {
+	Paused bool `json:"paused"`
+	*model.SchedulerPause
+	// PendingTasks is the number of tasks that are waiting for resources.
+	PendingTasks int `json:"pending_tasks"`
+}
+
+// currentSchedulerPause returns the pause of the scheduler, or nil if it is not paused.
+func (m *Master) currentSchedulerPause() *model.SchedulerPause {
+	m.schedulerPauseLock.RLock()
+	defer m.schedulerPauseLock.RUnlock()
+	return m.schedulerPause
+}
+
+// loadSchedulerPause restores the pause of the scheduler from before the master restarted.
+func (m *Master) loadSchedulerPause() error {
+	pause, err := m.db.SchedulerPause()
+	if err != nil {
+		return err
+	}
+	if pause != nil {
+		log.Infof("scheduler is paused since %s", pause.StartTime)
+		m.schedulerPause = pause
+		m.system.Tell(m.rm, resourcemanagers.SetSchedulerPaused{Paused: true})
+	}
+	return nil
+}
+
+// schedulerStatus reports the given pause of the scheduler along with the tasks that it holds up.
+func (m *Master) schedulerStatus(pause *model.SchedulerPause) (interface{}, error) {
+	result, err := m.awaitResponse(
+		m.system.Ask(m.rm, resourcemanagers.GetTaskSummaries{}), taskSummariesAskTimeout)
+	if err != nil {
+		return nil, err
+	}
+	summaries, ok := result.(map[resourcemanagers.TaskID]resourcemanagers.TaskSummary)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get task summaries")
+	}
+	status := schedulerStatus{Paused: pause != nil, SchedulerPause: pause}
+	for _, summary := range summaries {
+		if len(summary.Containers) == 0 {
+			status.PendingTasks++
+		}
+	}
+	return status, nil
+}
+
+func (m *Master) getSchedulerStatus(c echo.Context) (interface{}, error) {
+	return m.schedulerStatus(m.currentSchedulerPause())
+}
+
+func (m *Master) postSchedulerPause(c echo.Context) (interface{}, error) {
+	m.schedulerPauseLock.Lock()
+	defer m.schedulerPauseLock.Unlock()
+	// Pausing a paused scheduler keeps the original pause.
+	if m.schedulerPause == nil {
+		pause := &model.SchedulerPause{
+			StartTime: time.Now().UTC(),
+			UserID:    c.(*context.DetContext).MustGetUser().ID,
+		}
+		if err := m.db.PauseScheduler(pause); err != nil {
+			return nil, err
+		}
+		m.schedulerPause = pause
+		m.system.Tell(m.rm, resourcemanagers.SetSchedulerPaused{Paused: true})
+		log.Info("scheduler paused")
+	}
+	return m.schedulerStatus(m.schedulerPause)
+}
+
+func (m *Master) postSchedulerResume(c echo.Context) (interface{}, error) {
+	m.schedulerPauseLock.Lock()
+	defer m.schedulerPauseLock.Unlock()
+	if m.schedulerPause != nil {
+		if err := m.db.ResumeScheduler(); err != nil {
+			return nil, err
+		}
+		m.schedulerPause = nil
+		m.system.Tell(m.rm, resourcemanagers.SetSchedulerPaused{Paused: false})
+		log.Info("scheduler resumed")
+	}
+	return m.schedulerStatus(nil)
+}
@@ -1,12 +1,21 @@
 package internal
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/labstack/echo"
-	log "github.com/sirupsen/logrus"
+	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/metrics"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/searcher"
@@ -17,7 +26,7 @@ func (m *Master) getSearcherPreview(c echo.Context) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	config := model.DefaultExperimentConfig(&m.config.TaskContainerDefaults)
+	config := model.DefaultExperimentConfig(&m.currentConfig().TaskContainerDefaults)
 	if uerr := yaml.Unmarshal(body, &config); uerr != nil {
 		return nil, uerr
 	}
@@ -30,12 +39,174 @@ func (m *Master) getSearcherPreview(c echo.Context) (interface{}, error) {
 	return searcher.Simulate(s, nil, searcher.RandomValidation, true, config.Searcher.Metric)
 }
 
-// cleanUpSearcherEvents deletes all searcher events for terminal state experiments from
-// the database.
-func (m *Master) cleanUpSearcherEvents() {
-	log.Info("deleting all searcher events for terminal state experiments")
-	err := m.db.DeleteSearcherEventsForTerminalStateExperiments()
+// Messages handled by the searcher events cleaner.
+type (
+	searcherEventsCleanupTick struct{}
+	cleanUpSearcherEvents     struct{}
+)
+
+// searcherEventsCleaner periodically deletes the searcher events of terminal state experiments
+// once their retention window has passed. Searcher events of non-terminal experiments are never
+// deleted, since they are needed to restore those experiments after a master restart.
+type searcherEventsCleaner struct {
+	db      *db.PgDB
+	metrics *metrics.Registry
+	config  SearcherEventsConfig
+}
+
+// Receive implements the actor.Actor interface.
+func (s *searcherEventsCleaner) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		s.metrics.SetFunc("det_searcher_events_rows",
+			"Estimated number of rows in the searcher_events table.",
+			func() (float64, error) {
+				count, err := s.db.SearcherEventsRowCount()
+				return float64(count), err
+			})
+		actors.NotifyAfter(ctx, 0, searcherEventsCleanupTick{})
+
+	case searcherEventsCleanupTick:
+		// Log the error but carry on so that the next pass is still scheduled.
+		_, _ = s.cleanUp(ctx)
+		if s.config.CleanupInterval > 0 {
+			actors.NotifyAfter(ctx, time.Duration(s.config.CleanupInterval)*time.Second,
+				searcherEventsCleanupTick{})
+		}
+
+	case cleanUpSearcherEvents:
+		deleted, err := s.cleanUp(ctx)
+		if err != nil {
+			ctx.Respond(err)
+		} else {
+			ctx.Respond(deleted)
+		}
+
+	case actor.PostStop:
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (s *searcherEventsCleaner) cleanUp(ctx *actor.Context) (int64, error) {
+	retention := time.Duration(s.config.Retention) * time.Second
+	deleted, err := s.db.DeleteSearcherEventsForTerminalStateExperiments(retention)
+	if err != nil {
+		ctx.Log().WithError(err).Error("cannot delete searcher events")
+		return 0, err
+	}
+	ctx.Log().Infof("deleted %d searcher events for terminal state experiments", deleted)
+	s.metrics.Set("det_searcher_events_last_cleanup_timestamp_seconds",
+		"Unix time of the last successful searcher events cleanup pass.",
+		float64(time.Now().Unix()))
+	s.metrics.Set("det_searcher_events_last_cleanup_deleted_rows",
+		"Number of searcher events deleted by the last cleanup pass.", float64(deleted))
+	return deleted, nil
+}
+
+func (m *Master) postCleanupSearcherEvents(c echo.Context) (interface{}, error) {
+	resp := m.system.AskAt(actor.Addr("searcherEventsCleaner"), cleanUpSearcherEvents{})
+	// A cleanup pass over a large table may take a while, so wait for it to finish.
+	switch result := resp.Get().(type) {
+	case int64:
+		return map[string]int64{"deleted": result}, nil
+	case error:
+		return nil, result
+	default:
+		return nil, errors.New("searcher events cleaner is not running")
+	}
+}
+
+// askExperiment relays a message, e.g., from the external controller of a custom searcher, to the
+// experiment actor and returns its response. Errors that the experiment responds with are
+// treated as invalid requests.
+func (m *Master) askExperiment(experimentID int, msg interface{}) (interface{}, error) {
+	resp := m.system.AskAt(actor.Addr("experiments", experimentID), msg)
+	if resp.Source() == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("active experiment not found: %d", experimentID))
+	}
+	result, err := m.awaitResponse(resp, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := result.(error); ok {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return result, nil
+}
+
+func (m *Master) getCustomSearcherEvents(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int  `path:"experiment_id"`
+		Acknowledged *int `query:"acknowledged"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	msg := getCustomSearcherEvents{}
+	if args.Acknowledged != nil {
+		msg.acknowledged = *args.Acknowledged
+	}
+	events, err := m.askExperiment(args.ExperimentID, msg)
 	if err != nil {
-		log.WithError(err).Errorf("cannot delete searcher events")
+		return nil, err
+	}
+	return map[string]interface{}{"events": events}, nil
+}
+
+func (m *Master) postCustomSearcherOperations(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	body := struct {
+		Operations []searcher.CustomOperation `json:"operations"`
+	}{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("invalid searcher operations: %s", err))
+	}
+	if _, err := m.askExperiment(
+		args.ExperimentID, postCustomSearcherOperations{operations: body.Operations}); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (m *Master) getExperimentMaxConcurrentTrials(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	maxConcurrentTrials, err := m.askExperiment(args.ExperimentID, getMaxConcurrentTrials{})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"max_concurrent_trials": maxConcurrentTrials}, nil
+}
+
+// getExperimentSearcherState returns a snapshot of the searcher of an active experiment: its
+// progress, the trials and operations it waits on, and the rungs of successive halving searches.
+func (m *Master) getExperimentSearcherState(c echo.Context) (interface{}, error) {
+	args := struct {
+		ExperimentID int `path:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	state, err := m.askExperiment(args.ExperimentID, getSearcherState{})
+	if err != nil {
+		return nil, err
 	}
+	return struct {
+		ExperimentID int `json:"experiment_id"`
+		searcher.State
+	}{args.ExperimentID, state.(searcher.State)}, nil
 }
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+)
+
+// dbWaitStatus is the response of /healthz while the master waits for the database.
+const dbWaitStatus = "waiting for database"
+
+// setupDB connects to the database, migrates it and reads the cluster ID, retrying with backoff
+// until db.startup_timeout has elapsed, so that the master waits for a database that is starting
+// along with it.
+func (m *Master) setupDB() error {
+	deadline := time.Now().Add(time.Duration(m.config.DB.StartupTimeout) * time.Second)
+	for tries := 1; ; tries++ {
+		err := m.trySetupDB(time.Until(deadline))
+		if err == nil {
+			return nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return err
+		}
+		backoff := db.StartupBackoff(tries)
+		if backoff > remaining {
+			backoff = remaining
+		}
+		log.WithError(err).Warnf("could not set up database, retrying in %v", backoff)
+		time.Sleep(backoff)
+	}
+}
+
+func (m *Master) trySetupDB(timeout time.Duration) error {
+	pgDB, err := db.Setup(&m.config.DB, timeout)
+	if err != nil {
+		return err
+	}
+	clusterID, err := pgDB.GetClusterID()
+	if err != nil {
+		_ = pgDB.Close()
+		return errors.Wrap(err, "could not fetch cluster id from database")
+	}
+	m.db, m.ClusterID = pgDB, clusterID
+	return nil
+}
+
+// startDegradedServer serves a minimal HTTP server on the port of the master while it waits for
+// the database, so that orchestrators and users can tell that the master is alive: /healthz
+// responds with 503 Service Unavailable and /info reports that the master is degraded. The
+// returned function stops the server and frees the port for the full servers.
+func (m *Master) startDegradedServer(cert *tls.Certificate) (func(), error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", m.config.Port))
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{*cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
+
+	server := &http.Server{Handler: m.degradedHandler()}
+	go func() {
+		if serr := server.Serve(listener); serr != nil && serr != http.ErrServerClosed {
+			log.WithError(serr).Error("degraded HTTP server failed")
+		}
+	}()
+	log.Infof("answering health checks on port %d while waiting for the database", m.config.Port)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if serr := server.Shutdown(ctx); serr != nil {
+			log.WithError(serr).Warn("failed to stop degraded HTTP server")
+		}
+	}, nil
+}
+
+// degradedHandler handles the requests to the master while it waits for the database.
+func (m *Master) degradedHandler() http.Handler {
+	writeJSON := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": dbWaitStatus})
+	})
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, aproto.MasterInfo{
+			Version:     m.Version,
+			MasterID:    m.MasterID,
+			ClusterName: m.currentConfig().ClusterName,
+			MasterURL:   m.MasterURL,
+			Degraded:    dbWaitStatus,
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"message": dbWaitStatus})
+	})
+	return mux
+}
+
+// getHealthz reports that the master is up, once it has connected to the database.
+func (m *Master) getHealthz(c echo.Context) (interface{}, error) {
+	return map[string]string{"status": "ok"}, nil
+}
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+)
+
+func TestDegradedHandler(t *testing.T) {
+	m := &Master{MasterID: "master", Version: "1.0.0", config: &Config{ClusterName: "cluster"}}
+	server := httptest.NewServer(m.degradedHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	assert.NilError(t, err)
+	assert.NilError(t, resp.Body.Close())
+	assert.Equal(t, resp.StatusCode, http.StatusServiceUnavailable)
+
+	resp, err = http.Get(server.URL + "/experiments")
+	assert.NilError(t, err)
+	assert.NilError(t, resp.Body.Close())
+	assert.Equal(t, resp.StatusCode, http.StatusServiceUnavailable)
+
+	resp, err = http.Get(server.URL + "/info")
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	var info aproto.MasterInfo
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&info))
+	assert.DeepEqual(t, info, aproto.MasterInfo{
+		Version:     "1.0.0",
+		MasterID:    "master",
+		ClusterName: "cluster",
+		Degraded:    dbWaitStatus,
+	})
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// Headers with which proxies pass the host and path prefix that they serve the master under.
+const (
+	headerXForwardedHost   = "X-Forwarded-Host"
+	headerXForwardedPrefix = "X-Forwarded-Prefix"
+)
+
+// swaggerServer is where clients reach the API, as seen through any proxies in front of the master.
+type swaggerServer struct {
+	scheme   string
+	host     string
+	basePath string
+}
+
+// requestSwaggerServer returns where the client of the request reached the API.
+func requestSwaggerServer(c echo.Context) swaggerServer {
+	firstValue := func(header string) string {
+		return strings.TrimSpace(strings.Split(c.Request().Header.Get(header), ",")[0])
+	}
+	server := swaggerServer{
+		scheme:   c.Scheme(),
+		host:     firstValue(headerXForwardedHost),
+		basePath: path.Clean("/" + firstValue(headerXForwardedPrefix)),
+	}
+	if server.host == "" {
+		server.host = c.Request().Host
+	}
+	return server
+}
+
+// rewriteSwagger points the swagger spec at the server so that requests made from the API
+// documentation reach the master.
+func rewriteSwagger(spec map[string]interface{}, server swaggerServer) {
+	spec["host"] = server.host
+	spec["basePath"] = server.basePath
+	spec["schemes"] = []string{server.scheme}
+	if _, ok := spec["servers"]; ok {
+		url := server.scheme + "://" + server.host + strings.TrimSuffix(server.basePath, "/")
+		spec["servers"] = []map[string]string{{"url": url}}
+	}
+}
+
+// swaggerHandler serves the swagger spec at specPath pointed at the server that each request
+// reached.
+func swaggerHandler(specPath string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		data, err := ioutil.ReadFile(specPath) //nolint:gosec
+		if os.IsNotExist(err) {
+			return echo.ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		var spec map[string]interface{}
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return err
+		}
+		rewriteSwagger(spec, requestSwaggerServer(c))
+		return c.JSON(http.StatusOK, spec)
+	}
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+)
+
+func TestRequestSwaggerServer(t *testing.T) {
+	newContext := func(headers map[string]string) echo.Context {
+		req := httptest.NewRequest(http.MethodGet, "http://master:8080/api/v1/api.swagger.json", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return echo.New().NewContext(req, httptest.NewRecorder())
+	}
+
+	assert.Equal(t, requestSwaggerServer(newContext(nil)),
+		swaggerServer{scheme: "http", host: "master:8080", basePath: "/"})
+	assert.Equal(t, requestSwaggerServer(newContext(map[string]string{
+		echo.HeaderXForwardedProto: "https",
+		headerXForwardedHost:       "det.example.com, proxy.internal",
+		headerXForwardedPrefix:     "determined/",
+	})), swaggerServer{scheme: "https", host: "det.example.com", basePath: "/determined"})
+}
+
+func TestRewriteSwagger(t *testing.T) {
+	server := swaggerServer{scheme: "https", host: "det.example.com", basePath: "/determined"}
+
+	spec := map[string]interface{}{"host": "localhost", "paths": map[string]interface{}{}}
+	rewriteSwagger(spec, server)
+	assert.Equal(t, spec["host"], "det.example.com")
+	assert.Equal(t, spec["basePath"], "/determined")
+	assert.DeepEqual(t, spec["schemes"], []string{"https"})
+	_, ok := spec["servers"]
+	assert.Assert(t, !ok)
+
+	spec = map[string]interface{}{"servers": []interface{}{}}
+	rewriteSwagger(spec, server)
+	assert.DeepEqual(t, spec["servers"],
+		[]map[string]string{{"url": "https://det.example.com/determined"}})
+}
@@ -1,16 +1,19 @@
 package internal
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo"
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 func (m *Master) getTasks(c echo.Context) (interface{}, error) {
-	return m.system.Ask(m.rm, resourcemanagers.GetTaskSummaries{}).Get(), nil
+	return m.awaitResponse(m.system.Ask(m.rm, resourcemanagers.GetTaskSummaries{}),
+		taskSummariesAskTimeout)
 }
 
 func (m *Master) getTask(c echo.Context) (interface{}, error) {
@@ -22,8 +25,82 @@ func (m *Master) getTask(c echo.Context) (interface{}, error) {
 	}
 	id := resourcemanagers.TaskID(args.TaskID)
 	resp := m.system.Ask(m.rm, resourcemanagers.GetTaskSummary{ID: &id})
+	if _, err := m.awaitResponse(resp, taskSummariesAskTimeout); err != nil {
+		return nil, err
+	}
 	if resp.Empty() {
 		return nil, echo.NewHTTPError(http.StatusNotFound, "task not found: %s", args.TaskID)
 	}
-	return resp.Get(), nil
+	var summary resourcemanagers.TaskSummary
+	switch s := resp.Get().(type) {
+	case resourcemanagers.TaskSummary:
+		summary = s
+	case *resourcemanagers.TaskSummary:
+		summary = *s
+	}
+
+	// A task that is running may have lost earlier allocations, e.g., to preemption.
+	allocations, err := m.db.TaskAllocations(args.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	var reason *model.FailureReason
+	if len(allocations) > 0 {
+		reason = allocations[len(allocations)-1].FailureReason
+	}
+	return struct {
+		resourcemanagers.TaskSummary
+		FailureReason *model.FailureReason `json:"failure_reason"`
+	}{summary, reason}, nil
+}
+
+// taskFailure is the failure of the latest allocation of a task.
+type taskFailure struct {
+	Reason  *model.FailureReason `json:"reason"`
+	Type    string               `json:"type"`
+	Message string               `json:"message"`
+}
+
+// getTaskContainerLogs returns the logs of the lifecycle of the containers of a task, e.g., of
+// pulling their images, along with its allocations and the failure of the latest one, which
+// explain tasks that fail before they start.
+func (m *Master) getTaskContainerLogs(c echo.Context) (interface{}, error) {
+	args := struct {
+		TaskID string `path:"task_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	allocations, err := m.db.TaskAllocations(args.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := m.db.TaskContainerLogs(args.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(allocations) == 0 && len(logs) == 0 {
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("task not found: %s", args.TaskID))
+	}
+
+	var failure *taskFailure
+	if len(allocations) > 0 {
+		latest := allocations[len(allocations)-1]
+		if latest.FailureType != nil || latest.FailureReason != nil {
+			failure = &taskFailure{Reason: latest.FailureReason}
+			if latest.FailureType != nil {
+				failure.Type = *latest.FailureType
+			}
+			if latest.FailureMessage != nil {
+				failure.Message = *latest.FailureMessage
+			}
+		}
+	}
+	return struct {
+		TaskID      string                    `json:"task_id"`
+		Failure     *taskFailure              `json:"failure"`
+		Allocations []model.AllocationSession `json:"allocations"`
+		Logs        []model.TaskContainerLog  `json:"logs"`
+	}{args.TaskID, failure, allocations, logs}, nil
 }
@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/master/version"
+)
+
+func TestIsWebUIAPIPath(t *testing.T) {
+	pattern := regexp.MustCompile(`^(v\d+|graphql)(/|$)`)
+	for path, expected := range map[string]bool{
+		"api":                    true,
+		"api/v1/experiments":     true,
+		"experiments/1":          false,
+		"apiary":                 false,
+		"v2/experiments":         true,
+		"graphql":                true,
+		"dashboard/graphql-tips": false,
+	} {
+		assert.Equal(t, isWebUIAPIPath(path, pattern), expected, path)
+	}
+	assert.Assert(t, !isWebUIAPIPath("v2/experiments", nil))
+}
+
+func TestWebUIHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webui")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The secrets are in a sibling of the react root whose name starts with the same prefix.
+	react := filepath.Join(dir, "react")
+	secrets := filepath.Join(dir, "react-secrets")
+	for _, d := range []string{filepath.Join(react, "static"), secrets} {
+		assert.NilError(t, os.MkdirAll(d, 0700))
+	}
+	write := func(file, content string) {
+		assert.NilError(t, ioutil.WriteFile(file, []byte(content), 0600))
+	}
+	write(filepath.Join(react, "index.html"), "index")
+	write(filepath.Join(react, "static", "main.js"), "main")
+	write(filepath.Join(secrets, "key"), "secret")
+	assert.NilError(t, os.Symlink(filepath.Join(react, "static", "main.js"),
+		filepath.Join(react, "latest.js")))
+	assert.NilError(t, os.Symlink(filepath.Join(secrets, "key"), filepath.Join(react, "key")))
+	assert.NilError(t, os.Symlink(secrets, filepath.Join(react, "linked")))
+
+	e := echo.New()
+	e.GET(webuiBaseRoute+"/*", webuiHandler(react, nil))
+
+	tests := []struct {
+		path     string
+		code     int
+		expected string
+	}{
+		{"/det/static/main.js", http.StatusOK, "main"},
+		{"/det/latest.js", http.StatusOK, "main"},
+		{"/det/experiments/1", http.StatusOK, "index"},
+		{"/det/static/missing.js", http.StatusNotFound, ""},
+		{"/det/api/v1/missing", http.StatusNotFound, ""},
+		{"/det/key", http.StatusForbidden, ""},
+		{"/det/linked/key", http.StatusForbidden, ""},
+		{"/det/../react-secrets/key", http.StatusForbidden, ""},
+		{"/det/..%2freact-secrets%2fkey", http.StatusForbidden, ""},
+		{"/det/static%2f..%2f..%2freact-secrets%2fkey", http.StatusForbidden, ""},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL, err = url.Parse(tc.path)
+		assert.NilError(t, err)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, rec.Code, tc.code, tc.path)
+		if tc.expected != "" {
+			assert.Equal(t, rec.Body.String(), tc.expected, tc.path)
+		}
+	}
+}
+
+func TestWebUIConfigValidation(t *testing.T) {
+	assert.NilError(t, check.Validate(WebUIConfig{}))
+	assert.NilError(t, check.Validate(WebUIConfig{APIPathPattern: `^v\d+/`}))
+	assert.ErrorContains(t, check.Validate(WebUIConfig{APIPathPattern: `(`}),
+		"api_path_pattern must be a valid regular expression")
+}
+
+func TestPprofRoutes(t *testing.T) {
+	e := echo.New()
+	e.Pre(api.TrailingSlashes(api.TrailingSlashConfig{WebRoutes: []string{pprofRoute}}))
+	// The gRPC gateway is mounted under /api/v1, so it must not take requests for profiles.
+	e.Any("/api/v1/*", func(c echo.Context) error { return c.NoContent(http.StatusTeapot) })
+	registerPprofRoutes(e, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("Admin") == "" {
+				return echo.NewHTTPError(http.StatusForbidden)
+			}
+			return next(c)
+		}
+	})
+
+	get := func(path string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if admin {
+			req.Header.Set("Admin", "true")
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, path := range []string{
+		"/debug/pprof/", "/debug/pprof/goroutine?debug=2", "/debug/pprof/cmdline",
+		"/debug/pprof/heap", "/debug/pprof/symbol",
+	} {
+		assert.Equal(t, get(path, false).Code, http.StatusForbidden, path)
+		assert.Equal(t, get(path, true).Code, http.StatusOK, path)
+	}
+
+	// The index page links to profiles relative to itself, so it needs a trailing slash.
+	rec := get("/debug/pprof", true)
+	assert.Equal(t, rec.Code, http.StatusMovedPermanently)
+	assert.Equal(t, rec.Header().Get(echo.HeaderLocation), "/debug/pprof/")
+	assert.Assert(t, strings.Contains(get("/debug/pprof/", true).Body.String(),
+		`href="goroutine?debug=2"`))
+	assert.Assert(t, strings.Contains(get("/debug/pprof/goroutine?debug=2", true).Body.String(),
+		"goroutine "))
+}
+
+func TestGatewayClientCertificate(t *testing.T) {
+	cert, ca, err := newGatewayClientCertificate()
+	assert.NilError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	_, err = cert.Leaf.Verify(x509.VerifyOptions{
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NilError(t, err)
+}
+
+func TestLogEntriesSince(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	var entries []*logger.Entry
+	for i := 0; i < 5; i++ {
+		entries = append(entries, &logger.Entry{
+			ID: i, Time: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	ids := func(entries []*logger.Entry) []int {
+		ids := []int{}
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+		return ids
+	}
+
+	assert.DeepEqual(t, ids(logEntriesSince(entries, start.Add(2*time.Minute), -1)), []int{2, 3, 4})
+	assert.DeepEqual(t, ids(logEntriesSince(entries, start.Add(90*time.Second), 2)), []int{3, 4})
+	assert.DeepEqual(t, ids(logEntriesSince(entries, start.Add(-time.Hour), 10)),
+		[]int{0, 1, 2, 3, 4})
+	assert.DeepEqual(t, ids(logEntriesSince(entries, start.Add(time.Hour), -1)), []int{})
+}
+
+func TestGetHarnessWheel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wheels")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	anyWheel := fmt.Sprintf("determined-%s-py3-none-any.whl", version.Version)
+	armWheel := fmt.Sprintf("determined-%s-cp36-linux_aarch64.whl", version.Version)
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "linux-arm64-py3.6"), 0700))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, anyWheel), []byte("any"), 0600))
+	assert.NilError(t, ioutil.WriteFile(
+		filepath.Join(dir, "linux-arm64-py3.6", armWheel), []byte("arm"), 0600))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "determined-0.0.1.whl"), nil, 0600))
+
+	manifest, err := tasks.LoadHarnessManifest(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, manifest.Python, "3.6")
+	assert.DeepEqual(t, manifest.Wheels, map[string][]container.HarnessWheel{
+		"any": {{
+			Name:   anyWheel,
+			SHA256: "d6a7cd2a7371b1a15d543196979ff74fdb027023ebf187d5d329be11055c77fd",
+			Size:   3,
+		}},
+		"linux-arm64-py3.6": {{
+			Name:   armWheel,
+			SHA256: "ddf7ff5ebd9d66ce161466c1c0262430fa04de32b0e420ee3f489e2e2112e386",
+			Size:   3,
+		}},
+	})
+	m := &Master{taskSpec: &tasks.TaskSpec{HarnessPath: dir, HarnessManifest: manifest}}
+	e := echo.New()
+	e.GET("/wheels/:platform/:filename", m.getHarnessWheel)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	rec := get("/wheels/linux-arm64-py3.6/" + armWheel)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Body.String(), "arm")
+	assert.Equal(t, rec.Header().Get("ETag"),
+		`"`+manifest.Wheels["linux-arm64-py3.6"][0].SHA256+`"`)
+	assert.Equal(t, get("/wheels/any/"+anyWheel).Body.String(), "any")
+	// Only wheels in the manifest are served.
+	assert.Equal(t, get("/wheels/any/determined-0.0.1.whl").Code, http.StatusNotFound)
+	assert.Equal(t, get("/wheels/linux-arm64-py3.6/"+anyWheel).Code, http.StatusNotFound)
+	assert.Equal(t, get("/wheels/any/..%2f..%2fetc%2fpasswd").Code, http.StatusNotFound)
+}
+
+func TestMetricsAuth(t *testing.T) {
+	config := DefaultConfig()
+	m := &Master{config: config}
+	e := echo.New()
+	e.GET("/metrics", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, m.metricsAuth(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("Cookie") != "auth=user" {
+				return echo.ErrUnauthorized
+			}
+			return next(c)
+		}
+	}))
+
+	get := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Without a configured token, only authenticated users may read the metrics.
+	assert.Equal(t, get("", ""), http.StatusUnauthorized)
+	assert.Equal(t, get(echo.HeaderAuthorization, "Bearer "), http.StatusUnauthorized)
+	assert.Equal(t, get("Cookie", "auth=user"), http.StatusOK)
+
+	config.MetricsBearerToken = "scrape"
+	assert.Equal(t, get(echo.HeaderAuthorization, "Bearer scrape"), http.StatusOK)
+	assert.Equal(t, get(echo.HeaderAuthorization, "Bearer wrong"), http.StatusUnauthorized)
+	assert.Equal(t, get(echo.HeaderAuthorization, "scrape"), http.StatusUnauthorized)
+	assert.Equal(t, get("Cookie", "auth=user"), http.StatusOK)
+}
+
+func TestBodyLimitFollowsReloads(t *testing.T) {
+	m := &Master{config: DefaultConfig()}
+	m.config.MaxExperimentConfigBytes = 4
+	e := echo.New()
+	e.POST("/experiments", func(c echo.Context) error {
+		_, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	}, m.bodyLimit(func(config *Config) int64 { return config.MaxExperimentConfigBytes }))
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/experiments",
+			strings.NewReader(body)))
+		return rec.Code
+	}
+
+	assert.Equal(t, post("1234"), http.StatusOK)
+	assert.Equal(t, post("12345678"), http.StatusRequestEntityTooLarge)
+
+	reloaded := *m.config
+	reloaded.MaxExperimentConfigBytes = 8
+	m.config = &reloaded
+	assert.Equal(t, post("12345678"), http.StatusOK)
+	assert.Equal(t, post("123456789"), http.StatusRequestEntityTooLarge)
+}
@@ -1,19 +1,42 @@
 package internal
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo"
-	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/ansi"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/model"
 )
 
+// activeTrial returns the actor of the given trial, if its experiment is active.
+func (m *Master) activeTrial(trialID int) (*actor.Ref, error) {
+	trial, err := m.db.TrialByID(trialID)
+	if err != nil {
+		return nil, err
+	}
+	resp := m.system.AskAt(actor.Addr("experiments", trial.ExperimentID),
+		getTrial{trialID: trialID})
+	if resp.Source() == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("active experiment not found: %d", trial.ExperimentID))
+	}
+	if resp.Empty() {
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("active trial not found: %d", trialID))
+	}
+	return resp.Get().(*actor.Ref), nil
+}
+
 func (m *Master) postTrialKill(c echo.Context) (interface{}, error) {
 	args := struct {
 		TrialID int `path:"trial_id"`
@@ -22,29 +45,99 @@ func (m *Master) postTrialKill(c echo.Context) (interface{}, error) {
 		return nil, err
 	}
 
-	trial, err := m.db.TrialByID(args.TrialID)
+	ref, err := m.activeTrial(args.TrialID)
 	if err != nil {
 		return nil, err
 	}
-	resp := m.system.AskAt(actor.Addr("experiments", trial.ExperimentID),
-		getTrial{trialID: args.TrialID})
+	resp := m.system.AskAt(ref.Address(), killTrial{})
 	if resp.Source() == nil {
-		return nil, echo.NewHTTPError(http.StatusNotFound,
-			fmt.Sprintf("active experiment not found: %d", trial.ExperimentID))
-	}
-	if resp.Empty() {
 		return nil, echo.NewHTTPError(http.StatusNotFound,
 			fmt.Sprintf("active trial not found: %d", args.TrialID))
 	}
-	resp = m.system.AskAt(resp.Get().(*actor.Ref).Address(), killTrial{})
+	if _, err := m.awaitResponse(resp, 0); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Lifecycle states of a trial's runner that are not container states.
+const (
+	// runnerIdle means that the trial is not asking for resources, e.g., because its experiment is
+	// paused or it has no more work to do.
+	runnerIdle = "IDLE"
+	// runnerQueued means that the trial is waiting for the resource manager to allocate resources.
+	runnerQueued = "QUEUED"
+)
+
+// containerStateOrder orders container states by how far along their lifecycle they are.
+var containerStateOrder = map[cproto.State]int{
+	cproto.Assigned:   0,
+	cproto.Pulling:    1,
+	cproto.Starting:   2,
+	cproto.Running:    3,
+	cproto.Terminated: 4,
+}
+
+// runnerLifecycleState summarizes the state of a trial's runner: whether it is idle or queued
+// and, once resources are allocated, the state of its least advanced container.
+func runnerLifecycleState(state runnerState) string {
+	switch {
+	case state.TaskID == nil:
+		return runnerIdle
+	case !state.Allocated || len(state.Containers) == 0:
+		return runnerQueued
+	}
+	lifecycle := state.Containers[0].State
+	for _, container := range state.Containers[1:] {
+		if containerStateOrder[container.State] < containerStateOrder[lifecycle] {
+			lifecycle = container.State
+		}
+	}
+	return lifecycle.String()
+}
+
+func (m *Master) getTrialRunnerState(c echo.Context) (interface{}, error) {
+	args := struct {
+		TrialID int `path:"trial_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+
+	ref, err := m.activeTrial(args.TrialID)
+	if err != nil {
+		return nil, err
+	}
+	resp := m.system.AskAt(ref.Address(), getRunnerState{})
 	if resp.Source() == nil {
 		return nil, echo.NewHTTPError(http.StatusNotFound,
 			fmt.Sprintf("active trial not found: %d", args.TrialID))
 	}
-	if _, notTimedOut := resp.GetOrTimeout(defaultAskTimeout); !notTimedOut {
-		return nil, errors.Errorf("attempt to kill trial timed out")
+	result, err := m.awaitResponse(resp, 0)
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	state := result.(runnerState)
+
+	// The resource manager knows which resource pool and agents the task is on.
+	var task interface{}
+	if state.TaskID != nil {
+		summary := m.system.Ask(m.rm, resourcemanagers.GetTaskSummary{ID: state.TaskID})
+		if !summary.Empty() {
+			task = summary.Get()
+		}
+	}
+	return struct {
+		TrialID int         `json:"trial_id"`
+		State   string      `json:"state"`
+		Task    interface{} `json:"task"`
+		runnerState
+	}{
+		TrialID:     args.TrialID,
+		State:       runnerLifecycleState(state),
+		Task:        task,
+		runnerState: state,
+	}, nil
 }
 
 func (m *Master) getTrial(c echo.Context) (interface{}, error) {
@@ -58,55 +151,201 @@ func (m *Master) getTrialDetails(c echo.Context) (interface{}, error) {
 	if err := api.BindArgs(&args, c); err != nil {
 		return nil, err
 	}
-	return m.db.TrialDetailsRaw(args.TrialID)
+	raw, err := m.db.TrialDetailsRaw(args.TrialID)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]json.RawMessage{}
+	if err = json.Unmarshal(raw, &details); err != nil {
+		return nil, err
+	}
+
+	// Active trials report when their containers and agents last sent heartbeats, so that stale
+	// ones can be seen before the trial is considered lost.
+	var heartbeats *trialHeartbeats
+	if ref, tErr := m.activeTrial(args.TrialID); tErr == nil {
+		resp := m.system.AskAt(ref.Address(), getHeartbeats{})
+		if resp.Source() != nil {
+			result, rErr := m.awaitResponse(resp, 0)
+			if rErr != nil {
+				return nil, rErr
+			}
+			current := result.(trialHeartbeats)
+			heartbeats = &current
+		}
+	}
+	if details["heartbeats"], err = json.Marshal(heartbeats); err != nil {
+		return nil, err
+	}
+	return details, nil
 }
 
 func (m *Master) getTrialMetrics(c echo.Context) (interface{}, error) {
-	return m.db.RawQuery("get_trial_metrics", c.Param("trial_id"))
+	names, err := parseMetricNames(c)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := m.db.RawQuery("get_trial_metrics", c.Param("trial_id"))
+	if err != nil || len(names) == 0 {
+		return metrics, err
+	}
+	return filterMetrics(metrics, names)
 }
 
-func (m *Master) getTrialLogs(c echo.Context) error {
+// parseTrialLogsArgs translates the arguments of the deprecated trial logs endpoint, which returns
+// the logs between two IDs or, given tail, the last logs between them. Given since, only logs from
+// that time on are returned.
+func parseTrialLogsArgs(c echo.Context) (db.TrialLogsPage, error) {
 	args := struct {
-		TrialID       int  `path:"trial_id"`
-		GreaterThanID *int `query:"greater_than_id"`
-		LessThanID    *int `query:"less_than_id"`
-		Limit         *int `query:"tail"`
+		GreaterThanID *int    `query:"greater_than_id"`
+		LessThanID    *int    `query:"less_than_id"`
+		Tail          *int    `query:"tail"`
+		Since         *string `query:"since"`
 	}{}
 	if err := api.BindArgs(&args, c); err != nil {
-		return err
+		return db.TrialLogsPage{}, err
 	}
+	since, err := parseSinceArg(args.Since)
+	if err != nil {
+		return db.TrialLogsPage{}, err
+	}
+	return db.TrialLogsPage{
+		GreaterThanID: args.GreaterThanID,
+		LessThanID:    args.LessThanID,
+		Since:         since,
+		Limit:         args.Tail,
+		Tail:          args.Tail != nil,
+	}, nil
+}
 
-	logs, err := m.db.TrialLogsRaw(args.TrialID, args.GreaterThanID, args.LessThanID, args.Limit)
+// parseTrialLogsV2Args translates the arguments of the trial logs endpoint, which returns the logs
+// after the ID given by offset, the logs after the one that a cursor was returned with or,
+// without either, the last logs of the trial. Given since, only logs from that time on are
+// returned. Without an offset, logs are returned in commit order along with cursors.
+func parseTrialLogsV2Args(c echo.Context, trialID int) (db.TrialLogsPage, error) {
+	args := struct {
+		Offset *int    `query:"offset"`
+		Cursor *string `query:"cursor"`
+		Limit  *int    `query:"limit"`
+		Since  *string `query:"since"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return db.TrialLogsPage{}, err
+	}
+	since, err := parseSinceArg(args.Since)
 	if err != nil {
-		return err
+		return db.TrialLogsPage{}, err
+	}
+	page := db.TrialLogsPage{
+		InCommitOrder: args.Offset == nil,
+		GreaterThanID: args.Offset,
+		Since:         since,
+		Limit:         args.Limit,
+		Tail:          args.Offset == nil && args.Cursor == nil && args.Limit != nil,
+	}
+	if args.Cursor != nil {
+		if args.Offset != nil {
+			return db.TrialLogsPage{}, echo.NewHTTPError(
+				http.StatusBadRequest, "offset cannot be specified with a cursor")
+		}
+		after, err := decodeTrialLogsCursor(trialID, *args.Cursor)
+		if err != nil {
+			return db.TrialLogsPage{}, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		page.After = &after
+	}
+	return page, nil
+}
+
+// parseSinceArg parses the since argument of the REST logs endpoints like the gRPC trial logs
+// endpoint does.
+func parseSinceArg(since *string) (*time.Time, error) {
+	if since == nil {
+		return nil, nil
+	}
+	t, err := parseLogsSince(*since, time.Now())
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return &t, nil
+}
+
+// trialLogMessages converts trial log entries to the log messages of the deprecated trial logs
+// endpoint.
+func trialLogMessages(entries []db.TrialLogEntry) []*model.LogMessage {
+	logs := make([]*model.LogMessage, 0, len(entries))
+	for _, entry := range entries {
+		logs = append(logs, &model.LogMessage{
+			ID: entry.ID, Message: model.RawString(entry.Message),
+		})
+	}
+	return logs
+}
+
+// stripTrialLogsANSI removes ANSI escape sequences from the messages of trial log entries, for
+// clients that do not display logs in a terminal.
+func stripTrialLogsANSI(entries []db.TrialLogEntry) {
+	for i := range entries {
+		entries[i].Message = ansi.Strip(entries[i].Message)
 	}
+}
 
-	if len(logs) == 0 {
-		// Return a zero-length slice to ensure JSON serialization as `[]`
-		// rather than null.
-		return c.JSON(http.StatusOK, make([]*model.LogMessage, 0))
+// getTrialLogs is deprecated in favor of getTrialLogsV2, which returns the same entries along with
+// the state of the trial.
+func (m *Master) getTrialLogs(c echo.Context) (interface{}, error) {
+	args := struct {
+		TrialID   int   `path:"trial_id"`
+		StripANSI *bool `query:"strip_ansi"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	page, err := parseTrialLogsArgs(c)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.JSON(http.StatusOK, logs)
+	c.Response().Header().Set("Deprecation", "true")
+	c.Response().Header().Set("Link", fmt.Sprintf(
+		`</trials/%d/logsv2>; rel="successor-version"`, args.TrialID))
+
+	entries, err := m.db.TrialLogEntries(args.TrialID, page)
+	if err != nil {
+		return nil, err
+	}
+	if args.StripANSI != nil && *args.StripANSI {
+		stripTrialLogsANSI(entries)
+	}
+	return trialLogMessages(entries), nil
 }
 
 func (m *Master) getTrialLogsV2(c echo.Context) (interface{}, error) {
-	type Log struct {
-		ID      int    `db:"id" json:"id"`
-		State   string `db:"state" json:"state"`
-		Message string `db:"message" json:"message"`
-	}
-	var logs []Log
-	offset := c.QueryParam("offset")
-	if limit := c.QueryParam("limit"); limit != "" && offset != "" {
-		err := m.db.Query("get_logs_offset_limit", &logs, c.Param("trial_id"), offset, limit)
-		return logs, err
-	} else if limit != "" {
-		err := m.db.Query("get_logs_limit", &logs, c.Param("trial_id"), limit)
-		return logs, err
-	}
-	err := m.db.Query("get_logs", &logs, c.Param("trial_id"), offset)
-	return logs, err
+	args := struct {
+		TrialID   int   `path:"trial_id"`
+		StripANSI *bool `query:"strip_ansi"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	page, err := parseTrialLogsV2Args(c, args.TrialID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.db.TrialLogEntries(args.TrialID, page)
+	if err != nil {
+		return nil, err
+	}
+	if page.InCommitOrder {
+		for i, entry := range entries {
+			entries[i].NextCursor = encodeTrialLogsCursor(args.TrialID, db.TrialLogsCursor{
+				InsertedAt: entry.InsertedAt, ID: entry.ID,
+			})
+		}
+	}
+	if args.StripANSI != nil && *args.StripANSI {
+		stripTrialLogsANSI(entries)
+	}
+	return entries, nil
 }
 
 func (m *Master) trialWebSocket(socket *websocket.Conn, c echo.Context) error {
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+// trialLogsDownloadBatchSize is the number of logs that are loaded at a time while a trial's logs
+// are streamed, so that downloading the logs of large trials takes little memory.
+const trialLogsDownloadBatchSize = 1000
+
+// getTrialLogsDownload streams every log of a trial as plain text, one message per line. The logs
+// are gzipped when format is log.gz, as a .log.gz file, or when the client accepts gzip, as
+// text/plain with a gzip Content-Encoding.
+func (m *Master) getTrialLogsDownload(c echo.Context) error {
+	args := struct {
+		TrialID   int     `path:"trial_id"`
+		Format    *string `query:"format"`
+		StripANSI *bool   `query:"strip_ansi"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return err
+	}
+	format := "log"
+	if args.Format != nil {
+		format = *args.Format
+	}
+	if format != "log" && format != "log.gz" {
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be log or log.gz")
+	}
+	// Check that the trial exists before responding, so that a missing trial gets a proper error.
+	if _, err := m.db.TrialByID(args.TrialID); err != nil {
+		return err
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	header.Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="trial_%d_logs.%s"`, args.TrialID, format))
+	gzipped := true
+	switch {
+	case format == "log.gz":
+		header.Set(echo.HeaderContentType, "application/gzip")
+	case api.AcceptsGzip(c.Request().Header.Get(echo.HeaderAcceptEncoding)):
+		header.Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		header.Set(echo.HeaderContentEncoding, "gzip")
+	default:
+		header.Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		gzipped = false
+	}
+	c.Response().WriteHeader(http.StatusOK)
+
+	stripANSI := args.StripANSI != nil && *args.StripANSI
+	fetch := func(after *int) ([]db.TrialLogEntry, error) {
+		limit := trialLogsDownloadBatchSize
+		return m.db.TrialLogEntries(args.TrialID, db.TrialLogsPage{
+			GreaterThanID: after, Limit: &limit,
+		})
+	}
+	if !gzipped {
+		return writeTrialLogs(c.Response(), fetch, stripANSI)
+	}
+	gz := gzip.NewWriter(c.Response())
+	if err := writeTrialLogs(gz, fetch, stripANSI); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeTrialLogs writes the messages of the trial logs returned by fetch, one per line, fetching
+// the logs after the last one written until none are left.
+func writeTrialLogs(
+	w io.Writer, fetch func(after *int) ([]db.TrialLogEntry, error), stripANSI bool,
+) error {
+	bw := bufio.NewWriter(w)
+	var after *int
+	for {
+		entries, err := fetch(after)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return bw.Flush()
+		}
+		if stripANSI {
+			stripTrialLogsANSI(entries)
+		}
+		for _, entry := range entries {
+			if _, err = bw.WriteString(entry.Message); err != nil {
+				return err
+			}
+			if !strings.HasSuffix(entry.Message, "\n") {
+				if err = bw.WriteByte('\n'); err != nil {
+					return err
+				}
+			}
+		}
+		last := entries[len(entries)-1].ID
+		after = &last
+	}
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+func TestWriteTrialLogs(t *testing.T) {
+	logs := []db.TrialLogEntry{
+		{ID: 1, Message: "first\n"},
+		{ID: 2, Message: "\x1b[31msecond\x1b[0m"},
+		{ID: 5, Message: "third\n"},
+	}
+	var afters []int
+	fetch := func(after *int) ([]db.TrialLogEntry, error) {
+		start := 0
+		if after != nil {
+			afters = append(afters, *after)
+			for start < len(logs) && logs[start].ID <= *after {
+				start++
+			}
+		}
+		end := start + 2
+		if end > len(logs) {
+			end = len(logs)
+		}
+		return append([]db.TrialLogEntry(nil), logs[start:end]...), nil
+	}
+
+	var buf bytes.Buffer
+	assert.NilError(t, writeTrialLogs(&buf, fetch, true))
+	assert.Equal(t, buf.String(), "first\nsecond\nthird\n")
+	assert.DeepEqual(t, afters, []int{2, 5})
+}
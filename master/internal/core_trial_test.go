@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// parseTrialLogsV2ArgsOfTrial parses the arguments of the trial logs endpoint for trial 1.
+func parseTrialLogsV2ArgsOfTrial(c echo.Context) (db.TrialLogsPage, error) {
+	return parseTrialLogsV2Args(c, 1)
+}
+
+func TestTrialLogsEndpointsAgree(t *testing.T) {
+	parse := func(
+		parser func(echo.Context) (db.TrialLogsPage, error), query string,
+	) db.TrialLogsPage {
+		req := httptest.NewRequest(http.MethodGet, "/trials/1/logs?"+query, nil)
+		page, err := parser(echo.New().NewContext(req, httptest.NewRecorder()))
+		assert.NilError(t, err)
+		return page
+	}
+
+	// Each pair of queries asks the deprecated and the current endpoint for the same logs. Without
+	// an offset, only the current endpoint returns them in commit order.
+	for _, queries := range [][2]string{
+		{"", ""},
+		{"greater_than_id=5", "offset=5"},
+		{"tail=10", "limit=10"},
+		{"since=2020-10-01T00:00:00Z", "since=1601510400"},
+	} {
+		deprecated := parse(parseTrialLogsArgs, queries[0])
+		current := parse(parseTrialLogsV2ArgsOfTrial, queries[1])
+		deprecated.InCommitOrder = current.GreaterThanID == nil
+		assert.DeepEqual(t, deprecated, current)
+	}
+
+	five, ten, twenty := 5, 10, 20
+	assert.DeepEqual(t, parse(parseTrialLogsArgs, "greater_than_id=5&less_than_id=20&tail=10"),
+		db.TrialLogsPage{GreaterThanID: &five, LessThanID: &twenty, Limit: &ten, Tail: true})
+	assert.DeepEqual(t, parse(parseTrialLogsV2ArgsOfTrial, "offset=5&limit=10"),
+		db.TrialLogsPage{GreaterThanID: &five, Limit: &ten})
+
+	after := db.TrialLogsCursor{InsertedAt: time.Unix(1605787200, 0).UTC(), ID: 5}
+	assert.DeepEqual(t, parse(parseTrialLogsV2ArgsOfTrial,
+		"limit=10&cursor="+encodeTrialLogsCursor(1, after)),
+		db.TrialLogsPage{After: &after, InCommitOrder: true, Limit: &ten})
+}
+
+func TestTrialLogsInvalidCursor(t *testing.T) {
+	cursor := encodeTrialLogsCursor(2, db.TrialLogsCursor{InsertedAt: time.Now(), ID: 5})
+	for query, message := range map[string]string{
+		"cursor=" + cursor:          "cursor belongs to trial 2",
+		"cursor=not-a-cursor":       "invalid cursor",
+		"offset=5&cursor=" + cursor: "offset cannot be specified with a cursor",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/trials/1/logsv2?"+query, nil)
+		_, err := parseTrialLogsV2ArgsOfTrial(echo.New().NewContext(req, httptest.NewRecorder()))
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok)
+		assert.Equal(t, httpErr.Code, http.StatusBadRequest)
+		assert.ErrorContains(t, err, message)
+	}
+}
+
+func TestTrialLogsSince(t *testing.T) {
+	// Like the gRPC endpoint, both endpoints accept a duration before now.
+	for _, parser := range []func(echo.Context) (db.TrialLogsPage, error){
+		parseTrialLogsArgs, parseTrialLogsV2ArgsOfTrial,
+	} {
+		before := time.Now()
+		req := httptest.NewRequest(http.MethodGet, "/trials/1/logsv2?since=5m", nil)
+		page, err := parser(echo.New().NewContext(req, httptest.NewRecorder()))
+		assert.NilError(t, err)
+		assert.Assert(t, page.Since != nil)
+		assert.Assert(t, !page.Since.Before(before.Add(-5*time.Minute)))
+		assert.Assert(t, !page.Since.After(time.Now().Add(-5*time.Minute)))
+
+		req = httptest.NewRequest(http.MethodGet, "/trials/1/logsv2?since=-5m", nil)
+		_, err = parser(echo.New().NewContext(req, httptest.NewRecorder()))
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.Assert(t, ok)
+		assert.Equal(t, httpErr.Code, http.StatusBadRequest)
+		assert.ErrorContains(t, err, "since must not be a negative duration")
+	}
+}
+
+func TestTrialLogMessages(t *testing.T) {
+	entries := []db.TrialLogEntry{
+		{ID: 1, State: "ACTIVE", Message: "hello\n"},
+		{ID: 2, State: "ACTIVE", Message: "world\n"},
+	}
+	assert.DeepEqual(t, trialLogMessages(entries), []*model.LogMessage{
+		{ID: 1, Message: "hello\n"},
+		{ID: 2, Message: "world\n"},
+	})
+	assert.DeepEqual(t, trialLogMessages(nil), []*model.LogMessage{})
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+)
+
+// userUsage is how much of the cluster a user is using.
+type userUsage struct {
+	ActiveExperiments int `json:"active_experiments"`
+	// SlotsInUse and SlotsQueued count the slots of the trials of the active experiments that are
+	// running and waiting to be scheduled.
+	SlotsInUse  int `json:"slots_in_use"`
+	SlotsQueued int `json:"slots_queued"`
+}
+
+// userLimits is the response of /users/me/limits: the usage of the user along with the limits
+// that experiments they submit must stay within.
+type userLimits struct {
+	Usage userUsage `json:"usage"`
+	// SubmissionsBlocked explains why new experiments are rejected, e.g., for maintenance.
+	SubmissionsBlocked       string                  `json:"submissions_blocked,omitempty"`
+	SubmitValidators         []SubmitValidatorConfig `json:"submit_validators"`
+	MaxExperimentConfigBytes int64                   `json:"max_experiment_config_bytes"`
+	MaxModelDefinitionBytes  int64                   `json:"max_model_definition_bytes"`
+}
+
+// experimentSlotUsage sums the slots of the tasks of the given experiments by whether the tasks
+// have been allocated yet.
+func experimentSlotUsage(
+	summaries map[resourcemanagers.TaskID]resourcemanagers.TaskSummary, experimentIDs []int,
+) (inUse, queued int) {
+	experiments := make(map[string]bool, len(experimentIDs))
+	for _, id := range experimentIDs {
+		experiments[strconv.Itoa(id)] = true
+	}
+	for _, summary := range summaries {
+		if summary.Group == nil || summary.Group.Address().Parent() != experimentsAddr ||
+			!experiments[summary.Group.Address().Local()] {
+			continue
+		}
+		if len(summary.Containers) > 0 {
+			inUse += summary.SlotsNeeded
+		} else {
+			queued += summary.SlotsNeeded
+		}
+	}
+	return inUse, queued
+}
+
+// getUserLimits reports the usage of the authenticated user and the limits on the experiments that
+// they submit, so that users can tell why a submission would be rejected before making it.
+func (m *Master) getUserLimits(c echo.Context) (interface{}, error) {
+	user := c.(*context.DetContext).MustGetUser()
+	config := m.currentConfig()
+
+	ids, err := m.db.ActiveExperimentIDsForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := m.awaitResponse(
+		m.system.Ask(m.rm, resourcemanagers.GetTaskSummaries{}), taskSummariesAskTimeout)
+	if err != nil {
+		return nil, err
+	}
+	summaries, ok := result.(map[resourcemanagers.TaskID]resourcemanagers.TaskSummary)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get task summaries")
+	}
+
+	limits := userLimits{
+		Usage:                    userUsage{ActiveExperiments: len(ids)},
+		SubmitValidators:         config.SubmitValidators,
+		MaxExperimentConfigBytes: config.MaxExperimentConfigBytes,
+		MaxModelDefinitionBytes:  config.MaxModelDefinitionBytes,
+	}
+	if limits.SubmitValidators == nil {
+		limits.SubmitValidators = []SubmitValidatorConfig{}
+	}
+	limits.Usage.SlotsInUse, limits.Usage.SlotsQueued = experimentSlotUsage(summaries, ids)
+	if err = m.checkMaintenance(user, false); err != nil {
+		limits.SubmissionsBlocked = err.Error()
+	}
+	return limits, nil
+}
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+func TestExperimentSlotUsage(t *testing.T) {
+	system := actor.NewSystem("")
+	noop := actor.ActorFunc(func(*actor.Context) error { return nil })
+	system.ActorOf(actor.Addr("experiments"), noop)
+	system.ActorOf(actor.Addr("commands"), noop)
+	mine, _ := system.ActorOf(actor.Addr("experiments", 1), noop)
+	theirs, _ := system.ActorOf(actor.Addr("experiments", 2), noop)
+	command, _ := system.ActorOf(actor.Addr("commands", 1), noop)
+
+	running := []resourcemanagers.ContainerSummary{{Agent: "agent"}}
+	summaries := map[resourcemanagers.TaskID]resourcemanagers.TaskSummary{
+		"running": {SlotsNeeded: 4, Containers: running, Group: mine},
+		"queued":  {SlotsNeeded: 2, Group: mine},
+		"theirs":  {SlotsNeeded: 8, Containers: running, Group: theirs},
+		"command": {SlotsNeeded: 1, Containers: running, Group: command},
+		"orphan":  {SlotsNeeded: 1},
+	}
+	inUse, queued := experimentSlotUsage(summaries, []int{1})
+	assert.Equal(t, inUse, 4)
+	assert.Equal(t, queued, 2)
+}
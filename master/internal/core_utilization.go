@@ -0,0 +1,228 @@
+package internal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// defaultUtilizationRange is how far back utilization is reported if no start is given.
+	defaultUtilizationRange = 30 * 24 * time.Hour
+	// defaultUtilizationResolution is the length of the periods that utilization is averaged over
+	// if no resolution is given.
+	defaultUtilizationResolution = time.Hour
+	// maxUtilizationBuckets bounds the number of periods in a single utilization report.
+	maxUtilizationBuckets = 10000
+	// defaultUsageRange is how far back usage is reported if no start is given.
+	defaultUsageRange = 30 * 24 * time.Hour
+)
+
+// allocationRecorder saves the allocations that the resource managers notify it of, so that the
+// utilization of the cluster can be reported later, along with the failures and lifecycle logs of
+// their containers that the agents report.
+type allocationRecorder struct {
+	db *db.PgDB
+}
+
+// Receive implements the actor.Actor interface.
+func (a *allocationRecorder) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		// Allocations that were active when the master last stopped ended at some point since.
+		if err := a.db.EndAllAllocations(time.Now()); err != nil {
+			ctx.Log().WithError(err).Error("cannot end allocations from before the master started")
+		}
+
+	case sproto.AllocationStarted:
+		if err := a.db.AddAllocation(&model.AllocationSession{
+			TaskID:       msg.TaskID,
+			ResourcePool: msg.ResourcePool,
+			Slots:        msg.Slots,
+			UserID:       msg.UserID,
+			ExperimentID: msg.ExperimentID,
+			CommandID:    msg.CommandID,
+			RequestTime:  &msg.RequestTime,
+			StartTime:    msg.Time,
+		}); err != nil {
+			ctx.Log().WithError(err).Error("cannot record allocation")
+		}
+
+	case sproto.AllocationEnded:
+		if err := a.db.EndAllocation(msg.TaskID, msg.Time); err != nil {
+			ctx.Log().WithError(err).Error("cannot record end of allocation")
+		}
+
+	case sproto.AllocationFailed:
+		if err := a.db.SetAllocationFailure(
+			msg.TaskID, msg.Reason, msg.FailureType, msg.Message); err != nil {
+			ctx.Log().WithError(err).Error("cannot record failure of allocation")
+		}
+
+	case sproto.AllocationFailureClassified:
+		if err := a.db.SetAllocationFailureReason(msg.TaskID, msg.Reason); err != nil {
+			ctx.Log().WithError(err).Error("cannot record failure reason of allocation")
+		}
+
+	case sproto.ContainerLifecycleLog:
+		if err := a.db.AddTaskContainerLog(&model.TaskContainerLog{
+			TaskID:      msg.TaskID,
+			ContainerID: msg.ContainerID,
+			Timestamp:   msg.Timestamp,
+			Source:      msg.Source,
+			Level:       msg.Level,
+			Message:     msg.Message,
+		}); err != nil {
+			ctx.Log().WithError(err).Error("cannot record container log")
+		}
+
+	case actor.PostStop:
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (m *Master) getClusterUtilization(c echo.Context) (interface{}, error) {
+	args := struct {
+		Start      *time.Time     `query:"start"`
+		End        *time.Time     `query:"end"`
+		Resolution *time.Duration `query:"resolution"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	if args.End != nil {
+		end = *args.End
+	}
+	start := end.Add(-defaultUtilizationRange)
+	if args.Start != nil {
+		start = *args.Start
+	}
+	resolution := defaultUtilizationResolution
+	if args.Resolution != nil {
+		if *args.Resolution < time.Second {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"resolution must be at least 1s")
+		}
+		resolution = *args.Resolution
+	}
+
+	switch {
+	case !start.Before(end):
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "start must be before end")
+	case end.Sub(start)/resolution >= maxUtilizationBuckets:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"the range must be divided into fewer than %d periods; use a larger resolution",
+			maxUtilizationBuckets))
+	}
+	return m.db.SlotUtilization(start, end, resolution)
+}
+
+// usageArgs are the arguments of the usage endpoints.
+type usageArgs struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+}
+
+func parseUsageArgs(c echo.Context) (usageArgs, error) {
+	args := struct {
+		From    *time.Time `query:"from"`
+		To      *time.Time `query:"to"`
+		GroupBy *string    `query:"group_by"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return usageArgs{}, err
+	}
+
+	parsed := usageArgs{To: time.Now(), GroupBy: "user"}
+	if args.To != nil {
+		parsed.To = *args.To
+	}
+	parsed.From = parsed.To.Add(-defaultUsageRange)
+	if args.From != nil {
+		parsed.From = *args.From
+	}
+	if !parsed.From.Before(parsed.To) {
+		return usageArgs{}, echo.NewHTTPError(http.StatusBadRequest, "from must be before to")
+	}
+	if args.GroupBy != nil {
+		parsed.GroupBy = *args.GroupBy
+	}
+	switch parsed.GroupBy {
+	case "user", "pool", "experiment":
+	default:
+		return usageArgs{}, echo.NewHTTPError(http.StatusBadRequest,
+			"group_by must be one of user, pool or experiment")
+	}
+	return parsed, nil
+}
+
+// getUsage reports the slot-hours allocated to each user, resource pool or experiment.
+func (m *Master) getUsage(c echo.Context) (interface{}, error) {
+	args, err := parseUsageArgs(c)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := m.db.SlotUsage(args.From, args.To, args.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	if usage == nil {
+		usage = []model.SlotUsage{}
+	}
+	return usage, nil
+}
+
+// getUsageCSV reports the same usage as getUsage as a CSV file, for spreadsheets.
+func (m *Master) getUsageCSV(c echo.Context) error {
+	args, err := parseUsageArgs(c)
+	if err != nil {
+		return err
+	}
+	usage, err := m.db.SlotUsage(args.From, args.To, args.GroupBy)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="usage_by_%s.csv"`, args.GroupBy))
+	c.Response().WriteHeader(http.StatusOK)
+	return writeUsageCSV(c.Response(), args.GroupBy, usage)
+}
+
+func writeUsageCSV(w io.Writer, groupBy string, usage []model.SlotUsage) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{groupBy, "slot_hours"}); err != nil {
+		return err
+	}
+	for _, u := range usage {
+		var group string
+		if u.Group != nil {
+			group = *u.Group
+		}
+		if err := out.Write([]string{
+			group, strconv.FormatFloat(u.SlotHours, 'f', -1, 64),
+		}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestParseUsageArgs(t *testing.T) {
+	parse := func(query string) (usageArgs, error) {
+		req := httptest.NewRequest(http.MethodGet, "/usage?"+query, nil)
+		return parseUsageArgs(echo.New().NewContext(req, httptest.NewRecorder()))
+	}
+
+	args, err := parse("from=2020-10-01T00:00:00Z&to=2020-11-01T00:00:00Z&group_by=pool")
+	assert.NilError(t, err)
+	assert.Equal(t, args.From, time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, args.To, time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, args.GroupBy, "pool")
+
+	args, err = parse("to=2020-11-01T00:00:00Z")
+	assert.NilError(t, err)
+	assert.Equal(t, args.To.Sub(args.From), defaultUsageRange)
+	assert.Equal(t, args.GroupBy, "user")
+
+	args, err = parse("from=2020-10-01T02:00:00%2B02:00&to=1604188800")
+	assert.NilError(t, err)
+	assert.Equal(t, args.From, time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, args.To, time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC))
+
+	_, err = parse("from=yesterday")
+	assert.ErrorContains(t, err, `invalid parameter from: "yesterday" is not an RFC 3339 time`)
+	_, err = parse("from=2020-11-01T00:00:00Z&to=2020-10-01T00:00:00Z")
+	assert.ErrorContains(t, err, "from must be before to")
+	_, err = parse("group_by=team")
+	assert.ErrorContains(t, err, "group_by must be one of user, pool or experiment")
+}
+
+func TestWriteUsageCSV(t *testing.T) {
+	alice := "alice"
+	var out bytes.Buffer
+	assert.NilError(t, writeUsageCSV(&out, "user", []model.SlotUsage{
+		{Group: &alice, SlotHours: 12.5},
+		{Group: nil, SlotHours: 2},
+	}))
+	assert.Equal(t, out.String(), "user,slot_hours\nalice,12.5\n,2\n")
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/redact"
+)
+
+const (
+	// defaultWebhookDeliveries is the number of deliveries listed if no limit is given.
+	defaultWebhookDeliveries = 100
+	// webhookSecretBytes is the number of random bytes in generated webhook secrets.
+	webhookSecretBytes = 32
+)
+
+// webhookRequest is the body of requests that create or update webhooks. If the secret is empty,
+// a new webhook gets a random secret and an updated webhook keeps its secret.
+type webhookRequest struct {
+	URL        string                   `json:"url"`
+	Secret     string                   `json:"secret"`
+	EventTypes []model.WebhookEventType `json:"event_types"`
+}
+
+// validate returns an error describing the first problem with the request, if any.
+func (r webhookRequest) validate() error {
+	parsed, err := url.Parse(r.URL)
+	switch {
+	case err != nil:
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid url: %s", err))
+	case (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "":
+		return echo.NewHTTPError(http.StatusBadRequest, "url must be an absolute http(s) URL")
+	case len(r.EventTypes) == 0:
+		return echo.NewHTTPError(http.StatusBadRequest, "event_types must not be empty")
+	}
+	for _, eventType := range r.EventTypes {
+		if !model.WebhookEventTypes[eventType] {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("unknown event type %q", eventType))
+		}
+	}
+	return nil
+}
+
+func bindWebhookRequest(c echo.Context) (*webhookRequest, error) {
+	var req webhookRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid webhook: "+err.Error())
+	}
+	return &req, req.validate()
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func (m *Master) getWebhooks(c echo.Context) (interface{}, error) {
+	webhooks, err := m.db.Webhooks()
+	if err != nil {
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = []model.Webhook{}
+	}
+	return redact.Redact(webhooks), nil
+}
+
+// postWebhook registers a webhook. The response is the only one that includes the secret.
+func (m *Master) postWebhook(c echo.Context) (interface{}, error) {
+	req, err := bindWebhookRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	webhook := model.Webhook{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if webhook.Secret == "" {
+		if webhook.Secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.db.AddWebhook(&webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (m *Master) getWebhook(c echo.Context) (interface{}, error) {
+	args := struct {
+		WebhookID int `path:"webhook_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	webhook, err := m.db.WebhookByID(args.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	return redact.Redact(webhook), nil
+}
+
+func (m *Master) putWebhook(c echo.Context) (interface{}, error) {
+	args := struct {
+		WebhookID int `path:"webhook_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	req, err := bindWebhookRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := m.db.WebhookByID(args.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	webhook.URL = req.URL
+	webhook.EventTypes = req.EventTypes
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if err := m.db.UpdateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	return redact.Redact(webhook), nil
+}
+
+func (m *Master) deleteWebhook(c echo.Context) (interface{}, error) {
+	args := struct {
+		WebhookID int `path:"webhook_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	return nil, m.db.DeleteWebhook(args.WebhookID)
+}
+
+func (m *Master) getWebhookDeliveries(c echo.Context) (interface{}, error) {
+	args := struct {
+		WebhookID int  `path:"webhook_id"`
+		Limit     *int `query:"limit"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	limit := defaultWebhookDeliveries
+	if args.Limit != nil {
+		if *args.Limit <= 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "limit must be positive")
+		}
+		limit = *args.Limit
+	}
+	if _, err := m.db.WebhookByID(args.WebhookID); err != nil {
+		return nil, err
+	}
+	deliveries, err := m.db.WebhookDeliveries(args.WebhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if deliveries == nil {
+		deliveries = []model.WebhookDelivery{}
+	}
+	return deliveries, nil
+}
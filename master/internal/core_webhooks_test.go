@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestWebhookRequestValidate(t *testing.T) {
+	events := []model.WebhookEventType{model.ExperimentCompletedEvent}
+	valid := webhookRequest{URL: "https://hooks.example.com/a", EventTypes: events}
+	assert.NilError(t, valid.validate())
+
+	for _, req := range []webhookRequest{
+		{URL: "hooks.example.com/a", EventTypes: events},
+		{URL: "ftp://hooks.example.com/a", EventTypes: events},
+		{URL: "https:///a", EventTypes: events},
+		{URL: "https://hooks.example.com/a"},
+		{URL: "https://hooks.example.com/a", EventTypes: []model.WebhookEventType{"experiment.paused"}},
+	} {
+		assert.ErrorContains(t, req.validate(), "", "%+v", req)
+	}
+}
+
+func TestGenerateWebhookSecret(t *testing.T) {
+	first, err := generateWebhookSecret()
+	assert.NilError(t, err)
+	second, err := generateWebhookSecret()
+	assert.NilError(t, err)
+	assert.Equal(t, len(first), 2*webhookSecretBytes)
+	assert.Assert(t, first != second)
+}
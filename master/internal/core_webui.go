@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// webuiAssetExtensions are the extensions of the files that the WebUI loads as assets rather than
+// navigating to. Requests for missing assets fail instead of getting the index page, so that
+// broken deployments are noticed.
+var webuiAssetExtensions = map[string]bool{
+	".css": true, ".eot": true, ".gif": true, ".ico": true, ".jpeg": true, ".jpg": true,
+	".js": true, ".json": true, ".map": true, ".png": true, ".svg": true, ".ttf": true,
+	".wasm": true, ".webp": true, ".woff": true, ".woff2": true,
+}
+
+// errOutsideWebUIRoot is returned for requested files that resolve outside of the WebUI root.
+var errOutsideWebUIRoot = echo.NewHTTPError(http.StatusForbidden)
+
+// resolveWebUIFile returns the path of the file under root that a path relative to the WebUI route
+// refers to, following symlinks, or "" if there is no such file. Paths that lead outside of root,
+// either through ".." or through symlinks, are rejected.
+func resolveWebUIFile(root, groupPath string) (string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	inRoot := func(file string) bool {
+		rel, rErr := filepath.Rel(root, file)
+		return rErr == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}
+
+	requested := filepath.Join(root, filepath.FromSlash(groupPath))
+	if !inRoot(requested) {
+		return "", errOutsideWebUIRoot
+	}
+	resolved, err := filepath.EvalSymlinks(requested)
+	switch {
+	case os.IsNotExist(err) || os.IsPermission(err):
+		return "", nil
+	case err != nil:
+		return "", err
+	case !inRoot(resolved):
+		return "", errOutsideWebUIRoot
+	}
+
+	stat, err := os.Stat(resolved)
+	switch {
+	case os.IsNotExist(err) || os.IsPermission(err):
+		return "", nil
+	case err != nil:
+		return "", err
+	case stat.IsDir():
+		return "", nil
+	}
+	return resolved, nil
+}
+
+// webuiHandler serves the files of the WebUI under reactRoot. Paths that are not files are WebUI
+// routes, which get the index page so that the WebUI can route them, unless they look like API
+// paths or missing assets.
+func webuiHandler(reactRoot string, apiPathPattern *regexp.Regexp) echo.HandlerFunc {
+	reactIndex := filepath.Join(reactRoot, "index.html")
+	return func(c echo.Context) error {
+		groupPath := strings.TrimPrefix(c.Request().URL.Path, webuiBaseRoute+"/")
+		file, err := resolveWebUIFile(reactRoot, groupPath)
+		switch {
+		case err == errOutsideWebUIRoot:
+			return err
+		case err != nil:
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to check if file exists")
+		case file != "":
+			return c.File(file)
+		}
+
+		// Only WebUI routes fall back to the index page; API clients and asset loads should get a
+		// proper 404.
+		if isWebUIAPIPath(groupPath, apiPathPattern) ||
+			webuiAssetExtensions[strings.ToLower(path.Ext(groupPath))] {
+			return echo.NewHTTPError(
+				http.StatusNotFound, fmt.Sprintf("%s not found", c.Request().URL.Path))
+		}
+		return c.File(reactIndex)
+	}
+}
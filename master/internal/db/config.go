@@ -1,21 +1,36 @@
 package db
 
+import (
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
 // DefaultConfig returns the default configuration of the database.
 func DefaultConfig() *Config {
 	return &Config{
-		Migrations: "file://static/migrations",
-		SSLMode:    sslModeDisable,
+		Migrations:     "file://static/migrations",
+		SSLMode:        sslModeDisable,
+		StartupTimeout: 5 * 60,
 	}
 }
 
 // Config hosts configuration fields of the database.
 type Config struct {
 	User        string `json:"user"`
-	Password    string `json:"password"`
+	Password    string `json:"password" secret:"true"`
 	Migrations  string `json:"migrations"`
 	Host        string `json:"host"`
-	Port        string `json:"port"`
+	Port        string `json:"port" jsontype:"string,integer"`
 	Name        string `json:"name"`
 	SSLMode     string `json:"ssl_mode"`
 	SSLRootCert string `json:"ssl_root_cert"`
+	// StartupTimeout is the number of seconds for which the master retries connecting to the
+	// database when it starts, so that it waits for a database that is starting along with it.
+	StartupTimeout int `json:"startup_timeout"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(c.StartupTimeout, 0, "db.startup_timeout must be non-negative"),
+	}
 }
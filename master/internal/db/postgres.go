@@ -28,19 +28,44 @@ type PgDB struct {
 	queries   *staticQueryMap
 }
 
-// ConnectPostgres connects to a Postgres database.
-func ConnectPostgres(url string) (*PgDB, error) {
-	numTries := 0
-	for {
+const (
+	startupBackoffBase = time.Second
+	startupBackoffMax  = 30 * time.Second
+)
+
+// StartupBackoff returns how long to wait before trying again to reach the database after the
+// given number of failed tries.
+func StartupBackoff(tries int) time.Duration {
+	backoff := startupBackoffBase
+	for i := 1; i < tries && backoff < startupBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > startupBackoffMax {
+		backoff = startupBackoffMax
+	}
+	return backoff
+}
+
+// ConnectPostgres connects to a Postgres database. Failed attempts are retried with exponential
+// backoff until the timeout has elapsed, so that the database may still be starting.
+func ConnectPostgres(url string, timeout time.Duration) (*PgDB, error) {
+	deadline := time.Now().Add(timeout)
+	for numTries := 1; ; numTries++ {
 		sql, err := sqlx.Connect("postgres", url)
 		if err == nil {
 			return &PgDB{sql: sql, queries: &staticQueryMap{queries: make(map[string]string)}}, err
 		}
-		numTries++
-		if numTries >= 15 {
-			return nil, errors.Wrapf(err, "could not connect to database after %v tries", numTries)
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, errors.Wrapf(err, "could not connect to database after %v tries in %v",
+				numTries, timeout)
+		}
+		backoff := StartupBackoff(numTries)
+		if backoff > remaining {
+			backoff = remaining
 		}
-		time.Sleep(4 * time.Second)
+		log.WithError(err).Warnf("could not connect to database, retrying in %v", backoff)
+		time.Sleep(backoff)
 	}
 }
 
@@ -187,7 +212,8 @@ WHERE NOT EXISTS ( SELECT * FROM cluster_id );
 }
 
 // ExperimentWithTrialSummariesRaw returns a JSON string containing information for an experiment,
-// including extra computed information for each trial.
+// including extra computed information for each trial, counts of their failures by category and
+// the most common reason that its allocations failed.
 func (db *PgDB) ExperimentWithTrialSummariesRaw(id int) ([]byte, error) {
 	return db.rawQuery(`
 WITH const AS (
@@ -258,6 +284,23 @@ FROM (
                 WHERE t.experiment_id = e.id
             ) t
            ) AS trials,
+           -- Count the failed runs of the trials of this experiment by category.
+           (SELECT coalesce(jsonb_object_agg(c.category, c.count), '{}'::jsonb)
+            FROM (
+                SELECT f.category, count(*) AS count
+                FROM trial_failures f JOIN trials t ON f.trial_id = t.id
+                WHERE t.experiment_id = e.id
+                GROUP BY f.category
+            ) c
+           ) AS failure_categories,
+           -- Find the most common reason that the allocations of this experiment failed.
+           (SELECT a.failure_reason
+            FROM allocation_sessions a
+            WHERE a.experiment_id = e.id AND a.failure_reason IS NOT NULL
+            GROUP BY a.failure_reason
+            ORDER BY count(*) DESC, a.failure_reason
+            LIMIT 1
+           ) AS most_common_failure_reason,
 			(
 				SELECT to_json(u) FROM (SELECT id, username FROM users WHERE id = e.owner_id
 				) u
@@ -297,39 +340,122 @@ FROM (
 `, id)
 }
 
+// MetricReduction is a way to reduce the values of a metric that the trials of an experiment
+// reported at the same number of batches to one value.
+type MetricReduction string
+
+// These are the supported metric reductions.
+const (
+	ReduceMean   MetricReduction = "mean"
+	ReduceMedian MetricReduction = "median"
+	ReduceMin    MetricReduction = "min"
+	ReduceMax    MetricReduction = "max"
+	ReduceLast   MetricReduction = "last"
+)
+
+// metricReductions maps each reduction to an SQL aggregate over the values of a metric, given as
+// %[1]s, where %[2]s orders the values from the last reported.
+var metricReductions = map[MetricReduction]string{
+	ReduceMean:   "avg(%[1]s)",
+	ReduceMedian: "percentile_cont(0.5) WITHIN GROUP (ORDER BY %[1]s)",
+	ReduceMin:    "min(%[1]s)",
+	ReduceMax:    "max(%[1]s)",
+	ReduceLast:   "(array_agg(%[1]s ORDER BY %[2]s) FILTER (WHERE %[1]s IS NOT NULL))[1]",
+}
+
+// Valid returns whether the reduction is supported.
+func (r MetricReduction) Valid() bool {
+	_, ok := metricReductions[r]
+	return ok
+}
+
+// batchMetricsAggregate returns an SQL expression for an object that maps the name of each metric
+// in the batch metrics of the step s to an aggregate of its values, which is given as a format
+// string like those in metricReductions. Metric names are never spliced into the query, so they
+// are returned verbatim however many quotes, dots, slashes or characters they contain.
+func batchMetricsAggregate(aggregate string) string {
+	return fmt.Sprintf(`(SELECT coalesce(jsonb_object_agg(r.name, r.value), '{}'::jsonb)
+ FROM (
+     SELECT m.key AS name, %s AS value
+     FROM jsonb_array_elements(s.metrics->'batch_metrics')
+             WITH ORDINALITY AS b(value, ordinality),
+         jsonb_each_text(CASE WHEN jsonb_typeof(b.value) = 'object' THEN b.value
+                              ELSE '{}'::jsonb END) AS m(key, value)
+     GROUP BY m.key
+ ) r)`, fmt.Sprintf(aggregate, "try_float8_cast(m.value)"))
+}
+
+// reducedMetricsAcrossTrials returns an SQL expression for the metrics of the experiment e reduced
+// across its trials with the aggregate, which is given as a format string like those in
+// metricReductions: for each number of batches that trials reported metrics at, in order, the
+// number of such trials and their training and validation metrics at that point, reduced.
+func reducedMetricsAcrossTrials(aggregate string) string {
+	return fmt.Sprintf(`(WITH points AS (
+     SELECT coalesce(s.prior_batches_processed, 0) + coalesce(s.num_batches, 0) AS batches,
+            s.trial_id, s.end_time,
+            'metrics'::text AS kind,
+            (CASE
+                 WHEN s.metrics->'avg_metrics' IS NOT NULL THEN s.metrics->'avg_metrics'
+                 WHEN s.metrics->'batch_metrics' IS NOT NULL THEN %[1]s
+             END) AS metrics
+     FROM steps s JOIN trials t ON s.trial_id = t.id
+     WHERE t.experiment_id = e.id
+     UNION ALL
+     SELECT coalesce(s.prior_batches_processed, 0) + coalesce(s.num_batches, 0),
+            s.trial_id, v.end_time,
+            'validation_metrics'::text, v.metrics->'validation_metrics'
+     FROM validations v
+         JOIN steps s ON v.trial_id = s.trial_id AND v.step_id = s.id
+         JOIN trials t ON s.trial_id = t.id
+     WHERE t.experiment_id = e.id AND v.state = 'COMPLETED'
+ ), reduced AS (
+     SELECT r.batches, r.kind, jsonb_object_agg(r.name, r.value) AS metrics
+     FROM (
+         SELECT p.batches, p.kind, m.key AS name, %[2]s AS value
+         FROM points p,
+             jsonb_each_text(CASE WHEN jsonb_typeof(p.metrics) = 'object' THEN p.metrics
+                                  ELSE '{}'::jsonb END) AS m(key, value)
+         GROUP BY p.batches, p.kind, m.key
+     ) r
+     GROUP BY r.batches, r.kind
+ )
+ SELECT coalesce(jsonb_agg(r ORDER BY r.batches), '[]'::jsonb)
+ FROM (
+     SELECT p.batches, count(DISTINCT p.trial_id) AS num_trials,
+            coalesce((SELECT metrics FROM reduced
+                      WHERE batches = p.batches AND kind = 'metrics'), '{}'::jsonb) AS metrics,
+            coalesce((SELECT metrics FROM reduced
+                      WHERE batches = p.batches AND kind = 'validation_metrics'), '{}'::jsonb)
+                AS validation_metrics
+     FROM points p
+     GROUP BY p.batches
+ ) r)`,
+		batchMetricsAggregate("avg(%s)"),
+		fmt.Sprintf(aggregate,
+			"try_float8_cast(m.value)", "p.end_time DESC NULLS LAST, p.trial_id DESC"))
+}
+
 // ExperimentWithSummaryMetricsRaw returns a JSON string containing information
 // for one experiment with just summary metrics for all steps instead of all
-// metrics.
-func (db *PgDB) ExperimentWithSummaryMetricsRaw(id int) ([]byte, error) {
-	var metricNames []string
-	if err := db.sql.Select(&metricNames, `
-SELECT jsonb_object_keys(to_jsonb(r)->'metrics'->'batch_metrics'->0)
-FROM (
-    SELECT s.metrics
-    FROM steps s, trials t, experiments e
-    WHERE s.state = 'COMPLETED'
-        AND s.trial_id = t.id
-        AND t.experiment_id = $1
-    LIMIT 1
-) r
-`, id); err != nil {
-		return nil, errors.Wrapf(err, "failed to get metric names for experient %d", id)
-	}
-
-	averageMetrics := make([]string, 0, len(metricNames))
-	for _, name := range metricNames {
-		// Slow conversion (i.e., try_float8_cast versus ::float8) is ok here
-		// because we are only using this in legacy metrics where precomputed
-		// averages are not present.
-		averageMetrics = append(averageMetrics,
-			fmt.Sprintf(`avg(try_float8_cast(value->>'%s')) AS "%s"`, name, name))
+// metrics. Given a reduction, the experiment also has the metrics of its trials reduced across
+// them at each number of batches as reduced_metrics.
+func (db *PgDB) ExperimentWithSummaryMetricsRaw(
+	id int, reduction *MetricReduction,
+) ([]byte, error) {
+	reducedMetrics := ""
+	if reduction != nil {
+		aggregate, ok := metricReductions[*reduction]
+		if !ok {
+			return nil, errors.Errorf("unsupported metric reduction: %s", *reduction)
+		}
+		reducedMetrics = ",\n" + reducedMetricsAcrossTrials(aggregate) + " AS reduced_metrics"
 	}
 
 	queryTemplate := `
 SELECT row_to_json(e)
 FROM (
     SELECT e.archived, e.config, e.end_time, e.git_commit, e.git_commit_date, e.git_committer,
-           e.git_remote, e.id, e.start_time, e.state, e.progress,
+           e.git_remote, e.id, e.start_time, e.state, e.progress, e.external_id,
            (SELECT to_json(u) FROM (SELECT id, username FROM users WHERE id = e.owner_id) u)
 			as owner,
            (SELECT coalesce(jsonb_agg(t ORDER BY id ASC), '[]'::jsonb)
@@ -353,12 +479,7 @@ FROM (
                           WHEN s.metrics->'batch_metrics' IS NULL THEN s.metrics
                           WHEN s.metrics->'avg_metrics' IS NULL THEN
                               (s.metrics - 'batch_metrics') ||
-                                  jsonb_build_object('avg_metrics',
-                                  (SELECT to_jsonb(r1)
-                                   FROM (
-                                      SELECT %s
-                                      FROM jsonb_array_elements(s.metrics->'batch_metrics')
-                                   ) r1))
+                                  jsonb_build_object('avg_metrics', %s)
                           ELSE s.metrics - 'batch_metrics'
                       END) AS metrics,
                      (SELECT row_to_json(c)
@@ -382,12 +503,13 @@ FROM (
                 FROM trials t
                 WHERE t.experiment_id = e.id
             ) t
-           ) AS trials
+           ) AS trials%s
     FROM experiments e
     WHERE e.id = $1
 ) e
 `
-	return db.rawQuery(fmt.Sprintf(queryTemplate, strings.Join(averageMetrics, ",")), id)
+	return db.rawQuery(
+		fmt.Sprintf(queryTemplate, batchMetricsAggregate("avg(%s)"), reducedMetrics), id)
 }
 
 // CheckExperimentExists checks if the experiment exists.
@@ -527,9 +649,19 @@ func (db *PgDB) ExperimentRaw(id int) ([]byte, error) {
 SELECT row_to_json(e)
 FROM (
     SELECT e.archived, e.config, e.end_time, e.git_commit, e.git_commit_date, e.git_committer,
-           e.git_remote, e.id, e.start_time, e.state, e.progress,
+           e.git_remote, e.id, e.start_time, e.state, e.progress, e.external_id,
+           e.imported_from_cluster_id, e.imported_from_experiment_id, e.schedule_id,
+           e.deleted_at,
            (SELECT to_json(u) FROM (SELECT id, username FROM users WHERE id = e.owner_id) u)
 			as owner,
+           (SELECT name FROM teams WHERE id = e.team_id) AS team,
+           (SELECT coalesce(jsonb_agg(f ORDER BY checkpoint_uuid ASC), '[]'::jsonb)
+            FROM (
+                SELECT f.checkpoint_uuid, f.error, f.failed_at
+                FROM checkpoint_deletion_failures f
+                WHERE f.experiment_id = e.id
+            ) f
+           ) AS deletion_failures,
            (SELECT coalesce(jsonb_agg(t ORDER BY id ASC), '[]'::jsonb)
             FROM (
                 SELECT t.end_time, t.experiment_id, t.hparams, t.id, t.seed, t.start_time, t.state,
@@ -566,18 +698,26 @@ FROM (
 `, id)
 }
 
-// ExperimentListRaw creates a JSON string containing information for all experiments.
+// ExperimentListRaw creates a JSON string containing information for all experiments that are
+// visible to the viewer, or to any user if it is nil. If externalID is not empty, only experiments
+// with that external ID are included.
 func (db *PgDB) ExperimentListRaw(
-	skipArchived bool, username string, limit, offset int,
+	skipArchived bool, username, externalID string, viewer *model.UserID, limit, offset int,
 ) ([]byte, error) {
 	// Keep track of how many parameters we have added to the query so far.
-	varCounter := 1
+	varCounter := 2
 	usernameQuery := ""
 	if username != "" {
 		usernameQuery = fmt.Sprintf("AND u.username = $%d", varCounter+1)
 		varCounter++
 	}
 
+	externalIDQuery := ""
+	if externalID != "" {
+		externalIDQuery = fmt.Sprintf("AND e.external_id = $%d", varCounter+1)
+		varCounter++
+	}
+
 	limitOffsetQuery := ""
 	if limit != 0 {
 		limitOffsetQuery = fmt.Sprintf(`
@@ -590,75 +730,95 @@ OFFSET $%d
 SELECT coalesce(jsonb_agg(e ORDER BY e.id DESC), '[]'::jsonb)
 FROM (
     SELECT e.archived, e.config, e.end_time, e.git_commit, e.git_commit_date, e.git_committer,
-	   e.git_remote, e.id, e.start_time, e.state, e.progress,
+	   e.git_remote, e.id, e.start_time, e.state, e.progress, e.external_id,
       (SELECT to_json(u) FROM (SELECT id, username FROM users WHERE id = e.owner_id) u)
-		as owner
+		as owner,
+      (SELECT name FROM teams WHERE id = e.team_id) AS team
     FROM experiments e
 	 LEFT JOIN
 	 users u
 	 ON u.id = e.owner_id
 		WHERE (e.archived = false OR $1 = false)
+			AND e.deleted_at IS NULL
+			AND %s
+			%s
 			%s
 			%s
 ) e
-`, usernameQuery, limitOffsetQuery)
+`, experimentVisibleSQL("e", 2), usernameQuery, externalIDQuery, limitOffsetQuery)
 
 	// Build up the list of parameters based on the dynamic queries.
 	var parameters []interface{}
-	parameters = append(parameters, skipArchived)
+	parameters = append(parameters, skipArchived, viewer)
 	if usernameQuery != "" {
 		parameters = append(parameters, username)
 	}
+	if externalIDQuery != "" {
+		parameters = append(parameters, externalID)
+	}
 	if limitOffsetQuery != "" {
 		parameters = append(parameters, limit, offset)
 	}
 	return db.rawQuery(query, parameters...)
 }
 
-// ExperimentDescriptorsRaw creates a JSON string containing short descriptors for all experiments.
-func (db *PgDB) ExperimentDescriptorsRaw(skipArchived, skipInactive bool) ([]byte, error) {
-	return db.rawQuery(`
+// ExperimentDescriptorsRaw creates a JSON string containing short descriptors for all experiments
+// that are visible to the viewer, or to any user if it is nil.
+func (db *PgDB) ExperimentDescriptorsRaw(
+	skipArchived, skipInactive bool, viewer *model.UserID,
+) ([]byte, error) {
+	return db.rawQuery(fmt.Sprintf(`
 SELECT coalesce(jsonb_agg(descs ORDER BY id DESC), '[]'::jsonb)
 FROM (
     SELECT id,
         config->'description' AS description,
         coalesce(config->'labels', '{}') AS labels
-    FROM experiments
+    FROM experiments e
     WHERE (archived = false OR $1 = false)
     AND   (state = 'ACTIVE' OR $2 = false)
-) descs`, skipArchived, skipInactive)
+    AND   deleted_at IS NULL
+    AND   %s
+) descs`, experimentVisibleSQL("e", 3)), skipArchived, skipInactive, viewer)
 }
 
 // ExperimentDescriptorsRawForUser returns a JSON string containing short descriptors for each
-// experiment owned by a user.
+// experiment owned by a user that is visible to the viewer, or to any user if it is nil.
 func (db *PgDB) ExperimentDescriptorsRawForUser(skipArchived, skipInactive bool,
-	username string) ([]byte, error) {
-	return db.rawQuery(`
+	username string, viewer *model.UserID) ([]byte, error) {
+	return db.rawQuery(fmt.Sprintf(`
 SELECT coalesce(jsonb_agg(descs ORDER BY id DESC), '[]'::jsonb)
 FROM (
-    SELECT id,
-        config->'description' AS description,
-        coalesce(config->'labels', '{}') AS labels
-    FROM experiments
-    JOIN users ON (experiments.owner_id = users.id)
+    SELECT e.id,
+        e.config->'description' AS description,
+        coalesce(e.config->'labels', '{}') AS labels
+    FROM experiments e
+    JOIN users ON (e.owner_id = users.id)
     WHERE (archived = false OR $1 = false)
     AND   (state = 'ACTIVE' OR $2 = false)
     AND	  (users.username = $3)
-) descs`, skipArchived, skipInactive, username)
+    AND   e.deleted_at IS NULL
+    AND   %s
+) descs`, experimentVisibleSQL("e", 4)), skipArchived, skipInactive, username, viewer)
 }
 
-// AddExperiment adds the experiment to the database and sets its ID.
+// AddExperiment adds the experiment to the database and sets its ID. It returns
+// ErrDuplicateRecord if the external ID of the experiment must be unique and is not.
 func (db *PgDB) AddExperiment(experiment *model.Experiment) error {
 	if experiment.ID != 0 {
 		return errors.Errorf("error adding an experiment with non-zero id %v", experiment.ID)
 	}
 	err := db.namedGet(&experiment.ID, `
 INSERT INTO experiments
-(state, config, model_definition, start_time, end_time, archived,
- git_remote, git_commit, git_committer, git_commit_date, owner_id)
-VALUES (:state, :config, :model_definition, :start_time, :end_time, :archived,
-        :git_remote, :git_commit, :git_committer, :git_commit_date, :owner_id)
-RETURNING id`, experiment)
+(state, config, model_definition, model_definition_hash, start_time, end_time, archived,
+ git_remote, git_commit, git_committer, git_commit_date, owner_id, external_id,
+ external_id_unique, schedule_id, team_id)
+VALUES (:state, :config, :model_definition, :model_definition_hash, :start_time, :end_time,
+        :archived, :git_remote, :git_commit, :git_committer, :git_commit_date, :owner_id,
+        :external_id, :external_id_unique, :schedule_id, :team_id)
+RETURNING id`, experimentRow(experiment))
+	if isDuplicateExternalID(err) {
+		return ErrDuplicateRecord
+	}
 	if err != nil {
 		return errors.Wrapf(err, "error inserting experiment %v", *experiment)
 	}
@@ -670,8 +830,9 @@ func (db *PgDB) ExperimentByID(id int) (*model.Experiment, error) {
 	var experiment model.Experiment
 
 	if err := db.query(`
-SELECT id, state, config, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+SELECT id, state, config, model_definition, model_definition_hash, start_time, end_time,
+       archived, git_remote, git_commit, git_committer, git_commit_date, owner_id, external_id,
+       imported_from_cluster_id, imported_from_experiment_id, schedule_id, team_id
 FROM experiments
 WHERE id = $1`, &experiment, id); err != nil {
 		return nil, err
@@ -708,8 +869,9 @@ func (db *PgDB) ExperimentWithoutConfigByID(id int) (*model.Experiment, error) {
 	var experiment model.Experiment
 
 	if err := db.query(`
-SELECT id, state, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+SELECT id, state, model_definition, model_definition_hash, start_time, end_time, archived,
+       git_remote, git_commit, git_committer, git_commit_date, owner_id,
+       imported_from_cluster_id, imported_from_experiment_id, schedule_id, deleted_at
 FROM experiments
 WHERE id = $1`, &experiment, id); err != nil {
 		return nil, err
@@ -734,8 +896,8 @@ SELECT experiment_id FROM trials where id = $1
 func (db *PgDB) ExperimentByTrialID(id int) (*model.Experiment, error) {
 	experiment := model.Experiment{}
 	return &experiment, db.sql.QueryRowx(`
-SELECT e.id, e.state, e.config, e.model_definition, e.start_time, e.end_time,
-e.archived, e.git_remote, e.git_commit, e.git_committer, e.git_commit_date
+SELECT e.id, e.state, e.config, e.model_definition, e.model_definition_hash, e.start_time,
+e.end_time, e.archived, e.git_remote, e.git_commit, e.git_committer, e.git_commit_date
 FROM experiments e, trials t  WHERE t.id = $1 AND e.id = t.experiment_id`,
 		id).StructScan(&experiment)
 }
@@ -743,8 +905,8 @@ FROM experiments e, trials t  WHERE t.id = $1 AND e.id = t.experiment_id`,
 // NonTerminalExperiments finds all experiments in the database whose states are not terminal.
 func (db *PgDB) NonTerminalExperiments() ([]*model.Experiment, error) {
 	rows, err := db.sql.Queryx(`
-SELECT id, state, config, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+SELECT id, state, config, model_definition, model_definition_hash, start_time, end_time,
+       archived, git_remote, git_commit, git_committer, git_commit_date, owner_id
 FROM experiments
 WHERE state IN ('ACTIVE', 'PAUSED', 'STOPPING_CANCELED', 'STOPPING_COMPLETED', 'STOPPING_ERROR')`)
 	if err == sql.ErrNoRows {
@@ -831,6 +993,55 @@ func (db *PgDB) TerminateExperimentInRestart(id int, state model.State) error {
 	return nil
 }
 
+// ReopenErroredExperiment undoes TerminateExperimentInRestart for an errored experiment so that it
+// can be restored again: the experiment goes back to ACTIVE, along with the trials that were
+// errored at the same time as it. Trials that ended before the experiment stay terminal.
+func (db *PgDB) ReopenErroredExperiment(id int) error {
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer func() {
+		if tx == nil {
+			return
+		}
+
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Errorf("during rollback: %v", rErr)
+		}
+	}()
+
+	// Reopen trials.
+	if _, err = tx.Exec(`
+UPDATE trials t SET state = 'ACTIVE', end_time = NULL
+FROM experiments e
+WHERE e.id = $1 AND t.experiment_id = e.id AND e.state = 'ERROR'
+  AND t.state = 'ERROR' AND t.end_time = e.end_time`, id); err != nil {
+		return errors.Wrap(err, "reopening trials of an errored experiment")
+	}
+
+	// Reopen experiment.
+	result, err := tx.Exec(`
+UPDATE experiments SET state = 'ACTIVE', end_time = NULL WHERE id = $1 AND state = 'ERROR'`, id)
+	if err != nil {
+		return errors.Wrap(err, "reopening an errored experiment")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "reopening an errored experiment")
+	} else if rows == 0 {
+		return errors.Errorf("experiment %v is not in the ERROR state", id)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing reopening of errored experiment %v", id)
+	}
+
+	tx = nil
+
+	return nil
+}
+
 // SaveExperimentConfig saves the current experiment config to the database.
 func (db *PgDB) SaveExperimentConfig(experiment *model.Experiment) error {
 	query := `
@@ -945,6 +1156,20 @@ func (db *PgDB) SaveExperimentProgress(id int, progress *float64) error {
 	return nil
 }
 
+// SaveExperimentOwner transfers the ownership of an experiment to another user.
+func (db *PgDB) SaveExperimentOwner(id int, ownerID model.UserID) error {
+	res, err := db.sql.Exec(`UPDATE experiments SET owner_id = $1 WHERE id = $2`, ownerID, id)
+	if err != nil {
+		return errors.Wrap(err, "saving experiment owner")
+	}
+	if numRows, err := res.RowsAffected(); err != nil {
+		return errors.Wrap(err, "checking affected rows for saving experiment owner")
+	} else if numRows == 0 {
+		return errors.WithStack(ErrNotFound)
+	}
+	return nil
+}
+
 // ForEachSearcherEvent calls a callback for each searcher event of an experiment.
 func (db *PgDB) ForEachSearcherEvent(id int, callback func(model.SearcherEvent) error) error {
 	rows, err := db.sql.Queryx(`
@@ -1031,18 +1256,38 @@ WHERE trials.experiment_id = $1 AND steps.trial_id = trials.id
 	return numSteps, nil
 }
 
-// ExperimentModelDefinitionRaw returns the zipped model definition for an experiment as a byte
-// array.
-func (db *PgDB) ExperimentModelDefinitionRaw(id int) ([]byte, error) {
-	return db.rawQuery(`
-SELECT model_definition
+// ExperimentModelDefinition returns the zipped model definition of an experiment if it is kept in
+// the experiments table, and otherwise the hash that identifies it in the model definition
+// storage.
+func (db *PgDB) ExperimentModelDefinition(id int) ([]byte, *string, error) {
+	var row struct {
+		ModelDefinition []byte  `db:"model_definition"`
+		Hash            *string `db:"model_definition_hash"`
+	}
+	if err := db.query(`
+SELECT model_definition, model_definition_hash
 FROM experiments
-WHERE id = $1`, id)
+WHERE id = $1`, &row, id); err != nil {
+		return nil, nil, err
+	}
+	return row.ModelDefinition, row.Hash, nil
+}
+
+// UpdateExperimentModelDefinition replaces the model definition of an experiment with the one
+// with the hash in the model definition storage.
+func (db *PgDB) UpdateExperimentModelDefinition(id int, hash string) error {
+	if _, err := db.sql.Exec(`
+UPDATE experiments SET model_definition = NULL, model_definition_hash = $2
+WHERE id = $1`, id, hash); err != nil {
+		return errors.Wrapf(err, "error updating model definition of experiment %d", id)
+	}
+	return nil
 }
 
 // ExperimentCheckpointsToGCRaw returns a JSON string describing checkpoints that should be GCed
 // according to the given GC policy parameters. If the delete parameter is true, the returned
-// checkpoints are also marked as deleted in the database.
+// checkpoints are also marked as deleted in the database. Checkpoints that are registered as model
+// versions are never returned.
 func (db *PgDB) ExperimentCheckpointsToGCRaw(
 	id int,
 	experimentBest, trialBest, trialLatest *int,
@@ -1122,6 +1367,8 @@ WITH const AS (
                OR const.trial_best IS NOT NULL
                OR const.trial_latest IS NOT NULL)
           AND (SELECT COUNT(*) FROM trials t WHERE t.warm_start_checkpoint_id = c.id) = 0
+          -- Checkpoints registered as model versions are never garbage collected.
+          AND NOT EXISTS (SELECT 1 FROM model_versions mv WHERE mv.checkpoint_uuid = c.uuid)
           AND c.trial_order_rank > const.trial_latest
           AND ((c.experiment_rank > const.experiment_best
                 AND c.trial_rank > const.trial_best)
@@ -1177,7 +1424,8 @@ RETURNING id`, trial)
 func (db *PgDB) TrialByID(id int) (*model.Trial, error) {
 	trial := model.Trial{}
 	if err := db.query(`
-SELECT id, experiment_id, state, start_time, end_time, hparams, warm_start_checkpoint_id, seed
+SELECT id, experiment_id, state, start_time, end_time, hparams, warm_start_checkpoint_id, seed,
+       restarts_due_to_node_failure
 FROM trials
 WHERE id = $1`, &trial, id); err != nil {
 		return nil, errors.Wrapf(err, "error querying for trial %v", id)
@@ -1217,6 +1465,33 @@ WHERE id = :id`, setClause(toUpdate)), trial)
 	return nil
 }
 
+// UpdateTrialEndReason records why a trial ended, for reasons not implied by its state.
+func (db *PgDB) UpdateTrialEndReason(id int, reason string) error {
+	if _, err := db.sql.Exec(
+		`UPDATE trials SET end_reason = $1 WHERE id = $2`, reason, id); err != nil {
+		return errors.Wrapf(err, "error updating end reason of trial %v", id)
+	}
+	return nil
+}
+
+// UpdateTrialNodeFailureRestarts records how many runs of a trial were lost because its
+// containers or agents stopped sending heartbeats.
+func (db *PgDB) UpdateTrialNodeFailureRestarts(id int, restarts int) error {
+	if _, err := db.sql.Exec(`UPDATE trials SET restarts_due_to_node_failure = $1 WHERE id = $2`,
+		restarts, id); err != nil {
+		return errors.Wrapf(err, "error updating node failure restarts of trial %v", id)
+	}
+	return nil
+}
+
+// AddTrialFailure records the classified failure of a run of a trial.
+func (db *PgDB) AddTrialFailure(failure *model.TrialFailure) error {
+	_, err := db.sql.NamedExec(`
+INSERT INTO trial_failures (trial_id, category, exit_code, message, time)
+VALUES (:trial_id, :category, :exit_code, :message, :time)`, failure)
+	return errors.Wrapf(err, "error recording failure of trial %d", failure.TrialID)
+}
+
 // RollbackSearcherEvents rolls back the events for an experiment to the last step with a
 // checkpoint. This is (and should only be) called by master restart to roll searcher events back
 // to the last checkpoint for each trial in the given experiment.
@@ -1269,27 +1544,6 @@ WHERE trial_id = $1 AND step_id = $2 AND state != 'COMPLETED'
 // TrialDetailsRaw returns a trial as a JSON string. This includes checkpoints and
 // validations for every step, plus aggregated training metrics and full validation metrics.
 func (db *PgDB) TrialDetailsRaw(id int) ([]byte, error) {
-	// Find the desired metric names and construct parts of the query appropriately.
-	var metricNames []string
-	if err := db.sql.Select(&metricNames, `
-SELECT jsonb_object_keys(s.metrics->'batch_metrics'->0)
-FROM (
-    SELECT metrics
-    FROM steps
-    WHERE trial_id = $1 AND state = 'COMPLETED'
-    LIMIT 1
-) s
-`, id); err != nil {
-		return nil, errors.Wrapf(err, "failed to get metric names for trial %d", id)
-	}
-
-	averageMetrics := make([]string, 0, len(metricNames))
-	for _, name := range metricNames {
-		averageMetrics = append(averageMetrics,
-			fmt.Sprintf(`avg(try_float8_cast(value->>'%s')) AS "%s"`, name, name),
-		)
-	}
-
 	// We want to average the per-batch training metrics into per-step metrics.
 	// Newer runners compute the averages in the metrics already. For legacy
 	// data, we compute the averages on the fly.
@@ -1314,11 +1568,34 @@ WITH const AS (
 SELECT row_to_json(r1)::text
 FROM (
     SELECT t.end_time, t.experiment_id, t.hparams, t.id, t.seed, t.start_time, t.state,
-           t.warm_start_checkpoint_id,
+           t.warm_start_checkpoint_id, t.restarts_due_to_node_failure,
            (SELECT coalesce(sum(s.num_batches), 0)
             FROM steps s
             WHERE s.trial_id = t.id AND s.state = 'COMPLETED'
            ) AS total_batches_processed,
+           (SELECT coalesce(sum(extract(epoch FROM a.start_time - a.request_time)), 0)
+            FROM allocation_sessions a JOIN trial_tasks tt ON a.task_id = tt.task_id
+            WHERE tt.trial_id = t.id AND a.request_time IS NOT NULL
+           ) AS queued_time,
+           (SELECT coalesce(
+                       sum(extract(epoch FROM coalesce(a.end_time, now()) - a.start_time)), 0)
+            FROM allocation_sessions a JOIN trial_tasks tt ON a.task_id = tt.task_id
+            WHERE tt.trial_id = t.id
+           ) AS running_time,
+           -- Why the latest allocation of the trial failed or was cut short, if it was.
+           (SELECT a.failure_reason
+            FROM allocation_sessions a JOIN trial_tasks tt ON a.task_id = tt.task_id
+            WHERE tt.trial_id = t.id
+            ORDER BY a.id DESC
+            LIMIT 1
+           ) AS failure_reason,
+           (SELECT coalesce(jsonb_agg(row_to_json(f) ORDER BY f.id ASC), '[]'::jsonb)
+            FROM (
+                SELECT f.id, f.category, f.exit_code, f.message, f.time
+                FROM trial_failures f
+                WHERE f.trial_id = t.id
+            ) f
+           ) AS failures,
            (SELECT coalesce(jsonb_agg(row_to_json(r2) ORDER BY r2.id ASC), '[]'::jsonb)
             FROM (
                 SELECT s.end_time, s.id, s.state, s.start_time, s.num_batches,
@@ -1326,11 +1603,7 @@ FROM (
                        (SELECT CASE
                            WHEN s.metrics->'avg_metrics' IS NOT NULL THEN
                                (s.metrics->'avg_metrics')::json
-                           ELSE (SELECT row_to_json(r3)
-                                 FROM
-                                    (SELECT %s
-                                     FROM jsonb_array_elements(s.metrics->'batch_metrics')
-                                    ) r3)
+                           ELSE %s::json
                         END) AS avg_metrics,
                        (SELECT row_to_json(r4)
                         FROM (
@@ -1357,7 +1630,7 @@ FROM (
    WHERE t.id = $1
 ) r1;`
 
-	return db.rawQuery(fmt.Sprintf(queryTemplate, strings.Join(averageMetrics, ",")), id)
+	return db.rawQuery(fmt.Sprintf(queryTemplate, batchMetricsAggregate("avg(%s)")), id)
 }
 
 // AddTrialLogs adds a list of *model.TrialLog objects to the database with automatic IDs.
@@ -1399,53 +1672,6 @@ INSERT INTO trial_logs
 	return nil
 }
 
-// TrialLogsRaw returns the logs for a trial as a JSON string.
-func (db *PgDB) TrialLogsRaw(
-	id int,
-	greaterThan, lessThan *int,
-	limit *int,
-) ([]*model.LogMessage, error) {
-	innerQuery := `
-SELECT id, message
-FROM trial_logs
-WHERE trial_id = $1 AND (id > $2 OR $2 IS NULL) AND (id < $3 OR $3 IS NULL)
-`
-	var rows *sqlx.Rows
-	var err error
-
-	if limit != nil {
-		rows, err = db.sql.Queryx(fmt.Sprintf(`
-SELECT * FROM (
-	%s
-	ORDER BY id DESC LIMIT $4
-) r2
-ORDER BY id ASC`, innerQuery), id, greaterThan, lessThan, *limit)
-	} else {
-		rows, err = db.sql.Queryx(fmt.Sprintf(`
-%s
-ORDER BY id ASC
-`, innerQuery), id, greaterThan, lessThan)
-	}
-
-	if err == sql.ErrNoRows {
-		return nil, nil
-	} else if err != nil {
-		return nil, errors.Wrap(err, "querying trial logs")
-	}
-	defer rows.Close()
-
-	var logs []*model.LogMessage
-	for rows.Next() {
-		var msg model.LogMessage
-		if err = rows.StructScan(&msg); err != nil {
-			return nil, errors.Wrap(err, "scanning row")
-		}
-		logs = append(logs, &msg)
-	}
-
-	return logs, nil
-}
-
 // AddStep adds the step to the database.
 func (db *PgDB) AddStep(step *model.Step) error {
 	if !step.IsNew() {
@@ -1858,6 +2084,54 @@ WHERE id = :id`, setClause(toUpdate)), checkpoint)
 	return nil
 }
 
+// PatchCheckpointMetadata replaces the metadata of a checkpoint with the result of patch, in a
+// transaction that locks the checkpoint so that concurrent patches do not lose updates. It returns
+// the new metadata, or ErrNotFound if no checkpoint has the UUID. Errors of patch are returned as
+// they are, and leave the checkpoint unchanged.
+func (db *PgDB) PatchCheckpointMetadata(
+	id uuid.UUID, patch func(model.JSONObj) (model.JSONObj, error),
+) (model.JSONObj, error) {
+	tx, err := db.sql.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "error starting transaction")
+	}
+	defer func() {
+		if tx == nil {
+			return
+		}
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Errorf("during rollback: %v", rErr)
+		}
+	}()
+
+	var metadata model.JSONObj
+	switch err = tx.Get(
+		&metadata, "SELECT metadata FROM checkpoints WHERE uuid = $1 FOR UPDATE", id,
+	); {
+	case err == sql.ErrNoRows:
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, errors.Wrapf(err, "error querying for metadata of checkpoint (%v)", id)
+	}
+	if metadata == nil {
+		metadata = model.JSONObj{}
+	}
+	if metadata, err = patch(metadata); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(
+		"UPDATE checkpoints SET metadata = $2 WHERE uuid = $1", id, metadata,
+	); err != nil {
+		return nil, errors.Wrapf(err, "error updating metadata of checkpoint (%v)", id)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Wrapf(err, "error committing metadata of checkpoint (%v)", id)
+	}
+	tx = nil
+	return metadata, nil
+}
+
 // AddSearcherEvents adds the searcher events to the database.
 func (db *PgDB) AddSearcherEvents(events []*model.SearcherEvent) error {
 	if len(events) == 0 {
@@ -1914,27 +2188,66 @@ func (db *PgDB) DeleteSearcherEvents(expID int) error {
 }
 
 // DeleteSearcherEventsForTerminalStateExperiments deletes all searcher events for
-// terminal state experiments from the database. This is used to clean up searcher
-// events if master crashes before deleting searcher events.
-func (db *PgDB) DeleteSearcherEventsForTerminalStateExperiments() error {
+// terminal state experiments that ended more than retention ago from the database and returns
+// the number of deleted events. This is used to clean up searcher events if master crashes
+// before deleting searcher events, or if their deletion was deferred by a retention window.
+func (db *PgDB) DeleteSearcherEventsForTerminalStateExperiments(
+	retention time.Duration,
+) (int64, error) {
 	res, err := db.sql.Exec(`
 DELETE FROM searcher_events
 WHERE experiment_id IN (
 	SELECT id
 	FROM experiments
-	WHERE state IN ('COMPLETED', 'CANCELED', 'ERROR'))`)
+	WHERE state IN ('COMPLETED', 'CANCELED', 'ERROR', 'CANCELED_DEPENDENCY')
+		AND coalesce(end_time, start_time) < now() - $1 * interval '1 second')`,
+		retention.Seconds())
 	if err != nil {
-		return err
+		return 0, errors.Wrap(err, "error deleting searcher events for terminal state experiments")
 	}
 
 	num, err := res.RowsAffected()
 	if err != nil {
-		log.Errorf(
-			"RowsAffected failed in deleting searcher events for terminal state experiments. error: %v", err)
-		return nil
+		return 0, errors.Wrap(err,
+			"RowsAffected failed in deleting searcher events for terminal state experiments")
 	}
-	log.Debugf("deleted total %v searcher events for terminal state experiments", num)
-	return nil
+	return num, nil
+}
+
+// ArchiveTerminalStateExperiments archives the unarchived experiments that have been in a terminal
+// state for longer than the given age and returns how many it archived. Experiments are only ever
+// archived this way once, so that they stay unarchived if a user unarchives them.
+func (db *PgDB) ArchiveTerminalStateExperiments(age time.Duration) (int64, error) {
+	res, err := db.sql.Exec(`
+UPDATE experiments
+SET archived = true, auto_archived = true
+WHERE NOT archived AND NOT auto_archived
+	AND state IN ('COMPLETED', 'CANCELED', 'ERROR', 'CANCELED_DEPENDENCY')
+	AND coalesce(end_time, start_time) < now() - $1 * interval '1 second'`, age.Seconds())
+	if err != nil {
+		return 0, errors.Wrap(err, "error archiving terminal state experiments")
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "RowsAffected failed in archiving terminal state experiments")
+	}
+	return num, nil
+}
+
+// SearcherEventsRowCount returns an estimate of the number of rows in the searcher_events table.
+// An estimate is used because an exact count requires a full scan of a potentially huge table.
+func (db *PgDB) SearcherEventsRowCount() (int64, error) {
+	var count float64
+	if err := db.sql.QueryRow(`
+SELECT reltuples FROM pg_class WHERE oid = 'searcher_events'::regclass`).Scan(&count); err != nil {
+		return 0, errors.Wrap(err, "error estimating the number of searcher events")
+	}
+	// The estimate is negative if the table has never been analyzed.
+	if count < 0 {
+		return 0, nil
+	}
+	return int64(count), nil
 }
 
 // PeriodicTelemetryInfo returns anonymous information about the usage of the current
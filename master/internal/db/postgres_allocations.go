@@ -0,0 +1,177 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddAllocation records that slots were allocated to a task.
+func (db *PgDB) AddAllocation(session *model.AllocationSession) error {
+	_, err := db.sql.NamedExec(`
+INSERT INTO allocation_sessions
+    (task_id, resource_pool, slots, user_id, experiment_id, command_id, request_time,
+     start_time)
+VALUES
+    (:task_id, :resource_pool, :slots, :user_id, :experiment_id, :command_id, :request_time,
+     :start_time)`,
+		session)
+	return errors.Wrapf(err, "error recording allocation of task %s", session.TaskID)
+}
+
+// AddTrialTask records that a task of the resource manager belongs to a trial, so that the
+// allocations of the task count toward the queued and running time of the trial.
+func (db *PgDB) AddTrialTask(trialID int, taskID string) error {
+	_, err := db.sql.Exec(`
+INSERT INTO trial_tasks (task_id, trial_id) VALUES ($1, $2)
+ON CONFLICT (task_id) DO NOTHING`, taskID, trialID)
+	return errors.Wrapf(err, "error linking task %s to trial %d", taskID, trialID)
+}
+
+// EndAllocation records that the slots allocated to a task were released.
+func (db *PgDB) EndAllocation(taskID string, end time.Time) error {
+	_, err := db.sql.Exec(`
+UPDATE allocation_sessions SET end_time = $2
+WHERE task_id = $1 AND end_time IS NULL`, taskID, end)
+	return errors.Wrapf(err, "error recording end of allocation of task %s", taskID)
+}
+
+// SetAllocationFailure records why the latest allocation of a task failed, unless it already
+// failed. The reason is kept if the task already classified the failure.
+func (db *PgDB) SetAllocationFailure(
+	taskID string, reason model.FailureReason, failureType, message string,
+) error {
+	_, err := db.sql.Exec(`
+UPDATE allocation_sessions
+SET failure_reason = coalesce(failure_reason, $2), failure_type = $3, failure_message = $4
+WHERE id = (SELECT max(id) FROM allocation_sessions WHERE task_id = $1)
+  AND failure_type IS NULL`, taskID, reason, failureType, message)
+	return errors.Wrapf(err, "error recording failure of allocation of task %s", taskID)
+}
+
+// SetAllocationFailureReason records why the latest allocation of a task failed or was cut short,
+// replacing the reason of the failure of its containers.
+func (db *PgDB) SetAllocationFailureReason(taskID string, reason model.FailureReason) error {
+	_, err := db.sql.Exec(`
+UPDATE allocation_sessions SET failure_reason = $2
+WHERE id = (SELECT max(id) FROM allocation_sessions WHERE task_id = $1)`, taskID, reason)
+	return errors.Wrapf(err, "error recording failure reason of allocation of task %s", taskID)
+}
+
+// AddTaskContainerLog records a log of the lifecycle of a container of a task.
+func (db *PgDB) AddTaskContainerLog(log *model.TaskContainerLog) error {
+	_, err := db.sql.NamedExec(`
+INSERT INTO task_container_logs (task_id, container_id, timestamp, source, level, message)
+VALUES (:task_id, :container_id, :timestamp, :source, :level, :message)`, log)
+	return errors.Wrapf(err, "error recording container log of task %s", log.TaskID)
+}
+
+// TaskContainerLogs returns the logs of the lifecycle of the containers of a task, oldest first.
+func (db *PgDB) TaskContainerLogs(taskID string) ([]model.TaskContainerLog, error) {
+	var logs []model.TaskContainerLog
+	if err := db.queryRows(`
+SELECT id, task_id, container_id, timestamp, source, level, message
+FROM task_container_logs
+WHERE task_id = $1
+ORDER BY id`, &logs, taskID); err != nil {
+		return nil, errors.Wrapf(err, "error querying for container logs of task %s", taskID)
+	}
+	return logs, nil
+}
+
+// DeleteTaskContainerLogsBefore deletes the logs of the lifecycle of task containers that were
+// logged before the given time and returns how many it deleted.
+func (db *PgDB) DeleteTaskContainerLogsBefore(before time.Time) (int64, error) {
+	res, err := db.sql.Exec(`DELETE FROM task_container_logs WHERE timestamp < $1`, before)
+	if err != nil {
+		return 0, errors.Wrap(err, "error deleting task container logs")
+	}
+	num, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "RowsAffected failed in deleting task container logs")
+	}
+	return num, nil
+}
+
+// TaskAllocations returns the allocation sessions of a task, oldest first.
+func (db *PgDB) TaskAllocations(taskID string) ([]model.AllocationSession, error) {
+	var sessions []model.AllocationSession
+	if err := db.queryRows(`
+SELECT id, task_id, resource_pool, slots, user_id, experiment_id, command_id, request_time,
+    start_time, end_time, failure_type, failure_message, failure_reason
+FROM allocation_sessions
+WHERE task_id = $1
+ORDER BY id`, &sessions, taskID); err != nil {
+		return nil, errors.Wrapf(err, "error querying for allocations of task %s", taskID)
+	}
+	return sessions, nil
+}
+
+// EndAllAllocations records that all allocations have ended, e.g., because the master restarted.
+func (db *PgDB) EndAllAllocations(end time.Time) error {
+	_, err := db.sql.Exec(`
+UPDATE allocation_sessions SET end_time = greatest(start_time, $1)
+WHERE end_time IS NULL`, end)
+	return errors.Wrap(err, "error recording end of allocations")
+}
+
+// SlotUtilization returns the average number of allocated slots in each period of length
+// resolution between start and end. The last period is cut short if it would extend past end.
+// Allocations that have not ended count until the current time.
+func (db *PgDB) SlotUtilization(
+	start, end time.Time, resolution time.Duration,
+) ([]model.SlotUtilization, error) {
+	var utilization []model.SlotUtilization
+	if err := db.queryRows(`
+WITH buckets AS (
+    SELECT b AS start_time, least(b + $3::float8 * interval '1 second', $2) AS end_time
+    FROM generate_series($1::timestamptz, $2::timestamptz, $3::float8 * interval '1 second') b
+    WHERE b < $2
+)
+SELECT b.start_time, b.end_time,
+    coalesce(sum(a.slots * extract(epoch FROM
+        least(coalesce(a.end_time, now()), b.end_time) - greatest(a.start_time, b.start_time)
+    )), 0) / extract(epoch FROM b.end_time - b.start_time) AS slots
+FROM buckets b
+LEFT JOIN allocation_sessions a
+    ON a.start_time < b.end_time AND coalesce(a.end_time, now()) > b.start_time
+GROUP BY b.start_time, b.end_time
+ORDER BY b.start_time`, &utilization, start, end, resolution.Seconds()); err != nil {
+		return nil, errors.Wrap(err, "error computing slot utilization")
+	}
+	return utilization, nil
+}
+
+// usageGroups maps the ways that slot usage can be grouped to the SQL expressions that name the
+// group of an allocation session.
+var usageGroups = map[string]string{
+	"user":       "u.username",
+	"pool":       "a.resource_pool",
+	"experiment": "a.experiment_id::text",
+}
+
+// SlotUsage returns the number of slot-hours allocated between start and end, grouped by user,
+// resource pool or experiment. Sessions that have not ended count until the current time.
+func (db *PgDB) SlotUsage(start, end time.Time, groupBy string) ([]model.SlotUsage, error) {
+	group, ok := usageGroups[groupBy]
+	if !ok {
+		return nil, errors.Errorf("cannot group slot usage by %s", groupBy)
+	}
+	var usage []model.SlotUsage
+	if err := db.queryRows(fmt.Sprintf(`
+SELECT %s AS group_name,
+    sum(a.slots * extract(epoch FROM
+        least(coalesce(a.end_time, now()), $2) - greatest(a.start_time, $1)
+    )) / 3600 AS slot_hours
+FROM allocation_sessions a
+LEFT JOIN users u ON u.id = a.user_id
+WHERE a.slots > 0 AND a.start_time < $2 AND coalesce(a.end_time, now()) > $1
+GROUP BY group_name
+ORDER BY slot_hours DESC, group_name`, group), &usage, start, end); err != nil {
+		return nil, errors.Wrap(err, "error computing slot usage")
+	}
+	return usage, nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestDeleteTaskContainerLogsBefore(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	now := time.Now()
+	for _, log := range []*model.TaskContainerLog{
+		{TaskID: "old-task", Timestamp: now.Add(-48 * time.Hour)},
+		{TaskID: "new-task", Timestamp: now.Add(-time.Hour)},
+	} {
+		log.ContainerID, log.Source, log.Level, log.Message = "container", "agent", "INFO", "pulled"
+		assert.NilError(t, db.AddTaskContainerLog(log))
+	}
+
+	deleted, err := db.DeleteTaskContainerLogsBefore(now.Add(-24 * time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, deleted, int64(1))
+
+	logs, err := db.TaskContainerLogs("old-task")
+	assert.NilError(t, err)
+	assert.Equal(t, len(logs), 0)
+	logs, err = db.TaskContainerLogs("new-task")
+	assert.NilError(t, err)
+	assert.Equal(t, len(logs), 1)
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// CheckpointTrialRef is a trial that was warm-started from a checkpoint.
+type CheckpointTrialRef struct {
+	TrialID      int         `db:"trial_id" json:"trial_id"`
+	ExperimentID int         `db:"experiment_id" json:"experiment_id"`
+	State        model.State `db:"state" json:"state"`
+}
+
+// CheckpointExperimentRef is an experiment whose searcher starts from a checkpoint.
+type CheckpointExperimentRef struct {
+	ExperimentID int         `db:"experiment_id" json:"experiment_id"`
+	State        model.State `db:"state" json:"state"`
+}
+
+// CheckpointReferences lists everything that uses a checkpoint as a source.
+type CheckpointReferences struct {
+	Trials        []CheckpointTrialRef      `json:"trials"`
+	Experiments   []CheckpointExperimentRef `json:"experiments"`
+	ModelVersions []ModelVersionRef         `json:"model_versions"`
+}
+
+// CheckpointReferences returns the trials that were warm-started from the checkpoint with the
+// given UUID, the experiments configured to start from it, and the model versions registered from
+// it, each ordered by ID.
+func (db *PgDB) CheckpointReferences(uuid string) (*CheckpointReferences, error) {
+	var refs CheckpointReferences
+	if err := db.queryRows(`
+SELECT t.id AS trial_id, t.experiment_id, t.state
+FROM trials t
+JOIN checkpoints c ON c.id = t.warm_start_checkpoint_id
+WHERE c.uuid = $1
+ORDER BY t.id`, &refs.Trials, uuid); err != nil {
+		return nil, errors.Wrapf(err, "error querying trials that use checkpoint %s", uuid)
+	}
+	if err := db.queryRows(`
+SELECT id AS experiment_id, state
+FROM experiments
+WHERE config->'searcher'->>'source_checkpoint_uuid' = $1
+ORDER BY id`, &refs.Experiments, uuid); err != nil {
+		return nil, errors.Wrapf(err, "error querying experiments that use checkpoint %s", uuid)
+	}
+	if err := db.queryRows(`
+SELECT model_name, version
+FROM model_versions
+WHERE checkpoint_uuid = $1
+ORDER BY model_name, version`, &refs.ModelVersions, uuid); err != nil {
+		return nil, errors.Wrapf(err, "error querying model versions of checkpoint %s", uuid)
+	}
+	return &refs, nil
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// experimentDependenciesRow is a row of the experiment_dependencies table as it is stored.
+type experimentDependenciesRow struct {
+	ExperimentID int                       `db:"experiment_id"`
+	DependsOn    pq.Int64Array             `db:"depends_on"`
+	Condition    model.DependencyCondition `db:"condition"`
+	WarmStart    bool                      `db:"warm_start"`
+}
+
+func (r experimentDependenciesRow) toModel() model.ExperimentDependencies {
+	deps := model.ExperimentDependencies{
+		ExperimentID: r.ExperimentID,
+		DependsOn:    make([]int, 0, len(r.DependsOn)),
+		Condition:    r.Condition,
+		WarmStart:    r.WarmStart,
+	}
+	for _, id := range r.DependsOn {
+		deps.DependsOn = append(deps.DependsOn, int(id))
+	}
+	return deps
+}
+
+// AddQueuedExperiment adds an experiment in the QUEUED_DEPENDENCY state along with the experiments
+// it depends on, setting the ID of both.
+func (db *PgDB) AddQueuedExperiment(
+	experiment *model.Experiment, deps *model.ExperimentDependencies,
+) error {
+	if experiment.ID != 0 {
+		return errors.Errorf("error adding an experiment with non-zero id %v", experiment.ID)
+	}
+	if experiment.State != model.QueuedDependencyState {
+		return errors.Errorf("error adding an experiment in state %v as queued", experiment.State)
+	}
+
+	tx, err := db.sql.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer func() {
+		if tx == nil {
+			return
+		}
+
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Errorf("during rollback: %v", rErr)
+		}
+	}()
+
+	stmt, err := tx.PrepareNamed(`
+INSERT INTO experiments
+(state, config, model_definition, model_definition_hash, start_time, end_time, archived,
+ git_remote, git_commit, git_committer, git_commit_date, owner_id, external_id,
+ external_id_unique, schedule_id, team_id)
+VALUES (:state, :config, :model_definition, :model_definition_hash, :start_time, :end_time,
+        :archived, :git_remote, :git_commit, :git_committer, :git_commit_date, :owner_id,
+        :external_id, :external_id_unique, :schedule_id, :team_id)
+RETURNING id`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing to insert queued experiment")
+	}
+	defer stmt.Close()
+	err = stmt.Get(&experiment.ID, experimentRow(experiment))
+	if isDuplicateExternalID(err) {
+		return ErrDuplicateRecord
+	}
+	if err != nil {
+		return errors.Wrap(err, "error inserting queued experiment")
+	}
+
+	dependsOn := make(pq.Int64Array, 0, len(deps.DependsOn))
+	for _, id := range deps.DependsOn {
+		dependsOn = append(dependsOn, int64(id))
+	}
+	if _, err = tx.Exec(`
+INSERT INTO experiment_dependencies (experiment_id, depends_on, condition, warm_start)
+VALUES ($1, $2, $3, $4)`, experiment.ID, dependsOn, deps.Condition, deps.WarmStart); err != nil {
+		return errors.Wrapf(err, "error inserting dependencies of experiment %d", experiment.ID)
+	}
+	deps.ExperimentID = experiment.ID
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing queued experiment %d", experiment.ID)
+	}
+	tx = nil
+	return nil
+}
+
+// ExperimentDependenciesByID returns the experiments that an experiment depends on, returning
+// ErrNotFound if it was not submitted with dependencies.
+func (db *PgDB) ExperimentDependenciesByID(id int) (*model.ExperimentDependencies, error) {
+	var row experimentDependenciesRow
+	if err := db.query(`
+SELECT experiment_id, depends_on, condition, warm_start
+FROM experiment_dependencies
+WHERE experiment_id = $1`, &row, id); err != nil {
+		return nil, errors.Wrapf(err, "error querying for dependencies of experiment %d", id)
+	}
+	deps := row.toModel()
+	return &deps, nil
+}
+
+// QueuedExperimentDependencies returns the dependencies of the experiments that are waiting on
+// them, ordered by experiment ID.
+func (db *PgDB) QueuedExperimentDependencies() ([]model.ExperimentDependencies, error) {
+	var rows []experimentDependenciesRow
+	if err := db.queryRows(`
+SELECT d.experiment_id, d.depends_on, d.condition, d.warm_start
+FROM experiment_dependencies d
+JOIN experiments e ON e.id = d.experiment_id
+WHERE e.state = 'QUEUED_DEPENDENCY'
+ORDER BY d.experiment_id`, &rows); err != nil {
+		return nil, errors.Wrap(err, "error querying for queued experiments")
+	}
+	deps := make([]model.ExperimentDependencies, 0, len(rows))
+	for _, row := range rows {
+		deps = append(deps, row.toModel())
+	}
+	return deps, nil
+}
+
+// ExperimentDependents returns the IDs of the experiments that depend on an experiment, in order.
+func (db *PgDB) ExperimentDependents(id int) ([]int, error) {
+	ids := []int{}
+	if err := db.sql.Select(&ids, `
+SELECT experiment_id FROM experiment_dependencies WHERE depends_on @> ARRAY[$1::integer]
+ORDER BY experiment_id`, id); err != nil {
+		return nil, errors.Wrapf(err, "error querying for dependents of experiment %d", id)
+	}
+	return ids, nil
+}
+
+// ExperimentStates returns the states of the experiments with the given IDs that exist.
+func (db *PgDB) ExperimentStates(ids []int) (map[int]model.State, error) {
+	var rows []struct {
+		ID    int         `db:"id"`
+		State model.State `db:"state"`
+	}
+	if err := db.sql.Select(&rows, `
+SELECT id, state FROM experiments WHERE id = ANY($1::integer[])`, pq.Array(ids)); err != nil {
+		return nil, errors.Wrap(err, "error querying for experiment states")
+	}
+	states := make(map[int]model.State, len(rows))
+	for _, row := range rows {
+		states[row.ID] = row.State
+	}
+	return states, nil
+}
+
+// TransitionQueuedExperiment moves an experiment out of the QUEUED_DEPENDENCY state, returning
+// false if it was no longer queued, e.g., because it was canceled concurrently.
+func (db *PgDB) TransitionQueuedExperiment(id int, state model.State) (bool, error) {
+	if !model.ExperimentTransitions[model.QueuedDependencyState][state] {
+		return false, errors.Errorf(
+			"illegal transition %v -> %v for experiment %v", model.QueuedDependencyState, state, id)
+	}
+	var endTime *time.Time
+	if model.TerminalStates[state] {
+		now := time.Now().UTC()
+		endTime = &now
+	}
+	res, err := db.sql.Exec(`
+UPDATE experiments SET state = $2, end_time = $3
+WHERE id = $1 AND state = 'QUEUED_DEPENDENCY'`, id, state, endTime)
+	if err != nil {
+		return false, errors.Wrapf(err, "error moving experiment %d out of the queue", id)
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err,
+			"checking affected rows for moving experiment %d out of the queue", id)
+	}
+	return numRows > 0, nil
+}
+
+// BestExperimentCheckpointUUID returns the UUID of the completed checkpoint of an experiment with
+// the best value of the given validation metric, or nil if none of its checkpoints was validated
+// with the metric.
+func (db *PgDB) BestExperimentCheckpointUUID(
+	experimentID int, metricName string, smallerIsBetter bool,
+) (*string, error) {
+	order := desc
+	if smallerIsBetter {
+		order = asc
+	}
+	var uuid string
+	switch err := db.sql.Get(&uuid, fmt.Sprintf(`
+SELECT c.uuid::text
+FROM checkpoints c
+JOIN trials t ON t.id = c.trial_id
+JOIN validations v ON v.trial_id = c.trial_id AND v.step_id = c.step_id
+WHERE t.experiment_id = $1
+  AND c.state = 'COMPLETED'
+  AND v.state = 'COMPLETED'
+  AND jsonb_typeof(v.metrics->'validation_metrics'->$2) = 'number'
+ORDER BY (v.metrics->'validation_metrics'->>$2)::float8 %s, c.id
+LIMIT 1`, order), experimentID, metricName); {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, errors.Wrapf(err,
+			"error querying for best checkpoint of experiment %d", experimentID)
+	}
+	return &uuid, nil
+}
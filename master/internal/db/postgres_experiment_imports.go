@@ -0,0 +1,210 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ExperimentTrials returns the trials of an experiment ordered by ID.
+func (db *PgDB) ExperimentTrials(experimentID int) ([]model.Trial, error) {
+	trials := []model.Trial{}
+	if err := db.queryRows(`
+SELECT id, experiment_id, state, start_time, end_time, hparams, warm_start_checkpoint_id, seed
+FROM trials
+WHERE experiment_id = $1
+ORDER BY id ASC`, &trials, experimentID); err != nil {
+		return nil, errors.Wrapf(err, "error querying trials of experiment %d", experimentID)
+	}
+	return trials, nil
+}
+
+// TrialSteps returns the steps of a trial ordered by ID.
+func (db *PgDB) TrialSteps(trialID int) ([]model.Step, error) {
+	steps := []model.Step{}
+	if err := db.queryRows(`
+SELECT trial_id, id, state, start_time, end_time, metrics,
+       coalesce(num_batches, 0) AS num_batches,
+       coalesce(prior_batches_processed, 0) AS prior_batches_processed
+FROM steps
+WHERE trial_id = $1
+ORDER BY id ASC`, &steps, trialID); err != nil {
+		return nil, errors.Wrapf(err, "error querying steps of trial %d", trialID)
+	}
+	return steps, nil
+}
+
+// TrialValidations returns the validations of a trial ordered by step.
+func (db *PgDB) TrialValidations(trialID int) ([]model.Validation, error) {
+	validations := []model.Validation{}
+	if err := db.queryRows(`
+SELECT id, trial_id, step_id, state, start_time, end_time, metrics
+FROM validations
+WHERE trial_id = $1
+ORDER BY step_id ASC`, &validations, trialID); err != nil {
+		return nil, errors.Wrapf(err, "error querying validations of trial %d", trialID)
+	}
+	return validations, nil
+}
+
+// TrialCheckpoints returns the checkpoints of a trial ordered by step.
+func (db *PgDB) TrialCheckpoints(trialID int) ([]model.Checkpoint, error) {
+	checkpoints := []model.Checkpoint{}
+	if err := db.queryRows(`
+SELECT id, trial_id, step_id, state, start_time, end_time, uuid, resources, metadata,
+       coalesce(framework, '') AS framework, coalesce(format, '') AS format,
+       coalesce(determined_version, '') AS determined_version
+FROM checkpoints
+WHERE trial_id = $1
+ORDER BY step_id ASC`, &checkpoints, trialID); err != nil {
+		return nil, errors.Wrapf(err, "error querying checkpoints of trial %d", trialID)
+	}
+	return checkpoints, nil
+}
+
+// ImportedTrial is a trial of an imported experiment along with its steps, validations and
+// checkpoints.
+type ImportedTrial struct {
+	Trial       model.Trial
+	Steps       []model.Step
+	Validations []model.Validation
+	Checkpoints []model.Checkpoint
+}
+
+// ExperimentImport adds an experiment that was exported from another cluster, and then its
+// trials one at a time, in one transaction. Rows are inserted as they are, so callers must make
+// sure that they are in terminal states. Missing metrics and resources are stored as NULL.
+// Checkpoints whose UUIDs this cluster already tracks are skipped.
+type ExperimentImport struct {
+	tx         *sqlx.Tx
+	experiment *model.Experiment
+}
+
+// BeginExperimentImport starts importing an experiment, setting its ID. The import must be
+// committed or rolled back.
+func (db *PgDB) BeginExperimentImport(experiment *model.Experiment) (*ExperimentImport, error) {
+	if experiment.ID != 0 {
+		return nil, errors.Errorf(
+			"error importing an experiment with non-zero id %v", experiment.ID)
+	}
+	tx, err := db.sql.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "error starting transaction")
+	}
+	if err = namedGetTx(tx, &experiment.ID, `
+INSERT INTO experiments
+(state, config, model_definition, model_definition_hash, start_time, end_time, archived,
+ git_remote, git_commit, git_committer, git_commit_date, owner_id,
+ imported_from_cluster_id, imported_from_experiment_id)
+VALUES (:state, :config, :model_definition, :model_definition_hash, :start_time, :end_time,
+        :archived, :git_remote, :git_commit, :git_committer, :git_commit_date, :owner_id,
+        :imported_from_cluster_id, :imported_from_experiment_id)
+RETURNING id`, experimentRow(experiment)); err != nil {
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Errorf("error during rollback: %v", rErr)
+		}
+		return nil, errors.Wrap(err, "error inserting imported experiment")
+	}
+	return &ExperimentImport{tx: tx, experiment: experiment}, nil
+}
+
+// AddTrial adds a trial of the imported experiment. It sets the ID of the trial; the trial IDs of
+// its steps, validations and checkpoints are set to match.
+func (i *ExperimentImport) AddTrial(trial *ImportedTrial) error {
+	return importTrial(i.tx, i.experiment.ID, trial)
+}
+
+// Commit records the state of the imported experiment and commits the import.
+func (i *ExperimentImport) Commit() error {
+	if _, err := i.tx.NamedExec(`
+INSERT INTO experiment_state_transitions
+    (experiment_id, from_state, to_state, timestamp, reason)
+VALUES
+    (:experiment_id, :from_state, :to_state, :timestamp, :reason)`, model.ExperimentStateTransition{
+		ExperimentID: i.experiment.ID,
+		To:           i.experiment.State,
+		Timestamp:    i.experiment.StartTime,
+		Reason:       "experiment imported from cluster " + *i.experiment.ImportedFromClusterID,
+	}); err != nil {
+		return errors.Wrap(err, "error recording state of imported experiment")
+	}
+	if err := i.tx.Commit(); err != nil {
+		return errors.Wrap(err, "error committing imported experiment")
+	}
+	return nil
+}
+
+// Rollback abandons the import, unless it was committed.
+func (i *ExperimentImport) Rollback() {
+	if err := i.tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		log.Errorf("error during rollback: %v", err)
+	}
+}
+
+func importTrial(tx *sqlx.Tx, experimentID int, trial *ImportedTrial) error {
+	trial.Trial.ID = 0
+	trial.Trial.ExperimentID = experimentID
+	// Warm start checkpoints belong to the cluster that the experiment was exported from.
+	trial.Trial.WarmStartCheckpointID = nil
+	if err := namedGetTx(tx, &trial.Trial.ID, `
+INSERT INTO trials
+(experiment_id, state, start_time, end_time, hparams, warm_start_checkpoint_id, seed)
+VALUES (:experiment_id, :state, :start_time, :end_time, :hparams, :warm_start_checkpoint_id, :seed)
+RETURNING id`, &trial.Trial); err != nil {
+		return errors.Wrap(err, "error inserting imported trial")
+	}
+
+	for i := range trial.Steps {
+		trial.Steps[i].TrialID = trial.Trial.ID
+		if _, err := tx.NamedExec(`
+INSERT INTO steps
+(trial_id, id, state, start_time, end_time, num_batches, prior_batches_processed, metrics)
+VALUES (:trial_id, :id, :state, :start_time, :end_time, :num_batches, :prior_batches_processed,
+        nullif(CAST(:metrics AS jsonb), 'null'))`, &trial.Steps[i]); err != nil {
+			return errors.Wrapf(err, "error inserting step %d of imported trial", trial.Steps[i].ID)
+		}
+	}
+
+	for i := range trial.Validations {
+		trial.Validations[i].TrialID = trial.Trial.ID
+		if err := namedGetTx(tx, &trial.Validations[i].ID, `
+INSERT INTO validations
+(trial_id, step_id, state, start_time, end_time, metrics)
+VALUES (:trial_id, :step_id, :state, :start_time, :end_time,
+        nullif(CAST(:metrics AS jsonb), 'null'))
+RETURNING id`, &trial.Validations[i]); err != nil {
+			return errors.Wrapf(err, "error inserting validation of step %d of imported trial",
+				trial.Validations[i].StepID)
+		}
+	}
+
+	for i := range trial.Checkpoints {
+		trial.Checkpoints[i].TrialID = trial.Trial.ID
+		if _, err := tx.NamedExec(`
+INSERT INTO checkpoints
+(trial_id, step_id, state, start_time, end_time, uuid, resources, metadata,
+ framework, format, determined_version)
+VALUES (:trial_id, :step_id, :state, :start_time, :end_time, :uuid,
+        nullif(CAST(:resources AS jsonb), 'null'), :metadata,
+        :framework, :format, :determined_version)
+ON CONFLICT ON CONSTRAINT checkpoint_uuid_uniq DO NOTHING`, &trial.Checkpoints[i]); err != nil {
+			return errors.Wrapf(err, "error inserting checkpoint of step %d of imported trial",
+				trial.Checkpoints[i].StepID)
+		}
+	}
+	return nil
+}
+
+// namedGetTx is namedGet within a transaction.
+func namedGetTx(tx *sqlx.Tx, dest interface{}, query string, arg interface{}) error {
+	stmt, err := tx.PrepareNamed(query)
+	if err != nil {
+		return errors.Wrapf(err, "error preparing query %s", query)
+	}
+	defer stmt.Close()
+	return stmt.QueryRowx(arg).Scan(dest)
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// experimentScheduleColumns are the columns of experiment schedules other than the model
+// definition, which is only loaded to create experiments.
+const experimentScheduleColumns = `id, owner_id, config, git_remote, git_commit, git_committer,
+       git_commit_date, start_at, cron, max_runs, enabled, runs, last_run_time, next_run_time,
+       created_at`
+
+// AddExperimentSchedule adds the experiment schedule to the database and sets its ID.
+func (db *PgDB) AddExperimentSchedule(schedule *model.ExperimentSchedule) error {
+	if err := db.namedGet(&schedule.ID, `
+INSERT INTO experiment_schedules
+(owner_id, config, model_definition, git_remote, git_commit, git_committer, git_commit_date,
+ start_at, cron, max_runs, enabled, runs, last_run_time, next_run_time, created_at)
+VALUES (:owner_id, :config, :model_definition, :git_remote, :git_commit, :git_committer,
+        :git_commit_date, :start_at, :cron, :max_runs, :enabled, :runs, :last_run_time,
+        :next_run_time, :created_at)
+RETURNING id`, schedule); err != nil {
+		return errors.Wrap(err, "error inserting experiment schedule")
+	}
+	return nil
+}
+
+// ExperimentScheduleByID looks up an experiment schedule by ID, without its model definition,
+// returning ErrNotFound if it does not exist.
+func (db *PgDB) ExperimentScheduleByID(id int) (*model.ExperimentSchedule, error) {
+	var schedule model.ExperimentSchedule
+	if err := db.query(`
+SELECT `+experimentScheduleColumns+`
+FROM experiment_schedules
+WHERE id = $1`, &schedule, id); err != nil {
+		return nil, errors.Wrapf(err, "error querying for experiment schedule %d", id)
+	}
+	return &schedule, nil
+}
+
+// ExperimentSchedules returns the experiment schedules of the owner, or all of them if the owner is
+// nil, without their model definitions, ordered by ID.
+func (db *PgDB) ExperimentSchedules(ownerID *model.UserID) ([]model.ExperimentSchedule, error) {
+	var schedules []model.ExperimentSchedule
+	if err := db.queryRows(`
+SELECT `+experimentScheduleColumns+`
+FROM experiment_schedules
+WHERE $1::integer IS NULL OR owner_id = $1
+ORDER BY id`, &schedules, ownerID); err != nil {
+		return nil, errors.Wrap(err, "error querying for experiment schedules")
+	}
+	return schedules, nil
+}
+
+// DueExperimentSchedules returns the enabled experiment schedules that were due to fire by the
+// given time, along with their model definitions, ordered by when they were due.
+func (db *PgDB) DueExperimentSchedules(now time.Time) ([]model.ExperimentSchedule, error) {
+	var schedules []model.ExperimentSchedule
+	if err := db.queryRows(`
+SELECT `+experimentScheduleColumns+`, model_definition
+FROM experiment_schedules
+WHERE enabled AND next_run_time <= $1
+ORDER BY next_run_time, id`, &schedules, now); err != nil {
+		return nil, errors.Wrap(err, "error querying for due experiment schedules")
+	}
+	return schedules, nil
+}
+
+// UpdateExperimentSchedule saves whether the experiment schedule is enabled and when it ran and
+// runs next.
+func (db *PgDB) UpdateExperimentSchedule(schedule *model.ExperimentSchedule) error {
+	res, err := db.sql.NamedExec(`
+UPDATE experiment_schedules
+SET enabled = :enabled, runs = :runs, last_run_time = :last_run_time,
+    next_run_time = :next_run_time
+WHERE id = :id`, schedule)
+	if err != nil {
+		return errors.Wrapf(err, "error updating experiment schedule %d", schedule.ID)
+	}
+	if numRows, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "checking affected rows for updating experiment schedule %d",
+			schedule.ID)
+	} else if numRows == 0 {
+		return errors.WithStack(ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteExperimentSchedule deletes the experiment schedule. The experiments that it created are
+// kept.
+func (db *PgDB) DeleteExperimentSchedule(id int) error {
+	res, err := db.sql.Exec(`DELETE FROM experiment_schedules WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting experiment schedule %d", id)
+	}
+	if numRows, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "checking affected rows for deleting experiment schedule %d", id)
+	} else if numRows == 0 {
+		return errors.WithStack(ErrNotFound)
+	}
+	return nil
+}
+
+// ExperimentIDsBySchedule returns the IDs of the experiments that an experiment schedule created,
+// in order.
+func (db *PgDB) ExperimentIDsBySchedule(id int) ([]int, error) {
+	ids := []int{}
+	if err := db.sql.Select(&ids, `
+SELECT id FROM experiments WHERE schedule_id = $1 ORDER BY id`, id); err != nil {
+		return nil, errors.Wrapf(err, "error querying for experiments of schedule %d", id)
+	}
+	return ids, nil
+}
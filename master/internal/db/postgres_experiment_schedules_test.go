@@ -0,0 +1,57 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestExperimentSchedulesOfOwner(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	scheduleIDs := map[string]int{}
+	owners := map[string]model.UserID{}
+	for _, username := range []string{"admin", "determined"} {
+		var ownerID model.UserID
+		assert.NilError(t, db.sql.Get(&ownerID, `SELECT id FROM users WHERE username = $1`,
+			username))
+		schedule := &model.ExperimentSchedule{
+			OwnerID:              ownerID,
+			ModelDefinitionBytes: []byte("model"),
+			Enabled:              true,
+			CreatedAt:            time.Now().UTC(),
+		}
+		assert.NilError(t, db.AddExperimentSchedule(schedule))
+		scheduleIDs[username] = schedule.ID
+		owners[username] = ownerID
+	}
+	ids := func(schedules []model.ExperimentSchedule) map[int]model.UserID {
+		ids := map[int]model.UserID{}
+		for _, schedule := range schedules {
+			ids[schedule.ID] = schedule.OwnerID
+		}
+		return ids
+	}
+
+	all, err := db.ExperimentSchedules(nil)
+	assert.NilError(t, err)
+	for username, id := range scheduleIDs {
+		assert.Equal(t, ids(all)[id], owners[username])
+	}
+
+	ownerID := owners["determined"]
+	owned, err := db.ExperimentSchedules(&ownerID)
+	assert.NilError(t, err)
+	for id, owner := range ids(owned) {
+		assert.Equal(t, owner, ownerID, "schedule %d", id)
+	}
+	_, ok := ids(owned)[scheduleIDs["determined"]]
+	assert.Assert(t, ok)
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddExperimentStateTransition records that the state of an experiment changed.
+func (db *PgDB) AddExperimentStateTransition(transition *model.ExperimentStateTransition) error {
+	_, err := db.sql.NamedExec(`
+INSERT INTO experiment_state_transitions
+    (experiment_id, from_state, to_state, timestamp, reason)
+VALUES
+    (:experiment_id, :from_state, :to_state, :timestamp, :reason)`, transition)
+	return errors.Wrapf(err, "error recording state transition of experiment %d to %s",
+		transition.ExperimentID, transition.To)
+}
+
+// ExperimentStateHistory returns the state transitions of an experiment in the order they
+// happened.
+func (db *PgDB) ExperimentStateHistory(
+	experimentID int,
+) ([]model.ExperimentStateTransition, error) {
+	transitions := []model.ExperimentStateTransition{}
+	if err := db.queryRows(`
+SELECT id, experiment_id, from_state, to_state, timestamp, reason
+FROM experiment_state_transitions
+WHERE experiment_id = $1
+ORDER BY timestamp ASC, id ASC`, &transitions, experimentID); err != nil {
+		return nil, errors.Wrapf(err, "error querying state history of experiment %d", experimentID)
+	}
+	return transitions, nil
+}
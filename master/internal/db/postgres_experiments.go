@@ -1,13 +1,17 @@
 package db
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/determined-ai/determined/master/internal/lttb"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 )
@@ -104,6 +108,90 @@ GROUP BY name;`, &rows, experimentID, vStartTime)
 	return training, validation, sEndTime, vEndTime, err
 }
 
+// MetricNameCount is the name of a metric, verbatim as trials reported it, and the number of steps
+// or validations that reported it.
+type MetricNameCount struct {
+	Name  string `db:"name" json:"name"`
+	Count int    `db:"count" json:"count"`
+}
+
+// MetricNameCounts returns the distinct names of the training and validation metrics that the
+// completed steps and validations of an experiment reported, ordered by name. Steps from before
+// runners averaged training metrics are counted by the names of their first batch metrics.
+func (db *PgDB) MetricNameCounts(experimentID int) (
+	training []MetricNameCount, validation []MetricNameCount, err error) {
+	if err = db.queryRows(`
+SELECT k.name, count(*) AS count
+FROM trials t
+JOIN steps s ON s.trial_id = t.id,
+LATERAL (SELECT coalesce(s.metrics->'avg_metrics', s.metrics->'batch_metrics'->0) AS metrics) m,
+LATERAL jsonb_object_keys(CASE WHEN jsonb_typeof(m.metrics) = 'object' THEN m.metrics
+                               ELSE '{}'::jsonb END) AS k(name)
+WHERE t.experiment_id = $1 AND s.state = 'COMPLETED'
+GROUP BY k.name
+ORDER BY k.name`, &training, experimentID); err != nil {
+		return nil, nil, errors.Wrapf(err,
+			"error querying training metric names for experiment %d", experimentID)
+	}
+
+	if err = db.queryRows(`
+SELECT k.name, count(*) AS count
+FROM trials t
+JOIN validations v ON v.trial_id = t.id,
+LATERAL jsonb_object_keys(CASE WHEN jsonb_typeof(v.metrics->'validation_metrics') = 'object'
+                               THEN v.metrics->'validation_metrics'
+                               ELSE '{}'::jsonb END) AS k(name)
+WHERE t.experiment_id = $1 AND v.state = 'COMPLETED'
+GROUP BY k.name
+ORDER BY k.name`, &validation, experimentID); err != nil {
+		return nil, nil, errors.Wrapf(err,
+			"error querying validation metric names for experiment %d", experimentID)
+	}
+	return training, validation, nil
+}
+
+// LatestTrialMetric is the latest value that a trial reported for a metric.
+type LatestTrialMetric struct {
+	TrialID int     `db:"trial_id"`
+	Name    string  `db:"name"`
+	Value   float64 `db:"value"`
+}
+
+// LatestTrialMetrics returns the latest value of each numeric training and validation metric of
+// each trial of an experiment, ordered by trial and name. Training metrics are taken from the
+// averaged metrics of completed steps.
+func (db *PgDB) LatestTrialMetrics(experimentID int) (
+	training []LatestTrialMetric, validation []LatestTrialMetric, err error) {
+	if err = db.queryRows(`
+SELECT DISTINCT ON (s.trial_id, m.key)
+    s.trial_id, m.key AS name, (m.value #>> '{}')::float8 AS value
+FROM trials t
+JOIN steps s ON s.trial_id = t.id,
+LATERAL jsonb_each(CASE WHEN jsonb_typeof(s.metrics->'avg_metrics') = 'object'
+                        THEN s.metrics->'avg_metrics'
+                        ELSE '{}'::jsonb END) AS m(key, value)
+WHERE t.experiment_id = $1 AND s.state = 'COMPLETED' AND jsonb_typeof(m.value) = 'number'
+ORDER BY s.trial_id, m.key, s.id DESC`, &training, experimentID); err != nil {
+		return nil, nil, errors.Wrapf(err,
+			"error querying latest training metrics for experiment %d", experimentID)
+	}
+
+	if err = db.queryRows(`
+SELECT DISTINCT ON (v.trial_id, m.key)
+    v.trial_id, m.key AS name, (m.value #>> '{}')::float8 AS value
+FROM trials t
+JOIN validations v ON v.trial_id = t.id,
+LATERAL jsonb_each(CASE WHEN jsonb_typeof(v.metrics->'validation_metrics') = 'object'
+                        THEN v.metrics->'validation_metrics'
+                        ELSE '{}'::jsonb END) AS m(key, value)
+WHERE t.experiment_id = $1 AND v.state = 'COMPLETED' AND jsonb_typeof(m.value) = 'number'
+ORDER BY v.trial_id, m.key, v.step_id DESC`, &validation, experimentID); err != nil {
+		return nil, nil, errors.Wrapf(err,
+			"error querying latest validation metrics for experiment %d", experimentID)
+	}
+	return training, validation, nil
+}
+
 type batchesWrapper struct {
 	Batches int32     `db:"batches_processed"`
 	EndTime time.Time `db:"end_time"`
@@ -383,3 +471,397 @@ ORDER BY batches;`, &rows, metricName, trialID, startBatches, endBatches, startT
 	}
 	return metricSeries, endTime, nil
 }
+
+// TrialsMetricSeries returns the series of the specified training or validation metric in each of
+// the specified trials that reported it, keyed by trial ID and ordered by batches, in one query.
+func (db *PgDB) TrialsMetricSeries(
+	trialIDs []int, metricName string, validation bool,
+) (map[int][]lttb.Point, error) {
+	query := `
+SELECT s.trial_id, (s.prior_batches_processed + s.num_batches) AS batches,
+  (s.metrics->'avg_metrics'->>$2)::float8 AS value
+FROM steps s
+WHERE s.trial_id = ANY($1::integer[])
+  AND s.state = 'COMPLETED'
+  AND jsonb_typeof(s.metrics->'avg_metrics'->$2) = 'number'
+ORDER BY s.trial_id, batches`
+	if validation {
+		query = `
+SELECT s.trial_id, (s.prior_batches_processed + s.num_batches) AS batches,
+  (v.metrics->'validation_metrics'->>$2)::float8 AS value
+FROM steps s
+  INNER JOIN validations v ON s.id = v.step_id AND s.trial_id = v.trial_id
+WHERE s.trial_id = ANY($1::integer[])
+  AND v.state = 'COMPLETED'
+  AND jsonb_typeof(v.metrics->'validation_metrics'->$2) = 'number'
+ORDER BY s.trial_id, batches`
+	}
+	var rows []struct {
+		TrialID int     `db:"trial_id"`
+		Batches int     `db:"batches"`
+		Value   float64 `db:"value"`
+	}
+	if err := db.queryRows(query, &rows, pq.Array(trialIDs), metricName); err != nil {
+		return nil, errors.Wrapf(err, "error querying for series of metric %s", metricName)
+	}
+	series := make(map[int][]lttb.Point)
+	for _, row := range rows {
+		series[row.TrialID] = append(series[row.TrialID],
+			lttb.Point{X: float64(row.Batches), Y: row.Value})
+	}
+	return series, nil
+}
+
+// TrialFinalMetric is the hyperparameters of a completed trial and the value of a validation
+// metric at the last validation of the trial that reported it.
+type TrialFinalMetric struct {
+	TrialID int           `db:"trial_id"`
+	HParams model.JSONObj `db:"hparams"`
+	Value   float64       `db:"value"`
+}
+
+// CompletedTrialsFinalMetric returns the hyperparameters and final value of the given validation
+// metric of each completed trial of an experiment that reported the metric, ordered by trial ID.
+func (db *PgDB) CompletedTrialsFinalMetric(experimentID int, metricName string) (
+	[]TrialFinalMetric, error,
+) {
+	var rows []TrialFinalMetric
+	if err := db.queryRows(`
+SELECT t.id AS trial_id, t.hparams, v.value
+FROM trials t
+CROSS JOIN LATERAL (
+  SELECT (v.metrics->'validation_metrics'->>$2)::float8 AS value
+  FROM validations v
+  WHERE v.trial_id = t.id
+    AND v.state = 'COMPLETED'
+    AND jsonb_typeof(v.metrics->'validation_metrics'->$2) = 'number'
+  ORDER BY v.end_time DESC
+  LIMIT 1
+) v
+WHERE t.experiment_id = $1
+  AND t.state = 'COMPLETED'
+ORDER BY t.id`, &rows, experimentID, metricName); err != nil {
+		return nil, errors.Wrapf(err,
+			"error querying final %s of trials of experiment %d", metricName, experimentID)
+	}
+	return rows, nil
+}
+
+// LeaderboardEntry is the best value of a validation metric that a trial reported.
+type LeaderboardEntry struct {
+	TrialID   int           `db:"trial_id" json:"trial_id"`
+	State     string        `db:"state" json:"state"`
+	HParams   model.JSONObj `db:"hparams" json:"hparams"`
+	BestValue float64       `db:"best_value" json:"best_value"`
+}
+
+// ExperimentLeaderboard returns the best value of the given validation metric of each trial of an
+// experiment that reported the metric, ordered from best to worst, along with the hyperparameters
+// of the trial. If limit is not nil, only that many of the best trials are returned.
+func (db *PgDB) ExperimentLeaderboard(
+	experimentID int, metricName string, smallerIsBetter bool, limit *int,
+) ([]LeaderboardEntry, error) {
+	order := desc
+	if smallerIsBetter {
+		order = asc
+	}
+	var rows []LeaderboardEntry
+	if err := db.queryRows(fmt.Sprintf(`
+SELECT t.id AS trial_id, t.state, t.hparams, v.best_value
+FROM trials t
+CROSS JOIN LATERAL (
+  SELECT (v.metrics->'validation_metrics'->>$2)::float8 AS best_value
+  FROM validations v
+  WHERE v.trial_id = t.id
+    AND v.state = 'COMPLETED'
+    AND jsonb_typeof(v.metrics->'validation_metrics'->$2) = 'number'
+  ORDER BY best_value %[1]s
+  LIMIT 1
+) v
+WHERE t.experiment_id = $1
+ORDER BY v.best_value %[1]s, t.id
+LIMIT $3`, order), &rows, experimentID, metricName, limit); err != nil {
+		return nil, errors.Wrapf(err,
+			"error querying best %s of trials of experiment %d", metricName, experimentID)
+	}
+	return rows, nil
+}
+
+// HParamImportance returns the cached hyperparameter importance of the given metric for an
+// experiment, if it was computed from the set of trials identified by trialsKey.
+func (db *PgDB) HParamImportance(experimentID int, metricName, trialsKey string) (
+	map[string]float64, error,
+) {
+	raw, err := db.rawQuery(`
+SELECT importance
+FROM hparam_importance
+WHERE experiment_id = $1 AND metric = $2 AND trials_key = $3`,
+		experimentID, metricName, trialsKey)
+	if err != nil {
+		return nil, err
+	}
+	var importance map[string]float64
+	if err := json.Unmarshal(raw, &importance); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling hyperparameter importance")
+	}
+	return importance, nil
+}
+
+// SaveHParamImportance caches the hyperparameter importance of the given metric for an
+// experiment, computed from the set of trials identified by trialsKey.
+func (db *PgDB) SaveHParamImportance(
+	experimentID int, metricName, trialsKey string, importance map[string]float64,
+) error {
+	raw, err := json.Marshal(importance)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling hyperparameter importance")
+	}
+	if _, err := db.sql.Exec(`
+INSERT INTO hparam_importance (experiment_id, metric, trials_key, importance, computed_at)
+VALUES ($1, $2, $3, $4, now())
+ON CONFLICT (experiment_id, metric)
+DO UPDATE SET trials_key = $3, importance = $4, computed_at = now()`,
+		experimentID, metricName, trialsKey, raw); err != nil {
+		return errors.Wrapf(err,
+			"error saving hyperparameter importance of experiment %d", experimentID)
+	}
+	return nil
+}
+
+// ExperimentMetric is a single value of a training or validation metric reported by a trial.
+type ExperimentMetric struct {
+	TrialID int `db:"trial_id"`
+	// Batches is the number of batches that the trial had processed when it reported the metric.
+	Batches int `db:"batches"`
+	// Kind is either "training" or "validation".
+	Kind  string `db:"kind"`
+	Name  string `db:"name"`
+	Value string `db:"value"`
+}
+
+// ForEachExperimentMetric calls f with every completed training and validation metric of the
+// experiment, ordered by trial, batches, kind and name, without loading them all into memory. It
+// stops at the first error that f returns.
+func (db *PgDB) ForEachExperimentMetric(experimentID int, f func(ExperimentMetric) error) error {
+	rows, err := db.sql.Queryx(`
+SELECT t.id AS trial_id, s.prior_batches_processed + s.num_batches AS batches,
+  'training' AS kind, m.key AS name, coalesce(m.value #>> '{}', '') AS value
+FROM trials t
+  JOIN steps s ON s.trial_id = t.id,
+  jsonb_each(s.metrics->'avg_metrics') m
+WHERE t.experiment_id = $1 AND s.state = 'COMPLETED'
+UNION ALL
+SELECT t.id, s.prior_batches_processed + s.num_batches,
+  'validation', m.key, coalesce(m.value #>> '{}', '')
+FROM trials t
+  JOIN steps s ON s.trial_id = t.id
+  JOIN validations v ON v.trial_id = s.trial_id AND v.step_id = s.id,
+  jsonb_each(v.metrics->'validation_metrics') m
+WHERE t.experiment_id = $1 AND v.state = 'COMPLETED'
+ORDER BY trial_id, batches, kind, name`, experimentID)
+	if err != nil {
+		return errors.Wrapf(err, "error querying metrics of experiment %d", experimentID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var metric ExperimentMetric
+		if err := rows.StructScan(&metric); err != nil {
+			return errors.Wrapf(err, "error reading metrics of experiment %d", experimentID)
+		}
+		if err := f(metric); err != nil {
+			return err
+		}
+	}
+	return errors.Wrapf(rows.Err(), "error reading metrics of experiment %d", experimentID)
+}
+
+// isDuplicateExternalID returns whether inserting an experiment failed because its external ID
+// must be unique and is not.
+func isDuplicateExternalID(err error) bool {
+	pgerr, ok := errors.Cause(err).(*pq.Error)
+	return ok && pgerr.Code == uniqueViolation &&
+		pgerr.Constraint == "ix_experiments_unique_external_id"
+}
+
+// ExperimentIDsByExternalID returns the IDs of the experiments with the external ID, in ascending
+// order.
+func (db *PgDB) ExperimentIDsByExternalID(externalID string) ([]int, error) {
+	var ids []int
+	if err := db.sql.Select(&ids, `
+SELECT id FROM experiments WHERE external_id = $1 ORDER BY id`, externalID); err != nil {
+		return nil, errors.Wrapf(err, "error querying experiments with external ID %s", externalID)
+	}
+	return ids, nil
+}
+
+// ExperimentIDsInResourcePool returns the IDs of the experiments with trials that were allocated
+// slots in a resource pool, whether or not they still hold them, in ascending order.
+func (db *PgDB) ExperimentIDsInResourcePool(pool string) ([]int32, error) {
+	var ids []int32
+	if err := db.sql.Select(&ids, `
+SELECT DISTINCT experiment_id FROM allocation_sessions
+WHERE resource_pool = $1 AND experiment_id IS NOT NULL
+ORDER BY experiment_id`, pool); err != nil {
+		return nil, errors.Wrapf(err, "error querying experiments in resource pool %s", pool)
+	}
+	return ids, nil
+}
+
+// ActiveExperimentIDsForUser returns the IDs of the experiments of a user that are not in a
+// terminal state, in ascending order.
+func (db *PgDB) ActiveExperimentIDsForUser(userID model.UserID) ([]int, error) {
+	var ids []int
+	if err := db.sql.Select(&ids, `
+SELECT id FROM experiments
+WHERE owner_id = $1
+  AND state IN ('ACTIVE', 'PAUSED', 'STOPPING_CANCELED', 'STOPPING_COMPLETED', 'STOPPING_ERROR')
+ORDER BY id`, userID); err != nil {
+		return nil, errors.Wrapf(err, "error querying active experiments of user %d", userID)
+	}
+	return ids, nil
+}
+
+// MarkExperimentDeleting moves an experiment in a terminal state to the deleting state.
+func (db *PgDB) MarkExperimentDeleting(id int) error {
+	result, err := db.sql.Exec(`
+UPDATE experiments SET state = 'DELETING'
+WHERE id = $1 AND state IN ('COMPLETED', 'CANCELED', 'ERROR', 'CANCELED_DEPENDENCY')`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error marking experiment %d as deleting", id)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "error marking experiment %d as deleting", id)
+	} else if rows == 0 {
+		return errors.Errorf("experiment %d is not in a terminal state", id)
+	}
+	return nil
+}
+
+// MarkExpiredExperimentDeleting moves an experiment that was soft-deleted longer than the grace
+// period ago to the deleting state and returns the state that it moved from. It returns nil if
+// the experiment is no longer due to be deleted for good, e.g., because it was undeleted in the
+// meantime.
+func (db *PgDB) MarkExpiredExperimentDeleting(
+	id int, gracePeriod time.Duration,
+) (*model.State, error) {
+	var from model.State
+	if err := db.sql.Get(&from, `
+WITH expired AS (
+    SELECT id, state FROM experiments
+    WHERE id = $1 AND deleted_at IS NOT NULL AND deleted_at <= now() - $2 * interval '1 second'
+      AND state IN ('COMPLETED', 'CANCELED', 'ERROR', 'CANCELED_DEPENDENCY')
+    FOR UPDATE
+)
+UPDATE experiments e SET state = 'DELETING'
+FROM expired WHERE e.id = expired.id
+RETURNING expired.state`, id, gracePeriod.Seconds()); err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error marking experiment %d as deleting", id)
+	}
+	return &from, nil
+}
+
+// SoftDeleteExperiment marks an experiment in a terminal state as deleted, which hides it from
+// experiment lists until it is deleted for good or undeleted.
+func (db *PgDB) SoftDeleteExperiment(id int) (time.Time, error) {
+	var deletedAt time.Time
+	if err := db.sql.Get(&deletedAt, `
+UPDATE experiments SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+  AND state IN ('COMPLETED', 'CANCELED', 'ERROR', 'CANCELED_DEPENDENCY')
+RETURNING deleted_at`, id); err == sql.ErrNoRows {
+		return time.Time{}, errors.Errorf(
+			"experiment %d is not in a terminal state or is deleted already", id)
+	} else if err != nil {
+		return time.Time{}, errors.Wrapf(err, "error soft-deleting experiment %d", id)
+	}
+	return deletedAt, nil
+}
+
+// UndeleteExperiment restores an experiment that was soft-deleted and is not being deleted for
+// good yet.
+func (db *PgDB) UndeleteExperiment(id int) error {
+	result, err := db.sql.Exec(`
+UPDATE experiments SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL AND state != 'DELETING'`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error undeleting experiment %d", id)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "error undeleting experiment %d", id)
+	} else if rows == 0 {
+		return errors.Errorf("experiment %d is not soft-deleted", id)
+	}
+	return nil
+}
+
+// ExpiredSoftDeletedExperimentIDs returns the IDs of the experiments that were soft-deleted longer
+// than the grace period ago and are not being deleted for good yet, in ascending order.
+func (db *PgDB) ExpiredSoftDeletedExperimentIDs(gracePeriod time.Duration) ([]int, error) {
+	var ids []int
+	if err := db.sql.Select(&ids, `
+SELECT id FROM experiments
+WHERE deleted_at < now() - $1 * interval '1 second' AND state != 'DELETING'
+ORDER BY id`, gracePeriod.Seconds()); err != nil {
+		return nil, errors.Wrap(err, "error querying soft-deleted experiments to purge")
+	}
+	return ids, nil
+}
+
+// DeletingExperimentIDs returns the IDs of the experiments that are being deleted, in ascending
+// order.
+func (db *PgDB) DeletingExperimentIDs() ([]int, error) {
+	var ids []int
+	if err := db.sql.Select(&ids, `
+SELECT id FROM experiments WHERE state = 'DELETING' ORDER BY id`); err != nil {
+		return nil, errors.Wrap(err, "error querying experiments being deleted")
+	}
+	return ids, nil
+}
+
+// RecordCheckpointDeletion records the outcome of an attempt to delete the checkpoints of an
+// experiment from storage: the deleted checkpoints are marked as such and the failures, keyed by
+// checkpoint UUID, replace those of earlier attempts.
+func (db *PgDB) RecordCheckpointDeletion(
+	experimentID int, deleted []string, failures map[string]string,
+) error {
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return errors.Wrap(err, "error starting transaction")
+	}
+	defer func() {
+		if tx == nil {
+			return
+		}
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Errorf("error during rollback: %v", rErr)
+		}
+	}()
+
+	if _, err = tx.Exec(`
+UPDATE checkpoints SET state = 'DELETED'
+WHERE uuid = ANY($1::uuid[]) AND state = 'COMPLETED'`, pq.Array(deleted)); err != nil {
+		return errors.Wrapf(err, "error marking checkpoints of experiment %d deleted", experimentID)
+	}
+	if _, err = tx.Exec(`
+DELETE FROM checkpoint_deletion_failures WHERE experiment_id = $1`, experimentID); err != nil {
+		return errors.Wrapf(err, "error clearing checkpoint deletion failures of experiment %d",
+			experimentID)
+	}
+	for checkpoint, failure := range failures {
+		if _, err = tx.Exec(`
+INSERT INTO checkpoint_deletion_failures (experiment_id, checkpoint_uuid, error, failed_at)
+VALUES ($1, $2, $3, now())`, experimentID, checkpoint, failure); err != nil {
+			return errors.Wrapf(err, "error recording deletion failure of checkpoint %s", checkpoint)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "error recording checkpoint deletion of experiment %d",
+			experimentID)
+	}
+	tx = nil
+	return nil
+}
@@ -0,0 +1,227 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestSoftDeleteExperiment(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	id := addTestExperiment(t, db, "COMPLETED")
+	active := addTestExperiment(t, db, "ACTIVE")
+	defer func() {
+		assert.NilError(t, db.DeleteExperiment(id))
+		assert.NilError(t, db.DeleteExperiment(active))
+	}()
+	state := func() model.State {
+		exp, err := db.ExperimentWithoutConfigByID(id)
+		assert.NilError(t, err)
+		return exp.State
+	}
+	expired := func(gracePeriod time.Duration) bool {
+		ids, err := db.ExpiredSoftDeletedExperimentIDs(gracePeriod)
+		assert.NilError(t, err)
+		for _, expiredID := range ids {
+			if expiredID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	_, err := db.SoftDeleteExperiment(active)
+	assert.ErrorContains(t, err, "is not in a terminal state or is deleted already")
+	_, err = db.SoftDeleteExperiment(id)
+	assert.NilError(t, err)
+	_, err = db.SoftDeleteExperiment(id)
+	assert.ErrorContains(t, err, "is not in a terminal state or is deleted already")
+
+	// Experiments are only purged once their grace period passes.
+	assert.Assert(t, !expired(time.Hour))
+	from, err := db.MarkExpiredExperimentDeleting(id, time.Hour)
+	assert.NilError(t, err)
+	assert.Assert(t, from == nil)
+	assert.Assert(t, expired(0))
+
+	// Undeleted experiments are not purged, even if they were listed to be.
+	assert.NilError(t, db.UndeleteExperiment(id))
+	assert.ErrorContains(t, db.UndeleteExperiment(id), "is not soft-deleted")
+	assert.Assert(t, !expired(0))
+	from, err = db.MarkExpiredExperimentDeleting(id, 0)
+	assert.NilError(t, err)
+	assert.Assert(t, from == nil)
+	assert.Equal(t, state(), model.CompletedState)
+
+	_, err = db.SoftDeleteExperiment(id)
+	assert.NilError(t, err)
+	from, err = db.MarkExpiredExperimentDeleting(id, 0)
+	assert.NilError(t, err)
+	assert.Assert(t, from != nil)
+	assert.Equal(t, *from, model.CompletedState)
+	assert.Equal(t, state(), model.DeletingState)
+	assert.Assert(t, !expired(0))
+	assert.ErrorContains(t, db.UndeleteExperiment(id), "is not soft-deleted")
+}
+
+func TestUniqueExternalIDs(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	admin, err := db.UserByUsername("admin")
+	assert.NilError(t, err)
+	externalID := "external-" + time.Now().Format(time.RFC3339Nano)
+	var ids []int
+	defer func() {
+		for _, id := range ids {
+			assert.NilError(t, db.DeleteExperiment(id))
+		}
+	}()
+	add := func(unique bool) error {
+		exp := &model.Experiment{
+			State:                model.CompletedState,
+			Config:               model.DefaultExperimentConfig(nil),
+			ModelDefinitionBytes: []byte("model"),
+			StartTime:            time.Now(),
+			OwnerID:              &admin.ID,
+			ExternalID:           &externalID,
+			ExternalIDUnique:     unique,
+		}
+		err := db.AddExperiment(exp)
+		if err == nil {
+			ids = append(ids, exp.ID)
+		}
+		return err
+	}
+
+	// Experiments submitted while external IDs need not be unique may share them, with each other
+	// and with one experiment whose external ID must be unique.
+	assert.NilError(t, add(false))
+	assert.NilError(t, add(false))
+	assert.NilError(t, add(true))
+	assert.Equal(t, add(true), ErrDuplicateRecord)
+	assert.NilError(t, add(false))
+}
+
+func TestExperimentSummaryReducedMetrics(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	id := addTestExperiment(t, db, string(model.ActiveState))
+	start := time.Now().Add(-time.Hour)
+	// Each trial reports a loss at 100 and 200 batches; only the second trial validates at 200.
+	for i, losses := range [][]float64{{4, 2}, {6, 1}} {
+		trial := &model.Trial{
+			ExperimentID: id, State: model.ActiveState, StartTime: start, HParams: model.JSONObj{},
+		}
+		assert.NilError(t, db.AddTrial(trial))
+		for j, loss := range losses {
+			end := start.Add(time.Duration(2*j+i) * time.Minute)
+			_, err := db.sql.Exec(`
+INSERT INTO steps
+    (trial_id, id, state, start_time, end_time, metrics, num_batches, prior_batches_processed)
+VALUES ($1, $2, 'COMPLETED', $3, $4, jsonb_build_object('avg_metrics',
+    jsonb_build_object('loss', $5::float8)), 100, $6)`,
+				trial.ID, j+1, start, end, loss, j*100)
+			assert.NilError(t, err)
+		}
+		if i == 1 {
+			_, err := db.sql.Exec(`
+INSERT INTO validations (trial_id, step_id, state, start_time, end_time, metrics)
+VALUES ($1, 2, 'COMPLETED', $2, $2, '{"validation_metrics": {"error": 0.5}}')`,
+				trial.ID, start)
+			assert.NilError(t, err)
+		}
+	}
+
+	type point struct {
+		Batches           int                `json:"batches"`
+		NumTrials         int                `json:"num_trials"`
+		Metrics           map[string]float64 `json:"metrics"`
+		ValidationMetrics map[string]float64 `json:"validation_metrics"`
+	}
+	reduced := func(reduction MetricReduction) []point {
+		raw, err := db.ExperimentWithSummaryMetricsRaw(id, &reduction)
+		assert.NilError(t, err)
+		var summary struct {
+			ReducedMetrics []point `json:"reduced_metrics"`
+		}
+		assert.NilError(t, json.Unmarshal(raw, &summary))
+		return summary.ReducedMetrics
+	}
+
+	validation := map[string]float64{"error": 0.5}
+	assert.DeepEqual(t, reduced(ReduceMean), []point{
+		{100, 2, map[string]float64{"loss": 5}, map[string]float64{}},
+		{200, 2, map[string]float64{"loss": 1.5}, validation},
+	})
+	assert.DeepEqual(t, reduced(ReduceMin), []point{
+		{100, 2, map[string]float64{"loss": 4}, map[string]float64{}},
+		{200, 2, map[string]float64{"loss": 1}, validation},
+	})
+	// The second trial reported last at each number of batches.
+	assert.DeepEqual(t, reduced(ReduceLast), []point{
+		{100, 2, map[string]float64{"loss": 6}, map[string]float64{}},
+		{200, 2, map[string]float64{"loss": 1}, validation},
+	})
+
+	raw, err := db.ExperimentWithSummaryMetricsRaw(id, nil)
+	assert.NilError(t, err)
+	var summary map[string]interface{}
+	assert.NilError(t, json.Unmarshal(raw, &summary))
+	_, ok := summary["reduced_metrics"]
+	assert.Assert(t, !ok)
+}
+
+func TestArchiveTerminalStateExperiments(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	addExperiment := func(state model.State, ended time.Duration) int {
+		id := addTestExperiment(t, db, string(state))
+		_, err := db.sql.Exec(`UPDATE experiments SET end_time = now() - $2 * interval '1 second'
+WHERE id = $1`, id, ended.Seconds())
+		assert.NilError(t, err)
+		return id
+	}
+	old := addExperiment(model.CompletedState, 48*time.Hour)
+	recent := addExperiment(model.ErrorState, time.Hour)
+	active := addExperiment(model.ActiveState, 48*time.Hour)
+	archived := func(id int) bool {
+		var archived bool
+		assert.NilError(t, db.sql.Get(&archived, `SELECT archived FROM experiments WHERE id = $1`,
+			id))
+		return archived
+	}
+
+	_, err := db.ArchiveTerminalStateExperiments(24 * time.Hour)
+	assert.NilError(t, err)
+	assert.Assert(t, archived(old))
+	assert.Assert(t, !archived(recent))
+	assert.Assert(t, !archived(active))
+
+	// Experiments that a user unarchives are not archived again.
+	_, err = db.sql.Exec(`UPDATE experiments SET archived = false WHERE id = $1`, old)
+	assert.NilError(t, err)
+	_, err = db.ArchiveTerminalStateExperiments(24 * time.Hour)
+	assert.NilError(t, err)
+	assert.Assert(t, !archived(old))
+}
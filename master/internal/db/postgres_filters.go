@@ -48,6 +48,8 @@ func filterToSQL(f api.Filter, values []interface{}, paramID int) string {
 		return fmt.Sprintf("AND %s > $%d", f.Field, paramID)
 	case api.FilterOperationLessThan:
 		return fmt.Sprintf("AND %s < $%d", f.Field, paramID)
+	case api.FilterOperationGreaterThanOrEqual:
+		return fmt.Sprintf("AND %s >= $%d", f.Field, paramID)
 	default:
 		panic(fmt.Sprintf("cannot convert operation %d to SQL", f.Operation))
 	}
@@ -64,7 +66,7 @@ func filterToParams(f api.Filter) []interface{} {
 		for _, v := range vs {
 			params = append(params, v)
 		}
-	case time.Time:
+	case int, time.Time:
 		params = append(params, vs)
 	default:
 		panic(fmt.Sprintf("cannot convert filter values to params: %T", f.Values))
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Maintenance returns the maintenance mode of the cluster, or nil if it is not enabled.
+func (db *PgDB) Maintenance() (*model.Maintenance, error) {
+	var maintenance model.Maintenance
+	switch err := db.query(`
+SELECT message, start_time, user_id
+FROM maintenance`, &maintenance); errors.Cause(err) {
+	case nil:
+		return &maintenance, nil
+	case ErrNotFound:
+		return nil, nil
+	default:
+		return nil, errors.Wrap(err, "error querying for maintenance mode")
+	}
+}
+
+// StartMaintenance enables the maintenance mode of the cluster, replacing its message if it is
+// already enabled.
+func (db *PgDB) StartMaintenance(maintenance *model.Maintenance) error {
+	if _, err := db.sql.NamedExec(`
+INSERT INTO maintenance (message, start_time, user_id)
+VALUES (:message, :start_time, :user_id)
+ON CONFLICT (id) DO UPDATE
+SET message = EXCLUDED.message, start_time = EXCLUDED.start_time, user_id = EXCLUDED.user_id`,
+		maintenance); err != nil {
+		return errors.Wrap(err, "error enabling maintenance mode")
+	}
+	return nil
+}
+
+// EndMaintenance disables the maintenance mode of the cluster.
+func (db *PgDB) EndMaintenance() error {
+	if _, err := db.sql.Exec(`DELETE FROM maintenance`); err != nil {
+		return errors.Wrap(err, "error disabling maintenance mode")
+	}
+	return nil
+}
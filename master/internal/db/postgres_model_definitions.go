@@ -0,0 +1,119 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// experimentRow returns the experiment as it is inserted into the experiments table: model
+// definitions that are kept in the model definition storage are only referred to by hash.
+func experimentRow(experiment *model.Experiment) *model.Experiment {
+	if experiment.ModelDefinitionHash == nil {
+		return experiment
+	}
+	row := *experiment
+	row.ModelDefinitionBytes = nil
+	return &row
+}
+
+// AddModelDefinitionBlob stores a model definition in the model_definitions table under the hash
+// of its content, unless it is stored already.
+func (db *PgDB) AddModelDefinitionBlob(hash string, content []byte) error {
+	if _, err := db.sql.Exec(`
+INSERT INTO model_definitions (hash, content) VALUES ($1, $2)
+ON CONFLICT (hash) DO NOTHING`, hash, content); err != nil {
+		return errors.Wrapf(err, "error storing model definition %s", hash)
+	}
+	return nil
+}
+
+// ModelDefinitionBlob returns the model definition with the hash from the model_definitions
+// table.
+func (db *PgDB) ModelDefinitionBlob(hash string) ([]byte, error) {
+	var content []byte
+	if err := db.sql.Get(&content, `
+SELECT content FROM model_definitions WHERE hash = $1`, hash); err == sql.ErrNoRows {
+		return nil, errors.WithStack(ErrNotFound)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error loading model definition %s", hash)
+	}
+	return content, nil
+}
+
+// DeleteModelDefinitionBlob deletes the model definition with the hash from the
+// model_definitions table.
+func (db *PgDB) DeleteModelDefinitionBlob(hash string) error {
+	if _, err := db.sql.Exec(`
+DELETE FROM model_definitions WHERE hash = $1`, hash); err != nil {
+		return errors.Wrapf(err, "error deleting model definition %s", hash)
+	}
+	return nil
+}
+
+// ModelDefinitionBlobHashes returns up to limit hashes of the model definitions in the
+// model_definitions table, in ascending order.
+func (db *PgDB) ModelDefinitionBlobHashes(limit int) ([]string, error) {
+	var hashes []string
+	if err := db.sql.Select(&hashes, `
+SELECT hash FROM model_definitions ORDER BY hash LIMIT $1`, limit); err != nil {
+		return nil, errors.Wrap(err, "error querying stored model definitions")
+	}
+	return hashes, nil
+}
+
+// InlineModelDefinitionExperimentIDs returns up to limit IDs of the experiments that keep their
+// model definitions in the experiments table, in ascending order.
+func (db *PgDB) InlineModelDefinitionExperimentIDs(limit int) ([]int, error) {
+	var ids []int
+	if err := db.sql.Select(&ids, `
+SELECT id FROM experiments WHERE model_definition IS NOT NULL ORDER BY id LIMIT $1`,
+		limit); err != nil {
+		return nil, errors.Wrap(err, "error querying experiments with inline model definitions")
+	}
+	return ids, nil
+}
+
+// MoveExperimentModelDefinition replaces the model definition that an experiment keeps in the
+// experiments table with the hash of its copy in the model definition storage. It returns false
+// if the experiment no longer keeps its model definition in the table, e.g., because it was
+// replaced in the meantime.
+func (db *PgDB) MoveExperimentModelDefinition(id int, hash string) (bool, error) {
+	result, err := db.sql.Exec(`
+UPDATE experiments SET model_definition = NULL, model_definition_hash = $2
+WHERE id = $1 AND model_definition IS NOT NULL`, id, hash)
+	if err != nil {
+		return false, errors.Wrapf(err, "error moving model definition of experiment %d", id)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "error moving model definition of experiment %d", id)
+	}
+	return rows > 0, nil
+}
+
+// ExperimentModelDefinitionHash returns the hash of the model definition of an experiment in the
+// model definition storage, or nil if the experiment keeps it in the experiments table.
+func (db *PgDB) ExperimentModelDefinitionHash(id int) (*string, error) {
+	var hash *string
+	if err := db.sql.Get(&hash, `
+SELECT model_definition_hash FROM experiments WHERE id = $1`, id); err == sql.ErrNoRows {
+		return nil, errors.WithStack(ErrNotFound)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error querying model definition of experiment %d", id)
+	}
+	return hash, nil
+}
+
+// ModelDefinitionReferenced returns whether any experiment refers to the model definition with
+// the hash.
+func (db *PgDB) ModelDefinitionReferenced(hash string) (bool, error) {
+	var referenced bool
+	if err := db.sql.Get(&referenced, `
+SELECT EXISTS (SELECT 1 FROM experiments WHERE model_definition_hash = $1)`, hash); err != nil {
+		return false, errors.Wrapf(err, "error querying references to model definition %s", hash)
+	}
+	return referenced, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/golang-migrate/migrate"
+	postgresM "github.com/golang-migrate/migrate/database/postgres"
+	"gotest.tools/assert"
+)
+
+const (
+	versionBeforeModelDefinitionStorage = 20201115120000
+	versionModelDefinitionStorage       = 20201116120000
+)
+
+func TestModelDefinitionStorageMigration(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	driver, err := postgresM.WithInstance(db.sql.DB, &postgresM.Config{})
+	assert.NilError(t, err)
+	m, err := migrate.NewWithDatabaseInstance(testMigrations, "postgres", driver)
+	assert.NilError(t, err)
+
+	addExperiment := func(content []byte, hash *string) int {
+		var id int
+		assert.NilError(t, db.sql.Get(&id, `
+INSERT INTO experiments (state, config, model_definition, model_definition_hash, start_time,
+                         owner_id)
+VALUES ('COMPLETED', '{}', $1, $2, now(), (SELECT id FROM users WHERE username = 'admin'))
+RETURNING id`, content, hash))
+		return id
+	}
+	modelDefinition := func(id int) []byte {
+		var content []byte
+		assert.NilError(t, db.sql.Get(&content,
+			`SELECT model_definition FROM experiments WHERE id = $1`, id))
+		return content
+	}
+
+	// Experiments that keep their model definitions inline keep them across the migration.
+	assert.NilError(t, m.Migrate(versionBeforeModelDefinitionStorage))
+	var inline int
+	assert.NilError(t, db.sql.Get(&inline, `
+INSERT INTO experiments (state, config, model_definition, start_time, owner_id)
+VALUES ('COMPLETED', '{}', 'inline', now(), (SELECT id FROM users WHERE username = 'admin'))
+RETURNING id`))
+	assert.NilError(t, m.Migrate(versionModelDefinitionStorage))
+	assert.DeepEqual(t, modelDefinition(inline), []byte("inline"))
+	hash, err := db.ExperimentModelDefinitionHash(inline)
+	assert.NilError(t, err)
+	assert.Assert(t, hash == nil)
+
+	stored, outside := "stored", "outside"
+	inPostgres := addExperiment(nil, &stored)
+	assert.NilError(t, db.AddModelDefinitionBlob(stored, []byte("in postgres")))
+	inStorage := addExperiment(nil, &outside)
+	referenced, err := db.ModelDefinitionReferenced(outside)
+	assert.NilError(t, err)
+	assert.Assert(t, referenced)
+
+	// Migrating down stops, leaving everything as it was, while a model definition is only in
+	// other storage.
+	err = m.Migrate(versionBeforeModelDefinitionStorage)
+	assert.ErrorContains(t, err, "are not kept in Postgres")
+	assert.NilError(t, m.Force(versionModelDefinitionStorage))
+	content, err := db.ModelDefinitionBlob(stored)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, content, []byte("in postgres"))
+
+	assert.NilError(t, db.AddModelDefinitionBlob(outside, []byte("copied back")))
+	assert.NilError(t, m.Migrate(versionBeforeModelDefinitionStorage))
+	assert.DeepEqual(t, modelDefinition(inPostgres), []byte("in postgres"))
+	assert.DeepEqual(t, modelDefinition(inStorage), []byte("copied back"))
+	assert.DeepEqual(t, modelDefinition(inline), []byte("inline"))
+
+	if err = m.Up(); err != migrate.ErrNoChange {
+		assert.NilError(t, err)
+	}
+}
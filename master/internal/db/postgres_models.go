@@ -0,0 +1,27 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ModelVersionRef identifies a version of a model in the model registry.
+type ModelVersionRef struct {
+	ModelName string `db:"model_name" json:"model_name"`
+	Version   int    `db:"version" json:"version"`
+}
+
+// ExperimentModelVersions returns the model versions whose checkpoints belong to the experiment,
+// ordered by model name and version.
+func (db *PgDB) ExperimentModelVersions(experimentID int) ([]ModelVersionRef, error) {
+	var versions []ModelVersionRef
+	if err := db.queryRows(`
+SELECT mv.model_name, mv.version
+FROM model_versions mv
+JOIN checkpoints c ON c.uuid = mv.checkpoint_uuid
+JOIN trials t ON t.id = c.trial_id
+WHERE t.experiment_id = $1
+ORDER BY mv.model_name, mv.version`, &versions, experimentID); err != nil {
+		return nil, errors.Wrapf(err, "error querying model versions of experiment %d", experimentID)
+	}
+	return versions, nil
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// SchedulerPause returns the pause of the scheduler, or nil if it is not paused.
+func (db *PgDB) SchedulerPause() (*model.SchedulerPause, error) {
+	var pause model.SchedulerPause
+	switch err := db.query(`
+SELECT start_time, user_id
+FROM scheduler_pause`, &pause); errors.Cause(err) {
+	case nil:
+		return &pause, nil
+	case ErrNotFound:
+		return nil, nil
+	default:
+		return nil, errors.Wrap(err, "error querying for scheduler pause")
+	}
+}
+
+// PauseScheduler pauses the scheduler, keeping the original pause if it is already paused.
+func (db *PgDB) PauseScheduler(pause *model.SchedulerPause) error {
+	if _, err := db.sql.NamedExec(`
+INSERT INTO scheduler_pause (start_time, user_id)
+VALUES (:start_time, :user_id)
+ON CONFLICT (id) DO NOTHING`, pause); err != nil {
+		return errors.Wrap(err, "error pausing scheduler")
+	}
+	return nil
+}
+
+// ResumeScheduler resumes the scheduler.
+func (db *PgDB) ResumeScheduler() error {
+	if _, err := db.sql.Exec(`DELETE FROM scheduler_pause`); err != nil {
+		return errors.Wrap(err, "error resuming scheduler")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// experimentVisibleSQL returns a condition that holds for the experiments, under the given alias,
+// that the user whose ID is the query parameter with the given number sees: those without a team,
+// those of the teams of the user, and those that the user owns. If the parameter is NULL, as it is
+// for admins, every experiment is visible.
+func experimentVisibleSQL(alias string, param int) string {
+	return fmt.Sprintf(`($%[2]d::integer IS NULL OR %[1]s.team_id IS NULL OR %[1]s.owner_id = $%[2]d
+    OR %[1]s.team_id IN (SELECT team_id FROM team_memberships WHERE user_id = $%[2]d))`,
+		alias, param)
+}
+
+// AddTeam adds the team to the database and sets its ID. It returns ErrDuplicateRecord if a team
+// with the same name exists.
+func (db *PgDB) AddTeam(team *model.Team) error {
+	err := db.namedGet(&team.ID, `
+INSERT INTO teams (name)
+VALUES (:name)
+RETURNING id`, team)
+	if pgerr, ok := errors.Cause(err).(*pq.Error); ok && pgerr.Code == uniqueViolation {
+		return ErrDuplicateRecord
+	}
+	return errors.Wrapf(err, "error inserting team %s", team.Name)
+}
+
+// DeleteTeam deletes the team with the given ID. Its experiments become visible to everyone.
+func (db *PgDB) DeleteTeam(id int) error {
+	if _, err := db.sql.Exec(`DELETE FROM teams WHERE id = $1`, id); err != nil {
+		return errors.Wrapf(err, "error deleting team %d", id)
+	}
+	return nil
+}
+
+// TeamByName looks up a team by name, returning ErrNotFound if none exists.
+func (db *PgDB) TeamByName(name string) (*model.Team, error) {
+	var team model.Team
+	if err := db.query(`
+SELECT id, name
+FROM teams
+WHERE name = $1`, &team, name); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// TeamsRaw returns a JSON list of the teams along with the usernames of their members.
+func (db *PgDB) TeamsRaw() ([]byte, error) {
+	return db.rawQuery(`
+SELECT coalesce(jsonb_agg(t ORDER BY t.name ASC), '[]'::jsonb)
+FROM (
+    SELECT t.id, t.name,
+           (SELECT coalesce(jsonb_agg(u.username ORDER BY u.username ASC), '[]'::jsonb)
+            FROM team_memberships m
+            JOIN users u ON u.id = m.user_id
+            WHERE m.team_id = t.id) AS members
+    FROM teams t
+) t`)
+}
+
+// UserTeams returns the teams that the user is a member of, ordered by name.
+func (db *PgDB) UserTeams(userID model.UserID) ([]model.Team, error) {
+	var teams []model.Team
+	if err := db.queryRows(`
+SELECT t.id, t.name
+FROM teams t
+JOIN team_memberships m ON m.team_id = t.id
+WHERE m.user_id = $1
+ORDER BY t.name ASC`, &teams, userID); err != nil {
+		return nil, errors.Wrapf(err, "error querying for teams of user %d", userID)
+	}
+	return teams, nil
+}
+
+// AddTeamMember makes the user a member of the team. It does nothing if the user is already one.
+func (db *PgDB) AddTeamMember(teamID int, userID model.UserID) error {
+	if _, err := db.sql.Exec(`
+INSERT INTO team_memberships (team_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING`, teamID, userID); err != nil {
+		return errors.Wrapf(err, "error adding user %d to team %d", userID, teamID)
+	}
+	return nil
+}
+
+// RemoveTeamMember removes the user from the team. It does nothing if the user is not a member.
+func (db *PgDB) RemoveTeamMember(teamID int, userID model.UserID) error {
+	if _, err := db.sql.Exec(`
+DELETE FROM team_memberships
+WHERE team_id = $1 AND user_id = $2`, teamID, userID); err != nil {
+		return errors.Wrapf(err, "error removing user %d from team %d", userID, teamID)
+	}
+	return nil
+}
+
+// SaveExperimentTeam sets the team of the experiment; a nil team makes it visible to everyone.
+func (db *PgDB) SaveExperimentTeam(id int, teamID *int) error {
+	res, err := db.sql.Exec(`UPDATE experiments SET team_id = $1 WHERE id = $2`, teamID, id)
+	if err != nil {
+		return errors.Wrap(err, "saving experiment team")
+	}
+	if numRows, err := res.RowsAffected(); err != nil {
+		return errors.Wrap(err, "checking affected rows for saving experiment team")
+	} else if numRows == 0 {
+		return errors.WithStack(ErrNotFound)
+	}
+	return nil
+}
+
+// ExperimentVisible returns whether the experiment exists and is visible to the user with the
+// given ID, or to any user if it is nil.
+func (db *PgDB) ExperimentVisible(experimentID int, viewer *model.UserID) (bool, error) {
+	var visible bool
+	if err := db.sql.QueryRow(fmt.Sprintf(`
+SELECT EXISTS(
+    SELECT 1
+    FROM experiments e
+    WHERE e.id = $1 AND %s
+)`, experimentVisibleSQL("e", 2)), experimentID, viewer).Scan(&visible); err != nil {
+		return false, errors.Wrapf(err, "error checking whether experiment %d is visible",
+			experimentID)
+	}
+	return visible, nil
+}
+
+// TrialVisible returns whether the trial exists and its experiment is visible to the user with the
+// given ID, or to any user if it is nil.
+func (db *PgDB) TrialVisible(trialID int, viewer *model.UserID) (bool, error) {
+	var visible bool
+	if err := db.sql.QueryRow(fmt.Sprintf(`
+SELECT EXISTS(
+    SELECT 1
+    FROM trials t
+    JOIN experiments e ON e.id = t.experiment_id
+    WHERE t.id = $1 AND %s
+)`, experimentVisibleSQL("e", 2)), trialID, viewer).Scan(&visible); err != nil {
+		return false, errors.Wrapf(err, "error checking whether trial %d is visible", trialID)
+	}
+	return visible, nil
+}
+
+// CheckpointVisible returns whether the checkpoint exists and its experiment is visible to the
+// user with the given ID, or to any user if it is nil.
+func (db *PgDB) CheckpointVisible(id uuid.UUID, viewer *model.UserID) (bool, error) {
+	var visible bool
+	if err := db.sql.QueryRow(fmt.Sprintf(`
+SELECT EXISTS(
+    SELECT 1
+    FROM checkpoints c
+    JOIN trials t ON t.id = c.trial_id
+    JOIN experiments e ON e.id = t.experiment_id
+    WHERE c.uuid = $1 AND %s
+)`, experimentVisibleSQL("e", 2)), id.String(), viewer).Scan(&visible); err != nil {
+		return false, errors.Wrapf(err, "error checking whether checkpoint %s is visible", id)
+	}
+	return visible, nil
+}
@@ -18,19 +18,25 @@ func (db *PgDB) TemplateByName(name string) (value model.Template, err error) {
 	return value, err
 }
 
-// UpsertTemplate creates or updates a config template.
-func (db *PgDB) UpsertTemplate(tpl *model.Template) error {
-	if len(tpl.Name) == 0 {
+// UpsertTemplate creates or updates a config template. Without a description, a template that
+// exists already keeps its description and a new template has none.
+func (db *PgDB) UpsertTemplate(name string, description *string, config []byte) error {
+	if len(name) == 0 {
 		return errors.New("error setting a template: empty name")
 	}
 	err := db.namedExecOne(`
-INSERT INTO templates (name, config)
-VALUES (:name, :config)
+INSERT INTO templates (name, description, config)
+VALUES (:name, COALESCE(:description, ''), :config)
 ON CONFLICT (name)
 DO
-UPDATE SET config=:config`, tpl)
+UPDATE SET description=COALESCE(:description, templates.description), config=:config`,
+		struct {
+			Name        string  `db:"name"`
+			Description *string `db:"description"`
+			Config      []byte  `db:"config"`
+		}{name, description, config})
 	if err != nil {
-		return errors.Wrapf(err, "error setting a template '%v'", tpl.Name)
+		return errors.Wrapf(err, "error setting a template '%v'", name)
 	}
 	return nil
 }
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+const (
+	// testPostgresURLEnv names the variable with the URL of an empty database that the tests of
+	// this package may use; they are skipped unless it is set.
+	testPostgresURLEnv = "DET_TEST_POSTGRES_URL"
+
+	testMigrations = "file://../../static/migrations"
+)
+
+// connectTestDB connects to the test database, skipping the test if there is none.
+func connectTestDB(t *testing.T) *PgDB {
+	url := os.Getenv(testPostgresURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testPostgresURLEnv)
+	}
+	db, err := ConnectPostgres(url, time.Minute)
+	assert.NilError(t, err)
+	return db
+}
+
+// addTestExperiment adds an experiment in the state, owned by the admin user, and returns its ID.
+func addTestExperiment(t *testing.T, db *PgDB, state string) int {
+	var id int
+	assert.NilError(t, db.sql.Get(&id, `
+INSERT INTO experiments (state, config, model_definition, start_time, owner_id)
+VALUES ($1, '{}', 'model', now(), (SELECT id FROM users WHERE username = 'admin'))
+RETURNING id`, state))
+	return id
+}
@@ -2,23 +2,19 @@ package db
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/internal/api"
 
 	"github.com/determined-ai/determined/master/pkg/model"
 )
 
-// TrialLogs takes a trial ID and log offset, limit and filters and returns matching trial logs.
-func (db *PgDB) TrialLogs(
-	trialID, offset, limit int, fs []api.Filter,
-) ([]*model.TrialLog, error) {
-	params := []interface{}{trialID, offset, limit}
-	fragment, params := filtersToSQL(fs, params)
-	query := fmt.Sprintf(`
-SELECT
-    l.id,
-    l.trial_id,
-    CASE
+// trialLogMessage renders a row of trial_logs as a single line: structured logs are prefixed with
+// their timestamp, container, rank and level, while legacy logs are passed through as they are.
+const trialLogMessage = `CASE
       WHEN log IS NOT NULL THEN
         coalesce(to_char(timestamp, '[YYYY-MM-DD"T"HH24:MI:SS"Z"]' ), '[UNKNOWN TIME]')
         || ' '
@@ -26,21 +22,155 @@ SELECT
         || coalesce(' [rank=' || (rank_id::text) || ']', '')
         || ' || '
         || coalesce(level || ': ', '')
-        || encode(log, 'escape')
-      ELSE encode(message, 'escape')
-    END AS message,
+        || convert_from(log, 'UTF8')
+      ELSE convert_from(message, 'UTF8')
+    END`
+
+// TrialLogsCommitLag is how long ago a trial log must have been inserted to be returned in commit
+// order. IDs and insertion times are assigned before the inserting transaction commits, so a log
+// can become visible after later ones; once the lag has passed, every log inserted before it has
+// committed and a cursor can move past it without skipping any.
+const TrialLogsCommitLag = 5 * time.Second
+
+// TrialLogsCursor is a position in the logs of a trial in commit order: by insertion time and
+// then by ID.
+type TrialLogsCursor struct {
+	InsertedAt time.Time `db:"inserted_at"`
+	ID         int       `db:"id"`
+}
+
+// TrialLogs takes a trial ID, a cursor to continue after, log offset, limit and filters and returns
+// matching trial logs in commit order. Logs inserted within the lag are left out.
+func (db *PgDB) TrialLogs(
+	trialID int, after *TrialLogsCursor, lag time.Duration, offset, limit int, fs []api.Filter,
+) ([]*model.TrialLog, error) {
+	params := []interface{}{trialID, offset, limit, lag.Seconds()}
+	var afterFragment string
+	if after != nil {
+		afterFragment = "AND (l.inserted_at, l.id) > ($5, $6)"
+		params = append(params, after.InsertedAt, after.ID)
+	}
+	fragment, params := filtersToSQL(fs, params)
+	query := fmt.Sprintf(`
+SELECT
+    l.id,
+    l.trial_id,
+    %s AS message,
     l.agent_id,
     l.container_id,
     l.timestamp,
     l.level,
     l.stdtype,
-    l.source
+    l.source,
+    l.inserted_at
 FROM trial_logs l
-WHERE l.trial_id = $1
+WHERE l.trial_id = $1 AND l.inserted_at < now() - $4 * interval '1 second'
 %s
-ORDER BY l.id ASC OFFSET $2 LIMIT $3
-`, fragment)
+%s
+ORDER BY l.inserted_at ASC, l.id ASC OFFSET $2 LIMIT $3
+`, trialLogMessage, afterFragment, fragment)
 
 	var b []*model.TrialLog
 	return b, db.queryRows(query, &b, params...)
 }
+
+// TrialLogsPage selects a range of the logs of a trial by ID and, given Since, by timestamp, which
+// keeps the logs without a timestamp. When Tail is set, the page is the last Limit logs of the
+// range rather than the first. A nil bound or limit is not applied. Pages InCommitOrder, or that
+// continue After a cursor, are ordered by insertion time and then ID and leave out the logs
+// inserted within TrialLogsCommitLag.
+type TrialLogsPage struct {
+	After         *TrialLogsCursor
+	InCommitOrder bool
+	GreaterThanID *int
+	LessThanID    *int
+	Since         *time.Time
+	Limit         *int
+	Tail          bool
+}
+
+// TrialLogEntry is a trial log rendered as a single message, along with the state of its trial.
+type TrialLogEntry struct {
+	ID         int       `db:"id" json:"id"`
+	State      string    `db:"state" json:"state"`
+	Message    string    `db:"message" json:"message"`
+	InsertedAt time.Time `db:"inserted_at" json:"-"`
+	// NextCursor continues after the entry; it is only set on pages in commit order.
+	NextCursor string `db:"-" json:"next_cursor,omitempty"`
+}
+
+// TrialLogEntries returns a page of the logs of a trial in ascending order of ID, or in commit
+// order if the page asks for it. It backs every endpoint that pages through trial logs, so that
+// they agree on ordering and filtering.
+func (db *PgDB) TrialLogEntries(trialID int, page TrialLogsPage) ([]TrialLogEntry, error) {
+	params := []interface{}{trialID, page.Limit}
+	var fragments []string
+	order := "l.id %[1]s"
+	if page.After != nil || page.InCommitOrder {
+		params = append(params, TrialLogsCommitLag.Seconds())
+		fragments = append(fragments, "AND l.inserted_at < now() - $3 * interval '1 second'")
+		order = "l.inserted_at %[1]s, l.id %[1]s"
+	}
+	if page.After != nil {
+		fragments = append(fragments, fmt.Sprintf(
+			"AND (l.inserted_at, l.id) > ($%d, $%d)", len(params)+1, len(params)+2))
+		params = append(params, page.After.InsertedAt, page.After.ID)
+	}
+	if page.Since != nil {
+		// Legacy logs have no timestamp; they are kept rather than dropped.
+		fragments = append(fragments, fmt.Sprintf(
+			"AND (l.timestamp >= $%d OR l.timestamp IS NULL)", len(params)+1))
+		params = append(params, *page.Since)
+	}
+
+	var fs []api.Filter
+	if page.GreaterThanID != nil {
+		fs = append(fs, api.Filter{
+			Field: "l.id", Operation: api.FilterOperationGreaterThan, Values: *page.GreaterThanID,
+		})
+	}
+	if page.LessThanID != nil {
+		fs = append(fs, api.Filter{
+			Field: "l.id", Operation: api.FilterOperationLessThan, Values: *page.LessThanID,
+		})
+	}
+	fragment, params := filtersToSQL(fs, params)
+	fragments = append(fragments, fragment)
+
+	innerOrder, outerOrder := fmt.Sprintf(order, asc), fmt.Sprintf(order, asc)
+	if page.Tail {
+		innerOrder = fmt.Sprintf(order, desc)
+	}
+	query := fmt.Sprintf(`
+SELECT id, state, message, inserted_at FROM (
+    SELECT l.id, t.state, %s AS message, l.inserted_at
+    FROM trial_logs l JOIN trials t ON l.trial_id = t.id
+    WHERE l.trial_id = $1
+    %s
+    ORDER BY %s LIMIT $2
+) l
+ORDER BY %s`, trialLogMessage, strings.Join(fragments, "\n"), innerOrder, outerOrder)
+
+	var entries []TrialLogEntry
+	if err := db.queryRows(query, &entries, params...); err != nil {
+		return nil, errors.Wrapf(err, "error querying logs of trial %d", trialID)
+	}
+	return entries, nil
+}
+
+// LastTrialLogBefore returns the cursor of the last log of a trial, in commit order, with a
+// timestamp before the given time, or nil if there is none. It scans back from the newest log, so
+// that finding where a recent window of logs starts is fast even for trials with many logs.
+func (db *PgDB) LastTrialLogBefore(trialID int, before time.Time) (*TrialLogsCursor, error) {
+	var cursors []TrialLogsCursor
+	if err := db.sql.Select(&cursors, `
+SELECT inserted_at, id FROM trial_logs
+WHERE trial_id = $1 AND timestamp < $2
+ORDER BY inserted_at DESC, id DESC LIMIT 1`, trialID, before); err != nil {
+		return nil, errors.Wrapf(err, "finding the last log of trial %d before %s", trialID, before)
+	}
+	if len(cursors) == 0 {
+		return nil, nil
+	}
+	return &cursors[0], nil
+}
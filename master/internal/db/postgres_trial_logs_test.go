@@ -0,0 +1,127 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestTrialLogsInCommitOrder(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	trial := &model.Trial{
+		ExperimentID: addTestExperiment(t, db, string(model.ActiveState)),
+		State:        model.ActiveState,
+		StartTime:    time.Now(),
+		HParams:      model.JSONObj{},
+	}
+	assert.NilError(t, db.AddTrial(trial))
+
+	var logs []*model.TrialLog
+	for _, message := range []string{"first\n", "second\n", "third\n"} {
+		logs = append(logs, &model.TrialLog{TrialID: trial.ID, Message: message})
+	}
+	assert.NilError(t, db.AddTrialLogs(logs))
+	messages := func(logs []*model.TrialLog) []string {
+		var messages []string
+		for _, log := range logs {
+			messages = append(messages, log.Message)
+		}
+		return messages
+	}
+
+	// Logs are held back until they are older than the lag.
+	fetched, err := db.TrialLogs(trial.ID, nil, TrialLogsCommitLag, 0, 10, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(fetched), 0)
+
+	fetched, err = db.TrialLogs(trial.ID, nil, 0, 0, 2, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, messages(fetched), []string{"first\n", "second\n"})
+	after := TrialLogsCursor{InsertedAt: fetched[1].InsertedAt, ID: fetched[1].ID}
+	fetched, err = db.TrialLogs(trial.ID, &after, 0, 0, 10, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, messages(fetched), []string{"third\n"})
+
+	// A log that was inserted earlier but committed later, and so has a greater ID, comes first.
+	_, err = db.sql.Exec(`
+INSERT INTO trial_logs (trial_id, message, inserted_at)
+VALUES ($1, $2, now() - interval '1 minute')`, trial.ID, []byte("late\n"))
+	assert.NilError(t, err)
+	fetched, err = db.TrialLogs(trial.ID, nil, TrialLogsCommitLag, 0, 10, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, messages(fetched), []string{"late\n"})
+
+	entries, err := db.TrialLogEntries(trial.ID, TrialLogsPage{InCommitOrder: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Message, "late\n")
+}
+
+func TestTrialLogsNonASCII(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	trial := &model.Trial{
+		ExperimentID: addTestExperiment(t, db, string(model.ActiveState)),
+		State:        model.ActiveState,
+		StartTime:    time.Now(),
+		HParams:      model.JSONObj{},
+	}
+	assert.NilError(t, db.AddTrial(trial))
+
+	structured := "loss → 0.5 ✓\n"
+	assert.NilError(t, db.AddTrialLogs([]*model.TrialLog{
+		{TrialID: trial.ID, Message: "héllo wörld\n"},
+		{TrialID: trial.ID, Log: &structured},
+	}))
+
+	entries, err := db.TrialLogEntries(trial.ID, TrialLogsPage{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].Message, "héllo wörld\n")
+	assert.Assert(t, strings.HasSuffix(entries[1].Message, " || "+structured), entries[1].Message)
+}
+
+func TestTrialLogsSinceKeepsLogsWithoutTimestamps(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	assert.NilError(t, db.Migrate(testMigrations))
+
+	trial := &model.Trial{
+		ExperimentID: addTestExperiment(t, db, string(model.ActiveState)),
+		State:        model.ActiveState,
+		StartTime:    time.Now(),
+		HParams:      model.JSONObj{},
+	}
+	assert.NilError(t, db.AddTrial(trial))
+
+	since := time.Now().UTC()
+	old, recent := since.Add(-time.Hour), since.Add(time.Minute)
+	assert.NilError(t, db.AddTrialLogs([]*model.TrialLog{
+		{TrialID: trial.ID, Message: "old\n", Timestamp: &old},
+		{TrialID: trial.ID, Message: "untimed\n"},
+		{TrialID: trial.ID, Message: "recent\n", Timestamp: &recent},
+	}))
+
+	entries, err := db.TrialLogEntries(trial.ID, TrialLogsPage{Since: &since})
+	assert.NilError(t, err)
+	var messages []string
+	for _, entry := range entries {
+		messages = append(messages, entry.Message)
+	}
+	assert.DeepEqual(t, messages, []string{"untimed\n", "recent\n"})
+}
@@ -20,9 +20,15 @@ const SessionDuration = 7 * 24 * time.Hour
 
 // StartUserSession creates a row in the user_sessions table.
 func (db *PgDB) StartUserSession(user *model.User) (string, error) {
+	return db.StartUserSessionUntil(user, time.Now().Add(SessionDuration))
+}
+
+// StartUserSessionUntil creates a row in the user_sessions table for a session that expires at
+// expiry.
+func (db *PgDB) StartUserSessionUntil(user *model.User, expiry time.Time) (string, error) {
 	userSession := &model.UserSession{
 		UserID: user.ID,
-		Expiry: time.Now().Add(SessionDuration),
+		Expiry: expiry,
 	}
 
 	query := "INSERT INTO user_sessions (user_id, expiry) VALUES (:user_id, :expiry) RETURNING id"
@@ -259,7 +265,7 @@ func (db *PgDB) UserByID(userID model.UserID) (*model.FullUser, error) {
 	var fu model.FullUser
 	if err := db.query(`
 SELECT
-	u.id, u.username, u.admin, u.active,
+	u.id, u.username, u.admin, u.active, u.email,
 	h.uid AS agent_uid, h.gid AS agent_gid, h.user_ AS agent_user, h.group_ AS agent_group
 FROM users u
 LEFT OUTER JOIN agent_user_groups h ON (u.id = h.user_id)
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddWebhook adds the webhook to the database and sets its ID.
+func (db *PgDB) AddWebhook(webhook *model.Webhook) error {
+	if err := db.namedGet(&webhook.ID, `
+INSERT INTO webhooks (url, secret, event_types, created_at)
+VALUES (:url, :secret, :event_types, :created_at)
+RETURNING id`, webhook); err != nil {
+		return errors.Wrapf(err, "error inserting webhook for %s", webhook.URL)
+	}
+	return nil
+}
+
+// WebhookByID looks up a webhook by ID, returning ErrNotFound if it does not exist.
+func (db *PgDB) WebhookByID(id int) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := db.query(`
+SELECT id, url, secret, event_types, created_at
+FROM webhooks
+WHERE id = $1`, &webhook, id); err != nil {
+		return nil, errors.Wrapf(err, "error querying for webhook %d", id)
+	}
+	return &webhook, nil
+}
+
+// Webhooks returns all webhooks, ordered by ID.
+func (db *PgDB) Webhooks() ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := db.queryRows(`
+SELECT id, url, secret, event_types, created_at
+FROM webhooks
+ORDER BY id`, &webhooks); err != nil {
+		return nil, errors.Wrap(err, "error querying for webhooks")
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook saves the URL, secret and event types of the webhook.
+func (db *PgDB) UpdateWebhook(webhook *model.Webhook) error {
+	res, err := db.sql.NamedExec(`
+UPDATE webhooks
+SET url = :url, secret = :secret, event_types = :event_types
+WHERE id = :id`, webhook)
+	if err != nil {
+		return errors.Wrapf(err, "error updating webhook %d", webhook.ID)
+	}
+	if numRows, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "checking affected rows for updating webhook %d", webhook.ID)
+	} else if numRows == 0 {
+		return errors.WithStack(ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteWebhook deletes the webhook and the record of its deliveries.
+func (db *PgDB) DeleteWebhook(id int) error {
+	res, err := db.sql.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting webhook %d", id)
+	}
+	if numRows, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "checking affected rows for deleting webhook %d", id)
+	} else if numRows == 0 {
+		return errors.WithStack(ErrNotFound)
+	}
+	return nil
+}
+
+// AddWebhookDelivery records an attempt to deliver an event to a webhook.
+func (db *PgDB) AddWebhookDelivery(delivery *model.WebhookDelivery) error {
+	if err := db.namedGet(&delivery.ID, `
+INSERT INTO webhook_deliveries
+(webhook_id, event_id, event_type, attempt, time, status_code, error)
+VALUES (:webhook_id, :event_id, :event_type, :attempt, :time, :status_code, :error)
+RETURNING id`, delivery); err != nil {
+		return errors.Wrapf(err, "error recording delivery to webhook %d", delivery.WebhookID)
+	}
+	return nil
+}
+
+// WebhookDeliveries returns the latest attempts to deliver events to a webhook, newest first.
+func (db *PgDB) WebhookDeliveries(webhookID, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	if err := db.queryRows(`
+SELECT id, webhook_id, event_id, event_type, attempt, time, status_code, error
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY time DESC, id DESC
+LIMIT $2`, &deliveries, webhookID, limit); err != nil {
+		return nil, errors.Wrapf(err, "error querying for deliveries to webhook %d", webhookID)
+	}
+	return deliveries, nil
+}
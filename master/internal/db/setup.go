@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -15,12 +16,12 @@ const (
 	sslModeDisable = "disable"
 )
 
-// Setup connects to the database and run any necessary migrations.
-func Setup(opts *Config) (*PgDB, error) {
+// Setup connects to the database, retrying for up to the timeout, and run any necessary migrations.
+func Setup(opts *Config, timeout time.Duration) (*PgDB, error) {
 	dbURL := fmt.Sprintf(cnxTpl, opts.User, opts.Password, opts.Host, opts.Port, opts.Name)
 	dbURL += fmt.Sprintf(sslTpl, opts.SSLMode, opts.SSLRootCert)
 	log.Infof("connecting to database %s:%s", opts.Host, opts.Port)
-	db, err := ConnectPostgres(dbURL)
+	db, err := ConnectPostgres(dbURL, timeout)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error connecting to database: %s:%s", opts.Host, opts.Port)
 	}
@@ -29,7 +30,12 @@ func Setup(opts *Config) (*PgDB, error) {
 
 	log.Infof("running migrations from %v", opts.Migrations)
 	if err = db.Migrate(opts.Migrations); err != nil {
+		_ = db.Close()
 		return nil, errors.Wrap(err, "running migrations")
 	}
-	return db, db.initAuthKeys()
+	if err = db.initAuthKeys(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
 }
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+// earlyStopping evaluates the early stopping policies of an experiment as validation metrics
+// arrive. It is independent of the searcher: the experiment acts on its decisions by stopping
+// trials, which the searcher then sees as closed.
+type earlyStopping struct {
+	config          model.EarlyStoppingConfig
+	metric          string
+	smallerIsBetter bool
+	trials          map[int]*earlyStoppingTrial
+}
+
+type earlyStoppingTrial struct {
+	best float64
+	// sinceImprovement is the number of validations since the trial last improved on its best.
+	sinceImprovement int
+}
+
+// earlyStoppingDecision is the outcome of evaluating the policies against a validation. reason
+// describes why the trial or experiment should stop.
+type earlyStoppingDecision struct {
+	stopTrial      bool
+	stopExperiment bool
+	reason         string
+}
+
+// newEarlyStopping returns the early stopping policies of the experiment, or nil if it has none.
+func newEarlyStopping(config model.ExperimentConfig) *earlyStopping {
+	if config.EarlyStopping == nil {
+		return nil
+	}
+	metric, smallerIsBetter := config.EarlyStoppingMetric()
+	return &earlyStopping{
+		config:          *config.EarlyStopping,
+		metric:          metric,
+		smallerIsBetter: smallerIsBetter,
+		trials:          make(map[int]*earlyStoppingTrial),
+	}
+}
+
+func (e *earlyStopping) better(a, b float64) bool {
+	if e.smallerIsBetter {
+		return a < b
+	}
+	return a > b
+}
+
+// trialState returns the best value of the metric that the trial reached and the number of
+// validations since it last improved on it, if it validated.
+func (e *earlyStopping) trialState(trialID int) (float64, int, bool) {
+	state, ok := e.trials[trialID]
+	if !ok {
+		return 0, 0, false
+	}
+	return state.best, state.sinceImprovement, true
+}
+
+// restoreTrial restores the state of a trial that was recorded before the experiment was restored.
+func (e *earlyStopping) restoreTrial(trialID int, best float64, sinceImprovement int) {
+	e.trials[trialID] = &earlyStoppingTrial{best: best, sinceImprovement: sinceImprovement}
+}
+
+// validationCompleted records a validation of the given trial and decides whether the trial or
+// the whole experiment should be stopped.
+func (e *earlyStopping) validationCompleted(
+	trialID int, metrics workload.ValidationMetrics,
+) (earlyStoppingDecision, error) {
+	value, err := metrics.Metric(e.metric)
+	if err != nil {
+		return earlyStoppingDecision{}, errors.Wrap(err, "error evaluating early_stopping policy")
+	}
+
+	target := e.config.TargetMetric
+	if target != nil && (value == *target || e.better(value, *target)) {
+		return earlyStoppingDecision{
+			stopExperiment: true,
+			reason: fmt.Sprintf("trial %d reached the early_stopping target_metric: %s = %v",
+				trialID, e.metric, value),
+		}, nil
+	}
+
+	state, ok := e.trials[trialID]
+	switch {
+	case !ok:
+		e.trials[trialID] = &earlyStoppingTrial{best: value}
+		return earlyStoppingDecision{}, nil
+	case e.better(value, state.best):
+		state.best = value
+		state.sinceImprovement = 0
+		return earlyStoppingDecision{}, nil
+	}
+
+	state.sinceImprovement++
+	if patience := e.config.Patience; patience != nil && state.sinceImprovement >= *patience {
+		return earlyStoppingDecision{
+			stopTrial: true,
+			reason: fmt.Sprintf("%s has not improved on %v in %d validations",
+				e.metric, state.best, state.sinceImprovement),
+		}, nil
+	}
+	return earlyStoppingDecision{}, nil
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+func earlyStoppingConfig(policy model.EarlyStoppingConfig) model.ExperimentConfig {
+	config := model.DefaultExperimentConfig(nil)
+	config.Searcher.Metric = "error"
+	config.Searcher.SmallerIsBetter = true
+	config.EarlyStopping = &policy
+	return config
+}
+
+func validation(value float64) workload.ValidationMetrics {
+	return workload.ValidationMetrics{Metrics: map[string]interface{}{"error": value}}
+}
+
+func TestEarlyStoppingPatience(t *testing.T) {
+	patience := 2
+	e := newEarlyStopping(earlyStoppingConfig(model.EarlyStoppingConfig{Patience: &patience}))
+
+	for _, step := range []struct {
+		trialID int
+		value   float64
+		stop    bool
+	}{
+		{1, 0.5, false},
+		{2, 0.9, false},
+		{1, 0.4, false},
+		{1, 0.45, false},
+		{2, 0.95, false},
+		// Improving resets the patience of trial 1.
+		{1, 0.3, false},
+		{2, 0.9, true},
+		{1, 0.3, false},
+		{1, 0.35, true},
+	} {
+		decision, err := e.validationCompleted(step.trialID, validation(step.value))
+		assert.NilError(t, err)
+		assert.Equal(t, decision.stopTrial, step.stop)
+		assert.Assert(t, !decision.stopExperiment)
+	}
+}
+
+func TestEarlyStoppingTargetMetric(t *testing.T) {
+	target := 0.1
+	larger := false
+	e := newEarlyStopping(earlyStoppingConfig(model.EarlyStoppingConfig{
+		TargetMetric: &target, SmallerIsBetter: &larger,
+	}))
+
+	decision, err := e.validationCompleted(1, validation(0.05))
+	assert.NilError(t, err)
+	assert.Assert(t, !decision.stopExperiment)
+
+	decision, err = e.validationCompleted(2, validation(0.1))
+	assert.NilError(t, err)
+	assert.Assert(t, decision.stopExperiment)
+	assert.Assert(t, !decision.stopTrial)
+}
+
+func TestEarlyStoppingUnknownMetric(t *testing.T) {
+	metric := "accuracy"
+	patience := 1
+	e := newEarlyStopping(earlyStoppingConfig(model.EarlyStoppingConfig{
+		Metric: &metric, Patience: &patience,
+	}))
+
+	_, err := e.validationCompleted(1, validation(0.5))
+	assert.ErrorContains(t, err, "'accuracy' could not be found")
+}
+
+func TestNoEarlyStopping(t *testing.T) {
+	assert.Assert(t, newEarlyStopping(model.DefaultExperimentConfig(nil)) == nil)
+}
+
+func TestEarlyStoppingRestoreTrial(t *testing.T) {
+	patience := 2
+	e := newEarlyStopping(earlyStoppingConfig(model.EarlyStoppingConfig{Patience: &patience}))
+	_, _, ok := e.trialState(1)
+	assert.Assert(t, !ok)
+
+	_, err := e.validationCompleted(1, validation(0.5))
+	assert.NilError(t, err)
+	_, err = e.validationCompleted(1, validation(0.6))
+	assert.NilError(t, err)
+	best, sinceImprovement, ok := e.trialState(1)
+	assert.Assert(t, ok)
+	assert.Equal(t, best, 0.5)
+	assert.Equal(t, sinceImprovement, 1)
+
+	// A restored experiment picks up the patience of the trial where it left off.
+	restored := newEarlyStopping(
+		earlyStoppingConfig(model.EarlyStoppingConfig{Patience: &patience}))
+	restored.restoreTrial(1, best, sinceImprovement)
+	decision, err := restored.validationCompleted(1, validation(0.7))
+	assert.NilError(t, err)
+	assert.Assert(t, decision.stopTrial)
+}
+
+func TestCheckEarlyStoppingMetricNames(t *testing.T) {
+	other := "accuracy"
+	config := earlyStoppingConfig(model.EarlyStoppingConfig{Metric: &other})
+	parentID := 3
+
+	// Metrics are only checked against those that the parent reported, if it validated at all.
+	assert.NilError(t, checkEarlyStoppingMetricNames(config, nil, nil))
+	assert.NilError(t, checkEarlyStoppingMetricNames(config, &parentID, nil))
+
+	assert.NilError(t, checkEarlyStoppingMetricNames(
+		config, &parentID, []string{"error", "accuracy"}))
+	assert.ErrorContains(t, checkEarlyStoppingMetricNames(config, &parentID, []string{"error"}),
+		"early_stopping metric accuracy is not a validation metric of parent experiment 3 (error)")
+}
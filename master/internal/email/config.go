@@ -0,0 +1,52 @@
+package email
+
+import (
+	"encoding/json"
+	"net/mail"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Config configures the SMTP server through which the master sends emails.
+type Config struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password" secret:"true"`
+	// From is the address that emails are sent from.
+	From string `json:"from"`
+	// MasterURL is the URL at which users reach the master, which emails link to.
+	MasterURL string `json:"master_url"`
+}
+
+// DefaultConfig returns the default SMTP configuration.
+func DefaultConfig() *Config {
+	return &Config{Port: 587}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	*c = *DefaultConfig()
+	type DefaultParser *Config
+	return json.Unmarshal(data, DefaultParser(c))
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	errs := []error{
+		check.NotEmpty(c.Host, "smtp.host must be set"),
+		check.GreaterThan(c.Port, 0, "smtp.port must be positive"),
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		errs = append(errs, errors.Wrap(err, "smtp.from must be an email address"))
+	}
+	if c.MasterURL != "" {
+		if parsed, err := url.Parse(c.MasterURL); err != nil || parsed.Host == "" {
+			errs = append(errs, errors.Errorf("invalid smtp.master_url %q", c.MasterURL))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,307 @@
+// Package email sends emails that summarize experiments to their owners once the experiments end.
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/metrics"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// sendTimeout bounds how long sending an email to the SMTP server may take.
+	sendTimeout = 30 * time.Second
+
+	sentMetric   = "det_email_notifications_sent_total"
+	failedMetric = "det_email_notifications_failed_total"
+)
+
+// Addr is the address of the actor that sends emails.
+var Addr = actor.Addr("email")
+
+// ExperimentEnded describes an experiment that reached a terminal state.
+type ExperimentEnded struct {
+	ID          int
+	Description string
+	State       model.State
+	// Metric is the searcher metric, and BestValidation the best value of it that any trial
+	// reported, if any.
+	Metric         string
+	BestValidation *float64
+	StartTime      time.Time
+	EndTime        time.Time
+	// Recipients are the addresses that the summary is sent to. If nil, it is sent to the owner of
+	// the experiment, if they have an email address.
+	Recipients []string
+	OwnerID    *model.UserID
+}
+
+// NotifyExperimentEnded sends a summary of the experiment by email. It returns immediately, and
+// does nothing if the master is not configured to send emails.
+func NotifyExperimentEnded(system *actor.System, msg ExperimentEnded) {
+	system.TellAt(Addr, msg)
+}
+
+// Store is the storage of the email addresses of users.
+type Store interface {
+	UserByID(userID model.UserID) (*model.FullUser, error)
+}
+
+type (
+	// message is an email to send.
+	message struct {
+		experimentID int
+		to           []string
+		body         []byte
+	}
+	// sendResult is the outcome of sending an email.
+	sendResult struct {
+		message
+		err error
+	}
+	// sendFunc sends the email, including its headers, to the recipients.
+	sendFunc func(to []string, msg []byte) error
+)
+
+type emailActor struct {
+	config    Config
+	store     Store
+	templates *template.Template
+	metrics   *metrics.Registry
+	send      sendFunc
+
+	sent, failed int
+}
+
+// NewActor creates an actor that sends the summaries of experiments passed to
+// NotifyExperimentEnded through the SMTP server. The subject and body of the emails are rendered
+// by the "subject" and "body" templates defined by emailTemplates. Failures are logged and counted
+// in the metrics.
+func NewActor(
+	config Config, store Store, emailTemplates []byte, registry *metrics.Registry,
+) (actor.Actor, error) {
+	templates, err := template.New("email").Parse(string(emailTemplates))
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing email templates")
+	}
+	for _, name := range []string{"subject", "body"} {
+		if templates.Lookup(name) == nil {
+			return nil, errors.Errorf("email templates do not define %q", name)
+		}
+	}
+	return &emailActor{
+		config:    config,
+		store:     store,
+		templates: templates,
+		metrics:   registry,
+		send:      smtpSender(config),
+	}, nil
+}
+
+// Receive implements the actor.Actor interface.
+func (e *emailActor) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		e.updateMetrics()
+
+	case actor.PostStop:
+
+	case ExperimentEnded:
+		to, err := e.recipients(msg)
+		switch {
+		case err != nil:
+			ctx.Log().WithError(err).Errorf(
+				"cannot find who to email about experiment %d", msg.ID)
+			e.failed++
+			e.updateMetrics()
+			return nil
+		case len(to) == 0:
+			return nil
+		}
+		body, err := e.render(msg, to)
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("cannot render email about experiment %d", msg.ID)
+			e.failed++
+			e.updateMetrics()
+			return nil
+		}
+		self := ctx.Self()
+		go func() {
+			m := message{experimentID: msg.ID, to: to, body: body}
+			self.System().Tell(self, sendResult{message: m, err: e.send(m.to, m.body)})
+		}()
+
+	case sendResult:
+		if msg.err != nil {
+			ctx.Log().WithError(msg.err).Errorf(
+				"cannot email %s about experiment %d", strings.Join(msg.to, ", "), msg.experimentID)
+			e.failed++
+		} else {
+			e.sent++
+		}
+		e.updateMetrics()
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (e *emailActor) updateMetrics() {
+	if e.metrics == nil {
+		return
+	}
+	e.metrics.Set(sentMetric, "Number of emails sent by the master.", float64(e.sent))
+	e.metrics.Set(failedMetric,
+		"Number of emails that the master failed to send.", float64(e.failed))
+}
+
+func (e *emailActor) recipients(msg ExperimentEnded) ([]string, error) {
+	if msg.Recipients != nil || msg.OwnerID == nil {
+		return msg.Recipients, nil
+	}
+	owner, err := e.store.UserByID(*msg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if !owner.Email.Valid || owner.Email.String == "" {
+		return nil, nil
+	}
+	return []string{owner.Email.String}, nil
+}
+
+// templateData is the data that email templates are rendered with.
+type templateData struct {
+	ID          int
+	Description string
+	State       model.State
+	Metric      string
+	// BestValidation is empty if no trial reported the metric.
+	BestValidation string
+	StartTime      time.Time
+	EndTime        time.Time
+	Duration       time.Duration
+	// URL is empty if the URL of the master is not configured.
+	URL string
+}
+
+// render returns the email, including its headers.
+func (e *emailActor) render(msg ExperimentEnded, to []string) ([]byte, error) {
+	data := templateData{
+		ID:          msg.ID,
+		Description: msg.Description,
+		State:       msg.State,
+		Metric:      msg.Metric,
+		StartTime:   msg.StartTime,
+		EndTime:     msg.EndTime,
+		Duration:    msg.EndTime.Sub(msg.StartTime).Round(time.Second),
+	}
+	if msg.BestValidation != nil {
+		data.BestValidation = strconv.FormatFloat(*msg.BestValidation, 'g', -1, 64)
+	}
+	if e.config.MasterURL != "" {
+		data.URL = fmt.Sprintf(
+			"%s/det/experiments/%d", strings.TrimSuffix(e.config.MasterURL, "/"), msg.ID)
+	}
+
+	var subject, body bytes.Buffer
+	if err := e.templates.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := e.templates.ExecuteTemplate(&body, "body", data); err != nil {
+		return nil, err
+	}
+
+	var email bytes.Buffer
+	headers := [][2]string{
+		{"From", e.config.From},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String()))},
+		{"Date", msg.EndTime.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&email, "%s: %s\r\n", header[0], header[1])
+	}
+	email.WriteString("\r\n")
+	email.WriteString(strings.ReplaceAll(strings.TrimSpace(body.String()), "\n", "\r\n"))
+	email.WriteString("\r\n")
+	return email.Bytes(), nil
+}
+
+// smtpSender returns a function that sends emails through the SMTP server, using STARTTLS if the
+// server supports it. Unlike smtp.SendMail, it gives up on servers that do not respond.
+func smtpSender(config Config) sendFunc {
+	return func(to []string, msg []byte) error {
+		addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+		conn, err := net.DialTimeout("tcp", addr, sendTimeout)
+		if err != nil {
+			return errors.Wrapf(err, "error connecting to %s", addr)
+		}
+		if err = conn.SetDeadline(time.Now().Add(sendTimeout)); err != nil {
+			_ = conn.Close()
+			return err
+		}
+		client, err := smtp.NewClient(conn, config.Host)
+		if err != nil {
+			_ = conn.Close()
+			return errors.Wrapf(err, "error connecting to %s", addr)
+		}
+		defer func() {
+			_ = client.Close()
+		}()
+
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err = client.StartTLS(&tls.Config{ServerName: config.Host}); err != nil {
+				return errors.Wrap(err, "error starting TLS")
+			}
+		}
+		if config.Username != "" {
+			auth := smtp.PlainAuth("", config.Username, config.Password, config.Host)
+			if err = client.Auth(auth); err != nil {
+				return errors.Wrap(err, "error authenticating")
+			}
+		}
+		from, err := mail.ParseAddress(config.From)
+		if err != nil {
+			return err
+		}
+		if err = client.Mail(from.Address); err != nil {
+			return err
+		}
+		for _, rcpt := range to {
+			addr, perr := mail.ParseAddress(rcpt)
+			if perr != nil {
+				return errors.Wrapf(perr, "invalid recipient %s", rcpt)
+			}
+			if err = client.Rcpt(addr.Address); err != nil {
+				return errors.Wrapf(err, "error adding recipient %s", rcpt)
+			}
+		}
+		w, err := client.Data()
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(msg); err != nil {
+			return err
+		}
+		if err = w.Close(); err != nil {
+			return err
+		}
+		return client.Quit()
+	}
+}
@@ -0,0 +1,121 @@
+package email
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/metrics"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+type fakeStore struct {
+	users map[model.UserID]*model.FullUser
+}
+
+func (s fakeStore) UserByID(userID model.UserID) (*model.FullUser, error) {
+	return s.users[userID], nil
+}
+
+type sent struct {
+	to  []string
+	msg string
+}
+
+func newTestActor(t *testing.T, registry *metrics.Registry) (*emailActor, chan sent) {
+	templates, err := ioutil.ReadFile(
+		filepath.Join("../../static/srv", etc.ExperimentEmailTemplateResource))
+	assert.NilError(t, err)
+	config := Config{Host: "smtp.example.com", From: "det@example.com",
+		MasterURL: "https://det.example.com/"}
+	owner := &model.FullUser{ID: 1, Email: null.StringFrom("owner@example.com")}
+	a, err := NewActor(config, fakeStore{map[model.UserID]*model.FullUser{1: owner}},
+		templates, registry)
+	assert.NilError(t, err)
+
+	emails := make(chan sent, 10)
+	e := a.(*emailActor)
+	e.send = func(to []string, msg []byte) error {
+		emails <- sent{to, string(msg)}
+		if to[0] == "broken@example.com" {
+			return bytes.ErrTooLarge
+		}
+		return nil
+	}
+	return e, emails
+}
+
+func receive(t *testing.T, emails chan sent) sent {
+	select {
+	case email := <-emails:
+		return email
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an email")
+		return sent{}
+	}
+}
+
+func TestExperimentEndedEmail(t *testing.T) {
+	registry := metrics.NewRegistry()
+	e, emails := newTestActor(t, registry)
+	system := actor.NewSystem(t.Name())
+	ref, _ := system.ActorOf(Addr, e)
+
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	best := 0.025
+	ownerID := model.UserID(1)
+	system.Tell(ref, ExperimentEnded{
+		ID:             7,
+		Description:    "mnist",
+		State:          model.CompletedState,
+		Metric:         "validation_error",
+		BestValidation: &best,
+		StartTime:      start,
+		EndTime:        start.Add(90 * time.Minute),
+		OwnerID:        &ownerID,
+	})
+	email := receive(t, emails)
+	assert.DeepEqual(t, email.to, []string{"owner@example.com"})
+	for _, expected := range []string{
+		"To: owner@example.com\r\n",
+		"Subject: [Determined] Experiment 7 (mnist) ended: COMPLETED\r\n",
+		"Best validation validation_error: 0.025\r\n",
+		"Duration: 1h30m0s\r\n",
+		"https://det.example.com/det/experiments/7\r\n",
+	} {
+		assert.Assert(t, strings.Contains(email.msg, expected), "%q not in %q", expected, email.msg)
+	}
+
+	// Explicit recipients replace the owner, and failures are counted.
+	system.Tell(ref, ExperimentEnded{
+		ID:         8,
+		State:      model.ErrorState,
+		Recipients: []string{"broken@example.com"},
+		OwnerID:    &ownerID,
+	})
+	email = receive(t, emails)
+	assert.DeepEqual(t, email.to, []string{"broken@example.com"})
+	assert.Assert(t, strings.Contains(email.msg, "Best validation : none reported\r\n"))
+
+	// An empty list of recipients sends nothing.
+	system.Tell(ref, ExperimentEnded{ID: 9, Recipients: []string{}, OwnerID: &ownerID})
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		var buf bytes.Buffer
+		assert.NilError(t, registry.WriteText(&buf))
+		if strings.Contains(buf.String(), sentMetric+" 1\n") &&
+			strings.Contains(buf.String(), failedMetric+" 1\n") {
+			break
+		}
+		assert.Assert(t, time.Now().Before(deadline), "unexpected metrics: %s", buf.String())
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, len(emails), 0)
+}
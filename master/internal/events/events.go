@@ -0,0 +1,198 @@
+// Package events keeps a bounded history of changes to the state of the cluster, such as
+// experiments changing state or agents connecting, and streams them to subscribers.
+package events
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// Type is the type of an event.
+type Type string
+
+const (
+	// ExperimentStateChanged is published when an experiment changes state.
+	ExperimentStateChanged Type = "experiment.state_changed"
+	// TrialStateChanged is published when a trial changes state.
+	TrialStateChanged Type = "trial.state_changed"
+	// AgentConnected is published when an agent joins a resource pool.
+	AgentConnected Type = "agent.connected"
+	// AgentDisconnected is published when an agent leaves a resource pool.
+	AgentDisconnected Type = "agent.disconnected"
+	// ResourcePoolScaled is published when the provisioner of a resource pool launches or
+	// terminates instances.
+	ResourcePoolScaled Type = "resource_pool.scaled"
+)
+
+// Types are all the types of events.
+var Types = map[Type]bool{
+	ExperimentStateChanged: true,
+	TrialStateChanged:      true,
+	AgentConnected:         true,
+	AgentDisconnected:      true,
+	ResourcePoolScaled:     true,
+}
+
+const (
+	// historySize is the number of past events kept to resume streams from.
+	historySize = 1000
+	// subscriberBufferSize is the number of events that may be waiting to be sent to a subscriber.
+	// Subscribers that fall further behind are disconnected.
+	subscriberBufferSize = 256
+)
+
+// Addr is the address of the actor that keeps events and streams them to subscribers.
+var Addr = actor.Addr("events")
+
+// Event is a change to the state of the cluster. IDs increase with every event published since
+// the master started.
+type Event struct {
+	ID   uint64      `json:"id"`
+	Type Type        `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Publish records an event. It returns immediately, and does nothing if events are not running.
+func Publish(system *actor.System, eventType Type, data interface{}) {
+	system.TellAt(Addr, Event{Type: eventType, Time: time.Now().UTC(), Data: data})
+}
+
+type (
+	// Subscribe subscribes to events; the response is a *Subscription.
+	Subscribe struct {
+		// Types are the types of events to receive, or all types if empty.
+		Types map[Type]bool
+		// LastEventID resumes a stream after the event with this ID, if set.
+		LastEventID *uint64
+	}
+
+	// Unsubscribe ends a subscription.
+	Unsubscribe struct {
+		Subscription *Subscription
+	}
+
+	// Subscription is a stream of events.
+	Subscription struct {
+		// Backlog holds the past events after LastEventID.
+		Backlog []Event
+		// Missed is set if some of the events after LastEventID are no longer kept.
+		Missed bool
+		// Events receives new events. It is closed if the subscriber falls behind, after which
+		// Overflowed is set.
+		Events     <-chan Event
+		Overflowed bool
+
+		events chan Event
+		types  map[Type]bool
+	}
+)
+
+func (s *Subscription) wants(event Event) bool {
+	return len(s.types) == 0 || s.types[event.Type]
+}
+
+// history is a ring buffer of the latest events.
+type history struct {
+	events []Event
+	// next is the index at which the next event is stored, once the buffer is full.
+	next int
+}
+
+func (h *history) add(event Event) {
+	if len(h.events) < cap(h.events) {
+		h.events = append(h.events, event)
+		return
+	}
+	h.events[h.next] = event
+	h.next = (h.next + 1) % len(h.events)
+}
+
+// since returns the events after the one with the given ID, oldest first, and whether any such
+// events are no longer kept.
+func (h *history) since(id uint64) ([]Event, bool) {
+	var events []Event
+	ordered := append(append([]Event{}, h.events[h.next:]...), h.events[:h.next]...)
+	for _, event := range ordered {
+		if event.ID > id {
+			events = append(events, event)
+		}
+	}
+	missed := len(ordered) > 0 && ordered[0].ID > id+1
+	return events, missed
+}
+
+type eventsActor struct {
+	history     history
+	lastID      uint64
+	subscribers map[*Subscription]bool
+}
+
+// NewActor creates the actor that keeps events and streams them to subscribers.
+func NewActor() actor.Actor {
+	return &eventsActor{
+		history:     history{events: make([]Event, 0, historySize)},
+		subscribers: map[*Subscription]bool{},
+	}
+}
+
+// Receive implements the actor.Actor interface.
+func (a *eventsActor) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+
+	case actor.PostStop:
+		for sub := range a.subscribers {
+			a.unsubscribe(sub)
+		}
+
+	case Event:
+		a.lastID++
+		msg.ID = a.lastID
+		a.history.add(msg)
+		for sub := range a.subscribers {
+			if !sub.wants(msg) {
+				continue
+			}
+			select {
+			case sub.events <- msg:
+			default:
+				ctx.Log().Warnf("disconnecting an event subscriber that fell %d events behind",
+					subscriberBufferSize)
+				sub.Overflowed = true
+				a.unsubscribe(sub)
+			}
+		}
+
+	case Subscribe:
+		events := make(chan Event, subscriberBufferSize)
+		sub := &Subscription{Events: events, events: events, types: msg.Types}
+		if msg.LastEventID != nil {
+			backlog, missed := a.history.since(*msg.LastEventID)
+			for _, event := range backlog {
+				if sub.wants(event) {
+					sub.Backlog = append(sub.Backlog, event)
+				}
+			}
+			// IDs start over when the master restarts, so later IDs come from an earlier run.
+			sub.Missed = missed || *msg.LastEventID > a.lastID
+		}
+		a.subscribers[sub] = true
+		ctx.Respond(sub)
+
+	case Unsubscribe:
+		a.unsubscribe(msg.Subscription)
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (a *eventsActor) unsubscribe(sub *Subscription) {
+	if a.subscribers[sub] {
+		delete(a.subscribers, sub)
+		close(sub.events)
+	}
+}
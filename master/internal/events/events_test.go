@@ -0,0 +1,90 @@
+package events
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+func subscribe(t *testing.T, system *actor.System, msg Subscribe) *Subscription {
+	sub, ok := system.AskAt(Addr, msg).Get().(*Subscription)
+	assert.Assert(t, ok)
+	return sub
+}
+
+func ids(events []Event) []uint64 {
+	var ids []uint64
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestHistory(t *testing.T) {
+	h := history{events: make([]Event, 0, 3)}
+	events, missed := h.since(0)
+	assert.Equal(t, len(events), 0)
+	assert.Assert(t, !missed)
+
+	for id := uint64(1); id <= 5; id++ {
+		h.add(Event{ID: id})
+	}
+	events, missed = h.since(3)
+	assert.DeepEqual(t, ids(events), []uint64{4, 5})
+	assert.Assert(t, !missed)
+	events, missed = h.since(1)
+	assert.DeepEqual(t, ids(events), []uint64{3, 4, 5})
+	assert.Assert(t, missed)
+}
+
+func TestSubscription(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	ref, _ := system.ActorOf(Addr, NewActor())
+
+	Publish(system, AgentConnected, "agent-1")
+	Publish(system, ExperimentStateChanged, 1)
+	Publish(system, AgentDisconnected, "agent-1")
+
+	// Subscribers resume after the last event they saw, receiving only the types they want.
+	lastID := uint64(0)
+	sub := subscribe(t, system, Subscribe{
+		Types:       map[Type]bool{AgentConnected: true, AgentDisconnected: true},
+		LastEventID: &lastID,
+	})
+	assert.DeepEqual(t, ids(sub.Backlog), []uint64{1, 3})
+	assert.Assert(t, !sub.Missed)
+
+	Publish(system, ExperimentStateChanged, 1)
+	Publish(system, AgentConnected, "agent-2")
+	event := <-sub.Events
+	assert.Equal(t, event.ID, uint64(5))
+	assert.Equal(t, event.Data, "agent-2")
+
+	// IDs from before a restart of the master cannot be resumed from.
+	lastID = 100
+	assert.Assert(t, subscribe(t, system, Subscribe{LastEventID: &lastID}).Missed)
+
+	// Subscribers that fall behind are disconnected.
+	slow := subscribe(t, system, Subscribe{})
+	for i := 0; i <= subscriberBufferSize; i++ {
+		Publish(system, TrialStateChanged, i)
+	}
+	// Wait for the events to be published before receiving them.
+	subscribe(t, system, Subscribe{})
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	assert.Equal(t, received, subscriberBufferSize)
+	assert.Assert(t, slow.Overflowed)
+
+	system.Tell(ref, Unsubscribe{Subscription: sub})
+	for range sub.Events {
+	}
+	assert.Assert(t, !sub.Overflowed)
+
+	ref.Stop()
+	assert.NilError(t, ref.AwaitTermination())
+}
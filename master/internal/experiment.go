@@ -3,6 +3,7 @@ package internal
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -10,9 +11,13 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/email"
+	"github.com/determined-ai/determined/master/internal/events"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/telemetry"
+	"github.com/determined-ai/determined/master/internal/webhooks"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/searcher"
@@ -49,6 +54,30 @@ type (
 	trialsRestored struct{}
 	killExperiment struct{}
 
+	// Messages used to inspect and adjust the number of trials that the searcher keeps running.
+	getMaxConcurrentTrials struct{}
+	setMaxConcurrentTrials struct{ maxConcurrentTrials int }
+
+	// getSearcherState asks for a snapshot of the searcher. The snapshot is copied out of the
+	// searcher, so that it is serialized outside of the experiment actor.
+	getSearcherState struct{}
+
+	// setModelDefinition replaces the model definition of an experiment that has not run yet with
+	// one that is already in the model definition storage. The sender loads and decodes it, so
+	// that the experiment only records it and forwards it to its trials, which captured the model
+	// definition when created.
+	setModelDefinition struct {
+		// modelDefinitionHash identifies the model definition in the model definition storage.
+		modelDefinitionHash  string
+		modelDefinitionBytes []byte
+		modelDefinition      archive.Archive
+	}
+
+	// Messages used by the external controller of a custom searcher.
+	getCustomSearcherEvents       struct{ acknowledged int }
+	postCustomSearcherOperations  struct{ operations []searcher.CustomOperation }
+	customSearcherControllerCheck struct{}
+
 	// doneProcessingSearcherOperations message is only used during master restart, to ensure that
 	// all the searcher operations created by a given event (experiment created / trial created /
 	// workload completed) are fully handled before passing another event to the actor system. This
@@ -70,6 +99,15 @@ const (
 	TrialClosedEventType = "TrialClosed"
 	// WorkloadCompletedEventType is the event type in the database for a workload.CompletedMessage.
 	WorkloadCompletedEventType = "WorkloadCompleted"
+	// MaxConcurrentTrialsChangedEventType is the event type in the database for a
+	// searcher.MaxConcurrentTrialsChangedEvent.
+	MaxConcurrentTrialsChangedEventType = "MaxConcurrentTrialsChanged"
+	// CustomOperationsPostedEventType is the event type in the database for a
+	// searcher.CustomOperationsPostedEvent.
+	CustomOperationsPostedEventType = "CustomOperationsPosted"
+	// EarlyStoppingTrialUpdatedEventType is the event type in the database for a
+	// searcher.EarlyStoppingTrialUpdatedEvent.
+	EarlyStoppingTrialUpdatedEventType = "EarlyStoppingTrialUpdated"
 
 	// searcherEventBuffer is the maximum number of SearcherEvents that can be buffered before
 	// writing to the database.  In reality, it is much more likely flushing the buffer happens
@@ -87,12 +125,30 @@ type experiment struct {
 	searcher            *searcher.Searcher
 	warmStartCheckpoint *model.Checkpoint
 	bestValidation      *float64
+	earlyStopping       *earlyStopping
 	replaying           bool
+	// bypassMaintenance lets the trials of the experiment be allocated resources during
+	// maintenance; it is set for the experiments that are active when they are created or restored
+	// during maintenance, since only admins who override the maintenance mode can create them.
+	bypassMaintenance bool
 
 	pendingEvents []*model.SearcherEvent
-
-	agentUserGroup *model.AgentUserGroup
-	taskSpec       *tasks.TaskSpec
+	// retainSearcherEvents defers the deletion of searcher events to the searcher events cleaner.
+	retainSearcherEvents bool
+
+	// lastControllerContact is when the external controller of a custom searcher last fetched
+	// events or posted operations; pausedForController is set if the experiment was paused
+	// because the controller went silent.
+	lastControllerContact time.Time
+	pausedForController   bool
+
+	agentUserGroup  *model.AgentUserGroup
+	taskSpec        *tasks.TaskSpec
+	trialHeartbeats TrialHeartbeatsConfig
+	trialRestarts   TrialRestartsConfig
+
+	// listCache is invalidated whenever the state or progress of the experiment changes.
+	listCache *experimentListCache
 }
 
 // Create a new experiment object from the given model experiment object, along with its searcher
@@ -110,6 +166,17 @@ func newExperiment(master *Master, expModel *model.Experiment) (*experiment, err
 		return nil, err
 	}
 
+	// New experiments keep their model definitions in the model definition storage, from which
+	// those that are restored load them.
+	if expModel.ID == 0 {
+		err = master.storeModelDefinition(expModel)
+	} else {
+		err = master.loadModelDefinition(expModel)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	// Decompress the model definition from .tar.gz into an Archive.
 	modelDefinition, err := archive.FromTarGz(expModel.ModelDefinitionBytes)
 	if err != nil {
@@ -120,6 +187,14 @@ func newExperiment(master *Master, expModel *model.Experiment) (*experiment, err
 		if err = master.db.AddExperiment(expModel); err != nil {
 			return nil, err
 		}
+		if err = master.db.AddExperimentStateTransition(&model.ExperimentStateTransition{
+			ExperimentID: expModel.ID,
+			To:           expModel.State,
+			Timestamp:    expModel.StartTime,
+			Reason:       "experiment created",
+		}); err != nil {
+			return nil, err
+		}
 	}
 
 	agentUserGroup, err := master.db.AgentUserGroup(*expModel.OwnerID)
@@ -128,7 +203,7 @@ func newExperiment(master *Master, expModel *model.Experiment) (*experiment, err
 	}
 
 	if agentUserGroup == nil {
-		agentUserGroup = &master.config.Security.DefaultTask
+		agentUserGroup = &master.currentConfig().Security.DefaultTask
 	}
 
 	return &experiment{
@@ -139,10 +214,19 @@ func newExperiment(master *Master, expModel *model.Experiment) (*experiment, err
 		db:                  master.db,
 		searcher:            search,
 		warmStartCheckpoint: checkpoint,
+		earlyStopping:       newEarlyStopping(conf),
 		pendingEvents:       make([]*model.SearcherEvent, 0, searcherEventBuffer),
 
-		agentUserGroup: agentUserGroup,
-		taskSpec:       master.taskSpec,
+		bypassMaintenance: master.currentMaintenance() != nil &&
+			expModel.State == model.ActiveState,
+
+		retainSearcherEvents: master.currentConfig().SearcherEvents.Retention > 0,
+
+		agentUserGroup:  agentUserGroup,
+		taskSpec:        master.currentTaskSpec(),
+		trialHeartbeats: master.currentConfig().TrialHeartbeats,
+		trialRestarts:   master.currentConfig().TrialRestarts,
+		listCache:       master.experimentListCache,
 	}, nil
 }
 
@@ -211,6 +295,42 @@ func newSearcherEventCallback(master *Master, ref *actor.Ref) func(model.Searche
 			// workload.
 			master.system.Ask(ref, doneProcessingSearcherOperations{}).Get()
 
+		case MaxConcurrentTrialsChangedEventType:
+			maxConcurrentTrials := int(event.Content["max_concurrent_trials"].(float64))
+			log.Debugf("\x1b[32mrestore: max concurrent trials changed\x1b[m %d",
+				maxConcurrentTrials)
+			if err, ok := master.system.Ask(ref, setMaxConcurrentTrials{
+				maxConcurrentTrials: maxConcurrentTrials,
+			}).Get().(error); ok {
+				return errors.Wrap(err, "failed to restore max concurrent trials")
+			}
+
+			// Wait for the experiment to handle any searcher operations due to the change.
+			master.system.Ask(ref, doneProcessingSearcherOperations{}).Get()
+
+		case CustomOperationsPostedEventType:
+			log.Debugf("\x1b[32mrestore: custom operations posted\x1b[m %v",
+				event.Content["operations"])
+			var ops []searcher.CustomOperation
+			if err := marshalInto(event.Content["operations"], &ops); err != nil {
+				return errors.Wrap(err, "failed to process custom operations")
+			}
+			if err, ok := master.system.Ask(ref, postCustomSearcherOperations{
+				operations: ops,
+			}).Get().(error); ok {
+				return errors.Wrap(err, "failed to restore custom operations")
+			}
+
+			// Wait for the experiment to handle the operations.
+			master.system.Ask(ref, doneProcessingSearcherOperations{}).Get()
+
+		case EarlyStoppingTrialUpdatedEventType:
+			var update searcher.EarlyStoppingTrialUpdatedEvent
+			update.TrialID = int(event.Content["trial_id"].(float64))
+			update.Best = event.Content["best"].(float64)
+			update.SinceImprovement = int(event.Content["since_improvement"].(float64))
+			master.system.Ask(ref, update).Get()
+
 		case TrialClosedEventType:
 			// Ignore these events; the trial actors' closing will notify the experiment naturally.
 		}
@@ -224,8 +344,11 @@ func restoreExperiment(master *Master, expModel *model.Experiment) error {
 		if err := master.db.TerminateExperimentInRestart(expModel.ID, terminal); err != nil {
 			return errors.Wrapf(err, "terminating experiment %d", expModel.ID)
 		}
+		from := expModel.State
 		expModel.State = terminal
 		telemetry.ReportExperimentStateChanged(master.system, master.db, *expModel)
+		recordTransitionWithoutActor(master.db, expModel.ID, from, expModel.State,
+			"the master restarted while the experiment was stopping")
 		return nil
 	} else if _, ok := model.RunningStates[expModel.State]; !ok {
 		return errors.Errorf(
@@ -233,6 +356,8 @@ func restoreExperiment(master *Master, expModel *model.Experiment) error {
 		)
 	}
 
+	// The experiment registers its group with the resource manager when it starts.
+	master.restoreRMAsks.wait()
 	e, err := newExperiment(master, expModel)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create experiment %d from model", expModel.ID)
@@ -256,18 +381,17 @@ func restoreExperiment(master *Master, expModel *model.Experiment) error {
 		return errors.Wrapf(err, "failed to get searcher events")
 	}
 
-	// We have the experiment ask all the trials to restore (since we don't know all of the trial
-	// actor children) and wait here for them to finish. Since the trials might ask things of the
+	// We have the experiment list its trials (since we don't know all of the trial actor
+	// children) and ask them to restore from here. Since the trials might ask things of the
 	// experiment while restoring, we can't have the experiment itself wait for the trials.
-	trialResponses := master.system.Ask(ref, restoreTrials{}).Get()
+	trials, ok := master.system.Ask(ref, restoreTrials{}).Get().([]*actor.Ref)
 
 	// If the experiment failed during the replay we may receive a nil response.
-	if trialResponses == nil {
+	if !ok {
 		return errors.Errorf("experiment %v did not respond to 'restoreTrials' message", e.ID)
 	}
 
-	for range trialResponses.(actor.Responses) {
-	}
+	restoreTrialsRateLimited(master.system, master.restoreRMAsks, trials)
 
 	// Now notify the experiment that the trials are done and wait for a response, so that this
 	// function doesn't exit before the experiment and trials are fully caught up.
@@ -276,11 +400,22 @@ func restoreExperiment(master *Master, expModel *model.Experiment) error {
 	return nil
 }
 
+// restoreTrialsRateLimited asks each of the trials to restore, waiting for each one to finish.
+// Each restored trial asks the resource manager for resources right away, so the asks are
+// rate-limited by the bucket.
+func restoreTrialsRateLimited(system *actor.System, rmAsks *tokenBucket, trials []*actor.Ref) {
+	for _, trial := range trials {
+		rmAsks.wait()
+		system.Ask(trial, restoreTrial{}).Get()
+	}
+}
+
 func (e *experiment) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	// Searcher-related messages.
 	case actor.PreStart:
 		telemetry.ReportExperimentCreated(ctx.Self().System(), *e.Experiment)
+		e.listCache.invalidate()
 
 		ctx.Tell(e.rm, sproto.SetGroupMaxSlots{
 			MaxSlots: e.Config.Resources.MaxSlots,
@@ -293,6 +428,10 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		})
 		ops, err := e.searcher.InitialOperations()
 		e.processOperations(ctx, ops, err)
+		if e.Config.Searcher.CustomConfig != nil {
+			e.lastControllerContact = time.Now()
+			actors.NotifyAfter(ctx, e.controllerTimeout(), customSearcherControllerCheck{})
+		}
 	case trialCreated:
 		ops, err := e.searcher.TrialCreated(msg.create, msg.trialID)
 		e.processOperations(ctx, ops, err)
@@ -306,11 +445,17 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 			// Messages indicating trial failures won't have metrics (or need their status).
 			msg.completedMessage.ExitedReason == nil {
 			ctx.Respond(e.isBestValidation(*msg.completedMessage.ValidationMetrics))
+			// Replayed validations were already evaluated; their outcome is restored from the
+			// early stopping events that follow them.
+			if e.earlyStopping != nil && !e.replaying {
+				e.checkEarlyStopping(ctx, msg.trialID, *msg.completedMessage.ValidationMetrics)
+			}
 		}
 		progress := e.searcher.Progress()
 		if err := e.db.SaveExperimentProgress(e.ID, &progress); err != nil {
 			ctx.Log().WithError(err).Error("failed to save experiment progress")
 		}
+		e.listCache.invalidate()
 	case trialExitedEarly:
 		ops, err := e.searcher.TrialExitedEarly(msg.trialID, msg.exitedReason)
 		e.processOperations(ctx, ops, err)
@@ -337,6 +482,58 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		progress := e.searcher.Progress()
 		ctx.Respond(&progress)
 
+	case getSearcherState:
+		ctx.Respond(e.searcher.State())
+
+	case getMaxConcurrentTrials:
+		maxConcurrentTrials, err := e.searcher.MaxConcurrentTrials()
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		ctx.Respond(maxConcurrentTrials)
+	case setMaxConcurrentTrials:
+		if _, ok := model.StoppingStates[e.State]; ok {
+			ctx.Respond(errors.Errorf("experiment is in state %s", e.State))
+			return nil
+		}
+		ops, err := e.searcher.SetMaxConcurrentTrials(msg.maxConcurrentTrials)
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		e.processOperations(ctx, ops, nil)
+		ctx.Respond(msg.maxConcurrentTrials)
+
+	case setModelDefinition:
+		if e.State != model.PausedState {
+			ctx.Respond(errors.Errorf(
+				"the model definition can only be replaced while the experiment is paused, not %s",
+				e.State))
+			return nil
+		}
+		numTrials, err := e.db.ExperimentNumTrials(e.ID)
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		if numTrials > 0 {
+			ctx.Respond(errors.New(
+				"the model definition cannot be replaced after the experiment has run trials"))
+			return nil
+		}
+		if err := e.db.UpdateExperimentModelDefinition(e.ID, msg.modelDefinitionHash); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		e.ModelDefinitionBytes = msg.modelDefinitionBytes
+		e.ModelDefinitionHash = &msg.modelDefinitionHash
+		e.modelDefinition = msg.modelDefinition
+		for _, child := range ctx.Children() {
+			ctx.Tell(child, msg)
+		}
+		ctx.Respond(len(msg.modelDefinition))
+
 	case getTrial:
 		requestID, ok := e.searcher.RequestID(msg.trialID)
 		ref := ctx.Child(requestID)
@@ -344,18 +541,62 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 			ctx.Respond(ref)
 		}
 
+	// Custom searcher messages.
+	case getCustomSearcherEvents:
+		events, err := e.searcher.CustomEvents(msg.acknowledged)
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		e.controllerContacted(ctx)
+		ctx.Respond(events)
+	case postCustomSearcherOperations:
+		if _, ok := model.StoppingStates[e.State]; ok {
+			ctx.Respond(errors.Errorf("experiment is in state %s", e.State))
+			return nil
+		}
+		ops, err := e.searcher.CustomOperations(msg.operations)
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		e.controllerContacted(ctx)
+		e.processOperations(ctx, ops, nil)
+		progress := e.searcher.Progress()
+		if err := e.db.SaveExperimentProgress(e.ID, &progress); err != nil {
+			ctx.Log().WithError(err).Error("failed to save experiment progress")
+		}
+		e.listCache.invalidate()
+		ctx.Respond(ops)
+	case customSearcherControllerCheck:
+		timeout := e.controllerTimeout()
+		if silence := time.Since(e.lastControllerContact); silence < timeout {
+			actors.NotifyAfter(ctx, timeout-silence, msg)
+			return nil
+		}
+		if e.State == model.ActiveState {
+			ctx.Log().Warnf("custom searcher controller has been silent for %s, pausing", timeout)
+			e.pausedForController = e.updateState(ctx, model.PausedState, fmt.Sprintf(
+				"custom searcher controller was silent for %s", timeout))
+		}
+		actors.NotifyAfter(ctx, timeout, msg)
+
 	// Restoration-related messages.
 	case doneProcessingSearcherOperations:
 		// This is just a synchronization tool for master restarts; the actor system's default
 		// response is fine.
 	case restoreTrials:
-		ctx.Respond(ctx.AskAll(restoreTrial{}, ctx.Children()...))
+		ctx.Respond(ctx.Children())
 	case trialsRestored:
 		e.replaying = false
+	case searcher.EarlyStoppingTrialUpdatedEvent:
+		if e.earlyStopping != nil {
+			e.earlyStopping.restoreTrial(msg.TrialID, msg.Best, msg.SinceImprovement)
+		}
 
 	// Patch experiment messages.
 	case model.State:
-		e.updateState(ctx, msg)
+		e.updateState(ctx, msg, "state changed through the API")
 	case sproto.SetGroupMaxSlots:
 		e.Config.Resources.MaxSlots = msg.MaxSlots
 		msg.Handler = ctx.Self()
@@ -367,7 +608,7 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 
 	case killExperiment:
 		if _, running := model.RunningStates[e.State]; running {
-			e.updateState(ctx, model.StoppingCanceledState)
+			e.updateState(ctx, model.StoppingCanceledState, "experiment killed")
 		}
 
 		for _, child := range ctx.Children() {
@@ -379,13 +620,16 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		if err := e.db.SaveExperimentProgress(e.ID, nil); err != nil {
 			ctx.Log().Error(err)
 		}
+		e.listCache.invalidate()
 
 		// Flush any remaining searcher logs
 		if err := e.db.AddSearcherEvents(e.pendingEvents); err != nil {
 			ctx.Log().Error(err)
-			e.updateState(ctx, model.StoppingErrorState)
+			e.updateState(ctx, model.StoppingErrorState,
+				fmt.Sprintf("failed to save searcher events: %s", err))
 		}
 
+		from := e.State
 		state := model.StoppingToTerminalStates[e.State]
 		if wasPatched, err := e.Transition(state); err != nil {
 			return err
@@ -393,11 +637,16 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 			return errors.New("experiment is already in a terminal state")
 		}
 		telemetry.ReportExperimentStateChanged(ctx.Self().System(), e.db, *e.Experiment)
+		e.recordStateTransition(ctx, from, "all trials of the experiment exited")
 
 		if err := e.db.SaveExperimentState(e.Experiment); err != nil {
 			return err
 		}
+		e.listCache.invalidate()
 		ctx.Log().Infof("experiment state changed to %s", e.State)
+		e.publishState(ctx)
+		e.publishTerminalState(ctx)
+		e.emailTerminalState(ctx)
 		addr := actor.Addr(fmt.Sprintf("experiment-%d-checkpoint-gc", e.ID))
 		ctx.Self().System().ActorOf(addr, &checkpointGCTask{
 			agentUserGroup: e.agentUserGroup,
@@ -409,16 +658,19 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 
 		// Discard searcher events for all terminal experiments (even failed ones).
 		// This is safe because we never try to restore the state of the searcher for
-		// terminated experiments.
-		if err := e.db.DeleteSearcherEvents(e.Experiment.ID); err != nil {
-			ctx.Log().WithError(err).Errorf(
-				"failure to delete searcher events for experiment: %d", e.Experiment.ID)
+		// terminated experiments. If they are retained for a while, the searcher events
+		// cleaner deletes them instead.
+		if !e.retainSearcherEvents {
+			if err := e.db.DeleteSearcherEvents(e.Experiment.ID); err != nil {
+				ctx.Log().WithError(err).Errorf(
+					"failure to delete searcher events for experiment: %d", e.Experiment.ID)
+			}
 		}
 
 		ctx.Log().Info("experiment shut down successfully")
 
 	case *apiv1.ActivateExperimentRequest:
-		switch ok := e.updateState(ctx, model.ActiveState); ok {
+		switch ok := e.updateState(ctx, model.ActiveState, "activated through the API"); ok {
 		case true:
 			ctx.Respond(&apiv1.ActivateExperimentResponse{})
 		default:
@@ -427,7 +679,7 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		}
 
 	case *apiv1.PauseExperimentRequest:
-		switch ok := e.updateState(ctx, model.PausedState); ok {
+		switch ok := e.updateState(ctx, model.PausedState, "paused through the API"); ok {
 		case true:
 			ctx.Respond(&apiv1.PauseExperimentResponse{})
 		default:
@@ -440,7 +692,9 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		case model.StoppingStates[e.State] || model.TerminalStates[e.State]:
 			ctx.Respond(&apiv1.CancelExperimentResponse{})
 		default:
-			switch ok := e.updateState(ctx, model.StoppingCanceledState); ok {
+			switch ok := e.updateState(
+				ctx, model.StoppingCanceledState, "canceled through the API",
+			); ok {
 			case true:
 				ctx.Respond(&apiv1.CancelExperimentResponse{})
 				for _, child := range ctx.Children() {
@@ -457,7 +711,9 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		case model.StoppingStates[e.State] || model.TerminalStates[e.State]:
 			ctx.Respond(&apiv1.KillExperimentResponse{})
 		default:
-			switch ok := e.updateState(ctx, model.StoppingCanceledState); ok {
+			switch ok := e.updateState(
+				ctx, model.StoppingCanceledState, "killed through the API",
+			); ok {
 			case true:
 				ctx.Respond(&apiv1.KillExperimentResponse{})
 				for _, child := range ctx.Children() {
@@ -480,7 +736,7 @@ func (e *experiment) processOperations(
 	}
 	if err != nil {
 		ctx.Log().Error(err)
-		e.updateState(ctx, model.StoppingErrorState)
+		e.updateState(ctx, model.StoppingErrorState, fmt.Sprintf("searcher failed: %s", err))
 		return
 	}
 
@@ -494,15 +750,17 @@ func (e *experiment) processOperations(
 			if op.Checkpoint != nil {
 				trialID, ok := e.searcher.TrialID(op.Checkpoint.RequestID)
 				if !ok {
-					ctx.Log().Error(errors.Errorf(
-						"invalid request ID in Create operation: %d", op.Checkpoint.RequestID))
-					e.updateState(ctx, model.StoppingErrorState)
+					err := errors.Errorf(
+						"invalid request ID in Create operation: %d", op.Checkpoint.RequestID)
+					ctx.Log().Error(err)
+					e.updateState(ctx, model.StoppingErrorState, err.Error())
 					return
 				}
 				checkpointModel, err := checkpointFromTrialIDOrUUID(e.db, &trialID, nil)
 				if err != nil {
-					ctx.Log().Error(errors.Wrap(err, "checkpoint not found"))
-					e.updateState(ctx, model.StoppingErrorState)
+					err = errors.Wrap(err, "checkpoint not found")
+					ctx.Log().Error(err)
+					e.updateState(ctx, model.StoppingErrorState, err.Error())
 					return
 				}
 				checkpoint = checkpointModel
@@ -512,9 +770,9 @@ func (e *experiment) processOperations(
 			trialOperations[op.GetRequestID()] = append(trialOperations[op.GetRequestID()], op)
 		case searcher.Shutdown:
 			if op.Failure {
-				e.updateState(ctx, model.StoppingErrorState)
+				e.updateState(ctx, model.StoppingErrorState, "searcher shut down with a failure")
 			} else {
-				e.updateState(ctx, model.StoppingCompletedState)
+				e.updateState(ctx, model.StoppingCompletedState, "searcher completed")
 			}
 		default:
 			panic(fmt.Sprintf("unexpected operation: %v", op))
@@ -532,7 +790,8 @@ func (e *experiment) processOperations(
 			modelEvent, flush, err := convertSearcherEvent(e.ID, event)
 			if err != nil {
 				ctx.Log().Error(err)
-				e.updateState(ctx, model.StoppingErrorState)
+				e.updateState(ctx, model.StoppingErrorState,
+					fmt.Sprintf("failed to convert searcher event: %s", err))
 				return
 			}
 			flushEvents = flushEvents || flush
@@ -549,7 +808,8 @@ func (e *experiment) processOperations(
 		if flushEvents || len(e.pendingEvents) > searcherEventBuffer {
 			if err := e.db.AddSearcherEvents(e.pendingEvents); err != nil {
 				ctx.Log().Error(err)
-				e.updateState(ctx, model.StoppingErrorState)
+				e.updateState(ctx, model.StoppingErrorState,
+					fmt.Sprintf("failed to save searcher events: %s", err))
 				return
 			}
 			e.pendingEvents = e.pendingEvents[:0]
@@ -557,6 +817,20 @@ func (e *experiment) processOperations(
 	}
 }
 
+func (e *experiment) controllerTimeout() time.Duration {
+	return time.Duration(e.Config.Searcher.CustomConfig.ControllerTimeout) * time.Second
+}
+
+// controllerContacted records that the external controller of a custom searcher is alive and
+// resumes the experiment if it was paused only because the controller had gone silent.
+func (e *experiment) controllerContacted(ctx *actor.Context) {
+	e.lastControllerContact = time.Now()
+	if e.pausedForController && e.State == model.PausedState {
+		e.updateState(ctx, model.ActiveState, "custom searcher controller made contact again")
+	}
+	e.pausedForController = false
+}
+
 func (e *experiment) isBestValidation(metrics workload.ValidationMetrics) bool {
 	metricName := e.Config.Searcher.Metric
 	validation, err := metrics.Metric(metricName)
@@ -574,7 +848,44 @@ func (e *experiment) isBestValidation(metrics workload.ValidationMetrics) bool {
 	return isBest
 }
 
-func (e *experiment) updateState(ctx *actor.Context, state model.State) bool {
+// checkEarlyStopping evaluates the early stopping policies against a validation of the given
+// trial, stopping the trial or the whole experiment if they say so.
+func (e *experiment) checkEarlyStopping(
+	ctx *actor.Context, trialID int, metrics workload.ValidationMetrics,
+) {
+	if _, ok := model.StoppingStates[e.State]; ok {
+		return
+	}
+	decision, err := e.earlyStopping.validationCompleted(trialID, metrics)
+	if err != nil {
+		// The metric is only known to be reported once the model validates, so validations
+		// without it are skipped rather than failing the experiment.
+		ctx.Log().WithError(err).Warnf(
+			"skipping early stopping for a validation of trial %d", trialID)
+		return
+	}
+	if best, sinceImprovement, ok := e.earlyStopping.trialState(trialID); ok {
+		e.searcher.EarlyStoppingTrialUpdated(trialID, best, sinceImprovement)
+		e.processOperations(ctx, nil, nil) // We call processOperations to flush searcher events.
+	}
+	switch {
+	case decision.stopExperiment:
+		ctx.Log().Infof("stopping experiment early: %s", decision.reason)
+		for _, child := range ctx.Children() {
+			ctx.Tell(child, earlyStopTrial{reason: decision.reason})
+		}
+		e.updateState(ctx, model.StoppingCompletedState,
+			fmt.Sprintf("stopped early: %s", decision.reason))
+	case decision.stopTrial:
+		requestID, ok := e.searcher.RequestID(trialID)
+		if child := ctx.Child(requestID); ok && child != nil {
+			ctx.Tell(child, earlyStopTrial{reason: decision.reason})
+		}
+	}
+}
+
+func (e *experiment) updateState(ctx *actor.Context, state model.State, reason string) bool {
+	from := e.State
 	if wasPatched, err := e.Transition(state); err != nil {
 		ctx.Log().Errorf("error transitioning experiment state: %s", err)
 		return false
@@ -582,6 +893,7 @@ func (e *experiment) updateState(ctx *actor.Context, state model.State) bool {
 		return true
 	}
 	telemetry.ReportExperimentStateChanged(ctx.Self().System(), e.db, *e.Experiment)
+	e.recordStateTransition(ctx, from, reason)
 
 	ctx.Log().Infof("experiment state changed to %s", state)
 	for _, child := range ctx.Children() {
@@ -590,6 +902,8 @@ func (e *experiment) updateState(ctx *actor.Context, state model.State) bool {
 	if err := e.db.SaveExperimentState(e.Experiment); err != nil {
 		ctx.Log().Errorf("error saving experiment state: %s", err)
 	}
+	e.listCache.invalidate()
+	e.publishState(ctx)
 	if e.canTerminate(ctx) {
 		ctx.Self().Stop()
 	}
@@ -597,6 +911,88 @@ func (e *experiment) updateState(ctx *actor.Context, state model.State) bool {
 	return true
 }
 
+// recordStateTransition records the change of the state of the experiment from the given state to
+// its current state in its state history.
+func (e *experiment) recordStateTransition(ctx *actor.Context, from model.State, reason string) {
+	if err := e.db.AddExperimentStateTransition(&model.ExperimentStateTransition{
+		ExperimentID: e.ID,
+		From:         &from,
+		To:           e.State,
+		Timestamp:    time.Now().UTC(),
+		Reason:       reason,
+	}); err != nil {
+		ctx.Log().WithError(err).Error("error recording experiment state transition")
+	}
+}
+
+// recordTransitionWithoutActor records a change of the state of an experiment that the master
+// makes while the experiment has no actor, e.g., while restoring, starting or deleting it.
+func recordTransitionWithoutActor(
+	d *db.PgDB, experimentID int, from, to model.State, reason string,
+) {
+	if err := d.AddExperimentStateTransition(&model.ExperimentStateTransition{
+		ExperimentID: experimentID,
+		From:         &from,
+		To:           to,
+		Timestamp:    time.Now().UTC(),
+		Reason:       reason,
+	}); err != nil {
+		log.WithError(err).Errorf(
+			"error recording state transition of experiment %d", experimentID)
+	}
+}
+
+// publishState records the current state of the experiment in the cluster event stream.
+func (e *experiment) publishState(ctx *actor.Context) {
+	events.Publish(ctx.Self().System(), events.ExperimentStateChanged, map[string]interface{}{
+		"experiment_id": e.ID,
+		"state":         e.State,
+	})
+}
+
+// publishTerminalState notifies webhooks that the experiment completed or errored.
+func (e *experiment) publishTerminalState(ctx *actor.Context) {
+	var eventType model.WebhookEventType
+	switch e.State {
+	case model.CompletedState:
+		eventType = model.ExperimentCompletedEvent
+	case model.ErrorState:
+		eventType = model.ExperimentErroredEvent
+	default:
+		return
+	}
+	path := fmt.Sprintf("/det/experiments/%d", e.ID)
+	webhooks.Publish(ctx.Self().System(), eventType, path, map[string]interface{}{
+		"experiment_id": e.ID,
+		"description":   e.Config.Description,
+		"labels":        e.Config.Labels,
+		"state":         e.State,
+		"start_time":    e.StartTime,
+		"end_time":      e.EndTime,
+	})
+}
+
 func (e *experiment) canTerminate(ctx *actor.Context) bool {
 	return model.StoppingStates[e.State] && len(ctx.Children()) == 0
 }
+
+// emailTerminalState emails a summary of the experiment to the recipients of its notifications.
+func (e *experiment) emailTerminalState(ctx *actor.Context) {
+	msg := email.ExperimentEnded{
+		ID:             e.ID,
+		Description:    e.Config.Description,
+		State:          e.State,
+		Metric:         e.Config.Searcher.Metric,
+		BestValidation: e.bestValidation,
+		StartTime:      e.StartTime,
+		EndTime:        time.Now().UTC(),
+		OwnerID:        e.OwnerID,
+	}
+	if e.EndTime != nil {
+		msg.EndTime = *e.EndTime
+	}
+	if e.Config.Notifications != nil {
+		msg.Recipients = e.Config.Notifications.Email
+	}
+	email.NotifyExperimentEnded(ctx.Self().System(), msg)
+}
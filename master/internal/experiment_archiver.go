@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+)
+
+// experimentArchiverInterval is the time between passes of the experiment archiver.
+const experimentArchiverInterval = time.Hour
+
+type experimentArchiverTick struct{}
+
+// experimentArchiver periodically archives experiments that have been in a terminal state for
+// longer than the configured number of days, so that they drop out of the default experiment list.
+// Experiments that a user unarchives afterwards are not archived again.
+type experimentArchiver struct {
+	// archiveExperiments archives the experiments that have been in a terminal state for longer
+	// than the given age and returns how many it archived.
+	archiveExperiments func(age time.Duration) (int64, error)
+	days               int
+	listCache          *experimentListCache
+}
+
+// Receive implements the actor.Actor interface.
+func (a *experimentArchiver) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		if a.days > 0 {
+			actors.NotifyAfter(ctx, 0, experimentArchiverTick{})
+		}
+
+	case experimentArchiverTick:
+		a.archive(ctx)
+		actors.NotifyAfter(ctx, experimentArchiverInterval, experimentArchiverTick{})
+
+	case actor.PostStop:
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (a *experimentArchiver) archive(ctx *actor.Context) {
+	archived, err := a.archiveExperiments(time.Duration(a.days) * 24 * time.Hour)
+	if err != nil {
+		// Log the error but carry on so that the next pass is still scheduled.
+		ctx.Log().WithError(err).Error("cannot archive terminal state experiments")
+		return
+	}
+	ctx.Log().Infof("archived %d experiments that ended more than %d days ago", archived, a.days)
+	if archived > 0 {
+		a.listCache.invalidate()
+	}
+}
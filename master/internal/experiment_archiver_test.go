@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// runExperimentArchiver starts an experiment archiver that archives the given number of
+// experiments, or fails with the given error, on each pass. It returns the ages that the archiver
+// asked to archive experiments after, and the list cache that the archiver invalidates.
+func runExperimentArchiver(
+	days int, archived int64, err error,
+) (*actor.Ref, chan time.Duration, *experimentListCache) {
+	ages := make(chan time.Duration, 10)
+	listCache := newExperimentListCache()
+	system := actor.NewSystem("")
+	ref, _ := system.ActorOf(actor.Addr("experimentArchiver"), &experimentArchiver{
+		archiveExperiments: func(age time.Duration) (int64, error) {
+			ages <- age
+			return archived, err
+		},
+		days:      days,
+		listCache: listCache,
+	})
+	return ref, ages, listCache
+}
+
+func receiveAge(t *testing.T, ages chan time.Duration) time.Duration {
+	select {
+	case age := <-ages:
+		return age
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the experiment archiver to run")
+		return 0
+	}
+}
+
+func cacheGeneration(c *experimentListCache) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func TestExperimentArchiverArchivesOnStart(t *testing.T) {
+	ref, ages, listCache := runExperimentArchiver(3, 2, nil)
+
+	assert.Equal(t, receiveAge(t, ages), 3*24*time.Hour)
+	assert.NilError(t, ref.StopAndAwaitTermination())
+	// Archived experiments drop out of the cached experiment lists.
+	assert.Equal(t, cacheGeneration(listCache), uint64(1))
+}
+
+func TestExperimentArchiverKeepsCacheWithoutArchivedExperiments(t *testing.T) {
+	for name, err := range map[string]error{
+		"nothing archived": nil,
+		"failed":           errors.New("database is down"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ref, ages, listCache := runExperimentArchiver(1, 0, err)
+
+			assert.Equal(t, receiveAge(t, ages), 24*time.Hour)
+			// A failed pass does not stop the archiver.
+			assert.NilError(t, ref.StopAndAwaitTermination())
+			assert.Equal(t, cacheGeneration(listCache), uint64(0))
+		})
+	}
+}
+
+func TestExperimentArchiverDisabled(t *testing.T) {
+	ref, ages, listCache := runExperimentArchiver(0, 2, nil)
+
+	select {
+	case age := <-ages:
+		t.Fatalf("disabled experiment archiver archived experiments older than %s", age)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.NilError(t, ref.StopAndAwaitTermination())
+	assert.Equal(t, cacheGeneration(listCache), uint64(0))
+}
@@ -0,0 +1,273 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+)
+
+var experimentDeleterAddr = actor.Addr("experimentDeleter")
+
+// modelDefinitionGCDelay is how long after the last experiment that refers to a model definition
+// is deleted the model definition is deleted, if no experiment refers to it again by then. The
+// delay leaves time for experiments that are being created with an identical model definition,
+// which is stored already, to be added to the database.
+const modelDefinitionGCDelay = 10 * time.Minute
+
+// errDeletionInProgress is the response to deleteExperiment for experiments whose checkpoints are
+// being deleted already.
+var errDeletionInProgress = errors.New("the deletion of the experiment is in progress")
+
+// checkpointDeletionFailurePattern matches the lines that the checkpoint GC task logs for the
+// checkpoints that it failed to delete; see harness/determined/exec/gc_checkpoints.py.
+var checkpointDeletionFailurePattern = regexp.MustCompile(
+	`Failed to delete checkpoint ([0-9a-fA-F-]+): (.*)`)
+
+type (
+	// deleteExperiment asks the experiment deleter to delete an experiment in the deleting state,
+	// along with its checkpoints. The response is nil once the deletion has started.
+	deleteExperiment struct {
+		experimentID int
+	}
+
+	// checkpointsDeleted is sent by a checkpoint GC task that deletes the checkpoints of an
+	// experiment being deleted once it finishes. err is set if the task failed, and failures maps
+	// the UUIDs of the checkpoints that the task could not delete to the reasons why.
+	checkpointsDeleted struct {
+		err      error
+		failures map[string]string
+	}
+
+	// collectModelDefinition tells the experiment deleter to delete a model definition from the
+	// model definition storage unless an experiment refers to it.
+	collectModelDefinition struct {
+		hash string
+	}
+)
+
+// experimentDeletion is the deletion of an experiment whose checkpoints are being deleted.
+type experimentDeletion struct {
+	experimentID int
+	checkpoints  []string
+}
+
+// experimentDeleter deletes experiments in the background: first their checkpoints are deleted
+// from storage by a checkpoint GC task, then the experiments are removed from the database. If
+// some checkpoints can't be deleted, the experiment stays in the deleting state with the failures
+// recorded until the deletion is retried.
+type experimentDeleter struct {
+	master *Master
+	// deletions maps the checkpoint GC tasks to the deletions that they are part of.
+	deletions map[*actor.Ref]experimentDeletion
+}
+
+func newExperimentDeleter(master *Master) *experimentDeleter {
+	return &experimentDeleter{master: master, deletions: map[*actor.Ref]experimentDeletion{}}
+}
+
+func (d *experimentDeleter) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+
+	case deleteExperiment:
+		err := d.start(ctx, msg.experimentID)
+		if err != nil && err != errDeletionInProgress {
+			ctx.Log().WithError(err).Errorf("failed to delete experiment %d", msg.experimentID)
+		}
+		if ctx.ExpectingResponse() {
+			ctx.Respond(err)
+		}
+
+	case checkpointsDeleted:
+		if deletion, ok := d.deletions[ctx.Sender()]; ok {
+			delete(d.deletions, ctx.Sender())
+			d.finish(ctx, deletion, msg.failures, msg.err)
+		}
+
+	case collectModelDefinition:
+		d.collectModelDefinition(ctx, msg.hash)
+
+	case actor.ChildFailed:
+		if deletion, ok := d.deletions[msg.Child]; ok {
+			delete(d.deletions, msg.Child)
+			d.finish(ctx, deletion, nil, msg.Error)
+		}
+
+	case actor.ChildStopped:
+		if deletion, ok := d.deletions[msg.Child]; ok {
+			delete(d.deletions, msg.Child)
+			d.finish(ctx, deletion, nil, errors.New("checkpoint GC task stopped unexpectedly"))
+		}
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+// start deletes the checkpoints of an experiment that are not deleted yet, including those that
+// failed to be deleted before, or deletes the experiment right away if there are none.
+func (d *experimentDeleter) start(ctx *actor.Context, experimentID int) error {
+	for _, deletion := range d.deletions {
+		if deletion.experimentID == experimentID {
+			return errDeletionInProgress
+		}
+	}
+
+	exp, err := d.master.db.ExperimentByID(experimentID)
+	if err != nil {
+		return errors.Wrapf(err, "loading experiment %d to delete", experimentID)
+	}
+	// The checkpoints of an imported experiment belong to the cluster that it was exported from,
+	// so only the experiment itself is deleted.
+	if exp.Imported() {
+		d.finish(ctx, experimentDeletion{experimentID: experimentID}, nil, nil)
+		return nil
+	}
+	zero := 0
+	toDelete, err := d.master.db.ExperimentCheckpointsToGCRaw(
+		experimentID, &zero, &zero, &zero, false)
+	if err != nil {
+		return errors.Wrapf(err, "loading checkpoints of experiment %d to delete", experimentID)
+	}
+	var selected struct {
+		Checkpoints []struct {
+			UUID string `json:"uuid"`
+		} `json:"checkpoints"`
+	}
+	if err = json.Unmarshal(toDelete, &selected); err != nil {
+		return errors.Wrapf(err, "parsing checkpoints of experiment %d to delete", experimentID)
+	}
+
+	deletion := experimentDeletion{experimentID: experimentID}
+	for _, checkpoint := range selected.Checkpoints {
+		deletion.checkpoints = append(deletion.checkpoints, checkpoint.UUID)
+	}
+	if len(deletion.checkpoints) == 0 {
+		d.finish(ctx, deletion, nil, nil)
+		return nil
+	}
+
+	agentUserGroup, err := d.master.db.AgentUserGroup(*exp.OwnerID)
+	if err != nil {
+		return errors.Errorf("cannot find user and group for experiment %d", experimentID)
+	}
+	if agentUserGroup == nil {
+		agentUserGroup = &d.master.currentConfig().Security.DefaultTask
+	}
+
+	ctx.Log().Infof("deleting %d checkpoints of experiment %d",
+		len(deletion.checkpoints), experimentID)
+	addr := fmt.Sprintf("delete-checkpoint-gc-%s", uuid.New().String())
+	task, _ := ctx.ActorOf(addr, &checkpointGCTask{
+		agentUserGroup: agentUserGroup,
+		taskSpec:       d.master.currentTaskSpec(),
+		rm:             d.master.rm,
+		db:             d.master.db,
+		experiment:     exp,
+		toDelete:       toDelete,
+	})
+	d.deletions[task] = deletion
+	return nil
+}
+
+// finish removes an experiment from the database if all of its checkpoints were deleted, and
+// otherwise records which checkpoints could not be deleted so that the deletion can be retried.
+func (d *experimentDeleter) finish(
+	ctx *actor.Context, deletion experimentDeletion, failures map[string]string, err error,
+) {
+	id := deletion.experimentID
+	deleted, failed := checkpointDeletionOutcome(deletion.checkpoints, failures, err)
+	if len(failed) == 0 {
+		ctx.Log().Infof("deleting experiment %d from database", id)
+		hash, hErr := d.master.db.ExperimentModelDefinitionHash(id)
+		if hErr != nil {
+			ctx.Log().WithError(hErr).Warnf(
+				"failed to find the model definition of experiment %d to delete", id)
+		}
+		if dErr := d.master.db.DeleteExperiment(id); dErr != nil {
+			ctx.Log().WithError(dErr).Errorf("failed to delete experiment %d from database", id)
+		} else if hash != nil {
+			actors.NotifyAfter(ctx, modelDefinitionGCDelay, collectModelDefinition{hash: *hash})
+		}
+		d.master.experimentListCache.invalidate()
+		return
+	}
+
+	ctx.Log().Warnf("failed to delete %d of %d checkpoints of experiment %d",
+		len(failed), len(deletion.checkpoints), id)
+	if rErr := d.master.db.RecordCheckpointDeletion(id, deleted, failed); rErr != nil {
+		ctx.Log().WithError(rErr).Errorf(
+			"failed to record checkpoint deletion failures of experiment %d", id)
+	}
+}
+
+// collectModelDefinition deletes a model definition from the model definition storage if no
+// experiment refers to it. Model definitions are shared by the experiments with identical ones, so
+// they are only deleted along with the last of those experiments. The deletion happens outside
+// the actor, since the storage may be remote.
+func (d *experimentDeleter) collectModelDefinition(ctx *actor.Context, hash string) {
+	referenced, err := d.master.db.ModelDefinitionReferenced(hash)
+	if err != nil {
+		ctx.Log().WithError(err).Errorf("failed to collect model definition %s", hash)
+		return
+	} else if referenced {
+		return
+	}
+	logger := ctx.Log()
+	go func() {
+		if err := d.master.modelDefinitions.Delete(context.Background(), hash); err != nil {
+			logger.WithError(err).Errorf("failed to collect model definition %s", hash)
+			return
+		}
+		logger.Infof("deleted model definition %s, which no experiment refers to", hash)
+	}()
+}
+
+// checkpointDeletionOutcome splits the checkpoints that a checkpoint GC task was asked to delete
+// into those it deleted and those it failed to, with the reasons why. If the task failed without
+// reporting which checkpoints it could not delete, none of them are assumed to be deleted.
+func checkpointDeletionOutcome(
+	checkpoints []string, failures map[string]string, err error,
+) (deleted []string, failed map[string]string) {
+	failed = map[string]string{}
+	if err != nil && len(failures) == 0 {
+		for _, checkpoint := range checkpoints {
+			failed[checkpoint] = err.Error()
+		}
+		return nil, failed
+	}
+	for _, checkpoint := range checkpoints {
+		if failure, ok := failures[checkpoint]; ok {
+			failed[checkpoint] = failure
+		} else {
+			deleted = append(deleted, checkpoint)
+		}
+	}
+	return deleted, failed
+}
+
+// parseCheckpointDeletionFailures returns the reasons why checkpoints could not be deleted, keyed
+// by checkpoint UUID, from the logs of a checkpoint GC task.
+func parseCheckpointDeletionFailures(logs []sproto.ContainerLog) map[string]string {
+	failures := map[string]string{}
+	for _, log := range logs {
+		if log.RunMessage == nil {
+			continue
+		}
+		for _, match := range checkpointDeletionFailurePattern.FindAllStringSubmatch(
+			log.RunMessage.Value, -1) {
+			failures[match[1]] = match[2]
+		}
+	}
+	return failures
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/agent"
+)
+
+func TestParseCheckpointDeletionFailures(t *testing.T) {
+	const first = "7e0bad50-2b4e-4ac5-9b59-8f2a0ab37e0f"
+	const second = "ad6d2aa6-2b27-4d25-a0e0-3a3f3d6c3a4b"
+	logs := []sproto.ContainerLog{
+		{RunMessage: &agent.RunMessage{Value: "2020-10-28 12:00:00,000:gc_checkpoints:INFO: " +
+			"Deleting checkpoint " + first + "\n"}},
+		{RunMessage: &agent.RunMessage{Value: "2020-10-28 12:00:00,000:gc_checkpoints:ERROR: " +
+			"Failed to delete checkpoint " + first + ": Access Denied\n"}},
+		{RunMessage: &agent.RunMessage{Value: "2020-10-28 12:00:01,000:gc_checkpoints:ERROR: " +
+			"Failed to delete checkpoint " + second + ": Timeout: read timed out\n"}},
+		{},
+	}
+	assert.DeepEqual(t, parseCheckpointDeletionFailures(logs), map[string]string{
+		first:  "Access Denied",
+		second: "Timeout: read timed out",
+	})
+}
+
+func TestCheckpointDeletionOutcome(t *testing.T) {
+	checkpoints := []string{"a", "b", "c"}
+
+	deleted, failed := checkpointDeletionOutcome(checkpoints, map[string]string{}, nil)
+	assert.DeepEqual(t, deleted, checkpoints)
+	assert.DeepEqual(t, failed, map[string]string{})
+
+	deleted, failed = checkpointDeletionOutcome(
+		checkpoints, map[string]string{"b": "Access Denied"}, errors.New("exit code 1"))
+	assert.DeepEqual(t, deleted, []string{"a", "c"})
+	assert.DeepEqual(t, failed, map[string]string{"b": "Access Denied"})
+
+	// Without per-checkpoint failures, a failed task may not have deleted anything.
+	deleted, failed = checkpointDeletionOutcome(checkpoints, nil, errors.New("no agents"))
+	assert.Equal(t, len(deleted), 0)
+	assert.DeepEqual(t, failed, map[string]string{
+		"a": "no agents", "b": "no agents", "c": "no agents",
+	})
+}
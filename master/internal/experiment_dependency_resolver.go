@@ -0,0 +1,223 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// dependencyResolverInterval is the time between checks of the experiments that queued
+// experiments depend on.
+const dependencyResolverInterval = 10 * time.Second
+
+type dependencyResolverTick struct{}
+
+// dependencyResolver starts the experiments in the QUEUED_DEPENDENCY state once the experiments
+// they depend on meet their condition, and moves them to the CANCELED_DEPENDENCY state once it
+// can no longer be met. Experiments are not started while the master is in maintenance mode.
+type dependencyResolver struct {
+	m *Master
+}
+
+// Receive implements the actor.Actor interface.
+func (r *dependencyResolver) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		actors.NotifyAfter(ctx, 0, dependencyResolverTick{})
+
+	case dependencyResolverTick:
+		r.resolve(ctx)
+		actors.NotifyAfter(ctx, dependencyResolverInterval, dependencyResolverTick{})
+
+	case actor.PostStop:
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (r *dependencyResolver) resolve(ctx *actor.Context) {
+	queued, err := r.m.db.QueuedExperimentDependencies()
+	if err != nil {
+		// Log the error but carry on so that the next check is still scheduled.
+		ctx.Log().WithError(err).Error("cannot find queued experiments")
+		return
+	}
+	for _, deps := range queued {
+		if err := r.resolveExperiment(ctx, deps); err != nil {
+			ctx.Log().WithError(err).Errorf(
+				"cannot check dependencies of experiment %d", deps.ExperimentID)
+		}
+	}
+}
+
+func (r *dependencyResolver) resolveExperiment(
+	ctx *actor.Context, deps model.ExperimentDependencies,
+) error {
+	states, err := r.m.db.ExperimentStates(deps.DependsOn)
+	if err != nil {
+		return err
+	}
+	outcome, reason := checkDependencyStates(deps.DependsOn, states)
+	switch outcome {
+	case dependenciesPending:
+		return nil
+	case dependenciesFailed:
+		return r.cancel(ctx, deps.ExperimentID, reason)
+	}
+
+	// Every dependency completed; check the rest of the condition.
+	var warmStartUUID *string
+	for _, id := range deps.DependsOn {
+		var config *model.ExperimentConfig
+		if config, err = r.m.db.ExperimentConfig(id); err != nil {
+			return err
+		}
+		metric, smallerIsBetter := config.Searcher.Metric, config.Searcher.SmallerIsBetter
+		if deps.Condition.Metric != "" {
+			metric = deps.Condition.Metric
+		}
+		if deps.Condition.SmallerIsBetter != nil {
+			smallerIsBetter = *deps.Condition.SmallerIsBetter
+		}
+
+		if deps.Condition.Type == model.DependenciesMetricThreshold {
+			one := 1
+			var best []db.LeaderboardEntry
+			if best, err = r.m.db.ExperimentLeaderboard(id, metric, smallerIsBetter, &one); err != nil {
+				return err
+			}
+			var bestValue *float64
+			if len(best) > 0 {
+				bestValue = &best[0].BestValue
+			}
+			if reason = checkMetricThreshold(
+				id, metric, smallerIsBetter, *deps.Condition.Threshold, bestValue,
+			); reason != "" {
+				return r.cancel(ctx, deps.ExperimentID, reason)
+			}
+		}
+
+		if deps.WarmStart {
+			if warmStartUUID, err = r.m.db.BestExperimentCheckpointUUID(
+				id, metric, smallerIsBetter); err != nil {
+				return err
+			} else if warmStartUUID == nil {
+				return r.cancel(ctx, deps.ExperimentID, fmt.Sprintf(
+					"experiment %d has no checkpoint validated with %s to warm start from",
+					id, metric))
+			}
+		}
+	}
+
+	// Experiments wait in the queue until the maintenance ends.
+	if r.m.currentMaintenance() != nil {
+		return nil
+	}
+	return r.start(ctx, deps.ExperimentID, warmStartUUID)
+}
+
+func (r *dependencyResolver) cancel(ctx *actor.Context, id int, reason string) error {
+	if canceled, err := r.m.transitionQueuedExperiment(
+		id, model.CanceledDependencyState, reason); err != nil || !canceled {
+		return err
+	}
+	ctx.Log().Infof("canceled experiment %d: %s", id, reason)
+	return nil
+}
+
+// start creates the actor of a queued experiment whose dependencies met its condition. The
+// experiment starts active, as the CLI would activate it when it was submitted. It is saved as
+// active before its actor starts, so that if the master stops in between, the experiment is
+// restored and runs rather than staying paused.
+func (r *dependencyResolver) start(ctx *actor.Context, id int, warmStartUUID *string) error {
+	dbExp, err := r.m.db.ExperimentByID(id)
+	if err != nil {
+		return err
+	}
+	if warmStartUUID != nil {
+		dbExp.Config.Searcher.SourceCheckpointUUID = warmStartUUID
+		if err = r.m.db.SaveExperimentConfig(dbExp); err != nil {
+			return errors.Wrap(err, "saving warm start checkpoint")
+		}
+	}
+
+	started, err := r.m.transitionQueuedExperiment(
+		id, model.ActiveState, "the experiments it depends on met the condition")
+	if err != nil || !started {
+		return err
+	}
+	dbExp.State = model.ActiveState
+
+	e, err := newExperiment(r.m, dbExp)
+	if err != nil {
+		if tErr := r.m.db.TerminateExperimentInRestart(id, model.ErrorState); tErr != nil {
+			ctx.Log().WithError(tErr).Errorf("cannot mark experiment %d as errored", id)
+		}
+		dbExp.State = model.ErrorState
+		recordTransitionWithoutActor(r.m.db, id, model.ActiveState, dbExp.State,
+			fmt.Sprintf("the experiment could not be started: %s", err))
+		return errors.Wrap(err, "starting experiment")
+	}
+	r.m.system.ActorOf(actor.Addr("experiments", e.ID), e)
+	ctx.Log().Infof("started experiment %d after its dependencies", id)
+	return nil
+}
+
+// dependenciesOutcome is what becomes of an experiment that waits for other experiments.
+type dependenciesOutcome int
+
+const (
+	dependenciesPending dependenciesOutcome = iota
+	dependenciesCompleted
+	dependenciesFailed
+)
+
+// checkDependencyStates returns whether the experiments with the given IDs all completed, or, if
+// one of them was deleted or ended otherwise, why the experiment that depends on them cannot run.
+// states holds the states of the experiments that exist.
+func checkDependencyStates(
+	dependsOn []int, states map[int]model.State,
+) (dependenciesOutcome, string) {
+	outcome := dependenciesCompleted
+	for _, id := range dependsOn {
+		state, ok := states[id]
+		switch {
+		case !ok, state == model.DeletingState:
+			return dependenciesFailed, fmt.Sprintf("dependency experiment %d was deleted", id)
+		case state == model.CompletedState:
+		case model.TerminalStates[state]:
+			return dependenciesFailed, fmt.Sprintf(
+				"dependency experiment %d ended in state %s", id, state)
+		default:
+			outcome = dependenciesPending
+		}
+	}
+	return outcome, ""
+}
+
+// checkMetricThreshold returns why the best value of a validation metric of a completed
+// dependency does not meet the threshold, or "" if it does. best is nil if the dependency did not
+// report the metric.
+func checkMetricThreshold(
+	id int, metric string, smallerIsBetter bool, threshold float64, best *float64,
+) string {
+	switch {
+	case best == nil:
+		return fmt.Sprintf("dependency experiment %d did not report validation metric %s", id, metric)
+	case smallerIsBetter && *best > threshold:
+		return fmt.Sprintf("the best %s of dependency experiment %d was %v, above the threshold %v",
+			metric, id, *best, threshold)
+	case !smallerIsBetter && *best < threshold:
+		return fmt.Sprintf("the best %s of dependency experiment %d was %v, below the threshold %v",
+			metric, id, *best, threshold)
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestCheckDependencyStates(t *testing.T) {
+	for _, tc := range []struct {
+		states  map[int]model.State
+		outcome dependenciesOutcome
+		reason  string
+	}{
+		{
+			states:  map[int]model.State{1: model.CompletedState, 2: model.CompletedState},
+			outcome: dependenciesCompleted,
+		},
+		{
+			states:  map[int]model.State{1: model.CompletedState, 2: model.ActiveState},
+			outcome: dependenciesPending,
+		},
+		{
+			states:  map[int]model.State{1: model.PausedState, 2: model.ErrorState},
+			outcome: dependenciesFailed,
+			reason:  "dependency experiment 2 ended in state ERROR",
+		},
+		{
+			states:  map[int]model.State{1: model.CompletedState},
+			outcome: dependenciesFailed,
+			reason:  "dependency experiment 2 was deleted",
+		},
+		{
+			states:  map[int]model.State{1: model.DeletingState, 2: model.CompletedState},
+			outcome: dependenciesFailed,
+			reason:  "dependency experiment 1 was deleted",
+		},
+	} {
+		outcome, reason := checkDependencyStates([]int{1, 2}, tc.states)
+		assert.Equal(t, outcome, tc.outcome, "%v", tc.states)
+		assert.Equal(t, reason, tc.reason, "%v", tc.states)
+	}
+}
+
+func TestCheckMetricThreshold(t *testing.T) {
+	low, high := 0.1, 0.9
+
+	assert.Equal(t, checkMetricThreshold(1, "loss", true, 0.5, &low), "")
+	assert.Equal(t, checkMetricThreshold(1, "loss", true, 0.5, &high),
+		"the best loss of dependency experiment 1 was 0.9, above the threshold 0.5")
+	assert.Equal(t, checkMetricThreshold(1, "accuracy", false, 0.5, &high), "")
+	assert.Equal(t, checkMetricThreshold(1, "accuracy", false, 0.5, &low),
+		"the best accuracy of dependency experiment 1 was 0.1, below the threshold 0.5")
+	assert.Equal(t, checkMetricThreshold(1, "loss", true, 0.5, nil),
+		"dependency experiment 1 did not report validation metric loss")
+}
+
+func TestQueuedExperimentsStartActive(t *testing.T) {
+	// Queued experiments are saved as active before their actors start, so that they run after a
+	// restart of the master in between instead of staying paused.
+	transitions := model.ExperimentTransitions[model.QueuedDependencyState]
+	assert.Assert(t, transitions[model.ActiveState])
+	assert.Assert(t, !transitions[model.PausedState])
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/determined-ai/determined/master/internal/context"
+)
+
+// experimentListCacheMetric counts lookups in the experiment list cache by query and whether they
+// were served from the cache.
+const experimentListCacheMetric = "det_experiment_list_cache_lookups_total"
+
+// experimentListCache holds the results of the experiment list queries that the WebUI polls, keyed
+// by query and parameters, for a short time. Any change to an experiment invalidates all of them.
+type experimentListCache struct {
+	mu      sync.Mutex
+	entries map[string]experimentListCacheEntry
+	// generation is incremented on every invalidation, so that results loaded from before an
+	// invalidation are not cached after it.
+	generation uint64
+	now        func() time.Time
+}
+
+type experimentListCacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newExperimentListCache() *experimentListCache {
+	return &experimentListCache{entries: map[string]experimentListCacheEntry{}, now: time.Now}
+}
+
+// get returns the cached result for the key if it is younger than the TTL, and otherwise loads it
+// and caches it. hit is whether the result came from the cache.
+func (c *experimentListCache) get(
+	key string, ttl time.Duration, load func() (interface{}, error),
+) (value interface{}, hit bool, err error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	generation := c.generation
+	now := c.now()
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.value, true, nil
+	}
+
+	if value, err = load(); err != nil {
+		return nil, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		// Drop expired entries so that rarely used parameters don't pile up.
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.entries[key] = experimentListCacheEntry{value: value, expires: now.Add(ttl)}
+	}
+	return value, false, nil
+}
+
+// invalidate drops all cached results. It does nothing on a nil cache.
+func (c *experimentListCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.entries = map[string]experimentListCacheEntry{}
+}
+
+// cachedExperimentList serves an experiment list query from the experiment list cache, keyed by the
+// name of the query and the query parameters of the request.
+func (m *Master) cachedExperimentList(
+	c echo.Context, query string, load func() (interface{}, error),
+) (interface{}, error) {
+	ttl := time.Duration(m.currentConfig().Experiments.ListCacheTTL) * time.Second
+	if ttl <= 0 {
+		return load()
+	}
+	// Users who are not admins only see the experiments of their teams, so their lists are cached
+	// separately.
+	key := query + "?" + c.QueryParams().Encode()
+	if viewer := experimentViewer(c.(*context.DetContext).MustGetUser()); viewer != nil {
+		key += "#user=" + strconv.Itoa(int(*viewer))
+	}
+	value, hit, err := m.experimentListCache.get(key, ttl, load)
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.metrics.Inc(experimentListCacheMetric,
+		"Number of lookups in the experiment list cache, by whether they were cached.",
+		map[string]string{"query": query, "result": result})
+	return value, err
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestExperimentListCache(t *testing.T) {
+	cache := newExperimentListCache()
+	now := time.Unix(0, 0)
+	cache.now = func() time.Time { return now }
+	loads := 0
+	load := func() (interface{}, error) {
+		loads++
+		return loads, nil
+	}
+	get := func(key string) (interface{}, bool) {
+		value, hit, err := cache.get(key, 5*time.Second, load)
+		assert.NilError(t, err)
+		return value, hit
+	}
+
+	value, hit := get("experiment-list?")
+	assert.Equal(t, value, 1)
+	assert.Equal(t, hit, false)
+	value, hit = get("experiment-list?")
+	assert.Equal(t, value, 1)
+	assert.Equal(t, hit, true)
+
+	// Different parameters are cached separately.
+	value, _ = get("experiment-list?user=admin")
+	assert.Equal(t, value, 2)
+
+	// Results expire after the TTL.
+	now = now.Add(5 * time.Second)
+	value, hit = get("experiment-list?")
+	assert.Equal(t, value, 3)
+	assert.Equal(t, hit, false)
+
+	// Changes to experiments invalidate everything.
+	cache.invalidate()
+	value, hit = get("experiment-list?")
+	assert.Equal(t, value, 4)
+	assert.Equal(t, hit, false)
+
+	// Results loaded across an invalidation are not cached, since they may be stale.
+	_, _, err := cache.get("experiment-summaries?", 5*time.Second, func() (interface{}, error) {
+		cache.invalidate()
+		return "stale", nil
+	})
+	assert.NilError(t, err)
+	value, hit = get("experiment-summaries?")
+	assert.Equal(t, value, 5)
+	assert.Equal(t, hit, false)
+}
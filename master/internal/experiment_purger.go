@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// experimentPurgerInterval is the time between passes of the experiment purger.
+const experimentPurgerInterval = time.Hour
+
+type experimentPurgerTick struct{}
+
+// experimentPurger periodically deletes for good the experiments that were soft-deleted longer
+// than the grace period ago, handing them to the experiment deleter. Experiments whose checkpoints
+// were registered as model versions since they were soft-deleted are left alone.
+type experimentPurger struct {
+	db          *db.PgDB
+	gracePeriod time.Duration
+}
+
+// Receive implements the actor.Actor interface.
+func (p *experimentPurger) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		actors.NotifyAfter(ctx, 0, experimentPurgerTick{})
+
+	case experimentPurgerTick:
+		p.purge(ctx)
+		actors.NotifyAfter(ctx, experimentPurgerInterval, experimentPurgerTick{})
+
+	case actor.PostStop:
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (p *experimentPurger) purge(ctx *actor.Context) {
+	ids, err := p.db.ExpiredSoftDeletedExperimentIDs(p.gracePeriod)
+	if err != nil {
+		// Log the error but carry on so that the next pass is still scheduled.
+		ctx.Log().WithError(err).Error("cannot find soft-deleted experiments to purge")
+		return
+	}
+	for _, id := range ids {
+		versions, err := p.db.ExperimentModelVersions(id)
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("cannot purge soft-deleted experiment %d", id)
+			continue
+		}
+		if len(versions) > 0 {
+			ctx.Log().Warnf("not purging soft-deleted experiment %d: its checkpoints are "+
+				"registered as model versions", id)
+			continue
+		}
+		from, err := p.db.MarkExpiredExperimentDeleting(id, p.gracePeriod)
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("cannot purge soft-deleted experiment %d", id)
+			continue
+		} else if from == nil {
+			ctx.Log().Infof("not purging experiment %d, which was undeleted", id)
+			continue
+		}
+		recordTransitionWithoutActor(p.db, id, *from, model.DeletingState,
+			"the grace period after the experiment was deleted passed")
+		ctx.Log().Infof("purging experiment %d, whose grace period after deletion passed", id)
+		ctx.Self().System().TellAt(experimentDeleterAddr, deleteExperiment{experimentID: id})
+	}
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// experimentSchedulerInterval is the time between checks for due experiment schedules. Since cron
+// expressions fire on the minute, experiments are created at most this late.
+const experimentSchedulerInterval = 15 * time.Second
+
+type experimentSchedulerTick struct{}
+
+// experimentScheduler creates experiments from their schedules when they are due. A schedule that
+// was due while the master was down or in maintenance mode fires once, if it is late by no more
+// than the catch-up window, and then skips to its next time after now.
+type experimentScheduler struct {
+	m *Master
+}
+
+// Receive implements the actor.Actor interface.
+func (s *experimentScheduler) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		actors.NotifyAfter(ctx, 0, experimentSchedulerTick{})
+
+	case experimentSchedulerTick:
+		s.runDue(ctx)
+		actors.NotifyAfter(ctx, experimentSchedulerInterval, experimentSchedulerTick{})
+
+	case actor.PostStop:
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (s *experimentScheduler) runDue(ctx *actor.Context) {
+	// Schedules stay due until the maintenance ends.
+	if s.m.currentMaintenance() != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	due, err := s.m.db.DueExperimentSchedules(now)
+	if err != nil {
+		// Log the error but carry on so that the next check is still scheduled.
+		ctx.Log().WithError(err).Error("cannot find due experiment schedules")
+		return
+	}
+	catchUpWindow := time.Duration(s.m.currentConfig().ExperimentSchedules.CatchUpWindow)*
+		time.Second + experimentSchedulerInterval
+	for i := range due {
+		schedule := &due[i]
+		run := now.Sub(*schedule.NextRunTime) <= catchUpWindow
+		if !run {
+			ctx.Log().Warnf("skipping experiment schedule %d, which was due at %s",
+				schedule.ID, schedule.NextRunTime.Format(time.RFC3339))
+		}
+		// Advance the schedule before creating the experiment, so that an experiment is never
+		// created twice for the same time if the master stops in between.
+		if err := advanceExperimentSchedule(schedule, now, run); err != nil {
+			ctx.Log().WithError(err).Errorf("cannot advance experiment schedule %d", schedule.ID)
+		}
+		if err := s.m.db.UpdateExperimentSchedule(schedule); err != nil {
+			ctx.Log().WithError(err).Errorf("cannot update experiment schedule %d", schedule.ID)
+			continue
+		}
+		if !run {
+			continue
+		}
+		if err := s.createExperiment(schedule); err != nil {
+			ctx.Log().WithError(err).Errorf(
+				"cannot create experiment from schedule %d", schedule.ID)
+		}
+	}
+}
+
+// advanceExperimentSchedule records whether a due experiment schedule ran and sets when it next
+// runs after now. A schedule whose next time cannot be computed does not run again.
+func advanceExperimentSchedule(schedule *model.ExperimentSchedule, now time.Time, run bool) error {
+	if run {
+		schedule.Runs++
+		schedule.LastRunTime = &now
+	}
+	var err error
+	if schedule.NextRunTime, err = nextScheduleRunTime(schedule, now); err != nil {
+		schedule.NextRunTime = nil
+	}
+	return err
+}
+
+// createExperiment submits an experiment on behalf of the owner of the schedule. The config is
+// validated again, since the submit validators and the owner may have changed since the schedule
+// was saved.
+func (s *experimentScheduler) createExperiment(schedule *model.ExperimentSchedule) error {
+	owner, err := s.m.db.UserByID(schedule.OwnerID)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find owner %d", schedule.OwnerID)
+	}
+	if !owner.Active {
+		return errors.Errorf("owner %s is not active", owner.Username)
+	}
+	if err = s.m.validateExperimentConfig(schedule.Config, nil); err != nil {
+		return errors.Wrap(err, "invalid experiment")
+	}
+	dbExp, err := model.NewExperiment(
+		schedule.Config, schedule.ModelDefinitionBytes, nil, false,
+		schedule.GitRemote, schedule.GitCommit, schedule.GitCommitter, schedule.GitCommitDate)
+	if err != nil {
+		return err
+	}
+	dbExp.OwnerID = &schedule.OwnerID
+	dbExp.ScheduleID = &schedule.ID
+	teams, err := s.m.db.UserTeams(schedule.OwnerID)
+	if err != nil {
+		return err
+	}
+	dbExp.TeamID = defaultExperimentTeam(teams)
+	if _, err = s.m.startExperiment(dbExp); err != nil {
+		return errors.Wrap(err, "starting experiment")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestScheduleParamsValidate(t *testing.T) {
+	now := time.Date(2020, 11, 8, 12, 0, 0, 0, time.UTC)
+	future, past := now.Add(time.Hour), now.Add(-time.Hour)
+	nightly, invalid := "0 2 * * *", "0 2 * *"
+	one, zero := 1, 0
+
+	assert.NilError(t, scheduleParams{StartAt: &future}.validate(now))
+	assert.NilError(t, scheduleParams{Cron: &nightly, MaxRuns: &one}.validate(now))
+	for _, params := range []scheduleParams{
+		{},
+		{StartAt: &future, Cron: &nightly},
+		{StartAt: &past},
+		{StartAt: &future, MaxRuns: &one},
+		{Cron: &nightly, MaxRuns: &zero},
+		{Cron: &invalid},
+	} {
+		assert.Assert(t, params.validate(now) != nil, "%+v", params)
+	}
+}
+
+func TestAdvanceExperimentSchedule(t *testing.T) {
+	now := time.Date(2020, 11, 8, 12, 0, 0, 0, time.UTC)
+	nightly, maxRuns := "0 2 * * *", 2
+	schedule := &model.ExperimentSchedule{Cron: &nightly, MaxRuns: &maxRuns}
+
+	next, err := nextScheduleRunTime(schedule, now)
+	assert.NilError(t, err)
+	assert.Equal(t, *next, time.Date(2020, 11, 9, 2, 0, 0, 0, time.UTC))
+
+	// A skipped firing does not count as a run.
+	assert.NilError(t, advanceExperimentSchedule(schedule, now, false))
+	assert.Equal(t, schedule.Runs, 0)
+	assert.Equal(t, *schedule.NextRunTime, time.Date(2020, 11, 9, 2, 0, 0, 0, time.UTC))
+
+	later := now.Add(24 * time.Hour)
+	assert.NilError(t, advanceExperimentSchedule(schedule, later, true))
+	assert.Equal(t, schedule.Runs, 1)
+	assert.Equal(t, *schedule.LastRunTime, later)
+	assert.Equal(t, *schedule.NextRunTime, time.Date(2020, 11, 10, 2, 0, 0, 0, time.UTC))
+
+	assert.NilError(t, advanceExperimentSchedule(schedule, later.Add(24*time.Hour), true))
+	assert.Equal(t, schedule.Runs, 2)
+	assert.Assert(t, schedule.NextRunTime == nil)
+
+	startAt := now.Add(time.Hour)
+	once := &model.ExperimentSchedule{StartAt: &startAt}
+	next, err = nextScheduleRunTime(once, now)
+	assert.NilError(t, err)
+	assert.Equal(t, *next, startAt)
+	assert.NilError(t, advanceExperimentSchedule(once, startAt, true))
+	assert.Assert(t, once.NextRunTime == nil)
+}
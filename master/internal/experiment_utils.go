@@ -56,6 +56,8 @@ func convertSearcherEvent(id int, event searcher.Event) (
 	//  - We have a checkpoint that has occurred
 	//  - We have a trial created
 	//  - We have computed validation metrics
+	//  - We have changed the number of concurrent trials
+	//  - We have accepted operations from the controller of a custom search
 	var flush bool
 	switch event := event.(type) {
 	case searcher.TrialCreatedEvent:
@@ -77,6 +79,33 @@ func convertSearcherEvent(id int, event searcher.Event) (
 			"request_id": event.RequestID.String(),
 		}
 
+	case searcher.MaxConcurrentTrialsChangedEvent:
+		eventType = MaxConcurrentTrialsChangedEventType
+		content = model.JSONObj{
+			"max_concurrent_trials": event.MaxConcurrentTrials,
+		}
+		flush = true
+
+	case searcher.EarlyStoppingTrialUpdatedEvent:
+		eventType = EarlyStoppingTrialUpdatedEventType
+		content = model.JSONObj{
+			"trial_id":          event.TrialID,
+			"best":              event.Best,
+			"since_improvement": event.SinceImprovement,
+		}
+		flush = true
+
+	case searcher.CustomOperationsPostedEvent:
+		opsBytes, err := json.Marshal(event.Operations)
+		if err != nil {
+			return nil, false, err
+		}
+		eventType = CustomOperationsPostedEventType
+		content = model.JSONObj{
+			"operations": json.RawMessage(opsBytes),
+		}
+		flush = true
+
 	case workload.CompletedMessage:
 		switch event.Workload.Kind {
 		case workload.RunStep:
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/labstack/echo"
+)
+
+// Feature flags that gate endpoints which are not enabled by default. Each is enabled on a cluster
+// by setting it to true under feature_flags in the master configuration.
+const (
+	hparamImportanceFeatureFlag = "hparam_importance"
+	eventsFeatureFlag           = "events"
+)
+
+// masterFeatures are the features that every master of this version supports, which clients may
+// check for before using them.
+var masterFeatures = []string{
+	"model_registry",
+	"trial_logs_v2",
+}
+
+// features returns the features that the master supports, including the enabled feature flags.
+func (m *Master) features() []string {
+	features := append([]string{}, masterFeatures...)
+	for name, enabled := range m.currentConfig().FeatureFlags {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// featureEnabled returns whether the named feature flag is enabled.
+func (m *Master) featureEnabled(name string) bool {
+	return m.currentConfig().FeatureFlags[name]
+}
+
+// featureFlag returns a middleware that responds with 404 unless the named feature flag is
+// enabled. Flags are checked on every request, so they can be toggled by reloading the master
+// configuration.
+func (m *Master) featureFlag(name string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !m.featureEnabled(name) {
+				return echo.NewHTTPError(
+					echo.ErrNotFound.Code, fmt.Sprintf("%s not found", c.Request().URL.Path))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+)
+
+func TestFeatureFlag(t *testing.T) {
+	m := &Master{config: DefaultConfig()}
+	handler := m.featureFlag("new_endpoint")(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	serve := func() error {
+		req := httptest.NewRequest(http.MethodGet, "/new-endpoint", nil)
+		return handler(echo.New().NewContext(req, httptest.NewRecorder()))
+	}
+
+	err := serve()
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.Assert(t, ok, err)
+	assert.Equal(t, httpErr.Code, http.StatusNotFound)
+
+	m.config.FeatureFlags = map[string]bool{"new_endpoint": true}
+	assert.NilError(t, serve())
+
+	m.config.FeatureFlags["new_endpoint"] = false
+	assert.ErrorType(t, serve(), &echo.HTTPError{})
+}
@@ -34,11 +34,13 @@ func NewGRPCServer(db *db.PgDB, srv proto.DeterminedServer) *grpc.Server {
 	grpcS := grpc.NewServer(
 		grpc.StreamInterceptor(grpcmiddleware.ChainStreamServer(
 			grpclogrus.StreamServerInterceptor(logger, opts...),
+			streamRequestIDInterceptor,
 			grpcrecovery.StreamServerInterceptor(),
 			streamAuthInterceptor(db),
 		)),
 		grpc.UnaryInterceptor(grpcmiddleware.ChainUnaryServer(
 			grpclogrus.UnaryServerInterceptor(logger, opts...),
+			unaryRequestIDInterceptor,
 			grpcrecovery.UnaryServerInterceptor(grpcrecovery.WithRecoveryHandler(
 				func(p interface{}) (err error) {
 					logger.Error(string(debug.Stack()))
@@ -65,17 +67,24 @@ func newGRPCGatewayMux() *runtime.ServeMux {
 	return runtime.NewServeMux(serverOpts...)
 }
 
-// RegisterHTTPProxy registers grpc-gateway with the master echo server.
-func RegisterHTTPProxy(e *echo.Echo, port int, cert *tls.Certificate) error {
+// RegisterHTTPProxy registers grpc-gateway with the master echo server. If the master requires
+// client certificates, clientCert is the certificate that the gateway connects to it with.
+func RegisterHTTPProxy(
+	e *echo.Echo, port int, cert *tls.Certificate, clientCert *tls.Certificate,
+) error {
 	addr := fmt.Sprintf(":%d", port)
 	var opts []grpc.DialOption
 	if cert == nil {
 		opts = append(opts, grpc.WithInsecure())
 	} else {
 		// Since this connection is coming directly back to this process, we can skip verification.
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		config := &tls.Config{
 			InsecureSkipVerify: true, //nolint:gosec
-		})))
+		}
+		if clientCert != nil {
+			config.Certificates = []tls.Certificate{*clientCert}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(config)))
 	}
 	mux := newGRPCGatewayMux()
 	err := proto.RegisterDeterminedHandlerFromEndpoint(context.Background(), mux, addr, opts)
@@ -89,6 +98,9 @@ func RegisterHTTPProxy(e *echo.Echo, port int, cert *tls.Certificate) error {
 				request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cookie.Value))
 			}
 		}
+		if id := request.Header.Get(echo.HeaderXRequestID); id != "" {
+			request.Header.Set(runtime.MetadataHeaderPrefix+echo.HeaderXRequestID, id)
+		}
 		if _, ok := request.URL.Query()["pretty"]; ok {
 			request.Header.Set("Accept", jsonPretty)
 		}
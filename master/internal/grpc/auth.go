@@ -29,6 +29,10 @@ const (
 var unauthenticatedMethods = map[string]bool{
 	"/determined.api.v1.Determined/Login":     true,
 	"/determined.api.v1.Determined/GetMaster": true,
+	// Configuration schemas are public, so that tools can validate files before logging in.
+	"/determined.api.v1.Determined/GetConfigSchema": true,
+	// Trial logs are shipped without user credentials, as with POST /trial_logs.
+	"/determined.api.v1.Determined/PostTrialLogs": true,
 }
 
 var (
@@ -77,8 +81,10 @@ func streamAuthInterceptor(db *db.PgDB) grpc.StreamServerInterceptor {
 	return func(
 		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
 	) error {
-		if _, _, err := GetUser(ss.Context(), db); err != nil {
-			return err
+		if !unauthenticatedMethods[info.FullMethod] {
+			if _, _, err := GetUser(ss.Context(), db); err != nil {
+				return err
+			}
 		}
 		return handler(srv, ss)
 	}
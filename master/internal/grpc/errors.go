@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/labstack/echo"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -19,7 +20,8 @@ var fallbackError = errorBody{
 }
 
 type errorBody struct {
-	Error errorMessage `json:"error"`
+	Error     errorMessage `json:"error"`
+	RequestID string       `json:"request_id,omitempty"`
 }
 
 type errorMessage struct {
@@ -30,7 +32,7 @@ type errorMessage struct {
 
 func errorHandler(
 	_ context.Context, _ *runtime.ServeMux, m runtime.Marshaler,
-	w http.ResponseWriter, _ *http.Request, e error,
+	w http.ResponseWriter, r *http.Request, e error,
 ) {
 	w.Header().Set("Content-type", m.ContentType())
 	w.WriteHeader(runtime.HTTPStatusFromCode(status.Code(e)))
@@ -46,6 +48,7 @@ func errorHandler(
 			Reason:  s.Code().String(),
 			Message: s.Message(),
 		},
+		RequestID: r.Header.Get(echo.HeaderXRequestID),
 	}
 
 	encoder := json.NewEncoder(w)
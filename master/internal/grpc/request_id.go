@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	grpcmiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+// requestIDMetadataKey is the metadata key of the ID of a request. The gRPC gateway sets it from
+// the X-Request-ID header of HTTP requests.
+const requestIDMetadataKey = "x-request-id"
+
+// withRequestID returns a copy of the context of a call that carries the ID of the call, which is
+// taken from its metadata if it is valid or newly generated. The ID is added to the logs of the
+// call.
+func withRequestID(ctx context.Context) (context.Context, string) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIDMetadataKey); len(ids) > 0 {
+			id = ids[0]
+		}
+	}
+	if !logger.ValidRequestID(id) {
+		id = uuid.New().String()
+	}
+	ctxlogrus.AddFields(ctx, logrus.Fields{logger.RequestIDField: id})
+	return logger.WithRequestID(ctx, id), id
+}
+
+func unaryRequestIDInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, id := withRequestID(ctx)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, id)); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func streamRequestIDInterceptor(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ctx, id := withRequestID(ss.Context())
+	if err := ss.SetHeader(metadata.Pairs(requestIDMetadataKey, id)); err != nil {
+		return err
+	}
+	wrapped := grpcmiddleware.WrapServerStream(ss)
+	wrapped.WrappedContext = ctx
+	return handler(srv, wrapped)
+}
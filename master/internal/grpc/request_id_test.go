@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+func TestWithRequestID(t *testing.T) {
+	incoming := func(id string) context.Context {
+		return metadata.NewIncomingContext(
+			context.Background(), metadata.Pairs(requestIDMetadataKey, id))
+	}
+
+	ctx, id := withRequestID(incoming("abc-123"))
+	assert.Equal(t, id, "abc-123")
+	fromCtx, ok := logger.RequestID(ctx)
+	assert.Assert(t, ok)
+	assert.Equal(t, fromCtx, id)
+
+	// Invalid IDs are replaced rather than echoed into logs and headers.
+	for _, invalid := range []string{"abc\nlevel=error", string(make([]byte, 129))} {
+		_, id = withRequestID(incoming(invalid))
+		assert.Assert(t, id != invalid)
+		assert.Assert(t, logger.ValidRequestID(id))
+	}
+
+	_, id = withRequestID(context.Background())
+	assert.Assert(t, logger.ValidRequestID(id))
+}
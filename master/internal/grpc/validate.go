@@ -32,3 +32,18 @@ func ValidateFollow(limit int32, follow bool) Check {
 		return limit == 0 || !follow, "Limit cannot be specified when following"
 	}
 }
+
+// ValidateCursor validates Cursor message fields.
+func ValidateCursor(offset int32, cursor string) Check {
+	return func() (bool, string) {
+		return offset == 0 || cursor == "", "Offset cannot be specified with a cursor"
+	}
+}
+
+// ValidateSince validates Since message fields.
+func ValidateSince(offset int32, cursor, since string) Check {
+	return func() (bool, string) {
+		return since == "" || (offset == 0 && cursor == ""),
+			"Since cannot be specified with an offset or a cursor"
+	}
+}
@@ -0,0 +1,178 @@
+// Package hpimportance estimates how much each hyperparameter of an experiment influences a
+// metric. It fits a random forest of regression trees to the hyperparameters and final metrics of
+// the experiment's trials and measures the permutation importance of each hyperparameter on the
+// samples that each tree did not see during training.
+package hpimportance
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	numTrees    = 100
+	maxDepth    = 8
+	minLeafSize = 2
+)
+
+// node is a node of a regression tree. Leaves have a nil left child and predict value.
+type node struct {
+	feature     int
+	threshold   float64
+	left, right *node
+	value       float64
+}
+
+func (n *node) predict(sample []float64) float64 {
+	for n.left != nil {
+		if sample[n.feature] <= n.threshold {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return n.value
+}
+
+func mean(y []float64, indices []int) float64 {
+	sum := 0.0
+	for _, i := range indices {
+		sum += y[i]
+	}
+	return sum / float64(len(indices))
+}
+
+// buildTree fits a regression tree to the given samples by greedily choosing the split that
+// minimizes the sum of squared errors of the children.
+func buildTree(x [][]float64, y []float64, indices []int, depth int) *node {
+	leaf := &node{value: mean(y, indices)}
+	if depth >= maxDepth || len(indices) < 2*minLeafSize {
+		return leaf
+	}
+
+	bestFeature, bestThreshold := -1, 0.0
+	bestSSE := math.Inf(1)
+	sorted := make([]int, len(indices))
+	for feature := range x[indices[0]] {
+		copy(sorted, indices)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return x[sorted[i]][feature] < x[sorted[j]][feature]
+		})
+
+		total, totalSq := 0.0, 0.0
+		for _, i := range sorted {
+			total += y[i]
+			totalSq += y[i] * y[i]
+		}
+		left, leftSq := 0.0, 0.0
+		for split := 1; split < len(sorted); split++ {
+			v := y[sorted[split-1]]
+			left += v
+			leftSq += v * v
+			if split < minLeafSize || len(sorted)-split < minLeafSize ||
+				x[sorted[split-1]][feature] == x[sorted[split]][feature] {
+				continue
+			}
+			nLeft, nRight := float64(split), float64(len(sorted)-split)
+			right, rightSq := total-left, totalSq-leftSq
+			sse := leftSq - left*left/nLeft + rightSq - right*right/nRight
+			if sse < bestSSE {
+				bestFeature, bestSSE = feature, sse
+				bestThreshold = (x[sorted[split-1]][feature] + x[sorted[split]][feature]) / 2
+			}
+		}
+	}
+	if bestFeature < 0 {
+		return leaf
+	}
+
+	var leftIndices, rightIndices []int
+	for _, i := range indices {
+		if x[i][bestFeature] <= bestThreshold {
+			leftIndices = append(leftIndices, i)
+		} else {
+			rightIndices = append(rightIndices, i)
+		}
+	}
+	if len(leftIndices) == 0 || len(rightIndices) == 0 {
+		return leaf
+	}
+	return &node{
+		feature:   bestFeature,
+		threshold: bestThreshold,
+		left:      buildTree(x, y, leftIndices, depth+1),
+		right:     buildTree(x, y, rightIndices, depth+1),
+	}
+}
+
+func squaredError(tree *node, x [][]float64, y []float64, indices []int) float64 {
+	sse := 0.0
+	for _, i := range indices {
+		diff := tree.predict(x[i]) - y[i]
+		sse += diff * diff
+	}
+	return sse
+}
+
+// Compute returns the importance of each feature (column of x) for predicting y. Importances are
+// non-negative and sum to 1, unless no feature explains any of the variance of y, in which case
+// they are all 0. The result is deterministic for a given seed.
+func Compute(x [][]float64, y []float64, seed int64) []float64 {
+	if len(x) == 0 {
+		return nil
+	}
+	numFeatures := len(x[0])
+	importance := make([]float64, numFeatures)
+	if len(x) < 2*minLeafSize {
+		return importance
+	}
+
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec
+	permuted := make([][]float64, len(x))
+	for t := 0; t < numTrees; t++ {
+		// Fit a tree to a bootstrap sample and evaluate it on the samples left out of it.
+		inBag := make([]bool, len(x))
+		bag := make([]int, len(x))
+		for i := range bag {
+			bag[i] = rng.Intn(len(x))
+			inBag[bag[i]] = true
+		}
+		var outOfBag []int
+		for i, in := range inBag {
+			if !in {
+				outOfBag = append(outOfBag, i)
+			}
+		}
+		if len(outOfBag) < 2 {
+			continue
+		}
+		tree := buildTree(x, y, bag, 0)
+		baseline := squaredError(tree, x, y, outOfBag)
+
+		for feature := 0; feature < numFeatures; feature++ {
+			order := rng.Perm(len(outOfBag))
+			for k, i := range outOfBag {
+				sample := append([]float64(nil), x[i]...)
+				sample[feature] = x[outOfBag[order[k]]][feature]
+				permuted[i] = sample
+			}
+			increase := squaredError(tree, permuted, y, outOfBag) - baseline
+			importance[feature] += increase / float64(len(outOfBag))
+		}
+	}
+
+	total := 0.0
+	for feature, value := range importance {
+		if value < 0 {
+			importance[feature] = 0
+		}
+		total += importance[feature]
+	}
+	if total > 0 {
+		for feature := range importance {
+			importance[feature] /= total
+		}
+	}
+	return importance
+}
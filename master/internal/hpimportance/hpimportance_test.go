@@ -0,0 +1,40 @@
+package hpimportance
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCompute(t *testing.T) {
+	rng := rand.New(rand.NewSource(0)) //nolint:gosec
+	var x [][]float64
+	var y []float64
+	for i := 0; i < 100; i++ {
+		sample := []float64{rng.Float64(), rng.Float64(), float64(rng.Intn(3))}
+		x = append(x, sample)
+		y = append(y, 10*sample[0]+sample[1]+0.01*rng.NormFloat64())
+	}
+
+	importance := Compute(x, y, 0)
+	assert.Equal(t, len(importance), 3)
+	assert.Assert(t, importance[0] > 0.7, fmt.Sprint(importance))
+	assert.Assert(t, importance[1] > importance[2], fmt.Sprint(importance))
+
+	sum := 0.0
+	for _, value := range importance {
+		assert.Assert(t, value >= 0)
+		sum += value
+	}
+	assert.Assert(t, sum > 0.999 && sum < 1.001)
+
+	assert.DeepEqual(t, Compute(x, y, 0), importance)
+}
+
+func TestComputeConstantMetric(t *testing.T) {
+	x := [][]float64{{1, 2}, {2, 1}, {3, 3}, {4, 0}, {5, 5}, {6, 2}}
+	y := []float64{1, 1, 1, 1, 1, 1}
+	assert.DeepEqual(t, Compute(x, y, 0), []float64{0, 0})
+}
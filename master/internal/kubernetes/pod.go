@@ -164,6 +164,10 @@ func (p *pod) Receive(ctx *actor.Context) error {
 }
 
 func (p *pod) createPodSpecAndSubmit(ctx *actor.Context) error {
+	if err := p.taskSpec.Validate(); err != nil {
+		return err
+	}
+
 	var err error
 	switch {
 	case p.taskSpec.StartCommand != nil:
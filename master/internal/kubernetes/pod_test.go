@@ -2,6 +2,7 @@ package kubernetes
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"reflect"
 	"testing"
@@ -131,9 +132,16 @@ func createPodWithMockQueue() (
 		Config:         model.CommandConfig{Description: "test-config"},
 	}
 	task := tasks.TaskSpec{
-		TaskID:       "task",
-		ContainerID:  "container",
-		ClusterID:    "cluster",
+		TaskID:      "task",
+		ContainerID: "container",
+		ClusterID:   "cluster",
+		HarnessPath: ".",
+		HarnessManifest: container.HarnessManifest{
+			Python: "3.6",
+			Wheels: map[string][]container.HarnessWheel{
+				container.AnyHarnessPlatform: {{Name: testHarnessWheel}},
+			},
+		},
 		StartCommand: &startCmd,
 	}
 	system := actor.NewSystem("test-sys")
@@ -149,6 +157,9 @@ func createPodWithMockQueue() (
 	return system, newPod, ref, podMap, actorMap
 }
 
+// testHarnessWheel is the harness wheel that pods are given, which setupEntrypoint creates.
+const testHarnessWheel = "determined-test-py3-none-any.whl"
+
 func setupEntrypoint(t *testing.T) {
 	err := etc.SetRootPath(".")
 	if err != nil {
@@ -159,6 +170,9 @@ func setupEntrypoint(t *testing.T) {
 	if err != nil {
 		t.Logf("Failed to close entrypoint")
 	}
+	if err = ioutil.WriteFile(testHarnessWheel, nil, 0600); err != nil {
+		t.Logf("Failed to create harness wheel")
+	}
 }
 
 func cleanup(t *testing.T) {
@@ -166,6 +180,9 @@ func cleanup(t *testing.T) {
 	if err != nil {
 		t.Logf("Failed to remove entrypoint")
 	}
+	if err = os.Remove(testHarnessWheel); err != nil {
+		t.Logf("Failed to remove harness wheel")
+	}
 }
 
 func checkReceiveTermination(
@@ -420,7 +420,10 @@ func (p *pods) summarize(ctx *actor.Context) map[string]agent.AgentSummary {
 			ID:             node.Name,
 			RegisteredTime: node.ObjectMeta.CreationTimestamp.Time,
 			Slots:          slotsSummary,
+			NumSlots:       int(numSlots),
+			NumUsedSlots:   curSlot,
 			NumContainers:  len(podByNode[node.Name]),
+			Enabled:        true,
 		}
 	}
 
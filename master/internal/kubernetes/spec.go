@@ -39,7 +39,7 @@ func (p *pod) configureEnvVars(
 	environment model.Environment,
 	deviceType device.Type,
 ) ([]k8sV1.EnvVar, error) {
-	for _, envVar := range environment.EnvironmentVariables.For(deviceType) {
+	for _, envVar := range tasks.TaskEnvVars(p.taskSpec, environment, deviceType) {
 		envVarSplit := strings.Split(envVar, "=")
 		if len(envVarSplit) != 2 {
 			return nil, errors.Errorf("unable to split envVar %s", envVar)
@@ -186,9 +186,12 @@ func (p *pod) createPodSpecForTrial(ctx *actor.Context) error {
 		deviceType = device.GPU
 	}
 
-	runArchives := tasks.TrialArchives(p.taskSpec)
+	runArchives, err := tasks.TrialArchives(p.taskSpec)
+	if err != nil {
+		return err
+	}
 	initContainerVolumeMounts, volumeMounts, volumes := p.configureVolumes(
-		ctx, tasks.TrialDockerMounts(exp), runArchives)
+		ctx, tasks.TrialDockerMounts(p.taskSpec), runArchives)
 
 	p.ports = []int{
 		tasks.LocalRendezvousPort, tasks.LocalRendezvousPort + tasks.LocalRendezvousPortOffset}
@@ -243,9 +246,12 @@ func (p *pod) createPodSpecForCommand(ctx *actor.Context) error {
 		deviceType = device.GPU
 	}
 
-	runArchives := tasks.CommandArchives(p.taskSpec)
+	runArchives, err := tasks.CommandArchives(p.taskSpec)
+	if err != nil {
+		return err
+	}
 	initContainerVolumeMounts, volumeMounts, volumes := p.configureVolumes(
-		ctx, tasks.ToDockerMounts(cmd.Config.BindMounts), runArchives)
+		ctx, tasks.CommandDockerMounts(p.taskSpec), runArchives)
 
 	for _, port := range cmd.Config.Environment.Ports {
 		p.ports = append(p.ports, port)
@@ -298,9 +304,12 @@ func (p *pod) createPodSpecForGC(ctx *actor.Context) error {
 		deviceType = device.GPU
 	}
 
-	runArchives := tasks.GCArchives(p.taskSpec)
+	runArchives, err := tasks.GCArchives(p.taskSpec)
+	if err != nil {
+		return err
+	}
 	initContainerVolumeMounts, volumeMounts, volumes := p.configureVolumes(
-		ctx, tasks.GCDockerMounts(gcc), runArchives)
+		ctx, tasks.GCDockerMounts(p.taskSpec), runArchives)
 
 	envVars, err := p.configureEnvVars(
 		tasks.GCEnvVars(),
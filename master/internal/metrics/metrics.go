@@ -0,0 +1,182 @@
+// Package metrics collects gauges and counters describing the state of the master and renders
+// them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	gaugeType   = "gauge"
+	counterType = "counter"
+)
+
+type metric struct {
+	help string
+	kind string
+	// series maps the rendered labels of each series of the metric, e.g., `{actor="/agents"}`, to
+	// its value. Metrics without labels have a single series with empty labels.
+	series map[string]float64
+	// fn, if set, computes the value of the gauge each time the registry is rendered.
+	fn func() (float64, error)
+	// seriesFn, if set, computes all series of the metric each time the registry is rendered.
+	seriesFn func() ([]Series, error)
+}
+
+// Series is the value of one series of a metric, identified by its labels.
+type Series struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Registry is a set of named gauges and counters. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// Set sets the value of the named gauge, registering it if necessary.
+func (r *Registry) Set(name, help string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = &metric{help: help, kind: gaugeType, series: map[string]float64{"": value}}
+}
+
+// SetFunc registers a gauge whose value is computed by fn each time the registry is rendered.
+func (r *Registry) SetFunc(name, help string, fn func() (float64, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = &metric{help: help, kind: gaugeType, fn: fn}
+}
+
+// SetSeriesFunc registers a gauge whose series are computed by fn each time the registry is
+// rendered.
+func (r *Registry) SetSeriesFunc(name, help string, fn func() ([]Series, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = &metric{help: help, kind: gaugeType, seriesFn: fn}
+}
+
+// SetCounterSeriesFunc registers a counter whose series are computed by fn each time the registry
+// is rendered. The source of the counts must never decrease them.
+func (r *Registry) SetCounterSeriesFunc(name, help string, fn func() ([]Series, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = &metric{help: help, kind: counterType, seriesFn: fn}
+}
+
+// Inc increments the series of the named counter with the given labels, registering it if
+// necessary.
+func (r *Registry) Inc(name, help string, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.metrics[name]
+	if !ok || m.kind != counterType || m.seriesFn != nil {
+		m = &metric{help: help, kind: counterType, series: make(map[string]float64)}
+		r.metrics[name] = m
+	}
+	m.series[renderLabels(labels)]++
+}
+
+// Add adds delta to the series of the named gauge with the given labels, registering it if
+// necessary.
+func (r *Registry) Add(name, help string, labels map[string]string, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.metrics[name]
+	if !ok || m.kind != gaugeType || m.fn != nil || m.seriesFn != nil {
+		m = &metric{help: help, kind: gaugeType, series: make(map[string]float64)}
+		r.metrics[name] = m
+	}
+	m.series[renderLabels(labels)] += delta
+}
+
+// renderLabels renders labels, sorted by name, as they appear after the name of a series.
+func renderLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	escaper := strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escaper.Replace(labels[name])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// WriteText renders all metrics, sorted by name, in the Prometheus text exposition format. Metrics
+// whose values cannot be computed are left out.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	metrics := make(map[string]metric, len(r.metrics))
+	for name, m := range r.metrics {
+		names = append(names, name)
+		copied := *m
+		copied.series = make(map[string]float64, len(m.series))
+		for labels, value := range m.series {
+			copied.series[labels] = value
+		}
+		metrics[name] = copied
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := metrics[name]
+		if m.fn != nil {
+			value, err := m.fn()
+			if err != nil {
+				log.WithError(err).Warnf("failed to compute metric %s", name)
+				continue
+			}
+			m.series[""] = value
+		}
+		if m.seriesFn != nil {
+			computed, err := m.seriesFn()
+			if err != nil {
+				log.WithError(err).Warnf("failed to compute metric %s", name)
+				continue
+			}
+			for _, s := range computed {
+				m.series[renderLabels(s.Labels)] = s.Value
+			}
+		}
+		help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(m.help)
+		if _, err := fmt.Fprintf(
+			w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, m.kind,
+		); err != nil {
+			return err
+		}
+		series := make([]string, 0, len(m.series))
+		for labels := range m.series {
+			series = append(series, labels)
+		}
+		sort.Strings(series)
+		for _, labels := range series {
+			if _, err := fmt.Fprintf(w, "%s%s %v\n", name, labels, m.series[labels]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
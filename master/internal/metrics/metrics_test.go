@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	r.Set("det_b", "Second gauge.", 2.5)
+	r.Set("det_a", "First gauge\nwith a newline.", 1)
+	r.SetFunc("det_c", "Computed gauge.", func() (float64, error) { return 3, nil })
+	r.SetFunc("det_d", "Failing gauge.", func() (float64, error) {
+		return 0, errors.New("unavailable")
+	})
+
+	var buf bytes.Buffer
+	assert.NilError(t, r.WriteText(&buf))
+	assert.Equal(t, buf.String(), `# HELP det_a First gauge\nwith a newline.
+# TYPE det_a gauge
+det_a 1
+# HELP det_b Second gauge.
+# TYPE det_b gauge
+det_b 2.5
+# HELP det_c Computed gauge.
+# TYPE det_c gauge
+det_c 3
+`)
+}
+
+func TestSetReplacesValue(t *testing.T) {
+	r := NewRegistry()
+	r.Set("det_a", "A gauge.", 1)
+	r.Set("det_a", "A gauge.", 2)
+
+	var buf bytes.Buffer
+	assert.NilError(t, r.WriteText(&buf))
+	assert.Equal(t, buf.String(), "# HELP det_a A gauge.\n# TYPE det_a gauge\ndet_a 2\n")
+}
+
+func TestInc(t *testing.T) {
+	r := NewRegistry()
+	r.Inc("det_a_total", "A counter.", map[string]string{"actor": "/agents"})
+	r.Inc("det_a_total", "A counter.", map[string]string{"actor": "/agents"})
+	r.Inc("det_a_total", "A counter.", map[string]string{"actor": `/a"b`})
+	r.Inc("det_b_total", "Unlabeled counter.", nil)
+
+	var buf bytes.Buffer
+	assert.NilError(t, r.WriteText(&buf))
+	assert.Equal(t, buf.String(), `# HELP det_a_total A counter.
+# TYPE det_a_total counter
+det_a_total{actor="/a\"b"} 1
+det_a_total{actor="/agents"} 2
+# HELP det_b_total Unlabeled counter.
+# TYPE det_b_total counter
+det_b_total 1
+`)
+}
+
+func TestAdd(t *testing.T) {
+	r := NewRegistry()
+	r.Add("det_a", "A gauge.", map[string]string{"group": "logs"}, 1)
+	r.Add("det_a", "A gauge.", map[string]string{"group": "logs"}, 1)
+	r.Add("det_a", "A gauge.", map[string]string{"group": "metrics"}, 1)
+	r.Add("det_a", "A gauge.", map[string]string{"group": "metrics"}, -1)
+
+	var buf bytes.Buffer
+	assert.NilError(t, r.WriteText(&buf))
+	assert.Equal(t, buf.String(), `# HELP det_a A gauge.
+# TYPE det_a gauge
+det_a{group="logs"} 2
+det_a{group="metrics"} 0
+`)
+}
+
+func TestSetSeriesFunc(t *testing.T) {
+	r := NewRegistry()
+	r.SetSeriesFunc("det_a", "Computed gauge.", func() ([]Series, error) {
+		return []Series{
+			{Labels: map[string]string{"type": "trial"}, Value: 3},
+			{Labels: map[string]string{"type": "agent"}, Value: 1},
+		}, nil
+	})
+	r.SetCounterSeriesFunc("det_b_total", "Computed counter.", func() ([]Series, error) {
+		return []Series{{Labels: map[string]string{"type": "trial"}, Value: 2}}, nil
+	})
+	r.SetSeriesFunc("det_c", "Failing gauge.", func() ([]Series, error) {
+		return nil, errors.New("unavailable")
+	})
+
+	var buf bytes.Buffer
+	assert.NilError(t, r.WriteText(&buf))
+	assert.Equal(t, buf.String(), `# HELP det_a Computed gauge.
+# TYPE det_a gauge
+det_a{type="agent"} 1
+det_a{type="trial"} 3
+# HELP det_b_total Computed counter.
+# TYPE det_b_total counter
+det_b_total{type="trial"} 2
+`)
+}
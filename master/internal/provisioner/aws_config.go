@@ -21,11 +21,11 @@ type AWSClusterConfig struct {
 	RootVolumeSize int    `json:"root_volume_size"`
 	ImageID        string `json:"image_id"`
 
-	TagKey       string `json:"tag_key"`
+	TagKey       string `json:"tag_key" secret:"false"`
 	TagValue     string `json:"tag_value"`
 	InstanceName string `json:"instance_name"`
 
-	SSHKeyName            string              `json:"ssh_key_name"`
+	SSHKeyName            string              `json:"ssh_key_name" secret:"false"`
 	NetworkInterface      ec2NetworkInterface `json:"network_interface"`
 	IamInstanceProfileArn string              `json:"iam_instance_profile_arn"`
 
@@ -164,7 +164,7 @@ type ec2NetworkInterface struct {
 }
 
 type ec2Tag struct {
-	Key   string `json:"key"`
+	Key   string `json:"key" secret:"false"`
 	Value string `json:"value"`
 }
 
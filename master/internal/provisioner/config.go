@@ -42,6 +42,14 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 	}
 }
 
+// JSONSchema implements the jsonschema.Schemer interface.
+func (d Duration) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "a duration such as 30s, 20m or 1h",
+	}
+}
+
 // Config describes config for provisioner.
 type Config struct {
 	MasterURL              string            `json:"master_url"`
@@ -56,6 +64,7 @@ type Config struct {
 	GCP                    *GCPClusterConfig `union:"provider,gcp" json:"-"`
 	MaxIdleAgentPeriod     Duration          `json:"max_idle_agent_period"`
 	MaxAgentStartingPeriod Duration          `json:"max_agent_starting_period"`
+	ScaleDownDelay         Duration          `json:"scale_down_delay"`
 	MinInstances           int               `json:"min_instances"`
 	MaxInstances           int               `json:"max_instances"`
 }
@@ -111,6 +120,8 @@ func (c Config) Validate() []error {
 			int64(c.MaxIdleAgentPeriod), int64(0), "max idle agent period must be greater than 0"),
 		check.GreaterThan(
 			int64(c.MaxAgentStartingPeriod), int64(0), "max agent starting period must be greater than 0"),
+		check.GreaterThanOrEqualTo(int64(c.ScaleDownDelay), int64(0),
+			"scale down delay must be greater than or equal to 0"),
 		check.GreaterThanOrEqualTo(int64(c.MinInstances), int64(0),
 			"min instance must be greater than or equal to 0"),
 		check.GreaterThan(int64(c.MaxInstances), int64(0), "max instance must be greater than 0"),
@@ -31,7 +31,7 @@ type GCPClusterConfig struct {
 	BootDiskSize        int    `json:"boot_disk_size"`
 	BootDiskSourceImage string `json:"boot_disk_source_image"`
 
-	LabelKey   string `json:"label_key"`
+	LabelKey   string `json:"label_key" secret:"false"`
 	LabelValue string `json:"label_value"`
 	NamePrefix string `json:"name_prefix"`
 
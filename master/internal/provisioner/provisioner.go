@@ -6,6 +6,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/events"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
@@ -67,6 +68,7 @@ func New(resourcePool string, config *Config, cert *tls.Certificate) (*Provision
 			time.Duration(config.MaxIdleAgentPeriod),
 			time.Duration(config.MaxAgentStartingPeriod),
 			maxDisconnectPeriod,
+			time.Duration(config.ScaleDownDelay),
 			config.MinInstances,
 			config.MaxInstances,
 		),
@@ -117,11 +119,22 @@ func (p *Provisioner) provision(ctx *actor.Context) {
 		ctx.Log().Infof("decided to terminate %d instances: %s",
 			len(toTerminate.InstanceIDs), toTerminate.String())
 		p.provider.terminate(ctx, toTerminate.InstanceIDs)
+		events.Publish(ctx.Self().System(), events.ResourcePoolScaled, map[string]interface{}{
+			"resource_pool": ctx.Self().Parent().Address().Local(),
+			"action":        "terminate",
+			"instance_ids":  toTerminate.InstanceIDs,
+		})
 	}
 
 	if numToLaunch := p.scaleDecider.calculateNumInstancesToLaunch(); numToLaunch > 0 {
 		ctx.Log().Infof("decided to launch %d instances (type %s)",
 			numToLaunch, p.provider.instanceType().name())
 		p.provider.launch(ctx, numToLaunch)
+		events.Publish(ctx.Self().System(), events.ResourcePoolScaled, map[string]interface{}{
+			"resource_pool": ctx.Self().Parent().Address().Local(),
+			"action":        "launch",
+			"num_instances": numToLaunch,
+			"instance_type": p.provider.instanceType().name(),
+		})
 	}
 }
@@ -54,6 +54,7 @@ func newMockEnvironment(t *testing.T, setup *mockConfig) *mockEnvironment {
 			time.Duration(setup.MaxIdleAgentPeriod),
 			time.Duration(setup.MaxAgentStartingPeriod),
 			setup.maxDisconnectPeriod,
+			time.Duration(setup.ScaleDownDelay),
 			setup.MinInstances,
 			setup.MaxInstances,
 		),
@@ -28,9 +28,15 @@ type scaleDecider struct {
 	maxIdlePeriod       time.Duration
 	maxStartingPeriod   time.Duration
 	maxDisconnectPeriod time.Duration
+	scaleDownDelay      time.Duration
 	minInstanceNum      int
 	maxInstanceNum      int
 
+	// lastScaleUpDemand is when the scheduler last asked for more instances. Idle instances are
+	// not terminated until scaleDownDelay after it, so that bursty workloads don't make the pool
+	// terminate instances only to launch them again shortly after.
+	lastScaleUpDemand time.Time
+
 	instanceSnapshot       map[string]*Instance
 	connectedAgentSnapshot map[string]sproto.AgentSummary
 	idleAgentSnapshot      map[string]sproto.AgentSummary
@@ -48,7 +54,7 @@ type scaleDecider struct {
 
 func newScaleDecider(
 	maxIdlePeriod, maxStartingPeriod,
-	maxDisconnectPeriod time.Duration,
+	maxDisconnectPeriod, scaleDownDelay time.Duration,
 	minInstanceNum int,
 	maxInstanceNum int,
 ) *scaleDecider {
@@ -56,6 +62,7 @@ func newScaleDecider(
 		maxStartingPeriod:      maxStartingPeriod,
 		maxIdlePeriod:          maxIdlePeriod,
 		maxDisconnectPeriod:    maxDisconnectPeriod,
+		scaleDownDelay:         scaleDownDelay,
 		minInstanceNum:         minInstanceNum,
 		maxInstanceNum:         maxInstanceNum,
 		instanceSnapshot:       make(map[string]*Instance),
@@ -74,6 +81,9 @@ func newScaleDecider(
 
 func (s *scaleDecider) updateScalingInfo(info *sproto.ScalingInfo) {
 	s.desiredNewInstances = info.DesiredNewInstances
+	if info.DesiredNewInstances > 0 {
+		s.lastScaleUpDemand = time.Now()
+	}
 	s.idleAgentSnapshot = make(map[string]sproto.AgentSummary)
 	s.connectedAgentSnapshot = make(map[string]sproto.AgentSummary)
 	for _, agent := range info.Agents {
@@ -180,9 +190,11 @@ func (s *scaleDecider) findInstancesToTerminate() sproto.TerminateDecision {
 		delete(s.disconnected, id)
 	}
 
-	// Terminate instances that are idle for a long time.
+	// Terminate instances that are idle for a long time, unless more instances were needed
+	// recently.
+	scalingDown := !time.Now().Before(s.lastScaleUpDemand.Add(s.scaleDownDelay))
 	for id := range s.longIdle {
-		if len(s.instances)-len(toTerminate) > s.minInstanceNum {
+		if scalingDown && len(s.instances)-len(toTerminate) > s.minInstanceNum {
 			toTerminate[id] = sproto.TerminateLongIdleInstances
 			delete(s.idle, id)
 		} else {
@@ -200,6 +200,28 @@ func TestFindInstancesToTerminate(t *testing.T) {
 			},
 			toTerminate: []string{"long idle"},
 		},
+		{
+			name: "don't terminate long idle within the scale down delay",
+			scaleDecider: scaleDecider{
+				instances:         map[string]*Instance{"long idle": {}},
+				longIdle:          map[string]bool{"long idle": true},
+				maxInstanceNum:    10,
+				scaleDownDelay:    time.Hour,
+				lastScaleUpDemand: time.Now().Add(-time.Minute),
+			},
+			toTerminate: []string{},
+		},
+		{
+			name: "terminate long idle after the scale down delay",
+			scaleDecider: scaleDecider{
+				instances:         map[string]*Instance{"long idle": {}},
+				longIdle:          map[string]bool{"long idle": true},
+				maxInstanceNum:    10,
+				scaleDownDelay:    time.Minute,
+				lastScaleUpDemand: time.Now().Add(-time.Hour),
+			},
+			toTerminate: []string{"long idle"},
+		},
 		{
 			name: "terminate long disconnected",
 			scaleDecider: scaleDecider{
@@ -397,3 +419,16 @@ func TestCalculateNumInstancesToLaunch(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateScalingInfoRecordsScaleUpDemand(t *testing.T) {
+	s := newScaleDecider(time.Minute, time.Minute, time.Minute, time.Minute, 0, 10)
+	s.updateScalingInfo(&sproto.ScalingInfo{DesiredNewInstances: 0})
+	assert.Assert(t, s.lastScaleUpDemand.IsZero())
+
+	s.updateScalingInfo(&sproto.ScalingInfo{DesiredNewInstances: 2})
+	demand := s.lastScaleUpDemand
+	assert.Assert(t, !demand.IsZero())
+
+	s.updateScalingInfo(&sproto.ScalingInfo{DesiredNewInstances: 0})
+	assert.Equal(t, s.lastScaleUpDemand, demand)
+}
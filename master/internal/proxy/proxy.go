@@ -10,9 +10,9 @@ import (
 	"time"
 
 	"github.com/labstack/echo"
-	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 // Proxy-specific actor messages.
@@ -22,6 +22,9 @@ type (
 	Register struct {
 		ServiceID string
 		URL       *url.URL
+		// Owner is the user who may open CONNECT tunnels to the service, along with admins. Any
+		// user may open tunnels to services without an owner.
+		Owner *model.UserID
 	}
 	// Unregister removes the service from the proxy. All future requests until the service name is
 	// registered again will be responded with a 404 response. If the service is not registered with
@@ -31,11 +34,21 @@ type (
 	// running in the cluster.
 	NewProxyHandler struct{ ServiceID string }
 	// NewConnectHandler returns a middleware function for tunneling TCP-like traffic (via HTTP
-	// CONNECT) to services running in the cluster.
-	NewConnectHandler struct{}
+	// CONNECT) to services running in the cluster. Requests must be authenticated, and CONNECT
+	// requests for anything but a registered service are forbidden.
+	NewConnectHandler struct {
+		// User returns the authenticated user of a request.
+		User func(c echo.Context) model.User
+		// IdleTimeout closes tunnels that copy no traffic for this long, if it is positive.
+		IdleTimeout time.Duration
+		// MaxDuration closes tunnels that are open for this long, if it is positive.
+		MaxDuration time.Duration
+	}
 
 	// GetSummary returns a snapshot of the registered services.
 	GetSummary struct{}
+	// GetTunnels returns a snapshot of the active CONNECT tunnels, ordered by ID.
+	GetTunnels struct{}
 )
 
 // Service represents a registered service. The LastRequested field is used by
@@ -43,12 +56,16 @@ type (
 type Service struct {
 	URL           *url.URL
 	LastRequested time.Time
+	Owner         *model.UserID
 }
 
 // Proxy is an actor that proxies requests to registered services.
 type Proxy struct {
 	lock     sync.RWMutex
 	services map[string]*Service
+
+	tunnels      map[int]*tunnel
+	lastTunnelID int
 }
 
 // Receive implements the actor.Actor interface.
@@ -56,6 +73,7 @@ func (p *Proxy) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
 		p.services = make(map[string]*Service)
+		p.tunnels = make(map[int]*tunnel)
 	case Register:
 		if msg.ServiceID == "" {
 			return nil
@@ -63,7 +81,7 @@ func (p *Proxy) Receive(ctx *actor.Context) error {
 		p.lock.Lock()
 		defer p.lock.Unlock()
 		ctx.Log().Infof("registering service: %s (%v)", msg.ServiceID, msg.URL)
-		p.services[msg.ServiceID] = &Service{msg.URL, time.Now()}
+		p.services[msg.ServiceID] = &Service{msg.URL, time.Now(), msg.Owner}
 
 		if ctx.ExpectingResponse() {
 			ctx.Respond(nil)
@@ -75,9 +93,11 @@ func (p *Proxy) Receive(ctx *actor.Context) error {
 	case NewProxyHandler:
 		ctx.Respond(p.newProxyHandler(msg.ServiceID))
 	case NewConnectHandler:
-		ctx.Respond(p.newConnectHandler())
+		ctx.Respond(p.newConnectHandler(msg))
 	case GetSummary:
 		ctx.Respond(p.getSummary())
+	case GetTunnels:
+		ctx.Respond(p.getTunnels())
 	case actor.PostStop:
 		p.lock.Lock()
 		defer p.lock.Unlock()
@@ -88,6 +108,14 @@ func (p *Proxy) Receive(ctx *actor.Context) error {
 }
 
 func (p *Proxy) getTargetURL(serviceName string) *url.URL {
+	if service := p.getService(serviceName); service != nil {
+		return service.URL
+	}
+	return nil
+}
+
+// getService returns a copy of the registered service, or nil if there is none.
+func (p *Proxy) getService(serviceName string) *Service {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	service := p.services[serviceName]
@@ -98,7 +126,7 @@ func (p *Proxy) getTargetURL(serviceName string) *url.URL {
 	// Make a copy to avoid callers mutating the url outside of this locked
 	// method.
 	sURL := *service.URL
-	return &sURL
+	return &Service{URL: &sURL, LastRequested: service.LastRequested, Owner: service.Owner}
 }
 
 // Service a normal (non-CONNECT) HTTP request through the /proxy/:service/* route.
@@ -137,23 +165,33 @@ func (p *Proxy) newProxyHandler(serviceID string) echo.HandlerFunc {
 	}
 }
 
-// Service an HTTP CONNECT request, which have a hostname:port in place of a normal route.
-func (p *Proxy) newConnectHandler() echo.HandlerFunc {
+// Service an HTTP CONNECT request, which have a hostname:port in place of a normal route. The
+// hostname is the ID of a registered service, and the traffic is always sent to the address that
+// the service registered, so tunnels never reach anything else.
+func (p *Proxy) newConnectHandler(msg NewConnectHandler) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		// Parse the request-target, which must be in hostname[:port] format, per RFC 7231.
 		u, err := url.Parse("tcp://" + c.Request().RequestURI)
 		if err != nil {
-			return errors.Wrap(err, "failed to parse host for CONNECT")
+			return echo.NewHTTPError(http.StatusBadRequest, "failed to parse host for CONNECT")
 		}
 		serviceName := u.Hostname()
 
-		target := p.getTargetURL(serviceName)
-		if target == nil {
-			return echo.NewHTTPError(http.StatusNotFound,
-				fmt.Sprintf("service not found: %s", serviceName))
+		user := msg.User(c)
+		service := p.getService(serviceName)
+		switch {
+		case service == nil:
+			return echo.NewHTTPError(http.StatusForbidden,
+				fmt.Sprintf("CONNECT is only allowed to registered services, not %s", serviceName))
+		case service.Owner != nil && *service.Owner != user.ID && !user.Admin:
+			return echo.NewHTTPError(http.StatusForbidden,
+				fmt.Sprintf("user %s may not connect to service %s", user.Username, serviceName))
 		}
 
-		proxy := newSingleHostReverseTCPProxy(c, target)
+		t := p.openTunnel(serviceName, user.Username, c.Request().RemoteAddr, service.URL.Host)
+		defer p.closeTunnel(t)
+
+		proxy := newSingleHostReverseTCPProxy(c, service.URL, t, msg.IdleTimeout, msg.MaxDuration)
 
 		proxy.ServeHTTP(c.Response(), c.Request())
 
@@ -168,7 +206,7 @@ func (p *Proxy) getSummary() map[string]Service {
 
 	for id, service := range p.services {
 		sURL := *service.URL
-		snapshot[id] = Service{&sURL, service.LastRequested}
+		snapshot[id] = Service{&sURL, service.LastRequested, service.Owner}
 	}
 
 	return snapshot
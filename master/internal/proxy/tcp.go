@@ -4,12 +4,18 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
 )
 
-func newSingleHostReverseTCPProxy(c echo.Context, t *url.URL) http.Handler {
+// newSingleHostReverseTCPProxy copies traffic between the client and the target until either side
+// closes its connection, or the tunnel copies no traffic for idleTimeout or is open for
+// maxDuration, if they are positive.
+func newSingleHostReverseTCPProxy(
+	c echo.Context, t *url.URL, tun *tunnel, idleTimeout, maxDuration time.Duration,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Make sure we can open the connection to the remote host.
 		out, err := net.Dial("tcp", t.Host)
@@ -39,14 +45,69 @@ func newSingleHostReverseTCPProxy(c echo.Context, t *url.URL) http.Handler {
 			}
 		}()
 
-		copyReqErr := asyncCopy(out, in)
-		copyResErr := asyncCopy(in, out)
+		done := make(chan struct{})
+		watched := watchTunnel(tun, idleTimeout, maxDuration, done, func() {
+			_ = in.Close()
+			_ = out.Close()
+		})
 
-		if cerr := <-copyReqErr; cerr != nil {
-			c.Logger().Errorf("error copying request body for %v: %v", t, cerr)
+		copyReqErr := asyncCopy(countingWriter{out, &tun.bytesSent, tun}, in)
+		copyResErr := asyncCopy(countingWriter{in, &tun.bytesReceived, tun}, out)
+
+		reqErr, resErr := <-copyReqErr, <-copyResErr
+		close(done)
+		<-watched
+		if tun.closeReason != closedByPeer {
+			// The errors are those of reading from the connections closed by the watchdog.
+			return
 		}
-		if cerr := <-copyResErr; cerr != nil {
-			c.Logger().Errorf("error copying response body for %v: %v", t, cerr)
+		if reqErr != nil {
+			c.Logger().Errorf("error copying request body for %v: %v", t, reqErr)
+		}
+		if resErr != nil {
+			c.Logger().Errorf("error copying response body for %v: %v", t, resErr)
 		}
 	})
 }
+
+// watchTunnel calls closeConns, and records why in the tunnel, once it is idle for idleTimeout or
+// open for maxDuration, if they are positive, or until done is closed. The returned channel is
+// closed once it stops watching.
+func watchTunnel(
+	tun *tunnel, idleTimeout, maxDuration time.Duration, done <-chan struct{}, closeConns func(),
+) <-chan struct{} {
+	watched := make(chan struct{})
+	if idleTimeout <= 0 && maxDuration <= 0 {
+		close(watched)
+		return watched
+	}
+	interval := time.Second
+	for _, timeout := range []time.Duration{idleTimeout, maxDuration} {
+		if timeout > 0 && timeout/4 < interval {
+			interval = timeout / 4
+		}
+	}
+	go func() {
+		defer close(watched)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				switch {
+				case maxDuration > 0 && now.Sub(tun.StartTime) >= maxDuration:
+					tun.closeReason = closedByMaxDuration
+				case idleTimeout > 0 && tun.idle(now) >= idleTimeout:
+					tun.closeReason = closedByIdleTimeout
+				default:
+					continue
+				}
+				closeConns()
+				return
+			}
+		}
+	}()
+	return watched
+}
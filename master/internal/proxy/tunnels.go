@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"io"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Tunnel describes an active CONNECT tunnel to a service.
+type Tunnel struct {
+	ID          int       `json:"id"`
+	ServiceID   string    `json:"service_id"`
+	Username    string    `json:"username"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	StartTime   time.Time `json:"start_time"`
+	// AgeSeconds is the number of seconds that the tunnel has been open.
+	AgeSeconds float64 `json:"age_seconds"`
+	// BytesSent is the number of bytes copied from the client to the service.
+	BytesSent int64 `json:"bytes_sent"`
+	// BytesReceived is the number of bytes copied from the service to the client.
+	BytesReceived int64 `json:"bytes_received"`
+}
+
+// The reasons for which tunnels are closed, as recorded in the audit log.
+const (
+	closedByPeer        = "closed"
+	closedByIdleTimeout = "idle_timeout"
+	closedByMaxDuration = "max_duration"
+)
+
+// tunnel tracks the byte counts of an active tunnel and when it last copied traffic. The counters
+// are updated by the goroutines copying the traffic, so they are only accessed atomically.
+type tunnel struct {
+	Tunnel
+	bytesSent     int64
+	bytesReceived int64
+	// lastActive is the time in Unix nanoseconds at which traffic was last copied.
+	lastActive  int64
+	closeReason string
+}
+
+func (t *tunnel) idle(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, atomic.LoadInt64(&t.lastActive)))
+}
+
+func (t *tunnel) snapshot(now time.Time) Tunnel {
+	snapshot := t.Tunnel
+	snapshot.AgeSeconds = now.Sub(t.StartTime).Seconds()
+	snapshot.BytesSent = atomic.LoadInt64(&t.bytesSent)
+	snapshot.BytesReceived = atomic.LoadInt64(&t.bytesReceived)
+	return snapshot
+}
+
+// countingWriter adds the number of bytes written through it to a counter and marks the tunnel as
+// active.
+type countingWriter struct {
+	w     io.Writer
+	count *int64
+	t     *tunnel
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddInt64(c.count, int64(n))
+	atomic.StoreInt64(&c.t.lastActive, time.Now().UnixNano())
+	return n, err
+}
+
+// openTunnel records a new tunnel of the user from source to the service at destination.
+func (p *Proxy) openTunnel(serviceID, username, source, destination string) *tunnel {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.lastTunnelID++
+	now := time.Now()
+	t := &tunnel{
+		Tunnel: Tunnel{
+			ID:          p.lastTunnelID,
+			ServiceID:   serviceID,
+			Username:    username,
+			Source:      source,
+			Destination: destination,
+			StartTime:   now,
+		},
+		lastActive:  now.UnixNano(),
+		closeReason: closedByPeer,
+	}
+	p.tunnels[t.ID] = t
+	log.WithFields(log.Fields{
+		"tunnel_id":   t.ID,
+		"user":        username,
+		"service_id":  serviceID,
+		"source":      source,
+		"destination": destination,
+	}).Info("opened tunnel")
+	return t
+}
+
+// closeTunnel forgets the tunnel and records it in the audit log.
+func (p *Proxy) closeTunnel(t *tunnel) {
+	p.lock.Lock()
+	delete(p.tunnels, t.ID)
+	p.lock.Unlock()
+
+	snapshot := t.snapshot(time.Now())
+	log.WithFields(log.Fields{
+		"tunnel_id":      snapshot.ID,
+		"user":           snapshot.Username,
+		"service_id":     snapshot.ServiceID,
+		"source":         snapshot.Source,
+		"destination":    snapshot.Destination,
+		"duration":       time.Duration(snapshot.AgeSeconds * float64(time.Second)).String(),
+		"bytes_sent":     snapshot.BytesSent,
+		"bytes_received": snapshot.BytesReceived,
+		"reason":         t.closeReason,
+	}).Info("closed tunnel")
+}
+
+func (p *Proxy) getTunnels() []Tunnel {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	now := time.Now()
+	tunnels := make([]Tunnel, 0, len(p.tunnels))
+	for _, t := range p.tunnels {
+		tunnels = append(tunnels, t.snapshot(now))
+	}
+	sort.Slice(tunnels, func(i, j int) bool { return tunnels[i].ID < tunnels[j].ID })
+	return tunnels
+}
@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+var (
+	testOwner = model.User{ID: 1, Username: "owner"}
+	testOther = model.User{ID: 2, Username: "other"}
+	testAdmin = model.User{ID: 3, Username: "admin", Admin: true}
+)
+
+// newTestConnectServer serves the CONNECT handler of the proxy, as the user named by the User
+// header of each request.
+func newTestConnectServer(
+	system *actor.System, ref *actor.Ref, msg NewConnectHandler,
+) *httptest.Server {
+	users := map[string]model.User{}
+	for _, user := range []model.User{testOwner, testOther, testAdmin} {
+		users[user.Username] = user
+	}
+	msg.User = func(c echo.Context) model.User {
+		return users[c.Request().Header.Get("User")]
+	}
+	e := echo.New()
+	e.CONNECT("*", system.Ask(ref, msg).Get().(echo.HandlerFunc))
+	return httptest.NewServer(e)
+}
+
+// connect sends a CONNECT request for the service as the user to the server.
+func connect(
+	t *testing.T, server *httptest.Server, service, username string,
+) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	assert.NilError(t, err)
+	_, err = fmt.Fprintf(conn, "CONNECT %[1]s:80 HTTP/1.1\r\nHost: %[1]s:80\r\nUser: %[2]s\r\n\r\n",
+		service, username)
+	assert.NilError(t, err)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, &http.Request{Method: http.MethodConnect})
+	assert.NilError(t, err)
+	return conn, reader, resp
+}
+
+func TestConnectTunnels(t *testing.T) {
+	// The service echoes the first 5 bytes that it receives.
+	service, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer service.Close()
+	go func() {
+		conn, aerr := service.Accept()
+		if aerr != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.CopyN(conn, conn, 5)
+	}()
+
+	system := actor.NewSystem(t.Name())
+	ref, _ := system.ActorOf(actor.Addr("proxy"), &Proxy{})
+	serviceURL := &url.URL{Scheme: "tcp", Host: service.Addr().String()}
+	system.Ask(ref, Register{ServiceID: "service", URL: serviceURL, Owner: &testOwner.ID}).Get()
+
+	server := newTestConnectServer(system, ref, NewConnectHandler{})
+	defer server.Close()
+
+	conn, reader, resp := connect(t, server, "service", testOwner.Username)
+	defer conn.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	_, err = conn.Write([]byte("hello"))
+	assert.NilError(t, err)
+	echoed := make([]byte, 5)
+	_, err = io.ReadFull(reader, echoed)
+	assert.NilError(t, err)
+	assert.Equal(t, string(echoed), "hello")
+
+	// The received bytes are counted after they are written to the client, so wait for them.
+	var tunnels []Tunnel
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		tunnels = system.Ask(ref, GetTunnels{}).Get().([]Tunnel)
+		if len(tunnels) == 1 && tunnels[0].BytesReceived == 5 {
+			break
+		}
+	}
+	assert.Equal(t, len(tunnels), 1)
+	assert.Equal(t, tunnels[0].ServiceID, "service")
+	assert.Equal(t, tunnels[0].Username, testOwner.Username)
+	assert.Equal(t, tunnels[0].Source, conn.LocalAddr().String())
+	assert.Equal(t, tunnels[0].Destination, service.Addr().String())
+	assert.Equal(t, tunnels[0].BytesSent, int64(5))
+	assert.Equal(t, tunnels[0].BytesReceived, int64(5))
+
+	assert.NilError(t, conn.Close())
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if tunnels = system.Ask(ref, GetTunnels{}).Get().([]Tunnel); len(tunnels) == 0 {
+			break
+		}
+	}
+	assert.Equal(t, len(tunnels), 0)
+}
+
+func TestConnectForbidden(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	ref, _ := system.ActorOf(actor.Addr("proxy"), &Proxy{})
+	// Nothing listens at the address of the service, so allowed requests fail to dial it.
+	serviceURL := &url.URL{Scheme: "tcp", Host: "127.0.0.1:1"}
+	system.Ask(ref, Register{ServiceID: "service", URL: serviceURL, Owner: &testOwner.ID}).Get()
+
+	server := newTestConnectServer(system, ref, NewConnectHandler{})
+	defer server.Close()
+
+	for _, tc := range []struct {
+		service, username string
+		status            int
+	}{
+		{"service", testOwner.Username, http.StatusBadGateway},
+		{"service", testAdmin.Username, http.StatusBadGateway},
+		{"service", testOther.Username, http.StatusForbidden},
+		{"127.0.0.1", testOwner.Username, http.StatusForbidden},
+		{"unknown", testAdmin.Username, http.StatusForbidden},
+	} {
+		conn, _, resp := connect(t, server, tc.service, tc.username)
+		assert.Equal(t, resp.StatusCode, tc.status, "%s connecting to %s", tc.username, tc.service)
+		assert.NilError(t, conn.Close())
+	}
+}
+
+func TestConnectIdleTimeout(t *testing.T) {
+	// The service never sends anything, so the tunnel is idle once the client stops sending.
+	service, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer service.Close()
+	go func() {
+		conn, aerr := service.Accept()
+		if aerr != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(ioutil.Discard, conn)
+	}()
+
+	system := actor.NewSystem(t.Name())
+	ref, _ := system.ActorOf(actor.Addr("proxy"), &Proxy{})
+	serviceURL := &url.URL{Scheme: "tcp", Host: service.Addr().String()}
+	system.Ask(ref, Register{ServiceID: "service", URL: serviceURL}).Get()
+
+	server := newTestConnectServer(system, ref, NewConnectHandler{
+		IdleTimeout: 200 * time.Millisecond,
+		MaxDuration: time.Minute,
+	})
+	defer server.Close()
+
+	conn, reader, resp := connect(t, server, "service", testOther.Username)
+	defer conn.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	_, err = conn.Write([]byte("hello"))
+	assert.NilError(t, err)
+
+	// The master closes the tunnel, so reading from it ends.
+	assert.NilError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = reader.ReadByte()
+	assert.Equal(t, err, io.EOF)
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if len(system.Ask(ref, GetTunnels{}).Get().([]Tunnel)) == 0 {
+			break
+		}
+	}
+	assert.Equal(t, len(system.Ask(ref, GetTunnels{}).Get().([]Tunnel)), 0)
+}
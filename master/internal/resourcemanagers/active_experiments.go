@@ -0,0 +1,62 @@
+package resourcemanagers
+
+import (
+	"sync"
+)
+
+// The reasons for which a task waits for resources, as shown in its summary.
+const (
+	// PendingMaxActiveExperiments is the reason of the tasks of experiments that are held back
+	// because max_active_experiments experiments already hold resources.
+	PendingMaxActiveExperiments = "max_active_experiments"
+	// PendingInsufficientSlots is the reason of the tasks that wait for free slots.
+	PendingInsufficientSlots = "insufficient_slots"
+)
+
+// activeExperiments tracks the experiments that hold resources in each resource pool, so that the
+// pools together admit no more than max experiments. The resource pools share it and consult it
+// from their own actors, so it is guarded by a lock.
+type activeExperiments struct {
+	mu     sync.Mutex
+	max    *int
+	byPool map[string]map[int]bool
+}
+
+func newActiveExperiments(max *int) *activeExperiments {
+	return &activeExperiments{max: max, byPool: make(map[string]map[int]bool)}
+}
+
+// admit returns whether the experiment may be allocated resources in the pool and, if so, records
+// it as active in the pool until the next call to set for the pool. Experiments that are active in
+// any pool are always admitted.
+func (a *activeExperiments) admit(pool string, id int) bool {
+	if a == nil {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	active := make(map[int]bool)
+	for _, ids := range a.byPool {
+		for activeID := range ids {
+			active[activeID] = true
+		}
+	}
+	if !active[id] && a.max != nil && len(active) >= *a.max {
+		return false
+	}
+	if a.byPool[pool] == nil {
+		a.byPool[pool] = make(map[int]bool)
+	}
+	a.byPool[pool][id] = true
+	return true
+}
+
+// set replaces the active experiments of the pool with the given ones.
+func (a *activeExperiments) set(pool string, ids map[int]bool) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.byPool[pool] = ids
+}
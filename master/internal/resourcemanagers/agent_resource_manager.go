@@ -15,17 +15,27 @@ type agentResourceManager struct {
 	cert        *tls.Certificate
 
 	pools map[string]*actor.Ref
+	// activeExperiments is shared by the pools to enforce the max_active_experiments of the
+	// cluster; it is nil if the config sets none.
+	activeExperiments *activeExperiments
+	// decisions are the subscribers to the scheduling decisions of the pools.
+	decisions decisionSubscribers
 }
 
 func newAgentResourceManager(
 	config *AgentResourceManagerConfig, poolsConfig *ResourcePoolsConfig, cert *tls.Certificate,
 ) *agentResourceManager {
-	return &agentResourceManager{
+	a := &agentResourceManager{
 		config:      config,
 		poolsConfig: poolsConfig,
 		cert:        cert,
 		pools:       make(map[string]*actor.Ref),
+		decisions:   make(decisionSubscribers),
 	}
+	if config.MaxActiveExperiments != nil {
+		a.activeExperiments = newActiveExperiments(config.MaxActiveExperiments)
+	}
+	return a
 }
 
 func (a *agentResourceManager) Receive(ctx *actor.Context) error {
@@ -55,9 +65,21 @@ func (a *agentResourceManager) Receive(ctx *actor.Context) error {
 		}
 	case GetTaskSummaries:
 		ctx.Respond(a.aggregateTaskSummaries(a.forwardToAllPools(ctx, msg)))
-	case SetTaskName:
+	case GetQueuePosition:
+		// The trials of an experiment all ask for resources from the same pool.
+		for _, resp := range a.forwardToAllPools(ctx, msg) {
+			if resp != nil {
+				ctx.Respond(resp)
+				break
+			}
+		}
+	case SetTaskName, SetMaintenance, SetSchedulerPaused:
 		a.forwardToAllPools(ctx, msg)
 
+	case SchedulingDecision, SubscribeSchedulingDecisions, UnsubscribeSchedulingDecisions,
+		actor.PostStop:
+		a.decisions.receive(ctx)
+
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
 	}
@@ -85,6 +107,8 @@ func (a *agentResourceManager) createResourcePool(
 		MakeScheduler(config.Scheduler.getType()),
 		MakeFitFunction(config.Scheduler.FittingPolicy),
 	)
+	rp.activeExperiments = a.activeExperiments
+	rp.decisions = ctx.Self()
 	ref, ok := ctx.ActorOf(config.PoolName, rp)
 	if !ok {
 		ctx.Log().Errorf("cannot create resource pool actor: %s", config.PoolName)
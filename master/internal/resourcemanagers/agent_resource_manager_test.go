@@ -72,6 +72,7 @@ func TestAgentRMRoutingTaskRelatedMessages(t *testing.T) {
 	// Check the resource pools of the tasks are correct.
 	taskSummary := system.Ask(agentRMRef, GetTaskSummary{ID: &cpuTask1.id}).Get().(*TaskSummary)
 	assert.Equal(t, taskSummary.ResourcePool, cpuTask1.resourcePool)
+	assert.Equal(t, taskSummary.Group, cpuTask1Ref)
 	taskSummaries = system.Ask(agentRMRef, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
 	assert.Equal(t, taskSummaries[cpuTask1.id].ResourcePool, taskSummaries[cpuTask2.id].ResourcePool)
 
@@ -51,6 +51,37 @@ func (f *fairShare) Schedule(rp *ResourcePool) ([]*AllocateRequest, []*actor.Ref
 	return fairshareSchedule(rp.taskList, rp.groups, rp.agents, rp.fittingMethod)
 }
 
+// OrderedAllocations serves the tasks that need no slots first, since they are allocated as soon
+// as they fit, and then the groups that are furthest below their fair share, i.e., that hold the
+// fewest slots for their weight.
+func (f *fairShare) OrderedAllocations(rp *ResourcePool) []*AllocateRequest {
+	var ordered []*AllocateRequest
+	for it := rp.taskList.iterator(); it.next(); {
+		req := it.value()
+		if req.SlotsNeeded == 0 && rp.taskList.GetAllocations(req.TaskActor) == nil {
+			ordered = append(ordered, req)
+		}
+	}
+	share := func(state *groupState) float64 {
+		if state.weight <= 0 {
+			return float64(state.activeSlots)
+		}
+		return float64(state.activeSlots) / state.weight
+	}
+	pending := orderPendingRequests(rp.taskList, rp.groups, func(first, second *groupState) bool {
+		if share(first) != share(second) {
+			return share(first) < share(second)
+		}
+		return registeredBefore(first, second)
+	})
+	for _, req := range pending {
+		if req.SlotsNeeded != 0 {
+			ordered = append(ordered, req)
+		}
+	}
+	return ordered
+}
+
 func fairshareSchedule(
 	taskList *taskList,
 	groups map[*actor.Ref]*group,
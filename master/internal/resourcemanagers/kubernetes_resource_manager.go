@@ -1,6 +1,9 @@
 package resourcemanagers
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/determined-ai/determined/master/internal/sproto"
@@ -24,6 +27,17 @@ type kubernetesResourceManager struct {
 	agent *agentState
 
 	reschedule bool
+	// maintenance holds back the tasks that would start new work during maintenance.
+	maintenance maintenanceGroups
+	// paused stops the scheduler from allocating resources while it is set.
+	paused bool
+	// heldTasks are the tasks that were held back by the maintenance mode or by
+	// max_active_experiments when resources were last allocated, with the reason.
+	heldTasks map[TaskID]string
+
+	// decisions are the subscribers to the scheduling decisions of the resource manager.
+	decisions decisionSubscribers
+	denials   denials
 }
 
 func newKubernetesResourceManager(
@@ -35,6 +49,9 @@ func newKubernetesResourceManager(
 		reqList:           newTaskList(),
 		groups:            make(map[*actor.Ref]*group),
 		slotsUsedPerGroup: make(map[*group]int),
+		heldTasks:         make(map[TaskID]string),
+		decisions:         make(decisionSubscribers),
+		denials:           make(denials),
 	}
 }
 
@@ -68,18 +85,37 @@ func (k *kubernetesResourceManager) Receive(ctx *actor.Context) error {
 		ResourcesReleased:
 		return k.receiveRequestMsg(ctx)
 
+	case SetMaintenance:
+		k.maintenance = k.maintenance.setMaintenance(msg.Enabled, k.reqList)
+
+	case SetSchedulerPaused:
+		k.paused = msg.Paused
+
 	case GetTaskSummary:
-		if resp := getTaskSummary(k.reqList, *msg.ID); resp != nil {
+		if resp := getTaskSummary(k.reqList, k.heldTasks, *msg.ID); resp != nil {
 			ctx.Respond(*resp)
 		}
 		reschedule = false
 
 	case GetTaskSummaries:
 		reschedule = false
-		ctx.Respond(getTaskSummaries(k.reqList))
+		ctx.Respond(getTaskSummaries(k.reqList, k.heldTasks))
+
+	case GetQueuePosition:
+		reschedule = false
+		pending := pendingRequests(k.reqList)
+		if resp := getQueuePosition(pending, k.heldTasks, msg.ExperimentID); resp != nil {
+			ctx.Respond(*resp)
+		}
+
+	case SchedulingDecision, SubscribeSchedulingDecisions, UnsubscribeSchedulingDecisions,
+		actor.PostStop:
+		reschedule = false
+		k.decisions.receive(ctx)
 
 	case schedulerTick:
-		if k.reschedule {
+		// During a pause, pending tasks wait until it ends.
+		if k.reschedule && !k.paused {
 			k.schedulePendingTasks(ctx)
 		}
 		k.reschedule = false
@@ -100,6 +136,7 @@ func (k *kubernetesResourceManager) receiveRequestMsg(ctx *actor.Context) error
 	case groupActorStopped:
 		delete(k.slotsUsedPerGroup, k.groups[msg.Ref])
 		delete(k.groups, msg.Ref)
+		delete(k.maintenance, msg.Ref)
 
 	case sproto.SetGroupMaxSlots:
 		k.getOrCreateGroup(ctx, msg.Handler).maxSlots = msg.MaxSlots
@@ -128,6 +165,9 @@ func (k *kubernetesResourceManager) addTask(ctx *actor.Context, msg AllocateRequ
 	if len(msg.ID) == 0 {
 		msg.ID = TaskID(uuid.New().String())
 	}
+	if msg.RequestTime.IsZero() {
+		msg.RequestTime = time.Now()
+	}
 	if msg.Group == nil {
 		msg.Group = msg.TaskActor
 	}
@@ -141,6 +181,7 @@ func (k *kubernetesResourceManager) addTask(ctx *actor.Context, msg AllocateRequ
 		msg.TaskActor.Address(), msg.ID,
 	)
 	k.reqList.AddTask(&msg)
+	k.maintenance.addTask(&msg)
 }
 
 func (k *kubernetesResourceManager) receiveSetTaskName(ctx *actor.Context, msg SetTaskName) {
@@ -156,6 +197,7 @@ func (k *kubernetesResourceManager) assignResources(ctx *actor.Context, req *All
 		if k.config.MaxSlotsPerPod == 0 {
 			ctx.Log().WithField("task-id", req.ID).Error(
 				"set max_slots_per_pod > 0 to schedule tasks with slots")
+			k.decide(ctx, DecisionDenied, req, "max_slots_per_pod")
 			return
 		}
 
@@ -167,6 +209,7 @@ func (k *kubernetesResourceManager) assignResources(ctx *actor.Context, req *All
 				ctx.Log().WithField("task-id", req.ID).Errorf(
 					"task number of slots (%d) is not schedulable on the configured "+
 						"max_slots_per_pod (%d)", req.SlotsNeeded, k.config.MaxSlotsPerPod)
+				k.decide(ctx, DecisionDenied, req, "max_slots_per_pod")
 				return
 			}
 
@@ -190,15 +233,45 @@ func (k *kubernetesResourceManager) assignResources(ctx *actor.Context, req *All
 	assigned := ResourcesAllocated{ID: req.ID, Allocations: allocations}
 	k.reqList.SetAllocations(req.TaskActor, &assigned)
 	req.TaskActor.System().Tell(req.TaskActor, assigned)
+	ctx.Self().System().TellAt(sproto.AllocationRecorderAddr, sproto.AllocationStarted{
+		TaskID:       string(req.ID),
+		ResourcePool: req.ResourcePool,
+		Slots:        req.SlotsNeeded,
+		UserID:       req.UserID,
+		ExperimentID: req.ExperimentID,
+		CommandID:    req.CommandID,
+		RequestTime:  req.RequestTime,
+		Time:         time.Now(),
+	})
 
 	ctx.Log().
 		WithField("task-id", req.ID).
 		WithField("task-handler", req.TaskActor.Address()).
 		Infof("resources assigned with %d pods", numPods)
+	k.decide(ctx, DecisionAllocated, req, fmt.Sprintf("%d slots on %d pods", slotsPerPod, numPods))
+}
+
+// decide reports a scheduling decision about a task to the subscribers.
+func (k *kubernetesResourceManager) decide(
+	ctx *actor.Context, decisionType DecisionType, req *AllocateRequest, reason string,
+) {
+	decision := newDecision(req.ResourcePool, decisionType, req, reason)
+	if k.denials.record(decision) {
+		ctx.Tell(ctx.Self(), decision)
+	}
 }
 
 func (k *kubernetesResourceManager) resourcesReleased(ctx *actor.Context, handler *actor.Ref) {
 	ctx.Log().Infof("resources are released for %s", handler.Address())
+	if allocated := k.reqList.GetAllocations(handler); allocated != nil {
+		ctx.Self().System().TellAt(sproto.AllocationRecorderAddr, sproto.AllocationEnded{
+			TaskID: string(allocated.ID),
+			Time:   time.Now(),
+		})
+	}
+	if req, ok := k.reqList.GetTaskByHandler(handler); ok {
+		delete(k.denials, req.ID)
+	}
 	k.reqList.RemoveTaskByHandler(handler)
 
 	if req, ok := k.reqList.GetTaskByHandler(handler); ok {
@@ -227,19 +300,47 @@ func (k *kubernetesResourceManager) getOrCreateGroup(
 	return g
 }
 
+// schedulePendingTasks assigns resources to the tasks that wait for them in the order in which they
+// arrived, and records the tasks that are held back in heldTasks. Tasks that are not part of
+// experiments and the tasks of experiments that already hold resources are admitted regardless of
+// max_active_experiments.
 func (k *kubernetesResourceManager) schedulePendingTasks(ctx *actor.Context) {
+	admitted := make(map[int]bool)
+	for it := k.reqList.iterator(); it.next(); {
+		req := it.value()
+		if req.ExperimentID != nil && k.reqList.GetAllocations(req.TaskActor) != nil {
+			admitted[*req.ExperimentID] = true
+		}
+	}
+	k.heldTasks = make(map[TaskID]string)
 	for it := k.reqList.iterator(); it.next(); {
 		req := it.value()
 		group := k.groups[req.Group]
 		assigned := k.reqList.GetAllocations(req.TaskActor)
 		if unassigned := assigned == nil || len(assigned.Allocations) == 0; unassigned {
+			if k.maintenance.holds(req) {
+				k.heldTasks[req.ID] = PendingMaintenance
+				k.decide(ctx, DecisionDenied, req, PendingMaintenance)
+				continue
+			}
+			if id := req.ExperimentID; id != nil && !admitted[*id] {
+				if limit := k.config.MaxActiveExperiments; limit != nil && len(admitted) >= *limit {
+					k.heldTasks[req.ID] = PendingMaxActiveExperiments
+					k.decide(ctx, DecisionDenied, req, PendingMaxActiveExperiments)
+					continue
+				}
+			}
 			if maxSlots := group.maxSlots; maxSlots != nil {
 				if k.slotsUsedPerGroup[group]+req.SlotsNeeded > *maxSlots {
+					k.decide(ctx, DecisionDenied, req, "max_slots")
 					continue
 				}
 			}
 
 			k.assignResources(ctx, req)
+			if req.ExperimentID != nil && k.reqList.GetAllocations(req.TaskActor) != nil {
+				admitted[*req.ExperimentID] = true
+			}
 		}
 	}
 }
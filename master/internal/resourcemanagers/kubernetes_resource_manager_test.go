@@ -0,0 +1,61 @@
+package resourcemanagers
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+func TestKubernetesMaxActiveExperiments(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	maxActive := 1
+	ref, created := system.ActorOf(actor.Addr("kubernetesRM"), newKubernetesResourceManager(
+		&KubernetesResourceManagerConfig{MaxActiveExperiments: &maxActive}))
+	assert.Assert(t, created)
+	pods, created := system.ActorOf(actor.Addr("pods"), actor.ActorFunc(
+		func(ctx *actor.Context) error { return nil }))
+	assert.Assert(t, created)
+	system.Tell(ref, sproto.SetPods{Pods: pods})
+
+	experimentIDs := map[TaskID]*int{
+		"exp1-trial1": newMaxSlot(1),
+		"exp2-trial1": newMaxSlot(2),
+		"exp1-trial2": newMaxSlot(1),
+		"command":     nil,
+	}
+	for _, id := range []TaskID{"exp1-trial1", "exp2-trial1", "exp1-trial2", "command"} {
+		task, created := system.ActorOf(actor.Addr(id), &mockTask{id: id, slotsNeeded: 1})
+		assert.Assert(t, created)
+		system.Ask(ref, AllocateRequest{
+			ID:           id,
+			SlotsNeeded:  1,
+			TaskActor:    task,
+			ExperimentID: experimentIDs[id],
+		}).Get()
+	}
+
+	// Tasks are scheduled on the next tick of the resource manager.
+	var summaries map[TaskID]TaskSummary
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		summaries = system.Ask(ref, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+		if summaries["exp2-trial1"].PendingReason == PendingMaxActiveExperiments {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// The experiment that holds resources and tasks outside experiments are always admitted.
+	for _, id := range []TaskID{"exp1-trial1", "exp1-trial2", "command"} {
+		assert.Equal(t, len(summaries[id].Containers), 1, id)
+	}
+	assert.Equal(t, len(summaries["exp2-trial1"].Containers), 0)
+	assert.Equal(t, summaries["exp2-trial1"].PendingReason, PendingMaxActiveExperiments)
+
+	position := system.Ask(ref, GetQueuePosition{ExperimentID: 2}).Get().(QueuePosition)
+	assert.Equal(t, position.Position, 1)
+	assert.Equal(t, position.PendingReason, PendingMaxActiveExperiments)
+}
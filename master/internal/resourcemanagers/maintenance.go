@@ -0,0 +1,42 @@
+package resourcemanagers
+
+import (
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// PendingMaintenance is the reason of the tasks that are held back because they would start new
+// work during maintenance.
+const PendingMaintenance = "maintenance"
+
+// maintenanceGroups are the groups that may be allocated resources during maintenance: the groups
+// that had asked for resources when the maintenance started and the groups of the tasks that bypass
+// it. It is nil outside of maintenance.
+type maintenanceGroups map[*actor.Ref]bool
+
+// setMaintenance returns the groups admitted during maintenance after the maintenance mode is set
+// to enabled, given the tasks that have asked for resources.
+func (g maintenanceGroups) setMaintenance(enabled bool, reqList *taskList) maintenanceGroups {
+	switch {
+	case !enabled:
+		return nil
+	case g != nil:
+		return g
+	}
+	g = make(maintenanceGroups)
+	for it := reqList.iterator(); it.next(); {
+		g[it.value().Group] = true
+	}
+	return g
+}
+
+// addTask admits the group of the task if the task bypasses the maintenance.
+func (g maintenanceGroups) addTask(req *AllocateRequest) {
+	if g != nil && req.BypassMaintenance {
+		g[req.Group] = true
+	}
+}
+
+// holds returns whether the task waits for the maintenance to end.
+func (g maintenanceGroups) holds(req *AllocateRequest) bool {
+	return g != nil && !g[req.Group]
+}
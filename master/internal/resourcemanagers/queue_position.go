@@ -0,0 +1,73 @@
+package resourcemanagers
+
+type (
+	// GetQueuePosition asks for the QueuePosition of an experiment. Resource pools in which the
+	// experiment does not wait for resources do not respond.
+	GetQueuePosition struct {
+		ExperimentID int
+	}
+
+	// QueuePosition is where an experiment waits for resources in the queue of a resource pool.
+	// The queue holds each experiment with tasks that wait for resources as one entry, and each
+	// other waiting task, e.g., a command, as its own entry, in the order that the scheduler of
+	// the pool serves them; experiments are placed by their first waiting task. The scheduler may
+	// still allocate resources out of order to tasks that fit on the free slots.
+	QueuePosition struct {
+		ResourcePool string `json:"resource_pool"`
+		// Position starts at 1 for the entry at the head of the queue.
+		Position    int `json:"position"`
+		QueueLength int `json:"queue_length"`
+		// PendingTasks is the number of tasks of the experiment that wait for resources.
+		PendingTasks int `json:"pending_tasks"`
+		// PendingReason is why the first waiting task of the experiment waits: PendingMaintenance,
+		// PendingMaxActiveExperiments or PendingInsufficientSlots.
+		PendingReason string `json:"pending_reason"`
+	}
+)
+
+// pendingRequests returns the tasks in reqList that wait for resources in the order that they
+// asked for them.
+func pendingRequests(reqList *taskList) []*AllocateRequest {
+	var pending []*AllocateRequest
+	for it := reqList.iterator(); it.next(); {
+		req := it.value()
+		if allocated := reqList.GetAllocations(req.TaskActor); allocated == nil {
+			pending = append(pending, req)
+		}
+	}
+	return pending
+}
+
+// getQueuePosition returns the position of an experiment in the queue of the tasks in pending,
+// which wait for resources in the order that they are served, or nil if no task of the experiment
+// waits. The tasks in held are held back for the reason that they map to.
+func getQueuePosition(
+	pending []*AllocateRequest, held map[TaskID]string, experimentID int,
+) *QueuePosition {
+	var position QueuePosition
+	queued := map[int]bool{}
+	for _, req := range pending {
+		if req.ExperimentID != nil && *req.ExperimentID == experimentID {
+			position.ResourcePool = req.ResourcePool
+			position.PendingTasks++
+		}
+		if req.ExperimentID != nil {
+			if queued[*req.ExperimentID] {
+				continue
+			}
+			queued[*req.ExperimentID] = true
+		}
+		position.QueueLength++
+		if req.ExperimentID != nil && *req.ExperimentID == experimentID {
+			position.Position = position.QueueLength
+			position.PendingReason = held[req.ID]
+			if position.PendingReason == "" {
+				position.PendingReason = PendingInsufficientSlots
+			}
+		}
+	}
+	if position.PendingTasks == 0 {
+		return nil
+	}
+	return &position
+}
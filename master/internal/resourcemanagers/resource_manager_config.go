@@ -156,14 +156,23 @@ type AgentResourceManagerConfig struct {
 	Scheduler              *SchedulerConfig `json:"scheduler"`
 	DefaultCPUResourcePool string           `json:"default_cpu_resource_pool"`
 	DefaultGPUResourcePool string           `json:"default_gpu_resource_pool"`
+
+	// MaxActiveExperiments is the largest number of experiments whose tasks hold resources in any
+	// resource pool at once.
+	MaxActiveExperiments *int `json:"max_active_experiments,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
 func (a AgentResourceManagerConfig) Validate() []error {
-	return []error{
+	errs := []error{
 		check.NotEmpty(a.DefaultCPUResourcePool, "default_cpu_resource_pool should be non-empty"),
 		check.NotEmpty(a.DefaultGPUResourcePool, "default_gpu_resource_pool should be non-empty"),
 	}
+	if a.MaxActiveExperiments != nil {
+		errs = append(errs, check.GreaterThanOrEqualTo(*a.MaxActiveExperiments, 1,
+			"max_active_experiments must be >= 1"))
+	}
+	return errs
 }
 
 // KubernetesResourceManagerConfig hosts configuration fields for the kubernetes resource manager.
@@ -172,11 +181,19 @@ type KubernetesResourceManagerConfig struct {
 	MaxSlotsPerPod           int    `json:"max_slots_per_pod"`
 	MasterServiceName        string `json:"master_service_name"`
 	LeaveKubernetesResources bool   `json:"leave_kubernetes_resources"`
+
+	// MaxActiveExperiments is the largest number of experiments whose tasks hold resources at once.
+	MaxActiveExperiments *int `json:"max_active_experiments,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
 func (k KubernetesResourceManagerConfig) Validate() []error {
-	return []error{
+	errs := []error{
 		check.GreaterThanOrEqualTo(k.MaxSlotsPerPod, 0, "max_slots_per_pod must be >= 0"),
 	}
+	if k.MaxActiveExperiments != nil {
+		errs = append(errs, check.GreaterThanOrEqualTo(*k.MaxActiveExperiments, 1,
+			"max_active_experiments must be >= 1"))
+	}
+	return errs
 }
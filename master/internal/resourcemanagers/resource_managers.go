@@ -56,7 +56,8 @@ func (rm *ResourceManagers) Receive(ctx *actor.Context) error {
 		AllocateRequest, ResourcesReleased,
 		sproto.SetGroupMaxSlots, sproto.SetGroupWeight,
 		sproto.SetGroupPriority, GetTaskSummary,
-		GetTaskSummaries, SetTaskName:
+		GetTaskSummaries, GetQueuePosition, SetTaskName, SetMaintenance, SetSchedulerPaused,
+		SubscribeSchedulingDecisions, UnsubscribeSchedulingDecisions:
 		rm.forward(ctx, msg)
 
 	default:
@@ -2,10 +2,14 @@ package resourcemanagers
 
 import (
 	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/events"
 	"github.com/determined-ai/determined/master/internal/provisioner"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
@@ -33,6 +37,21 @@ type ResourcePool struct {
 	scalingInfo *sproto.ScalingInfo
 
 	reschedule bool
+	// maintenance holds back the tasks that would start new work during maintenance.
+	maintenance maintenanceGroups
+	// paused stops the scheduler from allocating resources while it is set.
+	paused bool
+
+	// activeExperiments enforces the max_active_experiments of the cluster; it is nil if the
+	// cluster sets none.
+	activeExperiments *activeExperiments
+	// heldTasks are the tasks that were held back by the maintenance mode or by
+	// max_active_experiments when resources were last allocated, with the reason.
+	heldTasks map[TaskID]string
+
+	// decisions receives the scheduling decisions of the pool; it is nil if nothing does.
+	decisions *actor.Ref
+	denials   denials
 
 	// Track notifyOnStop for testing purposes.
 	saveNotifications bool
@@ -59,6 +78,8 @@ func NewResourcePool(
 		scalingInfo: &sproto.ScalingInfo{},
 
 		reschedule: false,
+		heldTasks:  make(map[TaskID]string),
+		denials:    make(denials),
 	}
 	return d
 }
@@ -83,6 +104,9 @@ func (rp *ResourcePool) addTask(ctx *actor.Context, msg AllocateRequest) {
 	if len(msg.ID) == 0 {
 		msg.ID = TaskID(uuid.New().String())
 	}
+	if msg.RequestTime.IsZero() {
+		msg.RequestTime = time.Now()
+	}
 	if msg.Group == nil {
 		msg.Group = msg.TaskActor
 	}
@@ -96,6 +120,7 @@ func (rp *ResourcePool) addTask(ctx *actor.Context, msg AllocateRequest) {
 		msg.TaskActor.Address(), msg.ID,
 	)
 	rp.taskList.AddTask(&msg)
+	rp.maintenance.addTask(&msg)
 }
 
 func (rp *ResourcePool) receiveSetTaskName(ctx *actor.Context, msg SetTaskName) {
@@ -117,6 +142,7 @@ func (rp *ResourcePool) allocateResources(ctx *actor.Context, req *AllocateReque
 	for _, fit := range fits {
 		container := newContainer(req, fit.Agent, fit.Slots)
 		allocations = append(allocations, &containerAllocation{
+			pool:      rp.config,
 			req:       req,
 			agent:     fit.Agent,
 			container: container,
@@ -130,18 +156,107 @@ func (rp *ResourcePool) allocateResources(ctx *actor.Context, req *AllocateReque
 	rp.taskList.SetAllocations(req.TaskActor, &allocated)
 	req.TaskActor.System().Tell(req.TaskActor, allocated)
 	ctx.Log().Infof("allocated resources to %s", req.TaskActor.Address())
+	placements := make([]string, 0, len(fits))
+	for _, fit := range fits {
+		placements = append(placements,
+			fmt.Sprintf("%d slots on %s", fit.Slots, fit.Agent.handler.Address().Local()))
+	}
+	rp.decide(ctx, DecisionAllocated, req, strings.Join(placements, ", "))
+	ctx.Self().System().TellAt(sproto.AllocationRecorderAddr, sproto.AllocationStarted{
+		TaskID:       string(req.ID),
+		ResourcePool: rp.config.PoolName,
+		Slots:        req.SlotsNeeded,
+		UserID:       req.UserID,
+		ExperimentID: req.ExperimentID,
+		CommandID:    req.CommandID,
+		RequestTime:  req.RequestTime,
+		Time:         time.Now(),
+	})
 
 	return true
 }
 
 func (rp *ResourcePool) releaseResource(ctx *actor.Context, handler *actor.Ref) {
 	ctx.Log().Infof("releasing resources taken by %s", handler.Address())
+	if req, ok := rp.taskList.GetTaskByHandler(handler); ok {
+		rp.decide(ctx, DecisionPreempted, req, "released for tasks that the scheduler favors")
+	}
 	handler.System().Tell(handler, ReleaseResources{ResourcePool: rp.config.PoolName})
 }
 
 func (rp *ResourcePool) resourcesReleased(ctx *actor.Context, handler *actor.Ref) {
 	ctx.Log().Infof("resources are released for %s", handler.Address())
+	if allocated := rp.taskList.GetAllocations(handler); allocated != nil {
+		ctx.Self().System().TellAt(sproto.AllocationRecorderAddr, sproto.AllocationEnded{
+			TaskID: string(allocated.ID),
+			Time:   time.Now(),
+		})
+	}
+	if req, ok := rp.taskList.GetTaskByHandler(handler); ok {
+		delete(rp.denials, req.ID)
+	}
 	rp.taskList.RemoveTaskByHandler(handler)
+	rp.activeExperiments.set(rp.config.PoolName, rp.allocatedExperiments())
+}
+
+// allocatedExperiments returns the IDs of the experiments that hold resources in the pool.
+func (rp *ResourcePool) allocatedExperiments() map[int]bool {
+	ids := make(map[int]bool)
+	for it := rp.taskList.iterator(); it.next(); {
+		req := it.value()
+		if req.ExperimentID != nil && rp.taskList.GetAllocations(req.TaskActor) != nil {
+			ids[*req.ExperimentID] = true
+		}
+	}
+	return ids
+}
+
+// admittedTasks returns the tasks that may be allocated resources under the maintenance mode and
+// the max_active_experiments of the pool and of the cluster, and records the tasks that are held
+// back in heldTasks. Tasks that already hold resources are always admitted. During maintenance,
+// only the tasks of the groups that the maintenance admits are. Tasks that are not part of
+// experiments and the tasks of experiments that already hold resources are admitted regardless of
+// max_active_experiments; other experiments are admitted in the order in which their tasks arrived.
+func (rp *ResourcePool) admittedTasks() *taskList {
+	admitted := rp.allocatedExperiments()
+	rp.heldTasks = make(map[TaskID]string)
+	tasks := newTaskList()
+	for it := rp.taskList.iterator(); it.next(); {
+		req := it.value()
+		allocated := rp.taskList.GetAllocations(req.TaskActor)
+		if allocated == nil && rp.maintenance.holds(req) {
+			rp.heldTasks[req.ID] = PendingMaintenance
+			continue
+		}
+		if id := req.ExperimentID; allocated == nil && id != nil && !admitted[*id] {
+			limit := rp.config.MaxActiveExperiments
+			if (limit != nil && len(admitted) >= *limit) ||
+				!rp.activeExperiments.admit(rp.config.PoolName, *id) {
+				rp.heldTasks[req.ID] = PendingMaxActiveExperiments
+				continue
+			}
+			admitted[*id] = true
+		}
+		tasks.AddTask(req)
+		if allocated != nil {
+			tasks.SetAllocations(req.TaskActor, allocated)
+		}
+	}
+	return tasks
+}
+
+// schedule decides which tasks to allocate resources to and which to release. The scheduler only
+// sees the tasks that the maintenance mode and max_active_experiments admit, so that the tasks that
+// are held back neither get resources nor cause other tasks to be preempted.
+func (rp *ResourcePool) schedule() ([]*AllocateRequest, []*actor.Ref) {
+	if rp.maintenance == nil && rp.config.MaxActiveExperiments == nil &&
+		rp.activeExperiments == nil {
+		return rp.scheduler.Schedule(rp)
+	}
+	taskList := rp.taskList
+	rp.taskList = rp.admittedTasks()
+	defer func() { rp.taskList = taskList }()
+	return rp.scheduler.Schedule(rp)
 }
 
 func (rp *ResourcePool) getOrCreateGroup(
@@ -226,22 +341,39 @@ func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 		ResourcesReleased:
 		return rp.receiveRequestMsg(ctx)
 
+	case SetMaintenance:
+		rp.maintenance = rp.maintenance.setMaintenance(msg.Enabled, rp.taskList)
+
+	case SetSchedulerPaused:
+		rp.paused = msg.Paused
+
 	case GetTaskSummary:
 		reschedule = false
-		if resp := getTaskSummary(rp.taskList, *msg.ID); resp != nil {
+		if resp := getTaskSummary(rp.taskList, rp.heldTasks, *msg.ID); resp != nil {
 			ctx.Respond(*resp)
 		}
 
 	case GetTaskSummaries:
 		reschedule = false
-		ctx.Respond(getTaskSummaries(rp.taskList))
+		ctx.Respond(getTaskSummaries(rp.taskList, rp.heldTasks))
+
+	case GetQueuePosition:
+		reschedule = false
+		pending := rp.scheduler.OrderedAllocations(rp)
+		if resp := getQueuePosition(pending, rp.heldTasks, msg.ExperimentID); resp != nil {
+			ctx.Respond(*resp)
+		}
 
 	case schedulerTick:
-		if rp.reschedule {
-			toAllocate, toRelease := rp.scheduler.Schedule(rp)
+		// During a pause, pending tasks wait and running tasks are not preempted for them.
+		if rp.reschedule && !rp.paused {
+			toAllocate, toRelease := rp.schedule()
 			for _, req := range toAllocate {
 				rp.allocateResources(ctx, req)
 			}
+			rp.denyPendingTasks(ctx)
+			// Experiments that were admitted but not allocated resources do not count as active.
+			rp.activeExperiments.set(rp.config.PoolName, rp.allocatedExperiments())
 			for _, taskActor := range toRelease {
 				rp.releaseResource(ctx, taskActor)
 			}
@@ -263,6 +395,7 @@ func (rp *ResourcePool) receiveAgentMsg(ctx *actor.Context) error {
 	case sproto.AddAgent:
 		ctx.Log().Infof("adding agent: %s", msg.Agent.Address().Local())
 		rp.agents[msg.Agent] = newAgentState(msg)
+		rp.publishAgentEvent(ctx, events.AgentConnected, msg.Agent)
 
 	case sproto.AddDevice:
 		ctx.Log().Infof("adding device: %s on %s", msg.Device.String(), msg.Agent.Address().Local())
@@ -294,6 +427,7 @@ func (rp *ResourcePool) receiveAgentMsg(ctx *actor.Context) error {
 	case sproto.RemoveAgent:
 		ctx.Log().Infof("removing agent: %s", msg.Agent.Address().Local())
 		delete(rp.agents, msg.Agent)
+		rp.publishAgentEvent(ctx, events.AgentDisconnected, msg.Agent)
 
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
@@ -301,10 +435,44 @@ func (rp *ResourcePool) receiveAgentMsg(ctx *actor.Context) error {
 	return nil
 }
 
+// denyPendingTasks reports the tasks that still wait for resources after the scheduler acted.
+func (rp *ResourcePool) denyPendingTasks(ctx *actor.Context) {
+	for it := rp.taskList.iterator(); it.next(); {
+		req := it.value()
+		switch {
+		case rp.taskList.GetAllocations(req.TaskActor) != nil:
+		case rp.heldTasks[req.ID] != "":
+			rp.decide(ctx, DecisionDenied, req, rp.heldTasks[req.ID])
+		default:
+			rp.decide(ctx, DecisionDenied, req, PendingInsufficientSlots)
+		}
+	}
+}
+
+// decide reports a scheduling decision about a task to the resource manager.
+func (rp *ResourcePool) decide(
+	ctx *actor.Context, decisionType DecisionType, req *AllocateRequest, reason string,
+) {
+	decision := newDecision(rp.config.PoolName, decisionType, req, reason)
+	if rp.denials.record(decision) && rp.decisions != nil {
+		ctx.Tell(rp.decisions, decision)
+	}
+}
+
+func (rp *ResourcePool) publishAgentEvent(
+	ctx *actor.Context, eventType events.Type, agent *actor.Ref,
+) {
+	events.Publish(ctx.Self().System(), eventType, map[string]interface{}{
+		"agent_id":      agent.Address().Local(),
+		"resource_pool": rp.config.PoolName,
+	})
+}
+
 func (rp *ResourcePool) receiveRequestMsg(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case groupActorStopped:
 		delete(rp.groups, msg.Ref)
+		delete(rp.maintenance, msg.Ref)
 
 	case sproto.SetGroupMaxSlots:
 		rp.getOrCreateGroup(ctx, msg.Handler).maxSlots = msg.MaxSlots
@@ -340,6 +508,7 @@ func (rp *ResourcePool) receiveRequestMsg(ctx *actor.Context) error {
 
 // containerAllocation contains information for tasks have been allocated but not yet started.
 type containerAllocation struct {
+	pool      *ResourcePoolConfig
 	req       *AllocateRequest
 	container *container
 	agent     *agentState
@@ -357,12 +526,66 @@ func (c containerAllocation) Summary() ContainerSummary {
 
 // StartContainer notifies the agent to start a container.
 func (c containerAllocation) Start(ctx *actor.Context, spec image.TaskSpec) {
-	handler := c.agent.handler
 	spec.ContainerID = string(c.container.id)
 	spec.TaskID = string(c.req.ID)
 	spec.Devices = c.devices
+	spec.ResourcePool = c.pool.PoolName
+	spec.PoolDefaults = c.pool.TaskContainerDefaults
+	if err := spec.Validate(); err != nil {
+		c.fail(ctx, err)
+		return
+	}
+	if fetch := image.PendingRegistryToken(spec); fetch != nil {
+		// Fetching the token calls out to AWS, so it is done outside of the actor of the task,
+		// which is told to resume starting the container once it is done.
+		taskActor := c.req.TaskActor
+		go func() {
+			err := fetch()
+			taskActor.System().Tell(taskActor, RegistryTokenFetched{
+				ID:         c.req.ID,
+				Allocation: c,
+				resume: func(ctx *actor.Context) {
+					if err != nil {
+						c.fail(ctx, errors.Wrap(err, "failed to get the registry credentials"))
+						return
+					}
+					c.start(ctx, spec)
+				},
+			})
+		}()
+		return
+	}
+	c.start(ctx, spec)
+}
+
+// Resume starts the container whose registry token was fetched, or reports it as failed if the
+// token could not be fetched.
+func (m RegistryTokenFetched) Resume(ctx *actor.Context) {
+	m.resume(ctx)
+}
+
+// fail reports the container as failed to the task actor without starting it.
+func (c containerAllocation) fail(ctx *actor.Context, err error) {
+	ctx.Log().WithError(err).Errorf("cannot start container for task %s", c.req.ID)
+	ctx.Tell(c.req.TaskActor, sproto.TaskContainerStateChanged{
+		Container: cproto.Container{
+			Parent:  c.req.TaskActor.Address(),
+			ID:      c.container.id,
+			State:   cproto.Terminated,
+			Devices: c.devices,
+		},
+		ContainerStopped: &sproto.TaskContainerStopped{
+			ContainerStopped: aproto.ContainerError(aproto.TaskError, err),
+		},
+	})
+}
+
+// start notifies the agent to start the container of a validated task spec.
+func (c containerAllocation) start(ctx *actor.Context, spec image.TaskSpec) {
+	handler := c.agent.handler
 	ctx.Tell(handler, sproto.StartTaskContainer{
 		TaskActor: c.req.TaskActor,
+		TaskID:    string(c.req.ID),
 		StartContainer: aproto.StartContainer{
 			Container: cproto.Container{
 				Parent:  c.req.TaskActor.Address(),
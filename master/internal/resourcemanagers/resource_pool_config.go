@@ -5,6 +5,7 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/provisioner"
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 // DefaultRPsConfig returns the default resources pools configuration.
@@ -20,13 +21,26 @@ type ResourcePoolConfig struct {
 	Description string              `json:"description"`
 	Provider    *provisioner.Config `json:"provider"`
 	Scheduler   *SchedulerConfig    `json:"scheduler,omitempty"`
+
+	// MaxActiveExperiments is the largest number of experiments whose tasks hold resources in the
+	// pool at once; the tasks of other experiments wait until one of them releases its resources.
+	MaxActiveExperiments *int `json:"max_active_experiments,omitempty"`
+
+	// TaskContainerDefaults overrides the task container defaults of the master for the tasks
+	// that are allocated in the pool.
+	TaskContainerDefaults *model.TaskContainerOverrides `json:"task_container_defaults,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
 func (r ResourcePoolConfig) Validate() []error {
-	return []error{
+	errs := []error{
 		check.True(len(r.PoolName) != 0, "resource pool name cannot be empty"),
 	}
+	if r.MaxActiveExperiments != nil {
+		errs = append(errs, check.GreaterThanOrEqualTo(*r.MaxActiveExperiments, 1,
+			"max_active_experiments must be >= 1"))
+	}
+	return errs
 }
 
 // ResourcePoolsConfig hosts the configuration for resource pools
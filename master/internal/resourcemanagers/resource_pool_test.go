@@ -204,3 +204,236 @@ func TestSettingGroupPriority(t *testing.T) {
 	assert.Equal(t, *rp.groups[groupRefOne].priority, updatedPriority)
 	assert.Equal(t, *rp.groups[groupRefTwo].priority, defaultPriority)
 }
+
+func TestNoAllocationsDuringMaintenance(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent", slots: 1}}
+	tasks := []*mockTask{{id: "task", slotsNeeded: 1}}
+	_, ref := setupResourcePool(t, system, nil, tasks, nil, agents)
+	taskRef := system.Get(actor.Addr("task"))
+	system.Ask(taskRef, SendResourcesReleasedToResourceManager{}).Get()
+	system.Ask(ref, SetMaintenance{Enabled: true}).Get()
+
+	system.Ask(taskRef, SendRequestResourcesToResourceManager{}).Get()
+	system.Ask(ref, schedulerTick{}).Get()
+	taskSummaries := system.Ask(ref, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+	assert.Equal(t, len(taskSummaries["task"].Containers), 0)
+	assert.Equal(t, taskSummaries["task"].PendingReason, PendingMaintenance)
+
+	system.Ask(ref, SetMaintenance{Enabled: false}).Get()
+	system.Ask(ref, schedulerTick{}).Get()
+	taskSummaries = system.Ask(ref, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+	assert.Equal(t, len(taskSummaries["task"].Containers), 1)
+}
+
+func TestAdmittedAllocationsDuringMaintenance(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent", slots: 5}}
+	running := &mockGroup{id: "running"}
+	override := &mockGroup{id: "override"}
+	held := &mockGroup{id: "heldGroup"}
+	groups := []*mockGroup{running, override, held}
+	tasks := []*mockTask{
+		{id: "running1", slotsNeeded: 1, group: running},
+		{id: "running2", slotsNeeded: 1, group: running},
+		{id: "override1", slotsNeeded: 1, group: override, bypassMaintenance: true},
+		{id: "override2", slotsNeeded: 1, group: override},
+		{id: "held1", slotsNeeded: 1, group: held},
+	}
+	_, ref := setupResourcePool(t, system, nil, tasks, groups, agents)
+
+	send := func(id string, msg actor.Message) {
+		system.Ask(system.Get(actor.Addr(id)), msg).Get()
+	}
+	newTasks := []string{"running2", "override1", "override2", "held1"}
+	for _, id := range newTasks {
+		send(id, SendResourcesReleasedToResourceManager{})
+	}
+	system.Ask(ref, SetMaintenance{Enabled: true}).Get()
+
+	// New tasks of the groups that were running or that bypass the maintenance are still
+	// scheduled; other groups wait for the maintenance to end.
+	for _, id := range newTasks {
+		send(id, SendRequestResourcesToResourceManager{})
+	}
+	system.Ask(ref, schedulerTick{}).Get()
+	taskSummaries := system.Ask(ref, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+	for _, id := range []TaskID{"running1", "running2", "override1", "override2"} {
+		assert.Equal(t, len(taskSummaries[id].Containers), 1, id)
+	}
+	assert.Equal(t, len(taskSummaries["held1"].Containers), 0)
+	assert.Equal(t, taskSummaries["held1"].PendingReason, PendingMaintenance)
+
+	system.Ask(ref, SetMaintenance{Enabled: false}).Get()
+	system.Ask(ref, schedulerTick{}).Get()
+	taskSummaries = system.Ask(ref, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+	assert.Equal(t, len(taskSummaries["held1"].Containers), 1)
+}
+
+func TestNoAllocationsWhilePaused(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent", slots: 1}}
+	tasks := []*mockTask{{id: "task", slotsNeeded: 1}}
+	_, ref := setupResourcePool(t, system, nil, tasks, nil, agents)
+	system.Ask(ref, SetSchedulerPaused{Paused: true}).Get()
+
+	taskRef := system.Get(actor.Addr("task"))
+	system.Ask(taskRef, SendRequestResourcesToResourceManager{}).Get()
+	system.Ask(ref, schedulerTick{}).Get()
+	taskSummaries := system.Ask(ref, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+	assert.Equal(t, len(taskSummaries["task"].Containers), 0)
+
+	// Ending the maintenance mode does not resume a paused scheduler.
+	system.Ask(ref, SetMaintenance{Enabled: false}).Get()
+	system.Ask(ref, schedulerTick{}).Get()
+	taskSummaries = system.Ask(ref, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+	assert.Equal(t, len(taskSummaries["task"].Containers), 0)
+
+	system.Ask(ref, SetSchedulerPaused{Paused: false}).Get()
+	system.Ask(ref, schedulerTick{}).Get()
+	taskSummaries = system.Ask(ref, GetTaskSummaries{}).Get().(map[TaskID]TaskSummary)
+	assert.Equal(t, len(taskSummaries["task"].Containers), 1)
+}
+
+// setupExperimentTasks sets up a resource pool with tasks of one slot that are part of the
+// experiments with the given IDs, in order.
+func setupExperimentTasks(
+	t *testing.T, system *actor.System, config *ResourcePoolConfig,
+	taskIDs []string, experimentIDs []int, agents []*mockAgent,
+) *ResourcePool {
+	var tasks []*mockTask
+	for _, id := range taskIDs {
+		tasks = append(tasks, &mockTask{id: TaskID(id), slotsNeeded: 1})
+	}
+	rp := NewResourcePool(config, nil, NewFairShareScheduler(), BestFit)
+	rp.taskList, rp.groups, rp.agents = setupSchedulerStates(t, system, tasks, nil, agents)
+	for i, id := range taskIDs {
+		req, ok := rp.taskList.GetTaskByID(TaskID(id))
+		assert.Assert(t, ok)
+		req.ExperimentID = &experimentIDs[i]
+	}
+	return rp
+}
+
+func TestMaxActiveExperimentsOfPool(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	maxActive := 1
+	rp := setupExperimentTasks(t, system,
+		&ResourcePoolConfig{PoolName: "pool", MaxActiveExperiments: &maxActive},
+		[]string{"exp1-trial1", "exp1-trial2", "exp2-trial1"}, []int{1, 1, 2},
+		[]*mockAgent{{id: "agent", slots: 4}},
+	)
+
+	toAllocate, toRelease := rp.schedule()
+	assert.Equal(t, len(toRelease), 0)
+	allocated := make(map[TaskID]bool)
+	for _, req := range toAllocate {
+		allocated[req.ID] = true
+	}
+	assert.DeepEqual(t, allocated, map[TaskID]bool{"exp1-trial1": true, "exp1-trial2": true})
+	assert.Equal(t, rp.taskList.len(), 3)
+
+	summaries := getTaskSummaries(rp.taskList, rp.heldTasks)
+	assert.Equal(t, summaries["exp1-trial1"].PendingReason, PendingInsufficientSlots)
+	assert.Equal(t, summaries["exp2-trial1"].PendingReason, PendingMaxActiveExperiments)
+	assert.Equal(t, *summaries["exp2-trial1"].ExperimentID, 2)
+
+	position := getQueuePosition(rp.scheduler.OrderedAllocations(rp), rp.heldTasks, 2)
+	assert.Equal(t, position.PendingReason, PendingMaxActiveExperiments)
+}
+
+func TestMaxActiveExperimentsOfCluster(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	active := newActiveExperiments(newMaxSlot(1))
+	rp1 := setupExperimentTasks(t, system, &ResourcePoolConfig{PoolName: "pool1"},
+		[]string{"exp1-trial1"}, []int{1}, []*mockAgent{{id: "agent1", slots: 1}})
+	rp2 := setupExperimentTasks(t, system, &ResourcePoolConfig{PoolName: "pool2"},
+		[]string{"exp2-trial1"}, []int{2}, []*mockAgent{{id: "agent2", slots: 1}})
+	rp1.activeExperiments, rp2.activeExperiments = active, active
+
+	setTaskAllocations(t, rp1.taskList, "exp1-trial1", 1)
+	active.set("pool1", rp1.allocatedExperiments())
+
+	toAllocate, _ := rp2.schedule()
+	assert.Equal(t, len(toAllocate), 0)
+	assert.Equal(t, rp2.heldTasks["exp2-trial1"], PendingMaxActiveExperiments)
+
+	// Once the experiment in the other pool releases its resources, the held one is admitted.
+	setTaskAllocations(t, rp1.taskList, "exp1-trial1", 0)
+	active.set("pool1", rp1.allocatedExperiments())
+
+	toAllocate, _ = rp2.schedule()
+	assert.Equal(t, len(toAllocate), 1)
+	assert.Equal(t, rp2.heldTasks["exp2-trial1"], "")
+}
+
+func TestQueuePosition(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	rp := setupExperimentTasks(t, system, &ResourcePoolConfig{PoolName: "pool"},
+		[]string{"exp1-trial1", "exp2-trial1", "exp1-trial2", "exp3-trial1", "exp3-trial2"},
+		[]int{1, 2, 1, 3, 3},
+		[]*mockAgent{{id: "agent", slots: 1}},
+	)
+	setTaskAllocations(t, rp.taskList, "exp1-trial1", 1)
+	position := func(experimentID int) *QueuePosition {
+		return getQueuePosition(rp.scheduler.OrderedAllocations(rp), rp.heldTasks, experimentID)
+	}
+
+	// Experiment 1 waits in line from its oldest trial that waits, which asked for resources after
+	// the trial of experiment 2.
+	assert.DeepEqual(t, position(2),
+		&QueuePosition{Position: 1, QueueLength: 3, PendingTasks: 1,
+			PendingReason: PendingInsufficientSlots})
+	assert.DeepEqual(t, position(1),
+		&QueuePosition{Position: 2, QueueLength: 3, PendingTasks: 1,
+			PendingReason: PendingInsufficientSlots})
+	assert.DeepEqual(t, position(3),
+		&QueuePosition{Position: 3, QueueLength: 3, PendingTasks: 2,
+			PendingReason: PendingInsufficientSlots})
+
+	setTaskAllocations(t, rp.taskList, "exp2-trial1", 1)
+	assert.Assert(t, position(2) == nil)
+	assert.Equal(t, position(1).Position, 1)
+	assert.Equal(t, position(3).Position, 2)
+	assert.Assert(t, position(4) == nil)
+}
+
+func TestQueuePositionFollowsScheduler(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	light := &mockGroup{id: "light", weight: 1}
+	heavy := &mockGroup{id: "heavy", weight: 3}
+	agent := &mockAgent{id: "agent", slots: 2}
+	tasks := []*mockTask{
+		{id: "exp1-trial1", group: light, slotsNeeded: 1, allocatedAgent: agent},
+		{id: "exp1-trial2", group: light, slotsNeeded: 1},
+		{id: "exp2-trial1", group: heavy, slotsNeeded: 1, allocatedAgent: agent},
+		{id: "exp2-trial2", group: heavy, slotsNeeded: 1},
+		{id: "exp3-trial1", slotsNeeded: 1},
+	}
+	experimentIDs := []int{1, 1, 2, 2, 3}
+
+	rp := NewResourcePool(&ResourcePoolConfig{PoolName: "pool"}, nil, nil, BestFit)
+	rp.taskList, rp.groups, rp.agents = setupSchedulerStates(
+		t, system, tasks, []*mockGroup{light, heavy}, []*mockAgent{agent},
+	)
+	for i, task := range tasks {
+		req, ok := rp.taskList.GetTaskByID(task.id)
+		assert.Assert(t, ok)
+		req.ExperimentID = &experimentIDs[i]
+	}
+	positions := func(scheduler Scheduler) []int {
+		rp.scheduler = scheduler
+		var positions []int
+		for _, id := range []int{1, 2, 3} {
+			positions = append(positions,
+				getQueuePosition(scheduler.OrderedAllocations(rp), nil, id).Position)
+		}
+		return positions
+	}
+
+	// The round robin scheduler serves the groups that hold the fewest slots first, and then the
+	// groups that registered first.
+	assert.DeepEqual(t, positions(NewRoundRobinScheduler()), []int{2, 3, 1})
+	// The fair share scheduler serves the groups that hold the fewest slots for their weight first.
+	assert.DeepEqual(t, positions(NewFairShareScheduler()), []int{3, 2, 1})
+}
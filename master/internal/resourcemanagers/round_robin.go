@@ -18,6 +18,15 @@ func (p *roundRobinScheduler) Schedule(rp *ResourcePool) ([]*AllocateRequest, []
 	return roundRobinSchedule(rp.taskList, rp.groups, rp.agents, rp.fittingMethod)
 }
 
+func (p *roundRobinScheduler) OrderedAllocations(rp *ResourcePool) []*AllocateRequest {
+	return orderPendingRequests(rp.taskList, rp.groups, func(first, second *groupState) bool {
+		if first.activeSlots != second.activeSlots {
+			return first.activeSlots < second.activeSlots
+		}
+		return registeredBefore(first, second)
+	})
+}
+
 func roundRobinSchedule(
 	taskList *taskList,
 	groups map[*actor.Ref]*group,
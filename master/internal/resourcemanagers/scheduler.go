@@ -2,6 +2,7 @@ package resourcemanagers
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
 )
@@ -10,6 +11,9 @@ import (
 // running tasks to terminate.
 type Scheduler interface {
 	Schedule(rp *ResourcePool) ([]*AllocateRequest, []*actor.Ref)
+	// OrderedAllocations returns the tasks that wait for resources in the order in which the
+	// scheduler serves them.
+	OrderedAllocations(rp *ResourcePool) []*AllocateRequest
 }
 
 // MakeScheduler returns the corresponding scheduler implementation.
@@ -25,3 +29,52 @@ func MakeScheduler(schedulingPolicy string) Scheduler {
 		panic(fmt.Sprintf("invalid scheduler: %s", schedulingPolicy))
 	}
 }
+
+// orderPendingRequests returns the tasks in taskList that wait for resources, serving groups in the
+// order given by less and taking one task from each group in turn, as the schedulers offer
+// resources. Within a group, tasks are served in the order that they asked for resources.
+func orderPendingRequests(
+	taskList *taskList, groups map[*actor.Ref]*group, less func(first, second *groupState) bool,
+) []*AllocateRequest {
+	var states []*groupState
+	groupMapping := make(map[*group]*groupState)
+	for it := taskList.iterator(); it.next(); {
+		req := it.value()
+		group := groups[req.Group]
+		state, ok := groupMapping[group]
+		if !ok {
+			state = &groupState{group: group}
+			states = append(states, state)
+			groupMapping[group] = state
+		}
+		switch assigned := taskList.GetAllocations(req.TaskActor); {
+		case assigned == nil || len(assigned.Allocations) == 0:
+			state.pendingReqs = append(state.pendingReqs, req)
+		default:
+			state.activeSlots += req.SlotsNeeded
+		}
+	}
+
+	sort.SliceStable(states, func(i, j int) bool {
+		return less(states[i], states[j])
+	})
+
+	var ordered []*AllocateRequest
+	for len(states) > 0 {
+		filtered := states[:0]
+		for _, state := range states {
+			if len(state.pendingReqs) > 0 {
+				ordered = append(ordered, state.pendingReqs[0])
+				state.pendingReqs = state.pendingReqs[1:]
+				filtered = append(filtered, state)
+			}
+		}
+		states = filtered
+	}
+	return ordered
+}
+
+// registeredBefore orders groups by the time that they registered with the system.
+func registeredBefore(first, second *groupState) bool {
+	return first.handler.RegisteredTime().Before(second.handler.RegisteredTime())
+}
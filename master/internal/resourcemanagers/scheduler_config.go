@@ -30,7 +30,7 @@ type SchedulerConfig struct {
 	FairShare     *FairShareSchedulerConfig  `union:"type,fair_share" json:"-"`
 	Priority      *PrioritySchedulerConfig   `union:"type,priority" json:"-"`
 	RoundRobin    *RoundRobinSchedulerConfig `union:"type,round_robin" json:"-"`
-	FittingPolicy string                     `json:"fitting_policy"`
+	FittingPolicy string                     `json:"fitting_policy" enum:"best,worst"`
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -56,6 +56,8 @@ type mockTask struct {
 	resourcePool     string
 	allocatedAgent   *mockAgent
 	containerStarted bool
+
+	bypassMaintenance bool
 }
 
 func (t *mockTask) Receive(ctx *actor.Context) error {
@@ -71,6 +73,8 @@ func (t *mockTask) Receive(ctx *actor.Context) error {
 			Label:          t.label,
 			ResourcePool:   t.resourcePool,
 			TaskActor:      ctx.Self(),
+
+			BypassMaintenance: t.bypassMaintenance,
 		}
 		if t.group == nil {
 			task.Group = ctx.Self()
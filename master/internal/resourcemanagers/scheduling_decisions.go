@@ -0,0 +1,133 @@
+package resourcemanagers
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// DecisionType is the kind of a SchedulingDecision.
+type DecisionType string
+
+const (
+	// DecisionAllocated is made when a task is allocated resources.
+	DecisionAllocated DecisionType = "allocated"
+	// DecisionDenied is made when a task that asks for resources is not allocated them. It is made
+	// again only if the reason changes.
+	DecisionDenied DecisionType = "denied"
+	// DecisionPreempted is made when a task is asked to release its resources for other tasks.
+	DecisionPreempted DecisionType = "preempted"
+)
+
+// decisionBufferSize is the number of decisions that may be waiting to be sent to a subscriber.
+// Subscribers that fall further behind are disconnected.
+const decisionBufferSize = 256
+
+type (
+	// SchedulingDecision is a decision of the scheduler of a resource pool about a task.
+	SchedulingDecision struct {
+		Time         time.Time    `json:"time"`
+		Type         DecisionType `json:"type"`
+		ResourcePool string       `json:"resource_pool"`
+		TaskID       TaskID       `json:"task_id"`
+		TaskName     string       `json:"task_name"`
+		ExperimentID *int         `json:"experiment_id"`
+		Slots        int          `json:"slots"`
+		Reason       string       `json:"reason"`
+	}
+
+	// SubscribeSchedulingDecisions subscribes to the decisions of the schedulers of all resource
+	// pools; the response is a *DecisionSubscription.
+	SubscribeSchedulingDecisions struct{}
+
+	// UnsubscribeSchedulingDecisions ends a subscription to scheduling decisions.
+	UnsubscribeSchedulingDecisions struct {
+		Subscription *DecisionSubscription
+	}
+
+	// DecisionSubscription is a stream of scheduling decisions.
+	DecisionSubscription struct {
+		// Decisions receives new decisions. It is closed if the subscriber falls behind, after
+		// which Overflowed is set.
+		Decisions  <-chan SchedulingDecision
+		Overflowed bool
+
+		decisions chan SchedulingDecision
+	}
+)
+
+func newDecision(
+	pool string, decisionType DecisionType, req *AllocateRequest, reason string,
+) SchedulingDecision {
+	return SchedulingDecision{
+		Time:         time.Now().UTC(),
+		Type:         decisionType,
+		ResourcePool: pool,
+		TaskID:       req.ID,
+		TaskName:     req.Name,
+		ExperimentID: req.ExperimentID,
+		Slots:        req.SlotsNeeded,
+		Reason:       reason,
+	}
+}
+
+// denials are the reasons for which tasks were last denied resources, so that a denial is only
+// reported again if its reason changes.
+type denials map[TaskID]string
+
+// record records a decision about a task and returns whether it should be reported.
+func (d denials) record(decision SchedulingDecision) bool {
+	if decision.Type != DecisionDenied {
+		delete(d, decision.TaskID)
+		return true
+	}
+	if reason, ok := d[decision.TaskID]; ok && reason == decision.Reason {
+		return false
+	}
+	d[decision.TaskID] = decision.Reason
+	return true
+}
+
+// decisionSubscribers streams the scheduling decisions that a resource manager receives to its
+// subscribers. It is only used from the actor of the resource manager.
+type decisionSubscribers map[*DecisionSubscription]bool
+
+// receive handles SchedulingDecision, SubscribeSchedulingDecisions,
+// UnsubscribeSchedulingDecisions, and actor.PostStop messages.
+func (d decisionSubscribers) receive(ctx *actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case SchedulingDecision:
+		for sub := range d {
+			select {
+			case sub.decisions <- msg:
+			default:
+				ctx.Log().Warnf(
+					"disconnecting a scheduling decision subscriber that fell %d decisions behind",
+					decisionBufferSize)
+				sub.Overflowed = true
+				d.unsubscribe(sub)
+			}
+		}
+
+	case SubscribeSchedulingDecisions:
+		decisions := make(chan SchedulingDecision, decisionBufferSize)
+		sub := &DecisionSubscription{Decisions: decisions, decisions: decisions}
+		d[sub] = true
+		ctx.Respond(sub)
+
+	case UnsubscribeSchedulingDecisions:
+		d.unsubscribe(msg.Subscription)
+
+	case actor.PostStop:
+		for sub := range d {
+			d.unsubscribe(sub)
+		}
+	}
+}
+
+func (d decisionSubscribers) unsubscribe(sub *DecisionSubscription) {
+	if d[sub] {
+		delete(d, sub)
+		close(sub.decisions)
+	}
+}
@@ -0,0 +1,61 @@
+package resourcemanagers
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+type decisionHub struct {
+	decisionSubscribers
+}
+
+func (h decisionHub) Receive(ctx *actor.Context) error {
+	h.receive(ctx)
+	return nil
+}
+
+func TestDenialsReportedOnce(t *testing.T) {
+	req := &AllocateRequest{ID: "task", SlotsNeeded: 2}
+	d := make(denials)
+	assert.Assert(t, d.record(newDecision("pool", DecisionDenied, req, PendingInsufficientSlots)))
+	assert.Assert(t, !d.record(newDecision("pool", DecisionDenied, req, PendingInsufficientSlots)))
+	assert.Assert(t, d.record(newDecision("pool", DecisionDenied, req, PendingMaxActiveExperiments)))
+	assert.Assert(t, d.record(newDecision("pool", DecisionAllocated, req, "2 slots on agent")))
+	// Once the task is preempted, it is reported as denied again while it waits.
+	assert.Assert(t, d.record(newDecision("pool", DecisionPreempted, req, "")))
+	assert.Assert(t, d.record(newDecision("pool", DecisionDenied, req, PendingInsufficientSlots)))
+}
+
+func TestDecisionSubscribers(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	ref, created := system.ActorOf(actor.Addr("rm"), decisionHub{make(decisionSubscribers)})
+	assert.Assert(t, created)
+
+	sub := system.Ask(ref, SubscribeSchedulingDecisions{}).Get().(*DecisionSubscription)
+	req := &AllocateRequest{ID: "task", SlotsNeeded: 2}
+	system.Tell(ref, newDecision("pool", DecisionAllocated, req, "2 slots on agent"))
+	decision := <-sub.Decisions
+	assert.Equal(t, decision.Type, DecisionAllocated)
+	assert.Equal(t, decision.TaskID, TaskID("task"))
+
+	// Subscribers that do not keep up are disconnected.
+	for i := 0; i <= decisionBufferSize; i++ {
+		system.Tell(ref, newDecision("pool", DecisionPreempted, req, ""))
+	}
+	system.Ask(ref, actor.Ping{}).Get()
+	received := 0
+	for range sub.Decisions {
+		received++
+	}
+	assert.Equal(t, received, decisionBufferSize)
+	assert.Assert(t, sub.Overflowed)
+
+	other := system.Ask(ref, SubscribeSchedulingDecisions{}).Get().(*DecisionSubscription)
+	system.Tell(ref, UnsubscribeSchedulingDecisions{Subscription: other})
+	_, open := <-other.Decisions
+	assert.Assert(t, !open)
+	assert.NilError(t, ref.StopAndAwaitTermination())
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 )
 
@@ -15,15 +16,29 @@ type TaskSummary struct {
 	ResourcePool   string             `json:"resource_pool"`
 	SlotsNeeded    int                `json:"slots_needed"`
 	Containers     []ContainerSummary `json:"containers"`
+	ExperimentID   *int               `json:"experiment_id,omitempty"`
+	// PendingReason is why a task without containers waits for resources: PendingMaintenance,
+	// PendingMaxActiveExperiments or PendingInsufficientSlots.
+	PendingReason string `json:"pending_reason,omitempty"`
+	// Group is the actor that the task is scheduled as part of (e.g., the experiment of a trial).
+	Group *actor.Ref `json:"-"`
 }
 
-func newTaskSummary(request *AllocateRequest, allocated *ResourcesAllocated) TaskSummary {
+func newTaskSummary(
+	request *AllocateRequest, allocated *ResourcesAllocated, heldReason string,
+) TaskSummary {
 	// Summary returns a new immutable view of the task state.
 	containerSummaries := make([]ContainerSummary, 0)
-	if allocated != nil {
+	var pendingReason string
+	switch {
+	case allocated != nil:
 		for _, c := range allocated.Allocations {
 			containerSummaries = append(containerSummaries, c.Summary())
 		}
+	case heldReason != "":
+		pendingReason = heldReason
+	default:
+		pendingReason = PendingInsufficientSlots
 	}
 	return TaskSummary{
 		ID:             request.ID,
@@ -32,6 +47,9 @@ func newTaskSummary(request *AllocateRequest, allocated *ResourcesAllocated) Tas
 		ResourcePool:   request.ResourcePool,
 		SlotsNeeded:    request.SlotsNeeded,
 		Containers:     containerSummaries,
+		ExperimentID:   request.ExperimentID,
+		PendingReason:  pendingReason,
+		Group:          request.Group,
 	}
 }
 
@@ -50,19 +68,21 @@ func newAgentSummary(state *agentState) sproto.AgentSummary {
 	}
 }
 
-func getTaskSummary(reqList *taskList, id TaskID) *TaskSummary {
+// getTaskSummary returns the summary of a task, or nil if it is not in the list. The tasks in held
+// are held back for the reason that they map to.
+func getTaskSummary(reqList *taskList, held map[TaskID]string, id TaskID) *TaskSummary {
 	if req, ok := reqList.GetTaskByID(id); ok {
-		summary := newTaskSummary(req, reqList.GetAllocations(req.TaskActor))
+		summary := newTaskSummary(req, reqList.GetAllocations(req.TaskActor), held[id])
 		return &summary
 	}
 	return nil
 }
 
-func getTaskSummaries(reqList *taskList) map[TaskID]TaskSummary {
+func getTaskSummaries(reqList *taskList, held map[TaskID]string) map[TaskID]TaskSummary {
 	ret := make(map[TaskID]TaskSummary)
 	for it := reqList.iterator(); it.next(); {
 		req := it.value()
-		ret[req.ID] = newTaskSummary(req, reqList.GetAllocations(req.TaskActor))
+		ret[req.ID] = newTaskSummary(req, reqList.GetAllocations(req.TaskActor), held[req.ID])
 	}
 	return ret
 }
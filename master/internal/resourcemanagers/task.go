@@ -1,9 +1,12 @@
 package resourcemanagers
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 // Task-related cluster level messages.
@@ -19,6 +22,18 @@ type (
 		ResourcePool        string
 		FittingRequirements FittingRequirements
 		TaskActor           *actor.Ref
+		// RequestTime is when the task asked for resources. It defaults to when the resource pool
+		// receives the request.
+		RequestTime time.Time
+
+		// The user and the experiment or command that the allocations of the task are accounted to.
+		UserID       *model.UserID
+		ExperimentID *int
+		CommandID    *string
+		// BypassMaintenance lets the task, and with it the other tasks of its group, be allocated
+		// resources during maintenance even though the group did not ask for resources before the
+		// maintenance started.
+		BypassMaintenance bool
 	}
 	// ResourcesReleased notifies resource providers to return resources from a task.
 	ResourcesReleased struct {
@@ -33,6 +48,13 @@ type (
 		Name        string
 		TaskHandler *actor.Ref
 	}
+	// SetMaintenance stops or resumes allocating resources to new work. During maintenance, only
+	// the groups that had asked for resources before it started and the tasks that bypass it are
+	// allocated resources. Tasks that have been allocated resources keep them.
+	SetMaintenance struct{ Enabled bool }
+	// SetSchedulerPaused pauses or resumes allocating resources to tasks, independently of the
+	// maintenance mode. Tasks that have been allocated resources keep them.
+	SetSchedulerPaused struct{ Paused bool }
 )
 
 // Incoming task actor messages; task actors must accept these messages.
@@ -47,6 +69,15 @@ type (
 	ReleaseResources struct {
 		ResourcePool string
 	}
+	// RegistryTokenFetched notifies the task actor that the registry token that a container of
+	// the task pulls its image with was fetched, or failed to be. The task actor calls Resume to
+	// start the container, unless it has released the resources of the task since.
+	RegistryTokenFetched struct {
+		ID         TaskID
+		Allocation Allocation
+
+		resume func(ctx *actor.Context)
+	}
 )
 
 // TaskID is the ID of a task.
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket rate-limits the asks that restoring experiments and trials make of the resource
+// manager, so that restoring many experiments when the master starts doesn't swamp it. A nil
+// tokenBucket doesn't limit anything.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+// newTokenBucket returns a bucket that hands out rate tokens per second, up to burst of them at
+// once after a lull, or nil if rate is zero.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	b := &tokenBucket{
+		rate: rate, burst: float64(burst), tokens: float64(burst), now: time.Now, sleep: time.Sleep,
+	}
+	b.last = b.now()
+	return b
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it. Tokens are
+// handed out in the order that they were reserved, so waiting callers are not starved.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// wait blocks until the caller may ask the resource manager for resources.
+func (b *tokenBucket) wait() {
+	if b == nil {
+		return
+	}
+	if d := b.reserve(); d > 0 {
+		b.sleep(d)
+	}
+}
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Now()
+	now := start
+	b := newTokenBucket(2, 3)
+	b.now = func() time.Time { return now }
+	b.last = start
+
+	// The burst is handed out right away.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, b.reserve(), time.Duration(0))
+	}
+	// Then callers wait their turn, one token every half second.
+	assert.Equal(t, b.reserve(), 500*time.Millisecond)
+	assert.Equal(t, b.reserve(), time.Second)
+
+	// Tokens refill over time, but never beyond the burst.
+	now = start.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, b.reserve(), time.Duration(0))
+	}
+	assert.Equal(t, b.reserve(), 500*time.Millisecond)
+}
+
+func TestTokenBucketDisabled(t *testing.T) {
+	b := newTokenBucket(0, 10)
+	assert.Assert(t, b == nil)
+	// A nil bucket never blocks.
+	b.wait()
+}
+
+// fakeClock is a clock for token buckets that moves forward when they sleep instead of blocking.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRestoreTrialsWaitOnRateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	start := clock.Now()
+	b := newTokenBucket(2, 1)
+	b.now, b.sleep, b.last = clock.Now, clock.Sleep, start
+
+	system := actor.NewSystem("")
+	var restored []time.Duration
+	var trials []*actor.Ref
+	for i := 0; i < 4; i++ {
+		trial, created := system.ActorOf(actor.Addr(fmt.Sprintf("trial-%d", i)), actor.ActorFunc(
+			func(ctx *actor.Context) error {
+				if _, ok := ctx.Message().(restoreTrial); ok {
+					restored = append(restored, clock.Now().Sub(start))
+					ctx.Respond(true)
+				}
+				return nil
+			}))
+		assert.Assert(t, created)
+		trials = append(trials, trial)
+	}
+
+	// After the burst, each trial waits for its token before it restores and asks the resource
+	// manager for resources.
+	restoreTrialsRateLimited(system, b, trials)
+	assert.DeepEqual(t, restored, []time.Duration{
+		0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond,
+	})
+}
+
+func TestRestoreConcurrency(t *testing.T) {
+	const concurrency = 3
+	var toRestore []*model.Experiment
+	for i := 0; i < 10; i++ {
+		toRestore = append(toRestore, &model.Experiment{ID: i})
+	}
+
+	var mu sync.Mutex
+	var running, maxRunning int
+	var restored []int
+	started := make(chan int, len(toRestore))
+	release := make(chan struct{})
+	var done sync.WaitGroup
+	done.Add(len(toRestore))
+	restoreConcurrently(toRestore, concurrency, func(exp *model.Experiment) {
+		defer done.Done()
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		restored = append(restored, exp.ID)
+		mu.Unlock()
+		started <- exp.ID
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	receiveStarted := func() int {
+		select {
+		case id := <-started:
+			return id
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an experiment to be restored")
+			return 0
+		}
+	}
+	for i := 0; i < concurrency; i++ {
+		receiveStarted()
+	}
+	// No more experiments are restored until one of the running restores finishes.
+	select {
+	case id := <-started:
+		t.Fatalf("experiment %d was restored while %d others were", id, concurrency)
+	case <-time.After(50 * time.Millisecond):
+	}
+	release <- struct{}{}
+	receiveStarted()
+
+	close(release)
+	done.Wait()
+	assert.Equal(t, maxRunning, concurrency)
+	assert.Equal(t, len(restored), len(toRestore))
+	// Experiments are taken up in order.
+	for i, id := range restored[:concurrency+1] {
+		assert.Assert(t, id <= concurrency, "restore %d was of experiment %d", i, id)
+	}
+}
@@ -62,7 +62,7 @@ func (r *rwCoordinator) processResourceRequest(
 	ctx *actor.Context,
 	msg resourceRequest,
 ) error {
-	a := api.WrapSocket(msg.socket, nil, false)
+	a := api.WrapSocket(msg.socket, nil, api.SocketOptions{Class: "data_layer"})
 	ref, _ := ctx.ActorOf(fmt.Sprintf("resourceRequest-socket-%d", r.numResourceRequests), a)
 	// Create a unique identifier for every socket actor.
 	r.numResourceRequests++
@@ -3,6 +3,7 @@ package sproto
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
@@ -48,14 +49,31 @@ type (
 	// StartTaskContainer notifies the agent to start the task with the provided task spec.
 	StartTaskContainer struct {
 		TaskActor *actor.Ref
+		TaskID    string
 		aproto.StartContainer
 	}
 	// KillTaskContainer notifies the agent to kill a task container.
 	KillTaskContainer struct {
 		ContainerID cproto.ID
 	}
+	// WatchAgentHeartbeats asks an agent to tell the handler when it last answered a ping of the
+	// master, and again each time it answers one, until UnwatchAgentHeartbeats.
+	WatchAgentHeartbeats struct {
+		Handler *actor.Ref
+	}
+	// UnwatchAgentHeartbeats stops WatchAgentHeartbeats.
+	UnwatchAgentHeartbeats struct {
+		Handler *actor.Ref
+	}
 )
 
+// AgentHeartbeat tells the handlers that watch the heartbeats of an agent when it answered a ping
+// of the master.
+type AgentHeartbeat struct {
+	Agent string
+	Time  time.Time
+}
+
 // AgentSummary contains information about an agent for external display.
 type AgentSummary struct {
 	Name   string
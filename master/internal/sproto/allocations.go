@@ -0,0 +1,57 @@
+package sproto
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AllocationRecorderAddr is the address of the actor that the resource managers notify of
+// allocations, which keeps their history.
+var AllocationRecorderAddr = actor.Addr("allocationRecorder")
+
+type (
+	// AllocationStarted notifies that resources were allocated to a task.
+	AllocationStarted struct {
+		TaskID       string
+		ResourcePool string
+		Slots        int
+		UserID       *model.UserID
+		ExperimentID *int
+		CommandID    *string
+		// RequestTime is when the task asked for the resources, and Time when they were allocated.
+		RequestTime time.Time
+		Time        time.Time
+	}
+	// AllocationEnded notifies that the resources allocated to a task were released.
+	AllocationEnded struct {
+		TaskID string
+		Time   time.Time
+	}
+	// AllocationFailed notifies that a container of a task failed; only the first failure of an
+	// allocation is kept.
+	AllocationFailed struct {
+		TaskID      string
+		Reason      model.FailureReason
+		FailureType string
+		Message     string
+	}
+	// AllocationFailureClassified notifies that a task found out why its latest allocation failed
+	// or was cut short. The task knows more than its containers, e.g., that it was preempted or
+	// what its logs say, so its reason replaces the one of the failure of a container.
+	AllocationFailureClassified struct {
+		TaskID string
+		Reason model.FailureReason
+	}
+	// ContainerLifecycleLog notifies that a container of a task logged an event of its lifecycle,
+	// e.g., the progress of an image pull or an error from Docker, which is kept with the task.
+	ContainerLifecycleLog struct {
+		TaskID      string
+		ContainerID string
+		Timestamp   time.Time
+		Source      string
+		Level       string
+		Message     string
+	}
+)
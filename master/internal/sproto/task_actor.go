@@ -61,28 +61,45 @@ type (
 )
 
 func (c ContainerLog) String() string {
-	msg := ""
+	shortID := c.Container.ID[:8]
+	timestamp := c.Timestamp.UTC().Format(time.RFC3339)
+	return fmt.Sprintf("[%s] %s || %s", timestamp, shortID, c.message())
+}
+
+// Lifecycle returns the source, level and message of a log about the lifecycle of the container,
+// i.e., from the agent or an image pull, with ok set to false for logs of the output of the
+// container and for the progress bars of image pulls.
+func (c ContainerLog) Lifecycle() (source, level, msg string, ok bool) {
 	switch {
 	case c.AuxMessage != nil:
-		msg = *c.AuxMessage
+		return "agent", "INFO", c.message(), true
+	case c.PullMessage != nil && c.PullMessage.Error != nil:
+		return "pull", "ERROR", c.message(), true
+	case c.PullMessage != nil && c.PullMessage.Progress == nil:
+		return "pull", "INFO", c.message(), true
+	default:
+		return "", "", "", false
+	}
+}
+
+func (c ContainerLog) message() string {
+	switch {
+	case c.AuxMessage != nil:
+		return *c.AuxMessage
 	case c.RunMessage != nil:
-		msg = strings.TrimSuffix(c.RunMessage.Value, "\n")
+		return strings.TrimSuffix(c.RunMessage.Value, "\n")
 	case c.PullMessage != nil:
 		buf := new(bytes.Buffer)
 		if err := c.PullMessage.Display(buf, false); err != nil {
-			msg = err.Error()
-		} else {
-			msg = buf.String()
-			// Docker disables printing the progress bar in non-terminal mode.
-			if msg == "" && c.PullMessage.Progress != nil {
-				msg = c.PullMessage.Progress.String()
-			}
-			msg = strings.TrimSpace(msg)
+			return err.Error()
 		}
+		msg := buf.String()
+		// Docker disables printing the progress bar in non-terminal mode.
+		if msg == "" && c.PullMessage.Progress != nil {
+			msg = c.PullMessage.Progress.String()
+		}
+		return strings.TrimSpace(msg)
 	default:
 		panic("unknown log message received")
 	}
-	shortID := c.Container.ID[:8]
-	timestamp := c.Timestamp.UTC().Format(time.RFC3339)
-	return fmt.Sprintf("[%s] %s || %s", timestamp, shortID, msg)
 }
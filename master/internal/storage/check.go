@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// bucketRule is a rule that the names of the buckets of a backend follow.
+type bucketRule struct {
+	pattern     *regexp.Regexp
+	description string
+}
+
+// gcsBuckets are the names that GCS allows for buckets.
+var gcsBuckets = &bucketRule{
+	regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,220}[a-z0-9]$`),
+	"3 to 222 lowercase letters, digits, dots, hyphens and underscores",
+}
+
+// Check checks the checkpoint storage config without reaching the backend: that buckets are named
+// as the backend requires and that credentials are complete. S3 buckets created before AWS
+// tightened its naming rules, and buckets of other endpoints, e.g., MinIO, may be named almost
+// anyhow, so only the absence of S3 bucket names and slashes in them are caught.
+func Check(config model.CheckpointStorageConfig) error {
+	switch {
+	case config.S3Config != nil:
+		s3 := config.S3Config
+		if (s3.AccessKey == nil) != (s3.SecretKey == nil) {
+			return errors.New("s3 checkpoint storage needs both access_key and secret_key, or neither")
+		}
+		return checkBucketName("s3", s3.Bucket, nil)
+	case config.GCSConfig != nil:
+		return checkBucketName("gcs", config.GCSConfig.Bucket, gcsBuckets)
+	default:
+		return nil
+	}
+}
+
+func checkBucketName(storageType, bucket string, rule *bucketRule) error {
+	switch {
+	case bucket == "":
+		return errors.Errorf("%s checkpoint storage needs a bucket", storageType)
+	case strings.ContainsAny(bucket, "/ "):
+		return errors.Errorf("%s bucket %q must not contain slashes or spaces", storageType, bucket)
+	case rule != nil && (!rule.pattern.MatchString(bucket) || strings.Contains(bucket, "..")):
+		return errors.Errorf("%s bucket %q is not a valid bucket name: bucket names have %s, "+
+			"and start and end with a letter or digit", storageType, bucket, rule.description)
+	default:
+		return nil
+	}
+}
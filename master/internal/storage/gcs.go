@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+type gcsBucket struct {
+	client *gcs.Client
+	bucket *gcs.BucketHandle
+}
+
+// newGCS connects to GCS with the default credentials of the master, as tasks do.
+func newGCS(ctx context.Context, config model.GCSConfig) (*gcsBucket, error) {
+	client, err := gcs.NewClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a GCS client")
+	}
+	return &gcsBucket{client: client, bucket: client.Bucket(config.Bucket)}, nil
+}
+
+func (b *gcsBucket) Write(ctx context.Context, name string, content []byte) error {
+	w := b.bucket.Object(name).NewWriter(ctx)
+	if _, err := w.Write(content); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (b *gcsBucket) WriteFrom(ctx context.Context, name string, r io.ReadSeeker) error {
+	w := b.bucket.Object(name).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (b *gcsBucket) Read(ctx context.Context, name string) ([]byte, error) {
+	r, err := b.bucket.Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	return ioutil.ReadAll(r)
+}
+
+func (b *gcsBucket) Delete(ctx context.Context, name string) error {
+	return b.bucket.Object(name).Delete(ctx)
+}
+
+func (b *gcsBucket) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Types of model definition storage.
+const (
+	ModelDefinitionsPostgres   = "postgres"
+	ModelDefinitionsFilesystem = "filesystem"
+	ModelDefinitionsS3         = "s3"
+)
+
+// defaultModelDefinitionsPrefix is the prefix of the names of the model definitions in S3 when
+// none is configured.
+const defaultModelDefinitionsPrefix = "model-definitions/"
+
+// ModelDefinitionsConfig configures where the master stores the model definitions of experiments.
+type ModelDefinitionsConfig struct {
+	Type string `json:"type"`
+	// Path is the directory that filesystem storage keeps model definitions in.
+	Path string `json:"path,omitempty"`
+	// S3 is the bucket that s3 storage keeps model definitions in, under Prefix.
+	S3     *model.S3Config `json:"s3,omitempty"`
+	Prefix *string         `json:"prefix,omitempty"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c ModelDefinitionsConfig) Validate() []error {
+	switch c.Type {
+	case ModelDefinitionsPostgres:
+		return nil
+	case ModelDefinitionsFilesystem:
+		return []error{check.True(c.Path != "", "filesystem model definition storage needs a path")}
+	case ModelDefinitionsS3:
+		if c.S3 == nil {
+			return []error{errors.New("s3 model definition storage needs an s3 bucket")}
+		}
+		return []error{Check(model.CheckpointStorageConfig{S3Config: c.S3})}
+	default:
+		return []error{errors.Errorf(
+			"model definition storage type must be postgres, filesystem or s3, not %q", c.Type)}
+	}
+}
+
+// ModelDefinitionTable is the database table that postgres storage keeps model definitions in.
+type ModelDefinitionTable interface {
+	AddModelDefinitionBlob(hash string, content []byte) error
+	ModelDefinitionBlob(hash string) ([]byte, error)
+	DeleteModelDefinitionBlob(hash string) error
+}
+
+// postgresBlobs keeps model definitions in a database table, named by their hashes.
+type postgresBlobs struct {
+	table ModelDefinitionTable
+}
+
+func (p postgresBlobs) Write(_ context.Context, name string, content []byte) error {
+	return p.table.AddModelDefinitionBlob(name, content)
+}
+
+func (p postgresBlobs) Read(_ context.Context, name string) ([]byte, error) {
+	return p.table.ModelDefinitionBlob(name)
+}
+
+func (p postgresBlobs) Delete(_ context.Context, name string) error {
+	return p.table.DeleteModelDefinitionBlob(name)
+}
+
+func (p postgresBlobs) Close() error {
+	return nil
+}
+
+// ModelDefinitions stores the model definitions of experiments, which are .tar.gz archives, under
+// the SHA-256 hashes of their content so that identical model definitions are stored once.
+type ModelDefinitions struct {
+	config  ModelDefinitionsConfig
+	backend Backend
+	// table keeps the model definitions that were stored in Postgres before another storage was
+	// configured, until they are migrated.
+	table ModelDefinitionTable
+}
+
+// NewModelDefinitions returns the configured model definition storage; postgres storage keeps
+// model definitions in the table, which other storage falls back to reading from.
+func NewModelDefinitions(
+	ctx context.Context, config ModelDefinitionsConfig, table ModelDefinitionTable,
+) (*ModelDefinitions, error) {
+	if err := check.Validate(config); err != nil {
+		return nil, err
+	}
+	s := &ModelDefinitions{config: config, table: table}
+	switch config.Type {
+	case ModelDefinitionsPostgres:
+		s.backend = postgresBlobs{table: table}
+	case ModelDefinitionsFilesystem:
+		if err := os.MkdirAll(config.Path, 0700); err != nil {
+			return nil, errors.Wrapf(err, "failed to create model definition directory %s",
+				config.Path)
+		}
+		s.backend = sharedFS{dir: config.Path}
+	case ModelDefinitionsS3:
+		backend, err := newS3(ctx, *config.S3)
+		if err != nil {
+			return nil, err
+		}
+		s.backend = backend
+	}
+	return s, nil
+}
+
+// Type returns the type of the storage, as it is named in configs.
+func (s *ModelDefinitions) Type() string {
+	return s.config.Type
+}
+
+// Hash returns the hash that identifies a model definition in storage.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// name returns the name of the model definition with the hash in the backend.
+func (s *ModelDefinitions) name(hash string) string {
+	switch s.config.Type {
+	case ModelDefinitionsFilesystem:
+		return hash + ".tar.gz"
+	case ModelDefinitionsS3:
+		prefix := defaultModelDefinitionsPrefix
+		if s.config.Prefix != nil {
+			prefix = *s.config.Prefix
+		}
+		return path.Join(prefix, hash+".tar.gz")
+	default:
+		return hash
+	}
+}
+
+// Put stores a model definition, unless an identical one is stored already, and returns its hash.
+func (s *ModelDefinitions) Put(ctx context.Context, content []byte) (string, error) {
+	hash := Hash(content)
+	if err := s.backend.Write(ctx, s.name(hash), content); err != nil {
+		return "", errors.Wrapf(err, "failed to store model definition %s", hash)
+	}
+	return hash, nil
+}
+
+// PutFrom stores the model definition that the reader holds, unless an identical one is stored
+// already, and returns its hash. The model definition is hashed and, unless the backend is
+// postgres, stored by streaming it rather than holding it in memory.
+func (s *ModelDefinitions) PutFrom(ctx context.Context, r io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", errors.Wrap(err, "failed to hash model definition")
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	var err error
+	if w, ok := s.backend.(streamWriter); ok {
+		err = w.WriteFrom(ctx, s.name(hash), r)
+	} else {
+		var content []byte
+		if content, err = ioutil.ReadAll(r); err == nil {
+			err = s.backend.Write(ctx, s.name(hash), content)
+		}
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to store model definition %s", hash)
+	}
+	return hash, nil
+}
+
+// Get returns the model definition with the hash, checking that its content has that hash. Model
+// definitions that are missing from the backend are read from the table, if they are still there.
+func (s *ModelDefinitions) Get(ctx context.Context, hash string) ([]byte, error) {
+	content, err := s.backend.Read(ctx, s.name(hash))
+	if err != nil && s.config.Type != ModelDefinitionsPostgres && s.table != nil {
+		if fromTable, tErr := s.table.ModelDefinitionBlob(hash); tErr == nil {
+			content, err = fromTable, nil
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load model definition %s", hash)
+	}
+	if actual := Hash(content); actual != hash {
+		return nil, errors.Errorf(
+			"model definition %s is corrupt: its content has the hash %s", hash, actual)
+	}
+	return content, nil
+}
+
+// Delete deletes the model definition with the hash, along with any copy of it that is left in
+// the table. Deleting a model definition that is not stored is not an error.
+func (s *ModelDefinitions) Delete(ctx context.Context, hash string) error {
+	if err := s.backend.Delete(ctx, s.name(hash)); err != nil &&
+		!os.IsNotExist(errors.Cause(err)) {
+		return errors.Wrapf(err, "failed to delete model definition %s", hash)
+	}
+	if s.config.Type != ModelDefinitionsPostgres && s.table != nil {
+		return s.table.DeleteModelDefinitionBlob(hash)
+	}
+	return nil
+}
+
+// Close closes the connection to the backend of the storage.
+func (s *ModelDefinitions) Close() error {
+	return s.backend.Close()
+}
@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+type fakeModelDefinitionTable map[string][]byte
+
+func (t fakeModelDefinitionTable) AddModelDefinitionBlob(hash string, content []byte) error {
+	if _, ok := t[hash]; !ok {
+		t[hash] = content
+	}
+	return nil
+}
+
+func (t fakeModelDefinitionTable) ModelDefinitionBlob(hash string) ([]byte, error) {
+	content, ok := t[hash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return content, nil
+}
+
+func (t fakeModelDefinitionTable) DeleteModelDefinitionBlob(hash string) error {
+	delete(t, hash)
+	return nil
+}
+
+func TestFilesystemModelDefinitions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "model-definitions-test")
+	assert.NilError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	ctx := context.Background()
+	path := filepath.Join(dir, "model_defs")
+	s, err := NewModelDefinitions(ctx, ModelDefinitionsConfig{
+		Type: ModelDefinitionsFilesystem, Path: path,
+	}, nil)
+	assert.NilError(t, err)
+
+	content := []byte("model definition")
+	hash, err := s.Put(ctx, content)
+	assert.NilError(t, err)
+	assert.Equal(t, hash, Hash(content))
+	// Identical model definitions are stored once.
+	again, err := s.Put(ctx, content)
+	assert.NilError(t, err)
+	assert.Equal(t, again, hash)
+	streamed, err := s.PutFrom(ctx, bytes.NewReader(content))
+	assert.NilError(t, err)
+	assert.Equal(t, streamed, hash)
+	files, err := ioutil.ReadDir(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 1)
+
+	read, err := s.Get(ctx, hash)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, read, content)
+
+	// Corrupt copies are detected.
+	assert.NilError(t, ioutil.WriteFile(
+		filepath.Join(path, hash+".tar.gz"), []byte("corrupt"), 0600))
+	_, err = s.Get(ctx, hash)
+	assert.ErrorContains(t, err, "is corrupt")
+
+	assert.NilError(t, s.Delete(ctx, hash))
+	assert.NilError(t, s.Delete(ctx, hash))
+	_, err = s.Get(ctx, hash)
+	assert.ErrorContains(t, err, "failed to load model definition")
+}
+
+func TestModelDefinitionsFallBackToTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "model-definitions-test")
+	assert.NilError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	ctx := context.Background()
+	content := []byte("model definition")
+	table := fakeModelDefinitionTable{Hash(content): content}
+	s, err := NewModelDefinitions(ctx, ModelDefinitionsConfig{
+		Type: ModelDefinitionsFilesystem, Path: dir,
+	}, table)
+	assert.NilError(t, err)
+
+	// Model definitions that have not been migrated out of the table yet are read from it.
+	read, err := s.Get(ctx, Hash(content))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, read, content)
+
+	assert.NilError(t, s.Delete(ctx, Hash(content)))
+	assert.DeepEqual(t, table, fakeModelDefinitionTable{})
+	_, err = s.Get(ctx, Hash(content))
+	assert.ErrorContains(t, err, "failed to load model definition")
+}
+
+// fakeS3 is an S3 endpoint that keeps objects in memory and supports what the model definition
+// storage uses: putting, getting and deleting objects with path-style URLs.
+type fakeS3 struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		content, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.objects[r.URL.Path] = content
+	case http.MethodGet:
+		content, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+				`<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+			return
+		}
+		_, _ = w.Write(content)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3ModelDefinitions(t *testing.T) {
+	bucket := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+
+	ctx := context.Background()
+	key, secret, prefix := "key", "secret", "defs/"
+	old := []byte("model definition stored in postgres")
+	table := fakeModelDefinitionTable{Hash(old): old}
+	s, err := NewModelDefinitions(ctx, ModelDefinitionsConfig{
+		Type: ModelDefinitionsS3,
+		S3: &model.S3Config{
+			Bucket: "model-defs", AccessKey: &key, SecretKey: &secret, EndpointURL: &server.URL,
+		},
+		Prefix: &prefix,
+	}, table)
+	assert.NilError(t, err)
+
+	content := []byte("model definition")
+	hash, err := s.PutFrom(ctx, bytes.NewReader(content))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, bucket.objects,
+		map[string][]byte{"/model-defs/defs/" + hash + ".tar.gz": content})
+	read, err := s.Get(ctx, hash)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, read, content)
+
+	read, err = s.Get(ctx, Hash(old))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, read, old)
+
+	bucket.objects["/model-defs/defs/"+hash+".tar.gz"] = []byte("corrupt")
+	_, err = s.Get(ctx, hash)
+	assert.ErrorContains(t, err, "is corrupt")
+
+	assert.NilError(t, s.Delete(ctx, hash))
+	assert.Equal(t, len(bucket.objects), 0)
+	_, err = s.Get(ctx, hash)
+	assert.ErrorContains(t, err, "failed to load model definition")
+}
+
+func TestPostgresModelDefinitions(t *testing.T) {
+	ctx := context.Background()
+	table := fakeModelDefinitionTable{}
+	s, err := NewModelDefinitions(ctx, ModelDefinitionsConfig{Type: ModelDefinitionsPostgres}, table)
+	assert.NilError(t, err)
+
+	content := []byte("model definition")
+	hash, err := s.PutFrom(ctx, bytes.NewReader(content))
+	assert.NilError(t, err)
+	assert.Equal(t, hash, Hash(content))
+	assert.DeepEqual(t, table, fakeModelDefinitionTable{hash: content})
+	read, err := s.Get(ctx, hash)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, read, content)
+}
+
+func TestModelDefinitionsConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ModelDefinitionsConfig
+		err    string
+	}{
+		{name: "postgres", config: ModelDefinitionsConfig{Type: ModelDefinitionsPostgres}},
+		{
+			name:   "filesystem without path",
+			config: ModelDefinitionsConfig{Type: ModelDefinitionsFilesystem},
+			err:    "filesystem model definition storage needs a path",
+		},
+		{
+			name:   "s3 without bucket",
+			config: ModelDefinitionsConfig{Type: ModelDefinitionsS3},
+			err:    "s3 model definition storage needs an s3 bucket",
+		},
+		{
+			name: "s3",
+			config: ModelDefinitionsConfig{
+				Type: ModelDefinitionsS3, S3: &model.S3Config{Bucket: "model-defs"},
+			},
+		},
+		{
+			name:   "unknown type",
+			config: ModelDefinitionsConfig{Type: "hdfs"},
+			err:    `must be postgres, filesystem or s3, not "hdfs"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := check.Validate(tc.config)
+			if tc.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
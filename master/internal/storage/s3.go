@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// defaultS3Region is the region to look up the region of buckets in when none is configured.
+const defaultS3Region = "us-east-1"
+
+type s3Bucket struct {
+	client *s3.S3
+	bucket string
+}
+
+func newS3(ctx context.Context, config model.S3Config) (*s3Bucket, error) {
+	awsConfig := aws.NewConfig()
+	if config.AccessKey != nil && config.SecretKey != nil {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(
+			*config.AccessKey, *config.SecretKey, ""))
+	}
+	if config.EndpointURL != nil {
+		awsConfig = awsConfig.WithEndpoint(*config.EndpointURL).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create an S3 session")
+	}
+
+	// Endpoints other than AWS, e.g., MinIO, accept any region.
+	if aws.StringValue(sess.Config.Region) == "" {
+		region := defaultS3Region
+		if config.EndpointURL == nil {
+			if region, err = s3manager.GetBucketRegion(
+				ctx, sess, config.Bucket, defaultS3Region); err != nil {
+				return nil, errors.Wrapf(err, "failed to find the region of bucket %s", config.Bucket)
+			}
+		}
+		sess.Config.Region = aws.String(region)
+	}
+	return &s3Bucket{client: s3.New(sess), bucket: config.Bucket}, nil
+}
+
+func (b *s3Bucket) Write(ctx context.Context, name string, content []byte) error {
+	_, err := b.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+		Body:   bytes.NewReader(content),
+	})
+	return err
+}
+
+func (b *s3Bucket) WriteFrom(ctx context.Context, name string, r io.ReadSeeker) error {
+	_, err := b.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+		Body:   r,
+	})
+	return err
+}
+
+func (b *s3Bucket) Read(ctx context.Context, name string) ([]byte, error) {
+	resp, err := b.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (b *s3Bucket) Delete(ctx context.Context, name string) error {
+	_, err := b.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+	})
+	return err
+}
+
+func (b *s3Bucket) Close() error {
+	return nil
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// sharedFS is a shared filesystem that the master mounts at the host path of the agents.
+type sharedFS struct {
+	dir string
+}
+
+func newSharedFS(config model.SharedFSConfig) sharedFS {
+	dir := config.HostPath
+	if config.StoragePath != nil {
+		if filepath.IsAbs(*config.StoragePath) {
+			dir = *config.StoragePath
+		} else {
+			dir = filepath.Join(config.HostPath, *config.StoragePath)
+		}
+	}
+	return sharedFS{dir: dir}
+}
+
+func (s sharedFS) Write(ctx context.Context, name string, content []byte) error {
+	return s.WriteFrom(ctx, name, bytes.NewReader(content))
+}
+
+// WriteFrom writes the object to a temporary file in the same directory and renames it into
+// place, so that readers, and writers of the same object, never see a partially written object.
+func (s sharedFS) WriteFrom(_ context.Context, name string, r io.ReadSeeker) error {
+	path := filepath.Join(s.dir, name)
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+func (s sharedFS) Read(_ context.Context, name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(s.dir, name))
+}
+
+func (s sharedFS) Delete(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(s.dir, name))
+}
+
+func (s sharedFS) Close() error {
+	return nil
+}
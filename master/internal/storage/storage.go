@@ -0,0 +1,132 @@
+// Package storage checks that the master can reach the checkpoint storage backend by writing,
+// reading and deleting an object in it, and stores the model definitions of experiments.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Steps of a storage test, which a failed test reports.
+const (
+	StepConnect = "connect"
+	StepWrite   = "write"
+	StepRead    = "read"
+	StepVerify  = "verify"
+	StepDelete  = "delete"
+)
+
+// Backend is a checkpoint storage backend that objects can be written to, read from and deleted
+// from. It must be closed once it is no longer used.
+type Backend interface {
+	Write(ctx context.Context, name string, content []byte) error
+	Read(ctx context.Context, name string) ([]byte, error)
+	Delete(ctx context.Context, name string) error
+	Close() error
+}
+
+// streamWriter is a Backend that can write an object from a reader without holding the whole
+// object in memory.
+type streamWriter interface {
+	WriteFrom(ctx context.Context, name string, r io.ReadSeeker) error
+}
+
+// Type returns the type of the checkpoint storage, as it is named in configs.
+func Type(config model.CheckpointStorageConfig) string {
+	switch {
+	case config.SharedFSConfig != nil:
+		return "shared_fs"
+	case config.HDFSConfig != nil:
+		return "hdfs"
+	case config.S3Config != nil:
+		return "s3"
+	case config.GCSConfig != nil:
+		return "gcs"
+	default:
+		return ""
+	}
+}
+
+// New returns the backend of the checkpoint storage.
+func New(ctx context.Context, config model.CheckpointStorageConfig) (Backend, error) {
+	if err := check.Validate(config); err != nil {
+		return nil, err
+	}
+	switch {
+	case config.SharedFSConfig != nil:
+		return newSharedFS(*config.SharedFSConfig), nil
+	case config.S3Config != nil:
+		return newS3(ctx, *config.S3Config)
+	case config.GCSConfig != nil:
+		return newGCS(ctx, *config.GCSConfig)
+	case config.HDFSConfig != nil:
+		return nil, errors.New("the master cannot test hdfs checkpoint storage")
+	default:
+		return nil, errors.New("no checkpoint storage is configured")
+	}
+}
+
+// TestResult is the result of testing a checkpoint storage backend.
+type TestResult struct {
+	Type    string `json:"type"`
+	Success bool   `json:"success"`
+	// Step is the step that the test failed at and Error is why it failed.
+	Step     string  `json:"step,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// Test writes an object to the checkpoint storage, reads it back and deletes it. It reports the
+// step that fails, if any.
+func Test(ctx context.Context, config model.CheckpointStorageConfig) TestResult {
+	start := time.Now()
+	result := TestResult{Type: Type(config)}
+	step, err := roundtrip(ctx, config)
+	if err != nil {
+		result.Step, result.Error = step, err.Error()
+	} else {
+		result.Success = true
+	}
+	result.Duration = time.Since(start).Seconds()
+	return result
+}
+
+func roundtrip(ctx context.Context, config model.CheckpointStorageConfig) (string, error) {
+	backend, err := New(ctx, config)
+	if err != nil {
+		return StepConnect, err
+	}
+	defer func() {
+		_ = backend.Close()
+	}()
+
+	name := fmt.Sprintf("determined-storage-test-%s", uuid.New())
+	content := []byte(uuid.New().String())
+	if err := backend.Write(ctx, name, content); err != nil {
+		return StepWrite, err
+	}
+	read, err := backend.Read(ctx, name)
+	if err != nil {
+		// Leave as little behind as possible.
+		_ = backend.Delete(ctx, name)
+		return StepRead, err
+	}
+	if !bytes.Equal(read, content) {
+		_ = backend.Delete(ctx, name)
+		return StepVerify, errors.Errorf(
+			"read %d bytes that differ from the %d bytes written to %s", len(read), len(content), name)
+	}
+	if err := backend.Delete(ctx, name); err != nil {
+		return StepDelete, err
+	}
+	return "", nil
+}
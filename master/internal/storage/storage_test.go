@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestSharedFSRoundtrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage-test")
+	assert.NilError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "checkpoints"), 0700))
+
+	storagePath := "checkpoints"
+	result := Test(context.Background(), model.CheckpointStorageConfig{
+		SharedFSConfig: &model.SharedFSConfig{HostPath: dir, StoragePath: &storagePath},
+	})
+	assert.Assert(t, result.Success, result.Error)
+	assert.Equal(t, result.Type, "shared_fs")
+
+	// Nothing is left behind.
+	files, err := ioutil.ReadDir(filepath.Join(dir, "checkpoints"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 0)
+}
+
+func TestStorageTestFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		config model.CheckpointStorageConfig
+		step   string
+		err    string
+	}{
+		{
+			name: "missing directory",
+			config: model.CheckpointStorageConfig{
+				SharedFSConfig: &model.SharedFSConfig{HostPath: "/nonexistent/determined"},
+			},
+			step: StepWrite,
+			err:  "no such file or directory",
+		},
+		{
+			name: "relative host path",
+			config: model.CheckpointStorageConfig{
+				SharedFSConfig: &model.SharedFSConfig{HostPath: "checkpoints"},
+			},
+			step: StepConnect,
+			err:  "host_path must be an absolute path",
+		},
+		{
+			name: "hdfs",
+			config: model.CheckpointStorageConfig{
+				HDFSConfig: &model.HDFSConfig{URL: "hdfs:9870", Path: "/checkpoints"},
+			},
+			step: StepConnect,
+			err:  "the master cannot test hdfs checkpoint storage",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Test(context.Background(), tc.config)
+			assert.Assert(t, !result.Success)
+			assert.Equal(t, result.Step, tc.step)
+			assert.Assert(t, strings.Contains(result.Error, tc.err), result.Error)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	key := "key"
+	endpoint := "http://minio:9000"
+	tests := []struct {
+		name   string
+		config model.CheckpointStorageConfig
+		err    string
+	}{
+		{
+			name:   "valid s3",
+			config: model.CheckpointStorageConfig{S3Config: &model.S3Config{Bucket: "my-bucket"}},
+		},
+		{
+			name: "s3 missing secret key",
+			config: model.CheckpointStorageConfig{
+				S3Config: &model.S3Config{Bucket: "my-bucket", AccessKey: &key},
+			},
+			err: "needs both access_key and secret_key",
+		},
+		{
+			name:   "s3 missing bucket",
+			config: model.CheckpointStorageConfig{S3Config: &model.S3Config{}},
+			err:    "s3 checkpoint storage needs a bucket",
+		},
+		{
+			name:   "s3 legacy bucket",
+			config: model.CheckpointStorageConfig{S3Config: &model.S3Config{Bucket: "My_Bucket"}},
+		},
+		{
+			name: "s3 bucket elsewhere",
+			config: model.CheckpointStorageConfig{
+				S3Config: &model.S3Config{Bucket: "My_Bucket", EndpointURL: &endpoint},
+			},
+		},
+		{
+			name:   "gcs bucket with path",
+			config: model.CheckpointStorageConfig{GCSConfig: &model.GCSConfig{Bucket: "bucket/dir"}},
+			err:    "must not contain slashes or spaces",
+		},
+		{
+			name:   "valid gcs",
+			config: model.CheckpointStorageConfig{GCSConfig: &model.GCSConfig{Bucket: "my_bucket"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Check(tc.config)
+			if tc.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/union"
+)
+
+// submitValidator checks that an experiment follows a submission policy of the cluster. The
+// returned error explains the policy to the user who submitted the experiment.
+type submitValidator interface {
+	validateExperiment(config model.ExperimentConfig) error
+}
+
+// SubmitValidatorConfig configures one of the checks that experiments must pass to be created.
+type SubmitValidatorConfig struct {
+	RequiredLabels *RequiredLabelsValidatorConfig `union:"type,required_labels" json:"-"`
+	ImageAllowlist *ImageAllowlistValidatorConfig `union:"type,image_allowlist" json:"-"`
+	MaxSlots       *MaxSlotsValidatorConfig       `union:"type,max_slots" json:"-"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s SubmitValidatorConfig) MarshalJSON() ([]byte, error) {
+	return union.Marshal(s)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *SubmitValidatorConfig) UnmarshalJSON(data []byte) error {
+	return union.Unmarshal(data, s)
+}
+
+// Validate implements the check.Validatable interface.
+func (s SubmitValidatorConfig) Validate() []error {
+	if s.validator() == nil {
+		return []error{errors.New(
+			"submit_validators must have a type of required_labels, image_allowlist or max_slots")}
+	}
+	return nil
+}
+
+func (s SubmitValidatorConfig) validator() submitValidator {
+	switch {
+	case s.RequiredLabels != nil:
+		return s.RequiredLabels
+	case s.ImageAllowlist != nil:
+		return s.ImageAllowlist
+	case s.MaxSlots != nil:
+		return s.MaxSlots
+	default:
+		return nil
+	}
+}
+
+// validateSubmission runs the submit validators against the configuration of an experiment and
+// returns the error of the first one that fails.
+func validateSubmission(validators []SubmitValidatorConfig, config model.ExperimentConfig) error {
+	for _, v := range validators {
+		if validator := v.validator(); validator != nil {
+			if err := validator.validateExperiment(config); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RequiredLabelsValidatorConfig requires experiments to have labels matching each of the regular
+// expressions in Labels, e.g., "^cost-center:" for a label naming the cost center.
+type RequiredLabelsValidatorConfig struct {
+	Labels []string `json:"labels"`
+	// Message replaces the default error message, e.g., to explain how to pick the label.
+	Message string `json:"message"`
+}
+
+// Validate implements the check.Validatable interface.
+func (r RequiredLabelsValidatorConfig) Validate() []error {
+	if len(r.Labels) == 0 {
+		return []error{errors.New("required_labels validators must list labels")}
+	}
+	return validatePatterns("required_labels", "labels", r.Labels)
+}
+
+func (r RequiredLabelsValidatorConfig) validateExperiment(config model.ExperimentConfig) error {
+	var missing []string
+	for _, pattern := range r.Labels {
+		re := regexp.MustCompile(pattern)
+		found := false
+		for label := range config.Labels {
+			if re.MatchString(label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, pattern)
+		}
+	}
+	switch {
+	case len(missing) == 0:
+		return nil
+	case r.Message != "":
+		return errors.New(r.Message)
+	default:
+		return errors.Errorf("experiments must have labels matching: %s",
+			strings.Join(missing, ", "))
+	}
+}
+
+// ImageAllowlistValidatorConfig requires the CPU and GPU images of experiments to match one of the
+// regular expressions in Images, e.g., "^registry.example.com/" for images of a private registry.
+type ImageAllowlistValidatorConfig struct {
+	Images []string `json:"images"`
+	// Message replaces the default error message, e.g., to explain how to get an image allowed.
+	Message string `json:"message"`
+}
+
+// Validate implements the check.Validatable interface.
+func (i ImageAllowlistValidatorConfig) Validate() []error {
+	if len(i.Images) == 0 {
+		return []error{errors.New("image_allowlist validators must list images")}
+	}
+	return validatePatterns("image_allowlist", "images", i.Images)
+}
+
+func (i ImageAllowlistValidatorConfig) validateExperiment(config model.ExperimentConfig) error {
+	images := map[string]bool{
+		config.Environment.Image.CPU: true,
+		config.Environment.Image.GPU: true,
+	}
+	var denied []string
+	for image := range images {
+		allowed := false
+		for _, pattern := range i.Images {
+			if regexp.MustCompile(pattern).MatchString(image) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			denied = append(denied, image)
+		}
+	}
+	sort.Strings(denied)
+	switch {
+	case len(denied) == 0:
+		return nil
+	case i.Message != "":
+		return errors.New(i.Message)
+	default:
+		return errors.Errorf("images are not allowed: %s", strings.Join(denied, ", "))
+	}
+}
+
+// MaxSlotsValidatorConfig limits the number of slots that each trial of an experiment may use.
+type MaxSlotsValidatorConfig struct {
+	MaxSlots int `json:"max_slots"`
+	// Message replaces the default error message.
+	Message string `json:"message"`
+}
+
+// Validate implements the check.Validatable interface.
+func (m MaxSlotsValidatorConfig) Validate() []error {
+	if m.MaxSlots < 0 {
+		return []error{errors.New("max_slots validators must have a max_slots of at least 0")}
+	}
+	return nil
+}
+
+func (m MaxSlotsValidatorConfig) validateExperiment(config model.ExperimentConfig) error {
+	switch {
+	case config.Resources.SlotsPerTrial <= m.MaxSlots:
+		return nil
+	case m.Message != "":
+		return errors.New(m.Message)
+	default:
+		return errors.Errorf("resources.slots_per_trial must be at most %d", m.MaxSlots)
+	}
+}
+
+func validatePatterns(validator, field string, patterns []string) []error {
+	var errs []error
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, errors.Wrapf(err,
+				"%s validators must have valid regular expressions in %s", validator, field))
+		}
+	}
+	return errs
+}
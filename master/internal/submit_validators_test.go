@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestSubmitValidatorsParsing(t *testing.T) {
+	var validators []SubmitValidatorConfig
+	assert.NilError(t, json.Unmarshal([]byte(`[
+		{"type": "required_labels", "labels": ["^cost-center:"]},
+		{"type": "image_allowlist", "images": ["^determinedai/"], "message": "use a stock image"},
+		{"type": "max_slots", "max_slots": 8}
+	]`), &validators))
+	assert.NilError(t, check.Validate(validators))
+	assert.DeepEqual(t, validators[0].RequiredLabels.Labels, []string{"^cost-center:"})
+	assert.Equal(t, validators[1].ImageAllowlist.Message, "use a stock image")
+	assert.Equal(t, validators[2].MaxSlots.MaxSlots, 8)
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`[{"type": "max_gpus"}]`), &validators),
+		"unexpected type: max_gpus")
+	assert.ErrorContains(t, check.Validate([]SubmitValidatorConfig{{}}),
+		"submit_validators must have a type")
+	assert.ErrorContains(t, check.Validate([]SubmitValidatorConfig{{
+		RequiredLabels: &RequiredLabelsValidatorConfig{Labels: []string{"("}},
+	}}), "required_labels validators must have valid regular expressions in labels")
+	assert.ErrorContains(t, check.Validate([]SubmitValidatorConfig{{
+		ImageAllowlist: &ImageAllowlistValidatorConfig{},
+	}}), "image_allowlist validators must list images")
+}
+
+func TestValidateSubmission(t *testing.T) {
+	validators := []SubmitValidatorConfig{
+		{RequiredLabels: &RequiredLabelsValidatorConfig{Labels: []string{"^cost-center:", "^team:"}}},
+		{ImageAllowlist: &ImageAllowlistValidatorConfig{Images: []string{"^determinedai/"}}},
+		{MaxSlots: &MaxSlotsValidatorConfig{MaxSlots: 8, Message: "ask for a reservation"}},
+	}
+	config := model.ExperimentConfig{
+		Labels: model.Labels{"cost-center:research": true, "team:vision": true},
+		Environment: model.Environment{Image: model.RuntimeItem{
+			CPU: "determinedai/environments:cpu", GPU: "determinedai/environments:gpu",
+		}},
+		Resources: model.ResourcesConfig{SlotsPerTrial: 8},
+	}
+	assert.NilError(t, validateSubmission(nil, config))
+	assert.NilError(t, validateSubmission(validators, config))
+
+	unlabeled := config
+	unlabeled.Labels = model.Labels{"team:vision": true}
+	assert.Error(t, validateSubmission(validators, unlabeled),
+		"experiments must have labels matching: ^cost-center:")
+
+	customImage := config
+	customImage.Environment.Image.GPU = "example.com/custom:gpu"
+	assert.Error(t, validateSubmission(validators, customImage),
+		"images are not allowed: example.com/custom:gpu")
+
+	large := config
+	large.Resources.SlotsPerTrial = 16
+	assert.Error(t, validateSubmission(validators, large), "ask for a reservation")
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+)
+
+// taskContainerLogCleanerInterval is the time between passes of the task container log cleaner.
+const taskContainerLogCleanerInterval = time.Hour
+
+type taskContainerLogCleanerTick struct{}
+
+// taskContainerLogCleaner periodically deletes the logs of the lifecycle of task containers that
+// are older than the configured number of days, so that the table does not grow without bound.
+type taskContainerLogCleaner struct {
+	db   *db.PgDB
+	days int
+}
+
+// Receive implements the actor.Actor interface.
+func (c *taskContainerLogCleaner) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		if c.days > 0 {
+			actors.NotifyAfter(ctx, 0, taskContainerLogCleanerTick{})
+		}
+
+	case taskContainerLogCleanerTick:
+		c.cleanUp(ctx)
+		actors.NotifyAfter(ctx, taskContainerLogCleanerInterval, taskContainerLogCleanerTick{})
+
+	case actor.PostStop:
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (c *taskContainerLogCleaner) cleanUp(ctx *actor.Context) {
+	deleted, err := c.db.DeleteTaskContainerLogsBefore(
+		time.Now().Add(-time.Duration(c.days) * 24 * time.Hour))
+	if err != nil {
+		// Log the error but carry on so that the next pass is still scheduled.
+		ctx.Log().WithError(err).Error("cannot delete task container logs")
+		return
+	}
+	ctx.Log().Infof("deleted %d task container logs older than %d days", deleted, c.days)
+}
@@ -9,9 +9,14 @@ import (
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/redact"
 )
 
 func report(system *actor.System, event string, properties map[string]interface{}) {
+	// Reported configuration never includes secrets.
+	for key, value := range properties {
+		properties[key] = redact.Redact(value)
+	}
 	system.TellAt(
 		actor.Addr("telemetry"),
 		analytics.Track{Event: event, Properties: analytics.Properties(properties)},
@@ -11,15 +11,65 @@ import (
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
-	"github.com/determined-ai/determined/master/pkg/check"
 )
 
+// Categories selects the kinds of telemetry that are reported.
+type Categories struct {
+	// ClusterSnapshots are the periodic summaries of the cluster, such as the number of agents and
+	// experiments.
+	ClusterSnapshots bool `json:"cluster_snapshots"`
+	// ExperimentStateChanges are the creation of experiments and the changes to their states.
+	ExperimentStateChanges bool `json:"experiment_state_changes"`
+	// MasterLifecycle is the master starting, with its version, and stopping.
+	MasterLifecycle bool `json:"master_lifecycle"`
+	// ClusterChanges are agents connecting and disconnecting and users being created.
+	ClusterChanges bool `json:"cluster_changes"`
+}
+
+// allows returns whether events with the given name are reported.
+func (c Categories) allows(event string) bool {
+	switch event {
+	case "master_started", "master_stopped":
+		return c.MasterLifecycle
+	case "master_tick":
+		return c.ClusterSnapshots
+	case "experiment_created", "experiment_state_changed":
+		return c.ExperimentStateChanges
+	case "agent_connected", "agent_disconnected", "user_created":
+		return c.ClusterChanges
+	default:
+		return false
+	}
+}
+
+// MasterInfo identifies the master that reports telemetry.
+type MasterInfo struct {
+	ClusterID           string
+	MasterID            string
+	MasterVersion       string
+	ResourceManagerType string
+}
+
+func (i MasterInfo) identify() analytics.Identify {
+	return analytics.Identify{
+		Type:   "identify",
+		UserId: i.ClusterID,
+		Traits: analytics.Traits{
+			"go_version":            runtime.Version(),
+			"master_id":             i.MasterID,
+			"master_version":        i.MasterVersion,
+			"resource_manager_type": i.ResourceManagerType,
+		},
+	}
+}
+
 // telemetryActor manages gathering and sending telemetry data.
 type telemetryActor struct {
 	db           *db.PgDB
 	client       analytics.Client
 	tickInterval time.Duration
-	clusterID    string
+	info         MasterInfo
+	categories   Categories
 }
 
 type telemetryTick struct {
@@ -42,12 +92,7 @@ func (debugLogger) Errorf(s string, a ...interface{}) {
 
 // NewActor creates an actor to handle collecting and sending telemetry information.
 func NewActor(
-	db *db.PgDB,
-	clusterID string,
-	masterID string,
-	masterVersion string,
-	resourceManagerType string,
-	segmentKey string,
+	db *db.PgDB, info MasterInfo, segmentKey string, categories Categories,
 ) (actor.Actor, error) {
 	client, err := analytics.NewWithConfig(
 		segmentKey,
@@ -57,32 +102,66 @@ func NewActor(
 		return nil, err
 	}
 
-	err = client.Enqueue(analytics.Identify{
-		UserId: clusterID,
-		Traits: analytics.Traits{
-			"go_version":            runtime.Version(),
-			"master_id":             masterID,
-			"master_version":        masterVersion,
-			"resource_manager_type": resourceManagerType,
-		},
-	})
-	if err != nil {
-		logrus.WithError(err).Warn("failed to identify for telemetry")
+	if categories.MasterLifecycle {
+		if err := client.Enqueue(info.identify()); err != nil {
+			logrus.WithError(err).Warn("failed to identify for telemetry")
+		}
 	}
 
-	return &telemetryActor{db, client, 1 * time.Hour, clusterID}, nil
+	return &telemetryActor{db, client, 1 * time.Hour, info, categories}, nil
+}
+
+// Preview returns the messages that are reported when the master starts and then periodically,
+// given the current state of the cluster, without sending them. Messages are also timestamped and
+// given IDs when they are sent.
+func Preview(db *db.PgDB, info MasterInfo, categories Categories) ([]analytics.Message, error) {
+	var messages []analytics.Message
+	if categories.MasterLifecycle {
+		messages = append(messages, info.identify())
+	}
+	for _, event := range []string{"master_started", "master_tick"} {
+		track, ok, err := tick(db, info.ClusterID, categories, event)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			messages = append(messages, track)
+		}
+	}
+	return messages, nil
+}
+
+// tick returns the message reported for a telemetryTick with the given cause, if it is reported.
+// Snapshots of the cluster are only included if they are enabled.
+func tick(
+	db *db.PgDB, clusterID string, categories Categories, cause string,
+) (analytics.Track, bool, error) {
+	if !categories.allows(cause) {
+		return analytics.Track{}, false, nil
+	}
+	track := analytics.Track{Type: "track", UserId: clusterID, Event: cause}
+	if categories.ClusterSnapshots {
+		props, err := snapshotValues(db)
+		if err != nil {
+			return analytics.Track{}, false, err
+		}
+		track.Properties = props
+	}
+	return track, true, nil
 }
 
 func (s *telemetryActor) enqueue(ctx *actor.Context, t analytics.Track) {
-	check.Panic(check.Equal(t.UserId, ""))
-	t.UserId = s.clusterID
+	if !s.categories.allows(t.Event) {
+		return
+	}
+	t.UserId = s.info.ClusterID
 	if err := s.client.Enqueue(t); err != nil {
 		ctx.Log().WithError(err).Warnf("failed to enqueue event %s", t.Event)
 	}
 }
 
-func (s *telemetryActor) snapshotValues() (analytics.Properties, error) {
-	dbInfo, err := s.db.PeriodicTelemetryInfo()
+func snapshotValues(db *db.PgDB) (analytics.Properties, error) {
+	dbInfo, err := db.PeriodicTelemetryInfo()
 	if err != nil {
 		return nil, err
 	}
@@ -106,16 +185,15 @@ func (s *telemetryActor) Receive(ctx *actor.Context) error {
 	case telemetryTick:
 		actors.NotifyAfter(ctx, s.tickInterval, telemetryTick{"master_tick"})
 
-		props, err := s.snapshotValues()
+		track, ok, err := tick(s.db, s.info.ClusterID, s.categories, msg.cause)
 		if err != nil {
 			// Log the error but return nil so that this actor continues running.
 			ctx.Log().WithError(err).Error("failed to retrieve telemetry information")
 			return nil
 		}
-		s.enqueue(ctx, analytics.Track{
-			Event:      msg.cause,
-			Properties: props,
-		})
+		if ok {
+			s.enqueue(ctx, track)
+		}
 
 	case actor.PostStop:
 		s.enqueue(ctx, analytics.Track{
@@ -0,0 +1,43 @@
+package telemetry
+
+import (
+	"testing"
+
+	"gopkg.in/segmentio/analytics-go.v3"
+	"gotest.tools/assert"
+)
+
+func TestCategoriesAllows(t *testing.T) {
+	categories := Categories{ExperimentStateChanges: true, MasterLifecycle: true}
+	for event, allowed := range map[string]bool{
+		"master_started":           true,
+		"master_stopped":           true,
+		"master_tick":              false,
+		"experiment_created":       true,
+		"experiment_state_changed": true,
+		"agent_connected":          false,
+		"user_created":             false,
+		"unknown_event":            false,
+	} {
+		assert.Equal(t, categories.allows(event), allowed, event)
+	}
+}
+
+func TestPreviewWithoutSnapshots(t *testing.T) {
+	info := MasterInfo{ClusterID: "cluster", MasterID: "master", MasterVersion: "1.0"}
+
+	// Without snapshots, the preview does not need the database.
+	messages, err := Preview(nil, info, Categories{MasterLifecycle: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(messages), 2)
+	identify := messages[0].(analytics.Identify)
+	assert.Equal(t, identify.UserId, "cluster")
+	assert.Equal(t, identify.Traits["master_version"], "1.0")
+	assert.DeepEqual(t, messages[1], analytics.Track{
+		Type: "track", UserId: "cluster", Event: "master_started",
+	})
+
+	messages, err = Preview(nil, info, Categories{ExperimentStateChanges: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(messages), 0)
+}
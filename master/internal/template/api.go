@@ -1,7 +1,9 @@
 package template
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
 
 	"github.com/ghodss/yaml"
 	"github.com/labstack/echo"
@@ -12,9 +14,15 @@ import (
 	"github.com/determined-ai/determined/master/pkg/model"
 )
 
+// Resolver merges the config of a template onto the experiment defaults of the master, returning
+// the config that an experiment submitted with the template alone would run with.
+type Resolver func(config []byte) (json.RawMessage, error)
+
 // RegisterAPIHandler initializes and registers the API handlers for all template related features.
-func RegisterAPIHandler(echo *echo.Echo, db *db.PgDB, middleware ...echo.MiddlewareFunc) {
-	m := &manager{db: db}
+func RegisterAPIHandler(
+	echo *echo.Echo, db *db.PgDB, resolve Resolver, middleware ...echo.MiddlewareFunc,
+) {
+	m := &manager{db: db, resolve: resolve}
 	apiGroup := echo.Group("/templates", middleware...)
 	apiGroup.GET("", api.Route(m.list))
 	apiGroup.GET("/:template_name", api.Route(m.get))
@@ -22,19 +30,72 @@ func RegisterAPIHandler(echo *echo.Echo, db *db.PgDB, middleware ...echo.Middlew
 	apiGroup.DELETE("/:template_name", api.Route(m.delete))
 }
 
-type manager struct{ db *db.PgDB }
+type manager struct {
+	db      *db.PgDB
+	resolve Resolver
+}
+
+// templateView is a template as the API returns it. With preview set, it also holds the config
+// that the template resolves to, or why it does not resolve, so that users can pick a template.
+type templateView struct {
+	model.Template
+	ResolvedConfig json.RawMessage `json:"resolved_config,omitempty"`
+	ResolveError   string          `json:"resolve_error,omitempty"`
+}
+
+func (m *manager) view(tpl model.Template, preview bool) templateView {
+	view := templateView{Template: tpl}
+	if preview {
+		config, err := m.resolve(tpl.Config)
+		if err != nil {
+			view.ResolveError = err.Error()
+		} else {
+			view.ResolvedConfig = config
+		}
+	}
+	return view
+}
 
 func (m *manager) list(c echo.Context) (interface{}, error) {
-	return m.db.TemplateList()
+	args := struct {
+		Preview *bool `query:"preview"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	templates, err := m.db.TemplateList()
+	if err != nil {
+		return nil, err
+	}
+	views := make([]templateView, 0, len(templates))
+	for _, tpl := range templates {
+		views = append(views, m.view(tpl, args.Preview != nil && *args.Preview))
+	}
+	return views, nil
 }
 
 func (m *manager) get(c echo.Context) (interface{}, error) {
-	return m.db.TemplateByName(c.Param("template_name"))
+	args := struct {
+		Name    string `path:"template_name"`
+		Preview *bool  `query:"preview"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	tpl, err := m.db.TemplateByName(args.Name)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, echo.NewHTTPError(http.StatusNotFound, "template not found: "+args.Name)
+	case err != nil:
+		return nil, err
+	}
+	return m.view(tpl, args.Preview != nil && *args.Preview), nil
 }
 
 func (m *manager) put(c echo.Context) (interface{}, error) {
 	args := struct {
-		Name string `path:"template_name"`
+		Name        string  `path:"template_name"`
+		Description *string `query:"description"`
 	}{}
 	if err := api.BindArgs(&args, c); err != nil {
 		return nil, err
@@ -47,8 +108,7 @@ func (m *manager) put(c echo.Context) (interface{}, error) {
 	if err := yaml.Unmarshal(body, make(map[interface{}]interface{})); err != nil {
 		return nil, errors.Wrap(err, "invalid YAML for template")
 	}
-	return nil, errors.Wrapf(
-		m.db.UpsertTemplate(&model.Template{Name: name, Config: body}),
+	return nil, errors.Wrapf(m.db.UpsertTemplate(name, args.Description, body),
 		"error putting template %q", name)
 }
 
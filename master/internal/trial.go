@@ -5,16 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/determined-ai/determined/master/pkg/workload"
 
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/events"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/webhooks"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
@@ -33,8 +37,21 @@ import (
 const (
 	allReadyTimeoutPeriod  = 10 * time.Minute
 	terminateTimeoutPeriod = time.Minute
+	// failureLogLines is how many of the last logs of a trial are searched for the cause of a
+	// failure.
+	failureLogLines = 50
+	// trialLogsFlushTimeout is how long the classification of a failure waits for the trial logger
+	// to save the last logs of the trial.
+	trialLogsFlushTimeout = 10 * time.Second
 )
 
+// containerHeartbeatType is the type of the messages that trial containers send over their
+// websockets to show that they are still up.
+const containerHeartbeatType = "HEARTBEAT"
+
+// trialSocketClass is the class of the websockets of trial containers in the websocket metrics.
+const trialSocketClass = "trial"
+
 const (
 	// MinLocalRendezvousPort is the smallest port to use (from the container's point of view;
 	// it will be mapped to some arbitrary port on the host) for communication across containers.
@@ -74,6 +91,10 @@ type (
 	killTrial    struct{}
 	restoreTrial struct{}
 	trialAborted struct{}
+	// earlyStopTrial gracefully stops a trial because of an early stopping policy.
+	earlyStopTrial struct{ reason string }
+	// getRunnerState asks a trial for the state of its task and containers.
+	getRunnerState struct{}
 
 	// This message is used to synchronize the trial workload sequencer with the searcher. It allows
 	// the searcher to get more operations to the trial workload sequencer as a result of the trial
@@ -97,6 +118,18 @@ type (
 	// running containers.
 	terminateTimeout struct{ runID int }
 
+	// While a trial has resources, it checks every heartbeat interval that its containers and
+	// agents sent heartbeats recently. Like terminateTimeout, the check is dropped if the runID
+	// has changed since it was scheduled.
+	heartbeatCheck struct{ runID int }
+	// getHeartbeats asks a trial when the containers and agents of its current run last sent
+	// heartbeats.
+	getHeartbeats struct{}
+
+	// After a failure, a trial waits for its restart backoff to elapse before it asks for
+	// resources again; this message wakes it up once the backoff has elapsed.
+	restartBackoffElapsed struct{}
+
 	containerConnected struct {
 		ContainerID cproto.ID
 		socket      *websocket.Conn
@@ -150,6 +183,43 @@ type runWorkload struct {
 	Workload workload.Workload `json:"workload"`
 }
 
+// maxContainerStateTransitions is the number of container state transitions that a trial keeps.
+const maxContainerStateTransitions = 20
+
+// containerStateTransition records a container of a trial changing state.
+type containerStateTransition struct {
+	ContainerID cproto.ID    `json:"container_id"`
+	State       cproto.State `json:"state"`
+	Time        time.Time    `json:"time"`
+}
+
+// runnerContainer describes a container of the current run of a trial.
+type runnerContainer struct {
+	ID    cproto.ID    `json:"id"`
+	Rank  *int         `json:"rank,omitempty"`
+	State cproto.State `json:"state"`
+}
+
+// runnerState describes the task and containers of a trial, in response to getRunnerState.
+type runnerState struct {
+	// TaskID is the resource manager's ID of the trial's current task, if it has asked for
+	// resources.
+	TaskID      *resourcemanagers.TaskID   `json:"task_id"`
+	Allocated   bool                       `json:"allocated"`
+	Containers  []runnerContainer          `json:"containers"`
+	Transitions []containerStateTransition `json:"transitions"`
+}
+
+// trialHeartbeats describes when the containers and agents of the current run of a trial last sent
+// heartbeats, in response to getHeartbeats.
+type trialHeartbeats struct {
+	// Timeout is the number of seconds that a container or agent may go without a heartbeat before
+	// the trial is considered lost; zero if heartbeats are disabled.
+	Timeout    int                     `json:"timeout"`
+	Containers map[cproto.ID]time.Time `json:"containers"`
+	Agents     map[string]time.Time    `json:"agents"`
+}
+
 // terminatedContainerWithState records the terminatedContainer message with some state about the
 // trial at the time termination was received. That information is analyzed when determining if a
 // trial should be considered to have errored or not.
@@ -190,6 +260,13 @@ type trial struct {
 
 	// restarts is essentially a failure count, it increments when the trial fails and we retry it.
 	restarts int
+	// restartsDueToNodeFailure counts the runs that were lost to node failures, e.g., because their
+	// agents failed or their containers or agents stopped sending heartbeats. They do not count
+	// towards max_restarts, since the trial itself did not fail.
+	restartsDueToNodeFailure int
+	// restartAfter is when the trial may ask for resources again after its latest failure.
+	restartAfter   time.Time
+	restartBackoff TrialRestartsConfig
 
 	// runID is a count of how many times the task container(s) have stopped and restarted, which
 	// could be due to a failure or due to normal pausing and continuing. When runID increments,
@@ -199,9 +276,12 @@ type trial struct {
 	runID int
 
 	replaying bool
+	// bypassMaintenance is copied from the experiment of the trial.
+	bypassMaintenance bool
 
 	// The following fields tracks the reasons for termination.
 	earlyExit                  bool
+	earlyStopped               bool
 	pendingGracefulTermination bool
 	terminationSent            bool
 	cancelUnready              bool
@@ -219,8 +299,19 @@ type trial struct {
 	containerAddresses         map[cproto.ID][]cproto.Address // only for running containers.
 	containerSockets           map[cproto.ID]*actor.Ref       // only for running containers.
 	terminatedContainers       map[cproto.ID]terminatedContainerWithState
+	containerStates            map[cproto.ID]cproto.State // only for the current allocation.
+	// stateTransitions holds the most recent container state transitions, across runs.
+	stateTransitions []containerStateTransition
 	// tracks if allReady check has passed successfully.
 	allReadySucceeded bool
+	// firstFailure is the failure of the first container of the current run that failed, which
+	// likely caused the failures of the others, since they are killed after it.
+	firstFailure *aproto.ContainerFailure
+
+	// The following fields track the heartbeats of the current run.
+	heartbeats          TrialHeartbeatsConfig
+	containerHeartbeats map[cproto.ID]time.Time // only for connected containers.
+	agentHeartbeats     map[string]time.Time    // only for agents of the current allocation.
 
 	agentUserGroup *model.AgentUserGroup
 	taskSpec       *tasks.TaskSpec
@@ -250,8 +341,11 @@ func newTrial(
 
 		sequencer: newTrialWorkloadSequencer(exp.Experiment, create, firstCheckpoint),
 
-		create:    create,
-		replaying: exp.replaying,
+		restartBackoff: exp.trialRestarts,
+
+		create:            create,
+		replaying:         exp.replaying,
+		bypassMaintenance: exp.bypassMaintenance,
 
 		startedContainers:    make(map[cproto.ID]bool),
 		containers:           make(map[cproto.ID]cproto.Container),
@@ -259,6 +353,11 @@ func newTrial(
 		containerAddresses:   make(map[cproto.ID][]cproto.Address),
 		containerSockets:     make(map[cproto.ID]*actor.Ref),
 		terminatedContainers: make(map[cproto.ID]terminatedContainerWithState),
+		containerStates:      make(map[cproto.ID]cproto.State),
+
+		heartbeats:          exp.trialHeartbeats,
+		containerHeartbeats: make(map[cproto.ID]time.Time),
+		agentHeartbeats:     make(map[string]time.Time),
 
 		agentUserGroup: exp.agentUserGroup,
 		taskSpec:       exp.taskSpec,
@@ -295,11 +394,29 @@ func (t *trial) Receive(ctx *actor.Context) error {
 	case sproto.ContainerLog:
 		t.processContainerLog(ctx, msg)
 
+	case earlyStopTrial:
+		if !t.earlyStopped {
+			ctx.Log().Infof("early stopping trial: %s", msg.reason)
+			ctx.Tell(t.logger, model.TrialLog{
+				TrialID: t.id, Message: fmt.Sprintf("early stopping trial: %s", msg.reason),
+			})
+			t.earlyStopped = true
+		}
+		if t.task != nil {
+			t.terminate(ctx, false)
+		}
+
+	case getRunnerState:
+		ctx.Respond(t.runnerState())
+
 	case trialAborted:
 		// This is to handle trial being aborted. It does nothing here but requires
 		// the code below this switch statement to handle releasing resources in
 		// the scheduler. This should be refactored into the terminating logic.
 
+	case restartBackoffElapsed:
+		// Like trialAborted, this relies on the code below to ask for resources.
+
 	case actor.PostStop:
 		if !t.idSet {
 			return nil
@@ -309,6 +426,13 @@ func (t *trial) Receive(ctx *actor.Context) error {
 				if err := t.db.UpdateTrial(t.id, model.ErrorState); err != nil {
 					ctx.Log().Error(err)
 				}
+				t.publishState(ctx, model.ErrorState)
+				webhooks.Publish(ctx.Self().System(), model.TrialErroredEvent,
+					fmt.Sprintf("/det/trials/%d", t.id), map[string]interface{}{
+						"trial_id":      t.id,
+						"experiment_id": t.experiment.ID,
+						"restarts":      t.restarts,
+					})
 			}
 			return errors.Errorf("trial %d failed and reached maximum number of restarts", t.id)
 		}
@@ -321,6 +445,12 @@ func (t *trial) Receive(ctx *actor.Context) error {
 			if err := t.db.UpdateTrial(t.id, endState); err != nil {
 				ctx.Log().Error(err)
 			}
+			t.publishState(ctx, endState)
+			if t.earlyStopped && endState == model.CompletedState {
+				if err := t.db.UpdateTrialEndReason(t.id, model.EarlyStoppedEndReason); err != nil {
+					ctx.Log().Error(err)
+				}
+			}
 		}
 		return nil
 	default:
@@ -335,7 +465,7 @@ func (t *trial) Receive(ctx *actor.Context) error {
 		if t.trialClosing() {
 			ctx.Self().Stop()
 		} else if !t.sequencer.UpToDate() && t.experimentState == model.ActiveState &&
-			!t.replaying {
+			!t.replaying && !time.Now().Before(t.restartAfter) {
 			slotsNeeded := t.experiment.Config.Resources.SlotsPerTrial
 			label := t.experiment.Config.Resources.AgentLabel
 			var name string
@@ -355,7 +485,10 @@ func (t *trial) Receive(ctx *actor.Context) error {
 				FittingRequirements: resourcemanagers.FittingRequirements{
 					SingleAgent: false,
 				},
-				TaskActor: ctx.Self(),
+				TaskActor:         ctx.Self(),
+				UserID:            t.experiment.OwnerID,
+				ExperimentID:      &t.experiment.ID,
+				BypassMaintenance: t.bypassMaintenance,
 			}
 			ctx.Tell(t.rm, *t.task)
 		}
@@ -368,7 +501,8 @@ func (t *trial) Receive(ctx *actor.Context) error {
 
 func (t *trial) runningReceive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
-	case resourcemanagers.ResourcesAllocated, resourcemanagers.ReleaseResources:
+	case resourcemanagers.ResourcesAllocated, resourcemanagers.ReleaseResources,
+		resourcemanagers.RegistryTokenFetched:
 		return t.processSchedulerMsg(ctx)
 
 	case containerConnected, sproto.TaskContainerStateChanged:
@@ -377,7 +511,14 @@ func (t *trial) runningReceive(ctx *actor.Context) error {
 	case *websocket.Conn, *apiv1.KillTrialRequest:
 		return t.processAPIMsg(ctx)
 
+	case api.MessageTooLarge:
+		t.processMessageTooLarge(ctx, msg)
+
 	case workload.CompletedMessage:
+		if msg.Type == containerHeartbeatType {
+			t.processContainerHeartbeat(ctx)
+			return nil
+		}
 		if err := t.processCompletedWorkload(ctx, msg); err != nil {
 			return err
 		}
@@ -395,6 +536,9 @@ func (t *trial) runningReceive(ctx *actor.Context) error {
 		ctx.Log().Info("found child actor failed, terminating forcibly")
 		t.terminate(ctx, true)
 
+	case setModelDefinition:
+		t.modelDefinition = msg.modelDefinition
+
 	case killTrial:
 		ctx.Log().Info("received killing request")
 		t.killed = true
@@ -418,6 +562,24 @@ func (t *trial) runningReceive(ctx *actor.Context) error {
 			t.terminate(ctx, true)
 		}
 
+	case heartbeatCheck:
+		if msg.runID == t.runID && len(t.allocations) > 0 {
+			if missed := t.missedHeartbeat(time.Now()); missed != "" {
+				t.lost(ctx, missed)
+			} else {
+				actors.NotifyAfter(ctx, t.heartbeatInterval(), heartbeatCheck{runID: t.runID})
+			}
+		}
+
+	case sproto.AgentHeartbeat:
+		// Agents of earlier runs may not have been told to stop yet.
+		if last, ok := t.agentHeartbeats[msg.Agent]; ok && msg.Time.After(last) {
+			t.agentHeartbeats[msg.Agent] = msg.Time
+		}
+
+	case getHeartbeats:
+		ctx.Respond(t.currentHeartbeats())
+
 	case actor.ChildStopped:
 
 	default:
@@ -436,8 +598,19 @@ func (t *trial) processSchedulerMsg(ctx *actor.Context) error {
 
 	case resourcemanagers.ReleaseResources:
 		ctx.Log().Info("releasing resources because of being preempted")
+		t.classifyAllocation(ctx, model.PreemptedReason)
 		return t.releaseResource(ctx)
 
+	case resourcemanagers.RegistryTokenFetched:
+		if t.task == nil || msg.ID != t.task.ID {
+			return nil
+		}
+		msg.Resume(ctx)
+		// Kills that were sent while the token was fetched did not reach the container.
+		if t.killed || t.cancelUnready {
+			msg.Allocation.Kill(ctx)
+		}
+
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
 	}
@@ -462,9 +635,15 @@ func (t *trial) processContainerMsg(ctx *actor.Context) error {
 		}
 
 	case sproto.TaskContainerStateChanged:
+		// Containers of a run that was lost may still report their state if their agent comes back.
+		if _, ok := t.containerRanks[msg.Container.ID]; !ok {
+			ctx.Log().Infof("ignoring state of stale container: %s", msg.Container.ID)
+			return nil
+		}
 		if msg.Container.State != cproto.Assigned {
 			t.startedContainers[msg.Container.ID] = true
 		}
+		t.recordContainerState(msg.Container)
 
 		switch msg.Container.State {
 		case cproto.Running:
@@ -482,7 +661,9 @@ func (t *trial) processContainerMsg(ctx *actor.Context) error {
 func (t *trial) processAPIMsg(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case *websocket.Conn:
-		a := api.WrapSocket(msg, workload.CompletedMessage{}, false)
+		a := api.WrapSocket(msg, workload.CompletedMessage{}, api.SocketOptions{
+			Class: trialSocketClass, NotifyTooLarge: true,
+		})
 		if ref, created := ctx.ActorOf("socket", a); created {
 			ctx.Respond(ref)
 		}
@@ -520,6 +701,11 @@ func (t *trial) processAllocated(
 	}
 
 	t.allocations = msg.Allocations
+	t.containerStates = make(map[cproto.ID]cproto.State)
+	for _, a := range t.allocations {
+		t.containerStates[a.Summary().ID] = cproto.Assigned
+	}
+	t.startHeartbeats(ctx)
 
 	if len(t.privateKey) == 0 {
 		generatedKeys, err := ssh.GenerateKey(nil)
@@ -542,6 +728,7 @@ func (t *trial) processAllocated(
 			return nil
 		}
 		t.processID(ctx, modelTrial.ID)
+		t.publishState(ctx, modelTrial.State)
 		if t.experiment.Config.PerformInitialValidation {
 			if err := t.db.AddNoOpStep(model.NewNoOpStep(t.id, 0)); err != nil {
 				ctx.Log().WithError(err).Error("failed to save zeroth step for initial validation")
@@ -555,6 +742,9 @@ func (t *trial) processAllocated(
 		})
 		ctx.Tell(ctx.Self().Parent(), trialCreated{create: t.create, trialID: t.id})
 	}
+	if err := t.db.AddTrialTask(t.id, string(t.task.ID)); err != nil {
+		ctx.Log().WithError(err).Warn("failed to link the trial to its allocation")
+	}
 
 	// We need to complete cached checkpoints here in the event that between when we last shutdown
 	// and now the searcher asked for a checkpoint we already created (this happens in PBT).
@@ -625,6 +815,7 @@ func (t *trial) processAllocated(
 			AgentUserGroup:      t.agentUserGroup,
 			IsMultiAgent:        len(t.allocations) > 1,
 			Rank:                rank,
+			HeartbeatInterval:   t.heartbeats.Interval,
 		}
 		a.Start(ctx, taskSpec)
 	}
@@ -753,9 +944,12 @@ func (t *trial) processContainerConnected(ctx *actor.Context, msg containerConne
 		return nil
 	}
 
-	a := api.WrapSocket(msg.socket, workload.CompletedMessage{}, false)
+	a := api.WrapSocket(msg.socket, workload.CompletedMessage{}, api.SocketOptions{
+		Class: trialSocketClass, NotifyTooLarge: true,
+	})
 	ref, _ := ctx.ActorOf(fmt.Sprintf("socket-%s", msg.ContainerID), a)
 	t.containerSockets[msg.ContainerID] = ref
+	t.containerHeartbeats[msg.ContainerID] = time.Now()
 	ctx.Respond(ref)
 
 	if err := t.pushRendezvous(ctx); err != nil {
@@ -779,6 +973,7 @@ func (t *trial) killAndRemoveSocket(ctx *actor.Context, id cproto.ID) {
 		}
 		delete(t.containerSockets, id)
 	}
+	delete(t.containerHeartbeats, id)
 }
 
 // allReady returns true if and only if all the containers are reported to be started with the
@@ -902,6 +1097,40 @@ func (t *trial) pushRendezvous(ctx *actor.Context) error {
 	return nil
 }
 
+func (t *trial) recordContainerState(container cproto.Container) {
+	t.containerStates[container.ID] = container.State
+	t.stateTransitions = append(t.stateTransitions, containerStateTransition{
+		ContainerID: container.ID,
+		State:       container.State,
+		Time:        time.Now().UTC(),
+	})
+	if extra := len(t.stateTransitions) - maxContainerStateTransitions; extra > 0 {
+		t.stateTransitions = t.stateTransitions[extra:]
+	}
+}
+
+func (t *trial) runnerState() runnerState {
+	state := runnerState{
+		Allocated:   len(t.allocations) > 0,
+		Containers:  make([]runnerContainer, 0, len(t.containerStates)),
+		Transitions: append([]containerStateTransition{}, t.stateTransitions...),
+	}
+	if t.task != nil {
+		state.TaskID = &t.task.ID
+	}
+	for id, containerState := range t.containerStates {
+		container := runnerContainer{ID: id, State: containerState}
+		if rank, ok := t.containerRanks[id]; ok {
+			container.Rank = &rank
+		}
+		state.Containers = append(state.Containers, container)
+	}
+	sort.Slice(state.Containers, func(i, j int) bool {
+		return state.Containers[i].ID < state.Containers[j].ID
+	})
+	return state
+}
+
 func (t *trial) processContainerRunning(
 	ctx *actor.Context, msg sproto.TaskContainerStateChanged,
 ) error {
@@ -926,6 +1155,10 @@ func (t *trial) processContainerTerminated(
 		pendingGracefulTermination: t.pendingGracefulTermination,
 		needsCheckpoint:            t.sequencer.PrecloseCheckpointWorkload() != nil,
 	}
+	if failure := msg.ContainerStopped.Failure; failure != nil && t.firstFailure == nil &&
+		failure.FailureType != aproto.TaskAborted {
+		t.firstFailure = failure
+	}
 
 	_, ok := t.containers[msg.Container.ID]
 	delete(t.containers, msg.Container.ID)
@@ -970,6 +1203,25 @@ func (t *trial) processContainerLog(ctx *actor.Context, msg sproto.ContainerLog)
 		return
 	}
 
+	// Logs about the lifecycle of the container, e.g., of pulling its image, are labeled by where
+	// they came from, so that they stand apart from the output of the trial.
+	if source, level, lifecycleMsg, ok := msg.Lifecycle(); ok {
+		cid := string(msg.Container.ID)
+		log := fmt.Sprintf("[%s] %s\n", source, lifecycleMsg)
+		stdType := "stdout"
+		ctx.Tell(t.logger, model.TrialLog{
+			TrialID: t.id,
+			Log:     &log,
+
+			ContainerID: &cid,
+			Timestamp:   &msg.Timestamp,
+			Level:       &level,
+			Source:      &source,
+			StdType:     &stdType,
+		})
+		return
+	}
+
 	ctx.Tell(t.logger, model.TrialLog{TrialID: t.id, Message: msg.String() + "\n"})
 }
 
@@ -1008,6 +1260,99 @@ func classifyStatus(state terminatedContainerWithState) aproto.ContainerStopped
 	}
 }
 
+// failurePatterns are substrings of the logs that identify the category of a failure, in order of
+// precedence; e.g., CUDA errors that say that CUDA ran out of memory are OOM failures.
+var failurePatterns = []struct {
+	category model.FailureCategory
+	patterns []string
+}{
+	{model.OOMFailure, []string{
+		"out of memory", "MemoryError", "OOMKilled", "ResourceExhaustedError",
+		"OOM when allocating",
+	}},
+	{model.CUDAErrorFailure, []string{"CUDA error", "CUDA_ERROR", "cudaError", "CUBLAS_STATUS"}},
+	{model.UserExceptionFailure, []string{"Traceback (most recent call last)"}},
+}
+
+// classifyFailure guesses the category of a failure of a run of a trial from the failure and the
+// last lines of its logs, oldest first. It returns the log line that gave the category away, if
+// any; for user exceptions, that is the last line, which usually holds the exception rather than
+// the start of the traceback.
+func classifyFailure(
+	failure aproto.ContainerFailure, lines []string,
+) (model.FailureCategory, string) {
+	switch failure.FailureType {
+	case aproto.AgentFailed:
+		return model.NodeFailure, ""
+	case aproto.ImagePullFailed:
+		return model.ImagePullFailure, failure.ErrMsg
+	case aproto.ImagePullAuthFailed:
+		return model.ImagePullAuthFailure, failure.ErrMsg
+	}
+	for _, fp := range failurePatterns {
+		// The last matching line is likely the one closest to the error.
+		for i := len(lines) - 1; i >= 0; i-- {
+			for _, p := range fp.patterns {
+				switch {
+				case !strings.Contains(lines[i], p):
+				case fp.category == model.UserExceptionFailure:
+					return fp.category, lines[len(lines)-1]
+				default:
+					return fp.category, lines[i]
+				}
+			}
+		}
+	}
+	// Containers that the kernel killed for running out of memory exit with the same code as
+	// those killed for other reasons, so only Docker can tell them apart.
+	if failure.OOMKilled {
+		return model.OOMFailure, ""
+	}
+	return model.UnknownFailure, ""
+}
+
+// reasonPatterns are substrings of the logs that give away why the allocation of a failed run of a
+// trial failed, beyond what its containers show.
+var reasonPatterns = []struct {
+	reason   model.FailureReason
+	patterns []string
+}{
+	{model.InvalidConfigReason, []string{
+		"InvalidExperimentException", "InvalidConfigurationException",
+	}},
+	// The harness is missing from the image, or lacks what the master expects of it.
+	{model.HarnessIncompatibleReason, []string{"No module named 'determined", "from 'determined"}},
+}
+
+// failureReason returns why the allocation of a failed run of a trial failed, from the failure, its
+// category and the last lines of the logs of the run.
+func failureReason(
+	failure aproto.ContainerFailure, category model.FailureCategory, lines []string,
+) model.FailureReason {
+	switch category {
+	case model.NodeFailure:
+		return model.AgentLostReason
+	case model.ImagePullFailure, model.ImagePullAuthFailure:
+		return model.ImagePullReason
+	}
+	for _, rp := range reasonPatterns {
+		for _, l := range lines {
+			for _, p := range rp.patterns {
+				if strings.Contains(l, p) {
+					return rp.reason
+				}
+			}
+		}
+	}
+	switch {
+	case category == model.OOMFailure:
+		return model.OOMKilledReason
+	case failure.ExitCode != nil && *failure.ExitCode != aproto.SuccessExitCode:
+		return model.ExitNonzeroReason
+	}
+	return model.UnknownReason
+}
+
 func (t *trial) restore(ctx *actor.Context) {
 	// If the trial has not been created in the database yet (which can happen during master restart),
 	// it can't have any state to restore.
@@ -1022,6 +1367,9 @@ func (t *trial) restore(ctx *actor.Context) {
 		ctx.Self().Stop()
 		return
 	}
+	if trial != nil {
+		t.restartsDueToNodeFailure = trial.RestartsDueToNodeFailure
+	}
 
 	step := t.sequencer.RollBackSequencer()
 
@@ -1036,7 +1384,7 @@ func (t *trial) restore(ctx *actor.Context) {
 }
 
 func (t *trial) trialClosing() bool {
-	return t.earlyExit || t.killed || t.restarts > t.experiment.Config.MaxRestarts ||
+	return t.earlyExit || t.earlyStopped || t.killed || t.restarts > t.experiment.Config.MaxRestarts ||
 		(t.close != nil && t.sequencer.UpToDate()) ||
 		model.StoppingStates[t.experimentState]
 }
@@ -1082,19 +1430,10 @@ func (t *trial) terminated(ctx *actor.Context) {
 	}
 
 	terminationSent := t.terminationSent
+	failure := t.firstFailure
+	task := t.task
 
-	t.runID++
-
-	t.task = nil
-	t.allocations = nil
-	t.containerRanks = make(map[cproto.ID]int)
-	ctx.Tell(t.rm, resourcemanagers.ResourcesReleased{TaskActor: ctx.Self()})
-
-	t.allReadySucceeded = false
-	t.pendingGracefulTermination = false
-	t.terminationSent = false
-	t.terminatedContainers = make(map[cproto.ID]terminatedContainerWithState)
-	t.startedContainers = make(map[cproto.ID]bool)
+	t.releaseRun(ctx)
 
 	switch {
 	case status.Failure == nil:
@@ -1121,28 +1460,53 @@ func (t *trial) terminated(ctx *actor.Context) {
 		return
 	}
 
+	if failure == nil {
+		failure = status.Failure
+	}
+	t.recordFailure(ctx, task, *failure)
+	if failure.FailureType == aproto.AgentFailed {
+		ctx.Log().Warnf("trial runner lost to a node failure, requeueing the trial: %v", status)
+		t.restartAfterNodeFailure(ctx)
+		return
+	}
+
 	ctx.Log().Errorf("unexpected failure of trial after restart %d/%d: %v",
 		t.restarts, t.experiment.Config.MaxRestarts, status)
 	t.restarts++
 	if t.restarts <= t.experiment.Config.MaxRestarts {
 		t.restore(ctx)
+		t.backOff(ctx)
 		return
 	}
 
-	var w workload.Workload
-	var err error
+	w, err := t.currentWorkload()
+	switch {
+	case err != nil:
+		panic(err)
+	case w == nil:
+		panic("trial terminated due to failure but had nothing to fail")
+	}
+	t.failWorkload(ctx, *w)
+}
+
+// currentWorkload returns the workload that the trial runner is running, or nil if there is none.
+func (t *trial) currentWorkload() (*workload.Workload, error) {
 	switch {
 	case !t.sequencer.UpToDate():
-		w, err = t.sequencer.Workload()
+		w, err := t.sequencer.Workload()
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
+		return &w, nil
 	case t.sequencer.PrecloseCheckpointWorkload() != nil:
-		w = *t.sequencer.PrecloseCheckpointWorkload()
+		return t.sequencer.PrecloseCheckpointWorkload(), nil
 	default:
-		panic("trial terminated due to failure but had nothing to fail")
+		return nil, nil
 	}
+}
 
+// failWorkload marks the workload errored and completes it as such, which exits the trial early.
+func (t *trial) failWorkload(ctx *actor.Context, w workload.Workload) {
 	if !t.replaying {
 		if err := markWorkloadErrored(t.db, w); err != nil {
 			ctx.Log().
@@ -1162,3 +1526,279 @@ func (t *trial) terminated(ctx *actor.Context) {
 			Error("failed to process errored message")
 	}
 }
+
+// processMessageTooLarge fails the running workload when a container sends a message larger than
+// the read limit of websockets, since the message, e.g., the metrics of the workload, is lost.
+func (t *trial) processMessageTooLarge(ctx *actor.Context, msg api.MessageTooLarge) {
+	ctx.Log().Errorf("container sent a %q message of %d bytes, more than the limit of %d bytes",
+		msg.Type, msg.Size, msg.Limit)
+	w, err := t.currentWorkload()
+	switch {
+	case err != nil:
+		ctx.Log().WithError(err).Error("failed to find the workload to fail")
+	case w == nil:
+		ctx.Log().Warn("no workload is running to fail")
+	default:
+		ctx.Log().Errorf("failing workload %v", *w)
+		t.failWorkload(ctx, *w)
+	}
+}
+
+// releaseRun releases the resources of the current run of the trial and resets the state that
+// tracks it.
+func (t *trial) releaseRun(ctx *actor.Context) {
+	t.runID++
+
+	t.task = nil
+	t.allocations = nil
+	t.containerRanks = make(map[cproto.ID]int)
+	t.containerStates = make(map[cproto.ID]cproto.State)
+	ctx.Tell(t.rm, resourcemanagers.ResourcesReleased{TaskActor: ctx.Self()})
+
+	t.allReadySucceeded = false
+	t.pendingGracefulTermination = false
+	t.terminationSent = false
+	t.terminatedContainers = make(map[cproto.ID]terminatedContainerWithState)
+	t.startedContainers = make(map[cproto.ID]bool)
+	t.firstFailure = nil
+	t.containerHeartbeats = make(map[cproto.ID]time.Time)
+	for agentID := range t.agentHeartbeats {
+		if ref := ctx.Self().System().Get(actor.Addr("agents", agentID)); ref != nil {
+			ctx.Tell(ref, sproto.UnwatchAgentHeartbeats{Handler: ctx.Self()})
+		}
+	}
+	t.agentHeartbeats = make(map[string]time.Time)
+}
+
+// heartbeatInterval returns the interval between heartbeats, or zero if they are disabled.
+func (t *trial) heartbeatInterval() time.Duration {
+	return time.Duration(t.heartbeats.Interval) * time.Second
+}
+
+// startHeartbeats starts tracking the heartbeats of the agents of a new allocation and schedules
+// the first heartbeat check. The agents tell the trial each time they send heartbeats; agents
+// that are gone keep their last known heartbeat. Containers are tracked once they connect, since
+// they cannot send heartbeats before.
+func (t *trial) startHeartbeats(ctx *actor.Context) {
+	if t.heartbeatInterval() == 0 {
+		return
+	}
+	now := time.Now()
+	for _, a := range t.allocations {
+		agentID := a.Summary().Agent
+		// Only agents that connect to the master send heartbeats; e.g., Kubernetes nodes do not.
+		if ref := ctx.Self().System().Get(actor.Addr("agents", agentID)); ref != nil {
+			if _, ok := t.agentHeartbeats[agentID]; !ok {
+				t.agentHeartbeats[agentID] = now
+				ctx.Tell(ref, sproto.WatchAgentHeartbeats{Handler: ctx.Self()})
+			}
+		}
+	}
+	actors.NotifyAfter(ctx, t.heartbeatInterval(), heartbeatCheck{runID: t.runID})
+}
+
+func (t *trial) processContainerHeartbeat(ctx *actor.Context) {
+	if ctx.Sender() == nil {
+		return
+	}
+	for id, socket := range t.containerSockets {
+		if socket.Address() == ctx.Sender().Address() {
+			t.containerHeartbeats[id] = time.Now()
+			return
+		}
+	}
+}
+
+// missedHeartbeat describes the container or agent of the current run that has gone the longest
+// without a heartbeat, if that is longer than the heartbeat timeout, or returns "" otherwise.
+func (t *trial) missedHeartbeat(now time.Time) string {
+	var missed string
+	oldest := now.Add(-t.heartbeats.Timeout())
+	for id, last := range t.containerHeartbeats {
+		if last.Before(oldest) {
+			missed, oldest = fmt.Sprintf("container %s", id), last
+		}
+	}
+	for agentID, last := range t.agentHeartbeats {
+		if last.Before(oldest) {
+			missed, oldest = fmt.Sprintf("agent %s", agentID), last
+		}
+	}
+	if missed == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s has not sent a heartbeat since %s", missed,
+		oldest.UTC().Format(time.RFC3339))
+}
+
+func (t *trial) currentHeartbeats() trialHeartbeats {
+	heartbeats := trialHeartbeats{
+		Containers: make(map[cproto.ID]time.Time, len(t.containerHeartbeats)),
+		Agents:     make(map[string]time.Time, len(t.agentHeartbeats)),
+	}
+	if t.heartbeatInterval() > 0 {
+		heartbeats.Timeout = t.heartbeats.Interval * t.heartbeats.MaxMissed
+	}
+	for id, last := range t.containerHeartbeats {
+		heartbeats.Containers[id] = last
+	}
+	for agentID, last := range t.agentHeartbeats {
+		heartbeats.Agents[agentID] = last
+	}
+	return heartbeats
+}
+
+// lost gives up on the current run of the trial after one of its containers or agents missed too
+// many heartbeats, e.g., because its machine lost power. Since such a machine cannot report that
+// the containers terminated, the resources are released right away and the trial is restored to
+// its latest checkpoint, which requeues it. The containers are killed in case they come back.
+func (t *trial) lost(ctx *actor.Context, reason string) {
+	msg := fmt.Sprintf("trial runner lost, requeueing the trial: %s", reason)
+	ctx.Log().Warn(msg)
+	if t.canLog(ctx, msg) {
+		ctx.Tell(t.logger, model.TrialLog{TrialID: t.id, Message: msg + "\n"})
+	}
+
+	for _, allocation := range t.allocations {
+		allocation.Kill(ctx)
+	}
+	for id := range t.containerSockets {
+		t.killAndRemoveSocket(ctx, id)
+	}
+	t.containers = make(map[cproto.ID]cproto.Container)
+	t.containerAddresses = make(map[cproto.ID][]cproto.Address)
+	t.classifyAllocation(ctx, model.AgentLostReason)
+	t.releaseRun(ctx)
+
+	t.saveFailure(ctx, model.TrialFailure{Category: model.NodeFailure, Message: reason})
+	t.restartAfterNodeFailure(ctx)
+}
+
+// restartAfterNodeFailure restores the trial to its latest checkpoint after its run was lost to a
+// node failure. Such restarts neither count towards max_restarts nor back off.
+func (t *trial) restartAfterNodeFailure(ctx *actor.Context) {
+	t.restartsDueToNodeFailure++
+	if t.idSet && !t.replaying {
+		if err := t.db.UpdateTrialNodeFailureRestarts(t.id, t.restartsDueToNodeFailure); err != nil {
+			ctx.Log().WithError(err).Error("failed to save node failure restarts")
+		}
+	}
+	t.restore(ctx)
+}
+
+// backOff delays asking for resources again after the trial failed, by a wait that grows with the
+// number of failures.
+func (t *trial) backOff(ctx *actor.Context) {
+	backoff := t.restartBackoff.Backoff(t.restarts)
+	if backoff == 0 {
+		return
+	}
+	msg := fmt.Sprintf("restarting trial in %s after %d failures", backoff, t.restarts)
+	ctx.Log().Info(msg)
+	if t.canLog(ctx, msg) {
+		ctx.Tell(t.logger, model.TrialLog{TrialID: t.id, Message: msg + "\n"})
+	}
+	t.restartAfter = time.Now().Add(backoff)
+	actors.NotifyAfter(ctx, backoff, restartBackoffElapsed{})
+}
+
+// recordFailure classifies the failure of the latest run of the trial from the failure and the
+// last logs of the trial, and saves it. The logs are read outside the actor, once the trial logger
+// has saved the logs that it buffers, so the failure may be saved after the trial moved on.
+func (t *trial) recordFailure(
+	ctx *actor.Context, task *resourcemanagers.AllocateRequest, failure aproto.ContainerFailure,
+) {
+	system := ctx.Self().System()
+	var taskID string
+	if task != nil {
+		taskID = string(task.ID)
+	}
+	if failure.FailureType == aproto.AgentFailed || !t.idSet || t.replaying || t.db == nil {
+		t.saveFailure(ctx, classifyTrialFailure(system, taskID, failure, nil))
+		return
+	}
+
+	log, logger, pgDB, trialID := ctx.Log(), t.logger, t.db, t.id
+	go func() {
+		if logger != nil {
+			if _, ok := system.Ask(logger, flushTrialLogs{}).GetOrTimeout(
+				trialLogsFlushTimeout); !ok {
+				log.Warn("timed out waiting for the trial logger to save the last logs of the trial")
+			}
+		}
+		var lines []string
+		limit := failureLogLines
+		logs, err := pgDB.TrialLogEntries(trialID, db.TrialLogsPage{Limit: &limit, Tail: true})
+		if err != nil {
+			log.WithError(err).Warn("failed to get the last logs to classify a failure")
+		}
+		for _, l := range logs {
+			lines = append(lines, l.Message)
+		}
+		saveTrialFailure(log, pgDB, trialID, classifyTrialFailure(system, taskID, failure, lines))
+	}()
+}
+
+// classifyTrialFailure classifies a failure of a run of a trial from the failure and the last
+// lines of the logs of the trial, and records why the allocation of the run failed.
+func classifyTrialFailure(
+	system *actor.System, taskID string, failure aproto.ContainerFailure, lines []string,
+) model.TrialFailure {
+	category, line := classifyFailure(failure, lines)
+	if taskID != "" {
+		system.TellAt(sproto.AllocationRecorderAddr, sproto.AllocationFailureClassified{
+			TaskID: taskID,
+			Reason: failureReason(failure, category, lines),
+		})
+	}
+	record := model.TrialFailure{Category: category, Message: failure.Error()}
+	if line != "" {
+		record.Message = line
+	}
+	if failure.ExitCode != nil {
+		code := int(*failure.ExitCode)
+		record.ExitCode = &code
+	}
+	return record
+}
+
+// classifyAllocation records why the allocation of the current run of the trial was cut short.
+func (t *trial) classifyAllocation(ctx *actor.Context, reason model.FailureReason) {
+	if t.task == nil {
+		return
+	}
+	ctx.Self().System().TellAt(sproto.AllocationRecorderAddr, sproto.AllocationFailureClassified{
+		TaskID: string(t.task.ID),
+		Reason: reason,
+	})
+}
+
+// saveFailure saves a failure of the latest run of the trial.
+func (t *trial) saveFailure(ctx *actor.Context, failure model.TrialFailure) {
+	if !t.idSet || t.replaying || t.db == nil {
+		ctx.Log().Infof("classified failure of trial as %s: %s", failure.Category, failure.Message)
+		return
+	}
+	saveTrialFailure(ctx.Log(), t.db, t.id, failure)
+}
+
+// saveTrialFailure saves a failure of the latest run of the trial.
+func saveTrialFailure(
+	log *logrus.Entry, pgDB *db.PgDB, trialID int, failure model.TrialFailure,
+) {
+	log.Infof("classified failure of trial as %s: %s", failure.Category, failure.Message)
+	failure.TrialID = trialID
+	failure.Time = time.Now().UTC()
+	if err := pgDB.AddTrialFailure(&failure); err != nil {
+		log.WithError(err).Error("failed to save failure of trial")
+	}
+}
+
+// publishState records a change of the state of the trial in the cluster event stream.
+func (t *trial) publishState(ctx *actor.Context, state model.State) {
+	events.Publish(ctx.Self().System(), events.TrialStateChanged, map[string]interface{}{
+		"trial_id":      t.id,
+		"experiment_id": t.experiment.ID,
+		"state":         state,
+	})
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func startTrialLogger(config TrialLogsConfig) (*actor.Ref, chan []*model.TrialLog) {
+	batches := make(chan []*model.TrialLog, 10)
+	addLogs := func(logs []*model.TrialLog) error {
+		batches <- append([]*model.TrialLog(nil), logs...)
+		return nil
+	}
+	system := actor.NewSystem("")
+	ref, _ := system.ActorOf(actor.Addr("trialLogger"), newTrialLogger(addLogs, config))
+	return ref, batches
+}
+
+func receiveBatch(t *testing.T, batches chan []*model.TrialLog) []*model.TrialLog {
+	select {
+	case batch := <-batches:
+		return batch
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for trial logs to be flushed")
+		return nil
+	}
+}
+
+func TestTrialLoggerFlushCount(t *testing.T) {
+	ref, batches := startTrialLogger(TrialLogsConfig{FlushCount: 2, FlushInterval: 60 * 60 * 1000})
+	for i := 0; i < 3; i++ {
+		ref.System().Tell(ref, model.TrialLog{TrialID: i})
+	}
+
+	batch := receiveBatch(t, batches)
+	assert.Equal(t, len(batch), 2)
+	assert.Equal(t, batch[0].TrialID, 0)
+	assert.Equal(t, batch[1].TrialID, 1)
+
+	// The remaining log is flushed when the logger stops.
+	assert.NilError(t, ref.StopAndAwaitTermination())
+	batch = receiveBatch(t, batches)
+	assert.Equal(t, len(batch), 1)
+	assert.Equal(t, batch[0].TrialID, 2)
+	assert.Equal(t, len(batches), 0)
+}
+
+func TestTrialLoggerFlushInterval(t *testing.T) {
+	ref, batches := startTrialLogger(TrialLogsConfig{FlushCount: 100, FlushInterval: 10})
+	ref.System().Tell(ref, model.TrialLog{TrialID: 1})
+
+	batch := receiveBatch(t, batches)
+	assert.Equal(t, len(batch), 1)
+	assert.NilError(t, ref.StopAndAwaitTermination())
+	assert.Equal(t, len(batches), 0)
+}
+
+func TestTrialLoggerFlushOnRequest(t *testing.T) {
+	ref, batches := startTrialLogger(TrialLogsConfig{FlushCount: 100, FlushInterval: 60 * 60 * 1000})
+	ref.System().Tell(ref, model.TrialLog{TrialID: 1})
+
+	// The logs are saved by the time the logger responds.
+	_, ok := ref.System().Ask(ref, flushTrialLogs{}).GetOrTimeout(5 * time.Second)
+	assert.Assert(t, ok)
+	assert.Equal(t, len(batches), 1)
+	batch := receiveBatch(t, batches)
+	assert.Equal(t, len(batch), 1)
+	assert.Equal(t, batch[0].TrialID, 1)
+	assert.NilError(t, ref.StopAndAwaitTermination())
+	assert.Equal(t, len(batches), 0)
+}
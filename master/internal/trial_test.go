@@ -4,17 +4,23 @@ import (
 	"sort"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"gotest.tools/assert"
 
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/nprand"
+	"github.com/determined-ai/determined/master/pkg/searcher"
 	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/master/pkg/workload"
 )
 
 type mockActor struct {
@@ -32,10 +38,11 @@ func (a *mockActor) Receive(ctx *actor.Context) error {
 }
 
 type mockAllocation struct {
+	agent string
 }
 
-func (mockAllocation) Summary() resourcemanagers.ContainerSummary {
-	return resourcemanagers.ContainerSummary{}
+func (a mockAllocation) Summary() resourcemanagers.ContainerSummary {
+	return resourcemanagers.ContainerSummary{Agent: a.agent}
 }
 func (mockAllocation) Start(ctx *actor.Context, spec tasks.TaskSpec) {}
 func (mockAllocation) Kill(ctx *actor.Context)                       {}
@@ -110,6 +117,7 @@ func TestRendezvousInfo(t *testing.T) {
 		containerRanks:       make(map[cproto.ID]int),
 		containerAddresses:   make(map[cproto.ID][]cproto.Address),
 		containerSockets:     make(map[cproto.ID]*actor.Ref),
+		containerStates:      make(map[cproto.ID]cproto.State),
 		taskSpec:             defaultTaskSpec,
 	}
 	trialRef, created := system.ActorOf(actor.Addr("trial"), trial)
@@ -138,7 +146,8 @@ func TestRendezvousInfo(t *testing.T) {
 			t.Fatal("cannot make socket")
 		}
 
-		// Simulate trial containers connecting to the trial actor.
+		// Simulate the container being launched and connecting to the trial actor.
+		trial.containerRanks[c.ID] = idx
 		trial.containerSockets[c.ID] = ref
 
 		// Simulate the scheduling of a container.
@@ -207,3 +216,351 @@ func TestRendezvousInfo(t *testing.T) {
 		}
 	})
 }
+
+func TestRunnerState(t *testing.T) {
+	tr := &trial{
+		containerRanks:  map[cproto.ID]int{"b": 0},
+		containerStates: make(map[cproto.ID]cproto.State),
+	}
+	assert.Equal(t, runnerLifecycleState(tr.runnerState()), runnerIdle)
+
+	tr.task = &resourcemanagers.AllocateRequest{ID: "task"}
+	assert.Equal(t, runnerLifecycleState(tr.runnerState()), runnerQueued)
+
+	tr.allocations = []resourcemanagers.Allocation{mockAllocation{}, mockAllocation{}}
+	tr.recordContainerState(cproto.Container{ID: "a", State: cproto.Pulling})
+	tr.recordContainerState(cproto.Container{ID: "b", State: cproto.Pulling})
+	tr.recordContainerState(cproto.Container{ID: "b", State: cproto.Starting})
+	state := tr.runnerState()
+	assert.Equal(t, runnerLifecycleState(state), "PULLING")
+	assert.Equal(t, *state.TaskID, resourcemanagers.TaskID("task"))
+	assert.Equal(t, len(state.Containers), 2)
+	assert.Equal(t, state.Containers[0].ID, cproto.ID("a"))
+	assert.Assert(t, state.Containers[0].Rank == nil)
+	assert.Equal(t, *state.Containers[1].Rank, 0)
+	assert.Equal(t, state.Containers[1].State, cproto.Starting)
+	assert.Equal(t, len(state.Transitions), 3)
+
+	tr.recordContainerState(cproto.Container{ID: "a", State: cproto.Starting})
+	tr.recordContainerState(cproto.Container{ID: "a", State: cproto.Running})
+	assert.Equal(t, runnerLifecycleState(tr.runnerState()), "STARTING")
+
+	for i := 0; i < maxContainerStateTransitions; i++ {
+		tr.recordContainerState(cproto.Container{ID: "b", State: cproto.Running})
+	}
+	state = tr.runnerState()
+	assert.Equal(t, runnerLifecycleState(state), "RUNNING")
+	assert.Equal(t, len(state.Transitions), maxContainerStateTransitions)
+}
+
+func TestMissedHeartbeat(t *testing.T) {
+	now := time.Now()
+	tr := &trial{
+		heartbeats:          TrialHeartbeatsConfig{Interval: 30, MaxMissed: 4},
+		containerHeartbeats: map[cproto.ID]time.Time{"a": now.Add(-time.Minute)},
+		agentHeartbeats:     map[string]time.Time{"agent-1": now.Add(-90 * time.Second)},
+	}
+	assert.Equal(t, tr.missedHeartbeat(now), "")
+
+	heartbeats := tr.currentHeartbeats()
+	assert.Equal(t, heartbeats.Timeout, 120)
+	assert.Equal(t, heartbeats.Containers["a"], now.Add(-time.Minute))
+	assert.Equal(t, heartbeats.Agents["agent-1"], now.Add(-90*time.Second))
+
+	lastAgentHeartbeat := now.Add(-3 * time.Minute)
+	tr.agentHeartbeats["agent-1"] = lastAgentHeartbeat
+	assert.Equal(t, tr.missedHeartbeat(now), "agent agent-1 has not sent a heartbeat since "+
+		lastAgentHeartbeat.UTC().Format(time.RFC3339))
+
+	lastContainerHeartbeat := now.Add(-5 * time.Minute)
+	tr.containerHeartbeats["b"] = lastContainerHeartbeat
+	assert.Equal(t, tr.missedHeartbeat(now), "container b has not sent a heartbeat since "+
+		lastContainerHeartbeat.UTC().Format(time.RFC3339))
+}
+
+// messageRecorder passes on the messages that it receives, other than lifecycle messages.
+type messageRecorder chan actor.Message
+
+func (r messageRecorder) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop, actor.ChildStopped, actor.ChildFailed:
+	default:
+		r <- ctx.Message()
+	}
+	return nil
+}
+
+// next returns the next message that the recorder received that the filter accepts.
+func (r messageRecorder) next(t *testing.T, filter func(actor.Message) bool) actor.Message {
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case msg := <-r:
+			if filter(msg) {
+				return msg
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for a message")
+		}
+	}
+}
+
+func TestLostAgentRequeuesTrial(t *testing.T) {
+	system := actor.NewSystem("")
+	system.MustActorOf(actor.Addr("agents"), make(messageRecorder, 100))
+	agent := make(messageRecorder, 100)
+	system.MustActorOf(actor.Addr("agents", "agent-1"), agent)
+	rm := make(messageRecorder, 100)
+	rmRef := system.MustActorOf(actor.Addr("rm"), rm)
+
+	config := model.DefaultExperimentConfig(nil)
+	exp := &model.Experiment{ID: 1, State: model.ActiveState, Config: config}
+	create := searcher.NewCreate(nprand.New(0), map[string]interface{}{
+		model.GlobalBatchSize: 64,
+	}, model.TrialWorkloadSequencerType)
+	sequencer := newTrialWorkloadSequencer(exp, create, nil)
+	assert.NilError(t, sequencer.OperationRequested(
+		searcher.NewTrain(create.RequestID, model.NewLength(model.Batches, 100))))
+
+	stale := time.Now().Add(-time.Hour)
+	tr := &trial{
+		rm:                  rmRef,
+		experiment:          exp,
+		experimentState:     model.ActiveState,
+		sequencer:           sequencer,
+		task:                &resourcemanagers.AllocateRequest{ID: "task-1"},
+		allocations:         []resourcemanagers.Allocation{mockAllocation{agent: "agent-1"}},
+		containers:          make(map[cproto.ID]cproto.Container),
+		containerAddresses:  make(map[cproto.ID][]cproto.Address),
+		containerSockets:    make(map[cproto.ID]*actor.Ref),
+		heartbeats:          TrialHeartbeatsConfig{Interval: 1, MaxMissed: 1},
+		containerHeartbeats: make(map[cproto.ID]time.Time),
+		agentHeartbeats:     map[string]time.Time{"agent-1": stale},
+	}
+	trialRef := system.MustActorOf(actor.Addr("trial"), tr)
+	agentHeartbeats := func() map[string]time.Time {
+		resp, ok := system.Ask(trialRef, getHeartbeats{}).GetOrTimeout(10 * time.Second)
+		assert.Assert(t, ok)
+		return resp.(trialHeartbeats).Agents
+	}
+
+	// Heartbeats from agents that are not in the allocation, and late heartbeats, are ignored.
+	system.Tell(trialRef, sproto.AgentHeartbeat{Agent: "agent-2", Time: time.Now()})
+	system.Tell(trialRef, sproto.AgentHeartbeat{Agent: "agent-1", Time: stale.Add(-time.Hour)})
+	assert.DeepEqual(t, agentHeartbeats(), map[string]time.Time{"agent-1": stale})
+
+	// The trial keeps running while its agent sends heartbeats.
+	last := time.Now()
+	system.Tell(trialRef, sproto.AgentHeartbeat{Agent: "agent-1", Time: last})
+	system.Tell(trialRef, heartbeatCheck{runID: 0})
+	assert.DeepEqual(t, agentHeartbeats(), map[string]time.Time{"agent-1": last})
+
+	// Once the agent stops sending heartbeats, the resources of the trial are released, the agent
+	// is no longer watched and the trial asks for resources again.
+	isRM := func(msg actor.Message) bool {
+		switch msg.(type) {
+		case resourcemanagers.ResourcesReleased, resourcemanagers.AllocateRequest:
+			return true
+		}
+		return false
+	}
+	_, ok := rm.next(t, isRM).(resourcemanagers.ResourcesReleased)
+	assert.Assert(t, ok)
+	request, ok := rm.next(t, isRM).(resourcemanagers.AllocateRequest)
+	assert.Assert(t, ok)
+	assert.Assert(t, request.ID != "task-1")
+	unwatch := agent.next(t, func(msg actor.Message) bool {
+		_, ok := msg.(sproto.UnwatchAgentHeartbeats)
+		return ok
+	})
+	assert.Equal(t, unwatch.(sproto.UnwatchAgentHeartbeats).Handler, trialRef)
+	assert.Equal(t, len(agentHeartbeats()), 0)
+}
+
+func TestMessageTooLargeFailsWorkload(t *testing.T) {
+	system := actor.NewSystem("")
+	experiment := make(messageRecorder, 100)
+	system.MustActorOf(actor.Addr("experiment"), experiment)
+
+	config := model.DefaultExperimentConfig(nil)
+	config.Searcher.SingleConfig = &model.SingleConfig{
+		MaxLength: model.NewLength(model.Batches, 100),
+	}
+	exp := &model.Experiment{ID: 1, State: model.ActiveState, Config: config}
+	create := searcher.NewCreate(nprand.New(0), map[string]interface{}{
+		model.GlobalBatchSize: 64,
+	}, model.TrialWorkloadSequencerType)
+	sequencer := newTrialWorkloadSequencer(exp, create, nil)
+	assert.NilError(t, sequencer.OperationRequested(
+		searcher.NewTrain(create.RequestID, model.NewLength(model.Batches, 100))))
+	sequencer.SetTrialID(1)
+
+	// Replaying keeps the trial from recording the failed workload in the database.
+	trialRef := system.MustActorOf(actor.Addr("experiment", "trial"), &trial{
+		experiment:      exp,
+		experimentState: model.ActiveState,
+		sequencer:       sequencer,
+		task:            &resourcemanagers.AllocateRequest{ID: "task-1"},
+		replaying:       true,
+	})
+
+	// The lost message, e.g., the metrics of the workload, fails the workload that is running,
+	// which exits the trial early.
+	system.Tell(trialRef, api.MessageTooLarge{Type: "WORKLOAD_COMPLETED", Size: 2048, Limit: 1024})
+	exited := experiment.next(t, func(msg actor.Message) bool {
+		_, ok := msg.(trialExitedEarly)
+		return ok
+	}).(trialExitedEarly)
+	assert.Equal(t, *exited.exitedReason, workload.Errored)
+}
+
+func TestClassifyFailure(t *testing.T) {
+	exited := func(code aproto.ExitCode) aproto.ContainerFailure {
+		return *aproto.ContainerExited(code).Failure
+	}
+	oomKilled := exited(137)
+	oomKilled.OOMKilled = true
+	traceback := []string{
+		"Traceback (most recent call last):",
+		`  File "model_def.py", line 10, in train_batch`,
+		"ValueError: bad input",
+	}
+	for _, tc := range []struct {
+		name     string
+		failure  aproto.ContainerFailure
+		lines    []string
+		category model.FailureCategory
+		line     string
+	}{
+		{
+			name:     "agent failed",
+			failure:  *aproto.ContainerError(aproto.AgentFailed, errors.New("lost")).Failure,
+			lines:    traceback,
+			category: model.NodeFailure,
+		},
+		{
+			name: "image pull failed",
+			failure: *aproto.ContainerError(
+				aproto.ImagePullFailed, errors.New("manifest unknown")).Failure,
+			category: model.ImagePullFailure,
+			line:     "manifest unknown",
+		},
+		{
+			name: "image pull authentication failed",
+			failure: *aproto.ContainerError(
+				aproto.ImagePullAuthFailed, errors.New("unauthorized")).Failure,
+			category: model.ImagePullAuthFailure,
+			line:     "unauthorized",
+		},
+		{
+			name:     "user exception",
+			failure:  exited(1),
+			lines:    traceback,
+			category: model.UserExceptionFailure,
+			line:     "ValueError: bad input",
+		},
+		{
+			name:    "CUDA out of memory",
+			failure: exited(1),
+			lines: append(append([]string{}, traceback[:2]...),
+				"RuntimeError: CUDA error: out of memory"),
+			category: model.OOMFailure,
+			line:     "RuntimeError: CUDA error: out of memory",
+		},
+		{
+			name:     "CUDA error",
+			failure:  exited(1),
+			lines:    []string{"RuntimeError: CUDA error: device-side assert triggered", "exiting"},
+			category: model.CUDAErrorFailure,
+			line:     "RuntimeError: CUDA error: device-side assert triggered",
+		},
+		{
+			name:     "out of memory",
+			failure:  oomKilled,
+			category: model.OOMFailure,
+		},
+		{
+			name:     "killed",
+			failure:  exited(137),
+			category: model.UnknownFailure,
+		},
+		{
+			name:     "unknown",
+			failure:  exited(2),
+			lines:    []string{"exiting"},
+			category: model.UnknownFailure,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			category, line := classifyFailure(tc.failure, tc.lines)
+			assert.Equal(t, category, tc.category)
+			assert.Equal(t, line, tc.line)
+		})
+	}
+}
+
+func TestFailureReason(t *testing.T) {
+	exited := func(code aproto.ExitCode) aproto.ContainerFailure {
+		return *aproto.ContainerExited(code).Failure
+	}
+	oomKilled := exited(137)
+	oomKilled.OOMKilled = true
+	for _, tc := range []struct {
+		name    string
+		failure aproto.ContainerFailure
+		lines   []string
+		reason  model.FailureReason
+	}{
+		{
+			name:    "agent failed",
+			failure: *aproto.ContainerError(aproto.AgentFailed, errors.New("lost")).Failure,
+			reason:  model.AgentLostReason,
+		},
+		{
+			name: "image pull authentication failed",
+			failure: *aproto.ContainerError(
+				aproto.ImagePullAuthFailed, errors.New("unauthorized")).Failure,
+			reason: model.ImagePullReason,
+		},
+		{
+			name:    "invalid config",
+			failure: exited(1),
+			lines: []string{
+				"determined.errors.InvalidConfigurationException: bad hyperparameter",
+			},
+			reason: model.InvalidConfigReason,
+		},
+		{
+			name:    "missing harness",
+			failure: exited(1),
+			lines:   []string{"ModuleNotFoundError: No module named 'determined'"},
+			reason:  model.HarnessIncompatibleReason,
+		},
+		{
+			name:    "out of memory",
+			failure: oomKilled,
+			reason:  model.OOMKilledReason,
+		},
+		{
+			name:    "killed",
+			failure: exited(137),
+			reason:  model.ExitNonzeroReason,
+		},
+		{
+			name:    "user exception",
+			failure: exited(1),
+			lines:   []string{"Traceback (most recent call last):", "ValueError: bad input"},
+			reason:  model.ExitNonzeroReason,
+		},
+		{
+			name:    "no exit code",
+			failure: *aproto.ContainerError(aproto.TaskError, errors.New("cannot start")).Failure,
+			reason:  model.UnknownReason,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			category, _ := classifyFailure(tc.failure, tc.lines)
+			assert.Equal(t, failureReason(tc.failure, category, tc.lines), tc.reason)
+		})
+	}
+}
@@ -14,6 +14,13 @@ func RegisterAPIHandler(echo *echo.Echo, m *Service, middleware ...echo.Middlewa
 	usersGroup.GET("", api.Route(m.getUsers))
 	usersGroup.POST("", api.Route(m.postUser))
 	usersGroup.GET("/me", api.Route(m.getMe))
+	usersGroup.GET("/me/teams", api.Route(m.getMyTeams))
 	usersGroup.PATCH("/:username", api.Route(m.patchUser))
 	usersGroup.PATCH("/:username/username", api.Route(m.patchUsername))
+	teamsGroup := echo.Group("/teams", middleware...)
+	teamsGroup.GET("", api.Route(m.getTeams))
+	teamsGroup.POST("", api.Route(m.postTeam))
+	teamsGroup.DELETE("/:team_name", api.Route(m.deleteTeam))
+	teamsGroup.PUT("/:team_name/members/:username", api.Route(m.putTeamMember))
+	teamsGroup.DELETE("/:team_name/members/:username", api.Route(m.deleteTeamMember))
 }
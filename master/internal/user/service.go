@@ -1,15 +1,18 @@
 package user
 
 import (
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/mail"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/context"
@@ -50,11 +53,19 @@ func (h *agentUserGroup) Validate() (*model.AgentUserGroup, error) {
 type Service struct {
 	db     *db.PgDB
 	system *actor.System
+	// teamsChanged is called whenever teams or their members change, which changes which
+	// experiments users see.
+	teamsChanged func()
 }
 
 // New creates a new user service.
 func New(db *db.PgDB, system *actor.System) (*Service, error) {
-	return &Service{db, system}, nil
+	return &Service{db: db, system: system, teamsChanged: func() {}}, nil
+}
+
+// OnTeamsChanged sets the function that is called whenever teams or their members change.
+func (s *Service) OnTeamsChanged(f func()) {
+	s.teamsChanged = f
 }
 
 // ProcessAuthentication is a middleware processing function that attempts
@@ -62,7 +73,7 @@ func New(db *db.PgDB, system *actor.System) (*Service, error) {
 // for an authentication in three places (in the following order):
 // 1. The HTTP Authorization header.
 // 2. A cookie named "auth".
-// 3. A Query parameter named "_auth".
+// 3. A verified TLS client certificate, whose common name is the username.
 func (s *Service) ProcessAuthentication(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		authRaw := c.Request().Header.Get("Authorization")
@@ -76,6 +87,8 @@ func (s *Service) ProcessAuthentication(next echo.HandlerFunc) echo.HandlerFunc
 			token = strings.TrimPrefix(authRaw, "Bearer ")
 		} else if cookie, err := c.Cookie("auth"); err == nil {
 			token = cookie.Value
+		} else if cert := api.VerifiedClientCertificate(c.Request()); cert != nil {
+			return s.processCertificateAuthentication(c, cert, next)
 		} else {
 			// If we found no token, then abort the request with an HTTP 401.
 			return echo.NewHTTPError(http.StatusUnauthorized)
@@ -100,6 +113,36 @@ func (s *Service) ProcessAuthentication(next echo.HandlerFunc) echo.HandlerFunc
 	}
 }
 
+// processCertificateAuthentication authenticates a request as the user named by the common name of
+// its TLS client certificate. Such requests have no user session.
+func (s *Service) processCertificateAuthentication(
+	c echo.Context, cert *x509.Certificate, next echo.HandlerFunc,
+) error {
+	user, err := s.db.UserByUsername(cert.Subject.CommonName)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return echo.NewHTTPError(http.StatusUnauthorized,
+			fmt.Sprintf("no user for client certificate %q", cert.Subject.CommonName))
+	case err != nil:
+		return err
+	case !user.Active:
+		return echo.NewHTTPError(http.StatusForbidden)
+	}
+	c.(*context.DetContext).SetUser(*user)
+	return next(c)
+}
+
+// ProcessAdminAuthentication is a middleware that authenticates the request like
+// ProcessAuthentication and additionally rejects requests from users that are not admins.
+func (s *Service) ProcessAdminAuthentication(next echo.HandlerFunc) echo.HandlerFunc {
+	return s.ProcessAuthentication(func(c echo.Context) error {
+		if !c.(*context.DetContext).MustGetUser().Admin {
+			return echo.NewHTTPError(http.StatusForbidden, "only admins may perform this action")
+		}
+		return next(c)
+	})
+}
+
 func (s *Service) postLogout(c echo.Context) (interface{}, error) {
 	// Delete the cookie if one is set.
 	if cookie, err := c.Cookie("auth"); err == nil {
@@ -108,8 +151,12 @@ func (s *Service) postLogout(c echo.Context) (interface{}, error) {
 		c.SetCookie(cookie)
 	}
 
-	// Delete the user session information from the database.
-	sess := c.(*context.DetContext).MustGetUserSession()
+	// Delete the user session information from the database. Requests authenticated by client
+	// certificates have no session.
+	sess, ok := c.(*context.DetContext).GetUserSession()
+	if !ok {
+		return "", nil
+	}
 
 	if err := s.db.DeleteSessionByID(sess.ID); err != nil {
 		return nil, err
@@ -199,6 +246,7 @@ func (s *Service) patchUser(c echo.Context) (interface{}, error) {
 			Password *string `json:"password,omitempty"`
 			Active   *bool   `json:"active,omitempty"`
 			Admin    *bool   `json:"admin,omitempty"`
+			Email    *string `json:"email,omitempty"`
 
 			AgentUserGroup *agentUserGroup `json:"agent_user_group,omitempty"`
 		}
@@ -269,6 +317,21 @@ func (s *Service) patchUser(c echo.Context) (interface{}, error) {
 		toUpdate = append(toUpdate, "admin")
 	}
 
+	if params.Email != nil {
+		if !user.EmailCanBeModifiedBy(authenticatedUser) {
+			return nil, forbiddenError
+		}
+		// An empty address removes the email address of the user.
+		user.Email = null.NewString(*params.Email, *params.Email != "")
+		if user.Email.Valid {
+			if _, pErr := mail.ParseAddress(user.Email.String); pErr != nil {
+				return nil, echo.NewHTTPError(
+					http.StatusBadRequest, fmt.Sprintf("invalid email: %s", pErr))
+			}
+		}
+		toUpdate = append(toUpdate, "email")
+	}
+
 	var ug *model.AgentUserGroup
 	if pug := params.AgentUserGroup; pug != nil {
 		if !user.AdminCanBeModifiedBy(authenticatedUser) {
@@ -0,0 +1,146 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// checkAdmin returns an error unless the user of the request is an admin.
+func checkAdmin(c echo.Context) error {
+	if !c.(*context.DetContext).MustGetUser().Admin {
+		return echo.NewHTTPError(http.StatusForbidden, "only admins may manage teams")
+	}
+	return nil
+}
+
+// team looks up a team by name, returning a 404 error if it does not exist.
+func (s *Service) team(name string) (*model.Team, error) {
+	team, err := s.db.TeamByName(name)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, echo.NewHTTPError(
+			http.StatusNotFound, fmt.Sprintf("team %q does not exist", name))
+	case err != nil:
+		return nil, errors.Wrapf(err, "loading team %s", name)
+	}
+	return team, nil
+}
+
+func (s *Service) getTeams(c echo.Context) (interface{}, error) {
+	return s.db.TeamsRaw()
+}
+
+func (s *Service) postTeam(c echo.Context) (interface{}, error) {
+	if err := checkAdmin(c); err != nil {
+		return nil, err
+	}
+	var team model.Team
+	if err := json.NewDecoder(c.Request().Body).Decode(&team); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid team: "+err.Error())
+	}
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "team name must not be empty")
+	}
+	switch err := s.db.AddTeam(&team); {
+	case err == db.ErrDuplicateRecord:
+		return nil, echo.NewHTTPError(
+			http.StatusBadRequest, fmt.Sprintf("team %q already exists", team.Name))
+	case err != nil:
+		return nil, err
+	}
+	return team, nil
+}
+
+func (s *Service) deleteTeam(c echo.Context) (interface{}, error) {
+	if err := checkAdmin(c); err != nil {
+		return nil, err
+	}
+	args := struct {
+		TeamName string `path:"team_name"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	team, err := s.team(args.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.db.DeleteTeam(team.ID); err != nil {
+		return nil, err
+	}
+	s.teamsChanged()
+	return nil, nil
+}
+
+// teamMember looks up the team and the user of a team membership request by their names.
+func (s *Service) teamMember(c echo.Context) (*model.Team, *model.User, error) {
+	if err := checkAdmin(c); err != nil {
+		return nil, nil, err
+	}
+	args := struct {
+		TeamName string `path:"team_name"`
+		Username string `path:"username"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, nil, err
+	}
+	team, err := s.team(args.TeamName)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.db.UserByUsername(args.Username)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, nil, echo.NewHTTPError(
+			http.StatusNotFound, fmt.Sprintf("user %q does not exist", args.Username))
+	case err != nil:
+		return nil, nil, errors.Wrapf(err, "loading user %s", args.Username)
+	}
+	return team, user, nil
+}
+
+func (s *Service) putTeamMember(c echo.Context) (interface{}, error) {
+	team, user, err := s.teamMember(c)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.db.AddTeamMember(team.ID, user.ID); err != nil {
+		return nil, err
+	}
+	s.teamsChanged()
+	return nil, nil
+}
+
+func (s *Service) deleteTeamMember(c echo.Context) (interface{}, error) {
+	team, user, err := s.teamMember(c)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.db.RemoveTeamMember(team.ID, user.ID); err != nil {
+		return nil, err
+	}
+	s.teamsChanged()
+	return nil, nil
+}
+
+func (s *Service) getMyTeams(c echo.Context) (interface{}, error) {
+	teams, err := s.db.UserTeams(c.(*context.DetContext).MustGetUser().ID)
+	if err != nil {
+		return nil, err
+	}
+	if teams == nil {
+		teams = []model.Team{}
+	}
+	return teams, nil
+}
@@ -0,0 +1,217 @@
+// Package webhooks notifies external services, such as chat tools, of events in the cluster by
+// sending signed JSON payloads to the URLs that admins register.
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// SignatureHeader is the header of webhook requests that holds "sha256=" followed by the
+	// hex-encoded HMAC-SHA256 of the request body, keyed by the secret of the webhook. Receivers
+	// compute it themselves to verify that requests come from the master.
+	SignatureHeader = "X-Determined-Signature"
+	// EventHeader is the header of webhook requests that holds the type of the event.
+	EventHeader = "X-Determined-Event"
+	// DeliveryHeader is the header of webhook requests that holds the ID of the event, which is the
+	// same for every attempt to deliver it.
+	DeliveryHeader = "X-Determined-Delivery"
+
+	// maxAttempts is the number of times an event is sent to a webhook before giving up.
+	maxAttempts = 5
+	// requestTimeout bounds how long a webhook may take to respond.
+	requestTimeout = 10 * time.Second
+)
+
+// Addr is the address of the actor that delivers events to webhooks.
+var Addr = actor.Addr("webhooks")
+
+// Event is the payload sent to webhooks.
+type Event struct {
+	ID   string                 `json:"id"`
+	Type model.WebhookEventType `json:"type"`
+	Time time.Time              `json:"time"`
+	Data interface{}            `json:"data"`
+	// Link is the URL of the WebUI page about the event, if the URL of the master is known.
+	Link string `json:"link,omitempty"`
+
+	path string
+}
+
+// Publish sends an event to the webhooks that are subscribed to its type. The path of the WebUI
+// page about the event, e.g., "/det/experiments/1", is turned into the link of the event. It
+// returns immediately; events are delivered in the background, and dropped if webhooks are not
+// running.
+func Publish(
+	system *actor.System, eventType model.WebhookEventType, path string, data interface{},
+) {
+	system.TellAt(Addr, Event{
+		ID:   uuid.New().String(),
+		Type: eventType,
+		Time: time.Now().UTC(),
+		Data: data,
+		path: path,
+	})
+}
+
+// Sign returns the value of the signature header of a request with the given body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Store is the storage of webhooks and of the record of their deliveries.
+type Store interface {
+	Webhooks() ([]model.Webhook, error)
+	AddWebhookDelivery(delivery *model.WebhookDelivery) error
+}
+
+type (
+	// delivery is an attempt to deliver an event to a webhook.
+	delivery struct {
+		webhook model.Webhook
+		event   Event
+		payload []byte
+		attempt int
+	}
+	// deliveryResult is the outcome of a delivery.
+	deliveryResult struct {
+		delivery
+		time       time.Time
+		statusCode null.Int
+		err        error
+	}
+)
+
+type webhookActor struct {
+	store  Store
+	client *http.Client
+	// masterURL is the URL at which users reach the master, or "" if it is unknown.
+	masterURL string
+	// backoff is the delay before the second attempt to deliver an event. It doubles after every
+	// failed attempt.
+	backoff time.Duration
+}
+
+// NewActor creates an actor that delivers the events published with Publish to webhooks. Failed
+// deliveries are retried with exponential backoff, and every attempt is recorded. Links in events
+// are built from masterURL, and left out if it is "".
+func NewActor(store Store, masterURL string) actor.Actor {
+	return &webhookActor{
+		store:     store,
+		client:    &http.Client{Timeout: requestTimeout},
+		masterURL: masterURL,
+		backoff:   5 * time.Second,
+	}
+}
+
+// Receive implements the actor.Actor interface.
+func (w *webhookActor) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+
+	case Event:
+		if w.masterURL != "" && msg.path != "" {
+			msg.Link = w.masterURL + msg.path
+		}
+		webhooks, err := w.store.Webhooks()
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("cannot send %s event to webhooks", msg.Type)
+			return nil
+		}
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("cannot encode %s event", msg.Type)
+			return nil
+		}
+		for _, webhook := range webhooks {
+			if webhook.EventTypes.Matches(msg.Type) {
+				w.deliver(ctx, delivery{webhook: webhook, event: msg, payload: payload, attempt: 1})
+			}
+		}
+
+	case delivery:
+		w.deliver(ctx, msg)
+
+	case deliveryResult:
+		record := model.WebhookDelivery{
+			WebhookID:  msg.webhook.ID,
+			EventID:    msg.event.ID,
+			EventType:  msg.event.Type,
+			Attempt:    msg.attempt,
+			Time:       msg.time,
+			StatusCode: msg.statusCode,
+		}
+		if msg.err != nil {
+			record.Error = null.StringFrom(msg.err.Error())
+		}
+		if err := w.store.AddWebhookDelivery(&record); err != nil {
+			ctx.Log().WithError(err).Error("cannot record webhook delivery")
+		}
+
+		switch {
+		case msg.err == nil:
+		case msg.attempt < maxAttempts:
+			retry := msg.delivery
+			retry.attempt++
+			actors.NotifyAfter(ctx, w.backoff*time.Duration(1<<(msg.attempt-1)), retry)
+		default:
+			ctx.Log().WithError(msg.err).Warnf("giving up sending %s event %s to webhook %d",
+				msg.event.Type, msg.event.ID, msg.webhook.ID)
+		}
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+// deliver sends the event to the webhook in the background, so that slow webhooks hold up neither
+// other deliveries nor whoever published the event. The result is sent back to the actor.
+func (w *webhookActor) deliver(ctx *actor.Context, d delivery) {
+	self := ctx.Self()
+	go func() {
+		result := deliveryResult{delivery: d, time: time.Now().UTC()}
+		result.statusCode, result.err = w.send(d)
+		self.System().Tell(self, result)
+	}()
+}
+
+func (w *webhookActor) send(d delivery) (null.Int, error) {
+	req, err := http.NewRequest(http.MethodPost, d.webhook.URL, bytes.NewReader(d.payload))
+	if err != nil {
+		return null.Int{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(d.webhook.Secret, d.payload))
+	req.Header.Set(EventHeader, string(d.event.Type))
+	req.Header.Set(DeliveryHeader, d.event.ID)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return null.Int{}, errors.Wrap(err, "error sending request")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	statusCode := null.IntFrom(int64(resp.StatusCode))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusCode, errors.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return statusCode, nil
+}
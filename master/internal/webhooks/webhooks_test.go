@@ -0,0 +1,113 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+type fakeStore struct {
+	webhooks   []model.Webhook
+	deliveries chan model.WebhookDelivery
+}
+
+func (s *fakeStore) Webhooks() ([]model.Webhook, error) {
+	return s.webhooks, nil
+}
+
+func (s *fakeStore) AddWebhookDelivery(delivery *model.WebhookDelivery) error {
+	s.deliveries <- *delivery
+	return nil
+}
+
+func receiveDelivery(t *testing.T, deliveries chan model.WebhookDelivery) model.WebhookDelivery {
+	select {
+	case delivery := <-deliveries:
+		return delivery
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a webhook delivery")
+		return model.WebhookDelivery{}
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	var lock sync.Mutex
+	var requests []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NilError(t, err)
+		lock.Lock()
+		defer lock.Unlock()
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		// Fail the first attempt, so that the event is sent again.
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	store := &fakeStore{
+		webhooks: []model.Webhook{
+			{ID: 1, URL: server.URL, Secret: "s3cr3t", EventTypes: model.WebhookEventFilter{
+				model.ExperimentCompletedEvent,
+			}},
+			{ID: 2, URL: server.URL, Secret: "other", EventTypes: model.WebhookEventFilter{
+				model.TrialErroredEvent,
+			}},
+		},
+		deliveries: make(chan model.WebhookDelivery, 10),
+	}
+	system := actor.NewSystem("")
+	system.ActorOf(Addr, &webhookActor{
+		store:     store,
+		client:    &http.Client{Timeout: time.Second},
+		masterURL: "https://det.example.com",
+		backoff:   time.Millisecond,
+	})
+
+	Publish(system, model.ExperimentCompletedEvent, "/det/experiments/7",
+		map[string]int{"experiment_id": 7})
+
+	failed := receiveDelivery(t, store.deliveries)
+	assert.Equal(t, failed.WebhookID, 1)
+	assert.Equal(t, failed.Attempt, 1)
+	assert.Equal(t, failed.StatusCode.Int64, int64(http.StatusBadGateway))
+	assert.Assert(t, failed.Error.Valid)
+
+	delivered := receiveDelivery(t, store.deliveries)
+	assert.Equal(t, delivered.Attempt, 2)
+	assert.Equal(t, delivered.EventID, failed.EventID)
+	assert.Equal(t, delivered.StatusCode.Int64, int64(http.StatusOK))
+	assert.Assert(t, !delivered.Error.Valid)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, len(requests), 2, "only subscribed webhooks are notified")
+	for i, r := range requests {
+		assert.Equal(t, r.Header.Get(SignatureHeader), Sign("s3cr3t", bodies[i]))
+		assert.Equal(t, r.Header.Get(EventHeader), string(model.ExperimentCompletedEvent))
+		assert.Equal(t, r.Header.Get(DeliveryHeader), failed.EventID)
+	}
+	var event map[string]interface{}
+	assert.NilError(t, json.Unmarshal(bodies[1], &event))
+	assert.Equal(t, event["type"], string(model.ExperimentCompletedEvent))
+	assert.DeepEqual(t, event["data"], map[string]interface{}{"experiment_id": float64(7)})
+	assert.Equal(t, event["link"], "https://det.example.com/det/experiments/7")
+}
+
+func TestSign(t *testing.T) {
+	// The signature of the example in RFC 4231, test case 2.
+	assert.Equal(t, Sign("Jefe", []byte("what do ya want for nothing?")),
+		"sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843")
+}
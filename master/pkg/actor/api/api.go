@@ -11,7 +11,7 @@ import (
 )
 
 var (
-	upgrader = websocket.Upgrader{}
+	upgrader = websocket.Upgrader{EnableCompression: true}
 )
 
 // Route aims at routing HTTP and websocket requests to an actor. It returns an
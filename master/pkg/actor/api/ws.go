@@ -3,9 +3,13 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net"
 	"reflect"
+	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,8 +25,8 @@ import (
 const (
 	// pingWaitDuration is the duration to wait for a pong response to a ping.
 	pingWaitDuration = 1 * time.Minute
-	// pingInterval is the duration to wait for between pinging connections.
-	pingInterval = 1 * time.Minute
+	// PingInterval is the duration to wait for between pinging connections.
+	PingInterval = 1 * time.Minute
 )
 
 const (
@@ -31,6 +35,71 @@ const (
 	MaxWebsocketMessageSize = 128 * 1024 * 1024
 )
 
+// readLimit is the maximum size of a websocket message that we read in bytes.
+var readLimit int64 = MaxWebsocketMessageSize
+
+// SetReadLimit sets the maximum size of the websocket messages that websocket actors read. Larger
+// messages are dropped, rather than failing the socket.
+func SetReadLimit(limit int64) {
+	atomic.StoreInt64(&readLimit, limit)
+}
+
+// SocketOptions configure a websocket actor.
+type SocketOptions struct {
+	// Class is the kind of the socket, e.g., trial or agent, that its traffic is counted under.
+	Class string
+	// UsePing makes the actor ping the other end every PingInterval, fail if a ping is not
+	// answered in time and tell its parent a Heartbeat whenever one is.
+	UsePing bool
+	// NotifyTooLarge makes the actor tell its parent a MessageTooLarge for each message that
+	// exceeds the read limit.
+	NotifyTooLarge bool
+}
+
+// MessageTooLarge notifies the parent of a websocket actor that the other end sent a message
+// larger than the read limit, which was dropped.
+type MessageTooLarge struct {
+	// Type is the value of the "type" field of the message, if it has one near its start.
+	Type  string
+	Size  int64
+	Limit int64
+}
+
+// SocketStats is the traffic of the websockets of a class so far.
+type SocketStats struct {
+	BytesSent        int64
+	BytesReceived    int64
+	MessagesTooLarge int64
+}
+
+var (
+	socketStatsLock sync.Mutex
+	socketStats     = make(map[string]*SocketStats)
+)
+
+// Stats returns the traffic of websockets so far, by class. Sizes are those of the messages before
+// compression.
+func Stats() map[string]SocketStats {
+	socketStatsLock.Lock()
+	defer socketStatsLock.Unlock()
+	stats := make(map[string]SocketStats, len(socketStats))
+	for class, classStats := range socketStats {
+		stats[class] = *classStats
+	}
+	return stats
+}
+
+func countTraffic(class string, count func(*SocketStats)) {
+	socketStatsLock.Lock()
+	defer socketStatsLock.Unlock()
+	classStats, ok := socketStats[class]
+	if !ok {
+		classStats = &SocketStats{}
+		socketStats[class] = classStats
+	}
+	count(classStats)
+}
+
 // WebSocketConnected notifies the actor that a websocket is attempting to connect.
 type WebSocketConnected struct {
 	Ctx echo.Context
@@ -40,18 +109,59 @@ type WebSocketConnected struct {
 func (w WebSocketConnected) Accept(
 	ctx *actor.Context,
 	msgType interface{},
-	usePing bool,
+	opts SocketOptions,
 ) (*actor.Ref, bool) {
 	conn, err := upgrader.Upgrade(w.Ctx.Response(), w.Ctx.Request(), nil)
 	if err != nil {
 		ctx.Respond(errors.Wrap(err, "websocket connection error"))
 		return nil, false
 	}
-	a, _ := ctx.ActorOf("websocket-"+uuid.New().String(), WrapSocket(conn, msgType, usePing))
+	a, _ := ctx.ActorOf("websocket-"+uuid.New().String(), WrapSocket(conn, msgType, opts))
 	ctx.Respond(a)
 	return a, true
 }
 
+// closeTimeout bounds how long writing a close frame may take.
+const closeTimeout = 5 * time.Second
+
+// Reject accepts the connecting websocket only to close it right away with a close frame of the
+// given code and reason, which tells the other end why it was rejected; unlike an HTTP error,
+// websocket clients surface the reason of a close frame.
+func (w WebSocketConnected) Reject(ctx *actor.Context, code int, reason string) {
+	conn, err := upgrader.Upgrade(w.Ctx.Response(), w.Ctx.Request(), nil)
+	if err != nil {
+		ctx.Respond(errors.Wrap(err, "websocket connection error"))
+		return
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			ctx.Log().WithError(err).Debug("error closing rejected websocket")
+		}
+	}()
+	if err := writeClose(conn, code, reason); err != nil {
+		ctx.Log().WithError(err).Warn("error rejecting websocket")
+	}
+	ctx.Respond(nil)
+}
+
+func writeClose(conn *websocket.Conn, code int, reason string) error {
+	return conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason), time.Now().Add(closeTimeout))
+}
+
+// CloseMessage asks a websocketActor to close its connection with a close frame of the given code
+// and reason, e.g., to tell the other end why it is cut off, and to stop.
+type CloseMessage struct {
+	Code   int
+	Reason string
+}
+
+// Heartbeat notifies the parent of a websocketActor that pings its connection that the other end
+// answered a ping in time.
+type Heartbeat struct {
+	Time time.Time
+}
+
 // WriteMessage is a message to a websocketActor asking it to write out the
 // given message, encoding it to JSON.
 type WriteMessage struct {
@@ -100,11 +210,11 @@ func WriteSocketRaw(ctx *actor.Context, socket *actor.Ref, msg interface{}) erro
 }
 
 // WrapSocket wraps a websocket connection as an actor.
-func WrapSocket(conn *websocket.Conn, msgType interface{}, usePing bool) actor.Actor {
+func WrapSocket(conn *websocket.Conn, msgType interface{}, opts SocketOptions) actor.Actor {
 	return &websocketActor{
 		conn:         conn,
 		msgType:      reflect.TypeOf(msgType),
-		usePing:      usePing,
+		opts:         opts,
 		pendingPings: make(map[string]time.Time),
 	}
 }
@@ -112,8 +222,8 @@ func WrapSocket(conn *websocket.Conn, msgType interface{}, usePing bool) actor.A
 type websocketActor struct {
 	conn    *websocket.Conn
 	msgType reflect.Type
+	opts    SocketOptions
 
-	usePing      bool
 	pingLock     sync.Mutex
 	pendingPings map[string]time.Time
 }
@@ -122,7 +232,7 @@ type websocketActor struct {
 func (s *websocketActor) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
-		if s.usePing {
+		if s.opts.UsePing {
 			s.setupPingLoop(ctx)
 		}
 		go s.runReadLoop(ctx)
@@ -131,7 +241,24 @@ func (s *websocketActor) Receive(ctx *actor.Context) error {
 		return s.conn.Close()
 	case error: // Socket read errors.
 		return msg
+	case CloseMessage:
+		if err := writeClose(s.conn, msg.Code, msg.Reason); err != nil {
+			ctx.Log().WithError(err).Warn("error closing websocket")
+		}
+		ctx.Self().Stop()
+		return nil
+	case MessageTooLarge:
+		countTraffic(s.opts.Class, func(stats *SocketStats) { stats.MessagesTooLarge++ })
+		ctx.Log().Warnf("dropped a %q message of %d bytes, more than the read limit of %d bytes",
+			msg.Type, msg.Size, msg.Limit)
+		if s.opts.NotifyTooLarge {
+			ctx.Tell(ctx.Self().Parent(), msg)
+		}
+		return nil
 	case []byte: // Incoming messages on the socket.
+		countTraffic(s.opts.Class, func(stats *SocketStats) {
+			stats.BytesReceived += int64(len(msg))
+		})
 		parsed, err := parseMsg(msg, s.msgType)
 		if err != nil {
 			return err
@@ -167,6 +294,7 @@ func (s *websocketActor) processWriteMessage(
 
 	ctx.Respond(WriteResponse{})
 
+	countTraffic(s.opts.Class, func(stats *SocketStats) { stats.BytesSent += int64(buf.Len()) })
 	return s.conn.WriteMessage(websocket.TextMessage, buf.Bytes())
 }
 
@@ -195,6 +323,7 @@ func (s *websocketActor) handlePong(ctx *actor.Context, id string) error {
 		return nil
 	}
 	delete(s.pendingPings, id)
+	ctx.Tell(ctx.Self().Parent(), Heartbeat{Time: now})
 	return nil
 }
 
@@ -252,7 +381,7 @@ func (s *websocketActor) runPingLoop(ctx *actor.Context) {
 			return err
 		}
 
-		t := time.NewTimer(pingInterval)
+		t := time.NewTimer(PingInterval)
 		defer t.Stop()
 		<-t.C
 		return nil
@@ -276,33 +405,70 @@ func (s *websocketActor) runPingLoop(ctx *actor.Context) {
 }
 
 func (s *websocketActor) runReadLoop(ctx *actor.Context) {
-	read := func() ([]byte, error) {
-		msgType, msg, err := s.conn.ReadMessage()
+	read := func() ([]byte, *MessageTooLarge, error) {
+		msgType, r, err := s.conn.NextReader()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if msgType != websocket.TextMessage && msgType != websocket.BinaryMessage {
-			return nil, errors.Errorf("unexpected message type: %d", msgType)
+			return nil, nil, errors.Errorf("unexpected message type: %d", msgType)
 		}
-		return msg, nil
+		limit := atomic.LoadInt64(&readLimit)
+		msg, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+		if err != nil {
+			return nil, nil, err
+		}
+		if int64(len(msg)) <= limit {
+			return msg, nil, nil
+		}
+		// Read the rest of the message, so that the socket can be read on.
+		rest, err := io.Copy(ioutil.Discard, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &MessageTooLarge{
+			Type:  messageTypeOf(msg),
+			Size:  int64(len(msg)) + rest,
+			Limit: limit,
+		}, nil
 	}
 
 	defer ctx.Self().Stop()
 
 	for {
-		msg, err := read()
-		if isClosingError(err) {
+		msg, tooLarge, err := read()
+		switch {
+		case isClosingError(err):
 			return
-		} else if err != nil {
+		case err != nil:
 			// Socket read errors are sent to the socket actor rather than the parent. Exceptions
 			// will bubble up the parent through the actor system.
 			ctx.Tell(ctx.Self(), err)
 			return
+		case tooLarge != nil:
+			ctx.Tell(ctx.Self(), *tooLarge)
+		default:
+			ctx.Tell(ctx.Self(), msg)
 		}
-		ctx.Tell(ctx.Self(), msg)
 	}
 }
 
+// typeField matches the "type" field of a JSON message.
+var typeField = regexp.MustCompile(`"type"\s*:\s*"([^"]*)"`)
+
+// messageTypeOf returns the value of the first "type" field in the start of a JSON message, which
+// is how the messages on most sockets say what they are, or "unknown" if there is none.
+func messageTypeOf(msg []byte) string {
+	const prefixSize = 4096
+	if len(msg) > prefixSize {
+		msg = msg[:prefixSize]
+	}
+	if match := typeField.FindSubmatch(msg); match != nil {
+		return string(match[1])
+	}
+	return "unknown"
+}
+
 func parseMsg(raw []byte, msgType reflect.Type) (interface{}, error) {
 	var parsed interface{}
 	if msgType.Kind() == reflect.Ptr {
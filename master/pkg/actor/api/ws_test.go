@@ -1,8 +1,16 @@
 package api
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
 )
 
 type parsed struct {
@@ -45,3 +53,82 @@ func Test_parseMsg(t *testing.T) {
 		runTestCase(t, tc)
 	}
 }
+
+func Test_messageTypeOf(t *testing.T) {
+	tests := map[string]string{
+		`{"type": "WORKLOAD_COMPLETED", "metrics": {}}`:  "WORKLOAD_COMPLETED",
+		`{"metrics": {"loss": 0.5}, "type":"HEARTBEAT"}`: "HEARTBEAT",
+		`{"metrics": {}}`: "unknown",
+		`not json`:        "unknown",
+	}
+	for msg, want := range tests {
+		if got := messageTypeOf([]byte(msg)); got != want {
+			t.Errorf("messageTypeOf(%q) = %q, want %q", msg, got, want)
+		}
+	}
+}
+
+// socketParent wraps the connections that it receives in websocket actors and passes on the
+// messages of its sockets.
+type socketParent chan actor.Message
+
+func (p socketParent) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case *websocket.Conn:
+		ctx.ActorOf("socket", WrapSocket(msg, parsed{}, SocketOptions{NotifyTooLarge: true}))
+	case actor.PreStart, actor.PostStop, actor.ChildStopped, actor.ChildFailed:
+	default:
+		p <- msg
+	}
+	return nil
+}
+
+func TestReadLoopDropsMessagesTooLarge(t *testing.T) {
+	SetReadLimit(64)
+	defer SetReadLimit(MaxWebsocketMessageSize)
+
+	system := actor.NewSystem(t.Name())
+	parent := make(socketParent, 10)
+	parentRef := system.MustActorOf(actor.Addr("parent"), parent)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		system.Tell(parentRef, conn)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	tooLarge := `{"type": "METRICS", "value": "` + strings.Repeat("x", 100) + `"}`
+	for _, msg := range []string{tooLarge, `{"value": "next"}`} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next := func() actor.Message {
+		select {
+		case msg := <-parent:
+			return msg
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a message")
+			return nil
+		}
+	}
+	// The message over the limit is dropped and reported, and the socket reads on.
+	want := MessageTooLarge{Type: "METRICS", Size: int64(len(tooLarge)), Limit: 64}
+	if got := next(); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := next(); got != (parsed{Value: "next"}) {
+		t.Errorf("got %v, want the message after the dropped one", got)
+	}
+}
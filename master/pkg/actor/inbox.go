@@ -11,37 +11,68 @@ type inbox struct {
 	queue      *list.List
 	closed     bool
 	queueEmpty *sync.Cond
+
+	// capacity is the number of messages the inbox holds before it rejects messages that are told
+	// to it, other than framework messages; asks are always queued, since their senders rely on
+	// getting a response. Zero means unbounded.
+	capacity int
+	// highWaterMark is the number of messages at which the inbox reports that it is backed up;
+	// zero disables the report. aboveHighWater is set once it is reported, until the inbox drains
+	// to half of the mark, so that an inbox hovering around the mark is reported once.
+	highWaterMark  int
+	aboveHighWater bool
+	// full is set once the inbox rejects a message, until it has room again, so that a full inbox
+	// is reported once.
+	full bool
 }
 
-func newInbox() *inbox {
-	i := &inbox{queue: list.New()}
+func newInbox(capacity, highWaterMark int) *inbox {
+	i := &inbox{queue: list.New(), capacity: capacity, highWaterMark: highWaterMark}
 	i.queueEmpty = sync.NewCond(&i.qLock)
 	return i
 }
 
-func (i *inbox) _add(ctx *Context) {
+// _add queues the message unless the inbox is full and the message may be dropped. It returns whether the message was queued and
+// whether that should be reported: if it was queued, because the inbox just crossed its high-water
+// mark, and otherwise, because the inbox just became full.
+func (i *inbox) _add(ctx *Context) (added bool, report bool) {
+	if i.capacity > 0 && i.queue.Len() >= i.capacity && !ctx.ExpectingResponse() &&
+		!isFrameworkMessage(ctx.message) {
+		report = !i.full
+		i.full = true
+		return false, report
+	}
 	i.queue.PushBack(ctx)
 	i.queueEmpty.Signal()
+	if i.highWaterMark > 0 && !i.aboveHighWater && i.queue.Len() >= i.highWaterMark {
+		i.aboveHighWater = true
+		return true, true
+	}
+	return true, false
 }
 
-func (i *inbox) tell(ctx context.Context, owner *Ref, sender *Ref, message Message) {
+func (i *inbox) tell(
+	ctx context.Context, owner *Ref, sender *Ref, message Message,
+) (added bool, report bool) {
 	i.qLock.Lock()
 	defer i.qLock.Unlock()
 	if i.closed {
-		return
+		return true, false
 	}
-	i._add(wrap(ctx, owner, sender, message, nil))
+	return i._add(wrap(ctx, owner, sender, message, nil))
 }
 
-func (i *inbox) ask(ctx context.Context, owner *Ref, sender *Ref, message Message) Response {
+func (i *inbox) ask(
+	ctx context.Context, owner *Ref, sender *Ref, message Message,
+) (resp Response, report bool) {
 	i.qLock.Lock()
 	defer i.qLock.Unlock()
 	if i.closed {
-		return emptyResponse(sender)
+		return emptyResponse(sender), false
 	}
-	resp := &response{source: owner, future: make(chan Message, 1)}
-	i._add(wrap(ctx, owner, sender, message, resp.future))
-	return resp
+	future := make(chan Message, 1)
+	_, report = i._add(wrap(ctx, owner, sender, message, future))
+	return &response{source: owner, future: future}, report
 }
 
 func (i *inbox) get() *Context {
@@ -55,7 +86,12 @@ func (i *inbox) get() *Context {
 		i.queueEmpty.Wait()
 	}
 
-	return i.queue.Remove(i.queue.Front()).(*Context)
+	ctx := i.queue.Remove(i.queue.Front()).(*Context)
+	i.full = false
+	if i.aboveHighWater && i.queue.Len() <= i.highWaterMark/2 {
+		i.aboveHighWater = false
+	}
+	return ctx
 }
 
 func (i *inbox) len() int {
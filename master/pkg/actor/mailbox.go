@@ -0,0 +1,86 @@
+package actor
+
+// MailboxConfig configures the mailboxes of the actors in a system.
+type MailboxConfig struct {
+	// Capacity is the number of messages that the mailbox of an actor holds; once it is full,
+	// messages told to the actor are dropped. Zero means unbounded. Asks, whose senders wait for a
+	// response, and messages of the actor framework itself, e.g., to stop the actor, are never
+	// dropped.
+	Capacity int
+	// Capacities overrides Capacity for the actors at the given addresses, e.g., "/trialLogger".
+	Capacities map[string]int
+	// HighWaterMark is the number of messages in the mailbox of an actor at which a warning is
+	// logged; zero disables the warning.
+	HighWaterMark int
+}
+
+func (c MailboxConfig) capacity(address Address) int {
+	if capacity, ok := c.Capacities[address.String()]; ok {
+		return capacity
+	}
+	return c.Capacity
+}
+
+// MailboxStats describes the mailboxes of the running actors of one type.
+type MailboxStats struct {
+	// Actors is the number of running actors of the type.
+	Actors int
+	// Depth is the total number of messages waiting in their mailboxes and MaxDepth is the number
+	// waiting in the fullest one.
+	Depth    int
+	MaxDepth int
+	// Overflows is the number of messages that were dropped because the mailbox of an actor of the
+	// type was full, since the system started.
+	Overflows int
+}
+
+// isFrameworkMessage returns whether the message is one that the actor framework relies on to
+// manage the lifecycle of actors.
+func isFrameworkMessage(message Message) bool {
+	switch message.(type) {
+	case stop, createChild, ChildFailed, ChildStopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// MailboxStats returns the statistics of the mailboxes of the actors in the system, by the type
+// of the actors.
+func (s *System) MailboxStats() map[string]MailboxStats {
+	s.refsLock.RLock()
+	refs := make([]*Ref, 0, len(s.refs)+1)
+	refs = append(refs, s.Ref)
+	for _, ref := range s.refs {
+		refs = append(refs, ref)
+	}
+	s.refsLock.RUnlock()
+
+	stats := make(map[string]MailboxStats)
+	for _, ref := range refs {
+		depth := ref.inbox.len()
+		typeStats := stats[ref.typeName]
+		typeStats.Actors++
+		typeStats.Depth += depth
+		if depth > typeStats.MaxDepth {
+			typeStats.MaxDepth = depth
+		}
+		stats[ref.typeName] = typeStats
+	}
+
+	s.overflowsLock.Lock()
+	defer s.overflowsLock.Unlock()
+	for typeName, overflows := range s.overflows {
+		typeStats := stats[typeName]
+		typeStats.Overflows = overflows
+		stats[typeName] = typeStats
+	}
+	return stats
+}
+
+// recordOverflow counts a message dropped because the mailbox of the actor was full.
+func (s *System) recordOverflow(ref *Ref) {
+	s.overflowsLock.Lock()
+	defer s.overflowsLock.Unlock()
+	s.overflows[ref.typeName]++
+}
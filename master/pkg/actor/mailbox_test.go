@@ -0,0 +1,86 @@
+package actor
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// blockingActor blocks on its first message other than lifecycle messages until unblocked.
+type blockingActor struct {
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (a *blockingActor) Receive(context *Context) error {
+	switch context.Message().(type) {
+	case PreStart, PostStop:
+	default:
+		if a.started != nil {
+			close(a.started)
+			a.started = nil
+			<-a.unblock
+		}
+		if context.ExpectingResponse() {
+			context.Respond(context.Message())
+		}
+	}
+	return nil
+}
+
+func TestMailboxCapacity(t *testing.T) {
+	system := NewSystemWithMailboxes(t.Name(), MailboxConfig{
+		Capacity:   100,
+		Capacities: map[string]int{"/small": 2},
+	})
+	actor := &blockingActor{started: make(chan struct{}), unblock: make(chan struct{})}
+	started := actor.started
+	ref, _ := system.ActorOf(Addr("small"), actor)
+
+	system.Tell(ref, "blocking")
+	<-started
+	system.Tell(ref, "queued")
+	resp := system.Ask(ref, "queued")
+	system.Tell(ref, "dropped")
+
+	stats := system.MailboxStats()["blockingActor"]
+	assert.Equal(t, stats, MailboxStats{Actors: 1, Depth: 2, MaxDepth: 2, Overflows: 1})
+
+	// Asks and framework messages are delivered even to a full mailbox.
+	overflow := system.Ask(ref, "asked while full")
+	ref.Stop()
+	close(actor.unblock)
+	assert.Equal(t, resp.Get(), "queued")
+	assert.Equal(t, overflow.Get(), "asked while full")
+	stats = system.MailboxStats()["blockingActor"]
+	assert.Equal(t, stats.Overflows, 1)
+	assert.NilError(t, ref.AwaitTermination())
+	assert.NilError(t, system.StopAndAwaitTermination())
+}
+
+func TestMailboxHighWaterMark(t *testing.T) {
+	system := NewSystemWithMailboxes(t.Name(), MailboxConfig{HighWaterMark: 4})
+	ref, _ := system.ActorOf(Addr("mock"), &mockActor{})
+	tell := func(i *inbox) bool {
+		_, report := i.tell(context.Background(), ref, nil, "message")
+		return report
+	}
+
+	i := newInbox(0, 4)
+	for n := 1; n <= 6; n++ {
+		assert.Equal(t, tell(i), n == 4, "message %d", n)
+	}
+
+	// The warning is not repeated until the mailbox drains to half of the mark.
+	i.get()
+	i.get()
+	i.get()
+	assert.Assert(t, !tell(i))
+	i.get()
+	i.get()
+	assert.Assert(t, !tell(i))
+	assert.Assert(t, tell(i))
+
+	assert.NilError(t, system.StopAndAwaitTermination())
+}
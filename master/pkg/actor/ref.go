@@ -40,6 +40,7 @@ type (
 type Ref struct {
 	log *log.Entry
 
+	typeName       string
 	address        Address
 	registeredTime time.Time
 
@@ -73,6 +74,7 @@ func newRef(system *System, parent *Ref, address Address, actor Actor) *Ref {
 		log: log.WithField("type", typeName).WithField("id", address.Local()).WithField(
 			"system", system.id),
 
+		typeName:       typeName,
 		address:        address,
 		registeredTime: time.Now(),
 
@@ -81,7 +83,8 @@ func newRef(system *System, parent *Ref, address Address, actor Actor) *Ref {
 		parent:       parent,
 		children:     make(map[Address]*Ref),
 		deadChildren: make(map[Address]bool),
-		inbox:        newInbox(),
+		inbox: newInbox(
+			system.mailboxes.capacity(address), system.mailboxes.HighWaterMark),
 	}
 
 	if traceEnabled {
@@ -135,14 +138,32 @@ func (r *Ref) tell(ctx context.Context, sender *Ref, message Message) {
 	if traceEnabled {
 		ctx = traceSend(ctx, sender, r, message, tellOperation)
 	}
-	r.inbox.tell(ctx, r, sender, message)
+	added, report := r.inbox.tell(ctx, r, sender, message)
+	r.checkMailbox(message, added, report)
 }
 
 func (r *Ref) ask(ctx context.Context, sender *Ref, message Message) Response {
 	if traceEnabled {
 		ctx = traceSend(ctx, sender, r, message, askOperation)
 	}
-	return r.inbox.ask(ctx, r, sender, message)
+	resp, report := r.inbox.ask(ctx, r, sender, message)
+	r.checkMailbox(message, true, report)
+	return resp
+}
+
+// checkMailbox records a message that was dropped because the mailbox of the actor was full and
+// warns when the mailbox backs up.
+func (r *Ref) checkMailbox(message Message, added, report bool) {
+	if !added {
+		r.system.recordOverflow(r)
+	}
+	switch {
+	case !report:
+	case added:
+		r.log.Warnf("mailbox has reached %d messages", r.system.mailboxes.HighWaterMark)
+	default:
+		r.log.Warnf("mailbox is full, dropping messages starting with %T", message)
+	}
 }
 
 // sendInternalMessage sends an actor framework message. These messages can be safely ignored by the
@@ -51,13 +51,12 @@ func (r *response) Source() *Ref {
 }
 
 func (r *response) get() Message {
-	if r.fetched {
-		return r.result
-	}
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	r.fetched = true
-	r.result = <-r.future
+	if !r.fetched {
+		r.fetched = true
+		r.result = <-r.future
+	}
 	return r.result
 }
 
@@ -77,19 +76,20 @@ func (r *response) GetOrElse(defaultValue Message) Message {
 }
 
 func (r *response) GetOrElseTimeout(defaultValue Message, timeout time.Duration) (Message, bool) {
-	future := make(chan Message, 1)
-	go func() {
-		future <- r.get()
-	}()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.fetched {
+		return r.result, true
+	}
+	// Wait on the future directly rather than through get, which would hold the lock until the
+	// actor responds and so keep the timeout from taking effect.
 	t := time.NewTimer(timeout)
 	defer t.Stop()
+	r.fetched = true
 	select {
-	case result := <-future:
-		return result, true
+	case r.result = <-r.future:
+		return r.result, true
 	case <-t.C:
-		r.lock.Lock()
-		defer r.lock.Unlock()
-		r.fetched = true
 		r.result = errNoResponse
 		return defaultValue, false
 	}
@@ -20,3 +20,20 @@ func TestResponseTimeout(t *testing.T) {
 	assert.Assert(t, result.(bool))
 	assert.Assert(t, !ok)
 }
+
+func TestResponseTimeoutDoesNotWaitForResponse(t *testing.T) {
+	system := NewSystem(t.Name())
+	release := make(chan struct{})
+	defer close(release)
+	ref, _ := system.ActorOf(Addr("test"), ActorFunc(func(context *Context) error {
+		if context.ExpectingResponse() {
+			<-release
+			context.Respond(false)
+		}
+		return nil
+	}))
+	resp := system.Ask(ref, "")
+	_, ok := resp.GetOrTimeout(10 * time.Millisecond)
+	assert.Assert(t, !ok)
+	assert.Assert(t, resp.Empty())
+}
@@ -13,6 +13,10 @@ type System struct {
 
 	refsLock sync.RWMutex
 	refs     map[Address]*Ref
+
+	mailboxes     MailboxConfig
+	overflowsLock sync.Mutex
+	overflows     map[string]int
 }
 
 // NewSystem constructs a new actor system and starts it.
@@ -20,9 +24,24 @@ func NewSystem(id string) *System {
 	return NewSystemWithRoot(id, &rootActor{})
 }
 
+// NewSystemWithMailboxes constructs a new actor system whose actors have mailboxes configured as
+// specified and starts it.
+func NewSystemWithMailboxes(id string, mailboxes MailboxConfig) *System {
+	return newSystem(id, &rootActor{}, mailboxes)
+}
+
 // NewSystemWithRoot constructs a new actor system with the specified root actor and starts it.
 func NewSystemWithRoot(id string, actor Actor) *System {
-	system := &System{id: id, refs: make(map[Address]*Ref)}
+	return newSystem(id, actor, MailboxConfig{})
+}
+
+func newSystem(id string, actor Actor, mailboxes MailboxConfig) *System {
+	system := &System{
+		id:        id,
+		refs:      make(map[Address]*Ref),
+		mailboxes: mailboxes,
+		overflows: make(map[string]int),
+	}
 	system.Ref = newRef(system, nil, rootAddress, actor)
 	return system
 }
@@ -11,6 +11,9 @@ type ContainerFailure struct {
 	FailureType FailureType
 	ErrMsg      string
 	ExitCode    *ExitCode
+	// OOMKilled is whether Docker reports that the kernel killed the container because it ran out
+	// of memory. Containers killed for other reasons, e.g., by the master, exit with the same code.
+	OOMKilled bool
 }
 
 func (c ContainerFailure) Error() string {
@@ -80,4 +83,11 @@ const (
 
 	// AgentError denotes that the agent failed to launch the container.
 	AgentError = FailureType("agent failed to launch the container")
+
+	// ImagePullFailed denotes that the agent failed to pull the image of the container.
+	ImagePullFailed = FailureType("agent failed to pull the container image")
+
+	// ImagePullAuthFailed denotes that the registry rejected the credentials used to pull the image
+	// of the container.
+	ImagePullAuthFailed = FailureType("image pull authentication failed")
 )
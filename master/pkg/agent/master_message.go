@@ -20,13 +20,53 @@ type TelemetryInfo struct {
 	SegmentKey string `json:"segment_key,omitempty"`
 }
 
+// MaintenanceInfo contains the maintenance mode of the master, for banners in the WebUI.
+type MaintenanceInfo struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+}
+
+// BuildInfo describes how the master was built.
+type BuildInfo struct {
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
 // MasterInfo contains the master information that the agent has connected to.
 type MasterInfo struct {
-	Version     string        `json:"version"`
-	MasterID    string        `json:"master_id"`
-	ClusterID   string        `json:"cluster_id"`
-	ClusterName string        `json:"cluster_name"`
-	Telemetry   TelemetryInfo `json:"telemetry"`
+	Version     string          `json:"version"`
+	MasterID    string          `json:"master_id"`
+	ClusterID   string          `json:"cluster_id"`
+	ClusterName string          `json:"cluster_name"`
+	MasterURL   string          `json:"master_url,omitempty"`
+	Telemetry   TelemetryInfo   `json:"telemetry"`
+	Maintenance MaintenanceInfo `json:"maintenance"`
+	// Degraded is why the master is only partly available, e.g., while it waits for the database.
+	Degraded string `json:"degraded,omitempty"`
+	// AgentVersions counts the connected agents by their versions.
+	AgentVersions map[string]int `json:"agent_versions,omitempty"`
+
+	// APIVersion, MinCLIVersion and Features let clients check that they are compatible with the
+	// master before relying on its APIs.
+	APIVersion    string    `json:"api_version"`
+	MinCLIVersion string    `json:"min_cli_version"`
+	Features      []string  `json:"features"`
+	Build         BuildInfo `json:"build"`
+}
+
+// AgentVersionRejectedCode is the code of the close frame with which the master rejects agents
+// whose versions it does not support. The reason of the frame is an AgentVersionRejection encoded
+// as JSON.
+const AgentVersionRejectedCode = 4001
+
+// AgentVersionRejection tells the operator of a rejected agent which versions the master supports
+// and what to upgrade: "agent" if the agent is too old and "master" if it is too new.
+type AgentVersionRejection struct {
+	AgentVersion string `json:"agent_version"`
+	MinVersion   string `json:"min_version"`
+	MaxVersion   string `json:"max_version,omitempty"`
+	Upgrade      string `json:"upgrade"`
 }
 
 // MasterMessage is a union type for all messages sent from agents.
@@ -42,6 +82,9 @@ type AgentStarted struct {
 	ResourcePool string
 	Label        string
 	Devices      []device.Device
+	// Time is the time on the clock of the agent when it sent the message, with which the master
+	// measures the skew between their clocks. It is zero for agents that predate it.
+	Time time.Time
 }
 
 // ContainerStateChanged notifies the master that the agent transitioned the container state.
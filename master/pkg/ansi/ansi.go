@@ -0,0 +1,15 @@
+// Package ansi removes ANSI escape sequences, such as the color codes that training frameworks
+// write to their logs, from text.
+package ansi
+
+import "regexp"
+
+// escapes matches control sequences (ESC [ ... final byte), operating system commands
+// (ESC ] ... BEL or ESC \), and two-byte escapes (ESC followed by a single byte).
+var escapes = regexp.MustCompile(
+	"\x1b(?:\\[[0-?]*[ -/]*[@-~]|\\][^\x07\x1b]*(?:\x07|\x1b\\\\)|[@-Z\\\\-_])")
+
+// Strip returns the text with its ANSI escape sequences removed.
+func Strip(s string) string {
+	return escapes.ReplaceAllString(s, "")
+}
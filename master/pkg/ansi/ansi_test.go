@@ -0,0 +1,23 @@
+package ansi
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestStrip(t *testing.T) {
+	for input, expected := range map[string]string{
+		"plain text":                   "plain text",
+		"\x1b[32mINFO\x1b[0m: started": "INFO: started",
+		"\x1b[1;31mERROR\x1b[m":        "ERROR",
+		"50%|\x1b[34m█████\x1b[0m| 5/10 [00:01<00:01]":        "50%|█████| 5/10 [00:01<00:01]",
+		"\x1b[2K\x1b[1Gepoch 1":                               "epoch 1",
+		"\x1b]0;title\x07done":                                "done",
+		"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\": "link",
+		"\x1bMup":            "up",
+		"[32m not an escape": "[32m not an escape",
+	} {
+		assert.Equal(t, Strip(input), expected, "%q", input)
+	}
+}
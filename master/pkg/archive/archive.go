@@ -232,3 +232,32 @@ func FromTarGz(zippedTarfile []byte) (Archive, error) {
 
 	return ar, nil
 }
+
+// CountTarGz checks that the reader holds a .tar.gz archive and returns the number of items in
+// it. Unlike FromTarGz, it streams the archive rather than holding its contents in memory.
+func CountTarGz(r io.Reader) (int, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+
+	n := 0
+	for {
+		_, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		n++
+	}
+
+	// Read to the end of the gzip stream, which verifies its checksum.
+	if _, err := io.Copy(ioutil.Discard, gzipReader); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
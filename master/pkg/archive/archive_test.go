@@ -2,6 +2,7 @@ package archive
 
 import (
 	"archive/tar"
+	"bytes"
 	"encoding/json"
 	"os"
 	"reflect"
@@ -133,3 +134,21 @@ func TestRoundtrip(t *testing.T) {
 
 	assert.DeepEqual(t, archive, roundTripArchive)
 }
+
+func TestCountTarGz(t *testing.T) {
+	archive := Archive{
+		RootItem("dir", nil, 0755, tar.TypeDir),
+		RootItem("dir/a.txt", []byte("this is a"), 0644, tar.TypeReg),
+	}
+	content, err := ToTarGz(archive)
+	assert.NilError(t, err)
+
+	n, err := CountTarGz(bytes.NewReader(content))
+	assert.NilError(t, err)
+	assert.Equal(t, n, 2)
+
+	_, err = CountTarGz(bytes.NewReader([]byte("not an archive")))
+	assert.ErrorContains(t, err, "gzip: invalid header")
+	_, err = CountTarGz(bytes.NewReader(content[:len(content)-4]))
+	assert.Assert(t, err != nil)
+}
@@ -0,0 +1,128 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// KnownFields returns an error naming the path of every field in the JSON object data that does
+// not correspond to a field of v, at any level of nesting. Unlike decoding with
+// DisallowUnknownFields, it also looks inside types with custom JSON unmarshaling, including
+// union types. Structs with neither json nor union struct tags are assumed to have a custom JSON
+// form and are not checked. Field names are matched case-insensitively, like encoding/json does.
+func KnownFields(data []byte, v interface{}) error {
+	var parsed interface{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	var unknown []string
+	unknownFields(parsed, reflect.TypeOf(v), "", &unknown)
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return errors.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+}
+
+func unknownFields(value interface{}, t reflect.Type, path string, unknown *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := structFields(t, object)
+		if fields == nil {
+			return
+		}
+		for key, child := range object {
+			field, ok := fields[strings.ToLower(key)]
+			switch {
+			case !ok:
+				*unknown = append(*unknown, fieldPath(path, key))
+			case field != nil:
+				unknownFields(child, field, fieldPath(path, key), unknown)
+			}
+		}
+	case reflect.Map:
+		object, _ := value.(map[string]interface{})
+		for key, child := range object {
+			unknownFields(child, t.Elem(), fieldPath(path, key), unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		items, _ := value.([]interface{})
+		for i, child := range items {
+			unknownFields(child, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+		}
+	}
+}
+
+// structFields returns the lowercased JSON names of the fields of t, given the object being
+// decoded into it, mapped to their types. Union type keys map to nil, since their values are not
+// checked further. It returns nil if t has no tagged fields.
+func structFields(t reflect.Type, object map[string]interface{}) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	tagged := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// The fields of the union member selected by the object are inlined into t.
+		if tag, ok := field.Tag.Lookup("union"); ok {
+			tagged = true
+			parsed := strings.Split(tag, ",")
+			if len(parsed) != 2 {
+				continue
+			}
+			fields[parsed[0]] = nil
+			if object[parsed[0]] == parsed[1] {
+				for name, member := range structFields(field.Type.Elem(), object) {
+					fields[name] = member
+				}
+			}
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("json")
+		tagged = tagged || ok
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case tag == "-":
+			continue
+		case field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct:
+			embedded := structFields(fieldType, object)
+			tagged = tagged || embedded != nil
+			for name, embeddedField := range embedded {
+				fields[name] = embeddedField
+			}
+			continue
+		case field.PkgPath != "":
+			continue
+		case name == "":
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field.Type
+	}
+	if !tagged {
+		return nil
+	}
+	return fields
+}
+
+func fieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
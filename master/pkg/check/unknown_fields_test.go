@@ -0,0 +1,63 @@
+package check
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type knownFieldsPool struct {
+	Name      string                `json:"pool_name"`
+	Scheduler *knownFieldsScheduler `json:"scheduler"`
+}
+
+type knownFieldsScheduler struct {
+	Fair     *knownFieldsFair `union:"type,fair" json:"-"`
+	Priority *struct {
+		Preemption bool `json:"preemption"`
+	} `union:"type,priority" json:"-"`
+	FittingPolicy string `json:"fitting_policy"`
+}
+
+type knownFieldsFair struct{}
+
+type knownFieldsEmbedded struct {
+	Pools []knownFieldsPool `json:"resource_pools"`
+}
+
+type knownFieldsLength struct {
+	Unit  string
+	Units int
+}
+
+type knownFieldsConfig struct {
+	Port int `json:"port"`
+	*knownFieldsEmbedded
+	Labels  map[string]knownFieldsPool `json:"labels"`
+	Length  knownFieldsLength          `json:"length"`
+	Data    map[string]interface{}     `json:"data"`
+	Ignored string                     `json:"-"`
+}
+
+func TestKnownFields(t *testing.T) {
+	assert.NilError(t, KnownFields([]byte(`{
+		"port": 8080,
+		"resource_pools": [{"pool_name": "a", "scheduler": {"type": "priority", "preemption": true}}],
+		"labels": {"x": {"POOL_NAME": "b"}},
+		"length": {"batches": 100},
+		"data": {"anything": {"goes": true}}
+	}`), &knownFieldsConfig{}))
+
+	err := KnownFields([]byte(`{
+		"prot": 8080,
+		"resouce_pools": [],
+		"resource_pools": [
+			{"pool_name": "a", "scheduler": {"type": "fair", "preemption": true}},
+			{"pool_name": "b", "scheduler": {"type": "priority", "fiting_policy": "best"}}
+		],
+		"labels": {"x": {"name": "b"}},
+		"Ignored": "x"
+	}`), &knownFieldsConfig{})
+	assert.Error(t, err, "unknown fields: Ignored, labels.x.name, prot, resouce_pools, "+
+		"resource_pools[0].scheduler.preemption, resource_pools[1].scheduler.fiting_policy")
+}
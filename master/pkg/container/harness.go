@@ -0,0 +1,76 @@
+package container
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// AnyHarnessPlatform is the platform key of the harness wheels that work on every platform.
+const AnyHarnessPlatform = "any"
+
+// HarnessPlatform is a platform that harness wheels are built for.
+type HarnessPlatform struct {
+	OS     string
+	Arch   string
+	Python string
+}
+
+// Key returns the name of the platform in the paths of harness wheels, e.g., linux-arm64-py3.6.
+func (p HarnessPlatform) Key() string {
+	return fmt.Sprintf("%s-%s-py%s", p.OS, p.Arch, p.Python)
+}
+
+func (p HarnessPlatform) String() string {
+	return fmt.Sprintf("%s/%s py%s", p.OS, p.Arch, p.Python)
+}
+
+// HarnessWheel is a harness wheel that the master serves. The checksum lets agents cache it.
+type HarnessWheel struct {
+	Name   string
+	SHA256 string
+	Size   int64
+}
+
+// HarnessManifest lists the harness wheels that the master serves for each platform.
+type HarnessManifest struct {
+	// Python is the Python version that tasks install the harness with.
+	Python string
+	// Wheels maps platform keys to the wheels built for the platform.
+	Wheels map[string][]HarnessWheel
+}
+
+// Resolve returns the key of the platform to fetch the harness wheels for the given platform from
+// and the wheels, preferring wheels built for the platform to those that work on every platform.
+func (m HarnessManifest) Resolve(p HarnessPlatform) (string, []HarnessWheel, error) {
+	if wheels := m.Wheels[p.Key()]; len(wheels) > 0 {
+		return p.Key(), wheels, nil
+	}
+	if wheels := m.Wheels[AnyHarnessPlatform]; len(wheels) > 0 {
+		return AnyHarnessPlatform, wheels, nil
+	}
+	return "", nil, errors.Errorf("no harness wheel for %s", p)
+}
+
+// Lookup returns the wheel with the given name that the master serves for the platform key.
+func (m HarnessManifest) Lookup(platformKey, name string) (HarnessWheel, bool) {
+	for _, wheel := range m.Wheels[platformKey] {
+		if wheel.Name == name {
+			return wheel, true
+		}
+	}
+	return HarnessWheel{}, false
+}
+
+// HarnessFiles tells an agent to fetch the harness wheels for its platform from the master and copy
+// them into the container before starting it.
+type HarnessFiles struct {
+	// Path is the directory in the container to copy the wheels to.
+	Path     string
+	Manifest HarnessManifest
+	UserID   int
+	GroupID  int
+	// Token is the session token that the agent authenticates to the master with when it fetches
+	// the wheels.
+	Token string
+}
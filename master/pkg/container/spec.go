@@ -29,6 +29,8 @@ type RunSpec struct {
 
 	Archives         []RunArchive
 	UseFluentLogging bool
+	// Harness, if set, is copied into the container after the archives.
+	Harness *HarnessFiles
 }
 
 // ChecksConfig describes the configuration for multiple readiness checks.
@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestNext(t *testing.T) {
+	// 2020-11-06 is a Friday.
+	start := time.Date(2020, 11, 6, 10, 30, 15, 0, time.UTC)
+	for _, tc := range []struct {
+		expr string
+		next []time.Time
+	}{
+		{"*/20 * * * *", []time.Time{
+			time.Date(2020, 11, 6, 10, 40, 0, 0, time.UTC),
+			time.Date(2020, 11, 6, 11, 0, 0, 0, time.UTC),
+		}},
+		{"@daily", []time.Time{
+			time.Date(2020, 11, 7, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 11, 8, 0, 0, 0, 0, time.UTC),
+		}},
+		{"30 2 * * mon-fri", []time.Time{
+			time.Date(2020, 11, 9, 2, 30, 0, 0, time.UTC),
+			time.Date(2020, 11, 10, 2, 30, 0, 0, time.UTC),
+		}},
+		{"0 12 1,15 * *", []time.Time{
+			time.Date(2020, 11, 15, 12, 0, 0, 0, time.UTC),
+			time.Date(2020, 12, 1, 12, 0, 0, 0, time.UTC),
+		}},
+		// With both days restricted, either may match.
+		{"0 0 1 * 0", []time.Time{
+			time.Date(2020, 11, 8, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 11, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 29 feb *", []time.Time{
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 9 * * 7", []time.Time{
+			time.Date(2020, 11, 8, 9, 0, 0, 0, time.UTC),
+			time.Date(2020, 11, 15, 9, 0, 0, 0, time.UTC),
+		}},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			assert.NilError(t, err)
+			next := start
+			for _, expected := range tc.next {
+				next = s.Next(next)
+				assert.Equal(t, next, expected)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"0 0 31 feb *",
+	} {
+		_, err := Parse(expr)
+		assert.Assert(t, err != nil, expr)
+	}
+}
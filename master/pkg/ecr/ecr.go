@@ -0,0 +1,102 @@
+// Package ecr gets Docker registry credentials for Amazon ECR registries from authorization
+// tokens, which are cached until shortly before they expire.
+package ecr
+
+import (
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsecr "github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+// refreshMargin is how long before they expire tokens are replaced, so that pulls that start
+// with a cached token have time to finish.
+const refreshMargin = 30 * time.Minute
+
+type cacheKey struct {
+	region  string
+	roleARN string
+}
+
+type cachedAuth struct {
+	auth      types.AuthConfig
+	expiresAt time.Time
+}
+
+var (
+	cacheLock sync.Mutex
+	cache     = map[cacheKey]cachedAuth{}
+)
+
+// CachedAuthConfig returns the credentials that AuthConfig returns if they are cached, without
+// calling out to AWS.
+func CachedAuthConfig(region, roleARN string) (types.AuthConfig, bool) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	cached, ok := cache[cacheKey{region: region, roleARN: roleARN}]
+	if !ok || !time.Now().Add(refreshMargin).Before(cached.expiresAt) {
+		return types.AuthConfig{}, false
+	}
+	return cached.auth, true
+}
+
+// AuthConfig returns the credentials for the ECR registries of the region from a token of the
+// given IAM role, which is assumed with the credentials of the master, or of the credentials of
+// the master themselves if roleARN is empty. It calls out to AWS unless the credentials are
+// cached, so callers must not hold up others while it runs.
+func AuthConfig(region, roleARN string) (types.AuthConfig, error) {
+	if auth, ok := CachedAuthConfig(region, roleARN); ok {
+		return auth, nil
+	}
+
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return types.AuthConfig{}, errors.Wrap(err, "error creating AWS session")
+	}
+	config := &aws.Config{}
+	if roleARN != "" {
+		config.Credentials = stscreds.NewCredentials(sess, roleARN)
+	}
+	out, err := awsecr.New(sess, config).GetAuthorizationToken(&awsecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return types.AuthConfig{}, errors.Wrapf(err, "error getting ECR token in %s", region)
+	}
+	if len(out.AuthorizationData) == 0 {
+		return types.AuthConfig{}, errors.Errorf("no ECR token returned in %s", region)
+	}
+	data := out.AuthorizationData[0]
+	auth, err := parseToken(
+		aws.StringValue(data.AuthorizationToken), aws.StringValue(data.ProxyEndpoint))
+	if err != nil {
+		return types.AuthConfig{}, err
+	}
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	cache[cacheKey{region: region, roleARN: roleARN}] = cachedAuth{
+		auth: auth, expiresAt: aws.TimeValue(data.ExpiresAt),
+	}
+	return auth, nil
+}
+
+// parseToken returns the credentials of an ECR authorization token, which is the base64 encoding
+// of a username and password separated by a colon.
+func parseToken(token, endpoint string) (types.AuthConfig, error) {
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return types.AuthConfig{}, errors.Wrap(err, "error decoding ECR token")
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return types.AuthConfig{}, errors.New("ECR token is not a username and password")
+	}
+	return types.AuthConfig{
+		Username: parts[0], Password: parts[1], ServerAddress: endpoint,
+	}, nil
+}
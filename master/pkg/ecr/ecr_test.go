@@ -0,0 +1,45 @@
+package ecr
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+)
+
+func TestParseToken(t *testing.T) {
+	token := base64.StdEncoding.EncodeToString([]byte("AWS:pass:word"))
+	auth, err := parseToken(token, "https://123.dkr.ecr.us-west-2.amazonaws.com")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, auth, types.AuthConfig{
+		Username:      "AWS",
+		Password:      "pass:word",
+		ServerAddress: "https://123.dkr.ecr.us-west-2.amazonaws.com",
+	})
+
+	_, err = parseToken(base64.StdEncoding.EncodeToString([]byte("AWS")), "")
+	assert.ErrorContains(t, err, "not a username and password")
+	_, err = parseToken("not base64!", "")
+	assert.ErrorContains(t, err, "error decoding")
+}
+
+func TestCachedAuthConfig(t *testing.T) {
+	cacheLock.Lock()
+	cache[cacheKey{region: "fresh"}] = cachedAuth{
+		auth: types.AuthConfig{Username: "AWS"}, expiresAt: time.Now().Add(12 * time.Hour),
+	}
+	cache[cacheKey{region: "expiring"}] = cachedAuth{
+		auth: types.AuthConfig{Username: "AWS"}, expiresAt: time.Now().Add(refreshMargin / 2),
+	}
+	cacheLock.Unlock()
+
+	auth, ok := CachedAuthConfig("fresh", "")
+	assert.Assert(t, ok)
+	assert.Equal(t, auth.Username, "AWS")
+	_, ok = CachedAuthConfig("fresh", "arn:aws:iam::123:role/other")
+	assert.Assert(t, !ok)
+	_, ok = CachedAuthConfig("expiring", "")
+	assert.Assert(t, !ok)
+}
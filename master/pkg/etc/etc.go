@@ -32,6 +32,8 @@ const (
 	AgentSetupScriptTemplateResource = "agent_setup_script.sh.template"
 	// K8InitContainerEntryScriptResource is the script to run the init container on k8s.
 	K8InitContainerEntryScriptResource = "k8_init_container_entrypoint.sh"
+	// ExperimentEmailTemplateResource is the template for emails that summarize experiments.
+	ExperimentEmailTemplateResource = "experiment_email.template"
 )
 
 var staticRoot string
@@ -0,0 +1,218 @@
+// Package jsonpatch applies JSON Patch (RFC 6902) and JSON Merge Patch (RFC 7386) documents to
+// JSON values decoded by encoding/json.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Operation is a single operation of a JSON Patch. The add, remove, replace and test operations
+// are supported.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// OperationError is returned when an operation of a JSON Patch cannot be applied.
+type OperationError struct {
+	// Index is the position of the operation in the patch.
+	Index     int
+	Operation Operation
+	Err       error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %d (%s %q) failed: %s",
+		e.Index, e.Operation.Op, e.Operation.Path, e.Err)
+}
+
+// Apply applies the operations of a JSON Patch to doc, in order, and returns the patched document.
+// doc may be modified in place. If an operation fails, the error is an *OperationError.
+func Apply(doc interface{}, patch []Operation) (interface{}, error) {
+	for i, op := range patch {
+		patched, err := op.apply(doc)
+		if err != nil {
+			return nil, &OperationError{Index: i, Operation: op, Err: err}
+		}
+		doc = patched
+	}
+	return doc, nil
+}
+
+// MergePatch applies a JSON Merge Patch to doc and returns the patched document. Objects in the
+// patch are merged into doc recursively; null values delete keys, and other values replace them.
+// doc may be modified in place.
+func MergePatch(doc, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	docObj, ok := doc.(map[string]interface{})
+	if !ok || docObj == nil {
+		docObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(docObj, key)
+		} else {
+			docObj[key] = MergePatch(docObj[key], value)
+		}
+	}
+	return docObj
+}
+
+func (o Operation) apply(doc interface{}) (interface{}, error) {
+	tokens, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch o.Op {
+	case "add", "replace", "test":
+		if len(o.Value) == 0 {
+			return nil, errors.New("missing value")
+		}
+		if err := json.Unmarshal(o.Value, &value); err != nil {
+			return nil, errors.Wrap(err, "invalid value")
+		}
+	case "remove":
+	default:
+		return nil, errors.Errorf("unsupported operation %q", o.Op)
+	}
+
+	if o.Op == "test" {
+		current, err := get(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, errors.Errorf("value does not match: found %v", current)
+		}
+		return doc, nil
+	}
+	if len(tokens) == 0 {
+		if o.Op == "remove" {
+			return nil, errors.New("cannot remove the whole document")
+		}
+		return value, nil
+	}
+	return update(doc, tokens, o.Op, value)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf("path %q must be empty or start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func get(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func child(doc interface{}, token string) (interface{}, error) {
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		value, ok := doc[token]
+		if !ok {
+			return nil, errors.Errorf("key %q does not exist", token)
+		}
+		return value, nil
+	case []interface{}:
+		i, err := index(token, len(doc)-1)
+		if err != nil {
+			return nil, err
+		}
+		return doc[i], nil
+	default:
+		return nil, errors.Errorf("cannot find %q in a value that is not an object or array", token)
+	}
+}
+
+// index parses an array index, which must be at most max.
+func index(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, errors.Errorf("invalid array index %q", token)
+	}
+	if i > max {
+		return 0, errors.Errorf("array index %d is out of bounds", i)
+	}
+	return i, nil
+}
+
+// update applies an add, remove or replace operation at the location given by tokens, which must
+// not be empty, and returns the updated doc.
+func update(doc interface{}, tokens []string, op string, value interface{}) (interface{}, error) {
+	token := tokens[0]
+	if len(tokens) > 1 {
+		next, err := child(doc, token)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = update(next, tokens[1:], op, value); err != nil {
+			return nil, err
+		}
+		op = "replace"
+	}
+
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		if _, ok := doc[token]; !ok && op != "add" {
+			return nil, errors.Errorf("key %q does not exist", token)
+		}
+		if op == "remove" {
+			delete(doc, token)
+		} else {
+			doc[token] = value
+		}
+		return doc, nil
+	case []interface{}:
+		if op == "add" && token == "-" {
+			return append(doc, value), nil
+		}
+		max := len(doc) - 1
+		if op == "add" {
+			max = len(doc)
+		}
+		i, err := index(token, max)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "add":
+			doc = append(doc, nil)
+			copy(doc[i+1:], doc[i:])
+			doc[i] = value
+		case "remove":
+			doc = append(doc[:i], doc[i+1:]...)
+		default:
+			doc[i] = value
+		}
+		return doc, nil
+	default:
+		return nil, errors.Errorf("cannot find %q in a value that is not an object or array", token)
+	}
+}
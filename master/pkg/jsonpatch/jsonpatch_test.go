@@ -0,0 +1,91 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func decode(t *testing.T, data string) interface{} {
+	var value interface{}
+	assert.NilError(t, json.Unmarshal([]byte(data), &value))
+	return value
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"a": {"b": 1, "c/d": [1, 2, 3]}, "e": "f"}`
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+	}{
+		{"add key", `[{"op": "add", "path": "/a/x", "value": {"y": null}}]`,
+			`{"a": {"b": 1, "c/d": [1, 2, 3], "x": {"y": null}}, "e": "f"}`},
+		{"add replaces key", `[{"op": "add", "path": "/e", "value": 2}]`,
+			`{"a": {"b": 1, "c/d": [1, 2, 3]}, "e": 2}`},
+		{"add to array", `[{"op": "add", "path": "/a/c~1d/1", "value": 0}]`,
+			`{"a": {"b": 1, "c/d": [1, 0, 2, 3]}, "e": "f"}`},
+		{"append to array", `[{"op": "add", "path": "/a/c~1d/-", "value": 4}]`,
+			`{"a": {"b": 1, "c/d": [1, 2, 3, 4]}, "e": "f"}`},
+		{"remove key", `[{"op": "remove", "path": "/a/b"}]`,
+			`{"a": {"c/d": [1, 2, 3]}, "e": "f"}`},
+		{"remove from array", `[{"op": "remove", "path": "/a/c~1d/0"}]`,
+			`{"a": {"b": 1, "c/d": [2, 3]}, "e": "f"}`},
+		{"replace", `[{"op": "replace", "path": "/a/c~1d/2", "value": "x"}]`,
+			`{"a": {"b": 1, "c/d": [1, 2, "x"]}, "e": "f"}`},
+		{"replace document", `[{"op": "replace", "path": "", "value": {}}]`, `{}`},
+		{"test then replace", `[
+			{"op": "test", "path": "/a/b", "value": 1},
+			{"op": "replace", "path": "/a/b", "value": 2}
+		]`, `{"a": {"b": 2, "c/d": [1, 2, 3]}, "e": "f"}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var patch []Operation
+			assert.NilError(t, json.Unmarshal([]byte(tc.patch), &patch))
+			patched, err := Apply(decode(t, doc), patch)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, patched, decode(t, tc.expected))
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	doc := `{"a": {"b": [1]}}`
+	tests := []struct {
+		name  string
+		patch string
+		index int
+	}{
+		{"missing key", `[{"op": "replace", "path": "/a/x", "value": 1}]`, 0},
+		{"missing parent", `[{"op": "add", "path": "/x/y", "value": 1}]`, 0},
+		{"index out of bounds", `[{"op": "add", "path": "/a/b/2", "value": 1}]`, 0},
+		{"invalid index", `[{"op": "remove", "path": "/a/b/01"}]`, 0},
+		{"not a container", `[{"op": "add", "path": "/a/b/0/c", "value": 1}]`, 0},
+		{"invalid path", `[{"op": "remove", "path": "a"}]`, 0},
+		{"missing value", `[{"op": "add", "path": "/a/c"}]`, 0},
+		{"unsupported op", `[{"op": "move", "path": "/a/c"}]`, 0},
+		{"failed test", `[
+			{"op": "remove", "path": "/a/b/0"},
+			{"op": "test", "path": "/a/b", "value": [1]}
+		]`, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var patch []Operation
+			assert.NilError(t, json.Unmarshal([]byte(tc.patch), &patch))
+			_, err := Apply(decode(t, doc), patch)
+			opErr, ok := err.(*OperationError)
+			assert.Assert(t, ok, "unexpected error: %v", err)
+			assert.Equal(t, opErr.Index, tc.index)
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	doc := decode(t, `{"a": {"b": 1, "c": 2}, "d": [1], "e": "f"}`)
+	patch := decode(t, `{"a": {"b": null, "x": 3}, "d": {"y": 4}, "e": null, "g": "h"}`)
+	assert.DeepEqual(t, MergePatch(doc, patch),
+		decode(t, `{"a": {"c": 2, "x": 3}, "d": {"y": 4}, "g": "h"}`))
+}
@@ -0,0 +1,213 @@
+// Package jsonschema generates JSON Schemas (draft 7) from Go types, so that the schemas of
+// configuration files always match the structs they are parsed into.
+//
+// Struct fields are named by their json tags. A `description:"..."` tag adds a description to the
+// field, an `enum:"a,b"` tag restricts a string field to a list of values, and a
+// `jsontype:"string,integer"` tag overrides the JSON types that a field accepts. Union types (see
+// the union package) accept the fields of whichever member is selected by their union key. Types
+// whose JSON form does not follow their fields implement Schemer. Structs from outside of this
+// repository are not described and accept any value.
+package jsonschema
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// Draft is the version of JSON Schema that generated schemas follow.
+	Draft = "http://json-schema.org/draft-07/schema#"
+
+	modulePath = "github.com/determined-ai/determined/"
+)
+
+// Schemer is implemented by types that describe their own JSON Schema.
+type Schemer interface {
+	JSONSchema() map[string]interface{}
+}
+
+// Generate returns a JSON Schema document describing the JSON form of v.
+func Generate(v interface{}) map[string]interface{} {
+	schema := Schema(reflect.TypeOf(v))
+	schema["$schema"] = Draft
+	return schema
+}
+
+// Schema returns the JSON Schema of values of type t, to be nested in another schema.
+func Schema(t reflect.Type) map[string]interface{} {
+	return generator{visiting: map[reflect.Type]bool{}}.schema(t)
+}
+
+// StructSchema returns the JSON Schema of the struct type t based on its fields, even if t
+// implements Schemer. It lets Schemer implementations extend the schema of their fields.
+func StructSchema(t reflect.Type) map[string]interface{} {
+	return generator{visiting: map[reflect.Type]bool{}}.structSchema(t)
+}
+
+type generator struct {
+	// visiting holds the types being described, to avoid describing recursive types forever.
+	visiting map[reflect.Type]bool
+}
+
+func (g generator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if schemer, ok := reflect.Zero(t).Interface().(Schemer); ok {
+		return schemer.JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schema(t.Elem()),
+		}
+	case reflect.Struct:
+		if !strings.HasPrefix(t.PkgPath(), modulePath) || g.visiting[t] {
+			return map[string]interface{}{}
+		}
+		g.visiting[t] = true
+		defer delete(g.visiting, t)
+		return g.structSchema(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct as an object with a property per field. The fields of union
+// members are allowed only when the member is selected.
+func (g generator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	unions := map[string]map[string]map[string]interface{}{}
+	g.addFields(t, properties, unions)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(unions) == 0 {
+		schema["additionalProperties"] = false
+		return schema
+	}
+
+	// The same field may be described differently by each union member, so the fields of members
+	// are only described once a member is selected.
+	all := map[string]bool{}
+	for name := range properties {
+		all[name] = true
+	}
+	var conditions []interface{}
+	for _, key := range sortedKeys(unions) {
+		members := unions[key]
+		names := sortedKeys(members)
+		properties[key] = map[string]interface{}{"type": "string", "enum": toInterfaces(names)}
+		all[key] = true
+		for _, name := range names {
+			allowed := map[string]bool{key: true}
+			for field := range properties {
+				allowed[field] = true
+			}
+			for field := range members[name] {
+				allowed[field] = true
+				all[field] = true
+			}
+			conditions = append(conditions, map[string]interface{}{
+				"if": map[string]interface{}{
+					"properties": map[string]interface{}{key: map[string]interface{}{"const": name}},
+					"required":   []interface{}{key},
+				},
+				"then": map[string]interface{}{
+					"properties":    members[name],
+					"propertyNames": map[string]interface{}{"enum": toInterfaces(sortedKeys(allowed))},
+				},
+			})
+		}
+	}
+	schema["propertyNames"] = map[string]interface{}{"enum": toInterfaces(sortedKeys(all))}
+	schema["allOf"] = conditions
+	return schema
+}
+
+// addFields adds the schemas of the fields of the struct type t to properties, by name. The
+// schemas of the fields of each union member are added to unions, by union key and member name,
+// instead.
+func (g generator) addFields(
+	t reflect.Type,
+	properties map[string]interface{},
+	unions map[string]map[string]map[string]interface{},
+) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if tag, ok := field.Tag.Lookup("union"); ok {
+			parsed := strings.Split(tag, ",")
+			if len(parsed) != 2 {
+				continue
+			}
+			if unions[parsed[0]] == nil {
+				unions[parsed[0]] = map[string]map[string]interface{}{}
+			}
+			members := map[string]interface{}{}
+			g.addFields(field.Type.Elem(), members, unions)
+			unions[parsed[0]][parsed[1]] = members
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case tag == "-":
+			continue
+		case field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct:
+			g.addFields(fieldType, properties, unions)
+			continue
+		case field.PkgPath != "":
+			continue
+		case name == "":
+			name = field.Name
+		}
+
+		schema := g.schema(field.Type)
+		if description, ok := field.Tag.Lookup("description"); ok {
+			schema["description"] = description
+		}
+		if types, ok := field.Tag.Lookup("jsontype"); ok {
+			schema["type"] = toInterfaces(strings.Split(types, ","))
+		}
+		if enum, ok := field.Tag.Lookup("enum"); ok {
+			schema["enum"] = toInterfaces(strings.Split(enum, ","))
+		}
+		properties[name] = schema
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func toInterfaces(strs []string) []interface{} {
+	values := make([]interface{}, 0, len(strs))
+	for _, s := range strs {
+		values = append(values, s)
+	}
+	return values
+}
@@ -0,0 +1,136 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type duration string
+
+func (d duration) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "pattern": "^[0-9]+s$"}
+}
+
+type node struct {
+	Name     string  `json:"name" description:"the name of the node"`
+	Children []*node `json:"children"`
+}
+
+type shared struct {
+	Shared bool `json:"shared"`
+}
+
+type first struct {
+	Value int `json:"value"`
+}
+
+type second struct {
+	Value string `json:"value" enum:"a,b"`
+}
+
+type config struct {
+	shared
+	Port    int                `json:"port,omitempty" jsontype:"string,integer"`
+	Timeout duration           `json:"timeout"`
+	Ratio   *float64           `json:"ratio"`
+	Labels  map[string]bool    `json:"labels"`
+	Tree    node               `json:"tree"`
+	Since   time.Time          `json:"since"`
+	Ignored string             `json:"-"`
+	hidden  string             //nolint:unused,structcheck
+	Any     interface{}        `json:"any"`
+	Nested  map[string][]first `json:"nested"`
+
+	First  *first  `union:"type,first" json:"-"`
+	Second *second `union:"type,second" json:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	schema := Generate(config{})
+	assert.Equal(t, schema["$schema"], Draft)
+	assert.Equal(t, schema["type"], "object")
+	_, hasAdditional := schema["additionalProperties"]
+	assert.Assert(t, !hasAdditional, "unions list their allowed property names instead")
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.DeepEqual(t, properties["shared"], map[string]interface{}{"type": "boolean"})
+	assert.DeepEqual(t, properties["port"],
+		map[string]interface{}{"type": []interface{}{"string", "integer"}})
+	assert.DeepEqual(t, properties["timeout"],
+		map[string]interface{}{"type": "string", "pattern": "^[0-9]+s$"})
+	assert.DeepEqual(t, properties["ratio"], map[string]interface{}{"type": "number"})
+	assert.DeepEqual(t, properties["labels"], map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "boolean"},
+	})
+	assert.DeepEqual(t, properties["since"], map[string]interface{}{})
+	assert.DeepEqual(t, properties["any"], map[string]interface{}{})
+	assert.DeepEqual(t, properties["type"],
+		map[string]interface{}{"type": "string", "enum": []interface{}{"first", "second"}})
+	for _, name := range []string{"Ignored", "hidden", "value"} {
+		_, ok := properties[name]
+		assert.Assert(t, !ok, name)
+	}
+
+	// Recursive types are described up to the point where they recur.
+	tree := properties["tree"].(map[string]interface{})
+	assert.DeepEqual(t, tree["additionalProperties"], false)
+	treeProperties := tree["properties"].(map[string]interface{})
+	assert.DeepEqual(t, treeProperties["name"],
+		map[string]interface{}{"type": "string", "description": "the name of the node"})
+	assert.DeepEqual(t, treeProperties["children"],
+		map[string]interface{}{"type": "array", "items": map[string]interface{}{}})
+
+	assert.DeepEqual(t, schema["propertyNames"], map[string]interface{}{"enum": []interface{}{
+		"any", "labels", "nested", "port", "ratio", "shared", "since", "timeout", "tree", "type",
+		"value",
+	}})
+	allOf := schema["allOf"].([]interface{})
+	assert.Equal(t, len(allOf), 2)
+	assert.DeepEqual(t, allOf[1], map[string]interface{}{
+		"if": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"type": map[string]interface{}{"const": "second"},
+			},
+			"required": []interface{}{"type"},
+		},
+		"then": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"value": map[string]interface{}{"type": "string", "enum": []interface{}{"a", "b"}},
+			},
+			"propertyNames": map[string]interface{}{"enum": []interface{}{
+				"any", "labels", "nested", "port", "ratio", "shared", "since", "timeout", "tree",
+				"type", "value",
+			}},
+		},
+	})
+}
+
+// image is either a string or an object with a field per device type.
+type image struct {
+	CPU string `json:"cpu"`
+}
+
+func (i image) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{map[string]interface{}{"type": "string"}, StructSchema(reflect.TypeOf(i))},
+	}
+}
+
+func TestStructSchema(t *testing.T) {
+	assert.DeepEqual(t, Schema(reflect.TypeOf(&image{})), map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"cpu": map[string]interface{}{"type": "string"},
+				},
+				"additionalProperties": false,
+			},
+		},
+	})
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,12 +14,23 @@ import (
 // New returns an echo logger connected to logrus.
 func New() echo.Logger {
 	return &logger{
-		log: logrus.StandardLogger(),
+		log:   logrus.StandardLogger(),
+		entry: logrus.NewEntry(logrus.StandardLogger()),
+	}
+}
+
+// NewWithContext returns an echo logger connected to logrus that logs with ctx, e.g., so that
+// hooks can add the ID of the request that ctx carries.
+func NewWithContext(ctx context.Context) echo.Logger {
+	return &logger{
+		log:   logrus.StandardLogger(),
+		entry: logrus.StandardLogger().WithContext(ctx),
 	}
 }
 
 type logger struct {
-	log *logrus.Logger
+	log   *logrus.Logger
+	entry *logrus.Entry
 }
 
 func mustMarshal(j log.JSON) string {
@@ -55,24 +67,24 @@ func (l *logger) Output() io.Writer     { return l.log.Out }
 func (l *logger) SetPrefix(p string) { /* Logrus uses formatters rather than prefixes. */ }
 func (l *logger) Prefix() string     { return "" }
 
-func (l *logger) Print(i ...interface{})                    { l.log.Print(i...) }
-func (l *logger) Printf(format string, args ...interface{}) { l.log.Printf(format, args...) }
-func (l *logger) Printj(j log.JSON)                         { l.log.Println(mustMarshal(j)) }
-func (l *logger) Debug(i ...interface{})                    { l.log.Debug(i...) }
-func (l *logger) Debugf(format string, args ...interface{}) { l.log.Debugf(format, args...) }
-func (l *logger) Debugj(j log.JSON)                         { l.log.Debugln(mustMarshal(j)) }
-func (l *logger) Info(i ...interface{})                     { l.log.Info(i...) }
-func (l *logger) Infof(format string, args ...interface{})  { l.log.Infof(format, args...) }
-func (l *logger) Infoj(j log.JSON)                          { l.log.Infoln(mustMarshal(j)) }
-func (l *logger) Warn(i ...interface{})                     { l.log.Warn(i...) }
-func (l *logger) Warnf(format string, args ...interface{})  { l.log.Warnf(format, args...) }
-func (l *logger) Warnj(j log.JSON)                          { l.log.Warnln(mustMarshal(j)) }
-func (l *logger) Error(i ...interface{})                    { l.log.Error(i...) }
-func (l *logger) Errorf(format string, args ...interface{}) { l.log.Errorf(format, args...) }
-func (l *logger) Errorj(j log.JSON)                         { l.log.Errorln(mustMarshal(j)) }
-func (l *logger) Fatal(i ...interface{})                    { l.log.Fatal(i...) }
-func (l *logger) Fatalf(format string, args ...interface{}) { l.log.Fatalf(format, args...) }
-func (l *logger) Fatalj(j log.JSON)                         { l.log.Fatalln(mustMarshal(j)) }
-func (l *logger) Panic(i ...interface{})                    { l.log.Panic(i...) }
-func (l *logger) Panicf(format string, args ...interface{}) { l.log.Panicf(format, args...) }
-func (l *logger) Panicj(j log.JSON)                         { l.log.Panicln(mustMarshal(j)) }
+func (l *logger) Print(i ...interface{})                    { l.entry.Print(i...) }
+func (l *logger) Printf(format string, args ...interface{}) { l.entry.Printf(format, args...) }
+func (l *logger) Printj(j log.JSON)                         { l.entry.Println(mustMarshal(j)) }
+func (l *logger) Debug(i ...interface{})                    { l.entry.Debug(i...) }
+func (l *logger) Debugf(format string, args ...interface{}) { l.entry.Debugf(format, args...) }
+func (l *logger) Debugj(j log.JSON)                         { l.entry.Debugln(mustMarshal(j)) }
+func (l *logger) Info(i ...interface{})                     { l.entry.Info(i...) }
+func (l *logger) Infof(format string, args ...interface{})  { l.entry.Infof(format, args...) }
+func (l *logger) Infoj(j log.JSON)                          { l.entry.Infoln(mustMarshal(j)) }
+func (l *logger) Warn(i ...interface{})                     { l.entry.Warn(i...) }
+func (l *logger) Warnf(format string, args ...interface{})  { l.entry.Warnf(format, args...) }
+func (l *logger) Warnj(j log.JSON)                          { l.entry.Warnln(mustMarshal(j)) }
+func (l *logger) Error(i ...interface{})                    { l.entry.Error(i...) }
+func (l *logger) Errorf(format string, args ...interface{}) { l.entry.Errorf(format, args...) }
+func (l *logger) Errorj(j log.JSON)                         { l.entry.Errorln(mustMarshal(j)) }
+func (l *logger) Fatal(i ...interface{})                    { l.entry.Fatal(i...) }
+func (l *logger) Fatalf(format string, args ...interface{}) { l.entry.Fatalf(format, args...) }
+func (l *logger) Fatalj(j log.JSON)                         { l.entry.Fatalln(mustMarshal(j)) }
+func (l *logger) Panic(i ...interface{})                    { l.entry.Panic(i...) }
+func (l *logger) Panicf(format string, args ...interface{}) { l.entry.Panicf(format, args...) }
+func (l *logger) Panicj(j log.JSON)                         { l.entry.Panicln(mustMarshal(j)) }
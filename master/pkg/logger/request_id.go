@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDField is the field of log entries that holds the ID of the request being handled.
+const RequestIDField = "request_id"
+
+// clientRequestIDPattern matches the request IDs that clients may choose themselves, which keeps
+// them from injecting arbitrary text into logs and headers.
+var clientRequestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// ValidRequestID returns whether a client may use id as the ID of its request.
+func ValidRequestID(id string) bool {
+	return clientRequestIDPattern.MatchString(id)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx that carries the ID of the request being handled.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request that ctx was created for, if any.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// RequestIDHook is a logrus hook that adds the ID of the request being handled to entries logged
+// with a context that carries one, so that the logs of a request can be found by its ID.
+type RequestIDHook struct{}
+
+// Levels implements the logrus.Hook interface.
+func (RequestIDHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements the logrus.Hook interface.
+func (RequestIDHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id, ok := RequestID(entry.Context); ok {
+		entry.Data[RequestIDField] = id
+	}
+	return nil
+}
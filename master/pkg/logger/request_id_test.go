@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestRequestIDHook(t *testing.T) {
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.Out = &buf
+	log.Formatter = &logrus.TextFormatter{DisableTimestamp: true}
+	log.AddHook(RequestIDHook{})
+
+	log.WithContext(WithRequestID(context.Background(), "abc")).Info("handling")
+	log.WithContext(context.Background()).Info("no request")
+	log.Info("no context")
+
+	assert.Equal(t, buf.String(), `level=info msg=handling request_id=abc
+level=info msg="no request"
+level=info msg="no context"
+`)
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.Assert(t, ValidRequestID("abc-123_4.5"))
+	assert.Assert(t, ValidRequestID(strings.Repeat("a", 128)))
+	assert.Assert(t, !ValidRequestID(""))
+	assert.Assert(t, !ValidRequestID(strings.Repeat("a", 129)))
+	assert.Assert(t, !ValidRequestID("abc\nlevel=error msg=forged"))
+	assert.Assert(t, !ValidRequestID("abc def"))
+}
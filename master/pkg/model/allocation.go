@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+// AllocationSession is a period of time during which slots were allocated to a task. A trial that
+// is preempted or restarted has a session for each time that it ran.
+type AllocationSession struct {
+	ID           int     `db:"id" json:"id"`
+	TaskID       string  `db:"task_id" json:"task_id"`
+	ResourcePool string  `db:"resource_pool" json:"resource_pool"`
+	Slots        int     `db:"slots" json:"slots"`
+	UserID       *UserID `db:"user_id" json:"user_id"`
+	ExperimentID *int    `db:"experiment_id" json:"experiment_id"`
+	CommandID    *string `db:"command_id" json:"command_id"`
+	// RequestTime is when the task asked for the slots; it is unknown for old allocations.
+	RequestTime *time.Time `db:"request_time" json:"request_time"`
+	StartTime   time.Time  `db:"start_time" json:"start_time"`
+	EndTime     *time.Time `db:"end_time" json:"end_time"`
+	// FailureType and FailureMessage describe the first failure of a container of the session.
+	FailureType    *string `db:"failure_type" json:"failure_type"`
+	FailureMessage *string `db:"failure_message" json:"failure_message"`
+	// FailureReason is why the session failed or was cut short, if it was.
+	FailureReason *FailureReason `db:"failure_reason" json:"failure_reason"`
+}
+
+// FailureReason is why an allocation of a task failed or was cut short. Unlike the category of a
+// trial failure, which is guessed from the logs of the trial, it is set by the agents and tasks
+// that saw the allocation end.
+type FailureReason string
+
+// These are the reasons that allocations fail.
+const (
+	// ExitNonzeroReason is a container that exited with a nonzero exit code.
+	ExitNonzeroReason FailureReason = "EXIT_NONZERO"
+	// OOMKilledReason is a container that was killed because it ran out of memory.
+	OOMKilledReason FailureReason = "OOM_KILLED"
+	// ImagePullReason is a container whose image could not be pulled.
+	ImagePullReason FailureReason = "IMAGE_PULL"
+	// AgentLostReason is an allocation whose agent failed or stopped sending heartbeats.
+	AgentLostReason FailureReason = "AGENT_LOST"
+	// PreemptedReason is an allocation whose resources were taken back by the scheduler.
+	PreemptedReason FailureReason = "PREEMPTED"
+	// InvalidConfigReason is a task that rejected its configuration, e.g., its hyperparameters.
+	InvalidConfigReason FailureReason = "INVALID_CONFIG"
+	// HarnessIncompatibleReason is a task whose container image holds a harness that cannot run
+	// it, e.g., because it is missing or too old.
+	HarnessIncompatibleReason FailureReason = "HARNESS_INCOMPATIBLE"
+	// UnknownReason is a failure that could not be classified.
+	UnknownReason FailureReason = "UNKNOWN"
+)
+
+// TaskContainerLog is a log of the lifecycle of a container of a task, e.g., the progress of an
+// image pull or an error from Docker, rather than of the output of the container.
+type TaskContainerLog struct {
+	ID          int       `db:"id" json:"id"`
+	TaskID      string    `db:"task_id" json:"task_id"`
+	ContainerID string    `db:"container_id" json:"container_id"`
+	Timestamp   time.Time `db:"timestamp" json:"timestamp"`
+	// Source is "agent" for events of the agent and "pull" for image pulls.
+	Source  string `db:"source" json:"source"`
+	Level   string `db:"level" json:"level"`
+	Message string `db:"message" json:"message"`
+}
+
+// SlotUtilization is the average number of slots that were allocated during a period of time.
+type SlotUtilization struct {
+	StartTime time.Time `db:"start_time" json:"start_time"`
+	EndTime   time.Time `db:"end_time" json:"end_time"`
+	Slots     float64   `db:"slots" json:"slots"`
+}
+
+// SlotUsage is the number of slot-hours that were allocated to a group of tasks, such as the tasks
+// of a user, during a period of time.
+type SlotUsage struct {
+	// Group is the user, resource pool or experiment that the tasks belong to, or nil for tasks
+	// that belong to none.
+	Group     *string `db:"group_name" json:"group"`
+	SlotHours float64 `db:"slot_hours" json:"slot_hours"`
+}
@@ -83,6 +83,10 @@ func DefaultExperimentConfig(taskContainerDefaults *TaskContainerDefaultsConfig)
 			PBTConfig: &PBTConfig{
 				SmallerIsBetter: true,
 			},
+			CustomConfig: &CustomConfig{
+				LengthUnit:        "batches",
+				ControllerTimeout: 300,
+			},
 		},
 		Resources: ResourcesConfig{
 			SlotsPerTrial:  1,
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// DependencyConditionType is the kind of condition that the experiments an experiment depends on
+// must meet for it to start.
+type DependencyConditionType string
+
+const (
+	// DependenciesCompleted requires the dependencies to complete.
+	DependenciesCompleted DependencyConditionType = "completed"
+	// DependenciesMetricThreshold requires the dependencies to complete with a best validation
+	// metric at least as good as a threshold.
+	DependenciesMetricThreshold DependencyConditionType = "metric_threshold"
+)
+
+// DependencyCondition is the condition that the experiments an experiment depends on must meet
+// for it to start.
+type DependencyCondition struct {
+	Type DependencyConditionType `json:"type"`
+	// Metric is the validation metric that DependenciesMetricThreshold compares to the threshold.
+	// It defaults to the searcher metric of each dependency.
+	Metric string `json:"metric,omitempty"`
+	// Threshold is the value that the best Metric of each dependency must reach.
+	Threshold *float64 `json:"threshold,omitempty"`
+	// SmallerIsBetter defaults to the searcher setting of each dependency.
+	SmallerIsBetter *bool `json:"smaller_is_better,omitempty"`
+}
+
+// Validate returns an error describing the first problem with the condition, if any.
+func (c DependencyCondition) Validate() error {
+	switch c.Type {
+	case DependenciesCompleted:
+		if c.Metric != "" || c.Threshold != nil || c.SmallerIsBetter != nil {
+			return errors.New(
+				"metric, threshold and smaller_is_better can only be used with metric_threshold")
+		}
+	case DependenciesMetricThreshold:
+		if c.Threshold == nil {
+			return errors.New("metric_threshold requires a threshold")
+		}
+	default:
+		return errors.Errorf(
+			"dependency condition type must be %s or %s, not %q",
+			DependenciesCompleted, DependenciesMetricThreshold, c.Type)
+	}
+	return nil
+}
+
+// Value marshals the condition to JSON.
+func (c DependencyCondition) Value() (driver.Value, error) {
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling dependency condition")
+	}
+	return bytes, nil
+}
+
+// Scan unmarshals the condition from JSON.
+func (c *DependencyCondition) Scan(src interface{}) error {
+	bytes, ok := src.([]byte)
+	if !ok {
+		return errors.Errorf("unable to convert to []byte: %v", src)
+	}
+	return errors.Wrapf(json.Unmarshal(bytes, c), "unable to unmarshal dependency condition: %v", src)
+}
+
+// ExperimentDependencies corresponds to a row in the "experiment_dependencies" DB table. It holds
+// an experiment in the QUEUED_DEPENDENCY state until the experiments it depends on meet the
+// condition.
+type ExperimentDependencies struct {
+	ExperimentID int                 `db:"experiment_id" json:"-"`
+	DependsOn    []int               `db:"-" json:"depends_on"`
+	Condition    DependencyCondition `db:"condition" json:"condition"`
+	// WarmStart starts the experiment from the best checkpoint of its only dependency.
+	WarmStart bool `db:"warm_start" json:"warm_start"`
+}
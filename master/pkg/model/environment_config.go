@@ -3,12 +3,13 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 
 	k8sV1 "k8s.io/api/core/v1"
 
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/jsonschema"
 
-	"github.com/docker/docker/api/types"
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/pkg/device"
@@ -23,10 +24,10 @@ type Environment struct {
 	Image                RuntimeItem  `json:"image"`
 	EnvironmentVariables RuntimeItems `json:"environment_variables,omitempty"`
 
-	Ports          map[string]int    `json:"ports"`
-	RegistryAuth   *types.AuthConfig `json:"registry_auth,omitempty"`
-	ForcePullImage bool              `json:"force_pull_image"`
-	PodSpec        *k8sV1.Pod        `json:"pod_spec"`
+	Ports          map[string]int `json:"ports"`
+	RegistryAuth   *RegistryAuth  `json:"registry_auth,omitempty"`
+	ForcePullImage bool           `json:"force_pull_image"`
+	PodSpec        *k8sV1.Pod     `json:"pod_spec"`
 }
 
 // RuntimeItem configures the runtime image.
@@ -53,6 +54,16 @@ func (r *RuntimeItem) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// JSONSchema implements the jsonschema.Schemer interface.
+func (r RuntimeItem) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			jsonschema.StructSchema(reflect.TypeOf(r)),
+		},
+	}
+}
+
 // For returns the value for the provided device type.
 func (r *RuntimeItem) For(deviceType device.Type) string {
 	switch deviceType {
@@ -89,6 +100,16 @@ func (r *RuntimeItems) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// JSONSchema implements the jsonschema.Schemer interface.
+func (r RuntimeItems) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			jsonschema.StructSchema(reflect.TypeOf(r)),
+		},
+	}
+}
+
 // For returns the value for the provided device type.
 func (r *RuntimeItems) For(deviceType device.Type) []string {
 	switch deviceType {
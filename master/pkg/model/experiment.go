@@ -28,6 +28,8 @@ const (
 	CompletedState State = "COMPLETED"
 	// DeletedState constant.
 	DeletedState State = "DELETED"
+	// DeletingState constant.
+	DeletingState State = "DELETING"
 	// ErrorState constant.
 	ErrorState State = "ERROR"
 	// PausedState constant.
@@ -38,6 +40,10 @@ const (
 	StoppingCompletedState State = "STOPPING_COMPLETED"
 	// StoppingErrorState constant.
 	StoppingErrorState State = "STOPPING_ERROR"
+	// QueuedDependencyState constant.
+	QueuedDependencyState State = "QUEUED_DEPENDENCY"
+	// CanceledDependencyState constant.
+	CanceledDependencyState State = "CANCELED_DEPENDENCY"
 
 	// TrialWorkloadSequencerType constant.
 	TrialWorkloadSequencerType WorkloadSequencerType = "TRIAL_WORKLOAD_SEQUENCER"
@@ -78,9 +84,10 @@ var StoppingStates = map[State]bool{
 
 // TerminalStates are the valid terminal states.
 var TerminalStates = map[State]bool{
-	CanceledState:  true,
-	CompletedState: true,
-	ErrorState:     true,
+	CanceledState:           true,
+	CompletedState:          true,
+	ErrorState:              true,
+	CanceledDependencyState: true,
 }
 
 // ManualStates are the states the user can set an experiment to.
@@ -105,15 +112,34 @@ var ExperimentTransitions = map[State]map[State]bool{
 		StoppingCompletedState: true,
 		StoppingErrorState:     true,
 	},
-	CanceledState:  {},
-	CompletedState: {},
-	ErrorState:     {},
+	CanceledState: {
+		DeletingState: true,
+	},
+	CanceledDependencyState: {
+		DeletingState: true,
+	},
+	CompletedState: {
+		DeletingState: true,
+	},
+	// Experiments stay in the deleting state until their checkpoints are deleted from storage and
+	// the experiment is removed from the database.
+	DeletingState: {},
+	ErrorState: {
+		DeletingState: true,
+	},
 	PausedState: {
 		ActiveState:            true,
 		StoppingCanceledState:  true,
 		StoppingCompletedState: true,
 		StoppingErrorState:     true,
 	},
+	// Experiments that depend on other experiments wait in the queued state until those meet the
+	// condition, and then start active, or until one of them fails or the experiment is canceled.
+	QueuedDependencyState: {
+		ActiveState:             true,
+		CanceledState:           true,
+		CanceledDependencyState: true,
+	},
 	StoppingCanceledState: {
 		CanceledState:      true,
 		StoppingErrorState: true,
@@ -183,16 +209,41 @@ type Experiment struct {
 	State  State            `db:"state"`
 	Config ExperimentConfig `db:"config"`
 	// The model definition is stored as a .tar.gz file (raw bytes).
-	ModelDefinitionBytes []byte     `db:"model_definition"`
-	StartTime            time.Time  `db:"start_time"`
-	EndTime              *time.Time `db:"end_time"`
-	ParentID             *int       `db:"parent_id"`
-	Archived             bool       `db:"archived"`
-	GitRemote            *string    `db:"git_remote"`
-	GitCommit            *string    `db:"git_commit"`
-	GitCommitter         *string    `db:"git_committer"`
-	GitCommitDate        *time.Time `db:"git_commit_date"`
-	OwnerID              *UserID    `db:"owner_id"`
+	ModelDefinitionBytes []byte `db:"model_definition"`
+	// ModelDefinitionHash is the SHA-256 hash of the model definition that identifies it in the
+	// model definition storage of the master. Model definitions that are kept in the experiments
+	// table have none.
+	ModelDefinitionHash *string    `db:"model_definition_hash"`
+	StartTime           time.Time  `db:"start_time"`
+	EndTime             *time.Time `db:"end_time"`
+	ParentID            *int       `db:"parent_id"`
+	Archived            bool       `db:"archived"`
+	GitRemote           *string    `db:"git_remote"`
+	GitCommit           *string    `db:"git_commit"`
+	GitCommitter        *string    `db:"git_committer"`
+	GitCommitDate       *time.Time `db:"git_commit_date"`
+	OwnerID             *UserID    `db:"owner_id"`
+	// ExternalID identifies the experiment in another system that submitted it.
+	ExternalID *string `db:"external_id"`
+	// ExternalIDUnique is whether no other experiment whose external ID must be unique may have
+	// the same external ID.
+	ExternalIDUnique bool `db:"external_id_unique"`
+	// ImportedFromClusterID and ImportedFromExperimentID identify the cluster and experiment that
+	// an imported experiment was exported from. Imported experiments are read-only.
+	ImportedFromClusterID    *string `db:"imported_from_cluster_id"`
+	ImportedFromExperimentID *int    `db:"imported_from_experiment_id"`
+	// ScheduleID identifies the schedule that created the experiment, if any.
+	ScheduleID *int `db:"schedule_id"`
+	// TeamID identifies the team whose members see the experiment; experiments without a team are
+	// visible to everyone.
+	TeamID *int `db:"team_id"`
+	// DeletedAt is when the experiment was soft-deleted, if it was.
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// Imported returns whether the experiment was imported from another cluster.
+func (e *Experiment) Imported() bool {
+	return e.ImportedFromClusterID != nil
 }
 
 // ExperimentDescriptor is a minimal description of an experiment.
@@ -201,6 +252,8 @@ type ExperimentDescriptor struct {
 	Archived bool             `json:"archived"`
 	Config   ExperimentConfig `json:"config"`
 	Labels   []string         `json:"labels"`
+	// ExternalID is only set for experiments submitted with an external ID.
+	ExternalID *string `json:"external_id,omitempty"`
 }
 
 // NewExperiment creates a new experiment struct in the paused state.  Note
@@ -253,6 +306,17 @@ func (e *Experiment) Transition(state State) (bool, error) {
 	return true, nil
 }
 
+// ExperimentStateTransition represents a row from the `experiment_state_transitions` table. From is
+// nil for the state that the experiment was created in.
+type ExperimentStateTransition struct {
+	ID           int       `db:"id" json:"-"`
+	ExperimentID int       `db:"experiment_id" json:"-"`
+	From         *State    `db:"from_state" json:"from"`
+	To           State     `db:"to_state" json:"to"`
+	Timestamp    time.Time `db:"timestamp" json:"timestamp"`
+	Reason       string    `db:"reason" json:"reason"`
+}
+
 // Trial represents a row from the `trials` table.
 type Trial struct {
 	ID                    int        `db:"id"`
@@ -263,8 +327,46 @@ type Trial struct {
 	HParams               JSONObj    `db:"hparams"`
 	WarmStartCheckpointID *int       `db:"warm_start_checkpoint_id"`
 	Seed                  int64      `db:"seed"`
+	// RestartsDueToNodeFailure counts the runs of the trial that were lost to node failures, e.g.,
+	// because its agents failed or its containers or agents stopped sending heartbeats.
+	RestartsDueToNodeFailure int `db:"restarts_due_to_node_failure"`
+}
+
+// FailureCategory is the likely cause of a failed run of a trial.
+type FailureCategory string
+
+// These are the categories of trial failures.
+const (
+	// OOMFailure is a run that ran out of host or GPU memory.
+	OOMFailure FailureCategory = "OOM"
+	// CUDAErrorFailure is a run that hit a CUDA error other than running out of memory.
+	CUDAErrorFailure FailureCategory = "CUDA_ERROR"
+	// UserExceptionFailure is a run that raised an exception in user code.
+	UserExceptionFailure FailureCategory = "USER_EXCEPTION"
+	// NodeFailure is a run that was lost because of its machine rather than the trial itself.
+	NodeFailure FailureCategory = "NODE_FAILURE"
+	// ImagePullFailure is a run whose container image could not be pulled.
+	ImagePullFailure FailureCategory = "IMAGE_PULL"
+	// ImagePullAuthFailure is a run whose container image registry rejected its credentials.
+	ImagePullAuthFailure FailureCategory = "IMAGE_PULL_AUTH"
+	// UnknownFailure is a run that failed for a reason that could not be classified.
+	UnknownFailure FailureCategory = "UNKNOWN"
+)
+
+// TrialFailure represents a row from the `trial_failures` table, one for each failed run of a
+// trial.
+type TrialFailure struct {
+	ID       int             `db:"id" json:"id"`
+	TrialID  int             `db:"trial_id" json:"trial_id"`
+	Category FailureCategory `db:"category" json:"category"`
+	ExitCode *int            `db:"exit_code" json:"exit_code"`
+	Message  string          `db:"message" json:"message"`
+	Time     time.Time       `db:"time" json:"time"`
 }
 
+// EarlyStoppedEndReason is the end reason of trials stopped by an early stopping policy.
+const EarlyStoppedEndReason = "EARLY_STOPPED"
+
 // NewTrial creates a new trial in the active state.  Note that the trial ID
 // will not be set.
 func NewTrial(
@@ -400,6 +502,9 @@ type TrialLog struct {
 	Log         *string    `db:"log" json:"log"`
 	Source      *string    `db:"source" json:"source"`
 	StdType     *string    `db:"stdtype" json:"stdtype"`
+
+	// InsertedAt is set by the database when the log is inserted.
+	InsertedAt time.Time `db:"inserted_at" json:"-"`
 }
 
 // TrialLogBatch represents a batch of model.TrialLog.
@@ -3,6 +3,7 @@ package model
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"net/mail"
 	"path/filepath"
 	"strings"
 
@@ -31,6 +32,7 @@ type ExperimentConfig struct {
 	CheckpointPolicy         string                    `json:"checkpoint_policy"`
 	Hyperparameters          Hyperparameters           `json:"hyperparameters"`
 	Searcher                 SearcherConfig            `json:"searcher"`
+	EarlyStopping            *EarlyStoppingConfig      `json:"early_stopping,omitempty"`
 	Resources                ResourcesConfig           `json:"resources"`
 	Optimizations            OptimizationsConfig       `json:"optimizations"`
 	RecordsPerEpoch          int                       `json:"records_per_epoch"`
@@ -44,6 +46,7 @@ type ExperimentConfig struct {
 	Internal                 *InternalConfig           `json:"internal"`
 	Entrypoint               string                    `json:"entrypoint"`
 	DataLayer                DataLayerConfig           `json:"data_layer"`
+	Notifications            *NotificationsConfig      `json:"notifications,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
@@ -145,6 +148,69 @@ func (e ExperimentConfig) Unit() Unit {
 	return e.Searcher.Unit()
 }
 
+// EarlyStoppingMetric returns the name of the validation metric that the early stopping policies
+// are evaluated against and whether smaller values of it are better. Unless overridden, these are
+// the searcher's metric and ordering.
+func (e ExperimentConfig) EarlyStoppingMetric() (string, bool) {
+	metric, smallerIsBetter := e.Searcher.Metric, e.Searcher.SmallerIsBetter
+	if e.EarlyStopping == nil {
+		return metric, smallerIsBetter
+	}
+	if e.EarlyStopping.Metric != nil {
+		metric = *e.EarlyStopping.Metric
+	}
+	if e.EarlyStopping.SmallerIsBetter != nil {
+		smallerIsBetter = *e.EarlyStopping.SmallerIsBetter
+	}
+	return metric, smallerIsBetter
+}
+
+// NotificationsConfig configures who is notified when the experiment ends.
+type NotificationsConfig struct {
+	// Email lists the addresses that a summary of the experiment is emailed to. If it is not set,
+	// the summary is emailed to the owner of the experiment; an empty list disables the email.
+	Email []string `json:"email"`
+}
+
+// Validate implements the check.Validatable interface.
+func (n NotificationsConfig) Validate() []error {
+	var errs []error
+	for _, address := range n.Email {
+		if _, err := mail.ParseAddress(address); err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid notifications email %q", address))
+		}
+	}
+	return errs
+}
+
+// EarlyStoppingConfig configures policies that the master evaluates as validation metrics arrive,
+// independently of the searcher, to stop trials or the whole experiment early.
+type EarlyStoppingConfig struct {
+	Metric          *string `json:"metric,omitempty"`
+	SmallerIsBetter *bool   `json:"smaller_is_better,omitempty"`
+	// Patience is the number of consecutive validations without improvement after which a trial
+	// is stopped.
+	Patience *int `json:"patience,omitempty"`
+	// TargetMetric is the metric value at which the experiment is stopped once any trial
+	// reaches it.
+	TargetMetric *float64 `json:"target_metric,omitempty"`
+}
+
+// Validate implements the check.Validatable interface.
+func (e EarlyStoppingConfig) Validate() []error {
+	errs := []error{
+		check.True(e.Patience != nil || e.TargetMetric != nil,
+			"early_stopping must specify patience, target_metric, or both"),
+	}
+	if e.Metric != nil {
+		errs = append(errs, check.NotEmpty(*e.Metric, "early_stopping metric must not be empty"))
+	}
+	if e.Patience != nil {
+		errs = append(errs, check.GreaterThan(*e.Patience, 0, "early_stopping patience must be > 0"))
+	}
+	return errs
+}
+
 // InUnits is describes a type that is in terms of a specific unit.
 type InUnits interface {
 	Unit() Unit
@@ -182,6 +248,19 @@ func (l *Labels) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+// JSONSchema implements the jsonschema.Schemer interface.
+func (l Labels) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "boolean"},
+			},
+		},
+	}
+}
+
 // ResourcesConfig configures experiment resource usage.
 type ResourcesConfig struct {
 	// Slots is used by commands while trials use SlotsPerTrial.
@@ -231,7 +310,7 @@ type OptimizationsConfig struct {
 	AverageTrainingMetrics     bool   `json:"average_training_metrics"`
 	GradientCompression        bool   `json:"gradient_compression"`
 	GradUpdateSizeFile         string `json:"grad_updates_size_file,omitempty"`
-	MixedPrecision             string `json:"mixed_precision"`
+	MixedPrecision             string `json:"mixed_precision" enum:"O0,O1,O2,O3"`
 	TensorFusionThreshold      int    `json:"tensor_fusion_threshold"`
 	TensorFusionCycleTime      int    `json:"tensor_fusion_cycle_time"`
 	AutoTuneTensorFusion       bool   `json:"auto_tune_tensor_fusion"`
@@ -5,8 +5,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/docker/docker/api/types"
-
 	"github.com/google/go-cmp/cmp"
 	"gotest.tools/assert"
 
@@ -221,6 +219,56 @@ func TestResourcesValidation(t *testing.T) {
 	}
 }
 
+// TestEarlyStoppingValidation tests that invalid early stopping policies produce validation errors
+// and that the policies default to the searcher's metric.
+func TestEarlyStoppingValidation(t *testing.T) {
+	{
+		config := validGridSearchConfig()
+		config.EarlyStopping = &EarlyStoppingConfig{Patience: intP(3)}
+		assert.NilError(t, check.Validate(config))
+		metric, smallerIsBetter := config.EarlyStoppingMetric()
+		assert.Equal(t, metric, config.Searcher.Metric)
+		assert.Equal(t, smallerIsBetter, config.Searcher.SmallerIsBetter)
+	}
+
+	{
+		config := validGridSearchConfig()
+		config.EarlyStopping = &EarlyStoppingConfig{}
+		assert.ErrorContains(t, check.Validate(config), "must specify patience, target_metric")
+	}
+
+	{
+		config := validGridSearchConfig()
+		config.EarlyStopping = &EarlyStoppingConfig{Patience: intP(0)}
+		assert.ErrorContains(t, check.Validate(config), "patience must be > 0")
+	}
+
+	{
+		config := validGridSearchConfig()
+		metric := ""
+		target := 0.9
+		config.EarlyStopping = &EarlyStoppingConfig{Metric: &metric, TargetMetric: &target}
+		assert.ErrorContains(t, check.Validate(config), "metric must not be empty")
+	}
+}
+
+// TestNotificationsValidation tests that notification email addresses must be valid.
+func TestNotificationsValidation(t *testing.T) {
+	{
+		config := validGridSearchConfig()
+		config.Notifications = &NotificationsConfig{
+			Email: []string{"alice@example.com", "Bob <bob@example.com>"},
+		}
+		assert.NilError(t, check.Validate(config))
+	}
+
+	{
+		config := validGridSearchConfig()
+		config.Notifications = &NotificationsConfig{Email: []string{"alice"}}
+		assert.ErrorContains(t, check.Validate(config), "invalid notifications email")
+	}
+}
+
 func TestExperiment(t *testing.T) {
 	json1 := []byte(`{
   "description": "test",
@@ -496,7 +544,7 @@ func TestOverrideMasterConfigPullPolicy(t *testing.T) {
 
 func TestMasterConfigRegistryAuth(t *testing.T) {
 	masterDefault := &TaskContainerDefaultsConfig{
-		RegistryAuth: &types.AuthConfig{
+		RegistryAuth: &RegistryAuth{
 			Username: "best-user",
 			Password: "secret-password",
 		},
@@ -505,7 +553,7 @@ func TestMasterConfigRegistryAuth(t *testing.T) {
 	actual.Description = description
 
 	expected := DefaultExperimentConfig(nil)
-	expected.Environment.RegistryAuth = &types.AuthConfig{
+	expected.Environment.RegistryAuth = &RegistryAuth{
 		Username: "best-user",
 		Password: "secret-password",
 	}
@@ -517,7 +565,7 @@ func TestMasterConfigRegistryAuth(t *testing.T) {
 
 func TestOverrideMasterConfigRegistryAuth(t *testing.T) {
 	masterDefault := &TaskContainerDefaultsConfig{
-		RegistryAuth: &types.AuthConfig{
+		RegistryAuth: &RegistryAuth{
 			Username: "best-user",
 		},
 	}
@@ -528,7 +576,7 @@ func TestOverrideMasterConfigRegistryAuth(t *testing.T) {
 }`), &actual))
 
 	expected := DefaultExperimentConfig(nil)
-	expected.Environment.RegistryAuth = &types.AuthConfig{
+	expected.Environment.RegistryAuth = &RegistryAuth{
 		Username: "worst-user",
 	}
 	expected.Description = description
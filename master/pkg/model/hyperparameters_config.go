@@ -2,11 +2,13 @@ package model
 
 import (
 	"encoding/json"
+	"reflect"
 	"sort"
 
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/jsonschema"
 	"github.com/determined-ai/determined/master/pkg/union"
 )
 
@@ -88,6 +90,17 @@ func (h *Hyperparameter) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// JSONSchema implements the jsonschema.Schemer interface. Values other than objects are shorthand
+// for constant hyperparameters.
+func (h Hyperparameter) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"not": map[string]interface{}{"type": "object"}},
+			jsonschema.StructSchema(reflect.TypeOf(h)),
+		},
+	}
+}
+
 // ConstHyperparameter is a constant.
 type ConstHyperparameter struct {
 	Val interface{} `json:"val"`
@@ -84,6 +84,21 @@ func (l *Length) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// JSONSchema implements the jsonschema.Schemer interface.
+func (l Length) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"records": map[string]interface{}{"type": "integer"},
+			"batches": map[string]interface{}{"type": "integer"},
+			"epochs":  map[string]interface{}{"type": "integer"},
+		},
+		"additionalProperties": false,
+		"minProperties":        1,
+		"maxProperties":        1,
+	}
+}
+
 // NewLength returns a new length with the specified unit and length.
 func NewLength(unit Unit, units int) Length {
 	return Length{Unit: unit, Units: units}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Maintenance is the maintenance mode of the cluster, during which new experiments and commands
+// are not started while the work that is already running carries on.
+type Maintenance struct {
+	Message   string    `db:"message" json:"message"`
+	StartTime time.Time `db:"start_time" json:"start_time"`
+	UserID    UserID    `db:"user_id" json:"user_id"`
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/redact"
+)
+
+// RegistryAuth configures the credentials to pull images from a Docker registry with: either the
+// fields of the Docker registry credentials, which have the same JSON names, or a token from
+// Amazon ECR. Its secrets are redacted whenever configs are displayed.
+type RegistryAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty" secret:"true"`
+	Auth          string `json:"auth,omitempty" secret:"true"`
+	Email         string `json:"email,omitempty"`
+	ServerAddress string `json:"serveraddress,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty" secret:"true"`
+	RegistryToken string `json:"registrytoken,omitempty" secret:"true"`
+
+	ECR *ECRAuth `json:"ecr,omitempty"`
+}
+
+// ECRAuth configures pulling images from the Amazon ECR registries of a region with an
+// authorization token of an IAM role, or of the credentials of the master if RoleARN is empty.
+type ECRAuth struct {
+	Region  string `json:"region"`
+	RoleARN string `json:"role_arn,omitempty"`
+}
+
+// Validate implements the check.Validatable interface.
+func (a RegistryAuth) Validate() []error {
+	if a.ECR == nil {
+		return nil
+	}
+	var errs []error
+	if a.Username != "" || a.Password != "" || a.Auth != "" || a.IdentityToken != "" ||
+		a.RegistryToken != "" {
+		errs = append(errs, errors.New("registry_auth must not set both ecr and credentials"))
+	}
+	if a.ECR.Region == "" {
+		errs = append(errs, errors.New("registry_auth.ecr.region must be set"))
+	}
+	return errs
+}
+
+// AuthConfig returns the Docker registry credentials of the static fields of the config.
+func (a RegistryAuth) AuthConfig() types.AuthConfig {
+	return types.AuthConfig{
+		Username:      a.Username,
+		Password:      a.Password,
+		Auth:          a.Auth,
+		Email:         a.Email,
+		ServerAddress: a.ServerAddress,
+		IdentityToken: a.IdentityToken,
+		RegistryToken: a.RegistryToken,
+	}
+}
+
+// registryAuthSecrets holds the JSON names of the secret fields of RegistryAuth.
+var registryAuthSecrets = func() map[string]bool {
+	secrets := make(map[string]bool)
+	t := reflect.TypeOf(RegistryAuth{})
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); redact.IsSecret(field) {
+			secrets[strings.Split(field.Tag.Get("json"), ",")[0]] = true
+		}
+	}
+	return secrets
+}()
+
+// RedactRegistryAuthJSON returns a copy of a JSON document, such as the raw config of an
+// experiment as stored in the database, in which the secrets of every registry_auth object are
+// replaced by redact.Mask. Documents without registry credentials are returned as they are.
+func RedactRegistryAuthJSON(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(`"registry_auth"`)) {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "error parsing JSON to redact")
+	}
+	redactRegistryAuth(doc)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, errors.Wrap(err, "error encoding redacted JSON")
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func redactRegistryAuth(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if auth, ok := value.(map[string]interface{}); ok && key == "registry_auth" {
+				for field, secret := range auth {
+					if s, ok := secret.(string); ok && s != "" && registryAuthSecrets[field] {
+						auth[field] = redact.Mask
+					}
+				}
+			} else {
+				redactRegistryAuth(value)
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			redactRegistryAuth(value)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/redact"
+)
+
+func TestRegistryAuthValidate(t *testing.T) {
+	assert.Equal(t, len(RegistryAuth{Username: "user", Password: "pass"}.Validate()), 0)
+	assert.Equal(t, len(RegistryAuth{ECR: &ECRAuth{Region: "us-west-2"}}.Validate()), 0)
+	assert.Equal(t, len(RegistryAuth{ECR: &ECRAuth{}}.Validate()), 1)
+	both := RegistryAuth{Password: "pass", ECR: &ECRAuth{Region: "us-west-2"}}
+	assert.Equal(t, len(both.Validate()), 1)
+}
+
+func TestRedactRegistryAuthJSON(t *testing.T) {
+	raw := []byte(`{"id":1,"config":{"environment":{"registry_auth":` +
+		`{"username":"user","password":"pass","auth":""}},"seed":12345678901234567890}}`)
+	redacted, err := RedactRegistryAuthJSON(raw)
+	assert.NilError(t, err)
+	assert.Equal(t, string(redacted), `{"config":{"environment":{"registry_auth":`+
+		`{"auth":"","password":"`+redact.Mask+`","username":"user"}},`+
+		`"seed":12345678901234567890},"id":1}`)
+
+	raw = []byte(`{"id": 1, "config": {}}`)
+	redacted, err = RedactRegistryAuthJSON(raw)
+	assert.NilError(t, err)
+	assert.Equal(t, string(redacted), string(raw))
+}
+
+func TestRedactRegistryAuth(t *testing.T) {
+	env := Environment{RegistryAuth: &RegistryAuth{Username: "user", Password: "pass"}}
+	redacted := redact.Redact(env).(Environment)
+	assert.Equal(t, redacted.RegistryAuth.Password, redact.Mask)
+	assert.Equal(t, redacted.RegistryAuth.Username, "user")
+	assert.Equal(t, env.RegistryAuth.Password, "pass")
+}
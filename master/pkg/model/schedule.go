@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// ExperimentSchedule corresponds to a row in the "experiment_schedules" DB table. It is an
+// experiment submission that the master creates experiments from at the scheduled times: either
+// once at StartAt or whenever Cron fires, up to MaxRuns times.
+type ExperimentSchedule struct {
+	ID      int              `db:"id" json:"id"`
+	OwnerID UserID           `db:"owner_id" json:"owner_id"`
+	Config  ExperimentConfig `db:"config" json:"config"`
+	// The model definition is stored as a .tar.gz file (raw bytes).
+	ModelDefinitionBytes []byte     `db:"model_definition" json:"-"`
+	GitRemote            *string    `db:"git_remote" json:"git_remote"`
+	GitCommit            *string    `db:"git_commit" json:"git_commit"`
+	GitCommitter         *string    `db:"git_committer" json:"git_committer"`
+	GitCommitDate        *time.Time `db:"git_commit_date" json:"git_commit_date"`
+
+	StartAt *time.Time `db:"start_at" json:"start_at"`
+	Cron    *string    `db:"cron" json:"cron"`
+	MaxRuns *int       `db:"max_runs" json:"max_runs"`
+	Enabled bool       `db:"enabled" json:"enabled"`
+	// Runs counts the experiments created from the schedule.
+	Runs        int        `db:"runs" json:"runs"`
+	LastRunTime *time.Time `db:"last_run_time" json:"last_run_time"`
+	// NextRunTime is nil once the schedule will not fire again.
+	NextRunTime *time.Time `db:"next_run_time" json:"next_run_time"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
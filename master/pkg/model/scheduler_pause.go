@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// SchedulerPause is a pause of the scheduler, during which tasks are not allocated resources while
+// the tasks that already have them carry on.
+type SchedulerPause struct {
+	StartTime time.Time `db:"start_time" json:"start_time"`
+	UserID    UserID    `db:"user_id" json:"user_id"`
+}
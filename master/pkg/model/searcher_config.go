@@ -15,8 +15,8 @@ const MaxAllowedTrials = 2000
 
 // SearcherConfig holds the searcher configurations.
 type SearcherConfig struct {
-	Metric               string  `json:"metric"`
-	SmallerIsBetter      bool    `json:"smaller_is_better"`
+	Metric               string  `json:"metric" description:"the metric to compare trials by"`
+	SmallerIsBetter      bool    `json:"smaller_is_better" description:"whether smaller is better"`
 	SourceTrialID        *int    `json:"source_trial_id"`
 	SourceCheckpointUUID *string `json:"source_checkpoint_uuid"`
 
@@ -29,6 +29,7 @@ type SearcherConfig struct {
 	AdaptiveSimpleConfig *AdaptiveSimpleConfig `union:"name,adaptive_simple" json:"-"`
 	AdaptiveASHAConfig   *AdaptiveASHAConfig   `union:"name,adaptive_asha" json:"-"`
 	PBTConfig            *PBTConfig            `union:"name,pbt" json:"-"`
+	CustomConfig         *CustomConfig         `union:"name,custom" json:"-"`
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -66,6 +67,8 @@ func (s SearcherConfig) Unit() Unit {
 		return s.AdaptiveASHAConfig.Unit()
 	case s.PBTConfig != nil:
 		return s.PBTConfig.Unit()
+	case s.CustomConfig != nil:
+		return s.CustomConfig.Unit()
 	default:
 		panic("no searcher type specified")
 	}
@@ -330,3 +333,34 @@ func (p PBTConfig) Validate() []error {
 func (p PBTConfig) Unit() Unit {
 	return p.LengthPerRound.Unit
 }
+
+// CustomConfig configures a search whose decisions are delegated to an external controller that
+// talks to the master over the API.
+type CustomConfig struct {
+	// LengthUnit is the unit that the controller specifies training lengths in.
+	LengthUnit string `json:"unit"`
+	// ControllerTimeout is the number of seconds the master waits without hearing from the
+	// controller before pausing the experiment.
+	ControllerTimeout int `json:"controller_timeout"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c CustomConfig) Validate() []error {
+	return []error{
+		check.In(c.LengthUnit, []string{"records", "batches", "epochs"},
+			"unit must be one of records, batches or epochs"),
+		check.GreaterThan(c.ControllerTimeout, 0, "controller_timeout must be > 0"),
+	}
+}
+
+// Unit implements the model.InUnits interface.
+func (c CustomConfig) Unit() Unit {
+	switch c.LengthUnit {
+	case "records":
+		return Records
+	case "epochs":
+		return Epochs
+	default:
+		return Batches
+	}
+}
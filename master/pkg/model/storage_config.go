@@ -137,8 +137,8 @@ func (h HDFSConfig) Validate() []error {
 // S3Config configures storing checkpoints on S3.
 type S3Config struct {
 	Bucket      string  `json:"bucket"`
-	AccessKey   *string `json:"access_key,omitempty"`
-	SecretKey   *string `json:"secret_key,omitempty"`
+	AccessKey   *string `json:"access_key,omitempty" secret:"true"`
+	SecretKey   *string `json:"secret_key,omitempty" secret:"true"`
 	EndpointURL *string `json:"endpoint_url,omitempty"`
 }
 
@@ -4,8 +4,6 @@ import (
 	"regexp"
 	"strconv"
 
-	"github.com/docker/docker/api/types"
-
 	k8sV1 "k8s.io/api/core/v1"
 
 	"github.com/docker/docker/api/types/container"
@@ -24,8 +22,71 @@ type TaskContainerDefaultsConfig struct {
 	CPUPodSpec             *k8sV1.Pod            `json:"cpu_pod_spec"`
 	GPUPodSpec             *k8sV1.Pod            `json:"gpu_pod_spec"`
 	Image                  *RuntimeItem          `json:"image,omitempty"`
-	RegistryAuth           *types.AuthConfig     `json:"registry_auth,omitempty"`
+	RegistryAuth           *RegistryAuth         `json:"registry_auth,omitempty"`
 	ForcePullImage         bool                  `json:"force_pull_image,omitempty"`
+	EnvironmentVariables   RuntimeItems          `json:"environment_variables,omitempty"`
+	BindMounts             []BindMount           `json:"bind_mounts,omitempty"`
+}
+
+// TaskContainerOverrides overrides the task container defaults of the master for the tasks of a
+// resource pool.
+type TaskContainerOverrides struct {
+	EnvironmentVariables RuntimeItems  `json:"environment_variables,omitempty"`
+	BindMounts           []BindMount   `json:"bind_mounts,omitempty"`
+	RegistryAuth         *RegistryAuth `json:"registry_auth,omitempty"`
+}
+
+// Validate implements the check.Validatable interface.
+func (o TaskContainerOverrides) Validate() []error {
+	return validateBindMountPaths(o.BindMounts)
+}
+
+// WithOverrides returns the task container defaults with the given overrides applied: their
+// environment variables follow those of the defaults, so that they take precedence, their bind
+// mounts replace those of the defaults with the same container path, and their registry
+// credentials replace those of the defaults.
+func (c TaskContainerDefaultsConfig) WithOverrides(
+	o *TaskContainerOverrides,
+) TaskContainerDefaultsConfig {
+	if o == nil {
+		return c
+	}
+	c.EnvironmentVariables = RuntimeItems{
+		CPU: append(append([]string{}, c.EnvironmentVariables.CPU...), o.EnvironmentVariables.CPU...),
+		GPU: append(append([]string{}, c.EnvironmentVariables.GPU...), o.EnvironmentVariables.GPU...),
+	}
+
+	overridden := make(map[string]bool, len(o.BindMounts))
+	for _, m := range o.BindMounts {
+		overridden[m.ContainerPath] = true
+	}
+	bindMounts := make([]BindMount, 0, len(c.BindMounts)+len(o.BindMounts))
+	for _, m := range c.BindMounts {
+		if !overridden[m.ContainerPath] {
+			bindMounts = append(bindMounts, m)
+		}
+	}
+	c.BindMounts = append(bindMounts, o.BindMounts...)
+
+	if o.RegistryAuth != nil {
+		c.RegistryAuth = o.RegistryAuth
+	}
+	return c
+}
+
+// validateBindMountPaths returns an error for each container path that more than one of the bind
+// mounts mounts to.
+func validateBindMountPaths(bindMounts []BindMount) []error {
+	var errs []error
+	seen := make(map[string]bool, len(bindMounts))
+	for _, m := range bindMounts {
+		if seen[m.ContainerPath] {
+			errs = append(errs, errors.Errorf(
+				"more than one bind mount has container_path %s", m.ContainerPath))
+		}
+		seen[m.ContainerPath] = true
+	}
+	return errs
 }
 
 func validatePortRange(portRange string) []error {
@@ -77,6 +138,7 @@ func (c TaskContainerDefaultsConfig) Validate() []error {
 
 	errs = append(errs, validatePodSpec(c.CPUPodSpec)...)
 	errs = append(errs, validatePodSpec(c.GPUPodSpec)...)
+	errs = append(errs, validateBindMountPaths(c.BindMounts)...)
 
 	return errs
 }
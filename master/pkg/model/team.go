@@ -0,0 +1,8 @@
+package model
+
+// Team is a group of users who see the experiments of the team. Users who are not members of a
+// team do not see its experiments, unless they own them or are admins.
+type Team struct {
+	ID   int    `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
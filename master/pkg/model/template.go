@@ -2,6 +2,7 @@ package model
 
 // Template represents a row from the `templates` table.
 type Template struct {
-	Name   string `db:"name" json:"name"`
-	Config []byte `db:"config" json:"config"`
+	Name        string `db:"name" json:"name"`
+	Description string `db:"description" json:"description"`
+	Config      []byte `db:"config" json:"config"`
 }
@@ -23,6 +23,7 @@ type User struct {
 	PasswordHash null.String `db:"password_hash" json:"-"`
 	Admin        bool        `db:"admin" json:"admin"`
 	Active       bool        `db:"active" json:"active"`
+	Email        null.String `db:"email" json:"email"`
 }
 
 // UserSession corresponds to a row in the "user_sessions" DB table.
@@ -39,6 +40,8 @@ type FullUser struct {
 	Admin    bool   `db:"admin" json:"admin"`
 	Active   bool   `db:"active" json:"active"`
 
+	Email null.String `db:"email" json:"email"`
+
 	AgentUID   null.Int    `db:"agent_uid" json:"agent_uid"`
 	AgentGID   null.Int    `db:"agent_gid" json:"agent_gid"`
 	AgentUser  null.String `db:"agent_user" json:"agent_user"`
@@ -82,6 +85,11 @@ func (user User) PasswordCanBeModifiedBy(other User) bool {
 	return false
 }
 
+// EmailCanBeModifiedBy checks whether "other" can change the email address of "user".
+func (user User) EmailCanBeModifiedBy(other User) bool {
+	return other.Admin || other.ID == user.ID
+}
+
 // CanCreateUser checks whether the calling user
 // has the authority to create other users.
 func (user User) CanCreateUser() bool {
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
+)
+
+// WebhookEventType is the type of an event that webhooks can be notified of.
+type WebhookEventType string
+
+const (
+	// ExperimentCompletedEvent is sent when an experiment completes successfully.
+	ExperimentCompletedEvent WebhookEventType = "experiment.completed"
+	// ExperimentErroredEvent is sent when an experiment stops because of an error.
+	ExperimentErroredEvent WebhookEventType = "experiment.errored"
+	// TrialErroredEvent is sent when a trial fails and will not be restarted.
+	TrialErroredEvent WebhookEventType = "trial.errored"
+	// ModelVersionRegisteredEvent is sent when a checkpoint is registered as a model version.
+	ModelVersionRegisteredEvent WebhookEventType = "model_version.registered"
+)
+
+// WebhookEventTypes are all the types of events that webhooks can be notified of.
+var WebhookEventTypes = map[WebhookEventType]bool{
+	ExperimentCompletedEvent:    true,
+	ExperimentErroredEvent:      true,
+	TrialErroredEvent:           true,
+	ModelVersionRegisteredEvent: true,
+}
+
+// WebhookEventFilter is the list of event types that a webhook is notified of.
+type WebhookEventFilter []WebhookEventType
+
+// Value marshals the filter to JSON.
+func (f WebhookEventFilter) Value() (driver.Value, error) {
+	bytes, err := json.Marshal(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling webhook event filter")
+	}
+	return bytes, nil
+}
+
+// Scan unmarshals the filter from JSON.
+func (f *WebhookEventFilter) Scan(src interface{}) error {
+	bytes, ok := src.([]byte)
+	if !ok {
+		return errors.Errorf("unable to convert to []byte: %v", src)
+	}
+	return errors.Wrapf(json.Unmarshal(bytes, f), "unable to unmarshal webhook event filter: %v", src)
+}
+
+// Matches returns whether the filter includes the event type.
+func (f WebhookEventFilter) Matches(eventType WebhookEventType) bool {
+	for _, t := range f {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook corresponds to a row in the "webhooks" DB table. Webhooks are sent the events that match
+// their filter as JSON payloads, signed with their secret.
+type Webhook struct {
+	ID         int                `db:"id" json:"id"`
+	URL        string             `db:"url" json:"url"`
+	Secret     string             `db:"secret" json:"secret" secret:"true"`
+	EventTypes WebhookEventFilter `db:"event_types" json:"event_types"`
+	CreatedAt  time.Time          `db:"created_at" json:"created_at"`
+}
+
+// WebhookDelivery corresponds to a row in the "webhook_deliveries" DB table. It records an attempt
+// to deliver an event to a webhook.
+type WebhookDelivery struct {
+	ID        int              `db:"id" json:"id"`
+	WebhookID int              `db:"webhook_id" json:"webhook_id"`
+	EventID   string           `db:"event_id" json:"event_id"`
+	EventType WebhookEventType `db:"event_type" json:"event_type"`
+	// Attempt is the number of the attempt to deliver the event, starting from 1.
+	Attempt int       `db:"attempt" json:"attempt"`
+	Time    time.Time `db:"time" json:"time"`
+	// StatusCode is the HTTP status of the response of the webhook, if it responded.
+	StatusCode null.Int `db:"status_code" json:"status_code"`
+	// Error describes why the delivery failed, if it did.
+	Error null.String `db:"error" json:"error"`
+}
@@ -0,0 +1,109 @@
+// Package redact hides secrets in configuration values before they are displayed. Secrets are
+// string or *string struct fields tagged with `secret:"true"`. Fields that look like secrets but
+// are not, such as the path of a key file, are tagged with `secret:"false"`.
+package redact
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Mask replaces the value of secrets.
+const Mask = "********"
+
+// Redact returns a copy of v in which every secret that is set is replaced by Mask. v itself is
+// not modified, and the copy only shares with v the parts of it that do not contain secrets.
+func Redact(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return redact(reflect.ValueOf(v)).Interface()
+}
+
+// IsSecret returns whether the struct field is tagged as a secret.
+func IsSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+func redact(v reflect.Value) reflect.Value {
+	if !hasSecrets(v.Type()) {
+		return v
+	}
+	out := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			out.Set(reflect.New(v.Type().Elem()))
+			out.Elem().Set(redact(v.Elem()))
+		}
+	case reflect.Struct:
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if IsSecret(field) {
+				out.Field(i).Set(mask(v.Field(i)))
+			} else {
+				out.Field(i).Set(redact(v.Field(i)))
+			}
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			out.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				out.Index(i).Set(redact(v.Index(i)))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redact(v.Index(i)))
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			out.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			for _, key := range v.MapKeys() {
+				out.SetMapIndex(key, redact(v.MapIndex(key)))
+			}
+		}
+	}
+	return out
+}
+
+// mask returns Mask in place of the value of a secret field, unless the field is unset.
+func mask(v reflect.Value) reflect.Value {
+	switch {
+	case v.Kind() == reflect.String && v.Len() > 0:
+		return reflect.ValueOf(Mask).Convert(v.Type())
+	case v.Kind() == reflect.Ptr && !v.IsNil() && v.Type().Elem().Kind() == reflect.String:
+		masked := reflect.New(v.Type().Elem())
+		masked.Elem().Set(reflect.ValueOf(Mask).Convert(v.Type().Elem()))
+		return masked
+	default:
+		return v
+	}
+}
+
+var secretTypes sync.Map
+
+// hasSecrets returns whether values of the type can contain secrets.
+func hasSecrets(t reflect.Type) bool {
+	if cached, ok := secretTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	// Assume that a type has no secrets while checking it, to handle recursive types.
+	secretTypes.Store(t, false)
+	result := false
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		result = hasSecrets(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !result; i++ {
+			field := t.Field(i)
+			result = field.PkgPath == "" && (IsSecret(field) || hasSecrets(field.Type))
+		}
+	}
+	secretTypes.Store(t, result)
+	return result
+}
@@ -0,0 +1,58 @@
+package redact
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type credentials struct {
+	User     string  `json:"user"`
+	Password string  `json:"password" secret:"true"`
+	Token    *string `json:"token" secret:"true"`
+	KeyPath  string  `json:"key_path" secret:"false"`
+}
+
+type config struct {
+	Name    string
+	Main    credentials
+	Backup  *credentials
+	Pools   []credentials
+	ByName  map[string]*credentials
+	Unset   *credentials
+	private credentials
+}
+
+func TestRedact(t *testing.T) {
+	token := "token"
+	original := config{
+		Name:    "cluster",
+		Main:    credentials{User: "admin", Password: "hunter2", KeyPath: "/key.pem"},
+		Backup:  &credentials{User: "backup", Token: &token},
+		Pools:   []credentials{{Password: "pool"}},
+		ByName:  map[string]*credentials{"a": {Password: "a"}},
+		private: credentials{Password: "private"},
+	}
+
+	redacted := Redact(original).(config)
+	assert.Equal(t, redacted.Name, "cluster")
+	assert.DeepEqual(t, redacted.Main,
+		credentials{User: "admin", Password: Mask, KeyPath: "/key.pem"})
+	assert.Equal(t, redacted.Backup.Password, "")
+	assert.Equal(t, *redacted.Backup.Token, Mask)
+	assert.Equal(t, redacted.Pools[0].Password, Mask)
+	assert.Equal(t, redacted.ByName["a"].Password, Mask)
+	assert.Assert(t, redacted.Unset == nil)
+
+	// The original is left untouched.
+	assert.Equal(t, original.Main.Password, "hunter2")
+	assert.Equal(t, *original.Backup.Token, "token")
+	assert.Equal(t, original.Pools[0].Password, "pool")
+	assert.Equal(t, original.ByName["a"].Password, "a")
+}
+
+func TestRedactWithoutSecrets(t *testing.T) {
+	assert.Equal(t, Redact("value"), "value")
+	assert.Equal(t, Redact(nil), nil)
+	assert.DeepEqual(t, Redact([]int{1, 2}), []int{1, 2})
+}
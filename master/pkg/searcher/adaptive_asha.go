@@ -94,5 +94,44 @@ func newAdaptiveASHASearch(config model.AdaptiveASHAConfig) SearchMethod {
 		methods = append(methods, newAsyncHalvingSearch(c))
 	}
 
-	return newTournamentSearch(methods...)
+	return &adaptiveASHASearch{
+		tournamentSearch: newTournamentSearch(methods...),
+		divisor:          config.Divisor,
+		bracketMaxTrials: bracketMaxTrials,
+	}
+}
+
+// adaptiveASHASearch runs a bracket of asynchronous successive halving for each of the numbers of
+// rungs that the adaptive mode selects.
+type adaptiveASHASearch struct {
+	*tournamentSearch
+	divisor          float64
+	bracketMaxTrials []int
+}
+
+func (s *adaptiveASHASearch) maxConcurrentTrials() int {
+	total := 0
+	for _, subSearch := range s.subSearches {
+		total += subSearch.(concurrencyLimiter).maxConcurrentTrials()
+	}
+	return total
+}
+
+// setMaxConcurrentTrials splits the trials across the brackets the same way as at submission.
+// Every bracket keeps at least one trial running so that each of them can finish.
+func (s *adaptiveASHASearch) setMaxConcurrentTrials(
+	ctx context, maxConcurrentTrials int,
+) ([]Operation, error) {
+	bracketMaxConcurrentTrials := getBracketMaxConcurrentTrials(
+		maxConcurrentTrials, s.divisor, s.bracketMaxTrials)
+	var operations []Operation
+	for i, subSearch := range s.subSearches {
+		ops, err := subSearch.(concurrencyLimiter).setMaxConcurrentTrials(
+			ctx, max(bracketMaxConcurrentTrials[i], 1))
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, s.markCreates(subSearch, ops)...)
+	}
+	return operations, nil
 }
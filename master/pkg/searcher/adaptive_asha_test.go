@@ -127,3 +127,24 @@ func TestAdaptiveASHASearchMethod(t *testing.T) {
 
 	runValueSimulationTestCases(t, testCases)
 }
+
+func TestAdaptiveASHASetMaxConcurrentTrials(t *testing.T) {
+	conf := model.AdaptiveASHAConfig{
+		Metric: defaultMetric, SmallerIsBetter: true,
+		MaxLength: model.NewLengthInBatches(6400), MaxTrials: 128, Divisor: 4,
+		Mode: model.StandardMode, MaxRungs: 3, MaxConcurrentTrials: 12,
+	}
+	s := NewSearcher(0, newAdaptiveASHASearch(conf), nil)
+	_, err := s.InitialOperations()
+	assert.NilError(t, err)
+	maxConcurrentTrials, err := s.MaxConcurrentTrials()
+	assert.NilError(t, err)
+	assert.Equal(t, maxConcurrentTrials, 12)
+
+	// Each bracket keeps at least one trial running.
+	_, err = s.SetMaxConcurrentTrials(1)
+	assert.NilError(t, err)
+	maxConcurrentTrials, err = s.MaxConcurrentTrials()
+	assert.NilError(t, err)
+	assert.Equal(t, maxConcurrentTrials, 2)
+}
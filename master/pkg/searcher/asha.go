@@ -23,6 +23,13 @@ type asyncHalvingSearch struct {
 	closedTrials    map[RequestID]bool
 	maxTrials       int
 	trialsCompleted int
+
+	// maxConcurrent is the number of trials that the search keeps running. It is set by the initial
+	// operations and only limits trial creation once it has been adjusted while the search runs.
+	maxConcurrent       int
+	concurrencyAdjusted bool
+	// pendingCreates is the number of trials that have been requested but not created yet.
+	pendingCreates int
 }
 
 const ashaExitedMetricValue = math.MaxFloat64
@@ -99,28 +106,66 @@ func (s *asyncHalvingSearch) initialOperations(ctx context) ([]Operation, error)
 	// Otherwise we will default to a number of trials that will
 	// guarantee at least one trial at the top rung.
 	var ops []Operation
-	var maxConcurrentTrials int
 
 	if s.MaxConcurrentTrials > 0 {
-		maxConcurrentTrials = min(s.MaxConcurrentTrials, s.MaxTrials)
+		s.maxConcurrent = min(s.MaxConcurrentTrials, s.MaxTrials)
 	} else {
-		maxConcurrentTrials = max(
+		s.maxConcurrent = max(
 			min(int(math.Pow(s.Divisor, float64(s.NumRungs-1))), s.MaxTrials),
 			1)
 	}
 
-	for trial := 0; trial < maxConcurrentTrials; trial++ {
-		create := NewCreate(
-			ctx.rand, sampleAll(ctx.hparams, ctx.rand), model.TrialWorkloadSequencerType)
-		s.trialRungs[create.RequestID] = 0
-		ops = append(ops, create)
-		ops = append(ops, NewTrain(create.RequestID, s.rungs[0].unitsNeeded))
-		ops = append(ops, NewValidate(create.RequestID))
+	for trial := 0; trial < s.maxConcurrent; trial++ {
+		ops = append(ops, s.createTrial(ctx)...)
 	}
 	return ops, nil
 }
 
+// createTrial returns the operations that create a new trial and train it to the bottom rung.
+func (s *asyncHalvingSearch) createTrial(ctx context) []Operation {
+	create := NewCreate(
+		ctx.rand, sampleAll(ctx.hparams, ctx.rand), model.TrialWorkloadSequencerType)
+	s.trialRungs[create.RequestID] = 0
+	s.pendingCreates++
+	return []Operation{
+		create,
+		NewTrain(create.RequestID, s.rungs[0].unitsNeeded),
+		NewValidate(create.RequestID),
+	}
+}
+
+// activeTrials returns the number of trials that are requested or have workloads outstanding.
+func (s *asyncHalvingSearch) activeTrials() int {
+	active := s.pendingCreates
+	for _, r := range s.rungs {
+		active += r.outstandingTrials
+	}
+	return active
+}
+
+func (s *asyncHalvingSearch) maxConcurrentTrials() int {
+	return s.maxConcurrent
+}
+
+func (s *asyncHalvingSearch) setMaxConcurrentTrials(
+	ctx context, maxConcurrentTrials int,
+) ([]Operation, error) {
+	s.maxConcurrent = maxConcurrentTrials
+	s.concurrencyAdjusted = true
+	return s.fillConcurrency(ctx), nil
+}
+
+// fillConcurrency creates trials until as many trials as allowed are active.
+func (s *asyncHalvingSearch) fillConcurrency(ctx context) []Operation {
+	var ops []Operation
+	for s.activeTrials() < s.maxConcurrent && len(s.trialRungs) < s.maxTrials {
+		ops = append(ops, s.createTrial(ctx)...)
+	}
+	return ops
+}
+
 func (s *asyncHalvingSearch) trialCreated(ctx context, requestID RequestID) ([]Operation, error) {
+	s.pendingCreates--
 	s.rungs[0].outstandingTrials++
 	s.trialRungs[requestID] = 0
 	return nil, nil
@@ -195,14 +240,14 @@ func (s *asyncHalvingSearch) promoteAsync(
 		}
 	}
 
+	// Unless the number of concurrent trials has been adjusted, the search keeps as many trials
+	// running as it started with by creating a trial whenever a workload is not handed out.
 	allTrials := len(s.trialRungs)
-	if !addedTrainWorkload && allTrials < s.maxTrials {
-		create := NewCreate(
-			ctx.rand, sampleAll(ctx.hparams, ctx.rand), model.TrialWorkloadSequencerType)
-		s.trialRungs[create.RequestID] = 0
-		ops = append(ops, create)
-		ops = append(ops, NewTrain(create.RequestID, s.rungs[0].unitsNeeded))
-		ops = append(ops, NewValidate(create.RequestID))
+	switch {
+	case s.concurrencyAdjusted:
+		ops = append(ops, s.fillConcurrency(ctx)...)
+	case !addedTrainWorkload && allTrials < s.maxTrials:
+		ops = append(ops, s.createTrial(ctx)...)
 	}
 
 	// Only close out trials once we have reached the maxTrials for the searcher.
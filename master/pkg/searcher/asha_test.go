@@ -3,7 +3,10 @@ package searcher
 import (
 	"testing"
 
+	"gotest.tools/assert"
+
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/workload"
 )
 
 func TestASHASearcherRecords(t *testing.T) {
@@ -236,3 +239,117 @@ func TestASHASearchMethod(t *testing.T) {
 
 	runValueSimulationTestCases(t, testCases)
 }
+
+// concurrencySimulation runs the workloads of a searcher one trial at a time, in the order that
+// the trials were given work, and tracks how many trials have work outstanding.
+type concurrencySimulation struct {
+	t        *testing.T
+	searcher *Searcher
+	pending  map[RequestID][]Runnable
+	queue    []RequestID
+	created  int
+	shutdown bool
+}
+
+func (c *concurrencySimulation) handle(ops []Operation) {
+	for _, op := range ops {
+		switch op := op.(type) {
+		case Create:
+			c.created++
+			_, err := c.searcher.TrialCreated(op, c.created)
+			assert.NilError(c.t, err)
+		case Runnable:
+			if len(c.pending[op.GetRequestID()]) == 0 {
+				c.queue = append(c.queue, op.GetRequestID())
+			}
+			c.pending[op.GetRequestID()] = append(c.pending[op.GetRequestID()], op)
+		case Close:
+			closeOps, err := c.searcher.TrialClosed(op.RequestID)
+			assert.NilError(c.t, err)
+			c.handle(closeOps)
+		case Shutdown:
+			c.shutdown = true
+		}
+	}
+}
+
+// step completes the outstanding workloads of the next trial.
+func (c *concurrencySimulation) step() {
+	requestID := c.queue[0]
+	c.queue = c.queue[1:]
+	trialID, _ := c.searcher.TrialID(requestID)
+	runnables := c.pending[requestID]
+	delete(c.pending, requestID)
+	for _, runnable := range runnables {
+		var metrics interface{}
+		if _, ok := runnable.(Validate); ok {
+			metrics = &workload.ValidationMetrics{
+				Metrics: map[string]interface{}{"error": float64(trialID)},
+			}
+		}
+		ops, err := c.searcher.OperationCompleted(trialID, runnable, metrics)
+		assert.NilError(c.t, err)
+		c.handle(ops)
+	}
+}
+
+func TestASHASetMaxConcurrentTrials(t *testing.T) {
+	config := model.AsyncHalvingConfig{
+		Metric:              "error",
+		NumRungs:            3,
+		SmallerIsBetter:     true,
+		MaxLength:           model.NewLengthInBatches(900),
+		MaxTrials:           30,
+		Divisor:             3,
+		MaxConcurrentTrials: 6,
+	}
+	c := &concurrencySimulation{
+		t:        t,
+		searcher: NewSearcher(0, newAsyncHalvingSearch(config), customHparams),
+		pending:  map[RequestID][]Runnable{},
+	}
+	ops, err := c.searcher.InitialOperations()
+	assert.NilError(t, err)
+	c.handle(ops)
+	assert.Equal(t, c.created, 6)
+
+	_, err = c.searcher.SetMaxConcurrentTrials(0)
+	assert.ErrorContains(t, err, "at least 1")
+
+	// Lowering the limit lets the running trials finish before new ones are created.
+	ops, err = c.searcher.SetMaxConcurrentTrials(2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 0)
+	for len(c.queue) > 2 {
+		created := c.created
+		c.step()
+		assert.Equal(t, c.created, created)
+	}
+	for i := 0; i < 10; i++ {
+		c.step()
+		assert.Assert(t, len(c.queue) <= 2)
+	}
+	maxConcurrentTrials, err := c.searcher.MaxConcurrentTrials()
+	assert.NilError(t, err)
+	assert.Equal(t, maxConcurrentTrials, 2)
+
+	// Raising the limit creates trials right away.
+	ops, err = c.searcher.SetMaxConcurrentTrials(5)
+	assert.NilError(t, err)
+	c.handle(ops)
+	assert.Equal(t, len(c.queue), 5)
+
+	for len(c.queue) > 0 {
+		c.step()
+		assert.Assert(t, len(c.queue) <= 5)
+	}
+	assert.Assert(t, c.shutdown)
+	assert.Equal(t, c.created, config.MaxTrials)
+}
+
+func TestSetMaxConcurrentTrialsUnsupported(t *testing.T) {
+	config := model.RandomConfig{MaxLength: model.NewLengthInBatches(100), MaxTrials: 4}
+	s := NewSearcher(0, newRandomSearch(config), customHparams)
+	_, err := s.SetMaxConcurrentTrials(2)
+	assert.ErrorContains(t, err, "does not support")
+}
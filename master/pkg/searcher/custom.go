@@ -0,0 +1,312 @@
+package searcher
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+// All the event types that are reported to a custom searcher controller.
+const (
+	CustomSearcherInitialized        = "initialized"
+	CustomSearcherTrialCreated       = "trial_created"
+	CustomSearcherTrainCompleted     = "train_completed"
+	CustomSearcherValidationComplete = "validation_completed"
+	CustomSearcherCheckpointComplete = "checkpoint_completed"
+	CustomSearcherTrialClosed        = "trial_closed"
+	CustomSearcherTrialExitedEarly   = "trial_exited_early"
+)
+
+// CustomSearcherEvent is an event that the master reports to the external controller of a custom
+// search. Events are identified by a monotonically increasing ID so that the controller can
+// acknowledge the events that it has already handled.
+type CustomSearcherEvent struct {
+	ID           int                    `json:"id"`
+	Type         string                 `json:"type"`
+	RequestID    *RequestID             `json:"request_id,omitempty"`
+	Length       *model.Length          `json:"length,omitempty"`
+	Metrics      map[string]interface{} `json:"metrics,omitempty"`
+	ExitedReason *workload.ExitedReason `json:"exited_reason,omitempty"`
+}
+
+// CustomOperation is an operation posted by the external controller of a custom search. Exactly
+// one of the fields must be set.
+type CustomOperation struct {
+	Create *struct {
+		// RequestID may be chosen by the controller so that it can refer to the new trial in the
+		// same batch of operations.
+		RequestID *RequestID             `json:"request_id"`
+		Hparams   map[string]interface{} `json:"hparams"`
+	} `json:"create,omitempty"`
+	Train *struct {
+		RequestID RequestID    `json:"request_id"`
+		Length    model.Length `json:"length"`
+	} `json:"train,omitempty"`
+	Validate *struct {
+		RequestID RequestID `json:"request_id"`
+	} `json:"validate,omitempty"`
+	Checkpoint *struct {
+		RequestID RequestID `json:"request_id"`
+	} `json:"checkpoint,omitempty"`
+	Close *struct {
+		RequestID RequestID `json:"request_id"`
+	} `json:"close,omitempty"`
+	SetProgress *float64 `json:"set_progress,omitempty"`
+	Shutdown    *struct {
+		Failure bool `json:"failure"`
+	} `json:"shutdown,omitempty"`
+}
+
+// customSearch delegates all of its decisions to an external controller. Rather than returning
+// operations in response to events, it queues the events for the controller and accepts the
+// operations the controller posts back through Searcher.CustomOperations.
+type customSearch struct {
+	model.CustomConfig
+
+	events      []CustomSearcherEvent
+	nextEventID int
+	progressVal float64
+
+	// open holds the trials that have been requested and not yet closed, mapped to whether they
+	// still accept operations (i.e., have not been asked to close).
+	open map[RequestID]bool
+}
+
+func newCustomSearch(config model.CustomConfig) SearchMethod {
+	return &customSearch{CustomConfig: config, nextEventID: 1, open: map[RequestID]bool{}}
+}
+
+func (s *customSearch) record(event CustomSearcherEvent) {
+	event.ID = s.nextEventID
+	s.nextEventID++
+	s.events = append(s.events, event)
+}
+
+func (s *customSearch) initialOperations(context) ([]Operation, error) {
+	s.record(CustomSearcherEvent{Type: CustomSearcherInitialized})
+	return nil, nil
+}
+
+func (s *customSearch) trialCreated(_ context, requestID RequestID) ([]Operation, error) {
+	s.record(CustomSearcherEvent{Type: CustomSearcherTrialCreated, RequestID: &requestID})
+	return nil, nil
+}
+
+func (s *customSearch) trainCompleted(
+	_ context, requestID RequestID, train Train,
+) ([]Operation, error) {
+	s.record(CustomSearcherEvent{
+		Type: CustomSearcherTrainCompleted, RequestID: &requestID, Length: &train.Length,
+	})
+	return nil, nil
+}
+
+func (s *customSearch) checkpointCompleted(
+	_ context, requestID RequestID, _ Checkpoint, _ workload.CheckpointMetrics,
+) ([]Operation, error) {
+	s.record(CustomSearcherEvent{Type: CustomSearcherCheckpointComplete, RequestID: &requestID})
+	return nil, nil
+}
+
+func (s *customSearch) validationCompleted(
+	_ context, requestID RequestID, _ Validate, metrics workload.ValidationMetrics,
+) ([]Operation, error) {
+	s.record(CustomSearcherEvent{
+		Type: CustomSearcherValidationComplete, RequestID: &requestID, Metrics: metrics.Metrics,
+	})
+	return nil, nil
+}
+
+func (s *customSearch) trialClosed(_ context, requestID RequestID) ([]Operation, error) {
+	delete(s.open, requestID)
+	s.record(CustomSearcherEvent{Type: CustomSearcherTrialClosed, RequestID: &requestID})
+	return nil, nil
+}
+
+func (s *customSearch) trialExitedEarly(
+	_ context, requestID RequestID, exitedReason workload.ExitedReason,
+) ([]Operation, error) {
+	s.record(CustomSearcherEvent{
+		Type: CustomSearcherTrialExitedEarly, RequestID: &requestID, ExitedReason: &exitedReason,
+	})
+	return nil, nil
+}
+
+func (s *customSearch) progress(float64) float64 {
+	return s.progressVal
+}
+
+// acknowledge drops all events up to and including the given ID and returns the rest. The
+// remaining events are copied, so that the dropped ones are freed.
+func (s *customSearch) acknowledge(through int) []CustomSearcherEvent {
+	i := 0
+	for ; i < len(s.events) && s.events[i].ID <= through; i++ {
+	}
+	if i > 0 {
+		s.events = append([]CustomSearcherEvent(nil), s.events[i:]...)
+	}
+	return append([]CustomSearcherEvent{}, s.events...)
+}
+
+func (s *customSearch) toOperations(
+	ctx context, customOps []CustomOperation,
+) ([]Operation, error) {
+	// Validate against a copy of the open trials so that an invalid batch is rejected as a whole.
+	open := make(map[RequestID]bool, len(s.open))
+	for requestID, accepting := range s.open {
+		open[requestID] = accepting
+	}
+	checkOpen := func(i int, requestID RequestID) error {
+		if !open[requestID] {
+			return errors.Errorf("operation %d: trial %s is not open", i, requestID)
+		}
+		return nil
+	}
+
+	var ops []Operation
+	progress := s.progressVal
+	for i, op := range customOps {
+		if n := op.count(); n != 1 {
+			return nil, errors.Errorf("operation %d: expected exactly one operation, got %d", i, n)
+		}
+		switch {
+		case op.Create != nil:
+			hparams, err := checkHparams(ctx.hparams, op.Create.Hparams)
+			if err != nil {
+				return nil, errors.Wrapf(err, "operation %d", i)
+			}
+			create := NewCreate(ctx.rand, hparams, model.TrialWorkloadSequencerType)
+			if op.Create.RequestID != nil {
+				if _, ok := open[*op.Create.RequestID]; ok {
+					return nil, errors.Errorf(
+						"operation %d: request ID %s is already in use", i, *op.Create.RequestID)
+				}
+				create.RequestID = *op.Create.RequestID
+			}
+			open[create.RequestID] = true
+			ops = append(ops, create)
+		case op.Train != nil:
+			if err := checkOpen(i, op.Train.RequestID); err != nil {
+				return nil, err
+			}
+			if op.Train.Length.Unit != s.Unit() || op.Train.Length.Units <= 0 {
+				return nil, errors.Errorf(
+					"operation %d: train length must be a positive number of %s", i, s.LengthUnit)
+			}
+			ops = append(ops, NewTrain(op.Train.RequestID, op.Train.Length))
+		case op.Validate != nil:
+			if err := checkOpen(i, op.Validate.RequestID); err != nil {
+				return nil, err
+			}
+			ops = append(ops, NewValidate(op.Validate.RequestID))
+		case op.Checkpoint != nil:
+			if err := checkOpen(i, op.Checkpoint.RequestID); err != nil {
+				return nil, err
+			}
+			ops = append(ops, NewCheckpoint(op.Checkpoint.RequestID))
+		case op.Close != nil:
+			if err := checkOpen(i, op.Close.RequestID); err != nil {
+				return nil, err
+			}
+			open[op.Close.RequestID] = false
+			ops = append(ops, NewClose(op.Close.RequestID))
+		case op.SetProgress != nil:
+			if *op.SetProgress < progress || *op.SetProgress > 1 {
+				return nil, errors.Errorf(
+					"operation %d: progress must be between the current progress and 1", i)
+			}
+			progress = *op.SetProgress
+		case op.Shutdown != nil:
+			if i != len(customOps)-1 {
+				return nil, errors.Errorf("operation %d: shutdown must be the last operation", i)
+			}
+			ops = append(ops, Shutdown{Failure: op.Shutdown.Failure})
+		}
+	}
+
+	s.open = open
+	s.progressVal = progress
+	return ops, nil
+}
+
+func (op CustomOperation) count() int {
+	n := 0
+	for _, set := range []bool{
+		op.Create != nil, op.Train != nil, op.Validate != nil, op.Checkpoint != nil,
+		op.Close != nil, op.SetProgress != nil, op.Shutdown != nil,
+	} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// checkHparams verifies that the controller provided a value for exactly the configured
+// hyperparameters. Constant hyperparameters may be omitted.
+func checkHparams(
+	configured model.Hyperparameters, provided map[string]interface{},
+) (hparamSample, error) {
+	sample := make(hparamSample)
+	var err error
+	configured.Each(func(name string, param model.Hyperparameter) {
+		switch val, ok := provided[name]; {
+		case ok:
+			sample[name] = val
+		case param.ConstHyperparameter != nil:
+			sample[name] = param.ConstHyperparameter.Val
+		case err == nil:
+			err = errors.Errorf("missing value for hyperparameter %s", name)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for name := range provided {
+		if _, ok := configured[name]; !ok {
+			return nil, errors.Errorf("unknown hyperparameter %s", name)
+		}
+	}
+	// Round trip through JSON so that values look the same as ones that were sampled by the
+	// master, e.g., global_batch_size as a float64.
+	bytes, err := json.Marshal(sample)
+	if err != nil {
+		return nil, err
+	}
+	sample = make(hparamSample)
+	return sample, json.Unmarshal(bytes, &sample)
+}
+
+// CustomEvents acknowledges all events up to and including the given event ID and returns the
+// events that the controller has not yet acknowledged. It returns an error if the searcher is not
+// a custom search.
+func (s *Searcher) CustomEvents(acknowledged int) ([]CustomSearcherEvent, error) {
+	custom, ok := s.method.(*customSearch)
+	if !ok {
+		return nil, errors.New("experiment does not use the custom searcher")
+	}
+	return custom.acknowledge(acknowledged), nil
+}
+
+// CustomOperations validates the operations posted by the external controller of a custom search
+// against the current searcher state and returns them as searcher operations. Either all of the
+// operations are accepted or none are.
+func (s *Searcher) CustomOperations(customOps []CustomOperation) ([]Operation, error) {
+	custom, ok := s.method.(*customSearch)
+	if !ok {
+		return nil, errors.New("experiment does not use the custom searcher")
+	}
+	if s.eventLog.Shutdown {
+		return nil, errors.New("searcher has already shut down")
+	}
+	ops, err := custom.toOperations(s.context(), customOps)
+	if err != nil {
+		return nil, err
+	}
+	s.eventLog.CustomOperationsPosted(customOps)
+	s.eventLog.OperationsCreated(ops...)
+	return ops, nil
+}
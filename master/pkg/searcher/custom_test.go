@@ -0,0 +1,252 @@
+package searcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/nprand"
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+var customHparams = model.Hyperparameters{
+	"global_batch_size": {ConstHyperparameter: &model.ConstHyperparameter{Val: 32}},
+	"lr": {
+		DoubleHyperparameter: &model.DoubleHyperparameter{Minval: 0.001, Maxval: 0.1},
+	},
+}
+
+func newCustomSearcher() *Searcher {
+	config := model.CustomConfig{LengthUnit: "batches", ControllerTimeout: 60}
+	return NewSearcher(0, newCustomSearch(config), customHparams)
+}
+
+func parseCustomOps(t *testing.T, ops ...string) []CustomOperation {
+	var parsed []CustomOperation
+	for _, op := range ops {
+		var customOp CustomOperation
+		assert.NilError(t, json.Unmarshal([]byte(op), &customOp))
+		parsed = append(parsed, customOp)
+	}
+	return parsed
+}
+
+// randomController is a trivial external controller that implements random search: it creates
+// a fixed number of trials with random learning rates, trains and validates each one once, and
+// shuts down once all of them are closed.
+type randomController struct {
+	t            *testing.T
+	rand         *nprand.State
+	maxTrials    int
+	closed       int
+	acknowledged int
+}
+
+func (c *randomController) step(s *Searcher) []Operation {
+	events, err := s.CustomEvents(c.acknowledged)
+	assert.NilError(c.t, err)
+
+	var ops []string
+	for _, event := range events {
+		c.acknowledged = event.ID
+		switch event.Type {
+		case CustomSearcherInitialized:
+			for i := 0; i < c.maxTrials; i++ {
+				requestID := RequestID(uuid.New())
+				ops = append(ops,
+					fmt.Sprintf(`{"create": {"request_id": "%s", "hparams": {"lr": %f}}}`,
+						requestID, 0.001+c.rand.UnitInterval()*0.099),
+					fmt.Sprintf(`{"train": {"request_id": "%s", "length": {"batches": 100}}}`,
+						requestID),
+					fmt.Sprintf(`{"validate": {"request_id": "%s"}}`, requestID),
+				)
+			}
+		case CustomSearcherValidationComplete:
+			ops = append(ops, fmt.Sprintf(`{"close": {"request_id": "%s"}}`, *event.RequestID))
+		case CustomSearcherTrialClosed:
+			c.closed++
+			ops = append(ops,
+				fmt.Sprintf(`{"set_progress": %f}`, float64(c.closed)/float64(c.maxTrials)))
+			if c.closed == c.maxTrials {
+				ops = append(ops, `{"shutdown": {"failure": false}}`)
+			}
+		}
+	}
+
+	if len(ops) == 0 {
+		return nil
+	}
+	created, err := s.CustomOperations(parseCustomOps(c.t, ops...))
+	assert.NilError(c.t, err)
+	return created
+}
+
+func TestCustomSearchRandomController(t *testing.T) {
+	s := newCustomSearcher()
+	controller := &randomController{t: t, rand: nprand.New(0), maxTrials: 3}
+
+	ops, err := s.InitialOperations()
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 0)
+
+	trialIDs := map[RequestID]int{}
+	pending := controller.step(s)
+	shutdown := false
+	for len(pending) > 0 {
+		op := pending[0]
+		pending = pending[1:]
+		switch op := op.(type) {
+		case Create:
+			assert.Equal(t, op.Hparams["global_batch_size"], float64(32))
+			trialIDs[op.RequestID] = len(trialIDs) + 1
+			_, err = s.TrialCreated(op, trialIDs[op.RequestID])
+		case Train:
+			_, err = s.OperationCompleted(trialIDs[op.RequestID], op, nil)
+		case Validate:
+			_, err = s.OperationCompleted(trialIDs[op.RequestID], op,
+				&workload.ValidationMetrics{Metrics: map[string]interface{}{defaultMetric: 0.5}})
+		case Close:
+			_, err = s.TrialClosed(op.RequestID)
+		case Shutdown:
+			assert.Assert(t, !op.Failure)
+			shutdown = true
+		}
+		assert.NilError(t, err)
+		pending = append(pending, controller.step(s)...)
+	}
+
+	assert.Assert(t, shutdown)
+	assert.Equal(t, len(trialIDs), 3)
+	assert.Equal(t, s.Progress(), 1.0)
+
+	_, err = s.CustomOperations(parseCustomOps(t, `{"set_progress": 1}`))
+	assert.ErrorContains(t, err, "already shut down")
+}
+
+func TestCustomSearchRejectsInvalidOperations(t *testing.T) {
+	s := newCustomSearcher()
+	_, err := s.InitialOperations()
+	assert.NilError(t, err)
+
+	requestID := RequestID(uuid.New())
+	ops, err := s.CustomOperations(parseCustomOps(t,
+		fmt.Sprintf(`{"create": {"request_id": "%s", "hparams": {"lr": 0.01}}}`, requestID),
+		`{"set_progress": 0.5}`,
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 1)
+
+	testCases := []struct {
+		name     string
+		ops      []string
+		expected string
+	}{
+		{"no operation", []string{`{}`}, "expected exactly one operation"},
+		{
+			"unknown trial",
+			[]string{fmt.Sprintf(`{"validate": {"request_id": "%s"}}`, uuid.New())},
+			"is not open",
+		},
+		{
+			"wrong unit",
+			[]string{fmt.Sprintf(
+				`{"train": {"request_id": "%s", "length": {"epochs": 1}}}`, requestID)},
+			"positive number of batches",
+		},
+		{
+			"missing hparam",
+			[]string{`{"create": {"hparams": {}}}`},
+			"missing value for hyperparameter lr",
+		},
+		{
+			"unknown hparam",
+			[]string{`{"create": {"hparams": {"lr": 0.01, "momentum": 0.9}}}`},
+			"unknown hyperparameter momentum",
+		},
+		{
+			"duplicate request ID",
+			[]string{fmt.Sprintf(
+				`{"create": {"request_id": "%s", "hparams": {"lr": 0.01}}}`, requestID)},
+			"already in use",
+		},
+		{
+			"operation after close",
+			[]string{
+				fmt.Sprintf(`{"close": {"request_id": "%s"}}`, requestID),
+				fmt.Sprintf(`{"checkpoint": {"request_id": "%s"}}`, requestID),
+			},
+			"is not open",
+		},
+		{"decreasing progress", []string{`{"set_progress": 0.25}`}, "progress must be between"},
+		{
+			"shutdown not last",
+			[]string{`{"shutdown": {"failure": false}}`, `{"set_progress": 1}`},
+			"shutdown must be the last operation",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.CustomOperations(parseCustomOps(t, tc.ops...))
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+
+	// Rejected batches must leave the searcher untouched.
+	ops, err = s.CustomOperations(parseCustomOps(t,
+		fmt.Sprintf(`{"train": {"request_id": "%s", "length": {"batches": 10}}}`, requestID),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 1)
+	assert.Equal(t, s.Progress(), 0.5)
+}
+
+func TestCustomSearchNotCustom(t *testing.T) {
+	s := NewSearcher(0, newRandomSearch(model.RandomConfig{
+		MaxTrials: 1, MaxLength: model.NewLengthInBatches(100),
+	}), customHparams)
+	_, err := s.CustomEvents(0)
+	assert.ErrorContains(t, err, "does not use the custom searcher")
+	_, err = s.CustomOperations(nil)
+	assert.ErrorContains(t, err, "does not use the custom searcher")
+}
+
+func TestCustomSearchReplay(t *testing.T) {
+	s := newCustomSearcher()
+	_, err := s.InitialOperations()
+	assert.NilError(t, err)
+	ops, err := s.CustomOperations(parseCustomOps(t,
+		`{"create": {"hparams": {"lr": 0.01}}}`, `{"set_progress": 0.5}`))
+	assert.NilError(t, err)
+
+	// The accepted batch is recorded, so that a restored experiment can post it again.
+	var posted []CustomOperation
+	for _, event := range s.UncommittedEvents() {
+		if event, ok := event.(CustomOperationsPostedEvent); ok {
+			bytes, err := json.Marshal(event.Operations)
+			assert.NilError(t, err)
+			assert.NilError(t, json.Unmarshal(bytes, &posted))
+		}
+	}
+	assert.Equal(t, len(posted), 2)
+
+	restored := newCustomSearcher()
+	_, err = restored.InitialOperations()
+	assert.NilError(t, err)
+	replayed, err := restored.CustomOperations(posted)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, replayed, ops)
+	assert.Equal(t, restored.Progress(), 0.5)
+
+	// Acknowledged events are dropped.
+	events, err := restored.CustomEvents(0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+	events, err = restored.CustomEvents(events[0].ID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+	assert.Equal(t, len(restored.method.(*customSearch).events), 0)
+}
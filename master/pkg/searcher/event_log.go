@@ -20,6 +20,27 @@ type TrialClosedEvent struct {
 	RequestID RequestID
 }
 
+// MaxConcurrentTrialsChangedEvent denotes that the number of trials that the searcher keeps running
+// has been changed while the search was running.
+type MaxConcurrentTrialsChangedEvent struct {
+	MaxConcurrentTrials int
+}
+
+// CustomOperationsPostedEvent denotes that the external controller of a custom search posted a
+// batch of operations that the searcher accepted.
+type CustomOperationsPostedEvent struct {
+	Operations []CustomOperation
+}
+
+// EarlyStoppingTrialUpdatedEvent denotes that the early stopping policies of the experiment
+// evaluated a validation of a trial, which left the trial with the given best value of the early
+// stopping metric and number of validations since it last improved on it.
+type EarlyStoppingTrialUpdatedEvent struct {
+	TrialID          int
+	Best             float64
+	SinceImprovement int
+}
+
 // EventLog records all actions coming to and from a searcher.
 type EventLog struct {
 	uncommitted []Event
@@ -34,6 +55,11 @@ type EventLog struct {
 	TrialsClosed    int
 	TrialIDs        map[RequestID]int
 	RequestIDs      map[int]RequestID
+
+	// Operations that have been created but not carried out yet.
+	pendingCreates map[RequestID]bool
+	pendingCloses  map[RequestID]bool
+	outstanding    map[RequestID][]Runnable
 }
 
 // NewEventLog initializes an empty event log.
@@ -46,15 +72,24 @@ func NewEventLog(unit model.Unit) *EventLog {
 		TrialsClosed:        0,
 		TrialIDs:            map[RequestID]int{},
 		RequestIDs:          map[int]RequestID{},
+		pendingCreates:      map[RequestID]bool{},
+		pendingCloses:       map[RequestID]bool{},
+		outstanding:         map[RequestID][]Runnable{},
 	}
 }
 
 // OperationsCreated records that the provided operations have been created by the searcher.
 func (el *EventLog) OperationsCreated(operations ...Operation) {
 	for _, operation := range operations {
-		switch operation.(type) {
+		switch operation := operation.(type) {
 		case Create:
 			el.TrialsRequested++
+			el.pendingCreates[operation.RequestID] = true
+		case Runnable:
+			requestID := operation.GetRequestID()
+			el.outstanding[requestID] = append(el.outstanding[requestID], operation)
+		case Close:
+			el.pendingCloses[operation.RequestID] = true
 		case Shutdown:
 			el.Shutdown = true
 		}
@@ -70,6 +105,22 @@ func (el *EventLog) TrialCreated(create Create, trialID int) {
 	el.uncommitted = append(el.uncommitted, trialCreated)
 	el.TrialIDs[create.RequestID] = trialID
 	el.RequestIDs[trialID] = create.RequestID
+	delete(el.pendingCreates, create.RequestID)
+}
+
+// OperationCompleted records that the trial with the specified request ID carried out an
+// operation.
+func (el *EventLog) OperationCompleted(requestID RequestID, op Runnable) {
+	ops := el.outstanding[requestID]
+	for i, outstanding := range ops {
+		if outstanding == op {
+			el.outstanding[requestID] = append(ops[:i:i], ops[i+1:]...)
+			break
+		}
+	}
+	if len(el.outstanding[requestID]) == 0 {
+		delete(el.outstanding, requestID)
+	}
 }
 
 // TrialExitedEarly marks the trial with the given requestID as exited early.
@@ -95,4 +146,31 @@ func (el *EventLog) TrialClosed(requestID RequestID) {
 	}
 	el.uncommitted = append(el.uncommitted, trialClosed)
 	el.TrialsClosed++
+	delete(el.pendingCreates, requestID)
+	delete(el.pendingCloses, requestID)
+	delete(el.outstanding, requestID)
+}
+
+// MaxConcurrentTrialsChanged records that the number of trials that the searcher keeps running has
+// been changed.
+func (el *EventLog) MaxConcurrentTrialsChanged(maxConcurrentTrials int) {
+	el.uncommitted = append(el.uncommitted, MaxConcurrentTrialsChangedEvent{
+		MaxConcurrentTrials: maxConcurrentTrials,
+	})
+}
+
+// EarlyStoppingTrialUpdated records the early stopping state of a trial after a validation, so that
+// the patience of the trial carries over when the experiment is restored.
+func (el *EventLog) EarlyStoppingTrialUpdated(trialID int, best float64, sinceImprovement int) {
+	el.uncommitted = append(el.uncommitted, EarlyStoppingTrialUpdatedEvent{
+		TrialID:          trialID,
+		Best:             best,
+		SinceImprovement: sinceImprovement,
+	})
+}
+
+// CustomOperationsPosted records that the external controller of a custom search posted a batch of
+// operations, so that the batch can be replayed when the experiment is restored.
+func (el *EventLog) CustomOperationsPosted(customOps []CustomOperation) {
+	el.uncommitted = append(el.uncommitted, CustomOperationsPostedEvent{Operations: customOps})
 }
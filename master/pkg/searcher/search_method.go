@@ -50,6 +50,17 @@ type SearchMethod interface {
 	model.InUnits
 }
 
+// concurrencyLimiter is implemented by search methods that can change the number of trials they
+// keep running while the search is in progress.
+type concurrencyLimiter interface {
+	// maxConcurrentTrials returns the number of trials that the search method keeps running.
+	maxConcurrentTrials() int
+	// setMaxConcurrentTrials changes the number of trials that the search method keeps running.
+	// It returns operations creating trials if the number was raised; if it was lowered, running
+	// trials continue and new trials are created once fewer trials are running.
+	setMaxConcurrentTrials(ctx context, maxConcurrentTrials int) ([]Operation, error)
+}
+
 // NewSearchMethod returns a new search method for the provided searcher configuration.
 func NewSearchMethod(c model.SearcherConfig) SearchMethod {
 	switch {
@@ -71,6 +82,8 @@ func NewSearchMethod(c model.SearcherConfig) SearchMethod {
 		return newAdaptiveASHASearch(*c.AdaptiveASHAConfig)
 	case c.PBTConfig != nil:
 		return newPBTSearch(*c.PBTConfig)
+	case c.CustomConfig != nil:
+		return newCustomSearch(*c.CustomConfig)
 	default:
 		panic("no searcher type specified")
 	}
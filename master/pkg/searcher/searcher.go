@@ -110,6 +110,7 @@ func (s *Searcher) OperationCompleted(
 	if err != nil {
 		return nil, errors.Wrapf(err, "error while handling a workload completed event: %s", requestID)
 	}
+	s.eventLog.OperationCompleted(requestID, op)
 	s.eventLog.OperationsCreated(operations...)
 	return operations, nil
 }
@@ -122,6 +123,10 @@ func (s *Searcher) TrialClosed(requestID RequestID) ([]Operation, error) {
 		return nil, errors.Wrapf(err, "error while handling a trial closed event: %s", requestID)
 	}
 	s.eventLog.OperationsCreated(operations...)
+	// A custom search only shuts down when its controller says so.
+	if _, custom := s.method.(*customSearch); custom {
+		return operations, nil
+	}
 	if s.eventLog.TrialsRequested == s.eventLog.TrialsClosed {
 		shutdown := Shutdown{Failure: len(s.eventLog.earlyExits) >= s.eventLog.TrialsRequested}
 		s.eventLog.OperationsCreated(shutdown)
@@ -130,6 +135,36 @@ func (s *Searcher) TrialClosed(requestID RequestID) ([]Operation, error) {
 	return operations, nil
 }
 
+// MaxConcurrentTrials returns the number of trials that the search keeps running.
+func (s *Searcher) MaxConcurrentTrials() (int, error) {
+	limiter, ok := s.method.(concurrencyLimiter)
+	if !ok {
+		return 0, errors.New("searcher does not support adjusting max_concurrent_trials")
+	}
+	return limiter.maxConcurrentTrials(), nil
+}
+
+// SetMaxConcurrentTrials changes the number of trials that the search keeps running. Returns
+// operations creating trials if the number was raised.
+func (s *Searcher) SetMaxConcurrentTrials(maxConcurrentTrials int) ([]Operation, error) {
+	limiter, ok := s.method.(concurrencyLimiter)
+	switch {
+	case !ok:
+		return nil, errors.New("searcher does not support adjusting max_concurrent_trials")
+	case maxConcurrentTrials < 1:
+		return nil, errors.New("max_concurrent_trials must be at least 1")
+	case s.eventLog.Shutdown:
+		return nil, errors.New("searcher has already shut down")
+	}
+	s.eventLog.MaxConcurrentTrialsChanged(maxConcurrentTrials)
+	operations, err := limiter.setMaxConcurrentTrials(s.context(), maxConcurrentTrials)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while changing max_concurrent_trials")
+	}
+	s.eventLog.OperationsCreated(operations...)
+	return operations, nil
+}
+
 // Progress returns experiment progress as a float between 0.0 and 1.0.
 func (s *Searcher) Progress() float64 {
 	progress := s.method.progress(s.eventLog.TotalUnitsCompleted)
@@ -155,6 +190,11 @@ func (s *Searcher) RequestID(id int) (RequestID, bool) {
 	return requestID, ok
 }
 
+// EarlyStoppingTrialUpdated records the early stopping state of a trial in the event log.
+func (s *Searcher) EarlyStoppingTrialUpdated(trialID int, best float64, sinceImprovement int) {
+	s.eventLog.EarlyStoppingTrialUpdated(trialID, best, sinceImprovement)
+}
+
 // UncommittedEvents returns the searcher events that have occurred since the last call to
 // UncommittedEvents.
 func (s *Searcher) UncommittedEvents() []Event {
@@ -0,0 +1,160 @@
+package searcher
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+type (
+	// State is a snapshot of the progress of a search, for inspecting searches that seem stuck.
+	State struct {
+		Progress        float64 `json:"progress"`
+		TrialsRequested int     `json:"trials_requested"`
+		TrialsClosed    int     `json:"trials_closed"`
+		Shutdown        bool    `json:"shutdown"`
+		// PendingCreates are the trials that the searcher requested that were not created yet.
+		PendingCreates []RequestID `json:"pending_creates"`
+		// PendingCloses are the trials that the searcher asked to close that did not close yet.
+		PendingCloses []RequestID `json:"pending_closes"`
+		// Outstanding are the operations that trials have not carried out yet.
+		Outstanding []OutstandingOperations `json:"outstanding_operations"`
+		// Brackets are the brackets of successive halving searches, which include the adaptive
+		// searches; other searches have none.
+		Brackets []BracketState `json:"brackets,omitempty"`
+	}
+
+	// OutstandingOperations are the operations that a trial has not carried out yet, in order.
+	OutstandingOperations struct {
+		RequestID  RequestID `json:"request_id"`
+		TrialID    *int      `json:"trial_id"`
+		Operations []string  `json:"operations"`
+	}
+
+	// BracketState is a snapshot of a bracket of successive halving.
+	BracketState struct {
+		Rungs []RungState `json:"rungs"`
+	}
+
+	// RungState is a snapshot of a rung of a bracket of successive halving.
+	RungState struct {
+		UnitsNeeded model.Length `json:"units_needed"`
+		// OutstandingTrials is the number of created trials that are training toward the rung, for
+		// asynchronous successive halving.
+		OutstandingTrials int `json:"outstanding_trials"`
+		// Trials are the trials that reached the rung, best first.
+		Trials []RungTrial `json:"trials"`
+	}
+
+	// RungTrial is a trial that reached a rung.
+	RungTrial struct {
+		RequestID RequestID `json:"request_id"`
+		TrialID   *int      `json:"trial_id"`
+		// Metric is the searcher metric of the trial at the rung, or nil if the trial exited early.
+		Metric   *float64 `json:"metric"`
+		Promoted bool     `json:"promoted"`
+	}
+)
+
+// bracketReporter is implemented by search methods that are made of brackets of successive
+// halving.
+type bracketReporter interface {
+	brackets() []BracketState
+}
+
+// State returns a snapshot of the progress of the search.
+func (s *Searcher) State() State {
+	el := s.eventLog
+	state := State{
+		Progress:        s.Progress(),
+		TrialsRequested: el.TrialsRequested,
+		TrialsClosed:    el.TrialsClosed,
+		Shutdown:        el.Shutdown,
+		PendingCreates:  sortedRequestIDs(el.pendingCreates),
+		PendingCloses:   sortedRequestIDs(el.pendingCloses),
+		Outstanding:     []OutstandingOperations{},
+	}
+	outstanding := make(map[RequestID]bool, len(el.outstanding))
+	for requestID := range el.outstanding {
+		outstanding[requestID] = true
+	}
+	for _, requestID := range sortedRequestIDs(outstanding) {
+		ops := OutstandingOperations{RequestID: requestID, TrialID: s.trialID(requestID)}
+		for _, op := range el.outstanding[requestID] {
+			ops.Operations = append(ops.Operations, fmt.Sprint(op))
+		}
+		state.Outstanding = append(state.Outstanding, ops)
+	}
+	if reporter, ok := s.method.(bracketReporter); ok {
+		state.Brackets = reporter.brackets()
+		for _, bracket := range state.Brackets {
+			for _, rung := range bracket.Rungs {
+				for i := range rung.Trials {
+					rung.Trials[i].TrialID = s.trialID(rung.Trials[i].RequestID)
+				}
+			}
+		}
+	}
+	return state
+}
+
+func (s *Searcher) trialID(requestID RequestID) *int {
+	if trialID, ok := s.TrialID(requestID); ok {
+		return &trialID
+	}
+	return nil
+}
+
+func sortedRequestIDs(ids map[RequestID]bool) []RequestID {
+	sorted := make([]RequestID, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	return sorted
+}
+
+// rungStates returns snapshots of rungs, whose metrics are negated if larger metrics are better
+// and are exitedMetric for trials that exited early.
+func rungStates(rungs []*rung, smallerIsBetter bool, exitedMetric float64) []RungState {
+	states := make([]RungState, 0, len(rungs))
+	for _, r := range rungs {
+		state := RungState{
+			UnitsNeeded:       r.unitsNeeded,
+			OutstandingTrials: r.outstandingTrials,
+			Trials:            make([]RungTrial, 0, len(r.metrics)),
+		}
+		for _, m := range r.metrics {
+			trial := RungTrial{RequestID: m.requestID, Promoted: m.promoted}
+			if m.metric != exitedMetric {
+				metric := m.metric
+				if !smallerIsBetter {
+					metric *= -1
+				}
+				trial.Metric = &metric
+			}
+			state.Trials = append(state.Trials, trial)
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+func (s *syncHalvingSearch) brackets() []BracketState {
+	return []BracketState{{Rungs: rungStates(s.rungs, s.SmallerIsBetter, shaExitedMetricValue)}}
+}
+
+func (s *asyncHalvingSearch) brackets() []BracketState {
+	return []BracketState{{Rungs: rungStates(s.rungs, s.SmallerIsBetter, ashaExitedMetricValue)}}
+}
+
+func (s *tournamentSearch) brackets() []BracketState {
+	var brackets []BracketState
+	for _, subSearch := range s.subSearches {
+		if reporter, ok := subSearch.(bracketReporter); ok {
+			brackets = append(brackets, reporter.brackets()...)
+		}
+	}
+	return brackets
+}
@@ -0,0 +1,61 @@
+package searcher
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/workload"
+)
+
+func TestSearcherState(t *testing.T) {
+	config := model.AsyncHalvingConfig{
+		Metric: "accuracy", SmallerIsBetter: false, NumRungs: 2,
+		MaxLength: model.NewLengthInBatches(400),
+		Divisor:   2,
+		MaxTrials: 2,
+	}
+	s := NewSearcher(0, newAsyncHalvingSearch(config), nil)
+	ops, err := s.InitialOperations()
+	assert.NilError(t, err)
+
+	var creates []Create
+	for _, op := range ops {
+		if create, ok := op.(Create); ok {
+			creates = append(creates, create)
+		}
+	}
+	assert.Equal(t, len(creates), 2)
+	state := s.State()
+	assert.Equal(t, state.TrialsRequested, 2)
+	assert.Equal(t, len(state.PendingCreates), 2)
+	assert.Equal(t, len(state.Outstanding), 2)
+
+	first := creates[0].RequestID
+	_, err = s.TrialCreated(creates[0], 1)
+	assert.NilError(t, err)
+	_, err = s.OperationCompleted(1, NewTrain(first, model.NewLengthInBatches(200)), nil)
+	assert.NilError(t, err)
+	_, err = s.OperationCompleted(1, NewValidate(first), &workload.ValidationMetrics{
+		Metrics: map[string]interface{}{"accuracy": 0.75},
+	})
+	assert.NilError(t, err)
+
+	state = s.State()
+	assert.DeepEqual(t, state.PendingCreates, []RequestID{creates[1].RequestID})
+	trialID := 1
+	metric := 0.75
+	assert.DeepEqual(t, state.Brackets[0].Rungs[0].Trials, []RungTrial{
+		{RequestID: first, TrialID: &trialID, Metric: &metric, Promoted: false},
+	})
+	// The trial waits at the bottom rung for other trials to decide whether to promote it, while
+	// the other trial is yet to train.
+	assert.DeepEqual(t, state.Outstanding, []OutstandingOperations{{
+		RequestID: creates[1].RequestID,
+		Operations: []string{
+			NewTrain(creates[1].RequestID, model.NewLengthInBatches(200)).String(),
+			NewValidate(creates[1].RequestID).String(),
+		},
+	}})
+}
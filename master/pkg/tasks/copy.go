@@ -2,12 +2,15 @@ package tasks
 
 import (
 	"archive/tar"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/pkg/errors"
 
@@ -22,29 +25,110 @@ const (
 	// path in task containers. The value must match where the entrypoint scripts look for wheels when
 	// they run `pip install`.
 	harnessTargetPath = "/opt/determined/wheels"
+	// defaultHarnessPython is the Python version that the entrypoint scripts install the harness
+	// with unless the harness wheels are built for another one.
+	defaultHarnessPython = "3.6"
+
+	// kubernetesHarnessOS and kubernetesHarnessArch are the platform of the harness wheels that the
+	// master copies into pods itself, since there is no agent to pick the wheels for its platform.
+	kubernetesHarnessOS   = "linux"
+	kubernetesHarnessArch = "amd64"
 )
 
-func harnessArchive(harnessPath string, aug *model.AgentUserGroup) container.RunArchive {
-	var harnessFiles archive.Archive
+// harnessPythonKey matches the Python version at the end of a platform key, e.g., -py3.6.
+var harnessPythonKey = regexp.MustCompile(`-py(\d+\.\d+)$`)
+
+// LoadHarnessManifest lists the harness wheels of the running version under harnessPath. Wheels in
+// harnessPath itself work on every platform, and wheels in subdirectories named after platform
+// keys, e.g., linux-arm64-py3.6, are built for those platforms. The Python version of the manifest
+// is the one that the platform keys name, if any.
+func LoadHarnessManifest(harnessPath string) (container.HarnessManifest, error) {
+	manifest := container.HarnessManifest{
+		Python: defaultHarnessPython,
+		Wheels: map[string][]container.HarnessWheel{},
+	}
+	entries, err := ioutil.ReadDir(harnessPath)
+	switch {
+	case os.IsNotExist(err):
+		return manifest, nil
+	case err != nil:
+		return manifest, errors.Wrapf(err, "error listing harness wheels in %s", harnessPath)
+	}
+	platformKeys := []string{container.AnyHarnessPlatform}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			platformKeys = append(platformKeys, entry.Name())
+			if m := harnessPythonKey.FindStringSubmatch(entry.Name()); m != nil {
+				manifest.Python = m[1]
+			}
+		}
+	}
+
 	validWhlNames := fmt.Sprintf("*%s*.whl", version.Version)
-	wheelPaths, err := filepath.Glob(filepath.Join(harnessPath, validWhlNames))
+	for _, key := range platformKeys {
+		wheelPaths, err := filepath.Glob(
+			filepath.Join(HarnessWheelPath(harnessPath, key, ""), validWhlNames))
+		if err != nil {
+			return manifest, errors.Wrapf(err,
+				"error finding Python wheel files for version %s in path: %s",
+				version.Version, harnessPath)
+		}
+		for _, path := range wheelPaths {
+			content, err := ioutil.ReadFile(path) // #nosec: G304
+			if err != nil {
+				return manifest, errors.Wrapf(err, "error reading harness file: %s", path)
+			}
+			sum := sha256.Sum256(content)
+			manifest.Wheels[key] = append(manifest.Wheels[key], container.HarnessWheel{
+				Name:   filepath.Base(path),
+				SHA256: hex.EncodeToString(sum[:]),
+				Size:   int64(len(content)),
+			})
+		}
+	}
+	return manifest, nil
+}
+
+// HarnessWheelPath returns the path of a harness wheel built for the platform key under
+// harnessPath.
+func HarnessWheelPath(harnessPath, platformKey, name string) string {
+	if platformKey == container.AnyHarnessPlatform {
+		return filepath.Join(harnessPath, name)
+	}
+	return filepath.Join(harnessPath, platformKey, name)
+}
+
+// harnessFiles tells the agent that runs a task to copy in the harness wheels for its platform.
+func harnessFiles(t TaskSpec, aug *model.AgentUserGroup) *container.HarnessFiles {
+	files := &container.HarnessFiles{
+		Path: harnessTargetPath, Manifest: t.HarnessManifest, Token: t.HarnessToken,
+	}
+	if aug != nil {
+		files.UserID = aug.UID
+		files.GroupID = aug.GID
+	}
+	return files
+}
+
+// harnessArchive returns the harness wheels for the pods of a task on Kubernetes.
+func harnessArchive(t TaskSpec, aug *model.AgentUserGroup) (container.RunArchive, error) {
+	key, wheels, err := t.HarnessManifest.Resolve(container.HarnessPlatform{
+		OS: kubernetesHarnessOS, Arch: kubernetesHarnessArch, Python: t.HarnessManifest.Python,
+	})
 	if err != nil {
-		panic(errors.Wrapf(err, "error finding Python wheel files for version %s in path: %s",
-			version.Version, harnessPath))
+		return container.RunArchive{}, err
 	}
-	for _, path := range wheelPaths {
+	var harnessFiles archive.Archive
+	for _, wheel := range wheels {
+		path := HarnessWheelPath(t.HarnessPath, key, wheel.Name)
 		info, err := os.Stat(path)
 		if err != nil {
-			panic(errors.Wrapf(err, "error retrieving stats for harness file: %s", path))
-		}
-		var content []byte
-		content, err = ioutil.ReadFile(path) // #nosec: G304
-		if err != nil {
-			panic(errors.Wrapf(err, "error reading harness file: %s", path))
+			return container.RunArchive{}, errors.Wrapf(err,
+				"error retrieving stats for harness file: %s", path)
 		}
-		rel, err := filepath.Rel(harnessPath, path)
+		content, err := ioutil.ReadFile(path) // #nosec: G304
 		if err != nil {
-			panic(errors.Wrapf(err, "error constructing relative path: %s", path))
+			return container.RunArchive{}, errors.Wrapf(err, "error reading harness file: %s", path)
 		}
 
 		var uid int
@@ -57,7 +141,7 @@ func harnessArchive(harnessPath string, aug *model.AgentUserGroup) container.Run
 		}
 
 		harnessFiles = append(harnessFiles, archive.Item{
-			Path:         filepath.Join(harnessTargetPath, rel),
+			Path:         filepath.Join(harnessTargetPath, wheel.Name),
 			Type:         byte(tar.TypeReg),
 			Content:      content,
 			FileMode:     info.Mode(),
@@ -66,7 +150,7 @@ func harnessArchive(harnessPath string, aug *model.AgentUserGroup) container.Run
 			GroupID:      gid,
 		})
 	}
-	return wrapArchive(aug.OwnArchive(harnessFiles), "/")
+	return wrapArchive(aug.OwnArchive(harnessFiles), "/"), nil
 }
 
 func masterCertArchive(cert *tls.Certificate) container.RunArchive {
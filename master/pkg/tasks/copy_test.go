@@ -0,0 +1,38 @@
+package tasks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/version"
+)
+
+func TestLoadHarnessManifestPython(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wheels")
+	assert.NilError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	manifest, err := LoadHarnessManifest(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, manifest.Python, defaultHarnessPython)
+
+	// The Python version is taken from the platforms that the wheels are built for.
+	platform := container.HarnessPlatform{OS: "linux", Arch: "arm64", Python: "3.8"}
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, platform.Key()), 0700))
+	name := "determined-" + version.Version + "-py3-none-any.whl"
+	assert.NilError(t, ioutil.WriteFile(
+		filepath.Join(dir, platform.Key(), name), []byte("wheel"), 0600))
+
+	manifest, err = LoadHarnessManifest(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, manifest.Python, "3.8")
+	_, ok := manifest.Lookup(platform.Key(), name)
+	assert.Assert(t, ok)
+}
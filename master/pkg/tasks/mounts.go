@@ -12,14 +12,10 @@ import (
 func ToDockerMounts(bindMounts []model.BindMount) []mount.Mount {
 	dockerMounts := make([]mount.Mount, 0, len(bindMounts))
 	for _, m := range bindMounts {
-		target := m.ContainerPath
-		if !filepath.IsAbs(target) {
-			target = filepath.Join(ContainerWorkDir, target)
-		}
 		dockerMounts = append(dockerMounts, mount.Mount{
 			Type:     mount.TypeBind,
 			Source:   m.HostPath,
-			Target:   target,
+			Target:   bindMountTarget(m),
 			ReadOnly: m.ReadOnly,
 			BindOptions: &mount.BindOptions{
 				Propagation: mount.Propagation(m.Propagation),
@@ -28,3 +24,11 @@ func ToDockerMounts(bindMounts []model.BindMount) []mount.Mount {
 	}
 	return dockerMounts
 }
+
+// bindMountTarget returns the path that a bind mount mounts to in the container.
+func bindMountTarget(m model.BindMount) string {
+	if !filepath.IsAbs(m.ContainerPath) {
+		return filepath.Join(ContainerWorkDir, m.ContainerPath)
+	}
+	return filepath.Clean(m.ContainerPath)
+}
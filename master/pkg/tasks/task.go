@@ -110,8 +110,17 @@ func CommandEnvVars(t TaskSpec) map[string]string {
 	return envVarsMap
 }
 
-// CommandArchives returns the additional files for a command as an archive.
-func CommandArchives(t TaskSpec) []container.RunArchive {
+// CommandArchives returns the additional files for a command as an archive, along with the harness
+// wheels, for tasks that the master copies the harness wheels into itself.
+func CommandArchives(t TaskSpec) ([]container.RunArchive, error) {
+	harness, err := harnessArchive(t, t.StartCommand.AgentUserGroup)
+	if err != nil {
+		return nil, err
+	}
+	return append(commandArchives(t), harness), nil
+}
+
+func commandArchives(t TaskSpec) []container.RunArchive {
 	cmd := *t.StartCommand
 
 	return []container.RunArchive{
@@ -119,7 +128,6 @@ func CommandArchives(t TaskSpec) []container.RunArchive {
 		injectUserArchive(cmd.AgentUserGroup),
 		wrapArchive(cmd.AgentUserGroup.OwnArchive(cmd.UserFiles), ContainerWorkDir),
 		wrapArchive(cmd.AdditionalFiles, rootDir),
-		harnessArchive(t.HarnessPath, cmd.AgentUserGroup),
 		masterCertArchive(t.MasterCert),
 	}
 }
@@ -136,7 +144,7 @@ func startCommand(t TaskSpec) container.Spec {
 	for envVarKey, envVarValue := range envVarsMap {
 		envVars = append(envVars, fmt.Sprintf("%s=%s", envVarKey, envVarValue))
 	}
-	envVars = append(envVars, cmd.Config.Environment.EnvironmentVariables.For(deviceType)...)
+	envVars = append(envVars, TaskEnvVars(t, cmd.Config.Environment, deviceType)...)
 
 	shmSize := t.TaskContainerDefaults.ShmSizeBytes
 	if cmd.Config.Resources.ShmSize != nil {
@@ -145,7 +153,7 @@ func startCommand(t TaskSpec) container.Spec {
 
 	return container.Spec{
 		PullSpec: container.PullSpec{
-			Registry:  cmd.Config.Environment.RegistryAuth,
+			Registry:  dockerRegistryAuth(t, cmd.Config.Environment),
 			ForcePull: cmd.Config.Environment.ForcePullImage,
 		},
 		RunSpec: container.RunSpec{
@@ -159,18 +167,25 @@ func startCommand(t TaskSpec) container.Spec {
 			},
 			HostConfig: docker.HostConfig{
 				NetworkMode:     t.TaskContainerDefaults.NetworkMode,
-				Mounts:          ToDockerMounts(cmd.Config.BindMounts),
+				Mounts:          CommandDockerMounts(t),
 				PublishAllPorts: true,
 				ShmSize:         shmSize,
 			},
-			Archives: CommandArchives(t),
+			Archives: commandArchives(t),
+			Harness:  harnessFiles(t, cmd.AgentUserGroup),
 		},
 	}
 }
 
+// CommandDockerMounts returns the host mounts for a command container.
+func CommandDockerMounts(t TaskSpec) []mount.Mount {
+	return ToDockerMounts(TaskBindMounts(t, t.StartCommand.Config.BindMounts))
+}
+
 // TrialDockerMounts returns the host mounts for a trial container.
-func TrialDockerMounts(exp StartContainer) []mount.Mount {
-	mounts := ToDockerMounts(exp.ExperimentConfig.BindMounts)
+func TrialDockerMounts(t TaskSpec) []mount.Mount {
+	exp := *t.StartContainer
+	mounts := ToDockerMounts(TaskBindMounts(t, exp.ExperimentConfig.BindMounts))
 	if exp.ExperimentConfig.CheckpointStorage.SharedFSConfig != nil {
 		sharedFS := exp.ExperimentConfig.CheckpointStorage.SharedFSConfig
 		mounts = append(mounts, mount.Mount{
@@ -238,6 +253,7 @@ func TrialEnvVars(t TaskSpec, rendezvousPorts []string, tPortOffset int) map[str
 	envVars["DET_RENDEZVOUS_PORTS"] = strings.Join(rendezvousPorts, ",")
 	envVars["DET_TRIAL_UNIQUE_PORT_OFFSET"] = fmt.Sprintf("%d", tPortOffset)
 	envVars["DET_TRIAL_RUNNER_NETWORK_INTERFACE"] = networkInterface
+	envVars["DET_HEARTBEAT_INTERVAL"] = fmt.Sprintf("%d", exp.HeartbeatInterval)
 	addTLSVars(t, envVars)
 
 	if t.TaskContainerDefaults.NCCLPortRange != "" {
@@ -250,8 +266,17 @@ func TrialEnvVars(t TaskSpec, rendezvousPorts []string, tPortOffset int) map[str
 	return envVars
 }
 
-// TrialArchives returns the additional files for a trial as an archive.
-func TrialArchives(t TaskSpec) []container.RunArchive {
+// TrialArchives returns the additional files for a trial as an archive, along with the harness
+// wheels, for tasks that the master copies the harness wheels into itself.
+func TrialArchives(t TaskSpec) ([]container.RunArchive, error) {
+	harness, err := harnessArchive(t, t.StartContainer.AgentUserGroup)
+	if err != nil {
+		return nil, err
+	}
+	return append(trialArchives(t), harness), nil
+}
+
+func trialArchives(t TaskSpec) []container.RunArchive {
 	exp := *t.StartContainer
 
 	return []container.RunArchive{
@@ -270,7 +295,6 @@ func TrialArchives(t TaskSpec) []container.RunArchive {
 			trainDir,
 		),
 		wrapArchive(exp.AgentUserGroup.OwnArchive(exp.ModelDefinition), ContainerWorkDir),
-		harnessArchive(t.HarnessPath, exp.AgentUserGroup),
 		masterCertArchive(t.MasterCert),
 	}
 }
@@ -282,7 +306,7 @@ func startContainer(t TaskSpec) container.Spec {
 	if len(t.Devices) > 0 {
 		deviceType = t.Devices[0].Type
 	}
-	mounts := TrialDockerMounts(exp)
+	mounts := TrialDockerMounts(t)
 	networkMode := t.TaskContainerDefaults.NetworkMode
 	if exp.IsMultiAgent {
 		networkMode = hostMode
@@ -301,12 +325,12 @@ func startContainer(t TaskSpec) container.Spec {
 	for envVarKey, envVarValue := range envVarsMap {
 		envVars = append(envVars, fmt.Sprintf("%s=%s", envVarKey, envVarValue))
 	}
-	envVars = append(envVars, exp.ExperimentConfig.Environment.EnvironmentVariables.For(deviceType)...)
+	envVars = append(envVars, TaskEnvVars(t, exp.ExperimentConfig.Environment, deviceType)...)
 
 	spec := container.Spec{
 		PullSpec: container.PullSpec{
 			ForcePull: exp.ExperimentConfig.Environment.ForcePullImage,
-			Registry:  exp.ExperimentConfig.Environment.RegistryAuth,
+			Registry:  dockerRegistryAuth(t, exp.ExperimentConfig.Environment),
 		},
 		RunSpec: container.RunSpec{
 			ContainerConfig: docker.Config{
@@ -322,8 +346,9 @@ func startContainer(t TaskSpec) container.Spec {
 				Mounts:          mounts,
 				PublishAllPorts: true,
 			},
-			Archives:         TrialArchives(t),
+			Archives:         trialArchives(t),
 			UseFluentLogging: true,
+			Harness:          harnessFiles(t, exp.AgentUserGroup),
 		},
 	}
 	spec.RunSpec.HostConfig.ShmSize = t.TaskContainerDefaults.ShmSizeBytes
@@ -339,8 +364,9 @@ func GCEnvVars() map[string]string {
 }
 
 // GCDockerMounts returns the host mounts for a gc container.
-func GCDockerMounts(gcc GCCheckpoints) []mount.Mount {
-	mounts := ToDockerMounts(gcc.ExperimentConfig.BindMounts)
+func GCDockerMounts(t TaskSpec) []mount.Mount {
+	gcc := *t.GCCheckpoints
+	mounts := ToDockerMounts(TaskBindMounts(t, gcc.ExperimentConfig.BindMounts))
 	if gcc.ExperimentConfig.CheckpointStorage.SharedFSConfig != nil {
 		sharedFS := gcc.ExperimentConfig.CheckpointStorage.SharedFSConfig
 		mounts = append(mounts, mount.Mount{
@@ -356,8 +382,17 @@ func GCDockerMounts(gcc GCCheckpoints) []mount.Mount {
 	return mounts
 }
 
-// GCArchives returns the additional files for gc as an archive.
-func GCArchives(t TaskSpec) []container.RunArchive {
+// GCArchives returns the additional files for gc as an archive, along with the harness wheels, for
+// tasks that the master copies the harness wheels into itself.
+func GCArchives(t TaskSpec) ([]container.RunArchive, error) {
+	harness, err := harnessArchive(t, t.GCCheckpoints.AgentUserGroup)
+	if err != nil {
+		return nil, err
+	}
+	return append(gcArchives(t), harness), nil
+}
+
+func gcArchives(t TaskSpec) []container.RunArchive {
 	gcc := *t.GCCheckpoints
 
 	return []container.RunArchive{
@@ -386,7 +421,6 @@ func GCArchives(t TaskSpec) []container.RunArchive {
 			},
 			ContainerWorkDir,
 		),
-		harnessArchive(t.HarnessPath, gcc.AgentUserGroup),
 	}
 }
 
@@ -413,12 +447,12 @@ func gcCheckpoint(t TaskSpec) container.Spec {
 	for envVarKey, envVarValue := range envVarsMap {
 		envVars = append(envVars, fmt.Sprintf("%s=%s", envVarKey, envVarValue))
 	}
-	envVars = append(envVars, gcc.ExperimentConfig.Environment.EnvironmentVariables.For(deviceType)...)
+	envVars = append(envVars, TaskEnvVars(t, gcc.ExperimentConfig.Environment, deviceType)...)
 
 	return container.Spec{
 		PullSpec: container.PullSpec{
 			ForcePull: gcc.ExperimentConfig.Environment.ForcePullImage,
-			Registry:  gcc.ExperimentConfig.Environment.RegistryAuth,
+			Registry:  dockerRegistryAuth(t, gcc.ExperimentConfig.Environment),
 		},
 		RunSpec: container.RunSpec{
 			ContainerConfig: docker.Config{
@@ -436,10 +470,11 @@ func gcCheckpoint(t TaskSpec) container.Spec {
 			},
 			HostConfig: docker.HostConfig{
 				NetworkMode:     t.TaskContainerDefaults.NetworkMode,
-				Mounts:          GCDockerMounts(gcc),
+				Mounts:          GCDockerMounts(t),
 				PublishAllPorts: true,
 			},
-			Archives: GCArchives(t),
+			Archives: gcArchives(t),
+			Harness:  harnessFiles(t, gcc.AgentUserGroup),
 		},
 	}
 }
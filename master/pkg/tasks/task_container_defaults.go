@@ -0,0 +1,140 @@
+package tasks
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/ecr"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// taskContainerDefaults returns the task container defaults of the resource pool of the task.
+func taskContainerDefaults(t TaskSpec) model.TaskContainerDefaultsConfig {
+	return t.TaskContainerDefaults.WithOverrides(t.PoolDefaults)
+}
+
+// TaskEnvVars returns the environment variables that are set for a task by its task container
+// defaults and its config, in that order, so that those of the config take precedence.
+func TaskEnvVars(t TaskSpec, env model.Environment, deviceType device.Type) []string {
+	defaults := taskContainerDefaults(t)
+	envVars := append([]string{}, defaults.EnvironmentVariables.For(deviceType)...)
+	return append(envVars, env.EnvironmentVariables.For(deviceType)...)
+}
+
+// TaskBindMounts returns the bind mounts of the task container defaults of a task followed by the
+// given bind mounts of its config.
+func TaskBindMounts(t TaskSpec, bindMounts []model.BindMount) []model.BindMount {
+	defaults := taskContainerDefaults(t)
+	return append(append([]model.BindMount{}, defaults.BindMounts...), bindMounts...)
+}
+
+// registryAuth returns the registry credentials to pull the image of a task with. Configs are
+// created with the credentials of the master if they have none, so those are replaced by the
+// credentials of the resource pool of the task.
+func registryAuth(t TaskSpec, env model.Environment) *model.RegistryAuth {
+	if env.RegistryAuth == nil ||
+		reflect.DeepEqual(env.RegistryAuth, t.TaskContainerDefaults.RegistryAuth) {
+		if auth := taskContainerDefaults(t).RegistryAuth; auth != nil {
+			return auth
+		}
+	}
+	return env.RegistryAuth
+}
+
+// dockerRegistryAuth returns the Docker registry credentials that the agent pulls the image of a
+// task with. The tokens for ECR registries must have been fetched with PendingRegistryToken.
+func dockerRegistryAuth(t TaskSpec, env model.Environment) *types.AuthConfig {
+	auth := registryAuth(t, env)
+	if auth == nil {
+		return nil
+	}
+	if auth.ECR == nil {
+		docker := auth.AuthConfig()
+		return &docker
+	}
+	docker, ok := ecr.CachedAuthConfig(auth.ECR.Region, auth.ECR.RoleARN)
+	if !ok {
+		log.Errorf("the ECR token for %s was not fetched before starting the task", auth.ECR.Region)
+		return nil
+	}
+	return &docker
+}
+
+// taskEnvironment returns the environment of the config of the task.
+func taskEnvironment(t TaskSpec) (model.Environment, bool) {
+	switch {
+	case t.StartCommand != nil:
+		return t.StartCommand.Config.Environment, true
+	case t.StartContainer != nil:
+		return t.StartContainer.ExperimentConfig.Environment, true
+	case t.GCCheckpoints != nil:
+		return t.GCCheckpoints.ExperimentConfig.Environment, true
+	default:
+		return model.Environment{}, false
+	}
+}
+
+// PendingRegistryToken returns a function that fetches the ECR token that the image of the task is
+// pulled with if the token is not cached, and nil otherwise. The function calls out to AWS, so it
+// must be called outside of actors; the task can be started once it returns without an error.
+func PendingRegistryToken(t TaskSpec) func() error {
+	env, ok := taskEnvironment(t)
+	if !ok {
+		return nil
+	}
+	auth := registryAuth(t, env)
+	if auth == nil || auth.ECR == nil {
+		return nil
+	}
+	region, roleARN := auth.ECR.Region, auth.ECR.RoleARN
+	if _, ok := ecr.CachedAuthConfig(region, roleARN); ok {
+		return nil
+	}
+	return func() error {
+		_, err := ecr.AuthConfig(region, roleARN)
+		return err
+	}
+}
+
+// configBindMounts returns the bind mounts of the config of the task and the name of the config.
+func configBindMounts(t TaskSpec) ([]model.BindMount, string) {
+	switch {
+	case t.StartCommand != nil:
+		return t.StartCommand.Config.BindMounts, "the command config"
+	case t.StartContainer != nil:
+		return t.StartContainer.ExperimentConfig.BindMounts, "the experiment config"
+	case t.GCCheckpoints != nil:
+		return t.GCCheckpoints.ExperimentConfig.BindMounts, "the experiment config"
+	default:
+		return nil, ""
+	}
+}
+
+// Validate returns an error if the task container defaults of the task and its config bind mount
+// to the same container path.
+func (t TaskSpec) Validate() error {
+	defaultsSource := "the task container defaults of the master"
+	if t.ResourcePool != "" {
+		defaultsSource = fmt.Sprintf(
+			"the task container defaults of resource pool %s", t.ResourcePool)
+	}
+	defaults := make(map[string]model.BindMount)
+	for _, m := range taskContainerDefaults(t).BindMounts {
+		defaults[bindMountTarget(m)] = m
+	}
+
+	bindMounts, configSource := configBindMounts(t)
+	for _, m := range bindMounts {
+		if d, ok := defaults[bindMountTarget(m)]; ok {
+			return errors.Errorf(
+				"bind mounts of %s (host_path %s) and %s (host_path %s) both mount to %s",
+				defaultsSource, d.HostPath, configSource, m.HostPath, bindMountTarget(m))
+		}
+	}
+	return nil
+}
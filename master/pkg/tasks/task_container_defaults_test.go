@@ -0,0 +1,175 @@
+package tasks
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func trialTaskSpec(
+	defaults model.TaskContainerDefaultsConfig,
+	pool *model.TaskContainerOverrides,
+	config model.ExperimentConfig,
+) TaskSpec {
+	spec := TaskSpec{
+		TaskContainerDefaults: defaults,
+		StartContainer:        &StartContainer{ExperimentConfig: config},
+	}
+	if pool != nil {
+		spec.ResourcePool = "pool"
+		spec.PoolDefaults = pool
+	}
+	return spec
+}
+
+func TestTaskEnvVarsPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults []string
+		pool     *model.TaskContainerOverrides
+		config   []string
+		want     []string
+	}{
+		{name: "none", want: []string{}},
+		{name: "master", defaults: []string{"HTTP_PROXY=master"}, want: []string{"HTTP_PROXY=master"}},
+		{
+			name:     "pool after master",
+			defaults: []string{"HTTP_PROXY=master"},
+			pool: &model.TaskContainerOverrides{
+				EnvironmentVariables: model.RuntimeItems{CPU: []string{"HTTP_PROXY=pool"}},
+			},
+			want: []string{"HTTP_PROXY=master", "HTTP_PROXY=pool"},
+		},
+		{
+			name:     "config after pool",
+			defaults: []string{"HTTP_PROXY=master"},
+			pool: &model.TaskContainerOverrides{
+				EnvironmentVariables: model.RuntimeItems{CPU: []string{"HTTP_PROXY=pool"}},
+			},
+			config: []string{"HTTP_PROXY=config"},
+			want:   []string{"HTTP_PROXY=master", "HTTP_PROXY=pool", "HTTP_PROXY=config"},
+		},
+		{
+			name: "gpu variables of the pool are not set for cpu tasks",
+			pool: &model.TaskContainerOverrides{
+				EnvironmentVariables: model.RuntimeItems{GPU: []string{"NCCL_DEBUG=INFO"}},
+			},
+			config: []string{"HTTP_PROXY=config"},
+			want:   []string{"HTTP_PROXY=config"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			defaults := model.TaskContainerDefaultsConfig{
+				EnvironmentVariables: model.RuntimeItems{CPU: tc.defaults, GPU: tc.defaults},
+			}
+			var config model.ExperimentConfig
+			config.Environment.EnvironmentVariables = model.RuntimeItems{CPU: tc.config}
+			spec := trialTaskSpec(defaults, tc.pool, config)
+			assert.DeepEqual(t, TaskEnvVars(spec, config.Environment, device.CPU), tc.want)
+		})
+	}
+}
+
+func TestRegistryAuthPrecedence(t *testing.T) {
+	master := &model.RegistryAuth{Username: "master"}
+	pool := &model.RegistryAuth{Username: "pool"}
+	config := &model.RegistryAuth{Username: "config"}
+
+	tests := []struct {
+		name     string
+		defaults *model.RegistryAuth
+		pool     *model.RegistryAuth
+		config   *model.RegistryAuth
+		want     *model.RegistryAuth
+	}{
+		{name: "none"},
+		{name: "master", defaults: master, config: master, want: master},
+		{name: "pool", pool: pool, want: pool},
+		{name: "pool over master", defaults: master, pool: pool, config: master, want: pool},
+		{name: "config over master", defaults: master, config: config, want: config},
+		{name: "config over pool", defaults: master, pool: pool, config: config, want: config},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var overrides *model.TaskContainerOverrides
+			if tc.pool != nil {
+				overrides = &model.TaskContainerOverrides{RegistryAuth: tc.pool}
+			}
+			var config model.ExperimentConfig
+			config.Environment.RegistryAuth = tc.config
+			spec := trialTaskSpec(
+				model.TaskContainerDefaultsConfig{RegistryAuth: tc.defaults}, overrides, config)
+			assert.DeepEqual(t, registryAuth(spec, config.Environment), tc.want)
+		})
+	}
+}
+
+func TestTaskBindMountsPrecedence(t *testing.T) {
+	mount := func(host, container string) model.BindMount {
+		return model.BindMount{HostPath: host, ContainerPath: container}
+	}
+
+	tests := []struct {
+		name     string
+		defaults []model.BindMount
+		pool     *model.TaskContainerOverrides
+		config   []model.BindMount
+		want     []model.BindMount
+		wantErr  string
+	}{
+		{name: "none", want: []model.BindMount{}},
+		{
+			name:     "master and config",
+			defaults: []model.BindMount{mount("/mnt/certs", "/etc/ssl/certs")},
+			config:   []model.BindMount{mount("/home/user", "/home")},
+			want: []model.BindMount{
+				mount("/mnt/certs", "/etc/ssl/certs"), mount("/home/user", "/home"),
+			},
+		},
+		{
+			name:     "pool replaces master",
+			defaults: []model.BindMount{mount("/mnt/data", "/data"), mount("/mnt/certs", "/certs")},
+			pool: &model.TaskContainerOverrides{
+				BindMounts: []model.BindMount{mount("/mnt/pool-data", "/data")},
+			},
+			want: []model.BindMount{mount("/mnt/certs", "/certs"), mount("/mnt/pool-data", "/data")},
+		},
+		{
+			name:     "config conflicts with master",
+			defaults: []model.BindMount{mount("/mnt/data", "/data")},
+			config:   []model.BindMount{mount("/home/user/data", "/data/")},
+			wantErr: "bind mounts of the task container defaults of the master (host_path " +
+				"/mnt/data) and the experiment config (host_path /home/user/data) both mount to /data",
+		},
+		{
+			name: "config conflicts with pool",
+			pool: &model.TaskContainerOverrides{
+				BindMounts: []model.BindMount{mount("/mnt/data", "data")},
+			},
+			config: []model.BindMount{mount("/home/user/data", ContainerWorkDir+"/data")},
+			wantErr: "bind mounts of the task container defaults of resource pool pool (host_path " +
+				"/mnt/data) and the experiment config (host_path /home/user/data) both mount to " +
+				ContainerWorkDir + "/data",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := trialTaskSpec(
+				model.TaskContainerDefaultsConfig{BindMounts: tc.defaults},
+				tc.pool,
+				model.ExperimentConfig{BindMounts: tc.config},
+			)
+			err := spec.Validate()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, TaskBindMounts(spec, tc.config), tc.want)
+		})
+	}
+}
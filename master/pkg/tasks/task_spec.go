@@ -7,6 +7,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/workload"
 
 	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/model"
 )
@@ -19,8 +20,16 @@ type TaskSpec struct {
 
 	ClusterID             string
 	HarnessPath           string
+	HarnessManifest       container.HarnessManifest
 	TaskContainerDefaults model.TaskContainerDefaultsConfig
 	MasterCert            *tls.Certificate
+	// HarnessToken is the session token that agents fetch harness wheels from the master with.
+	HarnessToken string
+
+	// ResourcePool is the resource pool that the task is allocated in and PoolDefaults are the
+	// overrides of the task container defaults of the pool, if any.
+	ResourcePool string
+	PoolDefaults *model.TaskContainerOverrides
 
 	StartCommand   *StartCommand
 	StartContainer *StartContainer
@@ -66,4 +75,8 @@ type StartContainer struct {
 	IsMultiAgent bool
 
 	Rank int
+
+	// HeartbeatInterval is the number of seconds between the heartbeats that the trial container
+	// sends to the master, or zero if it sends none.
+	HeartbeatInterval int
 }
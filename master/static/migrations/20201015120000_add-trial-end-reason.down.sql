@@ -0,0 +1,2 @@
+ALTER TABLE public.trials
+    DROP COLUMN end_reason;
@@ -0,0 +1,3 @@
+ALTER TABLE public.trials
+    -- Why the trial ended, when that is not implied by its state (e.g., it was early stopped).
+    ADD COLUMN end_reason text NULL;
@@ -0,0 +1 @@
+DROP TABLE public.hparam_importance;
@@ -0,0 +1,10 @@
+CREATE TABLE public.hparam_importance (
+    experiment_id integer NOT NULL REFERENCES public.experiments(id) ON DELETE CASCADE,
+    metric text NOT NULL,
+    -- Identifies the set of trials the importance was computed from, so that it can be
+    -- recomputed when more trials complete.
+    trials_key text NOT NULL,
+    importance jsonb NOT NULL,
+    computed_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (experiment_id, metric)
+);
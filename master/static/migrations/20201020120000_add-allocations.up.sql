@@ -0,0 +1,11 @@
+CREATE TABLE public.allocations (
+    id serial PRIMARY KEY,
+    task_id text NOT NULL,
+    resource_pool text NOT NULL,
+    slots integer NOT NULL,
+    start_time timestamp with time zone NOT NULL,
+    -- NULL while the resources are still allocated.
+    end_time timestamp with time zone
+);
+CREATE INDEX ix_allocations_start_time ON public.allocations (start_time);
+CREATE INDEX ix_allocations_end_time ON public.allocations (end_time);
@@ -0,0 +1 @@
+DROP INDEX public.ix_model_versions_checkpoint_uuid;
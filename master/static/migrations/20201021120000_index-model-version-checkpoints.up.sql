@@ -0,0 +1,2 @@
+-- Checkpoint GC and experiment deletion look up whether checkpoints belong to model versions.
+CREATE INDEX ix_model_versions_checkpoint_uuid ON public.model_versions (checkpoint_uuid);
@@ -0,0 +1,6 @@
+ALTER TABLE public.model_versions DROP COLUMN labels;
+
+ALTER TABLE public.models
+    DROP COLUMN labels,
+    DROP COLUMN archived,
+    DROP COLUMN last_version;
@@ -0,0 +1,14 @@
+ALTER TABLE public.models
+    ADD COLUMN labels jsonb NOT NULL DEFAULT '[]',
+    ADD COLUMN archived boolean NOT NULL DEFAULT false,
+    -- The number of the latest version ever created, so that numbers of deleted versions are not
+    -- reused.
+    ADD COLUMN last_version integer NOT NULL DEFAULT 0;
+
+UPDATE public.models m
+SET last_version = (
+    SELECT COALESCE(max(version), 0) FROM public.model_versions WHERE model_name = m.name
+);
+
+ALTER TABLE public.model_versions
+    ADD COLUMN labels jsonb NOT NULL DEFAULT '[]';
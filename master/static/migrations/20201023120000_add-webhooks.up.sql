@@ -0,0 +1,21 @@
+CREATE TABLE public.webhooks (
+    id serial PRIMARY KEY,
+    url text NOT NULL,
+    secret text NOT NULL,
+    event_types jsonb NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE public.webhook_deliveries (
+    id serial PRIMARY KEY,
+    webhook_id integer NOT NULL REFERENCES public.webhooks (id) ON DELETE CASCADE,
+    event_id text NOT NULL,
+    event_type text NOT NULL,
+    attempt integer NOT NULL,
+    time timestamp with time zone NOT NULL,
+    -- NULL if the webhook could not be reached.
+    status_code integer,
+    -- NULL if the event was delivered.
+    error text
+);
+CREATE INDEX ix_webhook_deliveries_webhook_id_time ON public.webhook_deliveries (webhook_id, time);
@@ -0,0 +1,3 @@
+DROP INDEX public.ix_experiments_external_id;
+
+ALTER TABLE public.experiments DROP COLUMN external_id;
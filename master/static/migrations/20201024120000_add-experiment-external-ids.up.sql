@@ -0,0 +1,6 @@
+ALTER TABLE public.experiments ADD COLUMN external_id text NULL;
+
+-- External IDs are not unique in the database, since the master can be configured to allow
+-- several experiments with the same external ID.
+CREATE INDEX ix_experiments_external_id ON public.experiments USING btree (external_id)
+    WHERE external_id IS NOT NULL;
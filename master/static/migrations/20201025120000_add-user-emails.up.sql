@@ -0,0 +1 @@
+ALTER TABLE public.users ADD COLUMN email text NULL;
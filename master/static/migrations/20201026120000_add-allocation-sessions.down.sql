@@ -0,0 +1,10 @@
+ALTER TABLE public.allocation_sessions
+    DROP COLUMN user_id,
+    DROP COLUMN experiment_id,
+    DROP COLUMN command_id;
+
+ALTER INDEX public.ix_allocation_sessions_end_time RENAME TO ix_allocations_end_time;
+ALTER INDEX public.ix_allocation_sessions_start_time RENAME TO ix_allocations_start_time;
+ALTER INDEX public.allocation_sessions_pkey RENAME TO allocations_pkey;
+ALTER SEQUENCE public.allocation_sessions_id_seq RENAME TO allocations_id_seq;
+ALTER TABLE public.allocation_sessions RENAME TO allocations;
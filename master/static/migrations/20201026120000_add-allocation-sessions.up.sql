@@ -0,0 +1,12 @@
+ALTER TABLE public.allocations RENAME TO allocation_sessions;
+ALTER SEQUENCE public.allocations_id_seq RENAME TO allocation_sessions_id_seq;
+ALTER INDEX public.allocations_pkey RENAME TO allocation_sessions_pkey;
+ALTER INDEX public.ix_allocations_start_time RENAME TO ix_allocation_sessions_start_time;
+ALTER INDEX public.ix_allocations_end_time RENAME TO ix_allocation_sessions_end_time;
+
+-- Who and what each allocation is accounted to. These are not foreign keys, so that the usage of
+-- deleted experiments is still accounted for.
+ALTER TABLE public.allocation_sessions
+    ADD COLUMN user_id integer,
+    ADD COLUMN experiment_id integer,
+    ADD COLUMN command_id text;
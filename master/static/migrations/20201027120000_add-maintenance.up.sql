@@ -0,0 +1,7 @@
+-- The cluster is in maintenance mode while this table has a row; it never has more than one.
+CREATE TABLE public.maintenance (
+    id boolean PRIMARY KEY DEFAULT true CHECK (id),
+    message text NOT NULL,
+    start_time timestamp with time zone NOT NULL,
+    user_id integer NOT NULL REFERENCES public.users(id)
+);
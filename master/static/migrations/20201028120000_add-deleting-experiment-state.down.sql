@@ -0,0 +1,3 @@
+-- Postgres can't remove enum values, so 'DELETING' stays in the type; experiments that were being
+-- deleted go back to being errored so that they can be deleted again.
+UPDATE public.experiments SET state = 'ERROR' WHERE state = 'DELETING';
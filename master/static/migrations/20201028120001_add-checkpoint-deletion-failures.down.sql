@@ -0,0 +1 @@
+DROP TABLE public.checkpoint_deletion_failures;
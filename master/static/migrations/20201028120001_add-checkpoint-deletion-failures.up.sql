@@ -0,0 +1,8 @@
+-- The checkpoints of experiments being deleted that could not be deleted from storage.
+CREATE TABLE public.checkpoint_deletion_failures (
+    experiment_id integer NOT NULL REFERENCES public.experiments(id) ON DELETE CASCADE,
+    checkpoint_uuid uuid NOT NULL,
+    error text NOT NULL,
+    failed_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (experiment_id, checkpoint_uuid)
+);
@@ -0,0 +1,3 @@
+DROP TABLE public.trial_tasks;
+
+ALTER TABLE public.allocation_sessions DROP COLUMN request_time;
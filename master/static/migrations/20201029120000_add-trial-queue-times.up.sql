@@ -0,0 +1,10 @@
+-- When tasks asked for the slots that they were allocated; unknown for earlier allocations.
+ALTER TABLE public.allocation_sessions ADD COLUMN request_time timestamp with time zone;
+
+-- The tasks of the resource manager that ran each trial, one for each time the trial was scheduled.
+CREATE TABLE public.trial_tasks (
+    task_id text PRIMARY KEY,
+    trial_id integer NOT NULL REFERENCES public.trials(id) ON DELETE CASCADE
+);
+
+CREATE INDEX ix_trial_tasks_trial_id ON public.trial_tasks USING btree (trial_id);
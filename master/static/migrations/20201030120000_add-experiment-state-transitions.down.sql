@@ -0,0 +1 @@
+DROP TABLE public.experiment_state_transitions;
@@ -0,0 +1,19 @@
+-- The changes of the state of each experiment, so that how an experiment reached its state can be
+-- audited. from_state is NULL for the state that an experiment was created in.
+CREATE TABLE public.experiment_state_transitions (
+    id SERIAL PRIMARY KEY,
+    experiment_id integer NOT NULL REFERENCES public.experiments(id) ON DELETE CASCADE,
+    from_state public.experiment_state,
+    to_state public.experiment_state NOT NULL,
+    "timestamp" timestamp with time zone NOT NULL,
+    reason text NOT NULL
+);
+
+CREATE INDEX ix_experiment_state_transitions_experiment_id
+    ON public.experiment_state_transitions USING btree (experiment_id);
+
+-- Experiments from before states were recorded start their history in their current state.
+INSERT INTO public.experiment_state_transitions
+    (experiment_id, from_state, to_state, "timestamp", reason)
+SELECT id, NULL, state, coalesce(end_time, start_time), 'state before history was recorded'
+FROM public.experiments;
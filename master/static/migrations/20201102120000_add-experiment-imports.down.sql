@@ -0,0 +1,3 @@
+ALTER TABLE public.experiments
+    DROP COLUMN imported_from_cluster_id,
+    DROP COLUMN imported_from_experiment_id;
@@ -0,0 +1,5 @@
+-- Where experiments that were imported from another cluster came from; NULL for experiments that
+-- were created on this cluster. Imported experiments are read-only.
+ALTER TABLE public.experiments
+    ADD COLUMN imported_from_cluster_id text NULL,
+    ADD COLUMN imported_from_experiment_id integer NULL;
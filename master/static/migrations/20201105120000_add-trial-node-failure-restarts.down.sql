@@ -0,0 +1,2 @@
+ALTER TABLE public.trials
+    DROP COLUMN restarts_due_to_node_failure;
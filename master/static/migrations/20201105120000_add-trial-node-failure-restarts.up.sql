@@ -0,0 +1,4 @@
+ALTER TABLE public.trials
+    -- How many times the trial was requeued because its containers or agents stopped sending
+    -- heartbeats. Unlike other failures, these do not count towards max_restarts.
+    ADD COLUMN restarts_due_to_node_failure integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE public.experiments
+    DROP COLUMN auto_archived;
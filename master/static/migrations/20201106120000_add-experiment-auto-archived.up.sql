@@ -0,0 +1,4 @@
+ALTER TABLE public.experiments
+    -- Whether the experiment was archived automatically once, so that it is not archived again
+    -- after a user unarchives it.
+    ADD COLUMN auto_archived boolean NOT NULL DEFAULT false;
@@ -0,0 +1,11 @@
+-- The classified failures of the runs of each trial.
+CREATE TABLE public.trial_failures (
+    id SERIAL PRIMARY KEY,
+    trial_id integer NOT NULL REFERENCES public.trials(id) ON DELETE CASCADE,
+    category text NOT NULL,
+    exit_code integer,
+    message text NOT NULL,
+    time timestamp with time zone NOT NULL
+);
+
+CREATE INDEX ix_trial_failures_trial_id ON public.trial_failures USING btree (trial_id);
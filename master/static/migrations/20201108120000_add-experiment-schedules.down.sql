@@ -0,0 +1,3 @@
+ALTER TABLE public.experiments DROP COLUMN schedule_id;
+
+DROP TABLE public.experiment_schedules;
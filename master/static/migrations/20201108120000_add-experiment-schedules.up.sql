@@ -0,0 +1,28 @@
+-- Experiment submissions that the master creates experiments from at the scheduled times.
+CREATE TABLE public.experiment_schedules (
+    id serial PRIMARY KEY,
+    owner_id integer NOT NULL REFERENCES public.users(id),
+    config jsonb NOT NULL,
+    model_definition bytea NOT NULL,
+    git_remote text,
+    git_commit text,
+    git_committer text,
+    git_commit_date timestamp without time zone,
+    -- Exactly one of start_at and cron is set.
+    start_at timestamp with time zone,
+    cron text,
+    max_runs integer,
+    enabled boolean NOT NULL DEFAULT true,
+    runs integer NOT NULL DEFAULT 0,
+    last_run_time timestamp with time zone,
+    -- NULL once the schedule will not fire again.
+    next_run_time timestamp with time zone,
+    created_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX ix_experiment_schedules_next_run_time ON public.experiment_schedules (next_run_time)
+    WHERE enabled;
+
+-- The schedule that created each experiment, if any.
+ALTER TABLE public.experiments ADD COLUMN schedule_id integer
+    REFERENCES public.experiment_schedules(id) ON DELETE SET NULL;
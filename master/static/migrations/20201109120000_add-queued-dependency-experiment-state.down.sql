@@ -0,0 +1,4 @@
+-- Postgres can't remove enum values, so 'QUEUED_DEPENDENCY' stays in the type; experiments that
+-- were waiting on their dependencies are canceled, since nothing would start them.
+UPDATE public.experiments SET state = 'CANCELED', end_time = now() AT TIME ZONE 'UTC'
+WHERE state = 'QUEUED_DEPENDENCY';
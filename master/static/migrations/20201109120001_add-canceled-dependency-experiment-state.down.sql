@@ -0,0 +1,3 @@
+-- Postgres can't remove enum values, so 'CANCELED_DEPENDENCY' stays in the type; experiments that
+-- were canceled because of a dependency are plainly canceled.
+UPDATE public.experiments SET state = 'CANCELED' WHERE state = 'CANCELED_DEPENDENCY';
@@ -0,0 +1,2 @@
+-- Adding an enum value can't be done in a transaction, so this must be the only statement here.
+ALTER TYPE public.experiment_state ADD VALUE 'CANCELED_DEPENDENCY';
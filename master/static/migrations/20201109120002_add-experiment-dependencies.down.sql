@@ -0,0 +1 @@
+DROP TABLE public.experiment_dependencies;
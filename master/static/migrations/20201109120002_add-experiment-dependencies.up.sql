@@ -0,0 +1,11 @@
+-- The experiments that each experiment in the QUEUED_DEPENDENCY state waits for. Dependencies are
+-- not foreign keys, so that a deleted dependency is noticed rather than silently dropped.
+CREATE TABLE public.experiment_dependencies (
+    experiment_id integer PRIMARY KEY REFERENCES public.experiments(id) ON DELETE CASCADE,
+    depends_on integer[] NOT NULL,
+    condition jsonb NOT NULL,
+    warm_start boolean NOT NULL DEFAULT false
+);
+
+CREATE INDEX ix_experiment_dependencies_depends_on ON public.experiment_dependencies
+    USING gin (depends_on);
@@ -0,0 +1 @@
+DROP TABLE public.scheduler_pause;
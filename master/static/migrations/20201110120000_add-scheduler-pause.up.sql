@@ -0,0 +1,6 @@
+-- The scheduler is paused while this table has a row; it never has more than one.
+CREATE TABLE public.scheduler_pause (
+    id boolean PRIMARY KEY DEFAULT true CHECK (id),
+    start_time timestamp with time zone NOT NULL,
+    user_id integer NOT NULL REFERENCES public.users(id)
+);
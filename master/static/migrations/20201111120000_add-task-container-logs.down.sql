@@ -0,0 +1,5 @@
+ALTER TABLE public.allocation_sessions
+    DROP COLUMN failure_type,
+    DROP COLUMN failure_message;
+
+DROP TABLE public.task_container_logs;
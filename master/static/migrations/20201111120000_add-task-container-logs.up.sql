@@ -0,0 +1,18 @@
+-- The logs of the lifecycle of the containers of each task, e.g., image pulls and Docker errors,
+-- which explain failures that happen before the containers run.
+CREATE TABLE public.task_container_logs (
+    id SERIAL PRIMARY KEY,
+    task_id text NOT NULL,
+    container_id text NOT NULL,
+    timestamp timestamp with time zone NOT NULL,
+    source text NOT NULL,
+    level text NOT NULL,
+    message text NOT NULL
+);
+
+CREATE INDEX ix_task_container_logs_task_id ON public.task_container_logs USING btree (task_id);
+
+-- Why each allocation failed, if a container of it did.
+ALTER TABLE public.allocation_sessions
+    ADD COLUMN failure_type text,
+    ADD COLUMN failure_message text;
@@ -0,0 +1,5 @@
+ALTER TABLE public.experiments DROP COLUMN team_id;
+
+DROP TABLE public.team_memberships;
+
+DROP TABLE public.teams;
@@ -0,0 +1,17 @@
+CREATE TABLE public.teams (
+    id serial PRIMARY KEY,
+    name text NOT NULL UNIQUE
+);
+
+CREATE TABLE public.team_memberships (
+    team_id integer NOT NULL REFERENCES public.teams(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX ix_team_memberships_user_id ON public.team_memberships (user_id);
+
+-- Experiments of a team are only visible to its members, their owners and admins; experiments
+-- without a team are visible to everyone.
+ALTER TABLE public.experiments
+    ADD COLUMN team_id integer REFERENCES public.teams(id) ON DELETE SET NULL;
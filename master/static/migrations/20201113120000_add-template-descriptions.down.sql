@@ -0,0 +1 @@
+ALTER TABLE public.templates DROP COLUMN description;
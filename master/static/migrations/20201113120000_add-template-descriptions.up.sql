@@ -0,0 +1,2 @@
+ALTER TABLE public.templates
+    ADD COLUMN description text NOT NULL DEFAULT '';
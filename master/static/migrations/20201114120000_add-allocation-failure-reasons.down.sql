@@ -0,0 +1,2 @@
+ALTER TABLE public.allocation_sessions
+    DROP COLUMN failure_reason;
@@ -0,0 +1,3 @@
+-- Why each allocation failed or was cut short, from the taxonomy of model.FailureReason.
+ALTER TABLE public.allocation_sessions
+    ADD COLUMN failure_reason text;
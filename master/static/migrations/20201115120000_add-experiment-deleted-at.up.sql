@@ -0,0 +1,3 @@
+-- When the experiment was soft-deleted; it is deleted for good once the grace period passes.
+ALTER TABLE public.experiments
+    ADD COLUMN deleted_at timestamptz;
@@ -0,0 +1,20 @@
+UPDATE public.experiments e
+    SET model_definition = d.content
+    FROM public.model_definitions d
+    WHERE e.model_definition IS NULL AND e.model_definition_hash = d.hash;
+
+-- Model definitions in filesystem or S3 storage can't be read from here, so migrating down must
+-- stop rather than lose them; the whole migration is rolled back.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM public.experiments WHERE model_definition IS NULL) THEN
+        RAISE EXCEPTION 'the model definitions of some experiments are not kept in Postgres; '
+            'copy them into the model_definitions table before migrating down';
+    END IF;
+END $$;
+
+ALTER TABLE public.experiments
+    ALTER COLUMN model_definition SET NOT NULL,
+    DROP COLUMN model_definition_hash;
+
+DROP TABLE public.model_definitions;
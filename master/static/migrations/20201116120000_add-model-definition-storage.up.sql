@@ -0,0 +1,15 @@
+-- Model definitions kept in Postgres, keyed by the SHA-256 hashes of their content so that
+-- experiments with identical model definitions share one copy.
+CREATE TABLE public.model_definitions (
+    hash text PRIMARY KEY,
+    content bytea NOT NULL
+);
+
+-- Experiments either keep their model definition inline, as they did before, or refer to it by
+-- hash in the configured model definition storage.
+ALTER TABLE public.experiments
+    ADD COLUMN model_definition_hash text,
+    ALTER COLUMN model_definition DROP NOT NULL;
+
+-- Model definitions are deleted along with the last experiment that refers to them.
+CREATE INDEX ix_experiments_model_definition_hash ON public.experiments (model_definition_hash);
@@ -0,0 +1,6 @@
+-- Whether the external ID of an experiment must be unique, because it was submitted while the
+-- master required unique external IDs. Experiments submitted otherwise may share external IDs.
+ALTER TABLE public.experiments ADD COLUMN external_id_unique boolean NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX ix_experiments_unique_external_id ON public.experiments
+    USING btree (external_id) WHERE external_id_unique;
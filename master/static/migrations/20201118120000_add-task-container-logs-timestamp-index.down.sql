@@ -0,0 +1 @@
+DROP INDEX public.ix_task_container_logs_timestamp;
@@ -0,0 +1,3 @@
+-- Container logs are deleted by age once their retention has passed.
+CREATE INDEX ix_task_container_logs_timestamp ON public.task_container_logs
+    USING btree (timestamp);
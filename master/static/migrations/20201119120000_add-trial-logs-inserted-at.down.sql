@@ -0,0 +1,3 @@
+DROP INDEX public.ix_trial_logs_trial_id_inserted_at_id;
+ALTER TABLE public.trial_logs
+    DROP COLUMN inserted_at;
@@ -0,0 +1,6 @@
+-- Trial logs are paged through by the time that they were inserted, which, unlike their IDs,
+-- bounds how late they can commit.
+ALTER TABLE public.trial_logs
+    ADD COLUMN inserted_at timestamp with time zone NOT NULL DEFAULT now();
+CREATE INDEX ix_trial_logs_trial_id_inserted_at_id ON public.trial_logs
+    USING btree (trial_id, inserted_at, id);
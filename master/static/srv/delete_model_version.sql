@@ -0,0 +1,3 @@
+DELETE FROM model_versions
+WHERE model_name = $1 AND version = $2
+RETURNING version, metadata, labels, creation_time, last_updated_time
@@ -8,8 +8,11 @@ SELECT
     start_time,
     end_time
 FROM
-    experiments
+    experiments e
 where
     state in (SELECT unnest(string_to_array($1, ','))::experiment_state)
+    AND e.deleted_at IS NULL
+    AND ($2::integer IS NULL OR e.team_id IS NULL OR e.owner_id = $2
+        OR e.team_id IN (SELECT team_id FROM team_memberships WHERE user_id = $2))
 ORDER BY
     id DESC
\ No newline at end of file
@@ -12,3 +12,6 @@ SELECT
 FROM
     experiments e
 JOIN users u ON e.owner_id = u.id
+WHERE e.deleted_at IS NULL
+    AND ($1::integer IS NULL OR e.team_id IS NULL OR e.owner_id = $1
+        OR e.team_id IN (SELECT team_id FROM team_memberships WHERE user_id = $1))
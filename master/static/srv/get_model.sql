@@ -1 +1,2 @@
-SELECT name, description, metadata, creation_time, last_updated_time FROM models WHERE name = $1;
+SELECT name, description, metadata, labels, archived, creation_time, last_updated_time
+FROM models WHERE name = $1;
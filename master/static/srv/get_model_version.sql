@@ -1,10 +1,11 @@
 WITH mv AS (
-  SELECT version, checkpoint_uuid
+  SELECT version, checkpoint_uuid, metadata, labels, creation_time, last_updated_time
     FROM model_versions
     WHERE model_name = $1 AND version = $2
 ),
 m AS (
-  Select * FROM models WHERE name = $1
+  SELECT name, description, metadata, labels, archived, creation_time, last_updated_time
+    FROM models WHERE name = $1
 ),
 c AS (
   SELECT
@@ -35,5 +36,8 @@ SELECT
     to_json(c) AS checkpoint,
     to_json(m) AS model,
     version AS version,
-    creation_time
+    mv.metadata,
+    mv.labels,
+    mv.creation_time,
+    mv.last_updated_time
     FROM c, m, mv
@@ -1,2 +1,2 @@
-SELECT name, description, metadata, creation_time, last_updated_time FROM models;
-
+SELECT name, description, metadata, labels, archived, creation_time, last_updated_time
+FROM models;
@@ -1 +1 @@
-SELECT name, config FROM templates WHERE name = $1;
+SELECT name, description, config FROM templates WHERE name = $1;
@@ -1 +1 @@
-SELECT name, config FROM templates
+SELECT name, description, config FROM templates
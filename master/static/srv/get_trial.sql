@@ -5,10 +5,18 @@ FROM
           t.state,
           t.start_time,
           t.end_time,
+          t.end_reason,
           t.hparams,
           t.seed,
           t.warm_start_checkpoint_id,
 
+     (SELECT a.failure_reason
+      FROM allocation_sessions a
+      JOIN trial_tasks tt ON a.task_id = tt.task_id
+      WHERE tt.trial_id = t.id
+      ORDER BY a.id DESC
+      LIMIT 1) AS failure_reason,
+
      (SELECT COALESCE(jsonb_agg(r2
                                 ORDER BY r2.id ASC), '[]'::JSONB)
       FROM
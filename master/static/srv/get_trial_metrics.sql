@@ -5,6 +5,7 @@ FROM
           t.state,
           t.start_time,
           t.end_time,
+          t.end_reason,
           t.hparams,
           t.seed,
           t.warm_start_checkpoint_id,
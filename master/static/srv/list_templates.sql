@@ -1 +1 @@
-SELECT name, config::TEXT FROM templates;
+SELECT name, description, config::TEXT FROM templates;
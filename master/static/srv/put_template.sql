@@ -1,4 +1,4 @@
-INSERT INTO templates (name, config)
-VALUES ($1, $2)
-ON CONFLICT (name) DO UPDATE SET config=$2
-RETURNING name, config
+INSERT INTO templates (name, description, config)
+VALUES ($1, COALESCE($2, ''), $3)
+ON CONFLICT (name) DO UPDATE SET description=COALESCE($2, templates.description), config=$3
+RETURNING name, description, config
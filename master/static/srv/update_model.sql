@@ -1,3 +1,3 @@
-UPDATE models SET description = $2, metadata = $3, last_updated_time = $4
+UPDATE models SET description = $2, metadata = $3, labels = $4, last_updated_time = $5
 WHERE name = $1
-RETURNING name, description, metadata, creation_time, last_updated_time
+RETURNING name, description, metadata, labels, archived, creation_time, last_updated_time
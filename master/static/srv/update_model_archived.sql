@@ -0,0 +1,3 @@
+UPDATE models SET archived = $2, last_updated_time = $3
+WHERE name = $1
+RETURNING name, description, metadata, labels, archived, creation_time, last_updated_time
@@ -0,0 +1,3 @@
+UPDATE model_versions SET metadata = $3, labels = $4, last_updated_time = $5
+WHERE model_name = $1 AND version = $2
+RETURNING version, metadata, labels, creation_time, last_updated_time
@@ -1,8 +1,103 @@
 package version
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 // Unset denotes that the version has not been set by the build system.
 const Unset = "unknown"
 
 // Version stores the current Determined version number when available, and unknown if otherwise not
 // found. This value is set via a linker flag at build time.
 var Version = Unset
+
+// GitCommit and BuildDate describe the build of the master. They are set via linker flags at build
+// time.
+var (
+	GitCommit = Unset
+	BuildDate = Unset
+)
+
+// APIVersion is the version of the APIs that the master serves.
+const APIVersion = "v1"
+
+// MinCLIVersion is the oldest version of the CLI that works with this master. Raise it when the
+// master stops serving APIs that older CLIs rely on.
+const MinCLIVersion = "0.13.0"
+
+// MinAgentVersion is the oldest version of agents that works with this master. Raise it when the
+// master stops understanding messages that older agents send.
+const MinAgentVersion = "0.13.0"
+
+// Compare compares two versions by their numeric release components, e.g., "0.13.8" in
+// "0.13.8.dev0" or "0.13.8rc1". It returns -1, 0 or 1 if a is older than, the same release as, or
+// newer than b.
+func Compare(a, b string) (int, error) {
+	aParts, err := releaseParts(a)
+	if err != nil {
+		return 0, err
+	}
+	bParts, err := releaseParts(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart int
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+		switch {
+		case aPart < bPart:
+			return -1, nil
+		case aPart > bPart:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// MajorMinor returns the major and minor release components of a version, e.g., "0.13" for
+// "0.13.8.dev0". Versions that differ only after them normalize to the same string.
+func MajorMinor(v string) (string, error) {
+	parts, err := releaseParts(v)
+	if err != nil {
+		return "", err
+	}
+	if len(parts) == 1 {
+		parts = append(parts, 0)
+	}
+	return fmt.Sprintf("%d.%d", parts[0], parts[1]), nil
+}
+
+// releaseParts returns the leading numeric components of a version.
+func releaseParts(v string) ([]int, error) {
+	var parts []int
+	for _, component := range strings.Split(strings.TrimPrefix(v, "v"), ".") {
+		digits := strings.IndexFunc(component, func(r rune) bool { return r < '0' || r > '9' })
+		if digits == -1 {
+			digits = len(component)
+		}
+		if digits == 0 {
+			break
+		}
+		part, err := strconv.Atoi(component[:digits])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version %q", v)
+		}
+		parts = append(parts, part)
+		if digits < len(component) {
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return nil, errors.Errorf("invalid version %q", v)
+	}
+	return parts, nil
+}
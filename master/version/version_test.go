@@ -9,3 +9,41 @@ import (
 func TestVersion(t *testing.T) {
 	assert.Assert(t, Version == "unknown")
 }
+
+func TestCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"0.13.8", "0.13.8", 0},
+		{"0.13.8.dev0", "0.13.8", 0},
+		{"0.13.8rc1", "0.13.8", 0},
+		{"0.13", "0.13.0", 0},
+		{"0.12.13", "0.13.0", -1},
+		{"0.13.10", "0.13.9", 1},
+		{"v1.0.0", "0.13.0", 1},
+	} {
+		actual, err := Compare(tc.a, tc.b)
+		assert.NilError(t, err, "%s %s", tc.a, tc.b)
+		assert.Equal(t, actual, tc.expected, "%s %s", tc.a, tc.b)
+	}
+
+	_, err := Compare("latest", "0.13.0")
+	assert.ErrorContains(t, err, `invalid version "latest"`)
+}
+
+func TestMajorMinor(t *testing.T) {
+	for v, expected := range map[string]string{
+		"0.13.8":       "0.13",
+		"0.12.13.dev0": "0.12",
+		"v1":           "1.0",
+		"0.13rc1":      "0.13",
+	} {
+		actual, err := MajorMinor(v)
+		assert.NilError(t, err, v)
+		assert.Equal(t, actual, expected, v)
+	}
+
+	_, err := MajorMinor("latest")
+	assert.ErrorContains(t, err, `invalid version "latest"`)
+}
@@ -295,6 +295,9 @@ const experimentStateMap = {
   [Sdk.Determinedexperimentv1State.COMPLETED]: types.RunState.Completed,
   [Sdk.Determinedexperimentv1State.ERROR]: types.RunState.Errored,
   [Sdk.Determinedexperimentv1State.DELETED]: types.RunState.Deleted,
+  [Sdk.Determinedexperimentv1State.DELETING]: types.RunState.Deleting,
+  [Sdk.Determinedexperimentv1State.QUEUEDDEPENDENCY]: types.RunState.QueuedDependency,
+  [Sdk.Determinedexperimentv1State.CANCELEDDEPENDENCY]: types.RunState.CanceledDependency,
 };
 
 export const decodeExperimentState = (data: Sdk.Determinedexperimentv1State): types.RunState => {
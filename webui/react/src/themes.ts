@@ -203,10 +203,13 @@ export const lightTheme: Theme = {
 const stateColorMapping = {
   [RunState.Active]: 'active',
   [RunState.Canceled]: 'inactive',
+  [RunState.CanceledDependency]: 'inactive',
   [RunState.Completed]: 'success',
   [RunState.Deleted]: 'failed',
+  [RunState.Deleting]: 'inactive',
   [RunState.Errored]: 'failed',
   [RunState.Paused]: 'suspended',
+  [RunState.QueuedDependency]: 'suspended',
   [RunState.StoppingCanceled]: 'inactive',
   [RunState.StoppingCompleted]: 'success',
   [RunState.StoppingError]: 'failed',
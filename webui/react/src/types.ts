@@ -210,6 +210,9 @@ export enum RunState {
   StoppingError = 'STOPPING_ERROR',
   Errored = 'ERROR',
   Deleted = 'DELETED',
+  Deleting = 'DELETING',
+  QueuedDependency = 'QUEUED_DEPENDENCY',
+  CanceledDependency = 'CANCELED_DEPENDENCY',
   Unspecified = 'UNSPECIFIED',
 }
 
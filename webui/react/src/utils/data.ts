@@ -59,6 +59,7 @@ export const categorize = <T>(array: T[], keyFn: ((arg0: T) => string)): Record<
 const runStateSortValues: Record<RunState, number> = {
   [RunState.Active]: 0,
   [RunState.Paused]: 1,
+  [RunState.QueuedDependency]: 1,
   [RunState.StoppingError]: 2,
   [RunState.Errored]: 3,
   [RunState.StoppingCompleted]: 4,
@@ -66,6 +67,8 @@ const runStateSortValues: Record<RunState, number> = {
   [RunState.StoppingCanceled]: 6,
   [RunState.Canceled]: 7,
   [RunState.Deleted]: 7,
+  [RunState.Deleting]: 7,
+  [RunState.CanceledDependency]: 7,
   [RunState.Unspecified]: 8,
 };
 
@@ -0,0 +1,28 @@
+import { MetricType } from 'types';
+
+import { metricNameFromValue, metricNameToValue, valueToMetricName } from './trial';
+
+describe('valueToMetricName', () => {
+  const names = [
+    'loss',
+    'val/accuracy@top5',
+    'loss.total',
+    'a|b|c',
+    'ünïcödé 损失 📉',
+    '\'; DROP TABLE steps; --',
+  ];
+
+  it('should round trip metric names verbatim', () => {
+    for (const name of names) {
+      for (const type of [ MetricType.Training, MetricType.Validation ]) {
+        const value = metricNameToValue({ name, type });
+        expect(valueToMetricName(value)).toEqual({ name, type });
+        expect(metricNameFromValue(value)).toEqual({ name, type });
+      }
+    }
+  });
+
+  it('should reject values without a metric type', () => {
+    expect(valueToMetricName('loss')).toBeUndefined();
+  });
+});
@@ -84,7 +84,8 @@ export const metricNameFromValue = (metricValue: string): MetricName | undefined
 };
 
 export const valueToMetricName = (value: string): MetricName | undefined => {
-  const parts = value.split('|');
-  if (parts.length === 2) return { name: parts[1], type: parts[0] as MetricType };
-  return undefined;
+  // Metric names are arbitrary strings and may contain the separator themselves.
+  const separator = value.indexOf('|');
+  if (separator === -1) return undefined;
+  return { name: value.slice(separator + 1), type: value.slice(0, separator) as MetricType };
 };
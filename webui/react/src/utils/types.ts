@@ -82,15 +82,19 @@ export const terminalRunStates: Set<RunState> = new Set([
   RunState.Completed,
   RunState.Errored,
   RunState.Deleted,
+  RunState.CanceledDependency,
 ]);
 
 export const runStateToLabel: {[key in RunState]: string} = {
   [RunState.Active]: 'Active',
   [RunState.Canceled]: 'Canceled',
+  [RunState.CanceledDependency]: 'Canceled (Dependency)',
   [RunState.Completed]: 'Completed',
   [RunState.Deleted]: 'Deleted',
+  [RunState.Deleting]: 'Deleting',
   [RunState.Errored]: 'Errored',
   [RunState.Paused]: 'Paused',
+  [RunState.QueuedDependency]: 'Queued',
   [RunState.StoppingCanceled]: 'Canceling',
   [RunState.StoppingCompleted]: 'Completing',
   [RunState.StoppingError]: 'Erroring',